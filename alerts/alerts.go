@@ -0,0 +1,374 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package alerts evaluates threshold rules against recent container stats and
+// notifies webhooks, Slack and PagerDuty of the alerts they fire.
+package alerts
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/logging"
+	"github.com/google/cadvisor/manager"
+)
+
+var argRulesFile = flag.String("alert_rules_file", "", "JSON file of alerting rules and the receivers they notify. Empty disables alerting")
+var argInterval = flag.Duration("alert_interval", 10*time.Second, "interval between evaluations of the alerting rules")
+
+// Metrics rules can apply to.
+const (
+	MetricCpuUsageCores        = "cpu_usage_cores"
+	MetricMemoryUsageBytes     = "memory_usage_bytes"
+	MetricMemoryWorkingSet     = "memory_working_set_bytes"
+	MetricMemoryLimitRatio     = "memory_limit_ratio"
+	MetricFilesystemUsageBytes = "filesystem_usage_bytes"
+	MetricNetworkRxRate        = "network_rx_bytes_per_second"
+	MetricNetworkTxRate        = "network_tx_bytes_per_second"
+)
+
+// States of an alert.
+const (
+	StateFiring   = "firing"
+	StateResolved = "resolved"
+)
+
+// Config is the content of the rules file.
+type Config struct {
+	Rules     []RuleConfig     `json:"rules"`
+	Receivers []ReceiverConfig `json:"receivers"`
+}
+
+type RuleConfig struct {
+	Name string `json:"name"`
+	// Regular expression matching the absolute names of the containers the
+	// rule applies to. Empty matches all containers.
+	Container string  `json:"container,omitempty"`
+	Metric    string  `json:"metric"`
+	Threshold float64 `json:"threshold"`
+	// Whether the rule fires below the threshold instead of above it.
+	Below bool `json:"below,omitempty"`
+	// How long the threshold must be crossed before the rule fires, e.g. "5m".
+	For string `json:"for,omitempty"`
+	// Names of the receivers to notify. Empty notifies all receivers.
+	Receivers []string `json:"receivers,omitempty"`
+}
+
+// Alert is sent to the receivers when a rule fires and when it resolves.
+type Alert struct {
+	Rule      string  `json:"rule"`
+	Hostname  string  `json:"hostname"`
+	Container string  `json:"container"`
+	Metric    string  `json:"metric"`
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+	State     string  `json:"state"`
+	// Since when the threshold is crossed.
+	Since     time.Time `json:"since"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func (self *Alert) String() string {
+	if self.State == StateResolved {
+		return fmt.Sprintf("[%s] %s resolved on %s: %s is %g", self.Hostname, self.Rule, self.Container, self.Metric, self.Value)
+	}
+	return fmt.Sprintf("[%s] %s firing on %s: %s is %g, past the threshold of %g since %s", self.Hostname, self.Rule, self.Container, self.Metric, self.Value, self.Threshold, self.Since.Format(time.RFC3339))
+}
+
+type rule struct {
+	RuleConfig
+	container *regexp.Regexp
+	duration  time.Duration
+	notifiers []Notifier
+}
+
+// Returns whether the value crosses the threshold of the rule.
+func (self *rule) crossed(value float64) bool {
+	if self.Below {
+		return value < self.Threshold
+	}
+	return value > self.Threshold
+}
+
+// State of a rule for a container.
+type alertState struct {
+	since  time.Time
+	firing bool
+	// Last value of the metric, sent when the alert resolves.
+	value float64
+	// Whether the container was seen in the last evaluation.
+	seen bool
+}
+
+type evaluator struct {
+	hostname string
+	rules    []*rule
+	// States keyed by rule index and container name.
+	states map[int]map[string]*alertState
+}
+
+// Start evaluates the rules of the file given on the command line every
+// --alert_interval. It does nothing if no file was given.
+func Start(m manager.Manager) error {
+	if *argRulesFile == "" {
+		return nil
+	}
+	if *argInterval <= 0 {
+		return fmt.Errorf("invalid --alert_interval %v, expected a positive duration", *argInterval)
+	}
+	data, err := ioutil.ReadFile(*argRulesFile)
+	if err != nil {
+		return fmt.Errorf("failed to read alerting rules: %v", err)
+	}
+	config := &Config{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return fmt.Errorf("failed to parse alerting rules: %v", err)
+	}
+	e, err := newEvaluator(config)
+	if err != nil {
+		return err
+	}
+	logging.Infof("Evaluating %d alerting rules every %v", len(e.rules), *argInterval)
+	go func() {
+		for {
+			time.Sleep(*argInterval)
+			infos, err := m.GetContainerInfoV2("/", v2.RequestOptions{
+				IdType:    v2.TypeName,
+				Count:     2,
+				Recursive: true,
+			})
+			if err != nil {
+				logging.Warningf("Failed to get container stats for alerting: %v", err)
+				// Evaluate the containers that were returned.
+			}
+			e.evaluate(infos, time.Now())
+		}
+	}()
+	return nil
+}
+
+func newEvaluator(config *Config) (*evaluator, error) {
+	notifiers := make(map[string]Notifier, len(config.Receivers))
+	var all []Notifier
+	for _, receiver := range config.Receivers {
+		if _, ok := notifiers[receiver.Name]; ok {
+			return nil, fmt.Errorf("duplicate receiver %q", receiver.Name)
+		}
+		n, err := newNotifier(&receiver)
+		if err != nil {
+			return nil, err
+		}
+		notifiers[receiver.Name] = n
+		all = append(all, n)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	self := &evaluator{
+		hostname: hostname,
+		states:   make(map[int]map[string]*alertState),
+	}
+	for i, config := range config.Rules {
+		r := &rule{RuleConfig: config}
+		if r.Name == "" {
+			return nil, fmt.Errorf("alerting rule %d has no name", i)
+		}
+		if !validMetric(r.Metric) {
+			return nil, fmt.Errorf("unknown metric %q in alerting rule %q", r.Metric, r.Name)
+		}
+		if r.container, err = regexp.Compile(r.Container); err != nil {
+			return nil, fmt.Errorf("invalid container regexp in alerting rule %q: %v", r.Name, err)
+		}
+		if r.For != "" {
+			if r.duration, err = time.ParseDuration(r.For); err != nil {
+				return nil, fmt.Errorf("invalid duration in alerting rule %q: %v", r.Name, err)
+			}
+		}
+		if len(r.Receivers) == 0 {
+			r.notifiers = all
+		}
+		for _, name := range r.Receivers {
+			n, ok := notifiers[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown receiver %q in alerting rule %q", name, r.Name)
+			}
+			r.notifiers = append(r.notifiers, n)
+		}
+		self.rules = append(self.rules, r)
+		self.states[i] = make(map[string]*alertState)
+	}
+	return self, nil
+}
+
+func validMetric(metric string) bool {
+	switch metric {
+	case MetricCpuUsageCores, MetricMemoryUsageBytes, MetricMemoryWorkingSet, MetricMemoryLimitRatio,
+		MetricFilesystemUsageBytes, MetricNetworkRxRate, MetricNetworkTxRate:
+		return true
+	}
+	return false
+}
+
+// Evaluates the rules against the latest stats of the containers and notifies
+// the alerts that fire or resolve.
+func (self *evaluator) evaluate(infos map[string]v2.ContainerInfo, now time.Time) {
+	for i, r := range self.rules {
+		states := self.states[i]
+		for _, state := range states {
+			state.seen = false
+		}
+		for name, cont := range infos {
+			if !r.container.MatchString(name) {
+				continue
+			}
+			value, ok := metricValue(r.Metric, &cont)
+			if !ok {
+				// Keep the state across a missing sample.
+				if state, ok := states[name]; ok {
+					state.seen = true
+				}
+				continue
+			}
+			state, ok := states[name]
+			if !r.crossed(value) {
+				if ok && state.firing {
+					self.notify(r, name, value, StateResolved, state.since, now)
+				}
+				delete(states, name)
+				continue
+			}
+			if !ok {
+				state = &alertState{since: now}
+				states[name] = state
+			}
+			state.seen = true
+			state.value = value
+			if !state.firing && now.Sub(state.since) >= r.duration {
+				state.firing = true
+				self.notify(r, name, value, StateFiring, state.since, now)
+			}
+		}
+		// Forget containers that went away, resolving their alerts.
+		for name, state := range states {
+			if !state.seen {
+				if state.firing {
+					self.notify(r, name, state.value, StateResolved, state.since, now)
+				}
+				delete(states, name)
+			}
+		}
+	}
+}
+
+func (self *evaluator) notify(r *rule, container string, value float64, state string, since, now time.Time) {
+	alert := &Alert{
+		Rule:      r.Name,
+		Hostname:  self.hostname,
+		Container: container,
+		Metric:    r.Metric,
+		Value:     value,
+		Threshold: r.Threshold,
+		State:     state,
+		Since:     since,
+		Timestamp: now,
+	}
+	logging.Infof("Alert: %v", alert)
+	for _, n := range r.notifiers {
+		go func(n Notifier) {
+			if err := n.Notify(alert); err != nil {
+				logging.Warningf("Failed to notify alert %q: %v", alert.Rule, err)
+			}
+		}(n)
+	}
+}
+
+// Returns the value of the metric in the latest stats of the container.
+func metricValue(metric string, cont *v2.ContainerInfo) (float64, bool) {
+	if len(cont.Stats) == 0 {
+		return 0, false
+	}
+	last := cont.Stats[len(cont.Stats)-1]
+	switch metric {
+	case MetricCpuUsageCores:
+		if last.CpuInst != nil {
+			return float64(last.CpuInst.Usage.Total) / 1e9, true
+		}
+		if len(cont.Stats) < 2 || last.Cpu == nil {
+			return 0, false
+		}
+		first := cont.Stats[0]
+		elapsed := last.Since(first)
+		if first.Cpu == nil || elapsed <= 0 || last.Cpu.Usage.Total < first.Cpu.Usage.Total {
+			return 0, false
+		}
+		return float64(last.Cpu.Usage.Total-first.Cpu.Usage.Total) / float64(elapsed.Nanoseconds()), true
+	case MetricMemoryUsageBytes, MetricMemoryWorkingSet, MetricMemoryLimitRatio:
+		if last.Memory == nil {
+			return 0, false
+		}
+		if metric == MetricMemoryUsageBytes {
+			return float64(last.Memory.Usage), true
+		}
+		if metric == MetricMemoryWorkingSet {
+			return float64(last.Memory.WorkingSet), true
+		}
+		limit := cont.Spec.Memory.Limit
+		// Containers without a limit report a huge one.
+		if !cont.Spec.HasMemory || limit == 0 || limit >= 1<<62 {
+			return 0, false
+		}
+		return float64(last.Memory.WorkingSet) / float64(limit), true
+	case MetricFilesystemUsageBytes:
+		if last.Filesystem == nil || last.Filesystem.TotalUsageBytes == nil {
+			return 0, false
+		}
+		return float64(*last.Filesystem.TotalUsageBytes), true
+	case MetricNetworkRxRate, MetricNetworkTxRate:
+		if len(cont.Stats) < 2 {
+			return 0, false
+		}
+		first := cont.Stats[0]
+		elapsed := last.Since(first)
+		if first.Network == nil || last.Network == nil || elapsed <= 0 {
+			return 0, false
+		}
+		rx := metric == MetricNetworkRxRate
+		before, after := networkBytes(first.Network, rx), networkBytes(last.Network, rx)
+		if after < before {
+			return 0, false
+		}
+		return float64(after-before) / elapsed.Seconds(), true
+	}
+	return 0, false
+}
+
+func networkBytes(stats *v2.NetworkStats, rx bool) uint64 {
+	var total uint64
+	for _, iface := range stats.Interfaces {
+		if rx {
+			total += iface.RxBytes
+		} else {
+			total += iface.TxBytes
+		}
+	}
+	return total
+}
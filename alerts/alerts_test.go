@@ -0,0 +1,167 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alerts
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/info/v2"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func memoryInfo(workingSet, limit uint64) v2.ContainerInfo {
+	return v2.ContainerInfo{
+		Spec: v2.ContainerSpec{
+			HasMemory: true,
+			Memory:    v2.MemorySpec{Limit: limit},
+		},
+		Stats: []*v2.ContainerStats{
+			{Memory: &info.MemoryStats{WorkingSet: workingSet}},
+		},
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	alerts := make(chan *Alert, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		alert := &Alert{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(alert))
+		alerts <- alert
+	}))
+	defer server.Close()
+
+	e, err := newEvaluator(&Config{
+		Rules: []RuleConfig{{
+			Name:      "memory",
+			Container: "^/docker/",
+			Metric:    MetricMemoryLimitRatio,
+			Threshold: 0.9,
+			For:       "1m",
+		}},
+		Receivers: []ReceiverConfig{{Name: "hook", Type: ReceiverWebhook, URL: server.URL}},
+	})
+	require.NoError(t, err)
+
+	start := time.Unix(1000, 0)
+	high := map[string]v2.ContainerInfo{
+		"/docker/a": memoryInfo(95, 100),
+		"/system":   memoryInfo(95, 100),
+	}
+	e.evaluate(high, start)
+	e.evaluate(high, start.Add(30*time.Second))
+	e.evaluate(high, start.Add(time.Minute))
+	alert := <-alerts
+	assert.Equal(t, "memory", alert.Rule)
+	assert.Equal(t, "/docker/a", alert.Container)
+	assert.Equal(t, StateFiring, alert.State)
+	assert.Equal(t, 0.95, alert.Value)
+	assert.Equal(t, start.Unix(), alert.Since.Unix())
+
+	// The alert fires once and resolves when the usage drops.
+	e.evaluate(high, start.Add(2*time.Minute))
+	e.evaluate(map[string]v2.ContainerInfo{"/docker/a": memoryInfo(50, 100)}, start.Add(3*time.Minute))
+	alert = <-alerts
+	assert.Equal(t, StateResolved, alert.State)
+	assert.Equal(t, 0.5, alert.Value)
+	assert.Len(t, alerts, 0)
+}
+
+func TestEvaluateMissingContainer(t *testing.T) {
+	alerts := make(chan *Alert, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		alert := &Alert{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(alert))
+		alerts <- alert
+	}))
+	defer server.Close()
+
+	e, err := newEvaluator(&Config{
+		Rules: []RuleConfig{{
+			Name:      "memory",
+			Metric:    MetricMemoryLimitRatio,
+			Threshold: 0.9,
+		}},
+		Receivers: []ReceiverConfig{{Name: "hook", Type: ReceiverWebhook, URL: server.URL}},
+	})
+	require.NoError(t, err)
+
+	start := time.Unix(1000, 0)
+	e.evaluate(map[string]v2.ContainerInfo{"/a": memoryInfo(95, 100)}, start)
+	alert := <-alerts
+	assert.Equal(t, StateFiring, alert.State)
+
+	// A missing sample keeps the alert firing without notifying again.
+	e.evaluate(map[string]v2.ContainerInfo{"/a": {}}, start.Add(time.Minute))
+	e.evaluate(map[string]v2.ContainerInfo{"/a": memoryInfo(95, 100)}, start.Add(2*time.Minute))
+	time.Sleep(50 * time.Millisecond)
+	assert.Len(t, alerts, 0)
+
+	// The alert resolves when the container goes away.
+	e.evaluate(map[string]v2.ContainerInfo{}, start.Add(3*time.Minute))
+	alert = <-alerts
+	assert.Equal(t, StateResolved, alert.State)
+	assert.Equal(t, "/a", alert.Container)
+	assert.Equal(t, 0.95, alert.Value)
+	assert.Equal(t, start.Unix(), alert.Since.Unix())
+}
+
+func TestNewEvaluatorErrors(t *testing.T) {
+	for _, config := range []*Config{
+		{Rules: []RuleConfig{{Name: "r", Metric: "unknown"}}},
+		{Rules: []RuleConfig{{Name: "r", Metric: MetricMemoryUsageBytes, Container: "("}}},
+		{Rules: []RuleConfig{{Name: "r", Metric: MetricMemoryUsageBytes, For: "soon"}}},
+		{Rules: []RuleConfig{{Name: "r", Metric: MetricMemoryUsageBytes, Receivers: []string{"missing"}}}},
+		{Receivers: []ReceiverConfig{{Name: "pd", Type: ReceiverPagerDuty}}},
+	} {
+		_, err := newEvaluator(config)
+		assert.Error(t, err)
+	}
+}
+
+func TestMetricValue(t *testing.T) {
+	start := time.Unix(1000, 0)
+	cont := &v2.ContainerInfo{
+		Stats: []*v2.ContainerStats{
+			{
+				Timestamp: start,
+				Cpu:       &info.CpuStats{Usage: info.CpuUsage{Total: 1e9}},
+				Network:   &v2.NetworkStats{Interfaces: []info.InterfaceStats{{RxBytes: 1000, TxBytes: 0}}},
+			},
+			{
+				Timestamp: start.Add(2 * time.Second),
+				Cpu:       &info.CpuStats{Usage: info.CpuUsage{Total: 4e9}},
+				Network:   &v2.NetworkStats{Interfaces: []info.InterfaceStats{{RxBytes: 5000, TxBytes: 100}}},
+			},
+		},
+	}
+	value, ok := metricValue(MetricCpuUsageCores, cont)
+	assert.True(t, ok)
+	assert.Equal(t, 1.5, value)
+	value, ok = metricValue(MetricNetworkRxRate, cont)
+	assert.True(t, ok)
+	assert.Equal(t, 2000.0, value)
+	value, ok = metricValue(MetricNetworkTxRate, cont)
+	assert.True(t, ok)
+	assert.Equal(t, 50.0, value)
+	_, ok = metricValue(MetricMemoryUsageBytes, cont)
+	assert.False(t, ok)
+}
@@ -0,0 +1,162 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package alerts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Types of receivers.
+const (
+	ReceiverWebhook   = "webhook"
+	ReceiverSlack     = "slack"
+	ReceiverPagerDuty = "pagerduty"
+)
+
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+type ReceiverConfig struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// URL of the webhook or Slack incoming webhook. Overrides the PagerDuty
+	// Events API URL.
+	URL string `json:"url,omitempty"`
+	// Integration key of the PagerDuty service.
+	RoutingKey string `json:"routing_key,omitempty"`
+}
+
+// Notifier delivers alerts to a receiver.
+type Notifier interface {
+	Notify(alert *Alert) error
+}
+
+var client = &http.Client{
+	Timeout: 10 * time.Second,
+}
+
+func newNotifier(config *ReceiverConfig) (Notifier, error) {
+	switch config.Type {
+	case ReceiverWebhook:
+		if config.URL == "" {
+			return nil, fmt.Errorf("webhook receiver %q has no url", config.Name)
+		}
+		return &webhookNotifier{config.URL}, nil
+	case ReceiverSlack:
+		if config.URL == "" {
+			return nil, fmt.Errorf("slack receiver %q has no url", config.Name)
+		}
+		return &slackNotifier{config.URL}, nil
+	case ReceiverPagerDuty:
+		if config.RoutingKey == "" {
+			return nil, fmt.Errorf("pagerduty receiver %q has no routing_key", config.Name)
+		}
+		n := &pagerDutyNotifier{
+			url:        config.URL,
+			routingKey: config.RoutingKey,
+		}
+		if n.url == "" {
+			n.url = pagerDutyEventsURL
+		}
+		return n, nil
+	default:
+		return nil, fmt.Errorf("unknown type %q of receiver %q", config.Type, config.Name)
+	}
+}
+
+// POSTs the JSON body to the URL.
+func post(url string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("notification rejected with status %q: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// Posts the alert as JSON.
+type webhookNotifier struct {
+	url string
+}
+
+func (self *webhookNotifier) Notify(alert *Alert) error {
+	return post(self.url, alert)
+}
+
+// Posts the alert as a message to a Slack incoming webhook.
+type slackNotifier struct {
+	url string
+}
+
+func (self *slackNotifier) Notify(alert *Alert) error {
+	return post(self.url, map[string]string{"text": alert.String()})
+}
+
+// Triggers and resolves incidents with the PagerDuty Events API v2.
+type pagerDutyNotifier struct {
+	url        string
+	routingKey string
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string    `json:"summary"`
+	Source        string    `json:"source"`
+	Severity      string    `json:"severity"`
+	Timestamp     time.Time `json:"timestamp"`
+	Component     string    `json:"component"`
+	CustomDetails *Alert    `json:"custom_details"`
+}
+
+func (self *pagerDutyNotifier) Notify(alert *Alert) error {
+	event := &pagerDutyEvent{
+		RoutingKey: self.routingKey,
+		// Resolving uses the same key as the incident triggered.
+		DedupKey: fmt.Sprintf("cadvisor/%s/%s/%s", alert.Hostname, alert.Rule, alert.Container),
+	}
+	if alert.State == StateResolved {
+		event.EventAction = "resolve"
+	} else {
+		event.EventAction = "trigger"
+		event.Payload = &pagerDutyPayload{
+			Summary:       alert.String(),
+			Source:        alert.Hostname,
+			Severity:      "error",
+			Timestamp:     alert.Timestamp,
+			Component:     alert.Container,
+			CustomDetails: alert,
+		}
+	}
+	return post(self.url, event)
+}
@@ -0,0 +1,240 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/pprof"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/cadvisor/config"
+	"github.com/google/cadvisor/events"
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/manager"
+)
+
+const (
+	// Number of recent stats samples per container in a bundle by default.
+	defaultBundleStats = 10
+	// How far back the events of a bundle go.
+	bundleEventsWindow = time.Hour
+	// Maximum number of events in a bundle.
+	maxBundleEvents = 1000
+)
+
+// Writes the files of a diagnostics bundle to a gzipped tar archive, all
+// under a directory named after the time of the bundle. A file that could
+// not be produced is listed with its error in errors.txt instead of failing
+// the whole bundle.
+type bundleWriter struct {
+	gz     *gzip.Writer
+	tar    *tar.Writer
+	dir    string
+	now    time.Time
+	errors []string
+}
+
+func newBundleWriter(w io.Writer, now time.Time) *bundleWriter {
+	gz := gzip.NewWriter(w)
+	return &bundleWriter{
+		gz:  gz,
+		tar: tar.NewWriter(gz),
+		dir: bundleName(now),
+		now: now,
+	}
+}
+
+func bundleName(now time.Time) string {
+	return "cadvisor-bundle-" + now.UTC().Format("20060102T150405Z")
+}
+
+// Adds a file with the data, or records the error.
+func (self *bundleWriter) add(name string, data []byte, err error) error {
+	if err != nil {
+		self.errors = append(self.errors, fmt.Sprintf("%s: %v", name, err))
+		return nil
+	}
+	header := &tar.Header{
+		Name:    self.dir + "/" + name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: self.now,
+	}
+	if err := self.tar.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = self.tar.Write(data)
+	return err
+}
+
+// Adds the value as an indented JSON file, or records the error.
+func (self *bundleWriter) addJson(name string, value interface{}, err error) error {
+	if err != nil {
+		return self.add(name, nil, err)
+	}
+	data, err := json.MarshalIndent(value, "", "  ")
+	return self.add(name, data, err)
+}
+
+// Adds the named runtime profile.
+func (self *bundleWriter) addProfile(name, profile string, debug int) error {
+	p := pprof.Lookup(profile)
+	if p == nil {
+		return self.add(name, nil, fmt.Errorf("unknown profile %q", profile))
+	}
+	var buf bytes.Buffer
+	err := p.WriteTo(&buf, debug)
+	return self.add(name, buf.Bytes(), err)
+}
+
+// Adds errors.txt if some files are missing and closes the archive.
+func (self *bundleWriter) Close() error {
+	if len(self.errors) > 0 {
+		if err := self.add("errors.txt", []byte(strings.Join(self.errors, "\n")+"\n"), nil); err != nil {
+			return err
+		}
+	}
+	if err := self.tar.Close(); err != nil {
+		return err
+	}
+	return self.gz.Close()
+}
+
+// Serves /debug/bundle: a gzipped tar archive of what is needed to
+// investigate a cAdvisor, namely its version, the machine, the effective
+// flags, the health checks, the specs and recent stats of all containers,
+// the recent events, the internal state of the manager and the goroutine and
+// heap profiles.
+func handleDebugRequest(request []string, m manager.Manager, w http.ResponseWriter, r *http.Request) error {
+	if len(request) != 1 || request[0] != "bundle" {
+		return &statusError{
+			status: http.StatusNotFound,
+			msg:    fmt.Sprintf("unknown debug request %q, expected bundle", strings.Join(request, "/")),
+		}
+	}
+	count := defaultBundleStats
+	if value := r.URL.Query().Get("count"); value != "" {
+		n, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return &statusError{
+				status: http.StatusBadRequest,
+				msg:    fmt.Sprintf("failed to parse 'count' option: %v", value),
+			}
+		}
+		count = int(n)
+	}
+	now := time.Now()
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", bundleName(now)+".tar.gz"))
+	return writeBundle(newBundleWriter(w, now), m, count)
+}
+
+func writeBundle(bundle *bundleWriter, m manager.Manager, count int) error {
+	version, err := m.GetVersionInfo()
+	if err := bundle.addJson("version.json", version, err); err != nil {
+		return err
+	}
+	machine, err := m.GetMachineInfo()
+	if err := bundle.addJson("machine.json", machine, err); err != nil {
+		return err
+	}
+	flags := configInfo{
+		Flags:      config.Effective(),
+		Reloadable: config.ReloadableFlags(),
+	}
+	if err := bundle.addJson("flags.json", flags, nil); err != nil {
+		return err
+	}
+	health := map[string][]v2.HealthCheck{
+		"healthz": m.Healthz(),
+		"readyz":  m.Readyz(),
+	}
+	if err := bundle.addJson("health.json", health, nil); err != nil {
+		return err
+	}
+
+	opt := v2.RequestOptions{IdType: v2.TypeName, Count: count, Recursive: true}
+	specs, err := m.GetContainerSpec("/", opt)
+	if err := bundle.addJson("specs.json", specs, err); err != nil {
+		return err
+	}
+	var stats map[string][]*v2.ContainerStats
+	conts, err := m.GetRequestedContainersInfo("/", opt)
+	if err == nil {
+		stats = make(map[string][]*v2.ContainerStats, len(conts))
+		for name, cont := range conts {
+			stats[name] = v2.ContainerStatsFromV1(&cont.Spec, cont.Stats)
+		}
+	}
+	if err := bundle.addJson("stats.json", stats, err); err != nil {
+		return err
+	}
+
+	eventRequest := &events.Request{
+		StartTime:            bundle.now.Add(-bundleEventsWindow),
+		EndTime:              bundle.now,
+		EventType:            make(map[info.EventType]bool, len(eventTypes)),
+		MaxEventsReturned:    maxBundleEvents,
+		ContainerName:        "/",
+		IncludeSubcontainers: true,
+	}
+	for _, eventType := range eventTypes {
+		eventRequest.EventType[eventType] = true
+	}
+	pastEvents, err := m.GetPastEvents(eventRequest)
+	if err := bundle.addJson("events.json", pastEvents, err); err != nil {
+		return err
+	}
+
+	if err := bundle.add("manager.txt", formatDebugInfo(m.DebugInfo()), nil); err != nil {
+		return err
+	}
+	if err := bundle.addProfile("goroutine.txt", "goroutine", 2); err != nil {
+		return err
+	}
+	if err := bundle.addProfile("heap.pprof", "heap", 0); err != nil {
+		return err
+	}
+	return bundle.Close()
+}
+
+// Formats the lines of each category of the debug info of the manager, in
+// the order of the categories.
+func formatDebugInfo(debugInfo map[string][]string) []byte {
+	categories := make([]string, 0, len(debugInfo))
+	for category := range debugInfo {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	var buf bytes.Buffer
+	for _, category := range categories {
+		fmt.Fprintf(&buf, "%s:\n", category)
+		for _, line := range debugInfo[category] {
+			fmt.Fprintf(&buf, "\t%s\n", line)
+		}
+		buf.WriteString("\n")
+	}
+	return buf.Bytes()
+}
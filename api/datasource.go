@@ -0,0 +1,380 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/cadvisor/events"
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
+	"github.com/google/cadvisor/manager"
+)
+
+// The Grafana SimpleJSON datasource, serving the stats cached in memory.
+const datasourceResource = "/api/datasource/"
+
+// Name of the datasource in the API groups.
+const datasourceApi = "datasource"
+
+// Maximum size of the body of the requests, in bytes.
+const maxDatasourceRequestSize = 1 << 20
+
+// Maximum number of events returned as annotations.
+const maxDatasourceAnnotations = 1000
+
+// A metric of the datasource, read from the stats of the containers having
+// the resource.
+type datasourceMetric struct {
+	// Whether the metric is reported as the per second rate of the value.
+	rate  bool
+	has   func(spec *info.ContainerSpec) bool
+	value func(stats *info.ContainerStats) float64
+}
+
+var datasourceMetrics = map[string]datasourceMetric{
+	"cpu_usage": {
+		rate: true,
+		has:  func(spec *info.ContainerSpec) bool { return spec.HasCpu },
+		// In cores.
+		value: func(stats *info.ContainerStats) float64 { return float64(stats.Cpu.Usage.Total) / float64(time.Second) },
+	},
+	"memory_usage": {
+		has:   func(spec *info.ContainerSpec) bool { return spec.HasMemory },
+		value: func(stats *info.ContainerStats) float64 { return float64(stats.Memory.Usage) },
+	},
+	"memory_working_set": {
+		has:   func(spec *info.ContainerSpec) bool { return spec.HasMemory },
+		value: func(stats *info.ContainerStats) float64 { return float64(stats.Memory.WorkingSet) },
+	},
+	"network_rx_bytes": {
+		rate:  true,
+		has:   func(spec *info.ContainerSpec) bool { return spec.HasNetwork },
+		value: func(stats *info.ContainerStats) float64 { return float64(stats.Network.RxBytes) },
+	},
+	"network_tx_bytes": {
+		rate:  true,
+		has:   func(spec *info.ContainerSpec) bool { return spec.HasNetwork },
+		value: func(stats *info.ContainerStats) float64 { return float64(stats.Network.TxBytes) },
+	},
+	"fs_usage": {
+		has: func(spec *info.ContainerSpec) bool { return spec.HasFilesystem },
+		value: func(stats *info.ContainerStats) float64 {
+			var usage uint64
+			for _, fs := range stats.Filesystem {
+				usage += fs.Usage
+			}
+			return float64(usage)
+		},
+	},
+}
+
+type datasourceRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+type datasourceTarget struct {
+	Target string `json:"target"`
+	RefId  string `json:"refId"`
+	// "timeserie" or "table".
+	Type string `json:"type"`
+}
+
+type datasourceQuery struct {
+	Range         datasourceRange    `json:"range"`
+	MaxDataPoints int                `json:"maxDataPoints"`
+	Targets       []datasourceTarget `json:"targets"`
+}
+
+type datasourceSearch struct {
+	Target string `json:"target"`
+}
+
+type datasourceAnnotationQuery struct {
+	Range datasourceRange `json:"range"`
+	// Returned with each annotation.
+	Annotation json.RawMessage `json:"annotation"`
+}
+
+type datasourceAnnotationSpec struct {
+	// Container whose events, and those of its subcontainers, are returned.
+	// The root container by default.
+	Query string `json:"query"`
+}
+
+type datasourceTimeserie struct {
+	Target string `json:"target"`
+	// Pairs of value and time in milliseconds.
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+type datasourceColumn struct {
+	Text string `json:"text"`
+	Type string `json:"type"`
+}
+
+type datasourceTable struct {
+	Type    string             `json:"type"`
+	Columns []datasourceColumn `json:"columns"`
+	Rows    [][]interface{}    `json:"rows"`
+}
+
+type datasourceAnnotation struct {
+	Annotation json.RawMessage `json:"annotation"`
+	// In milliseconds.
+	Time  int64    `json:"time"`
+	Title string   `json:"title"`
+	Text  string   `json:"text"`
+	Tags  []string `json:"tags"`
+}
+
+func handleDatasourceRequest(m manager.Manager, w http.ResponseWriter, r *http.Request) error {
+	request := strings.TrimPrefix(r.URL.Path, datasourceResource)
+	if request == "" {
+		// Grafana checks the connection with a GET of the root.
+		fmt.Fprint(w, "OK")
+		return nil
+	}
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		return &statusError{
+			status: http.StatusMethodNotAllowed,
+			msg:    fmt.Sprintf("method %s is not supported by the datasource", r.Method),
+		}
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxDatasourceRequestSize))
+	if err != nil {
+		return err
+	}
+	logging.V(4).Infof("Api - Datasource: %s %s", request, body)
+	switch request {
+	case "search":
+		var search datasourceSearch
+		if err := decodeDatasourceRequest(body, &search); err != nil {
+			return err
+		}
+		targets, err := searchDatasource(m, search.Target)
+		if err != nil {
+			return err
+		}
+		return writeResult(targets, w)
+	case "query":
+		var query datasourceQuery
+		if err := decodeDatasourceRequest(body, &query); err != nil {
+			return err
+		}
+		results, err := queryDatasource(m, &query)
+		if err != nil {
+			return err
+		}
+		return writeResult(results, w)
+	case "annotations":
+		var query datasourceAnnotationQuery
+		if err := decodeDatasourceRequest(body, &query); err != nil {
+			return err
+		}
+		annotations, err := annotateDatasource(m, &query)
+		if err != nil {
+			return err
+		}
+		return writeResult(annotations, w)
+	default:
+		return &statusError{
+			status: http.StatusNotFound,
+			msg:    fmt.Sprintf("unknown datasource request %q", request),
+		}
+	}
+}
+
+func decodeDatasourceRequest(body []byte, v interface{}) error {
+	if err := json.Unmarshal(body, v); err != nil {
+		return &statusError{
+			status: http.StatusBadRequest,
+			msg:    fmt.Sprintf("failed to decode datasource request: %v", err),
+		}
+	}
+	return nil
+}
+
+// Lists the "<container>:<metric>" targets containing the text.
+func searchDatasource(m manager.Manager, text string) ([]string, error) {
+	containers, err := m.SubcontainersInfo("/", &info.ContainerInfoRequest{NumStats: 0})
+	if err != nil {
+		return nil, err
+	}
+	targets := []string{}
+	for _, cont := range containers {
+		for name, metric := range datasourceMetrics {
+			target := cont.Name + ":" + name
+			if metric.has(&cont.Spec) && strings.Contains(target, text) {
+				targets = append(targets, target)
+			}
+		}
+	}
+	sort.Strings(targets)
+	return targets, nil
+}
+
+// Splits a target into its container name and metric.
+func parseDatasourceTarget(target string) (string, datasourceMetric, error) {
+	i := strings.LastIndex(target, ":")
+	if i < 0 {
+		return "", datasourceMetric{}, fmt.Errorf("target %q is not of the form <container>:<metric>", target)
+	}
+	metric, ok := datasourceMetrics[target[i+1:]]
+	if !ok {
+		return "", datasourceMetric{}, fmt.Errorf("unknown metric %q", target[i+1:])
+	}
+	return target[:i], metric, nil
+}
+
+func queryDatasource(m manager.Manager, query *datasourceQuery) ([]interface{}, error) {
+	results := make([]interface{}, 0, len(query.Targets))
+	for _, target := range query.Targets {
+		if target.Target == "" {
+			continue
+		}
+		name, metric, err := parseDatasourceTarget(target.Target)
+		if err != nil {
+			return nil, &statusError{
+				status: http.StatusBadRequest,
+				msg:    err.Error(),
+			}
+		}
+		cont, err := m.GetContainerInfo(name, &info.ContainerInfoRequest{
+			NumStats: -1,
+			Start:    query.Range.From,
+			End:      query.Range.To,
+		})
+		if err != nil {
+			return nil, err
+		}
+		datapoints := thinDatapoints(getDatapoints(metric, cont), query.MaxDataPoints)
+		if target.Type == "table" {
+			table := datasourceTable{
+				Type: "table",
+				Columns: []datasourceColumn{
+					{Text: "Time", Type: "time"},
+					{Text: target.Target, Type: "number"},
+				},
+				Rows: make([][]interface{}, 0, len(datapoints)),
+			}
+			for _, point := range datapoints {
+				table.Rows = append(table.Rows, []interface{}{int64(point[1]), point[0]})
+			}
+			results = append(results, table)
+			continue
+		}
+		results = append(results, datasourceTimeserie{
+			Target:     target.Target,
+			Datapoints: datapoints,
+		})
+	}
+	return results, nil
+}
+
+// Gets the values of the metric, or their rates, with their time in
+// milliseconds.
+func getDatapoints(metric datasourceMetric, cont *info.ContainerInfo) [][2]float64 {
+	datapoints := [][2]float64{}
+	if !metric.has(&cont.Spec) {
+		return datapoints
+	}
+	for i, stats := range cont.Stats {
+		value := metric.value(stats)
+		if metric.rate {
+			if i == 0 {
+				continue
+			}
+			prev := cont.Stats[i-1]
+			interval := stats.Since(prev).Seconds()
+			if interval <= 0 {
+				continue
+			}
+			value = (value - metric.value(prev)) / interval
+			if value < 0 {
+				// The counter was reset.
+				continue
+			}
+		}
+		datapoints = append(datapoints, [2]float64{value, float64(stats.Timestamp.UnixNano() / int64(time.Millisecond))})
+	}
+	return datapoints
+}
+
+// Keeps at most max evenly spaced datapoints, the latest included.
+func thinDatapoints(datapoints [][2]float64, max int) [][2]float64 {
+	if max <= 0 || len(datapoints) <= max {
+		return datapoints
+	}
+	if max == 1 {
+		return datapoints[len(datapoints)-1:]
+	}
+	thinned := make([][2]float64, max)
+	for i := range thinned {
+		thinned[i] = datapoints[len(datapoints)-1-(max-1-i)*(len(datapoints)-1)/(max-1)]
+	}
+	return thinned
+}
+
+func annotateDatasource(m manager.Manager, query *datasourceAnnotationQuery) ([]datasourceAnnotation, error) {
+	var spec datasourceAnnotationSpec
+	if len(query.Annotation) > 0 {
+		if err := json.Unmarshal(query.Annotation, &spec); err != nil {
+			return nil, &statusError{
+				status: http.StatusBadRequest,
+				msg:    fmt.Sprintf("failed to decode annotation: %v", err),
+			}
+		}
+	}
+	containerName := spec.Query
+	if containerName == "" {
+		containerName = "/"
+	}
+	request := &events.Request{
+		StartTime:            query.Range.From,
+		EndTime:              query.Range.To,
+		EventType:            make(map[info.EventType]bool, len(eventTypes)),
+		MaxEventsReturned:    maxDatasourceAnnotations,
+		ContainerName:        containerName,
+		IncludeSubcontainers: true,
+	}
+	for _, eventType := range eventTypes {
+		request.EventType[eventType] = true
+	}
+	evs, err := m.GetPastEvents(request)
+	if err != nil {
+		return nil, err
+	}
+	annotations := make([]datasourceAnnotation, 0, len(evs))
+	for _, ev := range evs {
+		annotations = append(annotations, datasourceAnnotation{
+			Annotation: query.Annotation,
+			Time:       ev.Timestamp.UnixNano() / int64(time.Millisecond),
+			Title:      string(ev.EventType),
+			Text:       ev.ContainerName,
+			Tags:       []string{string(ev.EventType)},
+		})
+	}
+	return annotations, nil
+}
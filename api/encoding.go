@@ -0,0 +1,121 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/google/cadvisor/utils/msgpack"
+)
+
+const (
+	jsonContentType     = "application/json"
+	protobufContentType = "application/x-protobuf"
+	msgpackContentType  = "application/msgpack"
+)
+
+// Other names clients use for the content types.
+var contentTypeAliases = map[string]string{
+	"application/protobuf":  protobufContentType,
+	"application/x-msgpack": msgpackContentType,
+}
+
+// Returns the content type of the offers the Accept header of the request
+// prefers. Ties go to the first offer, which is also returned when none is
+// acceptable.
+func negotiateContentType(r *http.Request, offers ...string) string {
+	best, bestQuality := offers[0], 0.0
+	for _, offer := range offers {
+		// The quality of the most specific media range matching the offer.
+		quality, specificity := 0.0, -1
+		for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+			params := strings.Split(part, ";")
+			mediaRange := strings.ToLower(strings.TrimSpace(params[0]))
+			if alias, ok := contentTypeAliases[mediaRange]; ok {
+				mediaRange = alias
+			}
+			s := 0
+			switch {
+			case mediaRange == offer:
+				s = 2
+			case mediaRange == offer[:strings.Index(offer, "/")]+"/*":
+				s = 1
+			case mediaRange == "*/*":
+			default:
+				continue
+			}
+			if s <= specificity {
+				continue
+			}
+			specificity, quality = s, 1
+			for _, param := range params[1:] {
+				kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+				if len(kv) == 2 && kv[0] == "q" {
+					if q, err := strconv.ParseFloat(kv[1], 64); err == nil {
+						quality = q
+					}
+				}
+			}
+		}
+		if quality > bestQuality {
+			best, bestQuality = offer, quality
+		}
+	}
+	return best
+}
+
+// Writes the result in JSON, MessagePack or, when toProto is given, in the
+// protocol buffer it returns, per the Accept header of the request.
+func writeEncodedResult(res interface{}, toProto func() proto.Message, w http.ResponseWriter, r *http.Request) error {
+	offers := []string{jsonContentType, msgpackContentType}
+	if toProto != nil {
+		offers = append(offers, protobufContentType)
+	}
+	contentType := negotiateContentType(r, offers...)
+	w.Header().Add("Vary", "Accept")
+
+	var out []byte
+	var err error
+	switch contentType {
+	case protobufContentType:
+		out, err = proto.Marshal(toProto())
+	case msgpackContentType:
+		out, err = msgpack.Marshal(res)
+	default:
+		return writeResult(res, w)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode response in %s with error: %s", contentType, err)
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Write(out)
+	return nil
+}
+
+// Returns the ETag of the representation of a response in the content type
+// the request prefers.
+func encodingETag(etag string, r *http.Request) string {
+	switch negotiateContentType(r, jsonContentType, msgpackContentType, protobufContentType) {
+	case protobufContentType:
+		return strings.TrimSuffix(etag, `"`) + `-pb"`
+	case msgpackContentType:
+		return strings.TrimSuffix(etag, `"`) + `-msgpack"`
+	}
+	return etag
+}
@@ -0,0 +1,214 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/cadvisor/manager"
+)
+
+var argResponseCacheSize = flag.Int("api_response_cache_size", 64, "Number of responses of the machine and spec endpoints kept in memory until the machine or the specs of the containers change. 0 disables the cache")
+
+// Distinguishes the revisions of this instance from those of a previous one,
+// which restart from 0.
+var etagEpoch = fmt.Sprintf("%x", time.Now().UnixNano())
+
+// Returns whether the response to the request only depends on the machine
+// info and the specs of the containers, or also on their stats, and whether
+// it only depends on them.
+func responseRevisions(requestType string, r *http.Request) (specsOnly bool, ok bool) {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		return false, false
+	}
+	// The responses of the requests waiting for changes are newer than the
+	// revisions they start at.
+	if r.URL.Query().Get("wait") != "" {
+		return false, false
+	}
+	switch requestType {
+	case machineApi, specApi:
+		return true, true
+	case containersApi, subcontainersApi, dockerApi, statsApi, summaryApi, machineStatsApi:
+		return false, true
+	}
+	return false, false
+}
+
+// Returns the ETag of the responses computed at the given revisions.
+func revisionsETag(specsOnly bool, specs, stats uint64) string {
+	if specsOnly {
+		return fmt.Sprintf(`"%s-%x"`, etagEpoch, specs)
+	}
+	return fmt.Sprintf(`"%s-%x-%x"`, etagEpoch, specs, stats)
+}
+
+// Returns whether the If-None-Match header of the request matches the ETag.
+func etagMatches(r *http.Request, etag string) bool {
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// A response recorded to be served again.
+type cachedResponse struct {
+	etag        string
+	contentType string
+	body        []byte
+}
+
+// Responses of the machine and spec endpoints by request, valid as long as
+// their ETag is current.
+type responseCache struct {
+	size      int
+	lock      sync.Mutex
+	responses map[string]*cachedResponse
+}
+
+func newResponseCache(size int) *responseCache {
+	return &responseCache{
+		size:      size,
+		responses: make(map[string]*cachedResponse, size),
+	}
+}
+
+func (self *responseCache) get(key, etag string) *cachedResponse {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	response, ok := self.responses[key]
+	if !ok || response.etag != etag {
+		return nil
+	}
+	return response
+}
+
+func (self *responseCache) put(key string, response *cachedResponse) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if _, ok := self.responses[key]; !ok && len(self.responses) >= self.size {
+		// Drop the stale responses first, or an arbitrary one.
+		for k, r := range self.responses {
+			if r.etag != response.etag {
+				delete(self.responses, k)
+			}
+		}
+		for k := range self.responses {
+			if len(self.responses) < self.size {
+				break
+			}
+			delete(self.responses, k)
+		}
+	}
+	self.responses[key] = response
+}
+
+// Records the response written by a handler, to cache it.
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (self *responseRecorder) Header() http.Header {
+	return self.header
+}
+
+func (self *responseRecorder) WriteHeader(status int) {
+	if self.status == 0 {
+		self.status = status
+	}
+}
+
+func (self *responseRecorder) Write(b []byte) (int, error) {
+	self.WriteHeader(http.StatusOK)
+	return self.body.Write(b)
+}
+
+// An API version answering the requests whose response didn't change since
+// the client got it with 304 Not Modified, and serving the responses of the
+// machine and spec endpoints from a cache.
+type cachedVersion struct {
+	ApiVersion
+	// Nil if responses aren't cached.
+	cache *responseCache
+}
+
+func (self *cachedVersion) HandleRequest(requestType string, request []string, m manager.Manager, w http.ResponseWriter, r *http.Request) error {
+	specsOnly, ok := responseRevisions(requestType, r)
+	if !ok {
+		return self.ApiVersion.HandleRequest(requestType, request, m, w, r)
+	}
+	// The revisions are read before computing the response, which is then
+	// at least as recent as them.
+	specs, stats := m.Revisions()
+	etag := encodingETag(revisionsETag(specsOnly, specs, stats), r)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if etagMatches(r, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+	if !specsOnly || self.cache == nil {
+		return self.ApiVersion.HandleRequest(requestType, request, m, w, r)
+	}
+
+	key := self.Version() + " " + r.URL.RequestURI() + " " + r.Header.Get("Accept")
+	if response := self.cache.get(key, etag); response != nil {
+		w.Header().Set("Content-Type", response.contentType)
+		w.Write(response.body)
+		return nil
+	}
+	recorder := &responseRecorder{header: w.Header()}
+	if err := self.ApiVersion.HandleRequest(requestType, request, m, recorder, r); err != nil {
+		return err
+	}
+	if recorder.status == 0 {
+		recorder.status = http.StatusOK
+	}
+	if recorder.status == http.StatusOK {
+		self.cache.put(key, &cachedResponse{
+			etag:        etag,
+			contentType: recorder.header.Get("Content-Type"),
+			body:        recorder.body.Bytes(),
+		})
+	}
+	w.WriteHeader(recorder.status)
+	w.Write(recorder.body.Bytes())
+	return nil
+}
+
+// Adds ETags to the responses of all API versions, and caches the responses
+// of the machine and spec endpoints.
+func cacheApiVersions(versions []ApiVersion, cacheSize int) []ApiVersion {
+	var cache *responseCache
+	if cacheSize > 0 {
+		cache = newResponseCache(cacheSize)
+	}
+	cached := make([]ApiVersion, 0, len(versions))
+	for _, v := range versions {
+		cached = append(cached, &cachedVersion{v, cache})
+	}
+	return cached
+}
@@ -0,0 +1,246 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"image/color"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/manager"
+	"github.com/google/cadvisor/utils/chart"
+)
+
+// Width of the exported charts, in pixels.
+const exportChartWidth = 800
+
+var exportCsvHeader = []string{
+	"timestamp",
+	"cpu_usage_total_ns",
+	"cpu_usage_user_ns",
+	"cpu_usage_system_ns",
+	"cpu_cores",
+	"memory_usage_bytes",
+	"memory_working_set_bytes",
+	"memory_rss_bytes",
+	"network_rx_bytes",
+	"network_tx_bytes",
+	"network_rx_errors",
+	"network_tx_errors",
+	"fs_usage_bytes",
+	"fs_limit_bytes",
+}
+
+// Serves the recent stats of a container as a CSV file or a PNG chart, per
+// the "format" option.
+func handleExportRequest(name string, m manager.Manager, w http.ResponseWriter, r *http.Request) error {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "png" {
+		return &statusError{
+			status: http.StatusBadRequest,
+			msg:    fmt.Sprintf("unknown 'format' %q, expected csv or png", format),
+		}
+	}
+	count := -1
+	if value := r.URL.Query().Get("count"); value != "" {
+		n, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return &statusError{
+				status: http.StatusBadRequest,
+				msg:    fmt.Sprintf("failed to parse 'count' option: %v", value),
+			}
+		}
+		count = int(n)
+	}
+	cont, err := m.GetContainerInfo(name, &info.ContainerInfoRequest{NumStats: count})
+	if err != nil {
+		return err
+	}
+
+	// Render first so that errors are reported as such, not as a download.
+	var out bytes.Buffer
+	now := time.Now()
+	contentType := "text/csv"
+	if format == "csv" {
+		err = writeStatsCsv(&out, cont.Stats)
+	} else {
+		contentType = "image/png"
+		err = chart.Render(&out, fmt.Sprintf("%s %s", getExportTitle(cont), now.UTC().Format("2006-01-02 15:04 MST")), exportChartWidth, getExportCharts(cont))
+	}
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, getExportFileName(cont.Name, now, format)))
+	_, err = out.WriteTo(w)
+	return err
+}
+
+// Returns the name of the exported file, e.g.
+// cadvisor-docker-abc-20160102-150405.csv.
+func getExportFileName(containerName string, now time.Time, ext string) string {
+	name := strings.Trim(containerName, "/")
+	if name == "" {
+		name = "root"
+	}
+	name = strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '.' || r == '_' {
+			return r
+		}
+		return '-'
+	}, name)
+	return fmt.Sprintf("cadvisor-%s-%s.%s", name, now.UTC().Format("20060102-150405"), ext)
+}
+
+func getExportTitle(cont *info.ContainerInfo) string {
+	if len(cont.Aliases) > 0 {
+		return fmt.Sprintf("%s (%s)", cont.Aliases[0], cont.Name)
+	}
+	return cont.Name
+}
+
+// Writes one line per sample. The cpu usage in cores is that since the
+// previous sample, empty for the first one.
+func writeStatsCsv(out io.Writer, stats []*info.ContainerStats) error {
+	w := csv.NewWriter(out)
+	if err := w.Write(exportCsvHeader); err != nil {
+		return err
+	}
+	u := func(v uint64) string {
+		return strconv.FormatUint(v, 10)
+	}
+	for i, s := range stats {
+		cores := ""
+		if i > 0 {
+			cores = strconv.FormatFloat(cpuCores(stats[i-1], s), 'f', 4, 64)
+		}
+		var fsUsage, fsLimit uint64
+		for _, fs := range s.Filesystem {
+			fsUsage += fs.Usage
+			fsLimit += fs.Limit
+		}
+		err := w.Write([]string{
+			s.Timestamp.UTC().Format(time.RFC3339Nano),
+			u(s.Cpu.Usage.Total),
+			u(s.Cpu.Usage.User),
+			u(s.Cpu.Usage.System),
+			cores,
+			u(s.Memory.Usage),
+			u(s.Memory.WorkingSet),
+			u(s.Memory.RSS),
+			u(s.Network.RxBytes),
+			u(s.Network.TxBytes),
+			u(s.Network.RxErrors),
+			u(s.Network.TxErrors),
+			u(fsUsage),
+			u(fsLimit),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// Returns the cpu usage in cores between two samples.
+func cpuCores(prev, cur *info.ContainerStats) float64 {
+	interval := cur.Since(prev)
+	if interval <= 0 || cur.Cpu.Usage.Total < prev.Cpu.Usage.Total {
+		return 0
+	}
+	return float64(cur.Cpu.Usage.Total-prev.Cpu.Usage.Total) / float64(interval)
+}
+
+// Returns the per second rate of a counter between two samples.
+func counterRate(prev, cur *info.ContainerStats, value func(*info.ContainerStats) uint64) float64 {
+	interval := cur.Since(prev).Seconds()
+	if interval <= 0 || value(cur) < value(prev) {
+		return 0
+	}
+	return float64(value(cur)-value(prev)) / interval
+}
+
+// Returns the charts of the resources of the container.
+func getExportCharts(cont *info.ContainerInfo) []chart.Chart {
+	stats := cont.Stats
+	var charts []chart.Chart
+	if cont.Spec.HasCpu {
+		var total []chart.Point
+		for i := 1; i < len(stats); i++ {
+			total = append(total, chart.Point{Time: stats[i].Timestamp, Value: cpuCores(stats[i-1], stats[i])})
+		}
+		charts = append(charts, chart.Chart{
+			Title:  "CPU usage (cores)",
+			Series: []chart.Series{{Name: "total", Points: total}},
+		})
+	}
+	if cont.Spec.HasMemory {
+		var usage, workingSet []chart.Point
+		for _, s := range stats {
+			usage = append(usage, chart.Point{Time: s.Timestamp, Value: float64(s.Memory.Usage)})
+			workingSet = append(workingSet, chart.Point{Time: s.Timestamp, Value: float64(s.Memory.WorkingSet)})
+		}
+		charts = append(charts, chart.Chart{
+			Title: "Memory",
+			Series: []chart.Series{
+				{Name: "usage", Points: usage},
+				{Name: "working set", Points: workingSet},
+			},
+			Format: chart.FormatBytes,
+		})
+	}
+	if cont.Spec.HasNetwork {
+		var rx, tx []chart.Point
+		for i := 1; i < len(stats); i++ {
+			rx = append(rx, chart.Point{Time: stats[i].Timestamp, Value: counterRate(stats[i-1], stats[i], func(s *info.ContainerStats) uint64 { return s.Network.RxBytes })})
+			tx = append(tx, chart.Point{Time: stats[i].Timestamp, Value: counterRate(stats[i-1], stats[i], func(s *info.ContainerStats) uint64 { return s.Network.TxBytes })})
+		}
+		charts = append(charts, chart.Chart{
+			Title: "Network (per second)",
+			Series: []chart.Series{
+				{Name: "rx", Points: rx},
+				{Name: "tx", Points: tx},
+			},
+			Format: chart.FormatBytes,
+		})
+	}
+	if cont.Spec.HasFilesystem {
+		var usage []chart.Point
+		for _, s := range stats {
+			var total uint64
+			for _, fs := range s.Filesystem {
+				total += fs.Usage
+			}
+			usage = append(usage, chart.Point{Time: s.Timestamp, Value: float64(total)})
+		}
+		charts = append(charts, chart.Chart{
+			Title:  "Filesystem usage",
+			Series: []chart.Series{{Name: "usage", Points: usage, Color: color.RGBA{0x10, 0x96, 0x18, 0xff}}},
+			Format: chart.FormatBytes,
+		})
+	}
+	return charts
+}
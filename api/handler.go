@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"path"
 	"regexp"
 	"sort"
@@ -28,12 +29,13 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/cadvisor/auth"
 	"github.com/google/cadvisor/events"
+	"github.com/google/cadvisor/federation"
 	httpmux "github.com/google/cadvisor/http/mux"
 	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
 	"github.com/google/cadvisor/manager"
-
-	"github.com/golang/glog"
 )
 
 const (
@@ -41,19 +43,96 @@ const (
 )
 
 func RegisterHandlers(mux httpmux.Mux, m manager.Manager) error {
-	apiVersions := getApiVersions()
+	fed, err := federation.New()
+	if err != nil {
+		return err
+	}
+	disabled, err := getDisabledRequestTypes(*argDisableApi)
+	if err != nil {
+		return err
+	}
+	apiVersions := limitApiVersions(restrictApiVersions(cacheApiVersions(getApiVersions(fed), *argResponseCacheSize), disabled), *argMaxExpensiveRequests)
 	supportedApiVersions := make(map[string]ApiVersion, len(apiVersions))
 	for _, v := range apiVersions {
 		supportedApiVersions[v.Version()] = v
 	}
 
-	mux.HandleFunc(apiResource, func(w http.ResponseWriter, r *http.Request) {
+	g, err := getGuards()
+	if err != nil {
+		return err
+	}
+	mux.HandleFunc(apiResource, g.wrap(newApiHandler(g.auth, supportedApiVersions, m)))
+
+	if !disabled[datasourceApi] {
+		datasourceHandler := g.auth.WrapRead(func(w http.ResponseWriter, r *http.Request) {
+			err := handleDatasourceRequest(m, w, r)
+			if err != nil {
+				status := http.StatusInternalServerError
+				if e, ok := err.(*statusError); ok {
+					status = e.status
+				}
+				http.Error(w, err.Error(), status)
+			}
+		})
+		// The datasource shares the rate limits of the API.
+		mux.HandleFunc(datasourceResource, g.wrap(datasourceHandler))
+	}
+	return nil
+}
+
+// Returns a handler serving the API requests of authorized clients.
+func newApiHandler(authHandler *auth.Handler, supportedApiVersions map[string]ApiVersion, m manager.Manager) http.HandlerFunc {
+	return authHandler.WrapRole(func(w http.ResponseWriter, r *http.Request) {
 		err := handleRequest(supportedApiVersions, m, w, r)
 		if err != nil {
-			http.Error(w, err.Error(), 500)
+			status := http.StatusInternalServerError
+			if e, ok := err.(*statusError); ok {
+				status = e.status
+			}
+			http.Error(w, err.Error(), status)
 		}
-	})
-	return nil
+	}, requiredRole)
+}
+
+// Returns the role needed to serve an API request. It depends on the request
+// type rather than the method, as the v1 API and its client POST the queries
+// of containers.
+func requiredRole(r *http.Request) string {
+	requestElements := apiRegexp.FindStringSubmatch(r.URL.Path)
+	if len(requestElements) == 0 {
+		return auth.RoleRead
+	}
+	switch requestElements[apiRequestType] {
+	case signalApi, configApi:
+		return auth.RoleAdmin
+	case snapshotApi, logLevelsApi:
+		// Restoring a snapshot and setting log levels.
+		if r.Method != "GET" && r.Method != "HEAD" {
+			return auth.RoleAdmin
+		}
+	case federateApi:
+		// /federate/<peer>/<version>/<request type>[/<args...>] needs the
+		// role of the request proxied to the peer.
+		parts := strings.SplitN(strings.TrimPrefix(requestElements[apiRequestArgs], "/"), "/", 2)
+		if len(parts) == 2 {
+			proxied := &http.Request{
+				Method: r.Method,
+				URL:    &url.URL{Path: "/api/" + parts[1]},
+			}
+			return requiredRole(proxied)
+		}
+	}
+	return auth.RoleRead
+}
+
+// An error reported with a specific HTTP status code.
+type statusError struct {
+	status int
+	msg    string
+}
+
+func (self *statusError) Error() string {
+	return self.msg
 }
 
 // Captures the API version, requestType [optional], and remaining request [optional].
@@ -68,7 +147,7 @@ const (
 func handleRequest(supportedApiVersions map[string]ApiVersion, m manager.Manager, w http.ResponseWriter, r *http.Request) error {
 	start := time.Now()
 	defer func() {
-		glog.V(4).Infof("Request took %s", time.Since(start))
+		logging.V(4).Infof("Request took %s", time.Since(start))
 	}()
 
 	request := r.URL.Path
@@ -157,7 +236,7 @@ func streamResults(eventChannel *events.EventChannel, w http.ResponseWriter, r *
 		case ev := <-eventChannel.GetChannel():
 			err := enc.Encode(ev)
 			if err != nil {
-				glog.Errorf("error encoding message %+v for result stream: %v", ev, err)
+				logging.Errorf("error encoding message %+v for result stream: %v", ev, err)
 			}
 			flusher.Flush()
 		}
@@ -175,12 +254,33 @@ func getContainerInfoRequest(body io.ReadCloser) (*info.ContainerInfoRequest, er
 	return &query, nil
 }
 
+// Event types by the name of their option in event requests.
+var eventTypes = map[string]info.EventType{
+	"oom_events":                  info.EventOom,
+	"oom_kill_events":             info.EventOomKill,
+	"creation_events":             info.EventContainerCreation,
+	"deletion_events":             info.EventContainerDeletion,
+	"anomaly_events":              info.EventAnomaly,
+	"exit_events":                 info.EventContainerExit,
+	"health_events":               info.EventContainerHealth,
+	"housekeeping_timeout_events": info.EventHousekeepingTimeout,
+	"machine_change_events":       info.EventMachineChange,
+	"thin_pool_events":            info.EventThinPoolExhaustion,
+	"cpuset_events":               info.EventCpusetChange,
+	"limit_events":                info.EventLimitChange,
+	"self_memory_events":          info.EventSelfMemoryPressure,
+}
+
 // The user can set any or none of the following arguments in any order
 // with any twice defined arguments being assigned the first value.
 // If the value type for the argument is wrong the field will be assumed to be
 // unassigned
-// bools: stream, subcontainers, oom_events, creation_events, deletion_events
-// ints: max_events, start_time (unix timestamp), end_time (unix timestamp)
+// bools: stream, subcontainers, all_events, oom_events, oom_kill_events,
+// creation_events, deletion_events, anomaly_events, exit_events, health_events,
+// housekeeping_timeout_events, machine_change_events, thin_pool_events,
+// cpuset_events, limit_events, self_memory_events
+// ints: max_events
+// times: start_time (RFC3339), end_time (RFC3339)
 // example r.URL: http://localhost:8080/api/v1.3/events?oom_events=true&stream=true
 func getEventRequest(r *http.Request) (*events.Request, bool, error) {
 	query := events.NewRequest()
@@ -200,12 +300,6 @@ func getEventRequest(r *http.Request) (*events.Request, bool, error) {
 			query.IncludeSubcontainers = newBool
 		}
 	}
-	eventTypes := map[string]info.EventType{
-		"oom_events":      info.EventOom,
-		"oom_kill_events": info.EventOomKill,
-		"creation_events": info.EventContainerCreation,
-		"deletion_events": info.EventContainerDeletion,
-	}
 	allEventTypes := false
 	if val, ok := urlMap["all_events"]; ok {
 		newBool, err := strconv.ParseBool(val[0])
@@ -0,0 +1,167 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"flag"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/cadvisor/manager"
+)
+
+var argRateLimit = flag.Float64("api_rate_limit", 0, "maximum sustained rate of API requests per second allowed from a single client IP. 0 disables rate limiting")
+var argRateBurst = flag.Int("api_rate_burst", 20, "number of API requests a client can make at once above --api_rate_limit")
+var argMaxExpensiveRequests = flag.Int("api_max_expensive_requests", 0, "maximum number of expensive API requests (recursive stats, processes, filesystems...) served concurrently. 0 means no limit")
+
+// How often the buckets of idle clients are dropped.
+const limiterCleanupInterval = time.Minute
+
+// Token bucket limiting the request rate of every client.
+type clientLimiter struct {
+	rate  float64
+	burst float64
+
+	lock        sync.Mutex
+	buckets     map[string]*bucket
+	lastCleanup time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newClientLimiter(rate float64, burst int) *clientLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &clientLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Returns whether the client may make a request now.
+func (self *clientLimiter) allow(client string, now time.Time) bool {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	if now.Sub(self.lastCleanup) > limiterCleanupInterval {
+		// Full buckets are the same as no bucket.
+		for c, b := range self.buckets {
+			if b.tokens+now.Sub(b.last).Seconds()*self.rate >= self.burst {
+				delete(self.buckets, c)
+			}
+		}
+		self.lastCleanup = now
+	}
+
+	b, ok := self.buckets[client]
+	if !ok {
+		b = &bucket{
+			tokens: self.burst,
+			last:   now,
+		}
+		self.buckets[client] = b
+	}
+	b.tokens += now.Sub(b.last).Seconds() * self.rate
+	if b.tokens > self.burst {
+		b.tokens = self.burst
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Returns the IP the request comes from.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Wraps the handler to reject clients going over the rate limit.
+func (self *clientLimiter) wrap(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !self.allow(clientIP(r), time.Now()) {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// Returns whether serving the request walks many containers or reads from
+// the filesystem. Streams are long lived but cheap and are not expensive.
+// Neither are the requests waiting for changes, which would otherwise hold a
+// slot while idle for up to maxChangesWait.
+func isExpensive(requestType string, r *http.Request) bool {
+	if r.URL.Query().Get("wait") != "" {
+		return false
+	}
+	switch requestType {
+	case psApi, storageApi, topApi, containersApi, subcontainersApi, dockerApi, federateApi, podsApi, imagesApi, snapshotApi, historyApi, exportApi, queryApi, debugApi:
+		return true
+	case streamApi, eventsApi:
+		return false
+	}
+	return r.URL.Query().Get("recursive") == "true"
+}
+
+// An API version serving a bounded number of expensive requests at once.
+type limitedVersion struct {
+	ApiVersion
+	// Holds a token per expensive request in flight.
+	inFlight chan struct{}
+}
+
+func (self *limitedVersion) HandleRequest(requestType string, request []string, m manager.Manager, w http.ResponseWriter, r *http.Request) error {
+	if isExpensive(requestType, r) {
+		select {
+		case self.inFlight <- struct{}{}:
+			defer func() { <-self.inFlight }()
+		default:
+			w.Header().Set("Retry-After", "1")
+			return &statusError{
+				status: http.StatusServiceUnavailable,
+				msg:    "too many expensive requests in flight",
+			}
+		}
+	}
+	return self.ApiVersion.HandleRequest(requestType, request, m, w, r)
+}
+
+// Bounds the number of expensive requests in flight across all API versions.
+func limitApiVersions(versions []ApiVersion, maxExpensive int) []ApiVersion {
+	if maxExpensive <= 0 {
+		return versions
+	}
+	inFlight := make(chan struct{}, maxExpensive)
+	limited := make([]ApiVersion, 0, len(versions))
+	for _, v := range versions {
+		limited = append(limited, &limitedVersion{v, inFlight})
+	}
+	return limited
+}
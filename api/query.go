@@ -0,0 +1,421 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/manager"
+)
+
+// Instant selectors return the latest stats of the containers within this
+// window, like Prometheus.
+const queryLookback = 5 * time.Minute
+
+// A metric of the query language, read from the stats of the containers
+// having the resource.
+type queryMetric struct {
+	has func(spec *info.ContainerSpec) bool
+	// Subsystem whose stats the value is read from, empty for the cgroup
+	// stats. Stats where it could not be collected are skipped.
+	subsystem string
+	value     func(stats *info.ContainerStats) float64
+}
+
+func hasCpu(spec *info.ContainerSpec) bool        { return spec.HasCpu }
+func hasMemory(spec *info.ContainerSpec) bool     { return spec.HasMemory }
+func hasNetwork(spec *info.ContainerSpec) bool    { return spec.HasNetwork }
+func hasFilesystem(spec *info.ContainerSpec) bool { return spec.HasFilesystem }
+
+var queryMetrics = map[string]queryMetric{
+	// Counters, in seconds and bytes.
+	"cpu_usage_total": {
+		has:   hasCpu,
+		value: func(stats *info.ContainerStats) float64 { return float64(stats.Cpu.Usage.Total) / float64(time.Second) },
+	},
+	"cpu_usage_user": {
+		has:   hasCpu,
+		value: func(stats *info.ContainerStats) float64 { return float64(stats.Cpu.Usage.User) / float64(time.Second) },
+	},
+	"cpu_usage_system": {
+		has: hasCpu,
+		value: func(stats *info.ContainerStats) float64 {
+			return float64(stats.Cpu.Usage.System) / float64(time.Second)
+		},
+	},
+	"cpu_throttled": {
+		has: hasCpu,
+		value: func(stats *info.ContainerStats) float64 {
+			return float64(stats.Cpu.Usage.Throttled) / float64(time.Second)
+		},
+	},
+	"network_rx_bytes": {
+		has:       hasNetwork,
+		subsystem: info.NetworkStatsSubsystem,
+		value:     func(stats *info.ContainerStats) float64 { return float64(stats.Network.RxBytes) },
+	},
+	"network_tx_bytes": {
+		has:       hasNetwork,
+		subsystem: info.NetworkStatsSubsystem,
+		value:     func(stats *info.ContainerStats) float64 { return float64(stats.Network.TxBytes) },
+	},
+	// Gauges.
+	"memory_usage": {
+		has:   hasMemory,
+		value: func(stats *info.ContainerStats) float64 { return float64(stats.Memory.Usage) },
+	},
+	"memory_working_set": {
+		has:   hasMemory,
+		value: func(stats *info.ContainerStats) float64 { return float64(stats.Memory.WorkingSet) },
+	},
+	"memory_rss": {
+		has:   hasMemory,
+		value: func(stats *info.ContainerStats) float64 { return float64(stats.Memory.RSS) },
+	},
+	"fs_usage": {
+		has:       hasFilesystem,
+		subsystem: info.FilesystemStatsSubsystem,
+		value: func(stats *info.ContainerStats) float64 {
+			var usage uint64
+			for _, fs := range stats.Filesystem {
+				usage += fs.Usage
+			}
+			return float64(usage)
+		},
+	},
+	"load_average": {
+		has:   hasCpu,
+		value: func(stats *info.ContainerStats) float64 { return float64(stats.Cpu.LoadAverage) / 1000 },
+	},
+}
+
+// A value of the result of a query.
+type querySample struct {
+	Labels map[string]string `json:"labels"`
+	Value  float64           `json:"value"`
+}
+
+type queryResult struct {
+	// Time at which the query was evaluated.
+	Timestamp time.Time     `json:"timestamp"`
+	Samples   []querySample `json:"samples"`
+}
+
+// The containers and their stats a query is evaluated against.
+type queryContext struct {
+	containers []*info.ContainerInfo
+	now        time.Time
+}
+
+type queryExpr interface {
+	// Returns the longest window the expression reads stats over.
+	window() time.Duration
+	eval(ctx *queryContext) []querySample
+}
+
+// Evaluates the expression against the stats cached in memory.
+func handleQueryRequest(m manager.Manager, w http.ResponseWriter, r *http.Request) error {
+	expr, err := parseQuery(r.URL.Query().Get("expr"))
+	if err != nil {
+		return &statusError{
+			status: http.StatusBadRequest,
+			msg:    err.Error(),
+		}
+	}
+	now := time.Now()
+	containers, err := m.SubcontainersInfo("/", &info.ContainerInfoRequest{
+		NumStats: -1,
+		Start:    now.Add(-expr.window()),
+		End:      now,
+	})
+	if err != nil {
+		return err
+	}
+	samples := expr.eval(&queryContext{containers: containers, now: now})
+	sort.Sort(querySamplesByLabels(samples))
+	return writeResult(queryResult{Timestamp: now, Samples: samples}, w)
+}
+
+// Label matchers of selectors.
+type queryMatcher struct {
+	label string
+	op    string
+	value string
+	re    *regexp.Regexp
+}
+
+func (self *queryMatcher) matches(labels map[string]string) bool {
+	value := labels[self.label]
+	switch self.op {
+	case "=":
+		return value == self.value
+	case "!=":
+		return value != self.value
+	case "=~":
+		return self.re.MatchString(value)
+	default:
+		return !self.re.MatchString(value)
+	}
+}
+
+// Labels of the values of the selectors.
+func queryLabels(cont *info.ContainerInfo) map[string]string {
+	name := cont.Name
+	if len(cont.Aliases) > 0 {
+		name = cont.Aliases[0]
+	}
+	return map[string]string{
+		"container": cont.Name,
+		"name":      name,
+		"image":     cont.Spec.Image,
+		"namespace": cont.Namespace,
+	}
+}
+
+// Selects a metric of the containers matching all the matchers: its latest
+// value, or its values within the range for the functions.
+type querySelector struct {
+	name     string
+	metric   queryMetric
+	matchers []*queryMatcher
+	// Zero for instant selectors.
+	rng time.Duration
+}
+
+func (self *querySelector) window() time.Duration {
+	if self.rng > queryLookback {
+		return self.rng
+	}
+	return queryLookback
+}
+
+// A series of values of a container, oldest first.
+type querySeries struct {
+	labels map[string]string
+	values []float64
+	stats  []*info.ContainerStats
+}
+
+func (self *querySelector) series(ctx *queryContext, window time.Duration) []querySeries {
+	var selected []querySeries
+	start := ctx.now.Add(-window)
+	for _, cont := range ctx.containers {
+		if !self.metric.has(&cont.Spec) {
+			continue
+		}
+		labels := queryLabels(cont)
+		matches := true
+		for _, matcher := range self.matchers {
+			matches = matches && matcher.matches(labels)
+		}
+		if !matches {
+			continue
+		}
+		series := querySeries{labels: labels}
+		for _, stats := range cont.Stats {
+			if !stats.Timestamp.After(start) || stats.Timestamp.After(ctx.now) {
+				continue
+			}
+			if self.metric.subsystem != "" && !stats.Valid(self.metric.subsystem) {
+				continue
+			}
+			series.values = append(series.values, self.metric.value(stats))
+			series.stats = append(series.stats, stats)
+		}
+		if len(series.values) > 0 {
+			selected = append(selected, series)
+		}
+	}
+	return selected
+}
+
+func (self *querySelector) eval(ctx *queryContext) []querySample {
+	var samples []querySample
+	for _, series := range self.series(ctx, queryLookback) {
+		samples = append(samples, querySample{
+			Labels: series.labels,
+			Value:  series.values[len(series.values)-1],
+		})
+	}
+	return samples
+}
+
+// Functions of the values of a range selector.
+var queryFunctions = map[string]func(series *querySeries) (float64, bool){
+	"rate": func(series *querySeries) (float64, bool) {
+		increase, ok := counterIncrease(series)
+		if !ok {
+			return 0, false
+		}
+		elapsed := series.stats[len(series.stats)-1].Since(series.stats[0]).Seconds()
+		if elapsed <= 0 {
+			return 0, false
+		}
+		return increase / elapsed, true
+	},
+	"increase": counterIncrease,
+	"avg_over_time": func(series *querySeries) (float64, bool) {
+		var sum float64
+		for _, value := range series.values {
+			sum += value
+		}
+		return sum / float64(len(series.values)), true
+	},
+	"min_over_time": func(series *querySeries) (float64, bool) {
+		min := math.Inf(1)
+		for _, value := range series.values {
+			min = math.Min(min, value)
+		}
+		return min, true
+	},
+	"max_over_time": func(series *querySeries) (float64, bool) {
+		max := math.Inf(-1)
+		for _, value := range series.values {
+			max = math.Max(max, value)
+		}
+		return max, true
+	},
+}
+
+// Returns the increase of a counter between the first and last values of the
+// series, counting the value after a reset as an increase from zero.
+func counterIncrease(series *querySeries) (float64, bool) {
+	if len(series.values) < 2 {
+		return 0, false
+	}
+	var increase float64
+	for i := 1; i < len(series.values); i++ {
+		if delta := series.values[i] - series.values[i-1]; delta >= 0 {
+			increase += delta
+		} else {
+			increase += series.values[i]
+		}
+	}
+	return increase, true
+}
+
+type queryCall struct {
+	function string
+	arg      *querySelector
+}
+
+func (self *queryCall) window() time.Duration {
+	return self.arg.window()
+}
+
+func (self *queryCall) eval(ctx *queryContext) []querySample {
+	var samples []querySample
+	for _, series := range self.arg.series(ctx, self.arg.rng) {
+		if value, ok := queryFunctions[self.function](&series); ok {
+			samples = append(samples, querySample{Labels: series.labels, Value: value})
+		}
+	}
+	return samples
+}
+
+// Aggregations of the values with the same "by" labels.
+var queryAggregations = map[string]func(values []float64) float64{
+	"sum": func(values []float64) float64 {
+		var sum float64
+		for _, value := range values {
+			sum += value
+		}
+		return sum
+	},
+	"avg": func(values []float64) float64 {
+		var sum float64
+		for _, value := range values {
+			sum += value
+		}
+		return sum / float64(len(values))
+	},
+	"min": func(values []float64) float64 {
+		min := math.Inf(1)
+		for _, value := range values {
+			min = math.Min(min, value)
+		}
+		return min
+	},
+	"max": func(values []float64) float64 {
+		max := math.Inf(-1)
+		for _, value := range values {
+			max = math.Max(max, value)
+		}
+		return max
+	},
+	"count": func(values []float64) float64 {
+		return float64(len(values))
+	},
+}
+
+type queryAggregate struct {
+	op   string
+	by   []string
+	expr queryExpr
+}
+
+func (self *queryAggregate) window() time.Duration {
+	return self.expr.window()
+}
+
+func (self *queryAggregate) eval(ctx *queryContext) []querySample {
+	groups := make(map[string][]float64)
+	groupLabels := make(map[string]map[string]string)
+	for _, sample := range self.expr.eval(ctx) {
+		labels := make(map[string]string, len(self.by))
+		for _, label := range self.by {
+			labels[label] = sample.Labels[label]
+		}
+		key := labelsKey(labels)
+		groups[key] = append(groups[key], sample.Value)
+		groupLabels[key] = labels
+	}
+	samples := make([]querySample, 0, len(groups))
+	for key, values := range groups {
+		samples = append(samples, querySample{
+			Labels: groupLabels[key],
+			Value:  queryAggregations[self.op](values),
+		})
+	}
+	return samples
+}
+
+func labelsKey(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	pairs := make([]string, 0, len(names))
+	for _, name := range names {
+		pairs = append(pairs, name+"="+strconv.Quote(labels[name]))
+	}
+	return strings.Join(pairs, ",")
+}
+
+type querySamplesByLabels []querySample
+
+func (s querySamplesByLabels) Len() int      { return len(s) }
+func (s querySamplesByLabels) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s querySamplesByLabels) Less(i, j int) bool {
+	return labelsKey(s[i].Labels) < labelsKey(s[j].Labels)
+}
@@ -0,0 +1,347 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type queryTokenKind int
+
+const (
+	queryEOF queryTokenKind = iota
+	queryIdent
+	queryString
+	queryPunct
+)
+
+type queryToken struct {
+	kind queryTokenKind
+	text string
+	pos  int
+}
+
+func (self queryToken) String() string {
+	switch self.kind {
+	case queryEOF:
+		return "end of expression"
+	case queryString:
+		return strconv.Quote(self.text)
+	default:
+		return fmt.Sprintf("%q", self.text)
+	}
+}
+
+func isQueryIdentRune(r rune) bool {
+	return r == '_' || r == ':' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// Splits the expression in identifiers (which include numbers and
+// durations), quoted strings and punctuation.
+func lexQuery(expr string) ([]queryToken, error) {
+	var tokens []queryToken
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case isQueryIdentRune(r):
+			start := i
+			for i < len(runes) && isQueryIdentRune(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, queryToken{queryIdent, string(runes[start:i]), start})
+		case r == '"' || r == '\'':
+			start := i
+			for i++; i < len(runes) && runes[i] != r; i++ {
+				if runes[i] == '\\' {
+					i++
+				}
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			i++
+			quoted := string(runes[start:i])
+			if r == '\'' {
+				quoted = strconv.Quote(strings.Replace(quoted[1:len(quoted)-1], `\'`, `'`, -1))
+			}
+			value, err := strconv.Unquote(quoted)
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at position %d: %v", start, err)
+			}
+			tokens = append(tokens, queryToken{queryString, value, start})
+		case strings.ContainsRune("(){}[],", r):
+			tokens = append(tokens, queryToken{queryPunct, string(r), i})
+			i++
+		case r == '=' || r == '!':
+			start := i
+			i++
+			if i < len(runes) && (runes[i] == '=' || runes[i] == '~') {
+				i++
+			}
+			op := string(runes[start:i])
+			if op == "!" || op == "==" {
+				return nil, fmt.Errorf("unexpected %q at position %d", op, start)
+			}
+			tokens = append(tokens, queryToken{queryPunct, op, start})
+		default:
+			return nil, fmt.Errorf("unexpected %q at position %d", r, i)
+		}
+	}
+	return append(tokens, queryToken{queryEOF, "", len(runes)}), nil
+}
+
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+// Parses an expression of the query language:
+//
+//	expr      = unary [ "by" labels ]
+//	unary     = aggregation [ "by" labels ] "(" expr ")" [ "by" labels ]
+//	          | function "(" selector ")"
+//	          | selector
+//	selector  = metric [ "{" matcher { "," matcher } "}" ] [ "[" duration "]" ]
+//	matcher   = label ( "=" | "!=" | "=~" | "!~" ) string
+//	labels    = "(" [ label { "," label } ] ")"
+//
+// A "by" clause following an expression which is not an aggregation sums it.
+func parseQuery(expr string) (queryExpr, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, fmt.Errorf("missing query expression")
+	}
+	tokens, err := lexQuery(expr)
+	if err != nil {
+		return nil, err
+	}
+	parser := &queryParser{tokens: tokens}
+	parsed, err := parser.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := parser.peek(); tok.kind != queryEOF {
+		return nil, fmt.Errorf("unexpected %v at position %d", tok, tok.pos)
+	}
+	return parsed, nil
+}
+
+func (self *queryParser) peek() queryToken {
+	return self.tokens[self.pos]
+}
+
+func (self *queryParser) next() queryToken {
+	tok := self.tokens[self.pos]
+	if tok.kind != queryEOF {
+		self.pos++
+	}
+	return tok
+}
+
+// Consumes the next token if it is the given identifier or punctuation.
+func (self *queryParser) accept(text string) bool {
+	if tok := self.peek(); (tok.kind == queryIdent || tok.kind == queryPunct) && tok.text == text {
+		self.pos++
+		return true
+	}
+	return false
+}
+
+func (self *queryParser) expect(text string) error {
+	if !self.accept(text) {
+		tok := self.peek()
+		return fmt.Errorf("expected %q at position %d, found %v", text, tok.pos, tok)
+	}
+	return nil
+}
+
+func (self *queryParser) expectKind(kind queryTokenKind, what string) (queryToken, error) {
+	tok := self.next()
+	if tok.kind != kind {
+		return tok, fmt.Errorf("expected %s at position %d, found %v", what, tok.pos, tok)
+	}
+	return tok, nil
+}
+
+func (self *queryParser) parseExpr() (queryExpr, error) {
+	expr, err := self.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	if self.accept("by") {
+		by, err := self.parseLabels()
+		if err != nil {
+			return nil, err
+		}
+		return &queryAggregate{op: "sum", by: by, expr: expr}, nil
+	}
+	return expr, nil
+}
+
+func (self *queryParser) parseUnary() (queryExpr, error) {
+	tok, err := self.expectKind(queryIdent, "metric, function or aggregation")
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := queryAggregations[tok.text]; ok {
+		aggregate := &queryAggregate{op: tok.text}
+		hasBy := self.accept("by")
+		if hasBy {
+			if aggregate.by, err = self.parseLabels(); err != nil {
+				return nil, err
+			}
+		}
+		if err := self.expect("("); err != nil {
+			return nil, err
+		}
+		if aggregate.expr, err = self.parseExpr(); err != nil {
+			return nil, err
+		}
+		if err := self.expect(")"); err != nil {
+			return nil, err
+		}
+		if !hasBy && self.accept("by") {
+			if aggregate.by, err = self.parseLabels(); err != nil {
+				return nil, err
+			}
+		}
+		return aggregate, nil
+	}
+	if _, ok := queryFunctions[tok.text]; ok && self.peek().text == "(" {
+		self.next()
+		name, err := self.expectKind(queryIdent, "metric")
+		if err != nil {
+			return nil, err
+		}
+		arg, err := self.parseSelector(name)
+		if err != nil {
+			return nil, err
+		}
+		if arg.rng == 0 {
+			return nil, fmt.Errorf("%s expects a range selector, such as %s[1m]", tok.text, arg.name)
+		}
+		if err := self.expect(")"); err != nil {
+			return nil, err
+		}
+		return &queryCall{function: tok.text, arg: arg}, nil
+	}
+	selector, err := self.parseSelector(tok)
+	if err != nil {
+		return nil, err
+	}
+	if selector.rng != 0 {
+		return nil, fmt.Errorf("range selector %s[%v] must be the argument of a function", selector.name, selector.rng)
+	}
+	return selector, nil
+}
+
+func (self *queryParser) parseSelector(name queryToken) (*querySelector, error) {
+	metric, ok := queryMetrics[name.text]
+	if !ok {
+		return nil, fmt.Errorf("unknown metric %q at position %d, known metrics are %s", name.text, name.pos, strings.Join(queryMetricNames(), ", "))
+	}
+	selector := &querySelector{name: name.text, metric: metric}
+	if self.accept("{") {
+		for !self.accept("}") {
+			if len(selector.matchers) > 0 {
+				if err := self.expect(","); err != nil {
+					return nil, err
+				}
+			}
+			matcher, err := self.parseMatcher()
+			if err != nil {
+				return nil, err
+			}
+			selector.matchers = append(selector.matchers, matcher)
+		}
+	}
+	if self.accept("[") {
+		tok, err := self.expectKind(queryIdent, "duration")
+		if err != nil {
+			return nil, err
+		}
+		selector.rng, err = time.ParseDuration(tok.text)
+		if err != nil || selector.rng <= 0 {
+			return nil, fmt.Errorf("invalid duration %q at position %d", tok.text, tok.pos)
+		}
+		if err := self.expect("]"); err != nil {
+			return nil, err
+		}
+	}
+	return selector, nil
+}
+
+func (self *queryParser) parseMatcher() (*queryMatcher, error) {
+	label, err := self.expectKind(queryIdent, "label")
+	if err != nil {
+		return nil, err
+	}
+	op, err := self.expectKind(queryPunct, "label matcher")
+	if err != nil {
+		return nil, err
+	}
+	if op.text != "=" && op.text != "!=" && op.text != "=~" && op.text != "!~" {
+		return nil, fmt.Errorf("expected label matcher at position %d, found %v", op.pos, op)
+	}
+	value, err := self.expectKind(queryString, "string")
+	if err != nil {
+		return nil, err
+	}
+	matcher := &queryMatcher{label: label.text, op: op.text, value: value.text}
+	if op.text == "=~" || op.text == "!~" {
+		// Regular expressions match whole label values.
+		if matcher.re, err = regexp.Compile("^(?:" + value.text + ")$"); err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q at position %d: %v", value.text, value.pos, err)
+		}
+	}
+	return matcher, nil
+}
+
+func (self *queryParser) parseLabels() ([]string, error) {
+	if err := self.expect("("); err != nil {
+		return nil, err
+	}
+	var labels []string
+	for !self.accept(")") {
+		if len(labels) > 0 {
+			if err := self.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		label, err := self.expectKind(queryIdent, "label")
+		if err != nil {
+			return nil, err
+		}
+		labels = append(labels, label.text)
+	}
+	return labels, nil
+}
+
+func queryMetricNames() []string {
+	names := make([]string, 0, len(queryMetrics))
+	for name := range queryMetrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
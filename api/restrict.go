@@ -0,0 +1,110 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/google/cadvisor/manager"
+)
+
+var argDisableApi = flag.String("disable_api", "", "comma separated list of API groups to disable. Groups are: "+strings.Join(apiGroupNames(), ", "))
+
+// Request types of every API group that can be disabled.
+var apiGroups = map[string][]string{
+	"attributes": {attributesApi},
+	"config":     {configApi, logLevelsApi},
+	"datasource": {datasourceApi},
+	"debug":      {debugApi},
+	"events":     {eventsApi},
+	"federate":   {federateApi},
+	"machine":    {machineApi},
+	"metrics":    {customMetricsApi},
+	"processes":  {psApi, signalApi},
+	"self":       {selfApi},
+	"snapshot":   {snapshotApi},
+	"spec":       {specApi, containersApi},
+	"stats":      {hostStatsApi, historyApi, exportApi, queryApi, statsApi, subcontainersApi, dockerApi, machineStatsApi, summaryApi, streamApi, topApi, predictApi, recommendApi, podsApi},
+	"storage":    {storageApi, imagesApi},
+}
+
+func apiGroupNames() []string {
+	names := make([]string, 0, len(apiGroups))
+	for name := range apiGroups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Returns the request types of the comma separated API groups.
+func getDisabledRequestTypes(groups string) (map[string]bool, error) {
+	disabled := make(map[string]bool)
+	if groups == "" {
+		return disabled, nil
+	}
+	for _, group := range strings.Split(groups, ",") {
+		requestTypes, ok := apiGroups[strings.TrimSpace(group)]
+		if !ok {
+			return nil, fmt.Errorf("unknown API group %q, supported groups are %s", group, strings.Join(apiGroupNames(), ","))
+		}
+		for _, requestType := range requestTypes {
+			disabled[requestType] = true
+		}
+	}
+	return disabled, nil
+}
+
+// An API version without the disabled request types.
+type restrictedVersion struct {
+	ApiVersion
+	disabled map[string]bool
+}
+
+func (self *restrictedVersion) SupportedRequestTypes() []string {
+	var supported []string
+	for _, requestType := range self.ApiVersion.SupportedRequestTypes() {
+		if !self.disabled[requestType] {
+			supported = append(supported, requestType)
+		}
+	}
+	return supported
+}
+
+func (self *restrictedVersion) HandleRequest(requestType string, request []string, m manager.Manager, w http.ResponseWriter, r *http.Request) error {
+	if self.disabled[requestType] {
+		return &statusError{
+			status: http.StatusForbidden,
+			msg:    fmt.Sprintf("request type %q is disabled", requestType),
+		}
+	}
+	return self.ApiVersion.HandleRequest(requestType, request, m, w, r)
+}
+
+// Restricts all the API versions if any request type is disabled.
+func restrictApiVersions(versions []ApiVersion, disabled map[string]bool) []ApiVersion {
+	if len(disabled) == 0 {
+		return versions
+	}
+	restricted := make([]ApiVersion, 0, len(versions))
+	for _, v := range versions {
+		restricted = append(restricted, &restrictedVersion{v, disabled})
+	}
+	return restricted
+}
@@ -0,0 +1,135 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/google/cadvisor/audit"
+	"github.com/google/cadvisor/auth"
+	"github.com/google/cadvisor/manager"
+	"github.com/google/cadvisor/rpc"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// Authentication, rate limits and audit log of the API. They are shared with
+// the gRPC server so that a client has a single rate limit and the audit log
+// is opened once.
+type guards struct {
+	auth    *auth.Handler
+	limiter *clientLimiter
+	audit   *audit.Logger
+}
+
+var (
+	guardsOnce   sync.Once
+	sharedGuards *guards
+	guardsErr    error
+)
+
+func getGuards() (*guards, error) {
+	guardsOnce.Do(func() {
+		sharedGuards, guardsErr = newGuards()
+	})
+	return sharedGuards, guardsErr
+}
+
+func newGuards() (*guards, error) {
+	authHandler, err := auth.New()
+	if err != nil {
+		return nil, err
+	}
+	if *manager.EnableProcessSignals && !authHandler.Enabled() {
+		return nil, fmt.Errorf("--enable_process_signals requires API authentication, e.g. --api_token_file")
+	}
+	var limiter *clientLimiter
+	if *argRateLimit > 0 {
+		limiter = newClientLimiter(*argRateLimit, *argRateBurst)
+	}
+	auditLogger, err := audit.New()
+	if err != nil {
+		return nil, err
+	}
+	return &guards{
+		auth:    authHandler,
+		limiter: limiter,
+		audit:   auditLogger,
+	}, nil
+}
+
+// Returns h behind the rate limits and the audit log.
+func (self *guards) wrap(h http.HandlerFunc) http.HandlerFunc {
+	if self.limiter != nil {
+		h = self.limiter.wrap(h)
+	}
+	return self.audit.Wrap(h)
+}
+
+// NewRpcGuard returns a guard applying the authentication, rate limits and
+// audit log of the API to the RPCs, which all require the read role.
+func NewRpcGuard() (rpc.Guard, error) {
+	g, err := getGuards()
+	if err != nil {
+		return nil, err
+	}
+	h := g.wrap(g.auth.WrapRead(func(http.ResponseWriter, *http.Request) {}))
+	return func(r *http.Request) error {
+		w := &guardResponse{header: make(http.Header)}
+		h(w, r)
+		msg := strings.TrimSpace(w.body.String())
+		switch w.status {
+		case 0, http.StatusOK:
+			return nil
+		case http.StatusUnauthorized:
+			return grpc.Errorf(codes.Unauthenticated, "%s", msg)
+		case http.StatusForbidden:
+			return grpc.Errorf(codes.PermissionDenied, "%s", msg)
+		case http.StatusTooManyRequests:
+			return grpc.Errorf(codes.ResourceExhausted, "%s", msg)
+		default:
+			return grpc.Errorf(codes.Unknown, "%s", msg)
+		}
+	}, nil
+}
+
+// Records the response of the guards to an RPC.
+type guardResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (self *guardResponse) Header() http.Header {
+	return self.header
+}
+
+func (self *guardResponse) WriteHeader(status int) {
+	if self.status == 0 {
+		self.status = status
+	}
+}
+
+func (self *guardResponse) Write(b []byte) (int, error) {
+	if self.status == 0 {
+		self.status = http.StatusOK
+	}
+	return self.body.Write(b)
+}
@@ -0,0 +1,110 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/manager"
+)
+
+// Criteria a container must all meet to be returned by a container search.
+type containerFilter struct {
+	// Required labels. An empty value only requires the label to be set.
+	labels map[string]string
+	// Glob pattern the image must match.
+	image string
+	// Pattern the name or one of the aliases must match.
+	name *regexp.Regexp
+}
+
+// Parses the "label", "image" and "name" query parameters. "label" may be
+// repeated and is either "key=value" or "key".
+func getContainerFilter(r *http.Request) (*containerFilter, error) {
+	query := r.URL.Query()
+	filter := &containerFilter{
+		labels: make(map[string]string),
+		image:  query.Get("image"),
+	}
+	for _, label := range query["label"] {
+		parts := strings.SplitN(label, "=", 2)
+		if parts[0] == "" {
+			return nil, fmt.Errorf("invalid 'label' option: %q", label)
+		}
+		if len(parts) == 2 {
+			filter.labels[parts[0]] = parts[1]
+		} else {
+			filter.labels[parts[0]] = ""
+		}
+	}
+	if filter.image != "" {
+		if _, err := path.Match(filter.image, ""); err != nil {
+			return nil, fmt.Errorf("invalid 'image' pattern %q: %v", filter.image, err)
+		}
+	}
+	if name := query.Get("name"); name != "" {
+		re, err := regexp.Compile(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid 'name' pattern %q: %v", name, err)
+		}
+		filter.name = re
+	}
+	return filter, nil
+}
+
+func (self *containerFilter) matches(name string, spec *v2.ContainerSpec) bool {
+	for key, value := range self.labels {
+		actual, ok := spec.Labels[key]
+		if !ok || (value != "" && actual != value) {
+			return false
+		}
+	}
+	if self.image != "" {
+		if ok, _ := path.Match(self.image, spec.Image); !ok {
+			return false
+		}
+	}
+	if self.name != nil {
+		if self.name.MatchString(name) {
+			return true
+		}
+		for _, alias := range spec.Aliases {
+			if self.name.MatchString(alias) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Returns the specs of the requested containers that match the filter.
+func searchContainers(name string, opt v2.RequestOptions, filter *containerFilter, m manager.Manager) (map[string]v2.ContainerSpec, error) {
+	specs, err := m.GetContainerSpec(name, opt)
+	if err != nil {
+		return nil, err
+	}
+	for name, spec := range specs {
+		if !filter.matches(name, &spec) {
+			delete(specs, name)
+		}
+	}
+	return specs, nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/cadvisor/manager"
+)
+
+// Header of the revision of the stats or specs a response is at, to pass as
+// the "since" option of the next request.
+const revisionHeader = "X-Cadvisor-Revision"
+
+// Longest time a request waits for changes with the "wait" option.
+const maxChangesWait = time.Minute
+
+// How often waiting requests check for changes. The revisions are cheap to
+// read.
+var changesPollInterval = 250 * time.Millisecond
+
+// Returns the "since" and "wait" options of the request. Only requests with
+// a "since" revision can wait.
+func getSinceOptions(r *http.Request) (uint64, time.Duration, error) {
+	var since uint64
+	var wait time.Duration
+	if value := r.URL.Query().Get("since"); value != "" {
+		var err error
+		if since, err = strconv.ParseUint(value, 10, 64); err != nil {
+			return 0, 0, &statusError{
+				status: http.StatusBadRequest,
+				msg:    fmt.Sprintf("failed to parse 'since' option: %v", value),
+			}
+		}
+	}
+	if value := r.URL.Query().Get("wait"); value != "" {
+		var err error
+		if wait, err = time.ParseDuration(value); err != nil || wait < 0 {
+			return 0, 0, &statusError{
+				status: http.StatusBadRequest,
+				msg:    fmt.Sprintf("failed to parse 'wait' option: %v", value),
+			}
+		}
+		if since == 0 {
+			return 0, 0, &statusError{
+				status: http.StatusBadRequest,
+				msg:    "the 'wait' option requires the 'since' option",
+			}
+		}
+		if wait > maxChangesWait {
+			wait = maxChangesWait
+		}
+	}
+	return since, wait, nil
+}
+
+// Calls fetch until it finds changes, at most for the wait, and sets the
+// revision of the specs, or of the stats, the changes were found at in the
+// revision header of the response.
+func waitForChanges(m manager.Manager, specs bool, wait time.Duration, w http.ResponseWriter, fetch func() (changed bool, err error)) error {
+	revision := func() uint64 {
+		specsRevision, statsRevision := m.Revisions()
+		if specs {
+			return specsRevision
+		}
+		return statsRevision
+	}
+	var closed <-chan bool
+	if cn, ok := w.(http.CloseNotifier); ok {
+		closed = cn.CloseNotify()
+	}
+	deadline := time.Now().Add(wait)
+	ticker := time.NewTicker(changesPollInterval)
+	defer ticker.Stop()
+	for {
+		// Read before fetching, so that the changes found later are newer.
+		fetched := revision()
+		changed, err := fetch()
+		if err != nil {
+			return err
+		}
+		w.Header().Set(revisionHeader, strconv.FormatUint(fetched, 10))
+		if changed {
+			return nil
+		}
+		for revision() == fetched {
+			if !time.Now().Before(deadline) {
+				return nil
+			}
+			select {
+			case <-ticker.C:
+			case <-closed:
+				return nil
+			}
+		}
+	}
+}
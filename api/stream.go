@@ -0,0 +1,220 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/logging"
+	"github.com/google/cadvisor/manager"
+	"github.com/google/cadvisor/utils/websocket"
+)
+
+// A stats sample pushed to stream subscribers.
+type streamedStats struct {
+	// Absolute name of the container the sample belongs to.
+	Name  string             `json:"name"`
+	Stats *v2.ContainerStats `json:"stats"`
+}
+
+// Set of stats kinds a stream subscriber asked for. A nil set selects everything.
+type streamMetrics map[string]bool
+
+var supportedStreamMetrics = []string{"cpu", "memory", "network", "filesystem", "diskio", "load", "custom"}
+
+// Parses the comma separated "metrics" query parameter.
+func getStreamMetrics(r *http.Request) (streamMetrics, error) {
+	value := r.URL.Query().Get("metrics")
+	if value == "" {
+		return nil, nil
+	}
+	supported := make(map[string]bool, len(supportedStreamMetrics))
+	for _, m := range supportedStreamMetrics {
+		supported[m] = true
+	}
+	metrics := make(streamMetrics)
+	for _, m := range strings.Split(value, ",") {
+		if !supported[m] {
+			return nil, fmt.Errorf("unknown metric %q, supported metrics are %s", m, strings.Join(supportedStreamMetrics, ","))
+		}
+		metrics[m] = true
+	}
+	return metrics, nil
+}
+
+func (self streamMetrics) has(metric string) bool {
+	return self == nil || self[metric]
+}
+
+// Restricts the spec to the stats kinds the subscriber asked for.
+func (self streamMetrics) filterSpec(spec info.ContainerSpec) info.ContainerSpec {
+	spec.HasCpu = spec.HasCpu && self.has("cpu")
+	spec.HasMemory = spec.HasMemory && self.has("memory")
+	spec.HasNetwork = spec.HasNetwork && self.has("network")
+	spec.HasFilesystem = spec.HasFilesystem && self.has("filesystem")
+	spec.HasDiskIo = spec.HasDiskIo && self.has("diskio")
+	spec.HasCustomMetrics = spec.HasCustomMetrics && self.has("custom")
+	return spec
+}
+
+// Converts raw samples into the v2 representation, remembering the previous
+// sample of every container to compute instantaneous cpu usage.
+type streamConverter struct {
+	m       manager.Manager
+	metrics streamMetrics
+	specs   map[string]info.ContainerSpec
+	last    map[string]*info.ContainerStats
+}
+
+func newStreamConverter(m manager.Manager, metrics streamMetrics) *streamConverter {
+	return &streamConverter{
+		m:       m,
+		metrics: metrics,
+		specs:   make(map[string]info.ContainerSpec),
+		last:    make(map[string]*info.ContainerStats),
+	}
+}
+
+func (self *streamConverter) convert(ref info.ContainerReference, stats *info.ContainerStats) (*streamedStats, error) {
+	spec, ok := self.specs[ref.Name]
+	if !ok {
+		cinfo, err := self.m.GetContainerInfo(ref.Name, &info.ContainerInfoRequest{NumStats: 0})
+		if err != nil {
+			return nil, err
+		}
+		spec = self.metrics.filterSpec(cinfo.Spec)
+		self.specs[ref.Name] = spec
+	}
+
+	samples := []*info.ContainerStats{stats}
+	if last, ok := self.last[ref.Name]; ok {
+		samples = []*info.ContainerStats{last, stats}
+	}
+	self.last[ref.Name] = stats
+	converted := v2.ContainerStatsFromV1(&spec, samples)
+	out := converted[len(converted)-1]
+	if self.metrics.has("load") {
+		load := stats.TaskStats
+		out.Load = &load
+	}
+	return &streamedStats{
+		Name:  ref.Name,
+		Stats: out,
+	}, nil
+}
+
+// Streams every new stats sample of the requested containers, over a
+// WebSocket if the client asked for one or as Server-Sent Events otherwise.
+func handleStreamRequest(request []string, m manager.Manager, w http.ResponseWriter, r *http.Request) error {
+	opt, err := getRequestOptions(r)
+	if err != nil {
+		return err
+	}
+	metrics, err := getStreamMetrics(r)
+	if err != nil {
+		return err
+	}
+	name := getContainerName(request)
+	logging.V(4).Infof("Api - Stream: streaming stats for container %q, options %+v", name, opt)
+
+	statsChannel, err := m.WatchForStats(name, opt)
+	if err != nil {
+		return err
+	}
+	defer m.CloseStatsChannel(statsChannel.GetWatchId())
+
+	var send func([]byte) error
+	var done <-chan struct{}
+	if websocket.IsWebSocketRequest(r) {
+		conn, err := websocket.Upgrade(w, r)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		send = conn.WriteText
+		done = conn.Done()
+	} else {
+		send, done, err = startEventStream(w)
+		if err != nil {
+			return err
+		}
+	}
+
+	converter := newStreamConverter(m, metrics)
+	for {
+		select {
+		case <-done:
+			return nil
+		case update, ok := <-statsChannel.GetChannel():
+			if !ok {
+				return nil
+			}
+			sample, err := converter.convert(update.ContainerReference, update.Stats)
+			if err != nil {
+				// The container may have gone away since the sample was taken.
+				logging.V(4).Infof("failed to convert stats of %q for stream: %v", update.ContainerReference.Name, err)
+				continue
+			}
+			out, err := json.Marshal(sample)
+			if err != nil {
+				logging.Errorf("error encoding stats %+v for stream: %v", sample, err)
+				continue
+			}
+			if err := send(out); err != nil {
+				// The client went away.
+				return nil
+			}
+		}
+	}
+}
+
+// Starts a Server-Sent Events response. Returns a function writing one event
+// and a channel closed when the client disconnects.
+func startEventStream(w http.ResponseWriter) (func([]byte) error, <-chan struct{}, error) {
+	cn, ok := w.(http.CloseNotifier)
+	if !ok {
+		return nil, nil, errors.New("could not access http.CloseNotifier")
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		return nil, nil, errors.New("could not access http.Flusher")
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	done := make(chan struct{})
+	closed := cn.CloseNotify()
+	go func() {
+		<-closed
+		close(done)
+	}()
+	send := func(data []byte) error {
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}
+	return send, done, nil
+}
@@ -0,0 +1,200 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/cadvisor/cache/memory"
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/manager"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetStreamMetrics(t *testing.T) {
+	metrics, err := getStreamMetrics(makeHTTPRequest("http://localhost:8080/api/v2.1/stream/", t))
+	require.Nil(t, err)
+	assert.Nil(t, metrics)
+
+	metrics, err = getStreamMetrics(makeHTTPRequest("http://localhost:8080/api/v2.1/stream/?metrics=cpu,load", t))
+	require.Nil(t, err)
+	assert.Equal(t, streamMetrics{"cpu": true, "load": true}, metrics)
+
+	_, err = getStreamMetrics(makeHTTPRequest("http://localhost:8080/api/v2.1/stream/?metrics=cpu,bogus", t))
+	assert.NotNil(t, err)
+}
+
+func TestStreamMetricsFilterSpec(t *testing.T) {
+	spec := info.ContainerSpec{
+		HasCpu:     true,
+		HasMemory:  true,
+		HasNetwork: true,
+	}
+	filtered := streamMetrics{"memory": true, "diskio": true}.filterSpec(spec)
+	assert.False(t, filtered.HasCpu)
+	assert.True(t, filtered.HasMemory)
+	assert.False(t, filtered.HasNetwork)
+	// Asking for a kind the container doesn't have doesn't add it.
+	assert.False(t, filtered.HasDiskIo)
+
+	// Without a "metrics" parameter, everything is streamed.
+	assert.Equal(t, spec, streamMetrics(nil).filterSpec(spec))
+}
+
+// Manager streaming the samples added to a real in-memory cache.
+type streamManager struct {
+	manager.Manager
+	cache *memory.InMemoryCache
+	// Receives the name of every watched container.
+	watching chan string
+}
+
+func newStreamManager() *streamManager {
+	return &streamManager{
+		cache:    memory.New(time.Minute, nil),
+		watching: make(chan string, 1),
+	}
+}
+
+func (self *streamManager) WatchForStats(containerName string, options v2.RequestOptions) (*memory.StatsChannel, error) {
+	ch := self.cache.WatchStats(func(ref info.ContainerReference) bool {
+		return ref.Name == containerName
+	})
+	self.watching <- containerName
+	return ch, nil
+}
+
+func (self *streamManager) CloseStatsChannel(watchId int) {
+	self.cache.StopWatch(watchId)
+}
+
+func (self *streamManager) GetContainerInfo(containerName string, query *info.ContainerInfoRequest) (*info.ContainerInfo, error) {
+	return &info.ContainerInfo{
+		ContainerReference: info.ContainerReference{Name: containerName},
+		Spec: info.ContainerSpec{
+			HasCpu:    true,
+			HasMemory: true,
+		},
+	}, nil
+}
+
+// Waits for the stream to watch the container, then adds a sample for it.
+func (self *streamManager) addStats(t *testing.T) {
+	select {
+	case name := <-self.watching:
+		assert.Equal(t, "/c", name)
+	case <-time.After(10 * time.Second):
+		t.Fatal("the stream never watched the container")
+	}
+	stats := &info.ContainerStats{Timestamp: time.Now()}
+	stats.Cpu.Usage.Total = 100
+	stats.Memory.Usage = 200
+	require.Nil(t, self.cache.AddStats(info.ContainerReference{Name: "/c"}, stats))
+}
+
+func newStreamServer(m manager.Manager) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := handleStreamRequest([]string{"c"}, m, w, r); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}))
+}
+
+// Checks a streamed sample only has the stats asked for with ?metrics=memory.
+func checkStreamedSample(t *testing.T, data []byte) {
+	var sample streamedStats
+	require.Nil(t, json.Unmarshal(data, &sample), "%s", data)
+	assert.Equal(t, "/c", sample.Name)
+	require.NotNil(t, sample.Stats)
+	assert.Nil(t, sample.Stats.Cpu)
+	require.NotNil(t, sample.Stats.Memory)
+	assert.Equal(t, uint64(200), sample.Stats.Memory.Usage)
+}
+
+func TestStreamEvents(t *testing.T) {
+	m := newStreamManager()
+	server := newStreamServer(m)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/api/v2.1/stream/c?metrics=memory")
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	m.addStats(t)
+	br := bufio.NewReader(resp.Body)
+	line, err := br.ReadString('\n')
+	require.Nil(t, err)
+	require.True(t, strings.HasPrefix(line, "data: "), "%q", line)
+	checkStreamedSample(t, []byte(strings.TrimSuffix(strings.TrimPrefix(line, "data: "), "\n")))
+	// Events are terminated by an empty line.
+	line, err = br.ReadString('\n')
+	require.Nil(t, err)
+	assert.Equal(t, "\n", line)
+}
+
+func TestStreamWebSocket(t *testing.T) {
+	m := newStreamManager()
+	server := newStreamServer(m)
+	defer server.Close()
+
+	c, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	require.Nil(t, err)
+	defer c.Close()
+	io.WriteString(c, "GET /api/v2.1/stream/c?metrics=memory HTTP/1.1\r\nHost: localhost\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n"+
+		"Sec-WebSocket-Version: 13\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n")
+
+	br := bufio.NewReader(c)
+	resp, err := http.ReadResponse(br, nil)
+	require.Nil(t, err)
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+	m.addStats(t)
+	header := make([]byte, 2)
+	_, err = io.ReadFull(br, header)
+	require.Nil(t, err)
+	// A final, unmasked text frame.
+	assert.Equal(t, byte(0x81), header[0])
+	length := uint64(header[1])
+	switch length {
+	case 126:
+		ext := make([]byte, 2)
+		_, err = io.ReadFull(br, ext)
+		require.Nil(t, err)
+		length = uint64(binary.BigEndian.Uint16(ext))
+	case 127:
+		ext := make([]byte, 8)
+		_, err = io.ReadFull(br, ext)
+		require.Nil(t, err)
+		length = binary.BigEndian.Uint64(ext)
+	}
+	payload := make([]byte, length)
+	_, err = io.ReadFull(br, payload)
+	require.Nil(t, err)
+	checkStreamedSample(t, payload)
+}
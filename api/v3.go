@@ -0,0 +1,245 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/manager"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// A page of containers returned by the v3 listing endpoints.
+type containerPage struct {
+	Containers []containerEntry `json:"containers"`
+	// Opaque token to pass as "page_token" to fetch the next page. Empty on the last page.
+	NextPageToken string `json:"next_page_token,omitempty"`
+	// Total number of containers matching the request across all pages.
+	Total int `json:"total"`
+}
+
+type containerEntry struct {
+	Name  string               `json:"name"`
+	Spec  *v2.ContainerSpec    `json:"spec,omitempty"`
+	Stats []*v2.ContainerStats `json:"stats,omitempty"`
+}
+
+type pageOptions struct {
+	// Name of the last container of the previous page, empty for the first page.
+	after string
+	limit int
+}
+
+func getPageOptions(r *http.Request) (pageOptions, error) {
+	opt := pageOptions{
+		limit: defaultPageSize,
+	}
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		n, err := strconv.ParseUint(limit, 10, 32)
+		if err != nil || n == 0 {
+			return opt, fmt.Errorf("failed to parse 'limit' option: %v", limit)
+		}
+		opt.limit = int(n)
+		if opt.limit > maxPageSize {
+			opt.limit = maxPageSize
+		}
+	}
+	if token := r.URL.Query().Get("page_token"); token != "" {
+		after, err := base64.URLEncoding.DecodeString(token)
+		if err != nil || len(after) == 0 {
+			return opt, fmt.Errorf("invalid 'page_token' %q", token)
+		}
+		opt.after = string(after)
+	}
+	return opt, nil
+}
+
+// Returns the names on the requested page of the sorted names, and the token
+// of the following page.
+func (self pageOptions) page(names []string) ([]string, string) {
+	start := 0
+	if self.after != "" {
+		start = sort.Search(len(names), func(i int) bool { return names[i] > self.after })
+	}
+	end := start + self.limit
+	if end >= len(names) {
+		return names[start:], ""
+	}
+	return names[start:end], base64.URLEncoding.EncodeToString([]byte(names[end-1]))
+}
+
+// Set of fields selected with the "fields" query parameter. A nil set selects everything.
+type fieldSet map[string]bool
+
+var supportedFields = []string{"spec", "cpu", "memory", "network", "filesystem", "diskio", "load", "custom"}
+
+func getFields(r *http.Request) (fieldSet, error) {
+	value := r.URL.Query().Get("fields")
+	if value == "" {
+		return nil, nil
+	}
+	supported := make(map[string]bool, len(supportedFields))
+	for _, f := range supportedFields {
+		supported[f] = true
+	}
+	fields := make(fieldSet)
+	for _, f := range strings.Split(value, ",") {
+		if !supported[f] {
+			return nil, fmt.Errorf("unknown field %q, supported fields are %s", f, strings.Join(supportedFields, ","))
+		}
+		fields[f] = true
+	}
+	return fields, nil
+}
+
+func (self fieldSet) has(field string) bool {
+	return self == nil || self[field]
+}
+
+// Returns whether any stats field is selected.
+func (self fieldSet) hasStats() bool {
+	for _, f := range supportedFields {
+		if f != "spec" && self.has(f) {
+			return true
+		}
+	}
+	return false
+}
+
+// Drops the stats not selected by the field set.
+func (self fieldSet) project(stats *v2.ContainerStats) {
+	if !self.has("cpu") {
+		stats.Cpu = nil
+		stats.CpuInst = nil
+	}
+	if !self.has("memory") {
+		stats.Memory = nil
+	}
+	if !self.has("network") {
+		stats.Network = nil
+	}
+	if !self.has("filesystem") {
+		stats.Filesystem = nil
+		stats.Volumes = nil
+	}
+	if !self.has("diskio") {
+		stats.DiskIo = nil
+	}
+	if !self.has("load") {
+		stats.Load = nil
+	}
+	if !self.has("custom") {
+		stats.CustomMetrics = nil
+	}
+}
+
+// Lists one page of the requested containers with their spec and stats.
+func getContainerPage(name string, opt v2.RequestOptions, page pageOptions, fields fieldSet, ds downsampleOptions, m manager.Manager) (*containerPage, error) {
+	names, err := m.GetRequestedContainerNames(name, opt)
+	if err != nil {
+		return nil, err
+	}
+	// Root cgroup stats are exposed as machine stats.
+	filtered := names[:0]
+	for _, n := range names {
+		if n != "/" {
+			filtered = append(filtered, n)
+		}
+	}
+	names = filtered
+
+	pageNames, next := page.page(names)
+	result := &containerPage{
+		Containers:    make([]containerEntry, 0, len(pageNames)),
+		NextPageToken: next,
+		Total:         len(names),
+	}
+	query := v2.RequestOptions{
+		IdType: v2.TypeName,
+		Count:  opt.Count,
+	}
+	for _, n := range pageNames {
+		conts, err := m.GetRequestedContainersInfo(n, query)
+		if err != nil {
+			// The container went away since it was listed.
+			continue
+		}
+		cont, ok := conts[n]
+		if !ok {
+			continue
+		}
+		entry := containerEntry{
+			Name: n,
+		}
+		if fields.has("spec") {
+			spec := v2.ContainerSpecFromV1(&cont.Spec, cont.Aliases, cont.Namespace)
+			entry.Spec = &spec
+		}
+		if fields.hasStats() {
+			entry.Stats = ds.apply(v2.ContainerStatsFromV1(&cont.Spec, cont.Stats))
+			for _, stats := range entry.Stats {
+				fields.project(stats)
+			}
+		}
+		result.Containers = append(result.Containers, entry)
+	}
+	return result, nil
+}
+
+// Compresses everything written to the underlying ResponseWriter. The
+// Content-Encoding header is only set on the first write so that errors
+// reported before any output are sent uncompressed.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz *gzip.Writer
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		if strings.TrimSpace(strings.Split(encoding, ";")[0]) == "gzip" {
+			return true
+		}
+	}
+	return false
+}
+
+func (self *gzipResponseWriter) Write(b []byte) (int, error) {
+	if self.gz == nil {
+		h := self.ResponseWriter.Header()
+		h.Set("Content-Encoding", "gzip")
+		h.Add("Vary", "Accept-Encoding")
+		h.Del("Content-Length")
+		self.gz = gzip.NewWriter(self.ResponseWriter)
+	}
+	return self.gz.Write(b)
+}
+
+func (self *gzipResponseWriter) Close() error {
+	if self.gz == nil {
+		return nil
+	}
+	return self.gz.Close()
+}
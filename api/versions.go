@@ -15,16 +15,23 @@
 package api
 
 import (
+	"flag"
 	"fmt"
 	"net/http"
+	"os"
 	"path"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/google/cadvisor/config"
+	"github.com/google/cadvisor/federation"
 	info "github.com/google/cadvisor/info/v1"
 	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/logging"
 	"github.com/google/cadvisor/manager"
-
-	"github.com/golang/glog"
+	"github.com/google/cadvisor/rpc"
 )
 
 const (
@@ -42,8 +49,27 @@ const (
 	versionApi       = "version"
 	psApi            = "ps"
 	customMetricsApi = "appmetrics"
+	streamApi        = "stream"
+	topApi           = "top"
+	federateApi      = "federate"
+	predictApi       = "predict"
+	recommendApi     = "recommend"
+	podsApi          = "pods"
+	imagesApi        = "images"
+	configApi        = "config"
+	snapshotApi      = "snapshot"
+	hostStatsApi     = "hoststats"
+	historyApi       = "history"
+	signalApi        = "signal"
+	exportApi        = "export"
+	debugApi         = "debug"
+	selfApi          = "self"
+	logLevelsApi     = "loglevels"
+	queryApi         = "query"
 )
 
+var argMaxSnapshotSize = flag.Int64("api_max_snapshot_size", 256<<20, "maximum size in bytes of the snapshots restored through the API, decompressed")
+
 // Interface for a cAdvisor API version
 type ApiVersion interface {
 	// Returns the version string.
@@ -57,15 +83,16 @@ type ApiVersion interface {
 }
 
 // Gets all supported API versions.
-func getApiVersions() []ApiVersion {
+func getApiVersions(fed *federation.Federation) []ApiVersion {
 	v1_0 := &version1_0{}
 	v1_1 := newVersion1_1(v1_0)
 	v1_2 := newVersion1_2(v1_1)
 	v1_3 := newVersion1_3(v1_2)
 	v2_0 := newVersion2_0()
-	v2_1 := newVersion2_1(v2_0)
+	v2_1 := newVersion2_1(v2_0, fed)
+	v3_0 := newVersion3_0(v2_1)
 
-	return []ApiVersion{v1_0, v1_1, v1_2, v1_3, v2_0, v2_1}
+	return []ApiVersion{v1_0, v1_1, v1_2, v1_3, v2_0, v2_1, v3_0}
 
 }
 
@@ -85,7 +112,7 @@ func (self *version1_0) SupportedRequestTypes() []string {
 func (self *version1_0) HandleRequest(requestType string, request []string, m manager.Manager, w http.ResponseWriter, r *http.Request) error {
 	switch requestType {
 	case machineApi:
-		glog.V(4).Infof("Api - Machine")
+		logging.V(4).Infof("Api - Machine")
 
 		// Get the MachineInfo
 		machineInfo, err := m.GetMachineInfo()
@@ -99,7 +126,7 @@ func (self *version1_0) HandleRequest(requestType string, request []string, m ma
 		}
 	case containersApi:
 		containerName := getContainerName(request)
-		glog.V(4).Infof("Api - Container(%s)", containerName)
+		logging.V(4).Infof("Api - Container(%s)", containerName)
 
 		// Get the query request.
 		query, err := getContainerInfoRequest(r.Body)
@@ -149,7 +176,7 @@ func (self *version1_1) HandleRequest(requestType string, request []string, m ma
 	switch requestType {
 	case subcontainersApi:
 		containerName := getContainerName(request)
-		glog.V(4).Infof("Api - Subcontainers(%s)", containerName)
+		logging.V(4).Infof("Api - Subcontainers(%s)", containerName)
 
 		// Get the query request.
 		query, err := getContainerInfoRequest(r.Body)
@@ -198,7 +225,7 @@ func (self *version1_2) SupportedRequestTypes() []string {
 func (self *version1_2) HandleRequest(requestType string, request []string, m manager.Manager, w http.ResponseWriter, r *http.Request) error {
 	switch requestType {
 	case dockerApi:
-		glog.V(4).Infof("Api - Docker(%v)", request)
+		logging.V(4).Infof("Api - Docker(%v)", request)
 
 		// Get the query request.
 		query, err := getContainerInfoRequest(r.Body)
@@ -279,7 +306,7 @@ func handleEventRequest(request []string, m manager.Manager, w http.ResponseWrit
 		return err
 	}
 	query.ContainerName = path.Join("/", getContainerName(request))
-	glog.V(4).Infof("Api - Events(%v)", query)
+	logging.V(4).Infof("Api - Events(%v)", query)
 	if !stream {
 		pastEvents, err := m.GetPastEvents(query)
 		if err != nil {
@@ -319,14 +346,14 @@ func (self *version2_0) HandleRequest(requestType string, request []string, m ma
 	}
 	switch requestType {
 	case versionApi:
-		glog.V(4).Infof("Api - Version")
+		logging.V(4).Infof("Api - Version")
 		versionInfo, err := m.GetVersionInfo()
 		if err != nil {
 			return err
 		}
 		return writeResult(versionInfo.CadvisorVersion, w)
 	case attributesApi:
-		glog.V(4).Info("Api - Attributes")
+		logging.V(4).Info("Api - Attributes")
 
 		machineInfo, err := m.GetMachineInfo()
 		if err != nil {
@@ -339,17 +366,22 @@ func (self *version2_0) HandleRequest(requestType string, request []string, m ma
 		info := v2.GetAttributes(machineInfo, versionInfo)
 		return writeResult(info, w)
 	case machineApi:
-		glog.V(4).Info("Api - Machine")
+		logging.V(4).Info("Api - Machine")
 
 		// TODO(rjnagal): Move machineInfo from v1.
 		machineInfo, err := m.GetMachineInfo()
 		if err != nil {
 			return err
 		}
+		// Clients polling for hardware changes pass the generation they have.
+		if generation := r.URL.Query().Get("generation"); generation != "" && generation == strconv.FormatUint(machineInfo.Generation, 10) {
+			w.WriteHeader(http.StatusNotModified)
+			return nil
+		}
 		return writeResult(machineInfo, w)
 	case summaryApi:
 		containerName := getContainerName(request)
-		glog.V(4).Infof("Api - Summary for container %q, options %+v", containerName, opt)
+		logging.V(4).Infof("Api - Summary for container %q, options %+v", containerName, opt)
 
 		stats, err := m.GetDerivedStats(containerName, opt)
 		if err != nil {
@@ -358,8 +390,17 @@ func (self *version2_0) HandleRequest(requestType string, request []string, m ma
 		return writeResult(stats, w)
 	case statsApi:
 		name := getContainerName(request)
-		glog.V(4).Infof("Api - Stats: Looking for stats for container %q, options %+v", name, opt)
-		infos, err := m.GetRequestedContainersInfo(name, opt)
+		var wait time.Duration
+		if opt.Since, wait, err = getSinceOptions(r); err != nil {
+			return err
+		}
+		logging.V(4).Infof("Api - Stats: Looking for stats for container %q, options %+v", name, opt)
+		var infos map[string]*info.ContainerInfo
+		err = waitForChanges(m, false, wait, w, func() (bool, error) {
+			var err error
+			infos, err = m.GetRequestedContainersInfo(name, opt)
+			return len(infos) > 0, err
+		})
 		if err != nil {
 			return err
 		}
@@ -367,10 +408,10 @@ func (self *version2_0) HandleRequest(requestType string, request []string, m ma
 		for name, cinfo := range infos {
 			contStats[name] = v2.DeprecatedStatsFromV1(cinfo)
 		}
-		return writeResult(contStats, w)
+		return writeEncodedResult(contStats, nil, w, r)
 	case customMetricsApi:
 		containerName := getContainerName(request)
-		glog.V(4).Infof("Api - Custom Metrics: Looking for metrics for container %q, options %+v", containerName, opt)
+		logging.V(4).Infof("Api - Custom Metrics: Looking for metrics for container %q, options %+v", containerName, opt)
 		infos, err := m.GetContainerInfoV2(containerName, opt)
 		if err != nil {
 			return err
@@ -410,7 +451,7 @@ func (self *version2_0) HandleRequest(requestType string, request []string, m ma
 		return writeResult(contMetrics, w)
 	case specApi:
 		containerName := getContainerName(request)
-		glog.V(4).Infof("Api - Spec for container %q, options %+v", containerName, opt)
+		logging.V(4).Infof("Api - Spec for container %q, options %+v", containerName, opt)
 		specs, err := m.GetContainerSpec(containerName, opt)
 		if err != nil {
 			return err
@@ -441,7 +482,7 @@ func (self *version2_0) HandleRequest(requestType string, request []string, m ma
 		// ignore recursive.
 		// TODO(rjnagal): consider count to limit ps output.
 		name := getContainerName(request)
-		glog.V(4).Infof("Api - Spec for container %q, options %+v", name, opt)
+		logging.V(4).Infof("Api - Spec for container %q, options %+v", name, opt)
 		ps, err := m.GetProcessList(name, opt)
 		if err != nil {
 			return fmt.Errorf("process listing failed: %v", err)
@@ -454,11 +495,13 @@ func (self *version2_0) HandleRequest(requestType string, request []string, m ma
 
 type version2_1 struct {
 	baseVersion *version2_0
+	federation  *federation.Federation
 }
 
-func newVersion2_1(v *version2_0) *version2_1 {
+func newVersion2_1(v *version2_0, fed *federation.Federation) *version2_1 {
 	return &version2_1{
 		baseVersion: v,
+		federation:  fed,
 	}
 }
 
@@ -467,7 +510,7 @@ func (self *version2_1) Version() string {
 }
 
 func (self *version2_1) SupportedRequestTypes() []string {
-	return append([]string{machineStatsApi}, self.baseVersion.SupportedRequestTypes()...)
+	return append([]string{machineStatsApi, streamApi, topApi, containersApi, federateApi, predictApi, recommendApi, podsApi, imagesApi, configApi, snapshotApi, hostStatsApi, historyApi, signalApi, exportApi, debugApi, selfApi, logLevelsApi, queryApi}, self.baseVersion.SupportedRequestTypes()...)
 }
 
 func (self *version2_1) HandleRequest(requestType string, request []string, m manager.Manager, w http.ResponseWriter, r *http.Request) error {
@@ -479,36 +522,603 @@ func (self *version2_1) HandleRequest(requestType string, request []string, m ma
 
 	switch requestType {
 	case machineStatsApi:
-		glog.V(4).Infof("Api - MachineStats(%v)", request)
+		logging.V(4).Infof("Api - MachineStats(%v)", request)
 		cont, err := m.GetRequestedContainersInfo("/", opt)
 		if err != nil {
 			return err
 		}
 		return writeResult(v2.MachineStatsFromV1(cont["/"]), w)
+	case selfApi:
+		logging.V(4).Infof("Api - Self")
+		stats, err := m.GetSelfStats()
+		if err != nil {
+			return err
+		}
+		return writeResult(stats, w)
+	case hostStatsApi:
+		logging.V(4).Infof("Api - HostStats")
+		stats, err := m.GetHostStats()
+		if err != nil {
+			return err
+		}
+		return writeResult(stats, w)
 	case statsApi:
 		name := getContainerName(request)
-		glog.V(4).Infof("Api - Stats: Looking for stats for container %q, options %+v", name, opt)
-		conts, err := m.GetRequestedContainersInfo(name, opt)
+		ds, err := getDownsampleOptions(r, &opt)
 		if err != nil {
 			return err
 		}
-		contStats := make(map[string]v2.ContainerInfo, len(conts))
-		for name, cont := range conts {
-			if name == "/" {
-				// Root cgroup stats should be exposed as machine stats
-				continue
+		var wait time.Duration
+		if opt.Since, wait, err = getSinceOptions(r); err != nil {
+			return err
+		}
+		logging.V(4).Infof("Api - Stats: Looking for stats for container %q, options %+v", name, opt)
+		var contStats map[string]v2.ContainerInfo
+		err = waitForChanges(m, false, wait, w, func() (bool, error) {
+			conts, err := m.GetRequestedContainersInfo(name, opt)
+			if err != nil {
+				return false, err
+			}
+			contStats = make(map[string]v2.ContainerInfo, len(conts))
+			for name, cont := range conts {
+				if name == "/" {
+					// Root cgroup stats should be exposed as machine stats
+					continue
+				}
+				contStats[name] = v2.ContainerInfo{
+					Spec:  v2.ContainerSpecFromV1(&cont.Spec, cont.Aliases, cont.Namespace),
+					Stats: ds.apply(v2.ContainerStatsFromV1(&cont.Spec, cont.Stats)),
+				}
+			}
+			return len(contStats) > 0, nil
+		})
+		if err != nil {
+			return err
+		}
+		if r.URL.Query().Get("delta") == "true" {
+			deltas := make(map[string]v2.DeltaContainerInfo, len(contStats))
+			for name, cinfo := range contStats {
+				if deltas[name], err = v2.DeltaEncodeInfo(cinfo); err != nil {
+					return err
+				}
 			}
-			contStats[name] = v2.ContainerInfo{
-				Spec:  v2.ContainerSpecFromV1(&cont.Spec, cont.Aliases, cont.Namespace),
-				Stats: v2.ContainerStatsFromV1(&cont.Spec, cont.Stats),
+			return writeResult(deltas, w)
+		}
+		return writeEncodedResult(contStats, func() proto.Message {
+			return rpc.ContainersToProto(contStats)
+		}, w, r)
+	case streamApi:
+		return handleStreamRequest(request, m, w, r)
+	case containersApi:
+		// /containers/<container>/health
+		if len(request) > 0 && request[len(request)-1] == "health" {
+			name := getContainerName(request[:len(request)-1])
+			logging.V(4).Infof("Api - Containers: Getting the health of container %q, options %+v", name, opt)
+			health, err := m.GetContainerHealth(name, opt)
+			if err != nil {
+				return err
+			}
+			return writeResult(health, w)
+		}
+		name := getContainerName(request)
+		filter, err := getContainerFilter(r)
+		if err != nil {
+			return err
+		}
+		// Search the whole subtree unless asked otherwise.
+		if r.URL.Query().Get("recursive") == "" {
+			opt.Recursive = true
+		}
+		var wait time.Duration
+		if opt.Since, wait, err = getSinceOptions(r); err != nil {
+			return err
+		}
+		logging.V(4).Infof("Api - Containers: Searching containers under %q, options %+v, filter %+v", name, opt, filter)
+		var specs map[string]v2.ContainerSpec
+		err = waitForChanges(m, true, wait, w, func() (bool, error) {
+			var err error
+			specs, err = searchContainers(name, opt, filter, m)
+			return len(specs) > 0, err
+		})
+		if err != nil {
+			return err
+		}
+		return writeResult(specs, w)
+	case federateApi:
+		if len(request) == 0 || request[0] == "" {
+			logging.V(4).Infof("Api - Federate: Listing all nodes")
+			nodes, err := listFederation(self.federation, m)
+			if err != nil {
+				return err
+			}
+			return writeResult(nodes, w)
+		}
+		// /federate/<peer>/<version>/<request type>[/<args...>]
+		if len(request) < 3 {
+			return fmt.Errorf("incomplete federated request %q, expected /federate/<peer>/<version>/<request type>", r.URL.Path)
+		}
+		apiPath := "/" + strings.Join(request[1:], "/")
+		logging.V(4).Infof("Api - Federate: Proxying %q to peer %q", apiPath, request[0])
+		return self.federation.Proxy(request[0], apiPath, w, r)
+	case topApi:
+		name := getContainerName(request)
+		by, window, err := getTopOptions(r)
+		if err != nil {
+			return err
+		}
+		if r.URL.Query().Get("count") == "" {
+			opt.Count = defaultTopCount
+		}
+		logging.V(4).Infof("Api - Top: Looking for top %d subcontainers of %q by %s over %v", opt.Count, name, by, window)
+		usages, err := m.GetTopContainers(name, opt, by, window)
+		if err != nil {
+			return err
+		}
+		return writeResult(usages, w)
+	case summaryApi:
+		name := getContainerName(request)
+		logging.V(4).Infof("Api - Summary for container %q, options %+v", name, opt)
+		summaries, err := m.GetSummaries(name, opt)
+		if err != nil {
+			return err
+		}
+		return writeResult(summaries, w)
+	case specApi:
+		if r.URL.Query().Get("history") != "true" {
+			return self.baseVersion.HandleRequest(requestType, request, m, w, r)
+		}
+		name := getContainerName(request)
+		logging.V(4).Infof("Api - Spec history for container %q, options %+v", name, opt)
+		history, err := m.GetContainerSpecHistory(name, opt)
+		if err != nil {
+			return err
+		}
+		return writeResult(history, w)
+	case predictApi:
+		name := getContainerName(request)
+		logging.V(4).Infof("Api - Predict: Forecasting usage of container %q, options %+v", name, opt)
+		predictions, err := m.GetPredictions(name, opt)
+		if err != nil {
+			return err
+		}
+		return writeResult(predictions, w)
+	case recommendApi:
+		name := getContainerName(request)
+		window, headroom, err := getRecommendOptions(r)
+		if err != nil {
+			return err
+		}
+		logging.V(4).Infof("Api - Recommend: Recommending resources of container %q over the last %s with %v headroom, options %+v", name, window, headroom, opt)
+		recommendations, err := m.GetRecommendations(name, opt, window, headroom)
+		if err != nil {
+			return err
+		}
+		return writeResult(recommendations, w)
+	case podsApi:
+		// /pods[/<namespace>]
+		namespace := ""
+		if len(request) > 0 {
+			namespace = request[0]
+		}
+		_, window, err := getTopOptions(r)
+		if err != nil {
+			return err
+		}
+		logging.V(4).Infof("Api - Pods: Aggregating the usage of the pods of namespace %q over %v", namespace, window)
+		pods, err := m.GetPods(namespace, window)
+		if err != nil {
+			return err
+		}
+		return writeResult(pods, w)
+	case imagesApi:
+		logging.V(4).Infof("Api - Images")
+		images, err := m.GetImages()
+		if err != nil {
+			return err
+		}
+		return writeResult(images, w)
+	case configApi:
+		logging.V(4).Infof("Api - Config")
+		return writeResult(configInfo{
+			Flags:      config.Effective(),
+			Reloadable: config.ReloadableFlags(),
+		}, w)
+	case logLevelsApi:
+		switch r.Method {
+		case "GET":
+			logging.V(4).Infof("Api - LogLevels")
+		case "POST":
+			// POST /loglevels?module=<module>&level=<level>
+			module := r.URL.Query().Get("module")
+			level := r.URL.Query().Get("level")
+			logging.V(4).Infof("Api - LogLevels: Setting the level of module %q to %q", module, level)
+			if err := setLogLevel(module, level); err != nil {
+				return &statusError{
+					status: http.StatusBadRequest,
+					msg:    err.Error(),
+				}
+			}
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			return &statusError{
+				status: http.StatusMethodNotAllowed,
+				msg:    fmt.Sprintf("method %s is not supported by the loglevels endpoint", r.Method),
+			}
+		}
+		return writeResult(getLogLevels(), w)
+	case historyApi:
+		name := getContainerName(request)
+		if !m.HistoryAvailable() {
+			return &statusError{
+				status: http.StatusNotImplemented,
+				msg:    "the storage driver doesn't support reading stats back",
+			}
+		}
+		start, end, step, err := getHistoryOptions(r, time.Now())
+		if err != nil {
+			return &statusError{
+				status: http.StatusBadRequest,
+				msg:    err.Error(),
+			}
+		}
+		logging.V(4).Infof("Api - History(%v): Reading stats from %v to %v every %v", name, start, end, step)
+		history, err := m.GetHistoricalStats(name, start, end, step)
+		if err != nil {
+			return err
+		}
+		if r.URL.Query().Get("delta") == "true" {
+			deltas, err := v2.DeltaEncodeInfo(history)
+			if err != nil {
+				return err
+			}
+			return writeResult(deltas, w)
+		}
+		return writeResult(history, w)
+	case exportApi:
+		name := getContainerName(request)
+		logging.V(4).Infof("Api - Export(%v)", name)
+		return handleExportRequest(name, m, w, r)
+	case queryApi:
+		// GET /query?expr=<expression>
+		logging.V(4).Infof("Api - Query(%q)", r.URL.Query().Get("expr"))
+		return handleQueryRequest(m, w, r)
+	case debugApi:
+		logging.V(4).Infof("Api - Debug(%v)", request)
+		return handleDebugRequest(request, m, w, r)
+	case signalApi:
+		// POST /signal/<container>?pid=<pid>&signal=TERM|KILL
+		name := getContainerName(request)
+		if !*manager.EnableProcessSignals {
+			return &statusError{
+				status: http.StatusForbidden,
+				msg:    "sending signals to processes is disabled, see --enable_process_signals",
+			}
+		}
+		if r.Method != "POST" {
+			w.Header().Set("Allow", "POST")
+			return &statusError{
+				status: http.StatusMethodNotAllowed,
+				msg:    fmt.Sprintf("method %s is not supported by the signal endpoint", r.Method),
+			}
+		}
+		// Cross-site forms cannot set the header, and browsers only send it
+		// to another origin after a CORS preflight that cAdvisor does not
+		// answer.
+		if r.Header.Get("X-Requested-With") == "" {
+			return &statusError{
+				status: http.StatusForbidden,
+				msg:    "the signal endpoint requires the X-Requested-With header",
+			}
+		}
+		pid, err := strconv.Atoi(r.URL.Query().Get("pid"))
+		if err != nil || pid <= 0 {
+			return &statusError{
+				status: http.StatusBadRequest,
+				msg:    fmt.Sprintf("failed to parse 'pid' option: %v", r.URL.Query().Get("pid")),
 			}
 		}
-		return writeResult(contStats, w)
+		signal := r.URL.Query().Get("signal")
+		logging.V(4).Infof("Api - Signal: Sending SIG%s to process %d of container %q", signal, pid, name)
+		if err := m.SignalProcess(name, pid, signal); err != nil {
+			return &statusError{
+				status: http.StatusBadRequest,
+				msg:    err.Error(),
+			}
+		}
+		return writeResult(processSignal{Pid: pid, Signal: signal}, w)
+	case snapshotApi:
+		switch r.Method {
+		case "GET":
+			logging.V(4).Infof("Api - Snapshot: Writing a snapshot")
+			w.Header().Set("Content-Type", "application/gzip")
+			w.Header().Set("Content-Disposition", `attachment; filename="cadvisor-snapshot.json.gz"`)
+			return m.WriteSnapshot(w)
+		case "POST":
+			logging.V(4).Infof("Api - Snapshot: Restoring a snapshot")
+			// The snapshot is gzipped, so it is smaller than its limit
+			// decompressed.
+			body := http.MaxBytesReader(w, r.Body, *argMaxSnapshotSize)
+			containers, err := m.RestoreSnapshot(body, *argMaxSnapshotSize)
+			if err != nil {
+				return &statusError{
+					status: http.StatusBadRequest,
+					msg:    fmt.Sprintf("failed to restore snapshot: %v", err),
+				}
+			}
+			return writeResult(snapshotRestore{Containers: containers}, w)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			return &statusError{
+				status: http.StatusMethodNotAllowed,
+				msg:    fmt.Sprintf("method %s is not supported by the snapshot endpoint", r.Method),
+			}
+		}
+	default:
+		return self.baseVersion.HandleRequest(requestType, request, m, w, r)
+	}
+}
+
+// The effective configuration returned by the config endpoint.
+type configInfo struct {
+	// Current values of all flags, with secrets hidden.
+	Flags map[string]string `json:"flags"`
+	// Names of the flags reloaded on SIGHUP.
+	Reloadable []string `json:"reloadable"`
+}
+
+// The verbosity levels of the logs.
+type logLevels struct {
+	// Level of the modules without a level of their own, -v.
+	Default logging.Level `json:"default"`
+	// Levels of the modules, set with --log_levels or the API.
+	Modules map[string]logging.Level `json:"modules"`
+}
+
+func getLogLevels() logLevels {
+	return logLevels{
+		Default: logging.DefaultLevel(),
+		Modules: logging.ModuleLevels(),
+	}
+}
+
+// Sets the level of the module, or the default level without a module. An
+// empty level makes the module use the default level again.
+func setLogLevel(module, value string) error {
+	if module != "" && value == "" {
+		logging.ClearModuleLevel(module)
+		return nil
+	}
+	level, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return fmt.Errorf("failed to parse 'level' option: %q", value)
+	}
+	if module == "" {
+		return logging.SetDefaultLevel(logging.Level(level))
+	}
+	return logging.SetModuleLevel(module, logging.Level(level))
+}
+
+// The signal sent to a process.
+type processSignal struct {
+	Pid    int    `json:"pid"`
+	Signal string `json:"signal"`
+}
+
+// The result of restoring a snapshot.
+type snapshotRestore struct {
+	// Number of containers whose stats were restored.
+	Containers int `json:"containers"`
+}
+
+const defaultTopCount = 10
+
+const (
+	defaultHistoryRange = time.Hour
+	// Number of samples returned by the history endpoint when no step is given.
+	defaultHistorySamples = 300
+)
+
+// Parses the time range and step of the history endpoint. The range defaults
+// to the hour before now.
+func getHistoryOptions(r *http.Request, now time.Time) (start, end time.Time, step time.Duration, err error) {
+	query := r.URL.Query()
+	end = now
+	if value := query.Get("end"); value != "" {
+		end, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			return start, end, step, fmt.Errorf("failed to parse 'end' option: %v", value)
+		}
+	}
+	start = end.Add(-defaultHistoryRange)
+	if value := query.Get("start"); value != "" {
+		start, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			return start, end, step, fmt.Errorf("failed to parse 'start' option: %v", value)
+		}
+	}
+	if !start.Before(end) {
+		return start, end, step, fmt.Errorf("'start' must be before 'end'")
+	}
+	if value := query.Get("step"); value != "" {
+		step, err = time.ParseDuration(value)
+		if err != nil || step <= 0 {
+			return start, end, step, fmt.Errorf("failed to parse 'step' option: %v", value)
+		}
+	} else {
+		step = end.Sub(start) / defaultHistorySamples
+	}
+	if step < time.Second {
+		step = time.Second
+	}
+	return start, end, step, nil
+}
+
+// Downsampling of the returned stats requested with the "step" and "func" options.
+type downsampleOptions struct {
+	// Zero if no downsampling was requested.
+	step time.Duration
+	fn   string
+	// Number of downsampled samples to keep, -1 to keep all of them.
+	count int
+}
+
+// Parses the downsampling options. When downsampling, all the cached samples
+// are requested and "count" instead limits the number of downsampled samples.
+func getDownsampleOptions(r *http.Request, opt *v2.RequestOptions) (downsampleOptions, error) {
+	ds := downsampleOptions{
+		fn:    v2.DownsampleAvg,
+		count: -1,
+	}
+	value := r.URL.Query().Get("step")
+	if value == "" {
+		return ds, nil
+	}
+	step, err := time.ParseDuration(value)
+	if err != nil || step <= 0 {
+		return ds, fmt.Errorf("failed to parse 'step' option: %v", value)
+	}
+	ds.step = step
+	if fn := r.URL.Query().Get("func"); fn != "" {
+		if fn != v2.DownsampleAvg && fn != v2.DownsampleMax {
+			return ds, fmt.Errorf("unknown 'func' %q", fn)
+		}
+		ds.fn = fn
+	}
+	if r.URL.Query().Get("count") != "" {
+		ds.count = opt.Count
+	}
+	opt.Count = -1
+	return ds, nil
+}
+
+func (self downsampleOptions) apply(stats []*v2.ContainerStats) []*v2.ContainerStats {
+	if self.step == 0 {
+		return stats
+	}
+	// The function was validated when parsing the options.
+	stats, _ = v2.DownsampleStats(stats, self.step, self.fn)
+	if self.count >= 0 && len(stats) > self.count {
+		stats = stats[len(stats)-self.count:]
+	}
+	return stats
+}
+
+// Parses the resource to rank containers by and the window to compute usage over.
+func getTopOptions(r *http.Request) (string, time.Duration, error) {
+	by := r.URL.Query().Get("by")
+	if by == "" {
+		by = manager.TopByCpu
+	}
+	window := time.Minute
+	if value := r.URL.Query().Get("window"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return "", 0, fmt.Errorf("failed to parse 'window' option: %v", value)
+		}
+		window = d
+	}
+	return by, window, nil
+}
+
+const defaultRecommendHeadroom = 0.2
+
+func getRecommendOptions(r *http.Request) (string, float64, error) {
+	window := r.URL.Query().Get("window")
+	if window == "" {
+		window = manager.RecommendWindowDay
+	}
+	headroom := defaultRecommendHeadroom
+	if value := r.URL.Query().Get("headroom"); value != "" {
+		h, err := strconv.ParseFloat(value, 64)
+		if err != nil || h < 0 {
+			return "", 0, fmt.Errorf("failed to parse 'headroom' option: %v", value)
+		}
+		headroom = h
+	}
+	return window, headroom, nil
+}
+
+// API v3.0 pages and projects container listings and compresses responses
+// for clients that accept gzip.
+type version3_0 struct {
+	baseVersion *version2_1
+}
+
+func newVersion3_0(v *version2_1) *version3_0 {
+	return &version3_0{
+		baseVersion: v,
+	}
+}
+
+func (self *version3_0) Version() string {
+	return "v3.0"
+}
+
+func (self *version3_0) SupportedRequestTypes() []string {
+	return self.baseVersion.SupportedRequestTypes()
+}
+
+func (self *version3_0) HandleRequest(requestType string, request []string, m manager.Manager, w http.ResponseWriter, r *http.Request) error {
+	// Streamed responses are flushed as they are produced and are left uncompressed.
+	if requestType != streamApi && requestType != eventsApi && acceptsGzip(r) {
+		gw := &gzipResponseWriter{ResponseWriter: w}
+		defer gw.Close()
+		w = gw
+	}
+
+	switch requestType {
+	case statsApi:
+		opt, err := getRequestOptions(r)
+		if err != nil {
+			return err
+		}
+		page, err := getPageOptions(r)
+		if err != nil {
+			return err
+		}
+		fields, err := getFields(r)
+		if err != nil {
+			return err
+		}
+		ds, err := getDownsampleOptions(r, &opt)
+		if err != nil {
+			return err
+		}
+		name := getContainerName(request)
+		logging.V(4).Infof("Api - Stats: Looking for stats for container %q, options %+v, page %+v", name, opt, page)
+		result, err := getContainerPage(name, opt, page, fields, ds, m)
+		if err != nil {
+			return err
+		}
+		return writeResult(result, w)
 	default:
 		return self.baseVersion.HandleRequest(requestType, request, m, w, r)
 	}
 }
 
+// Lists the machine and containers of the local node and of all the peers,
+// keyed by node name.
+func listFederation(fed *federation.Federation, m manager.Manager) (map[string]federation.NodeInfo, error) {
+	machineInfo, err := m.GetMachineInfo()
+	if err != nil {
+		return nil, err
+	}
+	specs, err := m.GetContainerSpec("/", v2.RequestOptions{IdType: v2.TypeName, Count: 0, Recursive: true})
+	if err != nil {
+		return nil, err
+	}
+	nodes := fed.ListPeers()
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+	nodes[hostname] = federation.NodeInfo{
+		Machine:    machineInfo,
+		Containers: specs,
+	}
+	return nodes, nil
+}
+
 func getRequestOptions(r *http.Request) (v2.RequestOptions, error) {
 	supportedTypes := map[string]bool{
 		v2.TypeName:   true,
@@ -539,5 +1149,8 @@ func getRequestOptions(r *http.Request) (v2.RequestOptions, error) {
 	if recursive == "true" {
 		opt.Recursive = true
 	}
+	if r.URL.Query().Get("computed") == "true" {
+		opt.Computed = true
+	}
 	return opt, nil
 }
@@ -15,15 +15,35 @@
 package api
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"flag"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"os"
 	"reflect"
+	"sort"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/google/cadvisor/auth"
 	"github.com/google/cadvisor/events"
 	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/manager"
+	"github.com/google/cadvisor/rpc"
+	"github.com/google/cadvisor/rpc/pb"
+	"github.com/google/cadvisor/utils/msgpack"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // returns an http.Request pointer for an input url test string
@@ -79,3 +99,629 @@ func TestGetEventRequestDoubleArgument(t *testing.T) {
 	assert.True(t, stream)
 	assert.Nil(t, err)
 }
+
+func TestGetPageOptions(t *testing.T) {
+	page, err := getPageOptions(makeHTTPRequest("http://localhost:8080/api/v3.0/stats", t))
+	assert.Nil(t, err)
+	assert.Equal(t, defaultPageSize, page.limit)
+
+	names := []string{"/a", "/b", "/c", "/d", "/e"}
+	page.limit = 2
+	first, token := page.page(names)
+	assert.Equal(t, []string{"/a", "/b"}, first)
+	assert.NotEmpty(t, token)
+
+	page, err = getPageOptions(makeHTTPRequest("http://localhost:8080/api/v3.0/stats?limit=2&page_token="+token, t))
+	assert.Nil(t, err)
+	second, token := page.page(names)
+	assert.Equal(t, []string{"/c", "/d"}, second)
+
+	page, err = getPageOptions(makeHTTPRequest("http://localhost:8080/api/v3.0/stats?limit=2&page_token="+token, t))
+	assert.Nil(t, err)
+	last, token := page.page(names)
+	assert.Equal(t, []string{"/e"}, last)
+	assert.Empty(t, token)
+
+	_, err = getPageOptions(makeHTTPRequest("http://localhost:8080/api/v3.0/stats?limit=0", t))
+	assert.NotNil(t, err)
+	_, err = getPageOptions(makeHTTPRequest("http://localhost:8080/api/v3.0/stats?page_token=%25", t))
+	assert.NotNil(t, err)
+}
+
+func TestGetHistoryOptions(t *testing.T) {
+	now := time.Date(2016, 1, 2, 12, 0, 0, 0, time.UTC)
+	start, end, step, err := getHistoryOptions(makeHTTPRequest("http://localhost:8080/api/v2.1/history/", t), now)
+	assert.Nil(t, err)
+	assert.Equal(t, now.Add(-time.Hour), start)
+	assert.Equal(t, now, end)
+	assert.Equal(t, 12*time.Second, step)
+
+	start, end, step, err = getHistoryOptions(makeHTTPRequest("http://localhost:8080/api/v2.1/history/?start=2016-01-01T00:00:00Z&end=2016-01-01T00:00:10Z&step=1ms", t), now)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC), start)
+	assert.Equal(t, time.Date(2016, 1, 1, 0, 0, 10, 0, time.UTC), end)
+	assert.Equal(t, time.Second, step)
+
+	_, _, _, err = getHistoryOptions(makeHTTPRequest("http://localhost:8080/api/v2.1/history/?start=2016-01-03T00:00:00Z", t), now)
+	assert.NotNil(t, err)
+	_, _, _, err = getHistoryOptions(makeHTTPRequest("http://localhost:8080/api/v2.1/history/?end=yesterday", t), now)
+	assert.NotNil(t, err)
+}
+
+func TestGetFields(t *testing.T) {
+	fields, err := getFields(makeHTTPRequest("http://localhost:8080/api/v3.0/stats?fields=cpu,memory", t))
+	assert.Nil(t, err)
+	assert.False(t, fields.has("spec"))
+	assert.True(t, fields.hasStats())
+
+	stats := &v2.ContainerStats{
+		Cpu:     &info.CpuStats{},
+		Memory:  &info.MemoryStats{},
+		Network: &v2.NetworkStats{},
+	}
+	fields.project(stats)
+	assert.NotNil(t, stats.Cpu)
+	assert.NotNil(t, stats.Memory)
+	assert.Nil(t, stats.Network)
+
+	fields, err = getFields(makeHTTPRequest("http://localhost:8080/api/v3.0/stats?fields=spec", t))
+	assert.Nil(t, err)
+	assert.False(t, fields.hasStats())
+
+	_, err = getFields(makeHTTPRequest("http://localhost:8080/api/v3.0/stats?fields=bogus", t))
+	assert.NotNil(t, err)
+}
+
+func TestGzipResponseWriter(t *testing.T) {
+	r := makeHTTPRequest("http://localhost:8080/api/v3.0/machine", t)
+	r.Header.Set("Accept-Encoding", "deflate, gzip;q=0.8")
+	assert.True(t, acceptsGzip(r))
+
+	rec := httptest.NewRecorder()
+	gw := &gzipResponseWriter{ResponseWriter: rec}
+	assert.Nil(t, writeResult(map[string]string{"key": "value"}, gw))
+	assert.Nil(t, gw.Close())
+	assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+
+	gr, err := gzip.NewReader(rec.Body)
+	assert.Nil(t, err)
+	out, err := ioutil.ReadAll(gr)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"key":"value"}`, string(out))
+}
+
+func TestContainerFilter(t *testing.T) {
+	r := makeHTTPRequest("http://localhost:8080/api/v2.1/containers?label=com.aptible.app=web&label=com.aptible.env&image=nginx*&name=^/docker/", t)
+	filter, err := getContainerFilter(r)
+	assert.Nil(t, err)
+
+	spec := &v2.ContainerSpec{
+		Labels: map[string]string{"com.aptible.app": "web", "com.aptible.env": "prod"},
+		Image:  "nginx:1.9",
+	}
+	assert.True(t, filter.matches("/docker/abc", spec))
+	assert.False(t, filter.matches("/system.slice/docker.service", spec))
+
+	spec.Aliases = []string{"/docker/web"}
+	assert.True(t, filter.matches("/abc", spec))
+
+	spec.Image = "redis:3"
+	assert.False(t, filter.matches("/docker/abc", spec))
+
+	spec.Image = "nginx"
+	spec.Labels["com.aptible.app"] = "worker"
+	assert.False(t, filter.matches("/docker/abc", spec))
+
+	delete(spec.Labels, "com.aptible.env")
+	spec.Labels["com.aptible.app"] = "web"
+	assert.False(t, filter.matches("/docker/abc", spec))
+
+	_, err = getContainerFilter(makeHTTPRequest("http://localhost:8080/api/v2.1/containers?name=(", t))
+	assert.NotNil(t, err)
+	_, err = getContainerFilter(makeHTTPRequest("http://localhost:8080/api/v2.1/containers?image=[", t))
+	assert.NotNil(t, err)
+}
+
+func TestRestrictApiVersions(t *testing.T) {
+	disabled, err := getDisabledRequestTypes("processes, events")
+	assert.Nil(t, err)
+	assert.True(t, disabled[psApi])
+	assert.True(t, disabled[eventsApi])
+	assert.False(t, disabled[statsApi])
+
+	versions := restrictApiVersions([]ApiVersion{newVersion2_0()}, disabled)
+	assert.NotContains(t, versions[0].SupportedRequestTypes(), psApi)
+	assert.Contains(t, versions[0].SupportedRequestTypes(), statsApi)
+	err = versions[0].HandleRequest(psApi, nil, nil, nil, makeHTTPRequest("http://localhost:8080/api/v2.0/ps", t))
+	assert.Equal(t, http.StatusForbidden, err.(*statusError).status)
+
+	_, err = getDisabledRequestTypes("bogus")
+	assert.NotNil(t, err)
+}
+
+func TestClientLimiter(t *testing.T) {
+	l := newClientLimiter(1, 2)
+	now := time.Unix(1450000000, 0)
+	assert.True(t, l.allow("10.0.0.1", now))
+	assert.True(t, l.allow("10.0.0.1", now))
+	assert.False(t, l.allow("10.0.0.1", now))
+	// Other clients have their own bucket.
+	assert.True(t, l.allow("10.0.0.2", now))
+	// One token is added every second.
+	assert.True(t, l.allow("10.0.0.1", now.Add(time.Second)))
+	assert.False(t, l.allow("10.0.0.1", now.Add(time.Second)))
+
+	// Idle clients are dropped.
+	l.allow("10.0.0.3", now.Add(2*limiterCleanupInterval))
+	assert.Len(t, l.buckets, 1)
+}
+
+func TestLimitedVersion(t *testing.T) {
+	versions := limitApiVersions([]ApiVersion{newVersion2_0()}, 1)
+	v := versions[0].(*limitedVersion)
+	v.inFlight <- struct{}{}
+
+	rec := httptest.NewRecorder()
+	err := v.HandleRequest(statsApi, nil, nil, rec, makeHTTPRequest("http://localhost:8080/api/v2.0/stats?recursive=true", t))
+	assert.Equal(t, http.StatusServiceUnavailable, err.(*statusError).status)
+	assert.True(t, isExpensive(psApi, makeHTTPRequest("http://localhost:8080/api/v2.0/ps", t)))
+	assert.False(t, isExpensive(statsApi, makeHTTPRequest("http://localhost:8080/api/v2.0/stats/docker", t)))
+}
+
+// Blocks the requests waiting for changes until released.
+type waitingVersion struct {
+	ApiVersion
+	waiting chan struct{}
+	release chan struct{}
+}
+
+func (self *waitingVersion) HandleRequest(requestType string, request []string, m manager.Manager, w http.ResponseWriter, r *http.Request) error {
+	if r.URL.Query().Get("wait") != "" {
+		self.waiting <- struct{}{}
+		<-self.release
+	}
+	return nil
+}
+
+func TestLimitedVersionWaitingRequests(t *testing.T) {
+	const waiting = 4
+	v := &waitingVersion{
+		ApiVersion: newVersion2_1(newVersion2_0(), nil),
+		waiting:    make(chan struct{}),
+		release:    make(chan struct{}),
+	}
+	limited := limitApiVersions([]ApiVersion{v}, 1)[0]
+	errs := make(chan error, waiting)
+	for i := 0; i < waiting; i++ {
+		r := makeHTTPRequest("http://localhost:8080/api/v2.1/containers/?since=1&wait=1m", t)
+		go func() {
+			errs <- limited.HandleRequest(containersApi, nil, nil, httptest.NewRecorder(), r)
+		}()
+		select {
+		case <-v.waiting:
+		case err := <-errs:
+			t.Fatalf("waiting request %d failed: %v", i, err)
+		}
+	}
+
+	// The waiting requests hold no slot.
+	assert.NoError(t, limited.HandleRequest(psApi, nil, nil, httptest.NewRecorder(), makeHTTPRequest("http://localhost:8080/api/v2.1/ps", t)))
+	close(v.release)
+	for i := 0; i < waiting; i++ {
+		assert.NoError(t, <-errs)
+	}
+}
+
+// Serves the machine info of its revision of the specs.
+type revisionsManager struct {
+	manager.Manager
+	specs, stats uint64
+	computed     int
+}
+
+func (self *revisionsManager) Revisions() (uint64, uint64) {
+	return atomic.LoadUint64(&self.specs), atomic.LoadUint64(&self.stats)
+}
+
+func (self *revisionsManager) GetMachineInfo() (*info.MachineInfo, error) {
+	self.computed++
+	return &info.MachineInfo{NumCores: 2 * int(self.specs)}, nil
+}
+
+func TestCachedVersion(t *testing.T) {
+	m := &revisionsManager{specs: 1, stats: 5}
+	v := cacheApiVersions([]ApiVersion{&version1_0{}}, 8)[0]
+	get := func(etag string) *httptest.ResponseRecorder {
+		r := makeHTTPRequest("http://localhost:8080/api/v1.0/machine", t)
+		if etag != "" {
+			r.Header.Set("If-None-Match", etag)
+		}
+		rec := httptest.NewRecorder()
+		assert.NoError(t, v.HandleRequest(machineApi, nil, m, rec, r))
+		return rec
+	}
+
+	first := get("")
+	assert.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	assert.Contains(t, first.Body.String(), `"num_cores":2`)
+
+	// The machine info doesn't depend on the stats: the response is served
+	// from the cache, and not modified for the client holding it.
+	m.stats++
+	cached := get("")
+	assert.Equal(t, etag, cached.Header().Get("ETag"))
+	assert.Equal(t, first.Body.String(), cached.Body.String())
+	assert.Equal(t, "application/json", cached.Header().Get("Content-Type"))
+	assert.Equal(t, http.StatusNotModified, get(`"other", `+etag).Code)
+	assert.Equal(t, 1, m.computed)
+
+	// The specs changed.
+	m.specs++
+	changed := get(etag)
+	assert.Equal(t, http.StatusOK, changed.Code)
+	assert.NotEqual(t, etag, changed.Header().Get("ETag"))
+	assert.Contains(t, changed.Body.String(), `"num_cores":4`)
+	assert.Equal(t, 2, m.computed)
+
+	// The responses depending on the stats change with them, and other
+	// requests have no ETag.
+	assert.NotEqual(t, revisionsETag(false, 1, 5), revisionsETag(false, 1, 6))
+	_, ok := responseRevisions(eventsApi, makeHTTPRequest("http://localhost:8080/api/v2.0/events", t))
+	assert.False(t, ok)
+}
+
+func TestGetSinceOptions(t *testing.T) {
+	since, wait, err := getSinceOptions(makeHTTPRequest("http://localhost:8080/api/v2.1/stats?since=12&wait=2h", t))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(12), since)
+	assert.Equal(t, maxChangesWait, wait)
+
+	for _, query := range []string{"since=-1", "since=1&wait=soon", "wait=1s"} {
+		_, _, err := getSinceOptions(makeHTTPRequest("http://localhost:8080/api/v2.1/stats?"+query, t))
+		assert.Error(t, err, query)
+	}
+}
+
+func TestWaitForChanges(t *testing.T) {
+	defer func(interval time.Duration) { changesPollInterval = interval }(changesPollInterval)
+	changesPollInterval = time.Millisecond
+	m := &revisionsManager{specs: 1, stats: 5}
+	fetches := 0
+	unchanged := func() (bool, error) {
+		fetches++
+		return false, nil
+	}
+
+	// Without waiting, the changes are fetched once.
+	rec := httptest.NewRecorder()
+	require.NoError(t, waitForChanges(m, false, 0, rec, unchanged))
+	assert.Equal(t, 1, fetches)
+	assert.Equal(t, "5", rec.Header().Get(revisionHeader))
+
+	// They are fetched again when the revision changes.
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddUint64(&m.stats, 1)
+	}()
+	fetches = 0
+	rec = httptest.NewRecorder()
+	require.NoError(t, waitForChanges(m, false, time.Minute, rec, func() (bool, error) {
+		fetches++
+		return fetches > 1, nil
+	}))
+	assert.Equal(t, 2, fetches)
+	assert.Equal(t, "6", rec.Header().Get(revisionHeader))
+
+	// Until the wait elapses.
+	fetches = 0
+	rec = httptest.NewRecorder()
+	start := time.Now()
+	require.NoError(t, waitForChanges(m, true, 20*time.Millisecond, rec, unchanged))
+	assert.True(t, time.Since(start) >= 20*time.Millisecond)
+	assert.Equal(t, 1, fetches)
+	assert.Equal(t, "1", rec.Header().Get(revisionHeader))
+}
+
+func TestNegotiateContentType(t *testing.T) {
+	offers := []string{jsonContentType, msgpackContentType, protobufContentType}
+	for accept, expected := range map[string]string{
+		"":                                       jsonContentType,
+		"*/*":                                    jsonContentType,
+		"text/html":                              jsonContentType,
+		"application/x-protobuf":                 protobufContentType,
+		"application/x-msgpack":                  msgpackContentType,
+		"application/json;q=0.5, */*;q=0.8":      msgpackContentType,
+		"application/msgpack;q=0.2, */*":         jsonContentType,
+		"application/*;q=0, application/msgpack": msgpackContentType,
+	} {
+		r := makeHTTPRequest("http://localhost:8080/api/v2.1/stats", t)
+		r.Header.Set("Accept", accept)
+		assert.Equal(t, expected, negotiateContentType(r, offers...), accept)
+	}
+}
+
+func TestWriteEncodedResult(t *testing.T) {
+	conts := map[string]v2.ContainerInfo{
+		"/b": {Stats: []*v2.ContainerStats{{Timestamp: time.Unix(1, 0), Cpu: &info.CpuStats{Usage: info.CpuUsage{Total: 3, PerCpu: []uint64{1, 2}}}}}},
+		"/a": {},
+	}
+	write := func(accept string) *httptest.ResponseRecorder {
+		r := makeHTTPRequest("http://localhost:8080/api/v2.1/stats", t)
+		r.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		require.NoError(t, writeEncodedResult(conts, func() proto.Message {
+			return rpc.ContainersToProto(conts)
+		}, rec, r))
+		assert.Equal(t, "Accept", rec.Header().Get("Vary"))
+		return rec
+	}
+
+	rec := write("")
+	assert.Equal(t, jsonContentType, rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), `"per_cpu_usage":[1,2]`)
+
+	rec = write(protobufContentType)
+	assert.Equal(t, protobufContentType, rec.Header().Get("Content-Type"))
+	var resp pb.ContainerInfoResponse
+	require.NoError(t, proto.Unmarshal(rec.Body.Bytes(), &resp))
+	if !assert.Len(t, resp.Containers, 2) {
+		return
+	}
+	assert.Equal(t, "/a", resp.Containers[0].Name)
+	assert.Equal(t, []uint64{1, 2}, resp.Containers[1].Stats[0].Cpu.Usage.PerCpuUsage)
+	assert.Equal(t, time.Unix(1, 0).UnixNano(), resp.Containers[1].Stats[0].Timestamp)
+
+	rec = write(msgpackContentType)
+	assert.Equal(t, msgpackContentType, rec.Header().Get("Content-Type"))
+	out, err := msgpack.Marshal(conts)
+	require.NoError(t, err)
+	assert.Equal(t, out, rec.Body.Bytes())
+
+	// The representations have their own ETags.
+	r := makeHTTPRequest("http://localhost:8080/api/v2.1/stats", t)
+	assert.Equal(t, `"1-2"`, encodingETag(`"1-2"`, r))
+	r.Header.Set("Accept", protobufContentType)
+	assert.Equal(t, `"1-2-pb"`, encodingETag(`"1-2"`, r))
+}
+
+func TestDatasourceDatapoints(t *testing.T) {
+	name, metric, err := parseDatasourceTarget("/docker/a:cpu_usage")
+	assert.Nil(t, err)
+	assert.Equal(t, "/docker/a", name)
+	_, _, err = parseDatasourceTarget("/docker/a:unknown")
+	assert.NotNil(t, err)
+	_, _, err = parseDatasourceTarget("/docker/a")
+	assert.NotNil(t, err)
+
+	start := time.Unix(100, 0)
+	cont := &info.ContainerInfo{Spec: info.ContainerSpec{HasCpu: true, HasMemory: true}}
+	for i, total := range []uint64{0, 1e9, 3e9, 0} {
+		stats := &info.ContainerStats{Timestamp: start.Add(time.Duration(i) * 2 * time.Second)}
+		stats.Cpu.Usage.Total = total
+		stats.Memory.WorkingSet = uint64(i) * 1024
+		cont.Stats = append(cont.Stats, stats)
+	}
+	// Rates skip the first sample and the counter reset.
+	assert.Equal(t, [][2]float64{{0.5, 102000}, {1, 104000}}, getDatapoints(metric, cont))
+	assert.Equal(t, [][2]float64{{0, 100000}, {1024, 102000}, {2048, 104000}, {3072, 106000}}, getDatapoints(datasourceMetrics["memory_working_set"], cont))
+	assert.Empty(t, getDatapoints(datasourceMetrics["network_rx_bytes"], cont))
+
+	points := [][2]float64{{0, 0}, {1, 1}, {2, 2}, {3, 3}, {4, 4}}
+	assert.Equal(t, points, thinDatapoints(points, 0))
+	assert.Equal(t, [][2]float64{{0, 0}, {2, 2}, {4, 4}}, thinDatapoints(points, 3))
+	assert.Equal(t, [][2]float64{{4, 4}}, thinDatapoints(points, 1))
+}
+
+func TestWriteStatsCsv(t *testing.T) {
+	start := time.Date(2016, 1, 2, 15, 4, 5, 0, time.UTC)
+	var stats []*info.ContainerStats
+	for i := 0; i < 2; i++ {
+		s := &info.ContainerStats{Timestamp: start.Add(time.Duration(i) * time.Second)}
+		s.Cpu.Usage.Total = uint64(i) * 500000000
+		s.Memory.WorkingSet = 1024
+		s.Filesystem = []info.FsStats{{Usage: 10, Limit: 100}, {Usage: 5, Limit: 50}}
+		stats = append(stats, s)
+	}
+	var out bytes.Buffer
+	assert.Nil(t, writeStatsCsv(&out, stats))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Len(t, lines, 3)
+	assert.Equal(t, strings.Join(exportCsvHeader, ","), lines[0])
+	assert.Equal(t, "2016-01-02T15:04:05Z,0,0,0,,0,1024,0,0,0,0,0,15,150", lines[1])
+	assert.Equal(t, "2016-01-02T15:04:06Z,500000000,0,0,0.5000,0,1024,0,0,0,0,0,15,150", lines[2])
+
+	assert.Equal(t, "cadvisor-docker-web-1-20160102-150405.png", getExportFileName("/docker/web 1", start, "png"))
+	assert.Equal(t, "cadvisor-root-20160102-150405.csv", getExportFileName("/", start, "csv"))
+}
+
+func TestBundleWriter(t *testing.T) {
+	var buf bytes.Buffer
+	bundle := newBundleWriter(&buf, time.Date(2016, 3, 4, 5, 6, 7, 0, time.UTC))
+	assert.NoError(t, bundle.addJson("machine.json", info.MachineInfo{NumCores: 2}, nil))
+	assert.NoError(t, bundle.addJson("specs.json", nil, fmt.Errorf("no specs")))
+	assert.NoError(t, bundle.addProfile("goroutine.txt", "goroutine", 1))
+	assert.NoError(t, bundle.Close())
+
+	gz, err := gzip.NewReader(&buf)
+	assert.NoError(t, err)
+	files := make(map[string]string)
+	archive := tar.NewReader(gz)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		data, err := ioutil.ReadAll(archive)
+		assert.NoError(t, err)
+		files[header.Name] = string(data)
+	}
+	assert.Len(t, files, 3)
+	assert.Contains(t, files["cadvisor-bundle-20160304T050607Z/machine.json"], `"num_cores": 2`)
+	assert.Contains(t, files["cadvisor-bundle-20160304T050607Z/goroutine.txt"], "goroutine profile")
+	assert.Equal(t, "specs.json: no specs\n", files["cadvisor-bundle-20160304T050607Z/errors.txt"])
+}
+
+func TestQuery(t *testing.T) {
+	for _, expr := range []string{"", "unknown", "cpu_usage_total[1m]", "rate(cpu_usage_total)", "sum(memory_usage", `memory_usage{name=~"("}`, "memory_usage{name=1}", "rate(cpu_usage_total[1x])"} {
+		_, err := parseQuery(expr)
+		assert.NotNil(t, err, "expression %q", expr)
+	}
+
+	now := time.Unix(1000, 0)
+	var containers []*info.ContainerInfo
+	for i, name := range []string{"/docker/a", "/docker/b", "/docker/c"} {
+		cont := &info.ContainerInfo{
+			ContainerReference: info.ContainerReference{Name: name, Aliases: []string{fmt.Sprintf("app-%d", i)}},
+			Spec:               info.ContainerSpec{HasCpu: true, HasMemory: true, HasNetwork: i < 2, Image: "web"},
+		}
+		if i == 2 {
+			cont.Spec.Image = "db"
+		}
+		// The counter of the second container is reset.
+		for j, total := range [][]uint64{{0, 1e9, 4e9}, {1e9, 5e9, 2e9}, {0, 2e9, 4e9}}[i] {
+			stats := &info.ContainerStats{Timestamp: now.Add(time.Duration(j-2) * 20 * time.Second)}
+			stats.Cpu.Usage.Total = total
+			stats.Memory.Usage = uint64(i+1) * 1024
+			if i == 1 && j == 2 {
+				stats.SetError(info.NetworkStatsSubsystem, fmt.Errorf("no interface"))
+			} else {
+				stats.Network.RxBytes = 100
+			}
+			cont.Stats = append(cont.Stats, stats)
+		}
+		containers = append(containers, cont)
+	}
+	ctx := &queryContext{containers: containers, now: now}
+	eval := func(expr string) []querySample {
+		parsed, err := parseQuery(expr)
+		if !assert.Nil(t, err, "expression %q", expr) {
+			return nil
+		}
+		samples := parsed.eval(ctx)
+		sort.Sort(querySamplesByLabels(samples))
+		return samples
+	}
+
+	samples := eval(`rate(cpu_usage_total[1m]) by (container)`)
+	assert.Equal(t, []querySample{
+		{Labels: map[string]string{"container": "/docker/a"}, Value: 0.1},
+		{Labels: map[string]string{"container": "/docker/b"}, Value: 0.15},
+		{Labels: map[string]string{"container": "/docker/c"}, Value: 0.1},
+	}, samples)
+	assert.Equal(t, []querySample{
+		{Labels: map[string]string{"image": "db"}, Value: 4},
+		{Labels: map[string]string{"image": "web"}, Value: 10},
+	}, eval(`sum by (image) (increase(cpu_usage_total[1m]))`))
+	assert.Equal(t, []querySample{{Labels: map[string]string{}, Value: 2048}}, eval(`avg(memory_usage)`))
+	assert.Equal(t, []querySample{{Labels: map[string]string{}, Value: 2}}, eval(`count(memory_usage{image="web"})`))
+	assert.Equal(t, []querySample{{Labels: map[string]string{"name": "app-2"}, Value: 3072}}, eval(`max(memory_usage{name!~'app-[01]'}) by (name)`))
+	// The network stats which failed to be collected are skipped.
+	assert.Equal(t, 2, len(eval(`network_rx_bytes`)))
+	assert.Equal(t, 1, len(eval(`max_over_time(network_rx_bytes[10s])`)))
+}
+
+// Counts the signals sent.
+type signalManager struct {
+	manager.Manager
+	signaled int
+}
+
+func (self *signalManager) SignalProcess(containerName string, pid int, signal string) error {
+	self.signaled++
+	return nil
+}
+
+func TestSignalRequiresHeader(t *testing.T) {
+	enabled := *manager.EnableProcessSignals
+	*manager.EnableProcessSignals = true
+	defer func() {
+		*manager.EnableProcessSignals = enabled
+	}()
+	m := &signalManager{}
+	v := newVersion2_1(newVersion2_0(), nil)
+
+	r, err := http.NewRequest("POST", "http://localhost:8080/api/v2.1/signal/docker/abc?pid=42&signal=TERM", nil)
+	require.NoError(t, err)
+	err = v.HandleRequest(signalApi, []string{"docker", "abc"}, m, httptest.NewRecorder(), r)
+	if assert.Error(t, err) {
+		assert.Equal(t, http.StatusForbidden, err.(*statusError).status)
+	}
+	assert.Equal(t, 0, m.signaled)
+
+	r.Header.Set("X-Requested-With", "XMLHttpRequest")
+	assert.NoError(t, v.HandleRequest(signalApi, []string{"docker", "abc"}, m, httptest.NewRecorder(), r))
+	assert.Equal(t, 1, m.signaled)
+}
+
+// Serves empty containers.
+type containersManager struct {
+	manager.Manager
+}
+
+func (self *containersManager) GetContainerInfo(containerName string, query *info.ContainerInfoRequest) (*info.ContainerInfo, error) {
+	return &info.ContainerInfo{ContainerReference: info.ContainerReference{Name: containerName}}, nil
+}
+
+// Returns a handler of the API authenticating the tokens of a file with a
+// read and an admin token, with the given auth flags set.
+func newTestApiHandler(t *testing.T, m manager.Manager, flags map[string]string) http.HandlerFunc {
+	f, err := ioutil.TempFile("", "tokens")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	f.WriteString("read-token,read\nadmin-token,admin\n")
+	f.Close()
+	flags["api_token_file"] = f.Name()
+	for name, value := range flags {
+		f := flag.Lookup(name)
+		defer f.Value.Set(f.Value.String())
+		require.NoError(t, f.Value.Set(value))
+	}
+
+	authHandler, err := auth.New()
+	require.NoError(t, err)
+	versions := make(map[string]ApiVersion)
+	for _, v := range getApiVersions(nil) {
+		versions[v.Version()] = v
+	}
+	return newApiHandler(authHandler, versions, m)
+}
+
+func serveApi(h http.HandlerFunc, method, url, token string) int {
+	r, _ := http.NewRequest(method, url, strings.NewReader(`{"num_stats":1}`))
+	r.Header.Set("Authorization", "Bearer "+token)
+	rec := httptest.NewRecorder()
+	h(rec, r)
+	return rec.Code
+}
+
+func TestRequiredRole(t *testing.T) {
+	h := newTestApiHandler(t, &containersManager{}, map[string]string{})
+
+	// The v1 API and its client POST the queries of containers.
+	assert.Equal(t, http.StatusOK, serveApi(h, "POST", "http://localhost:8080/api/v1.3/containers/", "read-token"))
+	assert.Equal(t, http.StatusForbidden, serveApi(h, "POST", "http://localhost:8080/api/v2.1/signal/docker/abc?pid=42&signal=TERM", "read-token"))
+	assert.Equal(t, http.StatusForbidden, serveApi(h, "POST", "http://localhost:8080/api/v2.1/snapshot", "read-token"))
+	assert.Equal(t, http.StatusForbidden, serveApi(h, "GET", "http://localhost:8080/api/v2.1/config", "read-token"))
+	assert.Equal(t, http.StatusOK, serveApi(h, "GET", "http://localhost:8080/api/v2.1/config", "admin-token"))
+
+	// Federated requests need the role of the request proxied to the peer.
+	assert.Equal(t, auth.RoleRead, requiredRole(makeHTTPRequest("http://localhost:8080/api/v2.1/federate", t)))
+	assert.Equal(t, auth.RoleRead, requiredRole(makeHTTPRequest("http://localhost:8080/api/v2.1/federate/node1:8080/v2.1/machine", t)))
+	assert.Equal(t, http.StatusForbidden, serveApi(h, "POST", "http://localhost:8080/api/v2.1/federate/node1:8080/v2.1/signal/docker/abc?pid=42&signal=TERM", "read-token"))
+	assert.Equal(t, http.StatusForbidden, serveApi(h, "POST", "http://localhost:8080/api/v2.1/federate/node1:8080/v2.1/snapshot", "read-token"))
+	assert.Equal(t, http.StatusForbidden, serveApi(h, "GET", "http://localhost:8080/api/v2.1/federate/node1:8080/v2.1/federate/node2:8080/v2.1/config", "read-token"))
+}
+
+func TestReadOnlyApi(t *testing.T) {
+	h := newTestApiHandler(t, &containersManager{}, map[string]string{"read_only": "true"})
+
+	// Reads are served whatever their method, only changes are rejected.
+	assert.Equal(t, http.StatusOK, serveApi(h, "POST", "http://localhost:8080/api/v1.3/containers/", "read-token"))
+	assert.Equal(t, http.StatusOK, serveApi(h, "POST", "http://localhost:8080/api/v1.3/containers/", "admin-token"))
+	assert.Equal(t, http.StatusForbidden, serveApi(h, "POST", "http://localhost:8080/api/v2.1/snapshot", "admin-token"))
+	assert.Equal(t, http.StatusForbidden, serveApi(h, "POST", "http://localhost:8080/api/v2.1/loglevels?module=manager&level=4", "admin-token"))
+	assert.Equal(t, http.StatusForbidden, serveApi(h, "POST", "http://localhost:8080/api/v2.1/federate/node1:8080/v2.1/snapshot", "admin-token"))
+}
@@ -0,0 +1,186 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit logs the HTTP requests served by cAdvisor as JSON records.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/cadvisor/logging"
+)
+
+var argAuditLog = flag.String("audit_log", "", "file to append a JSON record of every API request to, or 'syslog' to send them to the local syslog. Empty disables the audit log")
+var argSampleRate = flag.Float64("audit_sample_rate", 1, "fraction of API requests recorded in the audit log, between 0 and 1")
+
+// Record of a served request.
+type Record struct {
+	Time   time.Time `json:"time"`
+	Client string    `json:"client"`
+	Method string    `json:"method"`
+	Path   string    `json:"path"`
+	Query  string    `json:"query,omitempty"`
+	Status int       `json:"status"`
+	// Size of the response body in bytes.
+	Size int64 `json:"size"`
+	// Time taken to serve the request in milliseconds.
+	LatencyMs float64 `json:"latency_ms"`
+}
+
+type Logger struct {
+	lock       sync.Mutex
+	out        io.Writer
+	sampleRate float64
+}
+
+// New returns the audit logger configured on the command line, or nil if the
+// audit log is disabled.
+func New() (*Logger, error) {
+	if *argAuditLog == "" {
+		return nil, nil
+	}
+	if *argSampleRate < 0 || *argSampleRate > 1 {
+		return nil, fmt.Errorf("audit sample rate %v is not between 0 and 1", *argSampleRate)
+	}
+	var out io.Writer
+	if *argAuditLog == "syslog" {
+		w, err := openSyslog()
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to syslog: %v", err)
+		}
+		out = w
+	} else {
+		f, err := os.OpenFile(*argAuditLog, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open audit log: %v", err)
+		}
+		out = f
+	}
+	return newLogger(out, *argSampleRate), nil
+}
+
+func newLogger(out io.Writer, sampleRate float64) *Logger {
+	return &Logger{
+		out:        out,
+		sampleRate: sampleRate,
+	}
+}
+
+func (self *Logger) log(record *Record) {
+	line, err := json.Marshal(record)
+	if err != nil {
+		logging.Errorf("Failed to marshal audit record %+v: %v", record, err)
+		return
+	}
+	line = append(line, '\n')
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if _, err := self.out.Write(line); err != nil {
+		logging.Errorf("Failed to write audit record: %v", err)
+	}
+}
+
+// Wrap returns a handler recording a sample of the requests served by h.
+// A nil logger records nothing.
+func (self *Logger) Wrap(h http.HandlerFunc) http.HandlerFunc {
+	if self == nil {
+		return h
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if self.sampleRate < 1 && rand.Float64() >= self.sampleRate {
+			h(w, r)
+			return
+		}
+		start := time.Now()
+		rw := &responseRecorder{ResponseWriter: w}
+		h(rw, r)
+		client, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			client = r.RemoteAddr
+		}
+		status := rw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		self.log(&Record{
+			Time:      start,
+			Client:    client,
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			Query:     r.URL.RawQuery,
+			Status:    status,
+			Size:      rw.size,
+			LatencyMs: float64(time.Since(start)) / float64(time.Millisecond),
+		})
+	}
+}
+
+// Records the status and size of a response. Streaming and WebSocket
+// support of the underlying ResponseWriter is kept.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+func (self *responseRecorder) WriteHeader(status int) {
+	if self.status == 0 {
+		self.status = status
+	}
+	self.ResponseWriter.WriteHeader(status)
+}
+
+func (self *responseRecorder) Write(b []byte) (int, error) {
+	if self.status == 0 {
+		self.status = http.StatusOK
+	}
+	n, err := self.ResponseWriter.Write(b)
+	self.size += int64(n)
+	return n, err
+}
+
+func (self *responseRecorder) Flush() {
+	if f, ok := self.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (self *responseRecorder) CloseNotify() <-chan bool {
+	if cn, ok := self.ResponseWriter.(http.CloseNotifier); ok {
+		return cn.CloseNotify()
+	}
+	return make(chan bool)
+}
+
+func (self *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := self.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("connection cannot be hijacked")
+	}
+	if self.status == 0 {
+		self.status = http.StatusSwitchingProtocols
+	}
+	return h.Hijack()
+}
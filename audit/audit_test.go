@@ -0,0 +1,67 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrap(t *testing.T) {
+	var out bytes.Buffer
+	logger := newLogger(&out, 1)
+	h := logger.Wrap(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusForbidden)
+	})
+
+	r, err := http.NewRequest("GET", "http://localhost:8080/api/v2.0/ps?type=docker", nil)
+	require.NoError(t, err)
+	r.RemoteAddr = "10.0.0.1:51234"
+	h(httptest.NewRecorder(), r)
+
+	var record Record
+	require.NoError(t, json.Unmarshal(out.Bytes(), &record))
+	assert.Equal(t, "10.0.0.1", record.Client)
+	assert.Equal(t, "GET", record.Method)
+	assert.Equal(t, "/api/v2.0/ps", record.Path)
+	assert.Equal(t, "type=docker", record.Query)
+	assert.Equal(t, http.StatusForbidden, record.Status)
+	assert.Equal(t, int64(len("nope\n")), record.Size)
+}
+
+func TestSampling(t *testing.T) {
+	var out bytes.Buffer
+	h := newLogger(&out, 0).Wrap(func(w http.ResponseWriter, r *http.Request) {})
+	r, err := http.NewRequest("GET", "http://localhost:8080/api/v2.0/machine", nil)
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		h(httptest.NewRecorder(), r)
+	}
+	assert.Empty(t, strings.TrimSpace(out.String()))
+}
+
+func TestNilLogger(t *testing.T) {
+	var logger *Logger
+	called := false
+	logger.Wrap(func(w http.ResponseWriter, r *http.Request) { called = true })(nil, nil)
+	assert.True(t, called)
+}
@@ -0,0 +1,284 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package auth authenticates and authorizes API clients using bearer tokens,
+// TLS client certificates, an external command or an OpenID Connect provider.
+package auth
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/google/cadvisor/logging"
+)
+
+var argTokenFile = flag.String("api_token_file", "", "file of 'token,role[,name]' lines granting bearer tokens access to the API. Roles are 'read' and 'admin'")
+var argJwtKeyFile = flag.String("api_jwt_key_file", "", "PEM RSA public key (RS256) or shared secret (HS256) validating JWT bearer tokens for the API")
+var argJwtAudience = flag.String("api_jwt_audience", "", "audience JWT bearer tokens must be issued for. Empty accepts any audience")
+var argClientCertAuth = flag.Bool("api_client_cert_auth", false, "authenticate API clients by their verified TLS client certificate, with read access")
+var argAdminCNs = flag.String("api_admin_cns", "", "comma separated common names of TLS client certificates granted admin access to the API")
+var argReadOnly = flag.Bool("read_only", false, "reject the API requests requiring the admin role: signals, snapshot restores, log level changes and the config, whoever makes them")
+
+// Roles granted to API clients. Admins can also read.
+const (
+	RoleRead  = "read"
+	RoleAdmin = "admin"
+)
+
+func validRole(role string) bool {
+	return role == RoleRead || role == RoleAdmin
+}
+
+// Identity of an authenticated API client.
+type Identity struct {
+	Name string
+	Role string
+}
+
+// Allowed returns whether the identity was granted the given role.
+func (self *Identity) Allowed(role string) bool {
+	return self.Role == RoleAdmin || self.Role == role
+}
+
+// Authenticator identifies the client of a request. It returns a nil identity
+// and no error when the request carries no credentials it understands.
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Identity, error)
+}
+
+// RequiredRole returns the role needed to serve the request: requests that
+// may modify state require admin access. Handlers whose POSTs only read state
+// should give the role of their requests with WrapRole instead.
+func RequiredRole(r *http.Request) string {
+	switch r.Method {
+	case "GET", "HEAD", "OPTIONS":
+		return RoleRead
+	default:
+		return RoleAdmin
+	}
+}
+
+// Handler restricts access to HTTP handlers to authorized clients.
+type Handler struct {
+	authenticators []Authenticator
+	// Whether requests requiring the admin role are always rejected.
+	readOnly bool
+}
+
+// New returns a handler using the authenticators configured on the command
+// line. It lets all requests through if none are configured and the read-only
+// mode is off.
+func New() (*Handler, error) {
+	self := &Handler{
+		readOnly: *argReadOnly,
+	}
+	if *argTokenFile != "" {
+		tokens, err := newTokenFile(*argTokenFile)
+		if err != nil {
+			return nil, err
+		}
+		self.Add(tokens)
+	}
+	if *argJwtKeyFile != "" {
+		validator, err := newJwtValidator(*argJwtKeyFile, *argJwtAudience)
+		if err != nil {
+			return nil, err
+		}
+		self.Add(validator)
+	}
+	if *argAuthCommand != "" {
+		self.Add(newCommandAuthenticator(*argAuthCommand, *argAuthCommandTimeout))
+	}
+	oidc, err := GetOIDC()
+	if err != nil {
+		return nil, err
+	}
+	if oidc != nil {
+		self.Add(oidc)
+	}
+	if *argClientCertAuth {
+		self.Add(newClientCertAuthenticator(*argAdminCNs))
+	}
+	return self, nil
+}
+
+// Enabled returns whether requests are authenticated.
+func (self *Handler) Enabled() bool {
+	return len(self.authenticators) > 0
+}
+
+// Add appends an authenticator. The identity of a request is given by the
+// first authenticator that accepts its credentials.
+func (self *Handler) Add(a Authenticator) {
+	self.authenticators = append(self.authenticators, a)
+}
+
+// Returns the first identity found and otherwise the first error, as a token
+// rejected by one authenticator may be meant for another.
+func (self *Handler) authenticate(r *http.Request) (*Identity, error) {
+	var firstErr error
+	for _, a := range self.authenticators {
+		id, err := a.Authenticate(r)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if id != nil {
+			return id, nil
+		}
+	}
+	return nil, firstErr
+}
+
+// Wrap returns a handler serving only the requests of authorized clients.
+func (self *Handler) Wrap(h http.HandlerFunc) http.HandlerFunc {
+	return self.WrapRole(h, RequiredRole)
+}
+
+// WrapRead is like Wrap for handlers whose requests, POSTs included, only
+// read state and require the read role.
+func (self *Handler) WrapRead(h http.HandlerFunc) http.HandlerFunc {
+	return self.WrapRole(h, func(*http.Request) string {
+		return RoleRead
+	})
+}
+
+// WrapRole is like Wrap with the role needed to serve each request given by
+// requiredRole.
+func (self *Handler) WrapRole(h http.HandlerFunc, requiredRole func(*http.Request) string) http.HandlerFunc {
+	if len(self.authenticators) == 0 && !self.readOnly {
+		return h
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		role := requiredRole(r)
+		if self.readOnly && role == RoleAdmin {
+			http.Error(w, "cAdvisor is in read-only mode", http.StatusForbidden)
+			return
+		}
+		if len(self.authenticators) == 0 {
+			h(w, r)
+			return
+		}
+		id, err := self.authenticate(r)
+		if err != nil {
+			logging.V(2).Infof("Rejecting API request from %s: %v", r.RemoteAddr, err)
+		}
+		if id == nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="cadvisor"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if !id.Allowed(role) {
+			http.Error(w, fmt.Sprintf("%q is not allowed %s access", id.Name, role), http.StatusForbidden)
+			return
+		}
+		h(w, r)
+	}
+}
+
+// Returns the bearer token of the request, if any.
+func bearerToken(r *http.Request) string {
+	const prefix = "Bearer "
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, prefix) {
+		return ""
+	}
+	return strings.TrimSpace(header[len(prefix):])
+}
+
+// Static bearer tokens.
+type tokenFile map[string]Identity
+
+func newTokenFile(path string) (tokenFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open token file: %v", err)
+	}
+	defer f.Close()
+	tokens := make(tokenFile)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		if len(fields) < 2 || len(fields) > 3 || fields[0] == "" || !validRole(fields[1]) {
+			return nil, fmt.Errorf("invalid line %d in token file %q, expected 'token,role[,name]'", n, path)
+		}
+		id := Identity{
+			Name: fmt.Sprintf("token on line %d", n),
+			Role: fields[1],
+		}
+		if len(fields) == 3 {
+			id.Name = fields[2]
+		}
+		tokens[fields[0]] = id
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read token file: %v", err)
+	}
+	return tokens, nil
+}
+
+func (self tokenFile) Authenticate(r *http.Request) (*Identity, error) {
+	token := bearerToken(r)
+	if token == "" {
+		return nil, nil
+	}
+	id, ok := self[token]
+	if !ok {
+		// Leave the token to other authenticators, it may be a JWT.
+		return nil, nil
+	}
+	return &id, nil
+}
+
+// Clients presenting a TLS certificate verified against the client CA.
+type clientCertAuthenticator struct {
+	admins map[string]bool
+}
+
+func newClientCertAuthenticator(adminCNs string) *clientCertAuthenticator {
+	self := &clientCertAuthenticator{
+		admins: make(map[string]bool),
+	}
+	for _, cn := range strings.Split(adminCNs, ",") {
+		if cn = strings.TrimSpace(cn); cn != "" {
+			self.admins[cn] = true
+		}
+	}
+	return self
+}
+
+func (self *clientCertAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, nil
+	}
+	cn := r.TLS.VerifiedChains[0][0].Subject.CommonName
+	id := &Identity{
+		Name: cn,
+		Role: RoleRead,
+	}
+	if self.admins[cn] {
+		id.Role = RoleAdmin
+	}
+	return id, nil
+}
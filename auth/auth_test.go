@@ -0,0 +1,145 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRequest(method, token string) *http.Request {
+	r, _ := http.NewRequest(method, "http://localhost:8080/api/v2.0/machine", nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r
+}
+
+func serve(h *Handler, r *http.Request) int {
+	rec := httptest.NewRecorder()
+	h.Wrap(func(w http.ResponseWriter, r *http.Request) {})(rec, r)
+	return rec.Code
+}
+
+func TestTokenFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "tokens")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	f.WriteString("# Comment\nreader-token,read\nadmin-token,admin,ops\n")
+	f.Close()
+
+	tokens, err := newTokenFile(f.Name())
+	require.NoError(t, err)
+	h := &Handler{}
+	h.Add(tokens)
+
+	assert.Equal(t, http.StatusUnauthorized, serve(h, newRequest("GET", "")))
+	assert.Equal(t, http.StatusUnauthorized, serve(h, newRequest("GET", "bogus")))
+	assert.Equal(t, http.StatusOK, serve(h, newRequest("GET", "reader-token")))
+	assert.Equal(t, http.StatusForbidden, serve(h, newRequest("POST", "reader-token")))
+	assert.Equal(t, http.StatusOK, serve(h, newRequest("POST", "admin-token")))
+}
+
+func TestNoAuthenticators(t *testing.T) {
+	assert.Equal(t, http.StatusOK, serve(&Handler{}, newRequest("POST", "")))
+}
+
+func hs256(secret, claims string) string {
+	signed := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + base64.RawURLEncoding.EncodeToString([]byte(claims))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signed))
+	return signed + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestJwt(t *testing.T) {
+	v := &jwtValidator{
+		secret:   []byte("secret"),
+		audience: "cadvisor",
+		now:      func() time.Time { return time.Unix(1000, 0) },
+	}
+
+	id, err := v.Authenticate(newRequest("GET", hs256("secret", `{"sub":"ops","aud":["cadvisor"],"exp":2000,"role":"admin"}`)))
+	require.NoError(t, err)
+	assert.Equal(t, &Identity{Name: "ops", Role: RoleAdmin}, id)
+
+	id, err = v.Authenticate(newRequest("GET", hs256("secret", `{"sub":"dash","aud":"cadvisor"}`)))
+	require.NoError(t, err)
+	assert.Equal(t, RoleRead, id.Role)
+
+	_, err = v.Authenticate(newRequest("GET", hs256("wrong", `{"sub":"ops","aud":"cadvisor"}`)))
+	assert.Error(t, err)
+	_, err = v.Authenticate(newRequest("GET", hs256("secret", `{"sub":"ops","aud":"cadvisor","exp":500}`)))
+	assert.Error(t, err)
+	_, err = v.Authenticate(newRequest("GET", hs256("secret", `{"sub":"ops","aud":"other"}`)))
+	assert.Error(t, err)
+	_, err = v.Authenticate(newRequest("GET", "not-a-jwt"))
+	assert.Error(t, err)
+}
+
+func TestClientCert(t *testing.T) {
+	a := newClientCertAuthenticator("ops, deploy")
+	r := newRequest("GET", "")
+	id, err := a.Authenticate(r)
+	assert.NoError(t, err)
+	assert.Nil(t, id)
+
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "ops"}}
+	r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	id, err = a.Authenticate(r)
+	assert.NoError(t, err)
+	assert.Equal(t, &Identity{Name: "ops", Role: RoleAdmin}, id)
+
+	cert.Subject.CommonName = "dashboard"
+	id, err = a.Authenticate(r)
+	assert.NoError(t, err)
+	assert.Equal(t, RoleRead, id.Role)
+}
+
+func TestReadOnly(t *testing.T) {
+	h := &Handler{readOnly: true}
+	assert.Equal(t, http.StatusOK, serve(h, newRequest("GET", "")))
+	assert.Equal(t, http.StatusForbidden, serve(h, newRequest("POST", "")))
+
+	// Admins are rejected too.
+	h.Add(tokenFile{"admin-token": {Name: "ops", Role: RoleAdmin}})
+	assert.Equal(t, http.StatusForbidden, serve(h, newRequest("POST", "admin-token")))
+	assert.Equal(t, http.StatusOK, serve(h, newRequest("GET", "admin-token")))
+}
+
+func TestWrapRead(t *testing.T) {
+	h := &Handler{readOnly: true}
+	h.Add(tokenFile{"read-token": {Name: "grafana", Role: RoleRead}})
+	handler := h.WrapRead(func(w http.ResponseWriter, r *http.Request) {})
+	serveRead := func(r *http.Request) int {
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w.Code
+	}
+	assert.Equal(t, http.StatusOK, serveRead(newRequest("POST", "read-token")))
+	assert.Equal(t, http.StatusUnauthorized, serveRead(newRequest("POST", "")))
+}
@@ -0,0 +1,152 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+var argAuthCommand = flag.String("api_auth_command", "", "command validating API bearer tokens. It reads the token on stdin and, to accept it, exits with status 0 after printing {\"name\": ..., \"role\": ...} as JSON")
+var argAuthCommandTimeout = flag.Duration("api_auth_command_timeout", 5*time.Second, "time --api_auth_command is given to validate a token")
+
+const (
+	// How long the verdicts of the auth command on a token are reused.
+	commandCacheTime = 30 * time.Second
+	// Maximum number of cached verdicts.
+	maxCommandVerdicts = 10000
+)
+
+// Delegates the validation of bearer tokens to an external command.
+type commandAuthenticator struct {
+	command string
+	timeout time.Duration
+	now     func() time.Time
+
+	lock sync.Mutex
+	// Recent verdicts of the command keyed by token.
+	verdicts map[string]*commandVerdict
+	// When expired verdicts were last dropped.
+	lastSweep time.Time
+}
+
+// The identity the command accepted a token with, or the error it rejected
+// it with.
+type commandVerdict struct {
+	id      *Identity
+	err     error
+	expires time.Time
+}
+
+func newCommandAuthenticator(command string, timeout time.Duration) *commandAuthenticator {
+	return &commandAuthenticator{
+		command:  command,
+		timeout:  timeout,
+		now:      time.Now,
+		verdicts: make(map[string]*commandVerdict),
+	}
+}
+
+func (self *commandAuthenticator) Authenticate(r *http.Request) (*Identity, error) {
+	token := bearerToken(r)
+	if token == "" {
+		return nil, nil
+	}
+	now := self.now()
+	self.lock.Lock()
+	cached, ok := self.verdicts[token]
+	if ok && now.After(cached.expires) {
+		delete(self.verdicts, token)
+		ok = false
+	}
+	self.lock.Unlock()
+	if ok {
+		return cached.id, cached.err
+	}
+
+	id, completed, err := self.run(token)
+	// The command is run again if it failed to complete.
+	if !completed {
+		return nil, err
+	}
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if now.Sub(self.lastSweep) >= commandCacheTime || len(self.verdicts) >= maxCommandVerdicts {
+		self.sweepVerdicts(now)
+	}
+	self.verdicts[token] = &commandVerdict{id, err, now.Add(commandCacheTime)}
+	return id, err
+}
+
+// Runs the command on the token. Returns whether it completed, in which case
+// the result is its verdict.
+func (self *commandAuthenticator) run(token string) (*Identity, bool, error) {
+	cmd := exec.Command("/bin/sh", "-c", self.command)
+	cmd.Stdin = strings.NewReader(token + "\n")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return nil, false, fmt.Errorf("failed to run auth command: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return nil, true, fmt.Errorf("auth command rejected token: %v: %s", err, strings.TrimSpace(stderr.String()))
+		}
+	case <-time.After(self.timeout):
+		cmd.Process.Kill()
+		return nil, false, fmt.Errorf("auth command timed out after %v", self.timeout)
+	}
+	id := &Identity{}
+	if err := json.Unmarshal(stdout.Bytes(), id); err != nil {
+		return nil, true, fmt.Errorf("invalid output of auth command: %v", err)
+	}
+	if id.Role == "" {
+		id.Role = RoleRead
+	}
+	if !validRole(id.Role) {
+		return nil, true, fmt.Errorf("unknown role %q from auth command", id.Role)
+	}
+	return id, true, nil
+}
+
+// Drops the expired verdicts, and arbitrary ones while the cache is full.
+// Must be called with the lock held.
+func (self *commandAuthenticator) sweepVerdicts(now time.Time) {
+	for token, cached := range self.verdicts {
+		if now.After(cached.expires) {
+			delete(self.verdicts, token)
+		}
+	}
+	for token := range self.verdicts {
+		if len(self.verdicts) < maxCommandVerdicts {
+			break
+		}
+		delete(self.verdicts, token)
+	}
+	self.lastSweep = now
+}
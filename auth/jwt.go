@@ -0,0 +1,175 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Validates JWT bearer tokens signed with RS256 or HS256.
+type jwtValidator struct {
+	// Set for RS256.
+	publicKey *rsa.PublicKey
+	// Set for HS256.
+	secret   []byte
+	audience string
+	now      func() time.Time
+}
+
+// Claims of a JWT used by cAdvisor.
+type jwtClaims struct {
+	Subject   string      `json:"sub"`
+	Audience  interface{} `json:"aud"`
+	ExpiresAt int64       `json:"exp"`
+	NotBefore int64       `json:"nbf"`
+	// Role granted to the bearer, read by default.
+	Role string `json:"role"`
+}
+
+// Reads the key file: a PEM encoded RSA public key or a shared secret.
+func newJwtValidator(keyFile, audience string) (*jwtValidator, error) {
+	data, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWT key file: %v", err)
+	}
+	self := &jwtValidator{
+		audience: audience,
+		now:      time.Now,
+	}
+	if block, _ := pem.Decode(data); block != nil {
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse JWT public key: %v", err)
+		}
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("JWT public key is not an RSA key")
+		}
+		self.publicKey = rsaKey
+	} else {
+		self.secret = bytes.TrimSpace(data)
+	}
+	return self, nil
+}
+
+func (self *jwtValidator) Authenticate(r *http.Request) (*Identity, error) {
+	token := bearerToken(r)
+	if token == "" {
+		return nil, nil
+	}
+	claims, err := self.validate(token)
+	if err != nil {
+		return nil, err
+	}
+	id := &Identity{
+		Name: claims.Subject,
+		Role: claims.Role,
+	}
+	if id.Role == "" {
+		id.Role = RoleRead
+	}
+	if !validRole(id.Role) {
+		return nil, fmt.Errorf("unknown role %q in JWT", id.Role)
+	}
+	return id, nil
+}
+
+func (self *jwtValidator) validate(token string) (*jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed JWT")
+	}
+	header := struct {
+		Algorithm string `json:"alg"`
+	}{}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed JWT signature: %v", err)
+	}
+	signed := []byte(parts[0] + "." + parts[1])
+	switch {
+	case header.Algorithm == "RS256" && self.publicKey != nil:
+		hash := sha256.Sum256(signed)
+		if err := rsa.VerifyPKCS1v15(self.publicKey, crypto.SHA256, hash[:], signature); err != nil {
+			return nil, fmt.Errorf("invalid JWT signature")
+		}
+	case header.Algorithm == "HS256" && self.secret != nil:
+		mac := hmac.New(sha256.New, self.secret)
+		mac.Write(signed)
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return nil, fmt.Errorf("invalid JWT signature")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", header.Algorithm)
+	}
+
+	claims := &jwtClaims{}
+	if err := decodeSegment(parts[1], claims); err != nil {
+		return nil, err
+	}
+	now := self.now().Unix()
+	if claims.ExpiresAt != 0 && now >= claims.ExpiresAt {
+		return nil, fmt.Errorf("expired JWT")
+	}
+	if claims.NotBefore != 0 && now < claims.NotBefore {
+		return nil, fmt.Errorf("JWT not valid yet")
+	}
+	if self.audience != "" && !hasAudience(claims.Audience, self.audience) {
+		return nil, fmt.Errorf("JWT not issued for audience %q", self.audience)
+	}
+	return claims, nil
+}
+
+func decodeSegment(segment string, out interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("malformed JWT: %v", err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("malformed JWT: %v", err)
+	}
+	return nil
+}
+
+// The "aud" claim is either a string or a list of strings.
+func hasAudience(aud interface{}, audience string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, a := range aud {
+			if a == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,498 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/cadvisor/logging"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+var argOidcIssuer = flag.String("oidc_issuer_url", "", "URL of the OpenID Connect provider authenticating UI users and validating API bearer tokens. Empty disables OpenID Connect")
+var argOidcClientId = flag.String("oidc_client_id", "", "client ID of cAdvisor at the OpenID Connect provider")
+var argOidcClientSecretFile = flag.String("oidc_client_secret_file", "", "file holding the client secret of cAdvisor at the OpenID Connect provider")
+var argOidcRedirectURL = flag.String("oidc_redirect_url", "", "URL the OpenID Connect provider redirects UI users to after login, e.g. https://node1:8080/oidc/callback")
+var argOidcAdminScope = flag.String("oidc_admin_scope", "cadvisor:admin", "scope granting admin access to the API to introspected bearer tokens")
+
+const (
+	// Path of the redirect URL handler.
+	OidcCallbackPath = "/oidc/callback"
+
+	sessionCookie  = "cadvisor_session"
+	stateCookie    = "cadvisor_oidc_state"
+	sessionTimeout = 8 * time.Hour
+	// How long token introspection results are reused.
+	introspectionCacheTime = time.Minute
+	// Maximum number of cached introspection results.
+	maxIntrospections = 10000
+)
+
+// Endpoints of the provider published at <issuer>/.well-known/openid-configuration.
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	IntrospectionEndpoint string `json:"introspection_endpoint"`
+}
+
+// OIDC authenticates UI users with the authorization code flow and API
+// clients by introspecting their bearer tokens at the provider.
+type OIDC struct {
+	issuer       string
+	clientId     string
+	clientSecret string
+	redirectURL  string
+	adminScope   string
+	// Key signing session cookies, derived from the client secret so that
+	// sessions are valid across restarts and nodes.
+	sessionKey []byte
+	client     *http.Client
+
+	// Guards the discovery apart from lock, so that a provider slow to
+	// answer doesn't hold back the cached introspections.
+	discoveryLock sync.Mutex
+	discovery     *oidcDiscovery
+	// Closed once the discovery in flight, if any, completes.
+	discovering chan struct{}
+	// Error of the last failed discovery, returned until retryDiscovery.
+	discoveryErr   error
+	retryDiscovery time.Time
+
+	lock sync.Mutex
+	// Recent introspection results of active tokens keyed by token.
+	introspected map[string]*introspection
+	// When expired introspection results were last dropped.
+	lastSweep time.Time
+}
+
+type introspection struct {
+	id      *Identity
+	expires time.Time
+}
+
+var (
+	oidcOnce   sync.Once
+	sharedOidc *OIDC
+	oidcErr    error
+)
+
+// GetOIDC returns the OpenID Connect integration configured on the command
+// line, or nil if it is not configured. It is created once, so that the UI,
+// the API and the RPCs share the provider endpoints and the introspection
+// results.
+func GetOIDC() (*OIDC, error) {
+	oidcOnce.Do(func() {
+		sharedOidc, oidcErr = newOIDCFromFlags()
+	})
+	return sharedOidc, oidcErr
+}
+
+func newOIDCFromFlags() (*OIDC, error) {
+	if *argOidcIssuer == "" {
+		return nil, nil
+	}
+	if *argOidcClientId == "" || *argOidcClientSecretFile == "" {
+		return nil, fmt.Errorf("--oidc_issuer_url requires --oidc_client_id and --oidc_client_secret_file")
+	}
+	secret, err := ioutil.ReadFile(*argOidcClientSecretFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenID Connect client secret: %v", err)
+	}
+	return newOIDC(*argOidcIssuer, *argOidcClientId, string(bytes.TrimSpace(secret)), *argOidcRedirectURL, *argOidcAdminScope), nil
+}
+
+func newOIDC(issuer, clientId, clientSecret, redirectURL, adminScope string) *OIDC {
+	mac := hmac.New(sha256.New, []byte(clientSecret))
+	mac.Write([]byte("cadvisor session"))
+	return &OIDC{
+		issuer:       strings.TrimSuffix(issuer, "/"),
+		clientId:     clientId,
+		clientSecret: clientSecret,
+		redirectURL:  redirectURL,
+		adminScope:   adminScope,
+		sessionKey:   mac.Sum(nil),
+		client:       &http.Client{Timeout: 10 * time.Second},
+		introspected: make(map[string]*introspection),
+	}
+}
+
+// Time a failed discovery is returned for before the provider is asked again.
+const discoveryRetryDelay = 10 * time.Second
+
+// Returns the provider endpoints, fetched once. Concurrent callers wait for
+// the same fetch, and a failed fetch is only retried after
+// discoveryRetryDelay.
+func (self *OIDC) getDiscovery() (*oidcDiscovery, error) {
+	for {
+		self.discoveryLock.Lock()
+		discovery, err := self.discovery, self.discoveryErr
+		if discovery != nil || (err != nil && time.Now().Before(self.retryDiscovery)) {
+			self.discoveryLock.Unlock()
+			return discovery, err
+		}
+		if wait := self.discovering; wait != nil {
+			self.discoveryLock.Unlock()
+			<-wait
+			continue
+		}
+		done := make(chan struct{})
+		self.discovering = done
+		self.discoveryLock.Unlock()
+
+		discovery, err = self.fetchDiscovery()
+		self.discoveryLock.Lock()
+		self.discovering = nil
+		self.discovery, self.discoveryErr = discovery, err
+		if err != nil {
+			self.retryDiscovery = time.Now().Add(discoveryRetryDelay)
+		}
+		self.discoveryLock.Unlock()
+		close(done)
+		return discovery, err
+	}
+}
+
+func (self *OIDC) fetchDiscovery() (*oidcDiscovery, error) {
+	resp, err := self.client.Get(self.issuer + "/.well-known/openid-configuration")
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover OpenID Connect provider: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to discover OpenID Connect provider: status %q", resp.Status)
+	}
+	discovery := &oidcDiscovery{}
+	if err := json.NewDecoder(resp.Body).Decode(discovery); err != nil {
+		return nil, fmt.Errorf("failed to decode OpenID Connect provider configuration: %v", err)
+	}
+	return discovery, nil
+}
+
+func (self *OIDC) oauth2Config(discovery *oidcDiscovery) *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     self.clientId,
+		ClientSecret: self.clientSecret,
+		RedirectURL:  self.redirectURL,
+		Scopes:       []string{"openid"},
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  discovery.AuthorizationEndpoint,
+			TokenURL: discovery.TokenEndpoint,
+		},
+	}
+}
+
+// Session cookies hold the signed JSON encoded session.
+type session struct {
+	Subject string `json:"sub"`
+	Expires int64  `json:"exp"`
+}
+
+func (self *OIDC) sign(payload string) string {
+	mac := hmac.New(sha256.New, self.sessionKey)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (self *OIDC) encodeSession(s *session) (string, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	return payload + "." + self.sign(payload), nil
+}
+
+func (self *OIDC) decodeSession(value string, now time.Time) (*session, error) {
+	parts := strings.Split(value, ".")
+	if len(parts) != 2 || !hmac.Equal([]byte(self.sign(parts[0])), []byte(parts[1])) {
+		return nil, fmt.Errorf("invalid session cookie")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid session cookie: %v", err)
+	}
+	s := &session{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("invalid session cookie: %v", err)
+	}
+	if now.Unix() >= s.Expires {
+		return nil, fmt.Errorf("expired session")
+	}
+	return s, nil
+}
+
+// Returns the session of the request, if any.
+func (self *OIDC) getSession(r *http.Request) (*session, error) {
+	cookie, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return nil, nil
+	}
+	return self.decodeSession(cookie.Value, time.Now())
+}
+
+// Authenticate identifies API clients by their UI session, with read access,
+// or by introspecting their bearer token.
+func (self *OIDC) Authenticate(r *http.Request) (*Identity, error) {
+	s, err := self.getSession(r)
+	if err != nil {
+		return nil, err
+	}
+	if s != nil {
+		return &Identity{Name: s.Subject, Role: RoleRead}, nil
+	}
+	token := bearerToken(r)
+	if token == "" {
+		return nil, nil
+	}
+	return self.introspect(token)
+}
+
+// Validates the token at the introspection endpoint of the provider (RFC 7662).
+func (self *OIDC) introspect(token string) (*Identity, error) {
+	now := time.Now()
+	self.lock.Lock()
+	cached, ok := self.introspected[token]
+	if ok && now.After(cached.expires) {
+		delete(self.introspected, token)
+		ok = false
+	}
+	self.lock.Unlock()
+	if ok {
+		return cached.id, nil
+	}
+
+	discovery, err := self.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+	if discovery.IntrospectionEndpoint == "" {
+		return nil, fmt.Errorf("OpenID Connect provider has no introspection endpoint")
+	}
+	req, err := http.NewRequest("POST", discovery.IntrospectionEndpoint, strings.NewReader(url.Values{"token": {token}}.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(self.clientId, self.clientSecret)
+	resp, err := self.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token introspection failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token introspection failed: status %q", resp.Status)
+	}
+	result := struct {
+		Active    bool   `json:"active"`
+		Subject   string `json:"sub"`
+		Username  string `json:"username"`
+		Scope     string `json:"scope"`
+		ExpiresAt int64  `json:"exp"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode token introspection: %v", err)
+	}
+
+	// Inactive tokens are not cached so that random tokens cannot fill the
+	// cache.
+	if !result.Active {
+		return nil, fmt.Errorf("inactive bearer token")
+	}
+	id := &Identity{
+		Name: result.Subject,
+		Role: RoleRead,
+	}
+	if result.Username != "" {
+		id.Name = result.Username
+	}
+	for _, scope := range strings.Fields(result.Scope) {
+		if scope == self.adminScope {
+			id.Role = RoleAdmin
+		}
+	}
+	expires := now.Add(introspectionCacheTime)
+	if result.ExpiresAt != 0 && time.Unix(result.ExpiresAt, 0).Before(expires) {
+		expires = time.Unix(result.ExpiresAt, 0)
+	}
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if now.Sub(self.lastSweep) >= introspectionCacheTime || len(self.introspected) >= maxIntrospections {
+		self.sweepIntrospections(now)
+	}
+	self.introspected[token] = &introspection{id, expires}
+	return id, nil
+}
+
+// Drops the expired introspection results, and arbitrary ones while the
+// cache is full. Must be called with the lock held.
+func (self *OIDC) sweepIntrospections(now time.Time) {
+	for token, cached := range self.introspected {
+		if now.After(cached.expires) {
+			delete(self.introspected, token)
+		}
+	}
+	for token := range self.introspected {
+		if len(self.introspected) < maxIntrospections {
+			break
+		}
+		delete(self.introspected, token)
+	}
+	self.lastSweep = now
+}
+
+// Wrap returns a handler redirecting users without a session to the provider
+// to log in.
+func (self *OIDC) Wrap(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s, err := self.getSession(r); err == nil && s != nil {
+			h(w, r)
+			return
+		}
+		discovery, err := self.getDiscovery()
+		if err != nil {
+			logging.Errorf("Failed to log in UI user: %v", err)
+			http.Error(w, "login unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		nonce := make([]byte, 16)
+		if _, err := rand.Read(nonce); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// The state carries the page to return to and is bound to the browser by a cookie.
+		state := base64.RawURLEncoding.EncodeToString(nonce) + "|" + r.URL.RequestURI()
+		http.SetCookie(w, &http.Cookie{
+			Name:     stateCookie,
+			Value:    self.sign(state),
+			Path:     OidcCallbackPath,
+			MaxAge:   600,
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+		})
+		http.Redirect(w, r, self.oauth2Config(discovery).AuthCodeURL(state), http.StatusFound)
+	}
+}
+
+// HandleCallback completes the login of a UI user redirected by the provider.
+func (self *OIDC) HandleCallback(w http.ResponseWriter, r *http.Request) {
+	state := r.URL.Query().Get("state")
+	cookie, err := r.Cookie(stateCookie)
+	if err != nil || !hmac.Equal([]byte(cookie.Value), []byte(self.sign(state))) {
+		http.Error(w, "invalid login state", http.StatusBadRequest)
+		return
+	}
+	if e := r.URL.Query().Get("error"); e != "" {
+		http.Error(w, fmt.Sprintf("login failed: %s", e), http.StatusForbidden)
+		return
+	}
+	discovery, err := self.getDiscovery()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	token, err := self.oauth2Config(discovery).Exchange(context.Background(), r.URL.Query().Get("code"))
+	if err != nil {
+		logging.V(2).Infof("Failed to exchange OpenID Connect authorization code: %v", err)
+		http.Error(w, "login failed", http.StatusForbidden)
+		return
+	}
+	idToken, _ := token.Extra("id_token").(string)
+	subject, err := self.idTokenSubject(idToken, discovery.Issuer, time.Now())
+	if err != nil {
+		logging.V(2).Infof("Rejecting OpenID Connect ID token: %v", err)
+		http.Error(w, "login failed", http.StatusForbidden)
+		return
+	}
+	value, err := self.encodeSession(&session{
+		Subject: subject,
+		Expires: time.Now().Add(sessionTimeout).Unix(),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   int(sessionTimeout.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+	})
+	http.Redirect(w, r, returnPath(state), http.StatusFound)
+}
+
+// Returns the page to return to after login carried by the state, or / if it
+// is not a path of this server. Browsers follow //host and /\host to
+// another host.
+func returnPath(state string) string {
+	i := strings.Index(state, "|")
+	if i < 0 {
+		return "/"
+	}
+	target := state[i+1:]
+	if !strings.HasPrefix(target, "/") || strings.HasPrefix(target, "//") || strings.HasPrefix(target, "/\\") {
+		return "/"
+	}
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme != "" || u.Host != "" {
+		return "/"
+	}
+	return target
+}
+
+// Returns the subject of an ID token received from the token endpoint. The
+// TLS connection to the endpoint authenticates the token so only its claims
+// are checked.
+func (self *OIDC) idTokenSubject(idToken, issuer string, now time.Time) (string, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed ID token")
+	}
+	claims := struct {
+		Issuer    string      `json:"iss"`
+		Subject   string      `json:"sub"`
+		Audience  interface{} `json:"aud"`
+		ExpiresAt int64       `json:"exp"`
+	}{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return "", err
+	}
+	if claims.Issuer != issuer {
+		return "", fmt.Errorf("ID token issued by %q instead of %q", claims.Issuer, issuer)
+	}
+	if !hasAudience(claims.Audience, self.clientId) {
+		return "", fmt.Errorf("ID token not issued for %q", self.clientId)
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return "", fmt.Errorf("expired ID token")
+	}
+	return claims.Subject, nil
+}
@@ -0,0 +1,290 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newProvider(t *testing.T) (*httptest.Server, *int) {
+	introspections := 0
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/.well-known/openid-configuration":
+			json.NewEncoder(w).Encode(oidcDiscovery{
+				Issuer:                server.URL,
+				AuthorizationEndpoint: server.URL + "/authorize",
+				TokenEndpoint:         server.URL + "/token",
+				IntrospectionEndpoint: server.URL + "/introspect",
+			})
+		case "/introspect":
+			introspections++
+			user, secret, ok := r.BasicAuth()
+			assert.True(t, ok)
+			assert.Equal(t, "cadvisor", user)
+			assert.Equal(t, "secret", secret)
+			switch r.PostFormValue("token") {
+			case "ops-token":
+				fmt.Fprint(w, `{"active":true,"sub":"1234","username":"ops","scope":"openid cadvisor:admin"}`)
+			case "dash-token":
+				fmt.Fprint(w, `{"active":true,"sub":"dash","scope":"openid"}`)
+			default:
+				fmt.Fprint(w, `{"active":false}`)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return server, &introspections
+}
+
+func TestOidcIntrospection(t *testing.T) {
+	server, introspections := newProvider(t)
+	defer server.Close()
+	o := newOIDC(server.URL, "cadvisor", "secret", "", "cadvisor:admin")
+
+	id, err := o.Authenticate(newRequest("GET", "ops-token"))
+	require.NoError(t, err)
+	assert.Equal(t, &Identity{Name: "ops", Role: RoleAdmin}, id)
+	id, err = o.Authenticate(newRequest("GET", "dash-token"))
+	require.NoError(t, err)
+	assert.Equal(t, &Identity{Name: "dash", Role: RoleRead}, id)
+	_, err = o.Authenticate(newRequest("GET", "revoked"))
+	assert.Error(t, err)
+
+	// Results of active tokens are cached.
+	_, err = o.Authenticate(newRequest("GET", "ops-token"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, *introspections)
+	_, err = o.Authenticate(newRequest("GET", "revoked"))
+	assert.Error(t, err)
+	assert.Equal(t, 4, *introspections)
+	assert.Len(t, o.introspected, 2)
+
+	id, err = o.Authenticate(newRequest("GET", ""))
+	assert.NoError(t, err)
+	assert.Nil(t, id)
+}
+
+func TestOidcDiscoveryDoesNotBlockCachedTokens(t *testing.T) {
+	release := make(chan struct{})
+	var lock sync.Mutex
+	discoveries := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		discoveries++
+		lock.Unlock()
+		<-release
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	o := newOIDC(server.URL, "cadvisor", "secret", "", "cadvisor:admin")
+	o.introspected["ops-token"] = &introspection{&Identity{Name: "ops", Role: RoleAdmin}, time.Now().Add(time.Hour)}
+
+	// Two requests wait for the same discovery.
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := o.Authenticate(newRequest("GET", "unknown-token"))
+			errs <- err
+		}()
+	}
+	// Cached tokens are served while the discovery is in flight.
+	for started := false; !started; {
+		time.Sleep(time.Millisecond)
+		lock.Lock()
+		started = discoveries > 0
+		lock.Unlock()
+	}
+	id, err := o.Authenticate(newRequest("GET", "ops-token"))
+	require.NoError(t, err)
+	assert.Equal(t, "ops", id.Name)
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		assert.Error(t, <-errs)
+	}
+	// The failure is cached.
+	_, err = o.Authenticate(newRequest("GET", "unknown-token"))
+	assert.Error(t, err)
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, 1, discoveries)
+}
+
+func TestOidcIntrospectionSweep(t *testing.T) {
+	o := newOIDC("https://accounts.example.com", "cadvisor", "secret", "", "cadvisor:admin")
+	now := time.Unix(1000, 0)
+	for i := 0; i < maxIntrospections; i++ {
+		o.introspected[fmt.Sprintf("token-%d", i)] = &introspection{&Identity{}, now.Add(time.Duration(i%2)*time.Hour - time.Second)}
+	}
+	o.introspected["expired"] = &introspection{&Identity{}, now.Add(-time.Second)}
+
+	// Expired results are dropped.
+	o.sweepIntrospections(now)
+	assert.Len(t, o.introspected, maxIntrospections/2)
+	assert.Nil(t, o.introspected["expired"])
+
+	// A full cache makes room for a new result.
+	for i := 0; i < maxIntrospections; i++ {
+		o.introspected[fmt.Sprintf("token-%d", i)] = &introspection{&Identity{}, now.Add(time.Hour)}
+	}
+	o.sweepIntrospections(now)
+	assert.Len(t, o.introspected, maxIntrospections-1)
+}
+
+func TestReturnPath(t *testing.T) {
+	for state, expected := range map[string]string{
+		"nonce|/containers/docker?x=1": "/containers/docker?x=1",
+		"nonce|/":                      "/",
+		"nonce":                        "/",
+		"nonce|":                       "/",
+		"nonce|//evil.example":         "/",
+		"nonce|/\\evil.example":        "/",
+		"nonce|https://evil.example/":  "/",
+		"nonce|evil.example":           "/",
+	} {
+		assert.Equal(t, expected, returnPath(state), state)
+	}
+}
+
+func TestOidcSession(t *testing.T) {
+	o := newOIDC("https://accounts.example.com", "cadvisor", "secret", "", "cadvisor:admin")
+	now := time.Unix(1000, 0)
+	value, err := o.encodeSession(&session{Subject: "ops", Expires: 2000})
+	require.NoError(t, err)
+	s, err := o.decodeSession(value, now)
+	require.NoError(t, err)
+	assert.Equal(t, "ops", s.Subject)
+
+	// Another instance with the same secret accepts the session.
+	_, err = newOIDC("https://accounts.example.com", "cadvisor", "secret", "", "").decodeSession(value, now)
+	assert.NoError(t, err)
+	_, err = newOIDC("https://accounts.example.com", "cadvisor", "other", "", "").decodeSession(value, now)
+	assert.Error(t, err)
+	_, err = o.decodeSession(value, time.Unix(2000, 0))
+	assert.Error(t, err)
+	_, err = o.decodeSession(value+"x", now)
+	assert.Error(t, err)
+
+	// Sessions grant read access to the API.
+	value, err = o.encodeSession(&session{Subject: "ops", Expires: time.Now().Add(time.Hour).Unix()})
+	require.NoError(t, err)
+	h := &Handler{}
+	h.Add(o)
+	for method, status := range map[string]int{"GET": http.StatusOK, "POST": http.StatusForbidden} {
+		r := newRequest(method, "")
+		r.AddCookie(&http.Cookie{Name: sessionCookie, Value: value})
+		assert.Equal(t, status, serve(h, r), method)
+	}
+}
+
+func TestOidcLogin(t *testing.T) {
+	server, _ := newProvider(t)
+	defer server.Close()
+	o := newOIDC(server.URL, "cadvisor", "secret", "https://node1:8080/oidc/callback", "cadvisor:admin")
+
+	rec := httptest.NewRecorder()
+	r, _ := http.NewRequest("GET", "http://node1:8080/containers/", nil)
+	o.Wrap(func(w http.ResponseWriter, r *http.Request) {})(rec, r)
+	assert.Equal(t, http.StatusFound, rec.Code)
+	assert.Contains(t, rec.Header().Get("Location"), server.URL+"/authorize?")
+	assert.Contains(t, rec.Header().Get("Set-Cookie"), stateCookie)
+
+	value, err := o.encodeSession(&session{Subject: "ops", Expires: time.Now().Add(time.Hour).Unix()})
+	require.NoError(t, err)
+	rec = httptest.NewRecorder()
+	r.AddCookie(&http.Cookie{Name: sessionCookie, Value: value})
+	o.Wrap(func(w http.ResponseWriter, r *http.Request) {})(rec, r)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestIdTokenSubject(t *testing.T) {
+	o := newOIDC("https://accounts.example.com", "cadvisor", "secret", "", "")
+	idToken := func(claims string) string {
+		return "e30." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".sig"
+	}
+	now := time.Unix(1000, 0)
+	sub, err := o.idTokenSubject(idToken(`{"iss":"https://accounts.example.com","sub":"ops","aud":"cadvisor","exp":2000}`), "https://accounts.example.com", now)
+	require.NoError(t, err)
+	assert.Equal(t, "ops", sub)
+	_, err = o.idTokenSubject(idToken(`{"iss":"https://evil.example.com","sub":"ops","aud":"cadvisor","exp":2000}`), "https://accounts.example.com", now)
+	assert.Error(t, err)
+	_, err = o.idTokenSubject(idToken(`{"iss":"https://accounts.example.com","sub":"ops","aud":"other","exp":2000}`), "https://accounts.example.com", now)
+	assert.Error(t, err)
+	_, err = o.idTokenSubject(idToken(`{"iss":"https://accounts.example.com","sub":"ops","aud":"cadvisor","exp":500}`), "https://accounts.example.com", now)
+	assert.Error(t, err)
+}
+
+func TestCommandAuthenticator(t *testing.T) {
+	a := newCommandAuthenticator(`read token; if [ "$token" = "good" ]; then echo '{"name":"ops","role":"admin"}'; else exit 1; fi`, 5*time.Second)
+	id, err := a.Authenticate(newRequest("GET", "good"))
+	require.NoError(t, err)
+	assert.Equal(t, &Identity{Name: "ops", Role: RoleAdmin}, id)
+	_, err = a.Authenticate(newRequest("GET", "bad"))
+	assert.Error(t, err)
+
+	// A token rejected by the command is left to the other authenticators.
+	h := &Handler{}
+	h.Add(a)
+	h.Add(tokenFile{"bad": {Name: "dash", Role: RoleRead}})
+	assert.Equal(t, http.StatusOK, serve(h, newRequest("GET", "bad")))
+	assert.Equal(t, http.StatusForbidden, serve(h, newRequest("POST", "bad")))
+}
+
+func TestCommandAuthenticatorCache(t *testing.T) {
+	runs, err := ioutil.TempFile("", "runs")
+	require.NoError(t, err)
+	defer os.Remove(runs.Name())
+	runs.Close()
+	a := newCommandAuthenticator(`echo run >> `+runs.Name()+`; read token; if [ "$token" = "good" ]; then echo '{"name":"ops"}'; else exit 1; fi`, 5*time.Second)
+	now := time.Unix(1000, 0)
+	a.now = func() time.Time { return now }
+	countRuns := func() int {
+		out, err := ioutil.ReadFile(runs.Name())
+		require.NoError(t, err)
+		return strings.Count(string(out), "run")
+	}
+
+	// Both accepted and rejected tokens are validated once until their
+	// verdict expires.
+	for i := 0; i < 2; i++ {
+		id, err := a.Authenticate(newRequest("GET", "good"))
+		require.NoError(t, err)
+		assert.Equal(t, "ops", id.Name)
+		_, err = a.Authenticate(newRequest("GET", "bad"))
+		assert.Error(t, err)
+	}
+	assert.Equal(t, 2, countRuns())
+
+	now = now.Add(commandCacheTime + time.Second)
+	_, err = a.Authenticate(newRequest("GET", "good"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, countRuns())
+}
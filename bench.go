@@ -0,0 +1,355 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	units "github.com/docker/go-units"
+	"github.com/google/cadvisor/container"
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/manager"
+	"github.com/google/cadvisor/storage"
+)
+
+const benchUsage = "bench [flags]: housekeep synthetic containers to measure the cost of scheduling, caching and storing their stats"
+
+// Runs the housekeeping of synthetic containers for a while and reports its
+// scheduling delays, the throughput of the storage driver and the memory
+// used. The flags of the daemon, e.g. --housekeeping_interval,
+// --housekeeping_workers, --storage_duration or --storage_driver, apply.
+func runBench(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	flag.CommandLine.VisitAll(func(f *flag.Flag) {
+		flags.Var(f.Value, f.Name, f.Usage)
+	})
+	numContainers := flags.Int("containers", 100, "number of synthetic containers")
+	duration := flags.Duration("duration", time.Minute, "how long to run the housekeeping for")
+	numInterfaces := flags.Int("interfaces", 1, "number of network interfaces of each container")
+	numFilesystems := flags.Int("filesystems", 1, "number of filesystems of each container")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: cadvisor %s\n", benchUsage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *numContainers <= 0 {
+		return fmt.Errorf("the number of containers must be positive")
+	}
+
+	backend, err := storage.New(*argDbDriver)
+	if err != nil {
+		return err
+	}
+	benchStorage := &benchStorageDriver{driver: backend}
+	memoryCache, err := newMemoryStorage(benchStorage)
+	if err != nil {
+		return err
+	}
+	defer memoryCache.Close()
+
+	recorder := &benchRecorder{interval: *manager.HousekeepingInterval}
+	handlers := make([]container.ContainerHandler, *numContainers)
+	for i := range handlers {
+		handlers[i] = newBenchHandler(fmt.Sprintf("/bench/%d", i), *numInterfaces, *numFilesystems, recorder)
+	}
+
+	var before runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	housekeeper, err := manager.StartHousekeeping(memoryCache, handlers)
+	if err != nil {
+		return err
+	}
+	time.Sleep(*duration)
+	housekeeper.Stop()
+	elapsed := time.Since(start)
+	if err := memoryCache.Flush(); err != nil {
+		return err
+	}
+	var after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+
+	writeBenchReport(out, &benchReport{
+		containers:   *numContainers,
+		elapsed:      elapsed,
+		recorder:     recorder,
+		storage:      benchStorage,
+		cacheBytes:   memoryCache.Bytes(),
+		heapGrowth:   int64(after.HeapAlloc) - int64(before.HeapAlloc),
+		allocated:    after.TotalAlloc - before.TotalAlloc,
+		gcCycles:     after.NumGC - before.NumGC,
+		gcPauseTotal: time.Duration(after.PauseTotalNs - before.PauseTotalNs),
+	})
+	return nil
+}
+
+// Records the housekeepings of the synthetic containers.
+type benchRecorder struct {
+	// Configured interval between the housekeepings of a container, which
+	// the manager jitters up to twice as long.
+	interval time.Duration
+	lock     sync.Mutex
+	// Number of housekeepings, and the time elapsed since the previous
+	// housekeeping of the container for all but the first ones.
+	housekeepings int
+	intervals     []time.Duration
+}
+
+func (self *benchRecorder) record(previous, now time.Time) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.housekeepings++
+	if !previous.IsZero() {
+		self.intervals = append(self.intervals, now.Sub(previous))
+	}
+}
+
+// Returns the interval below which the given fraction of the intervals are.
+func (self *benchRecorder) intervalPercentile(p float64) time.Duration {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if len(self.intervals) == 0 {
+		return 0
+	}
+	intervals := make([]time.Duration, len(self.intervals))
+	copy(intervals, self.intervals)
+	sort.Sort(durations(intervals))
+	return intervals[int(p*float64(len(intervals)-1))]
+}
+
+type durations []time.Duration
+
+func (s durations) Len() int           { return len(s) }
+func (s durations) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s durations) Less(i, j int) bool { return s[i] < s[j] }
+
+// Measures the writes to the storage driver, if any. The stats are discarded
+// without one.
+type benchStorageDriver struct {
+	driver storage.StorageDriver
+	lock   sync.Mutex
+	writes int
+	errors int
+	total  time.Duration
+	max    time.Duration
+}
+
+func (self *benchStorageDriver) AddStats(ref info.ContainerReference, stats *info.ContainerStats) error {
+	if self.driver == nil {
+		return nil
+	}
+	start := time.Now()
+	err := self.driver.AddStats(ref, stats)
+	latency := time.Since(start)
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.writes++
+	if err != nil {
+		self.errors++
+	}
+	self.total += latency
+	if latency > self.max {
+		self.max = latency
+	}
+	return err
+}
+
+func (self *benchStorageDriver) Flush() error {
+	if self.driver == nil {
+		return nil
+	}
+	return self.driver.Flush()
+}
+
+func (self *benchStorageDriver) Close() error {
+	if self.driver == nil {
+		return nil
+	}
+	return self.driver.Close()
+}
+
+// A synthetic container whose stats are generated: its cpu usage and network
+// counters increase randomly and its memory usage fluctuates.
+type benchHandler struct {
+	ref      info.ContainerReference
+	spec     info.ContainerSpec
+	recorder *benchRecorder
+	rand     *rand.Rand
+	// Previous stats, and the time they were generated at.
+	stats    info.ContainerStats
+	lastCall time.Time
+}
+
+func newBenchHandler(name string, numInterfaces, numFilesystems int, recorder *benchRecorder) *benchHandler {
+	self := &benchHandler{
+		ref: info.ContainerReference{Name: name},
+		spec: info.ContainerSpec{
+			CreationTime:  time.Now(),
+			HasCpu:        true,
+			HasMemory:     true,
+			HasNetwork:    numInterfaces > 0,
+			HasFilesystem: numFilesystems > 0,
+		},
+		recorder: recorder,
+		rand:     rand.New(rand.NewSource(int64(len(name)) + time.Now().UnixNano())),
+	}
+	self.spec.Cpu.Limit = 1024
+	self.spec.Memory.Limit = 1 << 30
+	for i := 0; i < numInterfaces; i++ {
+		self.stats.Network.Interfaces = append(self.stats.Network.Interfaces, info.InterfaceStats{Name: fmt.Sprintf("eth%d", i)})
+	}
+	for i := 0; i < numFilesystems; i++ {
+		self.stats.Filesystem = append(self.stats.Filesystem, info.FsStats{Device: fmt.Sprintf("/dev/bench%d", i), Limit: 10 << 30})
+	}
+	return self
+}
+
+func (self *benchHandler) Start() {}
+
+func (self *benchHandler) Cleanup() {}
+
+func (self *benchHandler) ContainerReference() (info.ContainerReference, error) {
+	return self.ref, nil
+}
+
+func (self *benchHandler) GetSpec() (info.ContainerSpec, error) {
+	return self.spec, nil
+}
+
+func (self *benchHandler) GetStats() (*info.ContainerStats, error) {
+	now := time.Now()
+	self.recorder.record(self.lastCall, now)
+	self.lastCall = now
+
+	// Up to half a core over the interval.
+	self.stats.Cpu.Usage.User += uint64(self.rand.Int63n(int64(self.recorder.interval)/2 + 1))
+	self.stats.Cpu.Usage.System += uint64(self.rand.Int63n(int64(self.recorder.interval)/4 + 1))
+	self.stats.Cpu.Usage.Total = self.stats.Cpu.Usage.User + self.stats.Cpu.Usage.System
+	self.stats.Memory.Usage = uint64(self.rand.Int63n(int64(self.spec.Memory.Limit)))
+	self.stats.Memory.WorkingSet = self.stats.Memory.Usage / 2
+	self.stats.Memory.RSS = self.stats.Memory.Usage / 4
+	for i := range self.stats.Network.Interfaces {
+		iface := &self.stats.Network.Interfaces[i]
+		iface.RxBytes += uint64(self.rand.Int63n(1 << 20))
+		iface.RxPackets += uint64(self.rand.Int63n(1 << 10))
+		iface.TxBytes += uint64(self.rand.Int63n(1 << 20))
+		iface.TxPackets += uint64(self.rand.Int63n(1 << 10))
+	}
+	for i := range self.stats.Filesystem {
+		self.stats.Filesystem[i].Usage = uint64(self.rand.Int63n(int64(self.stats.Filesystem[i].Limit)))
+	}
+
+	// The cache keeps the returned stats, which must not share slices with
+	// the next ones.
+	stats := self.stats
+	stats.Timestamp = now
+	if len(self.stats.Network.Interfaces) > 0 {
+		stats.Network.Interfaces = append([]info.InterfaceStats(nil), self.stats.Network.Interfaces...)
+		stats.Network.InterfaceStats = stats.Network.Interfaces[0]
+	}
+	stats.Filesystem = append([]info.FsStats(nil), self.stats.Filesystem...)
+	return &stats, nil
+}
+
+func (self *benchHandler) ListContainers(listType container.ListType) ([]info.ContainerReference, error) {
+	return nil, nil
+}
+
+func (self *benchHandler) ListThreads(listType container.ListType) ([]int, error) {
+	return nil, nil
+}
+
+func (self *benchHandler) ListProcesses(listType container.ListType) ([]int, error) {
+	return nil, nil
+}
+
+func (self *benchHandler) WatchSubcontainers(events chan container.SubcontainerEvent) error {
+	return fmt.Errorf("synthetic containers have no subcontainers")
+}
+
+func (self *benchHandler) StopWatchingSubcontainers() error {
+	return nil
+}
+
+func (self *benchHandler) GetCgroupPath(resource string) (string, error) {
+	return "", fmt.Errorf("synthetic containers have no cgroups")
+}
+
+func (self *benchHandler) GetContainerLabels() map[string]string {
+	return nil
+}
+
+func (self *benchHandler) Exists() bool {
+	return true
+}
+
+type benchReport struct {
+	containers   int
+	elapsed      time.Duration
+	recorder     *benchRecorder
+	storage      *benchStorageDriver
+	cacheBytes   int64
+	heapGrowth   int64
+	allocated    uint64
+	gcCycles     uint32
+	gcPauseTotal time.Duration
+}
+
+func writeBenchReport(out io.Writer, report *benchReport) {
+	seconds := report.elapsed.Seconds()
+	recorder := report.recorder
+	// The housekeeping of the containers may still be finishing.
+	recorder.lock.Lock()
+	housekeepings := recorder.housekeepings
+	recorder.lock.Unlock()
+	fmt.Fprintf(out, "containers:            %d\n", report.containers)
+	fmt.Fprintf(out, "duration:              %v\n", report.elapsed)
+	fmt.Fprintf(out, "housekeeping interval: %v\n", recorder.interval)
+	fmt.Fprintf(out, "housekeepings:         %d (%.1f/s)\n", housekeepings, float64(housekeepings)/seconds)
+	// Intervals beyond twice the configured one are housekeepings late.
+	fmt.Fprintf(out, "actual intervals:      p50 %v, p99 %v, max %v\n", recorder.intervalPercentile(0.5), recorder.intervalPercentile(0.99), recorder.intervalPercentile(1))
+
+	driver := report.storage
+	driver.lock.Lock()
+	defer driver.lock.Unlock()
+	if driver.driver != nil {
+		var mean time.Duration
+		if driver.writes > 0 {
+			mean = driver.total / time.Duration(driver.writes)
+		}
+		fmt.Fprintf(out, "storage writes:        %d (%.1f/s), latency mean %v, max %v, %d errors\n", driver.writes, float64(driver.writes)/seconds, mean, driver.max, driver.errors)
+	}
+	fmt.Fprintf(out, "cache size:            %s (%s per container)\n", units.BytesSize(float64(report.cacheBytes)), units.BytesSize(float64(report.cacheBytes)/float64(report.containers)))
+	heapGrowth := units.BytesSize(float64(report.heapGrowth))
+	if report.heapGrowth < 0 {
+		heapGrowth = "-" + units.BytesSize(float64(-report.heapGrowth))
+	}
+	fmt.Fprintf(out, "heap growth:           %s\n", heapGrowth)
+	fmt.Fprintf(out, "allocated:             %s (%s/s)\n", units.BytesSize(float64(report.allocated)), units.BytesSize(float64(report.allocated)/seconds))
+	fmt.Fprintf(out, "garbage collections:   %d, %v paused\n", report.gcCycles, report.gcPauseTotal)
+}
@@ -0,0 +1,226 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/json"
+	"io"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	info "github.com/google/cadvisor/info/v1"
+)
+
+// Number of samples compressed with the dictionary of the first one of their
+// group.
+const compressionGroupSize = 16
+
+// A sample stored in a container cache, compressed or not.
+type storedStats struct {
+	// Set unless the sample is compressed.
+	stats *info.ContainerStats
+	// Compression group of the compressed sample. The first sample of the
+	// group is its dictionary itself, and has no compressed data.
+	group      *compressionGroup
+	compressed []byte
+	// Estimated size of the sample in bytes.
+	size int64
+	// Revision of the cache the sample was added at.
+	revision uint64
+}
+
+// Compressed samples mostly encode their difference to the first sample of
+// their group, which flate uses as preset dictionary.
+type compressionGroup struct {
+	// JSON of the first sample.
+	dict    []byte
+	samples int
+}
+
+// Buffers the samples are compressed in or decompressed to, which are only
+// retained while encoding or decoding them.
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// Decompressors, which flate allocates with their whole window.
+var readerPool sync.Pool
+
+func decompress(compressed, dict []byte, buf *bytes.Buffer) error {
+	src := bytes.NewReader(compressed)
+	r, ok := readerPool.Get().(io.ReadCloser)
+	if ok {
+		if err := r.(flate.Resetter).Reset(src, dict); err != nil {
+			return err
+		}
+	} else {
+		r = flate.NewReaderDict(src, dict)
+	}
+	defer readerPool.Put(r)
+	_, err := buf.ReadFrom(r)
+	return err
+}
+
+func (self *storedStats) decode() (*info.ContainerStats, error) {
+	if self.stats != nil {
+		return self.stats, nil
+	}
+	data := self.group.dict
+	if self.compressed != nil {
+		buf := bufferPool.Get().(*bytes.Buffer)
+		buf.Reset()
+		defer bufferPool.Put(buf)
+		if err := decompress(self.compressed, self.group.dict, buf); err != nil {
+			return nil, err
+		}
+		data = buf.Bytes()
+	}
+	stats := &info.ContainerStats{}
+	if err := json.Unmarshal(data, stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// Compresses the sample in the current group of the cache, or in a new group.
+func (self *containerCache) compress(stats *info.ContainerStats) (*storedStats, error) {
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return nil, err
+	}
+	if self.group == nil || self.group.samples >= compressionGroupSize {
+		self.group = &compressionGroup{
+			dict:    data,
+			samples: 1,
+		}
+		return &storedStats{
+			group: self.group,
+			size:  int64(len(data)) + storedStatsOverhead,
+		}, nil
+	}
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufferPool.Put(buf)
+	// Writers can't change their dictionary, so they aren't reused.
+	w, err := flate.NewWriterDict(buf, flate.BestSpeed, self.group.dict)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	self.group.samples++
+	// Only the compressed bytes are retained, not the spare capacity of
+	// the buffer.
+	compressed := make([]byte, buf.Len())
+	copy(compressed, buf.Bytes())
+	return &storedStats{
+		group:      self.group,
+		compressed: compressed,
+		size:       int64(len(compressed)) + storedStatsOverhead,
+	}, nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Size of a stored sample, besides its stats or compressed data.
+var storedStatsOverhead = int64(reflect.TypeOf(storedStats{}).Size())
+
+// Estimates the memory used by the stats, following pointers, slices and
+// maps.
+func statsSize(stats *info.ContainerStats) int64 {
+	return storedStatsOverhead + valueSize(reflect.ValueOf(stats).Elem())
+}
+
+func valueSize(v reflect.Value) int64 {
+	size := int64(v.Type().Size())
+	return size + indirectSize(v)
+}
+
+// Returns the memory referenced by the value, besides the value itself.
+func indirectSize(v reflect.Value) int64 {
+	var size int64
+	if v.Type() == timeType {
+		// Locations are shared.
+		return 0
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			size += valueSize(v.Elem())
+		}
+	case reflect.String:
+		size += int64(v.Len())
+	case reflect.Slice:
+		if v.IsNil() {
+			break
+		}
+		size += int64(v.Cap()-v.Len()) * int64(v.Type().Elem().Size())
+		for i := 0; i < v.Len(); i++ {
+			size += valueSize(v.Index(i))
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			size += indirectSize(v.Index(i))
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			break
+		}
+		for _, key := range v.MapKeys() {
+			size += valueSize(key) + valueSize(v.MapIndex(key))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			size += indirectSize(v.Field(i))
+		}
+	}
+	return size
+}
+
+// Returns the largest size to which to trim every container so that the total
+// size fits in the budget, shrinking the largest containers first (max-min
+// fairness).
+func fairShare(sizes []int64, budget int64) int64 {
+	sorted := append([]int64(nil), sizes...)
+	sort.Sort(int64Slice(sorted))
+	remaining := budget
+	for i, size := range sorted {
+		share := remaining / int64(len(sorted)-i)
+		if size > share {
+			return share
+		}
+		remaining -= size
+	}
+	if len(sorted) == 0 {
+		return budget
+	}
+	return sorted[len(sorted)-1]
+}
+
+type int64Slice []int64
+
+func (self int64Slice) Len() int           { return len(self) }
+func (self int64Slice) Less(i, j int) bool { return self[i] < self[j] }
+func (self int64Slice) Swap(i, j int)      { self[i], self[j] = self[j], self[i] }
@@ -17,13 +17,14 @@ package memory
 import (
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/logging"
 	"github.com/google/cadvisor/storage"
 	"github.com/google/cadvisor/utils"
-
-	"github.com/golang/glog"
 )
 
 // TODO(vmarmol): See about refactoring this class, we have an unecessary redirection of containerCache and InMemoryCache.
@@ -33,14 +34,35 @@ type containerCache struct {
 	recentStats *utils.TimedStore
 	maxAge      time.Duration
 	lock        sync.RWMutex
+
+	// Estimated size of the stored samples in bytes.
+	bytes int64
+	// Estimated size of the samples of all containers, updated with the
+	// changes of bytes.
+	totalBytes *int64
+	// Whether to compress the samples, in the current group.
+	compressed bool
+	group      *compressionGroup
 }
 
-func (self *containerCache) AddStats(stats *info.ContainerStats) error {
+func (self *containerCache) AddStats(stats *info.ContainerStats, revision uint64) error {
 	self.lock.Lock()
 	defer self.lock.Unlock()
 
+	stored := &storedStats{stats: stats}
+	if self.compressed {
+		var err error
+		if stored, err = self.compress(stats); err != nil {
+			return fmt.Errorf("failed to compress stats of %q: %v", self.ref.Name, err)
+		}
+	} else {
+		stored.size = statsSize(stats)
+	}
+	stored.revision = revision
+	self.addBytes(stored.size)
+
 	// Add the stat to storage.
-	self.recentStats.Add(stats.Timestamp, stats)
+	self.recentStats.Add(stats.Timestamp, stored)
 	return nil
 }
 
@@ -50,48 +72,200 @@ func (self *containerCache) RecentStats(start, end time.Time, maxStats int) ([]*
 	result := self.recentStats.InTimeRange(start, end, maxStats)
 	converted := make([]*info.ContainerStats, len(result))
 	for i, el := range result {
-		converted[i] = el.(*info.ContainerStats)
+		stats, err := el.(*storedStats).decode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress stats of %q: %v", self.ref.Name, err)
+		}
+		converted[i] = stats
+	}
+	return converted, nil
+}
+
+func (self *containerCache) StatsSince(revision uint64, maxStats int) ([]*info.ContainerStats, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	var added []*storedStats
+	for _, el := range self.recentStats.InTimeRange(time.Time{}, time.Time{}, -1) {
+		if stored := el.(*storedStats); stored.revision > revision {
+			added = append(added, stored)
+		}
+	}
+	if maxStats != -1 && len(added) > maxStats {
+		added = added[len(added)-maxStats:]
+	}
+	converted := make([]*info.ContainerStats, len(added))
+	for i, stored := range added {
+		stats, err := stored.decode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress stats of %q: %v", self.ref.Name, err)
+		}
+		converted[i] = stats
 	}
 	return converted, nil
 }
 
-func newContainerStore(ref info.ContainerReference, maxAge time.Duration) *containerCache {
-	return &containerCache{
+// Called with the lock held.
+func (self *containerCache) addBytes(delta int64) {
+	self.bytes += delta
+	atomic.AddInt64(self.totalBytes, delta)
+}
+
+func (self *containerCache) size() int64 {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	return self.bytes
+}
+
+// Evicts the oldest samples until the estimated size of the remaining ones is
+// at most maxBytes. The latest sample is kept.
+func (self *containerCache) trim(maxBytes int64) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	for self.bytes > maxBytes && self.recentStats.Size() > 1 {
+		self.recentStats.RemoveOldest()
+	}
+}
+
+func newContainerStore(ref info.ContainerReference, maxAge time.Duration, totalBytes *int64, compressed bool) *containerCache {
+	cache := &containerCache{
 		ref:         ref,
 		recentStats: utils.NewTimedStore(maxAge, -1),
 		maxAge:      maxAge,
+		totalBytes:  totalBytes,
+		compressed:  compressed,
 	}
+	cache.recentStats.OnEvict(func(item interface{}) {
+		cache.addBytes(-item.(*storedStats).size)
+	})
+	return cache
 }
 
 type InMemoryCache struct {
+	// Estimated size of the samples of all containers in bytes. First for
+	// the alignment of atomic operations. Above byteBudget, if set, the
+	// oldest samples of the largest containers are evicted.
+	bytes      int64
+	byteBudget int64
+	// Incremented whenever stats are added or removed. Stats are added with
+	// the read lock of revisionLock held.
+	revision     uint64
+	revisionLock sync.RWMutex
+
 	lock              sync.RWMutex
 	containerCacheMap map[string]*containerCache
 	maxAge            time.Duration
 	backend           storage.StorageDriver
+	// Max ages of the containers whose stats are kept for a different
+	// duration.
+	retentionOverrides []RetentionOverride
+
+	// Registered stats watchers keyed by watch id.
+	watchers    map[int]*statsWatch
+	watchLock   sync.RWMutex
+	lastWatchId int
+
+	evicting int32
+	// Whether to compress the samples.
+	compressed bool
+
+	// File persisting the stats, if any, and the channel stopping their
+	// periodic persistence.
+	persistPath string
+	persistStop chan struct{}
+	persistLock sync.Mutex
+	// Containers whose stats were loaded from a snapshot and that were not
+	// checked for existence since. Guarded by lock.
+	snapshotContainers map[string]bool
+
+	// Result of the last write to the backend storage.
+	backendLock      sync.Mutex
+	backendErr       error
+	backendErrorTime time.Time
 }
 
-func (self *InMemoryCache) AddStats(ref info.ContainerReference, stats *info.ContainerStats) error {
-	var cstore *containerCache
-	var ok bool
+// Returns the cache of the container, created if needed.
+func (self *InMemoryCache) containerStore(ref info.ContainerReference) *containerCache {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	cstore, ok := self.containerCacheMap[ref.Name]
+	if !ok {
+		cstore = newContainerStore(ref, self.maxAgeOf(ref), &self.bytes, self.compressed)
+		self.containerCacheMap[ref.Name] = cstore
+	}
+	return cstore
+}
 
-	func() {
-		self.lock.Lock()
-		defer self.lock.Unlock()
-		if cstore, ok = self.containerCacheMap[ref.Name]; !ok {
-			cstore = newContainerStore(ref, self.maxAge)
-			self.containerCacheMap[ref.Name] = cstore
-		}
-	}()
+func (self *InMemoryCache) AddStats(ref info.ContainerReference, stats *info.ContainerStats) error {
+	cstore := self.containerStore(ref)
 
 	if self.backend != nil {
 		// TODO(monnand): To deal with long delay write operations, we
 		// may want to start a pool of goroutines to do write
 		// operations.
-		if err := self.backend.AddStats(ref, stats); err != nil {
-			glog.Error(err)
+		err := self.backend.AddStats(ref, stats)
+		if err != nil {
+			logging.Error(err)
 		}
+		self.setBackendError(err)
+	}
+	self.revisionLock.RLock()
+	err := cstore.AddStats(stats, atomic.AddUint64(&self.revision, 1))
+	self.revisionLock.RUnlock()
+	if err != nil {
+		return err
+	}
+	self.enforceByteBudget()
+	self.notifyWatchers(ref, stats)
+	return nil
+}
+
+// AddEvent records the event in the backend storage if it supports events.
+func (self *InMemoryCache) AddEvent(event *info.Event) error {
+	backend, ok := self.backend.(storage.EventStorageDriver)
+	if !ok {
+		return nil
+	}
+	return backend.AddEvent(event)
+}
+
+// AddDerivedStats records the derived stats in the backend storage if it
+// supports them.
+func (self *InMemoryCache) AddDerivedStats(ref info.ContainerReference, stats *v2.DerivedStats) error {
+	backend, ok := self.backend.(storage.DerivedStatsStorageDriver)
+	if !ok {
+		return nil
 	}
-	return cstore.AddStats(stats)
+	return backend.AddDerivedStats(ref, stats)
+}
+
+// SupportsDerivedStats returns whether the backend storage records derived
+// stats.
+func (self *InMemoryCache) SupportsDerivedStats() bool {
+	_, ok := self.backend.(storage.DerivedStatsStorageDriver)
+	return ok
+}
+
+// SupportsHistory returns whether the backend storage can read back the stats
+// it stored.
+func (self *InMemoryCache) SupportsHistory() bool {
+	_, ok := self.backend.(storage.StatsReader)
+	return ok
+}
+
+// HistoricalStats reads the stats of the container between start and end, one
+// per step, back from the backend storage.
+func (self *InMemoryCache) HistoricalStats(name string, start, end time.Time, step time.Duration) ([]*info.ContainerStats, error) {
+	backend, ok := self.backend.(storage.StatsReader)
+	if !ok {
+		return nil, fmt.Errorf("the storage driver can't read stats back")
+	}
+	return backend.ReadStats(name, start, end, step)
+}
+
+// SupportsEvents returns whether the backend storage records events.
+func (self *InMemoryCache) SupportsEvents() bool {
+	_, ok := self.backend.(storage.EventStorageDriver)
+	return ok
 }
 
 func (self *InMemoryCache) RecentStats(name string, start, end time.Time, maxStats int) ([]*info.ContainerStats, error) {
@@ -112,8 +286,61 @@ func (self *InMemoryCache) RecentStats(name string, start, end time.Time, maxSta
 	return cstore.RecentStats(start, end, maxStats)
 }
 
+// StatsSince returns up to maxStats of the latest stats of the container
+// added after the revision, from first to last. maxStats of -1 means no
+// limit.
+func (self *InMemoryCache) StatsSince(name string, revision uint64, maxStats int) ([]*info.ContainerStats, error) {
+	self.lock.RLock()
+	cstore, ok := self.containerCacheMap[name]
+	self.lock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unable to find data for container %v", name)
+	}
+	return cstore.StatsSince(revision, maxStats)
+}
+
+// Flush writes the stats buffered by the backend storage, if any, and
+// persists the stats if they are persisted.
+func (self *InMemoryCache) Flush() error {
+	if err := self.persist(); err != nil {
+		return err
+	}
+	if self.backend == nil {
+		return nil
+	}
+	err := self.backend.Flush()
+	self.setBackendError(err)
+	return err
+}
+
+func (self *InMemoryCache) setBackendError(err error) {
+	self.backendLock.Lock()
+	defer self.backendLock.Unlock()
+	if err != nil && self.backendErr == nil {
+		self.backendErrorTime = time.Now()
+	}
+	self.backendErr = err
+}
+
+// BackendError returns the time since which writes to the backend storage
+// fail and the error of the last one, or a nil error if it succeeded.
+func (self *InMemoryCache) BackendError() (time.Time, error) {
+	self.backendLock.Lock()
+	defer self.backendLock.Unlock()
+	return self.backendErrorTime, self.backendErr
+}
+
 func (self *InMemoryCache) Close() error {
+	if self.persistStop != nil {
+		close(self.persistStop)
+		self.persistStop = nil
+	}
 	self.lock.Lock()
+	for _, cstore := range self.containerCacheMap {
+		cstore.lock.Lock()
+		cstore.addBytes(-cstore.bytes)
+		cstore.lock.Unlock()
+	}
 	self.containerCacheMap = make(map[string]*containerCache, 32)
 	self.lock.Unlock()
 	return nil
@@ -121,11 +348,88 @@ func (self *InMemoryCache) Close() error {
 
 func (self *InMemoryCache) RemoveContainer(containerName string) error {
 	self.lock.Lock()
+	if cstore, ok := self.containerCacheMap[containerName]; ok {
+		cstore.lock.Lock()
+		cstore.addBytes(-cstore.bytes)
+		cstore.lock.Unlock()
+	}
 	delete(self.containerCacheMap, containerName)
 	self.lock.Unlock()
+	atomic.AddUint64(&self.revision, 1)
 	return nil
 }
 
+// SetByteBudget bounds the estimated size of the stats of all containers.
+// Above it, the oldest stats of the largest containers are evicted first. A
+// budget of 0 bounds the stats only by their age. The stats above a lowered
+// budget are evicted right away.
+func (self *InMemoryCache) SetByteBudget(budget int64) {
+	atomic.StoreInt64(&self.byteBudget, budget)
+	self.enforceByteBudget()
+}
+
+// ByteBudget returns the bound of the estimated size of the stats of all
+// containers, 0 if they are only bounded by their age.
+func (self *InMemoryCache) ByteBudget() int64 {
+	return atomic.LoadInt64(&self.byteBudget)
+}
+
+// EnableCompression compresses the stats added from now on. Stats are then
+// decompressed on every read.
+func (self *InMemoryCache) EnableCompression() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.compressed = true
+}
+
+// Revision returns a counter incremented whenever stats are added or
+// removed, so that the stats are unchanged while it is. It waits for the
+// stats being added, so that all the stats up to the revision can be read.
+// Revisions start from the time the cache is created in nanoseconds, which
+// keeps them increasing across restarts.
+func (self *InMemoryCache) Revision() uint64 {
+	self.revisionLock.Lock()
+	defer self.revisionLock.Unlock()
+	return atomic.LoadUint64(&self.revision)
+}
+
+// Bytes returns the estimated size of the stats of all containers.
+func (self *InMemoryCache) Bytes() int64 {
+	return atomic.LoadInt64(&self.bytes)
+}
+
+// Evicts the oldest stats of the largest containers until the size of all
+// stats is below 90% of the budget, so that evictions run in batches.
+func (self *InMemoryCache) enforceByteBudget() {
+	budget := self.ByteBudget()
+	if budget <= 0 || atomic.LoadInt64(&self.bytes) <= budget {
+		return
+	}
+	if !atomic.CompareAndSwapInt32(&self.evicting, 0, 1) {
+		return
+	}
+	defer atomic.StoreInt32(&self.evicting, 0)
+
+	self.lock.RLock()
+	cstores := make([]*containerCache, 0, len(self.containerCacheMap))
+	for _, cstore := range self.containerCacheMap {
+		cstores = append(cstores, cstore)
+	}
+	self.lock.RUnlock()
+
+	sizes := make([]int64, len(cstores))
+	for i, cstore := range cstores {
+		sizes[i] = cstore.size()
+	}
+	share := fairShare(sizes, budget/10*9)
+	for i, cstore := range cstores {
+		if sizes[i] > share {
+			cstore.trim(share)
+		}
+	}
+	logging.V(4).Infof("Evicted stats to fit in %d bytes, %d bytes of stats remain", budget, self.Bytes())
+}
+
 func New(
 	maxAge time.Duration,
 	backend storage.StorageDriver,
@@ -134,6 +438,8 @@ func New(
 		containerCacheMap: make(map[string]*containerCache, 32),
 		maxAge:            maxAge,
 		backend:           backend,
+		watchers:          make(map[int]*statsWatch),
+		revision:          uint64(time.Now().UnixNano()),
 	}
 	return ret
 }
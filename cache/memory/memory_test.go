@@ -15,10 +15,20 @@
 package memory
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/storage/test"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -96,3 +106,352 @@ func TestRecentStatsGetAllStats(t *testing.T) {
 
 	assert.Len(t, getRecentStats(t, memoryCache, -1), 10)
 }
+
+func TestStatsSince(t *testing.T) {
+	start := time.Now()
+	memoryCache := New(60*time.Second, nil)
+	assert.True(t, memoryCache.Revision() >= uint64(start.UnixNano()))
+
+	require.NoError(t, memoryCache.AddStats(containerRef, makeStat(0)))
+	require.NoError(t, memoryCache.AddStats(containerRef, makeStat(4)))
+	since := memoryCache.Revision()
+	memoryCache.EnableCompression()
+	require.NoError(t, memoryCache.AddStats(containerRef, makeStat(5)))
+	require.NoError(t, memoryCache.AddStats(info.ContainerReference{Name: "/other"}, makeStat(6)))
+	// Added later but older.
+	require.NoError(t, memoryCache.AddStats(containerRef, makeStat(3)))
+	assert.Equal(t, since+3, memoryCache.Revision())
+
+	stats, err := memoryCache.StatsSince(containerName, since, -1)
+	require.NoError(t, err)
+	if assert.Len(t, stats, 2) {
+		assert.Equal(t, int32(3), stats[0].Cpu.LoadAverage)
+		assert.Equal(t, int32(5), stats[1].Cpu.LoadAverage)
+	}
+	stats, err = memoryCache.StatsSince(containerName, since, 1)
+	require.NoError(t, err)
+	if assert.Len(t, stats, 1) {
+		assert.Equal(t, int32(5), stats[0].Cpu.LoadAverage)
+	}
+	stats, err = memoryCache.StatsSince(containerName, memoryCache.Revision(), -1)
+	require.NoError(t, err)
+	assert.Empty(t, stats)
+	_, err = memoryCache.StatsSince("/unknown", since, -1)
+	assert.Error(t, err)
+}
+
+func TestWatchStats(t *testing.T) {
+	memoryCache := New(60*time.Second, nil)
+	watch := memoryCache.WatchStats(func(ref info.ContainerReference) bool {
+		return ref.Name == containerName
+	})
+
+	require.Nil(t, memoryCache.AddStats(info.ContainerReference{Name: "/other"}, makeStat(0)))
+	require.Nil(t, memoryCache.AddStats(containerRef, makeStat(1)))
+
+	update := <-watch.GetChannel()
+	assert.Equal(t, containerName, update.ContainerReference.Name)
+	assert.Equal(t, int32(1), update.Stats.Cpu.LoadAverage)
+
+	memoryCache.StopWatch(watch.GetWatchId())
+	_, ok := <-watch.GetChannel()
+	assert.False(t, ok)
+
+	// Samples added after the watch is stopped are not delivered.
+	assert.Nil(t, memoryCache.AddStats(containerRef, makeStat(2)))
+}
+
+func TestWatchStatsDropsForSlowWatchers(t *testing.T) {
+	memoryCache := New(60*time.Second, nil)
+	watch := memoryCache.WatchStats(func(ref info.ContainerReference) bool {
+		return true
+	})
+	for i := 0; i < statsChannelSize+10; i++ {
+		require.Nil(t, memoryCache.AddStats(containerRef, makeStat(i)))
+	}
+	assert.Len(t, watch.GetChannel(), statsChannelSize)
+}
+
+func TestAddEvent(t *testing.T) {
+	event := &info.Event{
+		ContainerName: containerName,
+		Timestamp:     time.Now(),
+		EventType:     info.EventContainerCreation,
+	}
+	// Backends that do not support events ignore them.
+	memoryCache := New(60*time.Second, &test.MockStorageDriver{})
+	assert.False(t, memoryCache.SupportsEvents())
+	assert.NoError(t, memoryCache.AddEvent(event))
+
+	backend := &test.MockEventStorageDriver{}
+	backend.On("AddEvent", event).Return(nil)
+	memoryCache = New(60*time.Second, backend)
+	assert.True(t, memoryCache.SupportsEvents())
+	assert.NoError(t, memoryCache.AddEvent(event))
+	backend.AssertExpectations(t)
+}
+
+func TestAddDerivedStats(t *testing.T) {
+	stats := &v2.DerivedStats{Timestamp: time.Now()}
+	// Backends that do not support derived stats ignore them.
+	memoryCache := New(60*time.Second, &test.MockStorageDriver{})
+	assert.False(t, memoryCache.SupportsDerivedStats())
+	assert.NoError(t, memoryCache.AddDerivedStats(containerRef, stats))
+
+	backend := &test.MockDerivedStatsStorageDriver{}
+	backend.On("AddDerivedStats", containerRef, stats).Return(nil)
+	memoryCache = New(60*time.Second, backend)
+	assert.True(t, memoryCache.SupportsDerivedStats())
+	assert.NoError(t, memoryCache.AddDerivedStats(containerRef, stats))
+	backend.AssertExpectations(t)
+}
+
+func TestFlush(t *testing.T) {
+	assert.NoError(t, New(60*time.Second, nil).Flush())
+
+	backend := &test.MockStorageDriver{MockFlushMethod: true}
+	backend.On("Flush").Return(nil)
+	assert.NoError(t, New(60*time.Second, backend).Flush())
+	backend.AssertExpectations(t)
+}
+
+func TestPersistTo(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cadvisor-stats")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "stats")
+
+	// Stats persisted are only loaded back within the max age of the cache.
+	now := time.Now()
+	memoryCache := New(time.Hour, nil)
+	assert.Error(t, memoryCache.PersistTo(path, 0))
+	require.NoError(t, memoryCache.PersistTo(path, time.Hour))
+	for i := 0; i < 5; i++ {
+		stat := makeStat(i)
+		stat.Timestamp = now.Add(time.Duration(i-5) * time.Second)
+		require.NoError(t, memoryCache.AddStats(containerRef, stat))
+	}
+	require.NoError(t, memoryCache.Flush())
+	require.NoError(t, memoryCache.Close())
+
+	// The stats are loaded back by a new cache.
+	restored := New(time.Hour, nil)
+	require.NoError(t, restored.PersistTo(path, time.Hour))
+	defer restored.Close()
+	stats := getRecentStats(t, restored, -1)
+	require.Equal(t, 5, len(stats))
+	for i, s := range stats {
+		assert.True(t, s.Timestamp.Equal(now.Add(time.Duration(i-5)*time.Second)))
+		assert.Equal(t, int32(i), s.Cpu.LoadAverage)
+	}
+
+	// Stats older than the max age of the cache are not.
+	expired := New(time.Second, nil)
+	require.NoError(t, expired.PersistTo(path, time.Hour))
+	defer expired.Close()
+	_, err = expired.RecentStats(containerName, zero, zero, -1)
+	assert.Error(t, err)
+}
+
+func TestSnapshot(t *testing.T) {
+	memoryCache := New(time.Hour, nil)
+	stat := makeStat(0)
+	stat.Timestamp = time.Now()
+	require.NoError(t, memoryCache.AddStats(containerRef, stat))
+
+	var buf bytes.Buffer
+	require.NoError(t, memoryCache.WriteSnapshot(&buf, func(name string) *info.ContainerSpec {
+		return &info.ContainerSpec{HasCpu: true}
+	}))
+	gz, err := gzip.NewReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	var record snapshotRecord
+	require.NoError(t, json.NewDecoder(gz).Decode(&record))
+	assert.Equal(t, containerName, record.Container.Name)
+	require.NotNil(t, record.Spec)
+	assert.True(t, record.Spec.HasCpu)
+	assert.Equal(t, 1, len(record.Stats))
+
+	// Snapshots over the size limit are rejected as a whole.
+	restored := New(time.Hour, nil)
+	_, err = restored.ReadSnapshot(bytes.NewReader(buf.Bytes()), 16)
+	assert.Error(t, err)
+	_, err = restored.RecentStats(containerName, time.Time{}, time.Time{}, -1)
+	assert.Error(t, err)
+
+	containers, err := restored.ReadSnapshot(&buf, 1<<20)
+	require.NoError(t, err)
+	assert.Equal(t, 1, containers)
+	assert.Equal(t, 1, len(getRecentStats(t, restored, -1)))
+
+	_, err = restored.ReadSnapshot(bytes.NewReader([]byte("not a snapshot")), 0)
+	assert.Error(t, err)
+}
+
+// Gzips the snapshot records given as JSON lines.
+func gzipSnapshot(t *testing.T, lines string) io.Reader {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := io.WriteString(gz, lines)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	return &buf
+}
+
+func TestReadInvalidSnapshot(t *testing.T) {
+	restored := New(time.Hour, nil)
+	now, err := json.Marshal(time.Now())
+	require.NoError(t, err)
+
+	// Null stats are skipped.
+	containers, err := restored.ReadSnapshot(gzipSnapshot(t, fmt.Sprintf(`{"container":{"name":%q},"stats":[null,{"timestamp":%s}]}`, containerName, now)), 0)
+	require.NoError(t, err)
+	assert.Equal(t, 1, containers)
+	assert.Equal(t, 1, len(getRecentStats(t, restored, -1)))
+
+	// Snapshots with records of no container are rejected as a whole.
+	restored = New(time.Hour, nil)
+	_, err = restored.ReadSnapshot(gzipSnapshot(t, fmt.Sprintf(`{"container":{"name":%q},"stats":[{"timestamp":%s}]}
+{"container":{},"stats":[{"timestamp":%s}]}`, containerName, now, now)), 0)
+	assert.Error(t, err)
+	_, err = restored.RecentStats(containerName, zero, zero, -1)
+	assert.Error(t, err)
+}
+
+func TestDropSnapshotContainers(t *testing.T) {
+	memoryCache := New(time.Hour, nil)
+	goneRef := info.ContainerReference{Name: "/gone"}
+	for _, ref := range []info.ContainerReference{containerRef, goneRef} {
+		stat := makeStat(0)
+		stat.Timestamp = time.Now()
+		require.NoError(t, memoryCache.AddStats(ref, stat))
+	}
+	var buf bytes.Buffer
+	require.NoError(t, memoryCache.WriteSnapshot(&buf, nil))
+
+	restored := New(time.Hour, nil)
+	containers, err := restored.ReadSnapshot(&buf, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, containers)
+
+	// Only the stats of the containers that no longer exist are dropped,
+	// and each container is only checked once.
+	exists := func(name string) bool { return name == containerName }
+	assert.Equal(t, 1, restored.DropSnapshotContainers(exists))
+	assert.Equal(t, 1, len(getRecentStats(t, restored, -1)))
+	_, err = restored.RecentStats("/gone", zero, zero, -1)
+	assert.Error(t, err)
+	assert.Equal(t, 0, restored.DropSnapshotContainers(func(string) bool { return false }))
+	assert.Equal(t, 1, len(getRecentStats(t, restored, -1)))
+}
+
+func TestRetentionOverrides(t *testing.T) {
+	overrides, err := ParseRetentionOverrides("^/system.slice/=5m, ^/docker/=2h")
+	require.NoError(t, err)
+	memoryCache := New(time.Minute, nil)
+	memoryCache.SetRetentionOverrides(overrides)
+
+	for _, test := range []struct {
+		ref    info.ContainerReference
+		maxAge time.Duration
+	}{
+		{info.ContainerReference{Name: "/system.slice/sshd.service"}, 5 * time.Minute},
+		{info.ContainerReference{Name: "/other", Aliases: []string{"/docker/abc"}}, 2 * time.Hour},
+		{info.ContainerReference{Name: "/docker/abc", Labels: map[string]string{RetentionLabel: "10s"}}, 10 * time.Second},
+		{info.ContainerReference{Name: "/docker/abc", Labels: map[string]string{RetentionLabel: "-1s"}}, 2 * time.Hour},
+		{info.ContainerReference{Name: "/user.slice"}, time.Minute},
+	} {
+		assert.Equal(t, test.maxAge, memoryCache.maxAgeOf(test.ref), "%+v", test.ref)
+	}
+
+	for _, overrides := range []string{"^/system.slice/", "^/docker/=0s", "(=5m"} {
+		_, err := ParseRetentionOverrides(overrides)
+		assert.Error(t, err, overrides)
+	}
+}
+
+func TestByteBudget(t *testing.T) {
+	memoryCache := New(time.Hour, nil)
+	statSize := statsSize(makeStat(0))
+	// Room for 10 samples.
+	memoryCache.SetByteBudget(10 * statSize)
+
+	small := info.ContainerReference{Name: "/small"}
+	for i := 0; i < 2; i++ {
+		require.NoError(t, memoryCache.AddStats(small, makeStat(i)))
+	}
+	for i := 0; i < 9; i++ {
+		require.NoError(t, memoryCache.AddStats(containerRef, makeStat(i)))
+	}
+	// The large container lost its oldest samples, the small one kept its.
+	assert.True(t, memoryCache.Bytes() <= 10*statSize, "%d bytes above the budget", memoryCache.Bytes())
+	stats := getRecentStats(t, memoryCache, -1)
+	assert.True(t, len(stats) < 9)
+	assert.Equal(t, makeStat(8), stats[len(stats)-1])
+	smallStats, err := memoryCache.RecentStats("/small", zero, zero, -1)
+	require.NoError(t, err)
+	assert.Len(t, smallStats, 2)
+
+	require.NoError(t, memoryCache.RemoveContainer(containerName))
+	assert.Equal(t, 2*statSize, memoryCache.Bytes())
+}
+
+func TestCompression(t *testing.T) {
+	memoryCache := New(time.Hour, nil)
+	memoryCache.EnableCompression()
+	var expected []*info.ContainerStats
+	for i := 0; i < compressionGroupSize+2; i++ {
+		stats := makeStat(i)
+		stats.Cpu.Usage.PerCpu = []uint64{uint64(i), 2, 3, 4}
+		stats.Filesystem = []info.FsStats{{Device: "/dev/sda1", Usage: uint64(i)}}
+		stats.Timestamp = stats.Timestamp.UTC()
+		expected = append(expected, stats)
+		require.NoError(t, memoryCache.AddStats(containerRef, stats))
+	}
+	assert.Equal(t, expected, getRecentStats(t, memoryCache, -1))
+	assert.True(t, memoryCache.Bytes() < int64(len(expected))*statsSize(expected[0]))
+}
+
+// Adds a sample to each of a thousand containers per iteration.
+func BenchmarkAddStatsCompressed(b *testing.B) {
+	memoryCache := New(time.Hour, nil)
+	memoryCache.EnableCompression()
+	refs := make([]info.ContainerReference, 1000)
+	for i := range refs {
+		refs[i] = info.ContainerReference{Name: fmt.Sprintf("/docker/%d", i)}
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, ref := range refs {
+			stats := makeStat(i)
+			stats.Cpu.Usage.PerCpu = []uint64{uint64(i), 2, 3, 4}
+			if err := memoryCache.AddStats(ref, stats); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkRecentStatsCompressed(b *testing.B) {
+	memoryCache := New(time.Hour, nil)
+	memoryCache.EnableCompression()
+	for i := 0; i < 60; i++ {
+		require.NoError(b, memoryCache.AddStats(containerRef, makeStat(i)))
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := memoryCache.RecentStats(containerName, zero, zero, -1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestFairShare(t *testing.T) {
+	assert.Equal(t, int64(45), fairShare([]int64{10, 100, 50}, 100))
+	assert.Equal(t, int64(100), fairShare([]int64{10, 100, 50}, 200))
+	assert.Equal(t, int64(0), fairShare([]int64{10, 100}, 0))
+}
@@ -0,0 +1,254 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
+)
+
+// The recent stats of a container in a snapshot of the cache.
+type snapshotRecord struct {
+	Container info.ContainerReference `json:"container"`
+	Spec      *info.ContainerSpec     `json:"spec,omitempty"`
+	Stats     []*info.ContainerStats  `json:"stats"`
+}
+
+// WriteSnapshot writes the stats of all containers to w, as gzipped JSON
+// lines of one container each. The spec of each container returned by specOf,
+// if not nil, is written along with its stats.
+func (self *InMemoryCache) WriteSnapshot(w io.Writer, specOf func(name string) *info.ContainerSpec) error {
+	self.lock.RLock()
+	cstores := make([]*containerCache, 0, len(self.containerCacheMap))
+	for _, cstore := range self.containerCacheMap {
+		cstores = append(cstores, cstore)
+	}
+	self.lock.RUnlock()
+
+	gz := gzip.NewWriter(w)
+	enc := json.NewEncoder(gz)
+	for _, cstore := range cstores {
+		stats, err := cstore.RecentStats(time.Time{}, time.Time{}, -1)
+		if err != nil {
+			return err
+		}
+		if len(stats) == 0 {
+			continue
+		}
+		record := snapshotRecord{Container: cstore.ref, Stats: stats}
+		if specOf != nil {
+			record.Spec = specOf(cstore.ref.Name)
+		}
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+	}
+	return gz.Close()
+}
+
+// ReadSnapshot adds the stats of a snapshot written by WriteSnapshot that are
+// within the max age of their container. They are neither written to the
+// backend storage nor sent to the watchers, and the specs are ignored. The
+// snapshot is rejected if larger than maxSize bytes decompressed, unless
+// maxSize is 0. It returns the number of containers whose stats were added.
+func (self *InMemoryCache) ReadSnapshot(r io.Reader, maxSize int64) (int, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, err
+	}
+	defer gz.Close()
+	var in io.Reader = gz
+	if maxSize > 0 {
+		in = &sizeLimitedReader{r: gz, remaining: maxSize}
+	}
+	// Decode the whole snapshot before adding its stats, so that a slow
+	// reader doesn't hold revisionLock.
+	var records []snapshotRecord
+	dec := json.NewDecoder(bufio.NewReader(in))
+	for {
+		var record snapshotRecord
+		err = dec.Decode(&record)
+		if err == io.EOF {
+			err = nil
+			break
+		}
+		if err == errSnapshotTooLarge {
+			return 0, fmt.Errorf("snapshot larger than %d bytes decompressed", maxSize)
+		}
+		if err != nil {
+			// A truncated snapshot is added partially.
+			err = fmt.Errorf("failed to decode snapshot: %v", err)
+			break
+		}
+		if record.Container.Name == "" {
+			return 0, fmt.Errorf("invalid snapshot: record %d has no container name", len(records)+1)
+		}
+		records = append(records, record)
+	}
+
+	now := time.Now()
+	self.revisionLock.RLock()
+	defer self.revisionLock.RUnlock()
+	revision := atomic.AddUint64(&self.revision, 1)
+	containers := 0
+	for _, record := range records {
+		self.lock.RLock()
+		cutoff := now.Add(-self.maxAgeOf(record.Container))
+		self.lock.RUnlock()
+		added := false
+		for _, stats := range record.Stats {
+			if stats == nil || stats.Timestamp.Before(cutoff) {
+				continue
+			}
+			if err := self.containerStore(record.Container).AddStats(stats, revision); err != nil {
+				return containers, err
+			}
+			added = true
+		}
+		if added {
+			containers++
+			self.lock.Lock()
+			if self.snapshotContainers == nil {
+				self.snapshotContainers = make(map[string]bool)
+			}
+			self.snapshotContainers[record.Container.Name] = true
+			self.lock.Unlock()
+		}
+	}
+	self.enforceByteBudget()
+	return containers, err
+}
+
+var errSnapshotTooLarge = errors.New("snapshot too large")
+
+// Reader failing once more than remaining bytes are read.
+type sizeLimitedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (self *sizeLimitedReader) Read(p []byte) (int, error) {
+	if self.remaining < 0 {
+		return 0, errSnapshotTooLarge
+	}
+	if int64(len(p)) > self.remaining+1 {
+		p = p[:self.remaining+1]
+	}
+	n, err := self.r.Read(p)
+	self.remaining -= int64(n)
+	if self.remaining < 0 {
+		return n, errSnapshotTooLarge
+	}
+	return n, err
+}
+
+// DropSnapshotContainers removes the stats of the containers loaded from
+// snapshots for which exists returns false, e.g. containers destroyed while
+// cAdvisor was down. Each container is only checked once, after its stats are
+// loaded. It returns the number of containers removed.
+func (self *InMemoryCache) DropSnapshotContainers(exists func(name string) bool) int {
+	self.lock.Lock()
+	names := self.snapshotContainers
+	self.snapshotContainers = nil
+	self.lock.Unlock()
+	dropped := 0
+	for name := range names {
+		if !exists(name) {
+			self.RemoveContainer(name)
+			dropped++
+		}
+	}
+	return dropped
+}
+
+// PersistTo loads the stats persisted in the file, if any, then persists the
+// stats of all containers to it every interval and on Flush, so that they
+// survive restarts.
+func (self *InMemoryCache) PersistTo(path string, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("invalid persistence interval %v, expected a positive duration", interval)
+	}
+	f, err := os.Open(path)
+	if err == nil {
+		containers, err := self.ReadSnapshot(f, 0)
+		f.Close()
+		if err != nil {
+			// A snapshot truncated by a crash is loaded partially.
+			logging.Warningf("Failed to load the stats persisted in %q: %v", path, err)
+		}
+		logging.Infof("Loaded the stats of %d containers from %q", containers, path)
+	} else if !os.IsNotExist(err) {
+		return fmt.Errorf("failed to open the stats storage file: %v", err)
+	}
+
+	self.persistPath = path
+	self.persistStop = make(chan struct{})
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := self.persist(); err != nil {
+					logging.Warningf("Failed to persist stats: %v", err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}(self.persistStop)
+	return nil
+}
+
+// Writes a snapshot to the file persisting the stats, replacing the previous
+// one only once it is complete.
+func (self *InMemoryCache) persist() error {
+	if self.persistPath == "" {
+		return nil
+	}
+	self.persistLock.Lock()
+	defer self.persistLock.Unlock()
+	tmpPath := self.persistPath + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to persist stats: %v", err)
+	}
+	w := bufio.NewWriter(tmp)
+	err = self.WriteSnapshot(w, nil)
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, self.persistPath)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to persist stats: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
+)
+
+// Label of a container setting how long its stats are kept in memory.
+const RetentionLabel = "io.cadvisor.storage_duration"
+
+// Sets how long the stats of the containers with a matching name are kept.
+type RetentionOverride struct {
+	regexp *regexp.Regexp
+	maxAge time.Duration
+}
+
+// ParseRetentionOverrides parses a comma separated list of
+// <regexp>=<duration>.
+func ParseRetentionOverrides(overrides string) ([]RetentionOverride, error) {
+	var parsed []RetentionOverride
+	for _, override := range strings.Split(overrides, ",") {
+		override = strings.TrimSpace(override)
+		if override == "" {
+			continue
+		}
+		// Regexps may contain '=', durations may not.
+		eq := strings.LastIndex(override, "=")
+		if eq < 0 {
+			return nil, fmt.Errorf("invalid storage duration override %q, expected <regexp>=<duration>", override)
+		}
+		re, err := regexp.Compile(override[:eq])
+		if err != nil {
+			return nil, fmt.Errorf("invalid regexp in storage duration override %q: %v", override, err)
+		}
+		maxAge, err := parseRetention(override[eq+1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid storage duration override %q: %v", override, err)
+		}
+		parsed = append(parsed, RetentionOverride{regexp: re, maxAge: maxAge})
+	}
+	return parsed, nil
+}
+
+func parseRetention(value string) (time.Duration, error) {
+	maxAge, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+	if maxAge <= 0 {
+		return 0, fmt.Errorf("storage duration must be positive, got %v", maxAge)
+	}
+	return maxAge, nil
+}
+
+// SetRetentionOverrides sets how long the stats of the containers matching
+// the overrides are kept, instead of the max age of the cache. It applies to
+// the containers not cached yet.
+func (self *InMemoryCache) SetRetentionOverrides(overrides []RetentionOverride) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.retentionOverrides = overrides
+}
+
+// Returns how long the stats of the container are kept: the duration of its
+// label, else the one of the first override matching its name or an alias,
+// else the max age of the cache. Must be called with the lock held.
+func (self *InMemoryCache) maxAgeOf(ref info.ContainerReference) time.Duration {
+	if value, ok := ref.Labels[RetentionLabel]; ok {
+		maxAge, err := parseRetention(value)
+		if err == nil {
+			return maxAge
+		}
+		logging.Warningf("Ignoring invalid %s label of %q: %v", RetentionLabel, ref.Name, err)
+	}
+	for _, override := range self.retentionOverrides {
+		if override.regexp.MatchString(ref.Name) {
+			return override.maxAge
+		}
+		for _, alias := range ref.Aliases {
+			if override.regexp.MatchString(alias) {
+				return override.maxAge
+			}
+		}
+	}
+	return self.maxAge
+}
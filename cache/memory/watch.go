@@ -0,0 +1,98 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
+)
+
+// Number of samples buffered per watcher before new samples are dropped.
+const statsChannelSize = 64
+
+// A stats sample as it was added to the cache.
+type StatsUpdate struct {
+	ContainerReference info.ContainerReference
+	Stats              *info.ContainerStats
+}
+
+// Returns whether samples of the referenced container should be delivered to a watcher.
+type StatsFilter func(ref info.ContainerReference) bool
+
+type StatsChannel struct {
+	// Watch ID. Can be used by the caller to request cancellation of the watch.
+	watchId int
+	// Channel on which the caller can receive new samples.
+	channel chan *StatsUpdate
+}
+
+func (self *StatsChannel) GetChannel() <-chan *StatsUpdate {
+	return self.channel
+}
+
+func (self *StatsChannel) GetWatchId() int {
+	return self.watchId
+}
+
+type statsWatch struct {
+	filter  StatsFilter
+	channel *StatsChannel
+}
+
+// WatchStats registers for samples added to the cache for containers accepted by filter.
+func (self *InMemoryCache) WatchStats(filter StatsFilter) *StatsChannel {
+	self.watchLock.Lock()
+	defer self.watchLock.Unlock()
+	self.lastWatchId++
+	ch := &StatsChannel{
+		watchId: self.lastWatchId,
+		channel: make(chan *StatsUpdate, statsChannelSize),
+	}
+	self.watchers[ch.watchId] = &statsWatch{
+		filter:  filter,
+		channel: ch,
+	}
+	return ch
+}
+
+// StopWatch cancels a watch previously registered by WatchStats and closes its channel.
+func (self *InMemoryCache) StopWatch(watchId int) {
+	self.watchLock.Lock()
+	defer self.watchLock.Unlock()
+	w, ok := self.watchers[watchId]
+	if !ok {
+		logging.Errorf("Could not find stats watcher instance %v", watchId)
+		return
+	}
+	close(w.channel.channel)
+	delete(self.watchers, watchId)
+}
+
+// Sends the sample to all interested watchers. Watchers which are not keeping
+// up miss samples rather than blocking housekeeping.
+func (self *InMemoryCache) notifyWatchers(ref info.ContainerReference, stats *info.ContainerStats) {
+	self.watchLock.RLock()
+	defer self.watchLock.RUnlock()
+	for id, w := range self.watchers {
+		if !w.filter(ref) {
+			continue
+		}
+		select {
+		case w.channel.channel <- &StatsUpdate{ContainerReference: ref, Stats: stats}:
+		default:
+			logging.V(4).Infof("Dropping stats sample of %q for slow watcher %d", ref.Name, id)
+		}
+	}
+}
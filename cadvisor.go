@@ -15,6 +15,7 @@
 package main
 
 import (
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"net"
@@ -24,21 +25,35 @@ import (
 	"os/signal"
 	"runtime"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
+	"github.com/google/cadvisor/alerts"
+	"github.com/google/cadvisor/api"
+	"github.com/google/cadvisor/cli"
+	"github.com/google/cadvisor/config"
 	"github.com/google/cadvisor/container"
 	cadvisorhttp "github.com/google/cadvisor/http"
+	"github.com/google/cadvisor/logging"
 	"github.com/google/cadvisor/manager"
+	"github.com/google/cadvisor/registration"
+	"github.com/google/cadvisor/rpc"
+	"github.com/google/cadvisor/utils/certs"
 	"github.com/google/cadvisor/utils/sysfs"
 	"github.com/google/cadvisor/version"
 
-	"github.com/golang/glog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 var argPath = flag.String("listen_path", "", "Path to listen on (UNIX socket), defaults to empty (use TCP instead)")
 var argIp = flag.String("listen_ip", "", "IP to listen on, defaults to all IPs")
 var argPort = flag.Int("port", 8080, "port to listen")
+var argTlsCertFile = flag.String("tls_cert_file", "", "PEM certificate to serve HTTPS with. The certificate and key are reloaded when modified")
+var argTlsKeyFile = flag.String("tls_key_file", "", "PEM private key of --tls_cert_file")
+var argTlsClientCAFile = flag.String("tls_client_ca_file", "", "PEM CA certificates verifying the optional TLS client certificates")
+var argGrpcPort = flag.Int("grpc_port", 0, "port to serve the gRPC API on, 0 disables it")
 var maxProcs = flag.Int("max_procs", 0, "max number of CPUs that can be used simultaneously. Less than 1 for default (number of cores).")
 
 var argDbDriver = flag.String("storage_driver", "", "storage driver to use. Data is always cached shortly in memory, this controls where data is pushed besides the local cache. Empty means none. Options are: <empty> (default), bigquery, influxdb, and kafka")
@@ -54,18 +69,25 @@ var prometheusEndpoint = flag.String("prometheus_endpoint", "/metrics", "Endpoin
 var maxHousekeepingInterval = flag.Duration("max_housekeeping_interval", 60*time.Second, "Largest interval to allow between container housekeepings")
 var allowDynamicHousekeeping = flag.Bool("allow_dynamic_housekeeping", true, "Whether to allow the housekeeping interval to be dynamic")
 
+var shutdownTimeout = flag.Duration("shutdown_timeout", 10*time.Second, "Max duration for which to wait for the HTTP requests in progress to complete on SIGTERM, after flushing the storage driver")
+
 var enableProfiling = flag.Bool("profiling", false, "Enable profiling via web interface host:port/debug/pprof/")
 
 var (
 	// Metrics to be ignored.
-	// Tcp metrics are ignored by default.
-	ignoreMetrics metricSetValue = metricSetValue{container.MetricSet{container.NetworkTcpUsageMetrics: struct{}{}}}
+	// Tcp and scheduler metrics are ignored by default.
+	ignoreMetrics metricSetValue = metricSetValue{container.MetricSet{
+		container.NetworkTcpUsageMetrics: struct{}{},
+		container.SchedulerMetrics:       struct{}{},
+	}}
 
 	// List of metrics that can be ignored.
 	ignoreWhitelist = container.MetricSet{
 		container.DiskUsageMetrics:       struct{}{},
+		container.VolumeUsageMetrics:     struct{}{},
 		container.NetworkUsageMetrics:    struct{}{},
 		container.NetworkTcpUsageMetrics: struct{}{},
+		container.SchedulerMetrics:       struct{}{},
 	}
 )
 
@@ -97,11 +119,38 @@ func (ml *metricSetValue) Set(value string) error {
 }
 
 func init() {
-	flag.Var(&ignoreMetrics, "disable_metrics", "comma-separated list of `metrics` to be disabled. Options are 'disk', 'network', 'tcp'. Note: tcp is disabled by default due to high CPU usage.")
+	flag.Var(&ignoreMetrics, "disable_metrics", "comma-separated list of `metrics` to be disabled. Options are 'disk', 'volume', 'network', 'tcp', 'sched'. Note: tcp and sched are disabled by default due to high CPU usage.")
+	// The log flags apply their new values when set.
+	config.RegisterReloadable([]string{"log_format", "log_levels"}, nil)
 }
 
 func main() {
-	defer glog.Flush()
+	if len(os.Args) > 1 && cli.IsCommand(os.Args[1]) {
+		if err := cli.Run(os.Args[1], os.Args[2:], os.Stdout); err != nil {
+			if err != flag.ErrHelp {
+				fmt.Fprintf(os.Stderr, "cadvisor %s: %v\n", os.Args[1], err)
+			}
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		if err := runBench(os.Args[2:], os.Stdout); err != nil {
+			if err != flag.ErrHelp {
+				fmt.Fprintf(os.Stderr, "cadvisor bench: %v\n", err)
+			}
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	defer logging.Flush()
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nClient commands, querying a running cAdvisor:\n%s\n", cli.Usage())
+		fmt.Fprintf(os.Stderr, "\nBenchmark:\n  cadvisor %s\n", benchUsage)
+	}
 	flag.Parse()
 
 	if *versionFlag {
@@ -109,21 +158,25 @@ func main() {
 		os.Exit(0)
 	}
 
+	if err := config.Load(); err != nil {
+		logging.Fatalf("Failed to load the configuration file: %v", err)
+	}
+
 	setMaxProcs()
 
 	memoryStorage, err := NewMemoryStorage(*argDbDriver)
 	if err != nil {
-		glog.Fatalf("Failed to connect to database: %s", err)
+		logging.Fatalf("Failed to connect to database: %s", err)
 	}
 
 	sysFs, err := sysfs.NewRealSysFs()
 	if err != nil {
-		glog.Fatalf("Failed to create a system interface: %s", err)
+		logging.Fatalf("Failed to create a system interface: %s", err)
 	}
 
 	containerManager, err := manager.New(memoryStorage, sysFs, *maxHousekeepingInterval, *allowDynamicHousekeeping, ignoreMetrics.MetricSet)
 	if err != nil {
-		glog.Fatalf("Failed to create a Container Manager: %s", err)
+		logging.Fatalf("Failed to create a Container Manager: %s", err)
 	}
 
 	mux := http.NewServeMux()
@@ -133,52 +186,176 @@ func main() {
 		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
 		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
 		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
 	}
 
 	// Register all HTTP handlers.
 	err = cadvisorhttp.RegisterHandlers(mux, containerManager, *httpAuthFile, *httpAuthRealm, *httpDigestFile, *httpDigestRealm)
 	if err != nil {
-		glog.Fatalf("Failed to register HTTP handlers: %v", err)
+		logging.Fatalf("Failed to register HTTP handlers: %v", err)
 	}
 
 	cadvisorhttp.RegisterPrometheusHandler(mux, containerManager, *prometheusEndpoint, nil)
 
 	// Start the manager.
 	if err := containerManager.Start(); err != nil {
-		glog.Fatalf("Failed to start container manager: %v", err)
+		logging.Fatalf("Failed to start container manager: %v", err)
+	}
+
+	if err := registration.Start(containerManager); err != nil {
+		logging.Fatalf("Failed to start registration: %v", err)
+	}
+
+	if err := alerts.Start(containerManager); err != nil {
+		logging.Fatalf("Failed to start alerting: %v", err)
 	}
 
 	var listener net.Listener
 
 	if *argPath != "" {
 		if _, err := os.Stat(*argPath); err == nil {
-			glog.Infof("Deleting existing socket at %s", *argPath)
+			logging.Infof("Deleting existing socket at %s", *argPath)
 			os.Remove(*argPath)
 		}
 
 		var err error
 		listener, err = net.Listen("unix", *argPath)
 		if err != nil {
-			glog.Fatalf("Failed to start listening on UNIX socket at %s: %v", *argPath, err)
+			logging.Fatalf("Failed to start listening on UNIX socket at %s: %v", *argPath, err)
 		}
 		if err := os.Chmod(*argPath, 0660); err != nil {
-			glog.Fatalf("Failed to change permissions on UNIX socket at %s: %v", *argPath, err)
+			logging.Fatalf("Failed to change permissions on UNIX socket at %s: %v", *argPath, err)
 		}
 	} else {
 		var err error
 		listener, err = net.Listen("tcp", fmt.Sprintf("%s:%d", *argIp, *argPort))
 		if err != nil {
-			glog.Fatalf("Failed to start listening on TCP socket at %s:%s: %v", *argIp, *argPort, err)
+			logging.Fatalf("Failed to start listening on TCP socket at %s:%d: %v", *argIp, *argPort, err)
 		}
 	}
 
-	glog.Infof("Starting cAdvisor version: %s-%s on %s", version.Info["version"], version.Info["revision"], listener.Addr())
+	var tlsConfig *tls.Config
+	if *argTlsCertFile != "" || *argTlsKeyFile != "" {
+		tlsConfig, err = certs.NewServerConfig(*argTlsCertFile, *argTlsKeyFile, *argTlsClientCAFile)
+		if err != nil {
+			logging.Fatalf("Failed to set up TLS: %v", err)
+		}
+		listener = tls.NewListener(listener, tlsConfig)
+	} else if *argTlsClientCAFile != "" {
+		logging.Fatalf("--tls_client_ca_file requires --tls_cert_file and --tls_key_file")
+	}
+
+	logging.Infof("Starting cAdvisor version: %s-%s on %s", version.Info["version"], version.Info["revision"], listener.Addr())
+
+	if *argGrpcPort != 0 {
+		startGrpcServer(containerManager, tlsConfig)
+	}
+
+	handler := newDrainHandler(mux)
+	server := &http.Server{Handler: handler}
 
 	// Install signal handler.
-	installSignalHandler(containerManager, listener)
+	installSignalHandler(containerManager, server, listener, handler)
 
 	// Start serving requests
-	glog.Fatal(http.Serve(listener, mux))
+	err = server.Serve(listener)
+	if !handler.isDraining() {
+		logging.Fatal(err)
+	}
+	// The signal handler exits once the requests in progress complete.
+	select {}
+}
+
+// Tracks the HTTP requests in progress so that they can complete on
+// shutdown. Requests received once draining are rejected.
+type drainHandler struct {
+	http.Handler
+	lock     sync.Mutex
+	inFlight int
+	draining bool
+	// Closed once draining and no request is in progress.
+	drained chan struct{}
+}
+
+func newDrainHandler(handler http.Handler) *drainHandler {
+	return &drainHandler{
+		Handler: handler,
+		drained: make(chan struct{}),
+	}
+}
+
+func (self *drainHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	self.lock.Lock()
+	if self.draining {
+		self.lock.Unlock()
+		w.Header().Set("Connection", "close")
+		http.Error(w, "cAdvisor is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	self.inFlight++
+	self.lock.Unlock()
+	defer self.done()
+	self.Handler.ServeHTTP(w, r)
+}
+
+func (self *drainHandler) done() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.inFlight--
+	if self.draining && self.inFlight == 0 {
+		close(self.drained)
+	}
+}
+
+// Rejects the requests received from now on.
+func (self *drainHandler) drain() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if self.draining {
+		return
+	}
+	self.draining = true
+	if self.inFlight == 0 {
+		close(self.drained)
+	}
+}
+
+func (self *drainHandler) isDraining() bool {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return self.draining
+}
+
+// Waits for the requests in progress to complete, at most for the timeout.
+// Returns whether they did.
+func (self *drainHandler) wait(timeout time.Duration) bool {
+	select {
+	case <-self.drained:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// Serves the gRPC API with the authentication, rate limits and audit log of
+// the HTTP API, over TLS if tlsConfig is set.
+func startGrpcServer(containerManager manager.Manager, tlsConfig *tls.Config) {
+	guard, err := api.NewRpcGuard()
+	if err != nil {
+		logging.Fatalf("Failed to set up gRPC authentication: %v", err)
+	}
+	var opts []grpc.ServerOption
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	grpcListener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", *argIp, *argGrpcPort))
+	if err != nil {
+		logging.Fatalf("Failed to start listening for gRPC on %s:%d: %v", *argIp, *argGrpcPort, err)
+	}
+	logging.Infof("Serving gRPC API on %s", grpcListener.Addr())
+	go func() {
+		logging.Fatal(rpc.NewServer(containerManager, guard, opts...).Serve(grpcListener))
+	}()
 }
 
 func setMaxProcs() {
@@ -195,24 +372,43 @@ func setMaxProcs() {
 	// Check if the setting was successful.
 	actualNumProcs := runtime.GOMAXPROCS(0)
 	if actualNumProcs != numProcs {
-		glog.Warningf("Specified max procs of %v but using %v", numProcs, actualNumProcs)
+		logging.Warningf("Specified max procs of %v but using %v", numProcs, actualNumProcs)
 	}
 }
 
-func installSignalHandler(containerManager manager.Manager, listener net.Listener) {
+func installSignalHandler(containerManager manager.Manager, server *http.Server, listener net.Listener, handler *drainHandler) {
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, os.Kill, syscall.SIGTERM)
 
 	// Block until a signal is received.
 	go func() {
 		sig := <-c
-		glog.Infof("Exiting containerManager")
+		// Stopping the manager stops the housekeeping and flushes the storage driver.
+		logging.Infof("Exiting containerManager")
 		if err := containerManager.Stop(); err != nil {
-			glog.Errorf("Failed to stop container manager: %v", err)
+			logging.Errorf("Failed to stop container manager: %v", err)
 		}
-		glog.Infof("Exiting listener")
+		logging.Infof("Exiting listener")
+		handler.drain()
+		server.SetKeepAlivesEnabled(false)
 		listener.Close()
-		glog.Infof("Exiting given signal: %v", sig)
+		if !handler.wait(*shutdownTimeout) {
+			logging.Warningf("Failed to drain the HTTP requests in progress within %v", *shutdownTimeout)
+		}
+		logging.Infof("Exiting given signal: %v", sig)
+		logging.Flush()
 		os.Exit(0)
 	}()
+
+	// Reload the configuration file on SIGHUP.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			logging.Infof("Reloading the configuration file")
+			if err := config.Reload(); err != nil {
+				logging.Errorf("Failed to reload the configuration file: %v", err)
+			}
+		}
+	}()
 }
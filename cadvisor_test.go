@@ -15,8 +15,13 @@
 package main
 
 import (
+	"bytes"
 	"flag"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/cadvisor/container"
 	"github.com/stretchr/testify/assert"
@@ -47,3 +52,46 @@ func TestIgnoreMetrics(t *testing.T) {
 		}
 	}
 }
+
+func TestRunBench(t *testing.T) {
+	var out bytes.Buffer
+	assert.NoError(t, runBench([]string{"--containers=2", "--duration=50ms", "--interfaces=2"}, &out))
+	assert.Contains(t, out.String(), "containers:            2\n")
+	// The first housekeeping of each container is immediate.
+	assert.Regexp(t, "housekeepings: +[2-9]", out.String())
+
+	// Without a storage driver, the stats are only cached.
+	assert.False(t, strings.Contains(out.String(), "storage writes"))
+	assert.Error(t, runBench([]string{"--containers=0"}, &out))
+
+	// The first housekeepings have no interval.
+	recorder := &benchRecorder{interval: time.Second}
+	start := time.Unix(100, 0)
+	recorder.record(time.Time{}, start)
+	assert.Equal(t, time.Duration(0), recorder.intervalPercentile(1))
+	recorder.record(start, start.Add(time.Second))
+	recorder.record(start, start.Add(2*time.Second))
+	assert.Equal(t, time.Second, recorder.intervalPercentile(0.5))
+	assert.Equal(t, 2*time.Second, recorder.intervalPercentile(1))
+	assert.Equal(t, 3, recorder.housekeepings)
+}
+
+func TestDrainHandler(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	handler := newDrainHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	}))
+	go handler.ServeHTTP(httptest.NewRecorder(), &http.Request{})
+	<-started
+
+	handler.drain()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, &http.Request{})
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.False(t, handler.wait(10*time.Millisecond))
+
+	close(release)
+	assert.True(t, handler.wait(time.Second))
+}
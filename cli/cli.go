@@ -0,0 +1,339 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cli implements the client subcommands of the cadvisor binary,
+// querying a running cAdvisor.
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	client "github.com/google/cadvisor/client/v2"
+	v1 "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/info/v2"
+)
+
+type command struct {
+	usage string
+	run   func(args []string, out io.Writer) error
+}
+
+const (
+	topUsage   = "top [flags]: show the containers using the most resources, refreshed until interrupted"
+	statsUsage = "stats [flags] [container]: print the stats of a container as JSON or CSV"
+)
+
+var commands = map[string]command{
+	"top":   {usage: topUsage, run: runTop},
+	"stats": {usage: statsUsage, run: runStats},
+}
+
+// IsCommand returns whether the first argument of the binary is a client
+// subcommand rather than a flag of the cAdvisor daemon.
+func IsCommand(name string) bool {
+	_, ok := commands[name]
+	return ok
+}
+
+// Run runs the client subcommand with its arguments.
+func Run(name string, args []string, out io.Writer) error {
+	cmd, ok := commands[name]
+	if !ok {
+		return fmt.Errorf("unknown command %q", name)
+	}
+	return cmd.run(args, out)
+}
+
+// Flags shared by the subcommands.
+type clientFlags struct {
+	url   *string
+	token *string
+}
+
+func newFlagSet(name, usage string) (*flag.FlagSet, *clientFlags) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: cadvisor %s\n", usage)
+		flags.PrintDefaults()
+	}
+	return flags, &clientFlags{
+		url:   flags.String("url", "http://localhost:8080", "URL of the cAdvisor to query"),
+		token: flags.String("token", os.Getenv("CADVISOR_TOKEN"), "bearer token of the API, $CADVISOR_TOKEN by default"),
+	}
+}
+
+func (self *clientFlags) newClient() (*client.Client, error) {
+	c, err := client.NewClient(*self.url)
+	if err != nil {
+		return nil, err
+	}
+	if *self.token != "" {
+		c.SetBearerToken(*self.token)
+	}
+	return c, nil
+}
+
+func runTop(args []string, out io.Writer) error {
+	flags, clientFlags := newFlagSet("top", topUsage)
+	interval := flags.Duration("interval", 2*time.Second, "interval between refreshes")
+	sortBy := flags.String("sort", "cpu", "column to sort the containers by: cpu, memory, rx, tx or name")
+	limit := flags.Int("n", 20, "number of containers to show, 0 for all")
+	once := flags.Bool("once", false, "print the containers once and exit, without clearing the terminal")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if _, ok := topSorts[*sortBy]; !ok {
+		return fmt.Errorf("unknown sort column %q", *sortBy)
+	}
+	c, err := clientFlags.newClient()
+	if err != nil {
+		return err
+	}
+	machine, err := c.MachineInfo()
+	if err != nil {
+		return err
+	}
+	for {
+		containers, err := c.Stats("/", &v2.RequestOptions{IdType: v2.TypeName, Count: 2, Recursive: true})
+		if err != nil {
+			return err
+		}
+		if !*once {
+			// Move to the top left corner and clear the terminal.
+			fmt.Fprint(out, "\033[H\033[2J")
+		}
+		renderTop(out, *clientFlags.url, machine, containers, *sortBy, *limit, time.Now())
+		if *once {
+			return nil
+		}
+		time.Sleep(*interval)
+	}
+}
+
+// A line of top.
+type topRow struct {
+	name       string
+	cpu        float64
+	memory     uint64
+	workingSet uint64
+	rx         float64
+	tx         float64
+}
+
+var topSorts = map[string]func(a, b *topRow) bool{
+	"cpu":    func(a, b *topRow) bool { return a.cpu > b.cpu },
+	"memory": func(a, b *topRow) bool { return a.memory > b.memory },
+	"rx":     func(a, b *topRow) bool { return a.rx > b.rx },
+	"tx":     func(a, b *topRow) bool { return a.tx > b.tx },
+	"name":   func(a, b *topRow) bool { return a.name < b.name },
+}
+
+type topRows struct {
+	rows []*topRow
+	less func(a, b *topRow) bool
+}
+
+func (self topRows) Len() int      { return len(self.rows) }
+func (self topRows) Swap(i, j int) { self.rows[i], self.rows[j] = self.rows[j], self.rows[i] }
+func (self topRows) Less(i, j int) bool {
+	if self.less(self.rows[i], self.rows[j]) {
+		return true
+	}
+	if self.less(self.rows[j], self.rows[i]) {
+		return false
+	}
+	return self.rows[i].name < self.rows[j].name
+}
+
+func newTopRow(name string, cont v2.ContainerInfo) *topRow {
+	row := &topRow{name: name}
+	if len(cont.Spec.Aliases) > 0 {
+		row.name = cont.Spec.Aliases[0]
+	}
+	if len(cont.Stats) == 0 {
+		return row
+	}
+	cur := cont.Stats[len(cont.Stats)-1]
+	if cur.CpuInst != nil {
+		row.cpu = float64(cur.CpuInst.Usage.Total) / 1e9
+	}
+	if cur.Memory != nil {
+		row.memory = cur.Memory.Usage
+		row.workingSet = cur.Memory.WorkingSet
+	}
+	if len(cont.Stats) > 1 {
+		prev := cont.Stats[len(cont.Stats)-2]
+		interval := cur.Since(prev).Seconds()
+		prevRx, prevTx := networkBytes(prev)
+		curRx, curTx := networkBytes(cur)
+		if interval > 0 && curRx >= prevRx && curTx >= prevTx {
+			row.rx = float64(curRx-prevRx) / interval
+			row.tx = float64(curTx-prevTx) / interval
+		}
+	}
+	return row
+}
+
+// Returns the bytes received and transmitted on all the interfaces.
+func networkBytes(stats *v2.ContainerStats) (rx, tx uint64) {
+	if stats.Network == nil {
+		return 0, 0
+	}
+	for _, iface := range stats.Network.Interfaces {
+		rx += iface.RxBytes
+		tx += iface.TxBytes
+	}
+	return rx, tx
+}
+
+// Renders the header of the machine and a line per container, the root
+// container excluded.
+func renderTop(out io.Writer, url string, machine *v1.MachineInfo, containers map[string]v2.ContainerInfo, sortBy string, limit int, now time.Time) {
+	rows := topRows{less: topSorts[sortBy]}
+	var root *topRow
+	for name, cont := range containers {
+		row := newTopRow(name, cont)
+		if name == "/" {
+			root = row
+			continue
+		}
+		rows.rows = append(rows.rows, row)
+	}
+	sort.Sort(rows)
+
+	fmt.Fprintf(out, "cAdvisor %s - %s - %d containers\n", url, now.Format("15:04:05"), len(rows.rows))
+	if root != nil {
+		fmt.Fprintf(out, "Machine: %d cores, cpu %.2f cores, memory %s / %s\n", machine.NumCores, root.cpu, humanizeBytes(float64(root.workingSet)), humanizeBytes(float64(machine.MemoryCapacity)))
+	}
+	fmt.Fprintln(out)
+	fmt.Fprintf(out, "%-40s %8s %10s %10s %10s %10s\n", "CONTAINER", "CPU%", "MEM", "WSET", "RX/S", "TX/S")
+	for i, row := range rows.rows {
+		if limit > 0 && i >= limit {
+			break
+		}
+		fmt.Fprintf(out, "%-40s %8.1f %10s %10s %10s %10s\n", truncate(row.name, 40), row.cpu*100, humanizeBytes(float64(row.memory)), humanizeBytes(float64(row.workingSet)), humanizeBytes(row.rx), humanizeBytes(row.tx))
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-3] + "..."
+}
+
+func humanizeBytes(value float64) string {
+	units := []string{"B", "K", "M", "G", "T"}
+	i := 0
+	for value >= 1024 && i < len(units)-1 {
+		value /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f%s", value, units[i])
+	}
+	return fmt.Sprintf("%.1f%s", value, units[i])
+}
+
+func runStats(args []string, out io.Writer) error {
+	flags, clientFlags := newFlagSet("stats", statsUsage)
+	format := flags.String("format", "json", "output format: json or csv")
+	count := flags.Int("count", 1, "number of samples per container")
+	recursive := flags.Bool("recursive", false, "also print the stats of the subcontainers")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *format != "json" && *format != "csv" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	name := "/"
+	if flags.NArg() > 0 {
+		name = flags.Arg(0)
+	}
+	c, err := clientFlags.newClient()
+	if err != nil {
+		return err
+	}
+	containers, err := c.Stats(name, &v2.RequestOptions{IdType: v2.TypeName, Count: *count, Recursive: *recursive})
+	if err != nil {
+		return err
+	}
+	if *format == "json" {
+		data, err := json.MarshalIndent(containers, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(out, "%s\n", data)
+		return err
+	}
+	return writeStatsCsv(out, containers)
+}
+
+var statsCsvHeader = []string{"container", "timestamp", "cpu_cores", "memory_usage_bytes", "memory_working_set_bytes", "network_rx_bytes", "network_tx_bytes"}
+
+// Writes a line per sample of each container, ordered by container name.
+func writeStatsCsv(out io.Writer, containers map[string]v2.ContainerInfo) error {
+	names := make([]string, 0, len(containers))
+	for name := range containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	w := csv.NewWriter(out)
+	if err := w.Write(statsCsvHeader); err != nil {
+		return err
+	}
+	for _, name := range names {
+		for _, stats := range containers[name].Stats {
+			cpu := ""
+			if stats.CpuInst != nil {
+				cpu = strconv.FormatFloat(float64(stats.CpuInst.Usage.Total)/1e9, 'f', 4, 64)
+			}
+			var usage, workingSet string
+			if stats.Memory != nil {
+				usage = strconv.FormatUint(stats.Memory.Usage, 10)
+				workingSet = strconv.FormatUint(stats.Memory.WorkingSet, 10)
+			}
+			rx, tx := networkBytes(stats)
+			err := w.Write([]string{name, stats.Timestamp.UTC().Format(time.RFC3339Nano), cpu, usage, workingSet, strconv.FormatUint(rx, 10), strconv.FormatUint(tx, 10)})
+			if err != nil {
+				return err
+			}
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// Usage returns the usage of the subcommands.
+func Usage() string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		lines = append(lines, "  cadvisor "+commands[name].usage)
+	}
+	return strings.Join(lines, "\n")
+}
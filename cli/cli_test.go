@@ -0,0 +1,95 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/info/v2"
+)
+
+func testContainerInfo(alias string, cpu, workingSet, rx uint64) v2.ContainerInfo {
+	start := time.Unix(1000, 0)
+	info := v2.ContainerInfo{
+		Stats: []*v2.ContainerStats{
+			{
+				Timestamp: start,
+				Network:   &v2.NetworkStats{Interfaces: []v1.InterfaceStats{{RxBytes: 0}}},
+			},
+			{
+				Timestamp: start.Add(2 * time.Second),
+				CpuInst:   &v2.CpuInstStats{Usage: v2.CpuInstUsage{Total: cpu}},
+				Memory:    &v1.MemoryStats{Usage: workingSet * 2, WorkingSet: workingSet},
+				Network:   &v2.NetworkStats{Interfaces: []v1.InterfaceStats{{RxBytes: rx}}},
+			},
+		},
+	}
+	if alias != "" {
+		info.Spec.Aliases = []string{alias}
+	}
+	return info
+}
+
+func TestRenderTop(t *testing.T) {
+	containers := map[string]v2.ContainerInfo{
+		"/":       testContainerInfo("", 2e9, 1<<30, 0),
+		"/docker": testContainerInfo("", 5e8, 1<<20, 2048),
+		"/web":    testContainerInfo("web", 1e9, 1<<10, 0),
+	}
+	machine := &v1.MachineInfo{NumCores: 4, MemoryCapacity: 4 << 30}
+	var out bytes.Buffer
+	renderTop(&out, "http://node:8080", machine, containers, "cpu", 0, time.Unix(0, 0))
+	lines := strings.Split(out.String(), "\n")
+	if !strings.Contains(lines[1], "4 cores, cpu 2.00 cores, memory 1.0G / 4.0G") {
+		t.Errorf("unexpected machine line %q", lines[1])
+	}
+	if !strings.HasPrefix(lines[4], "web ") || !strings.Contains(lines[4], "100.0") {
+		t.Errorf("expected web first, got %q", lines[4])
+	}
+	if !strings.HasPrefix(lines[5], "/docker ") || !strings.HasSuffix(lines[5], "1.0K         0B") {
+		t.Errorf("unexpected /docker line %q", lines[5])
+	}
+
+	out.Reset()
+	renderTop(&out, "http://node:8080", machine, containers, "memory", 1, time.Unix(0, 0))
+	lines = strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 5 || !strings.HasPrefix(lines[4], "/docker ") {
+		t.Errorf("expected only /docker, got %q", out.String())
+	}
+}
+
+func TestWriteStatsCsv(t *testing.T) {
+	containers := map[string]v2.ContainerInfo{
+		"/b": testContainerInfo("", 5e8, 100, 10),
+		"/a": testContainerInfo("", 1e9, 200, 20),
+	}
+	var out bytes.Buffer
+	if err := writeStatsCsv(&out, containers); err != nil {
+		t.Fatal(err)
+	}
+	expected := `container,timestamp,cpu_cores,memory_usage_bytes,memory_working_set_bytes,network_rx_bytes,network_tx_bytes
+/a,1970-01-01T00:16:40Z,,,,0,0
+/a,1970-01-01T00:16:42Z,1.0000,400,200,20,0
+/b,1970-01-01T00:16:40Z,,,,0,0
+/b,1970-01-01T00:16:42Z,0.5000,200,100,10,0
+`
+	if out.String() != expected {
+		t.Errorf("unexpected CSV:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+}
@@ -29,8 +29,7 @@ import (
 	"strings"
 
 	"github.com/google/cadvisor/info/v1"
-
-	"github.com/golang/glog"
+	"github.com/google/cadvisor/logging"
 )
 
 // Client represents the base URL for a cAdvisor client.
@@ -216,7 +215,7 @@ func (self *Client) getEventStreamingData(url string, einfo chan *v1.Event) erro
 				break
 			}
 			// if called without &stream=true will not be able to parse event and will trigger fatal
-			glog.Fatalf("Received error %v", err)
+			logging.Fatalf("Received error %v", err)
 		}
 		einfo <- m
 	}
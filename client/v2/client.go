@@ -33,6 +33,7 @@ import (
 // Client represents the base URL for a cAdvisor client.
 type Client struct {
 	baseUrl string
+	token   string
 }
 
 // NewClient returns a new client with the specified base URL.
@@ -46,6 +47,12 @@ func NewClient(url string) (*Client, error) {
 	}, nil
 }
 
+// SetBearerToken authenticates the requests of the client with the token, for
+// cAdvisors requiring API authentication.
+func (self *Client) SetBearerToken(token string) {
+	self.token = token
+}
+
 // MachineInfo returns the JSON machine information for this client.
 // A non-nil error result indicates a problem with obtaining
 // the JSON machine information data.
@@ -125,7 +132,7 @@ func (self *Client) statsUrl(name string) string {
 }
 
 func (self *Client) httpGetResponse(postData interface{}, urlPath, infoName string) ([]byte, error) {
-	var resp *http.Response
+	var req *http.Request
 	var err error
 
 	if postData != nil {
@@ -133,10 +140,20 @@ func (self *Client) httpGetResponse(postData interface{}, urlPath, infoName stri
 		if marshalErr != nil {
 			return nil, fmt.Errorf("unable to marshal data: %v", marshalErr)
 		}
-		resp, err = http.Post(urlPath, "application/json", bytes.NewBuffer(data))
+		req, err = http.NewRequest("POST", urlPath, bytes.NewBuffer(data))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
 	} else {
-		resp, err = http.Get(urlPath)
+		req, err = http.NewRequest("GET", urlPath, nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to create request %q to %q: %v", infoName, urlPath, err)
+	}
+	if self.token != "" {
+		req.Header.Set("Authorization", "Bearer "+self.token)
 	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("unable to post %q to %q: %v", infoName, urlPath, err)
 	}
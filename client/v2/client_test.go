@@ -216,3 +216,25 @@ func TestRequestFails(t *testing.T) {
 		t.Fatalf("Expected error %q but received %q", expectedError, err)
 	}
 }
+
+func TestBearerToken(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprintf(w, "0.1.2")
+	}))
+	defer ts.Close()
+	client, err := NewClient(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.VersionInfo(); err == nil {
+		t.Errorf("expected the request without token to fail")
+	}
+	client.SetBearerToken("secret")
+	version, err := client.VersionInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, "0.1.2", version)
+}
@@ -0,0 +1,260 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package config sets the flags of cAdvisor from a configuration file, and
+// reloads the reloadable ones on SIGHUP.
+//
+// The file is the flat subset of YAML and TOML in which every key is the name
+// of a flag: one "name: value" or "name = value" per line, with optional
+// quotes around the value and "#" comments. Flags set on the command line take
+// precedence over the file.
+package config
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/cadvisor/logging"
+)
+
+var argConfigFile = flag.String("config_file", "", "YAML or TOML file of flag names to values, e.g. 'housekeeping_interval: 5s'. Flags set on the command line take precedence. Reloadable flags are reloaded on SIGHUP")
+
+// Flags whose values are hidden in the effective configuration.
+var secretWords = []string{"password", "secret", "token"}
+
+var (
+	lock sync.Mutex
+	// Flags set on the command line, which the file does not override.
+	commandLine map[string]bool
+	// Names of the reloadable flags, and the functions validating their new
+	// values.
+	reloadable = map[string]bool{"v": true}
+	onReload   []Applier
+)
+
+// An Applier validates the values the reloadable flags are about to take,
+// given by value, without reading the flags themselves. It returns the
+// function publishing the new values, called once every Applier accepted
+// them and the flags are set.
+type Applier func(value func(name string) string) (commit func(), err error)
+
+// RegisterReloadable marks the flags as reloadable. apply, if not nil, is
+// called when a reload changes any flag, to validate and apply the new
+// values. The flags without an Applier are validated by setting them, and
+// must synchronize their readers themselves.
+func RegisterReloadable(names []string, apply Applier) {
+	lock.Lock()
+	defer lock.Unlock()
+	for _, name := range names {
+		reloadable[name] = true
+	}
+	if apply != nil {
+		onReload = append(onReload, apply)
+	}
+}
+
+// Load sets the flags from the configuration file, if any. Must be called
+// after flag.Parse.
+func Load() error {
+	lock.Lock()
+	defer lock.Unlock()
+	commandLine = make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		commandLine[f.Name] = true
+	})
+	if *argConfigFile == "" {
+		return nil
+	}
+	values, err := readFile(*argConfigFile)
+	if err != nil {
+		return err
+	}
+	for name, value := range values {
+		if commandLine[name] {
+			continue
+		}
+		if err := flag.Set(name, value); err != nil {
+			return fmt.Errorf("invalid value %q of %q in %s: %v", value, name, *argConfigFile, err)
+		}
+	}
+	return nil
+}
+
+// Reload sets the reloadable flags from the configuration file again, and
+// applies their new values. Changes of the other flags require a restart.
+// Either all the new values are applied, or none if any is invalid.
+func Reload() error {
+	lock.Lock()
+	defer lock.Unlock()
+	if *argConfigFile == "" {
+		return fmt.Errorf("no configuration file to reload, see --config_file")
+	}
+	values, err := readFile(*argConfigFile)
+	if err != nil {
+		return err
+	}
+	changes := make(map[string]string)
+	for name, value := range values {
+		f := flag.Lookup(name)
+		if commandLine[name] || f.Value.String() == value {
+			continue
+		}
+		if !reloadable[name] {
+			logging.Warningf("Ignoring the new value of %q in %s, which requires a restart", name, *argConfigFile)
+			continue
+		}
+		changes[name] = value
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	value := func(name string) string {
+		if value, ok := changes[name]; ok {
+			return value
+		}
+		return flag.Lookup(name).Value.String()
+	}
+	commits := make([]func(), 0, len(onReload))
+	for _, apply := range onReload {
+		commit, err := apply(value)
+		if err != nil {
+			return fmt.Errorf("invalid configuration in %s: %v", *argConfigFile, err)
+		}
+		commits = append(commits, commit)
+	}
+
+	old := make(map[string]string, len(changes))
+	for name, value := range changes {
+		f := flag.Lookup(name)
+		old[name] = f.Value.String()
+		if err := f.Value.Set(value); err != nil {
+			// Restore the flags set so far.
+			for name, value := range old {
+				flag.Lookup(name).Value.Set(value)
+			}
+			return fmt.Errorf("invalid value %q of %q in %s: %v", value, name, *argConfigFile, err)
+		}
+	}
+	for name, value := range changes {
+		logging.Infof("Reloaded %q: %q (was %q)", name, value, old[name])
+	}
+	for _, commit := range commits {
+		commit()
+	}
+	return nil
+}
+
+// Effective returns the current values of all flags, with the values of
+// secret flags hidden.
+func Effective() map[string]string {
+	lock.Lock()
+	defer lock.Unlock()
+	values := make(map[string]string)
+	flag.VisitAll(func(f *flag.Flag) {
+		value := f.Value.String()
+		if value != "" && isSecret(f.Name) {
+			value = "<hidden>"
+		}
+		values[f.Name] = value
+	})
+	return values
+}
+
+// ReloadableFlags returns the sorted names of the reloadable flags.
+func ReloadableFlags() []string {
+	lock.Lock()
+	defer lock.Unlock()
+	names := make([]string, 0, len(reloadable))
+	for name := range reloadable {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func isSecret(name string) bool {
+	for _, word := range secretWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+func readFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the configuration file: %v", err)
+	}
+	defer f.Close()
+	values, err := parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %v", path, err)
+	}
+	return values, nil
+}
+
+// Parses "name: value" or "name = value" lines into flag values.
+func parse(r io.Reader) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line == "---" {
+			continue
+		}
+		sep := strings.IndexAny(line, ":=")
+		if sep < 0 {
+			return nil, fmt.Errorf("line %d: expected name: value or name = value", lineNumber)
+		}
+		name := strings.TrimSpace(line[:sep])
+		value, err := parseValue(strings.TrimSpace(line[sep+1:]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+		if flag.Lookup(name) == nil {
+			return nil, fmt.Errorf("line %d: unknown flag %q", lineNumber, name)
+		}
+		values[name] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// Unquotes the value, or strips the trailing comment of an unquoted value.
+func parseValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if quote := value[0]; quote == '"' || quote == '\'' {
+		end := strings.IndexByte(value[1:], quote)
+		if end < 0 {
+			return "", fmt.Errorf("unterminated quoted value %s", value)
+		}
+		return value[1 : end+1], nil
+	}
+	if comment := strings.Index(value, " #"); comment >= 0 {
+		value = strings.TrimSpace(value[:comment])
+	}
+	return value, nil
+}
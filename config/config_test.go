@@ -0,0 +1,101 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testInterval = flag.Duration("config_test_interval", time.Second, "")
+var testName = flag.String("config_test_name", "", "")
+var testPassword = flag.String("config_test_password", "", "")
+var testCount = flag.Int("config_test_count", 1, "")
+
+func TestParse(t *testing.T) {
+	values, err := parse(strings.NewReader(`---
+# YAML
+config_test_interval: 5s # comment
+config_test_name: "a: b # c"
+
+config_test_password = 'secret'
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"config_test_interval": "5s",
+		"config_test_name":     "a: b # c",
+		"config_test_password": "secret",
+	}, values)
+
+	for _, invalid := range []string{"config_test_name", "unknown_flag: 1", `config_test_name: "a`} {
+		_, err := parse(strings.NewReader(invalid))
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestLoadAndReload(t *testing.T) {
+	f, err := ioutil.TempFile("", "cadvisor-config")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	write := func(content string) {
+		require.NoError(t, ioutil.WriteFile(f.Name(), []byte(content), 0600))
+	}
+	defer func(file string) { *argConfigFile = file }(*argConfigFile)
+	*argConfigFile = f.Name()
+
+	write("config_test_interval: 5s\nconfig_test_name: a\nconfig_test_password: p\n")
+	require.NoError(t, Load())
+	assert.Equal(t, 5*time.Second, *testInterval)
+	assert.Equal(t, "a", *testName)
+	assert.Equal(t, "<hidden>", Effective()["config_test_password"])
+
+	applied := ""
+	RegisterReloadable([]string{"config_test_name", "config_test_count"}, func(value func(string) string) (func(), error) {
+		name := value("config_test_name")
+		if name == "invalid" {
+			return nil, fmt.Errorf("invalid name")
+		}
+		return func() { applied = name }, nil
+	})
+	write("config_test_interval: 10s\nconfig_test_name: b\n")
+	require.NoError(t, Reload())
+	assert.Equal(t, "b", *testName)
+	assert.Equal(t, "b", applied)
+	// Not reloadable.
+	assert.Equal(t, 5*time.Second, *testInterval)
+
+	// Unchanged.
+	applied = ""
+	require.NoError(t, Reload())
+	assert.Equal(t, "", applied)
+
+	// Nothing changes when a value is invalid, whether the flag or the
+	// Applier rejects it.
+	write("config_test_name: c\nconfig_test_count: x\n")
+	assert.Error(t, Reload())
+	write("config_test_name: invalid\nconfig_test_count: 2\n")
+	assert.Error(t, Reload())
+	assert.Equal(t, "b", *testName)
+	assert.Equal(t, 1, *testCount)
+	assert.Equal(t, "", applied)
+}
@@ -24,9 +24,7 @@ import (
 	"time"
 
 	"github.com/google/cadvisor/fs"
-	"github.com/google/cadvisor/utils"
-
-	"github.com/golang/glog"
+	"github.com/google/cadvisor/logging"
 )
 
 type FsHandler interface {
@@ -83,8 +81,8 @@ type realFsHandler struct {
 	fsInfo      fs.FsInfo
 	baseDirs    map[string]struct{}
 	allDirs     map[string]struct{}
-	// Tells the container to stop.
-	stopChan chan struct{}
+	// Schedules the updates.
+	worker *usageWorker
 }
 
 const (
@@ -119,7 +117,7 @@ func NewFsHandler(period time.Duration, baseDirs []string, extraDirs []string, f
 		baseDirs:    baseDirsSet,
 		allDirs:     allDirsSet,
 		fsInfo:      fsInfo,
-		stopChan:    make(chan struct{}, 1),
+		worker:      defaultUsageWorker,
 	}
 }
 
@@ -145,7 +143,7 @@ func (fh *realFsHandler) gatherDiskUsage(devices map[string]struct{}) (map[strin
 		if dir == "" {
 			// This should not happen if we're called properly, but it's
 			// presumably not worth crashing for.
-			glog.Warningf("FS handler received an empty dir: %q", dir)
+			logging.Warningf("FS handler received an empty dir: %q", dir)
 			continue
 		}
 
@@ -185,7 +183,7 @@ func (fh *realFsHandler) update() error {
 	for dir := range fh.allDirs {
 		fsDevice, err := fh.fsInfo.GetDirFsDevice(dir)
 		if err != nil {
-			glog.Warningf("Unable to find device for directory %q: %v", dir, err)
+			logging.Warningf("Unable to find device for directory %q: %v", dir, err)
 			continue
 		}
 
@@ -252,41 +250,32 @@ func (fh *realFsHandler) update() error {
 	return nil
 }
 
-func (fh *realFsHandler) trackUsage() {
-	err := fh.update()
-	if err != nil {
-		glog.Errorf("failed to collect filesystem stats - %v", err)
-	}
-
-	for {
-		select {
-		case <-fh.stopChan:
-			return
-		case <-time.After(utils.Jitter(fh.period, 0.25)):
-			start := time.Now()
-			if err := fh.update(); err != nil {
-				glog.Errorf("failed to collect filesystem stats - %v", err)
-				fh.period = fh.period * 2
-				if fh.period > maxDuBackoffFactor*fh.minPeriod {
-					fh.period = maxDuBackoffFactor * fh.minPeriod
-				}
-			} else {
-				fh.period = fh.minPeriod
-			}
-			duration := time.Since(start)
-			if duration > longDu {
-				glog.V(2).Infof("`du` on following dirs took %v: %v", duration, fh.allDirs)
-			}
+// Updates the usage and returns the period until the next update, backed
+// off after failures.
+func (fh *realFsHandler) updateWithBackoff() time.Duration {
+	start := time.Now()
+	if err := fh.update(); err != nil {
+		logging.Errorf("failed to collect filesystem stats - %v", err)
+		fh.period = fh.period * 2
+		if fh.period > maxDuBackoffFactor*fh.minPeriod {
+			fh.period = maxDuBackoffFactor * fh.minPeriod
 		}
+	} else {
+		fh.period = fh.minPeriod
 	}
+	duration := time.Since(start)
+	if duration > longDu {
+		logging.V(2).Infof("`du` on following dirs took %v: %v", duration, fh.allDirs)
+	}
+	return fh.period
 }
 
 func (fh *realFsHandler) Start() {
-	go fh.trackUsage()
+	fh.worker.register(fh)
 }
 
 func (fh *realFsHandler) Stop() {
-	close(fh.stopChan)
+	fh.worker.unregister(fh)
 }
 
 func (fh *realFsHandler) Usage() ([]*info.FsStats, error) {
@@ -298,3 +287,33 @@ func (fh *realFsHandler) Usage() ([]*info.FsStats, error) {
 	}
 	return fh.fsStats, nil
 }
+
+// Returns the stats of the network filesystems on which the dirs are, of all
+// of them if dirs is nil.
+func NetworkFsStats(fsInfo fs.FsInfo, dirs []string) ([]info.FsStats, error) {
+	filesystems, err := fsInfo.GetNetworkFsInfo(dirs)
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]info.FsStats, 0, len(filesystems))
+	for _, fs := range filesystems {
+		stat := info.FsStats{
+			Device:     fs.Source,
+			Type:       fs.Type,
+			Limit:      fs.Capacity,
+			Usage:      fs.Capacity - fs.Free,
+			Available:  fs.Available,
+			InodesFree: fs.InodesFree,
+		}
+		if fs.Ops != nil {
+			stat.NetworkOps = &info.NetworkFsOps{
+				Reads:    fs.Ops.Reads,
+				ReadRtt:  fs.Ops.ReadRtt,
+				Writes:   fs.Ops.Writes,
+				WriteRtt: fs.Ops.WriteRtt,
+			}
+		}
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"github.com/google/cadvisor/fs"
 	"github.com/stretchr/testify/assert"
+	"sync"
 	"testing"
 	"time"
 )
@@ -143,6 +144,10 @@ func (self *testFsInfo) GetMountpointForDevice(device string) (string, error) {
 	return "", fmt.Errorf("Not implemented: GetMountpointForDevice(%s)", device)
 }
 
+func (self *testFsInfo) GetNetworkFsInfo(dirs []string) ([]fs.NetworkFs, error) {
+	return nil, nil
+}
+
 var testBaseDirs = []string{
 	"/var/lib/docker/aufs/diff/aa",
 	"/some/mount",
@@ -331,3 +336,98 @@ func TestCollectionWithDuAndIgnoredDevices(t *testing.T) {
 		}
 	}
 }
+
+// Records the maximum number of concurrent dir usage scans.
+type concurrencyFsInfo struct {
+	testFsInfo
+	lock    sync.Mutex
+	current int
+	max     int
+}
+
+func (self *concurrencyFsInfo) GetDirUsage(dir string, timeout time.Duration) (uint64, error) {
+	self.lock.Lock()
+	self.current++
+	if self.current > self.max {
+		self.max = self.current
+	}
+	self.lock.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	self.lock.Lock()
+	self.current--
+	self.lock.Unlock()
+	return self.testFsInfo.GetDirUsage(dir, timeout)
+}
+
+func TestUsageWorkerConcurrency(t *testing.T) {
+	as := assert.New(t)
+
+	(*skipDuFlag) = false
+	oldConcurrency := *diskUsageMaxConcurrent
+	defer func() { *diskUsageMaxConcurrent = oldConcurrency }()
+	*diskUsageMaxConcurrent = 1
+
+	worker := newUsageWorker()
+	fsInfo := &concurrencyFsInfo{testFsInfo: testFsInfo{allowDirUsage: true, t: t}}
+	var handlers []*realFsHandler
+	for i := 0; i < 4; i++ {
+		hdlr := NewFsHandler(time.Hour, testBaseDirs, testExtraDirs, fsInfo).(*realFsHandler)
+		hdlr.worker = worker
+		hdlr.Start()
+		handlers = append(handlers, hdlr)
+	}
+
+	// Every handler is updated once, one scan at a time.
+	deadline := time.Now().Add(5 * time.Second)
+	for _, hdlr := range handlers {
+		for {
+			if _, err := hdlr.Usage(); err == nil {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("handler not updated")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		hdlr.Stop()
+	}
+	fsInfo.lock.Lock()
+	as.Equal(1, fsInfo.max)
+	fsInfo.lock.Unlock()
+}
+
+func TestUsageWorkerRestart(t *testing.T) {
+	as := assert.New(t)
+
+	(*skipDuFlag) = false
+	worker := newUsageWorker()
+	fsInfo := &concurrencyFsInfo{testFsInfo: testFsInfo{allowDirUsage: true, t: t}}
+	hdlr := NewFsHandler(time.Hour, testBaseDirs, testExtraDirs, fsInfo).(*realFsHandler)
+	hdlr.worker = worker
+
+	// The loop exits once the handler is unregistered, and the next
+	// registration starts another one that shares the slots of the first.
+	hdlr.Start()
+	worker.lock.Lock()
+	slots := worker.slots
+	worker.lock.Unlock()
+	hdlr.Stop()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		worker.lock.Lock()
+		started := worker.started
+		worker.lock.Unlock()
+		if !started {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("scheduling loop still running")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	hdlr.Start()
+	defer hdlr.Stop()
+	worker.lock.Lock()
+	as.True(slots == worker.slots)
+	worker.lock.Unlock()
+}
@@ -19,14 +19,15 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
 	"github.com/google/cadvisor/utils"
-
-	"github.com/golang/glog"
 )
 
 func DebugInfo(watches map[string][]string) map[string][]string {
@@ -84,7 +85,7 @@ func GetSpec(cgroupPaths map[string]string, machineInfoFactory info.MachineInfoF
 			if quota != "" && quota != "-1" {
 				val, err := strconv.ParseUint(quota, 10, 64)
 				if err != nil {
-					glog.Errorf("GetSpec: Failed to parse CPUQuota from %q: %s", path.Join(cpuRoot, "cpu.cfs_quota_us"), err)
+					logging.Errorf("GetSpec: Failed to parse CPUQuota from %q: %s", path.Join(cpuRoot, "cpu.cfs_quota_us"), err)
 				}
 				spec.Cpu.Quota = val
 			}
@@ -99,6 +100,7 @@ func GetSpec(cgroupPaths map[string]string, machineInfoFactory info.MachineInfoF
 			spec.HasCpu = true
 			mask := readString(cpusetRoot, "cpuset.cpus")
 			spec.Cpu.Mask = utils.FixCpuMask(mask, mi.NumCores)
+			spec.Cpu.Mems = readString(cpusetRoot, "cpuset.mems")
 		}
 	}
 
@@ -133,7 +135,7 @@ func readString(dirpath string, file string) string {
 	// Read
 	out, err := ioutil.ReadFile(cgroupFile)
 	if err != nil {
-		glog.Errorf("readString: Failed to read %q: %s", cgroupFile, err)
+		logging.Errorf("readString: Failed to read %q: %s", cgroupFile, err)
 		return ""
 	}
 	return strings.TrimSpace(string(out))
@@ -147,7 +149,7 @@ func readUInt64(dirpath string, file string) uint64 {
 
 	val, err := strconv.ParseUint(out, 10, 64)
 	if err != nil {
-		glog.Errorf("readUInt64: Failed to parse int %q from file %q: %s", out, path.Join(dirpath, file), err)
+		logging.Errorf("readUInt64: Failed to parse int %q from file %q: %s", out, path.Join(dirpath, file), err)
 		return 0
 	}
 
@@ -156,6 +158,12 @@ func readUInt64(dirpath string, file string) uint64 {
 
 // Lists all directories under "path" and outputs the results as children of "parent".
 func ListDirectories(dirpath string, parent string, recursive bool, output map[string]struct{}) error {
+	return ListDirectoriesPruned(dirpath, parent, recursive, output, nil)
+}
+
+// ListDirectoriesPruned lists the directories like ListDirectories, skipping
+// the directories whose name is pruned, with their subdirectories.
+func ListDirectoriesPruned(dirpath string, parent string, recursive bool, output map[string]struct{}, pruned func(name string) bool) error {
 	// Ignore if this hierarchy does not exist.
 	if !utils.FileExists(dirpath) {
 		return nil
@@ -169,11 +177,14 @@ func ListDirectories(dirpath string, parent string, recursive bool, output map[s
 		// We only grab directories.
 		if entry.IsDir() {
 			name := path.Join(parent, entry.Name())
+			if pruned != nil && pruned(name) {
+				continue
+			}
 			output[name] = struct{}{}
 
 			// List subcontainers if asked to.
 			if recursive {
-				err := ListDirectories(path.Join(dirpath, entry.Name()), name, true, output)
+				err := ListDirectoriesPruned(path.Join(dirpath, entry.Name()), name, true, output, pruned)
 				if err != nil {
 					return err
 				}
@@ -201,3 +212,40 @@ func CgroupExists(cgroupPaths map[string]string) bool {
 	}
 	return false
 }
+
+// Returns the usage of the volumes mounted in the container of the process
+// pid, keyed by their path inside the container with their host path as
+// value. The volumes are stat'ed through /proc/<pid>/root so they resolve
+// within the container's mount namespace.
+func GetVolumeStats(rootFs string, pid int, volumes map[string]string) []info.VolumeStats {
+	if pid == 0 || len(volumes) == 0 {
+		return nil
+	}
+	root := path.Join(rootFs, "proc", strconv.Itoa(pid), "root")
+	stats := make([]info.VolumeStats, 0, len(volumes))
+	for mountPoint, source := range volumes {
+		var s syscall.Statfs_t
+		if err := syscall.Statfs(path.Join(root, mountPoint), &s); err != nil {
+			logging.V(4).Infof("Failed to stat volume %q of process %d: %v", mountPoint, pid, err)
+			continue
+		}
+		capacity := uint64(s.Frsize) * s.Blocks
+		stats = append(stats, info.VolumeStats{
+			MountPoint: mountPoint,
+			Source:     source,
+			Limit:      capacity,
+			Usage:      capacity - uint64(s.Frsize)*s.Bfree,
+			Available:  uint64(s.Frsize) * s.Bavail,
+			Inodes:     s.Files,
+			InodesFree: s.Ffree,
+		})
+	}
+	sort.Sort(volumeStatsByMountPoint(stats))
+	return stats
+}
+
+type volumeStatsByMountPoint []info.VolumeStats
+
+func (s volumeStatsByMountPoint) Len() int           { return len(s) }
+func (s volumeStatsByMountPoint) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s volumeStatsByMountPoint) Less(i, j int) bool { return s[i].MountPoint < s[j].MountPoint }
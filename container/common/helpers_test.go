@@ -0,0 +1,39 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"os"
+	"testing"
+)
+
+func TestGetVolumeStats(t *testing.T) {
+	stats := GetVolumeStats("/", os.Getpid(), map[string]string{
+		"/":            "/host",
+		"/nonexistent": "/host/nonexistent",
+	})
+	if len(stats) != 1 {
+		t.Fatalf("expected the stats of 1 volume, got %+v", stats)
+	}
+	if stats[0].MountPoint != "/" || stats[0].Source != "/host" {
+		t.Errorf("unexpected volume %+v", stats[0])
+	}
+	if stats[0].Limit == 0 || stats[0].Usage > stats[0].Limit {
+		t.Errorf("unexpected usage %d of capacity %d", stats[0].Usage, stats[0].Limit)
+	}
+	if GetVolumeStats("/", 0, map[string]string{"/": "/host"}) != nil {
+		t.Errorf("expected no stats without a process")
+	}
+}
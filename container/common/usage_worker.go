@@ -0,0 +1,151 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"flag"
+	"sync"
+	"time"
+
+	"github.com/google/cadvisor/logging"
+	"github.com/google/cadvisor/utils"
+)
+
+var (
+	// Period of the disk usage scans of each container.
+	DiskUsagePeriod        = flag.Duration("disk_usage_period", time.Minute, "Period of the disk usage scans of each container, independent of its housekeeping")
+	diskUsageMaxConcurrent = flag.Int("disk_usage_max_concurrent", 2, "Maximum number of disk usage scans running at once, for all the containers")
+)
+
+// Schedules the disk usage updates of all the fs handlers, running at most
+// --disk_usage_max_concurrent of them at once so that the scans of many
+// containers don't add up to an IO spike.
+type usageWorker struct {
+	lock sync.Mutex
+	// The registered handlers and when their next update is due.
+	due map[*realFsHandler]time.Time
+	// The handlers being updated.
+	running map[*realFsHandler]bool
+	// Wakes the scheduling loop up when the handlers change.
+	wake chan struct{}
+	// Holds a token per running update. Created once, by the first
+	// registration, as the updates of a previous loop may still release
+	// theirs.
+	slots   chan struct{}
+	started bool
+}
+
+var defaultUsageWorker = newUsageWorker()
+
+func newUsageWorker() *usageWorker {
+	return &usageWorker{
+		due:     make(map[*realFsHandler]time.Time),
+		running: make(map[*realFsHandler]bool),
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Schedules an immediate update of the handler, then one every period.
+func (self *usageWorker) register(fh *realFsHandler) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.due[fh] = time.Now()
+	if self.slots == nil {
+		concurrency := *diskUsageMaxConcurrent
+		if concurrency < 1 {
+			concurrency = 1
+		}
+		self.slots = make(chan struct{}, concurrency)
+	}
+	if !self.started {
+		self.started = true
+		go self.loop(self.slots)
+	}
+	self.notify()
+}
+
+// Stops scheduling updates of the handler. A running update completes.
+func (self *usageWorker) unregister(fh *realFsHandler) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	delete(self.due, fh)
+	self.notify()
+}
+
+func (self *usageWorker) notify() {
+	select {
+	case self.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Starts the due updates as slots free up, and sleeps until the next one is
+// due. Exits once no handler is registered.
+func (self *usageWorker) loop(slots chan struct{}) {
+	for {
+		self.lock.Lock()
+		if len(self.due) == 0 {
+			self.started = false
+			self.lock.Unlock()
+			return
+		}
+		now := time.Now()
+		var next *realFsHandler
+		var nextDue time.Time
+		for fh, due := range self.due {
+			if self.running[fh] {
+				continue
+			}
+			if next == nil || due.Before(nextDue) {
+				next, nextDue = fh, due
+			}
+		}
+		if next == nil || nextDue.After(now) {
+			self.lock.Unlock()
+			wait := time.Hour
+			if next != nil {
+				wait = nextDue.Sub(now)
+			}
+			select {
+			case <-self.wake:
+			case <-time.After(wait):
+			}
+			continue
+		}
+		self.running[next] = true
+		self.lock.Unlock()
+
+		// Waits for a slot, the earliest due handler first.
+		slots <- struct{}{}
+		go self.run(next, slots)
+	}
+}
+
+// Updates the handler and schedules its next update if it is still
+// registered.
+func (self *usageWorker) run(fh *realFsHandler, slots chan struct{}) {
+	start := time.Now()
+	period := fh.updateWithBackoff()
+	<-slots
+	logging.V(4).Infof("Disk usage scan of %v took %v", fh.allDirs, time.Since(start))
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	delete(self.running, fh)
+	if _, ok := self.due[fh]; ok {
+		self.due[fh] = time.Now().Add(utils.Jitter(period, 0.25))
+	}
+	self.notify()
+}
@@ -0,0 +1,206 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Messages of the containerd gRPC API used by the handler. Only the fields
+// cAdvisor reads are declared, the others are skipped when decoding.
+package containerd
+
+import (
+	"github.com/golang/protobuf/proto"
+)
+
+const (
+	versionMethod        = "/containerd.services.version.v1.Version/Version"
+	listNamespacesMethod = "/containerd.services.namespaces.v1.Namespaces/List"
+	getContainerMethod   = "/containerd.services.containers.v1.Containers/Get"
+	getTaskMethod        = "/containerd.services.tasks.v1.Tasks/Get"
+	listImagesMethod     = "/containerd.services.images.v1.Images/List"
+	readContentMethod    = "/containerd.services.content.v1.Content/Read"
+)
+
+// google.protobuf.Empty
+type empty struct{}
+
+func (m *empty) Reset()         { *m = empty{} }
+func (m *empty) String() string { return proto.CompactTextString(m) }
+func (*empty) ProtoMessage()    {}
+
+// google.protobuf.Any
+type any struct {
+	TypeUrl string `protobuf:"bytes,1,opt,name=type_url" json:"type_url,omitempty"`
+	Value   []byte `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (m *any) Reset()         { *m = any{} }
+func (m *any) String() string { return proto.CompactTextString(m) }
+func (*any) ProtoMessage()    {}
+
+// google.protobuf.Timestamp
+type timestamp struct {
+	Seconds int64 `protobuf:"varint,1,opt,name=seconds" json:"seconds,omitempty"`
+	Nanos   int32 `protobuf:"varint,2,opt,name=nanos" json:"nanos,omitempty"`
+}
+
+func (m *timestamp) Reset()         { *m = timestamp{} }
+func (m *timestamp) String() string { return proto.CompactTextString(m) }
+func (*timestamp) ProtoMessage()    {}
+
+type versionResponse struct {
+	Version  string `protobuf:"bytes,1,opt,name=version" json:"version,omitempty"`
+	Revision string `protobuf:"bytes,2,opt,name=revision" json:"revision,omitempty"`
+}
+
+func (m *versionResponse) Reset()         { *m = versionResponse{} }
+func (m *versionResponse) String() string { return proto.CompactTextString(m) }
+func (*versionResponse) ProtoMessage()    {}
+
+type listNamespacesRequest struct {
+	Filter string `protobuf:"bytes,1,opt,name=filter" json:"filter,omitempty"`
+}
+
+func (m *listNamespacesRequest) Reset()         { *m = listNamespacesRequest{} }
+func (m *listNamespacesRequest) String() string { return proto.CompactTextString(m) }
+func (*listNamespacesRequest) ProtoMessage()    {}
+
+type namespace struct {
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+}
+
+func (m *namespace) Reset()         { *m = namespace{} }
+func (m *namespace) String() string { return proto.CompactTextString(m) }
+func (*namespace) ProtoMessage()    {}
+
+type listNamespacesResponse struct {
+	Namespaces []*namespace `protobuf:"bytes,1,rep,name=namespaces" json:"namespaces,omitempty"`
+}
+
+func (m *listNamespacesResponse) Reset()         { *m = listNamespacesResponse{} }
+func (m *listNamespacesResponse) String() string { return proto.CompactTextString(m) }
+func (*listNamespacesResponse) ProtoMessage()    {}
+
+type getContainerRequest struct {
+	Id string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+}
+
+func (m *getContainerRequest) Reset()         { *m = getContainerRequest{} }
+func (m *getContainerRequest) String() string { return proto.CompactTextString(m) }
+func (*getContainerRequest) ProtoMessage()    {}
+
+type containerRecord struct {
+	Id     string            `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	Labels map[string]string `protobuf:"bytes,2,rep,name=labels" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Image  string            `protobuf:"bytes,3,opt,name=image" json:"image,omitempty"`
+	// OCI runtime spec of the container, as JSON.
+	Spec      *any       `protobuf:"bytes,5,opt,name=spec" json:"spec,omitempty"`
+	CreatedAt *timestamp `protobuf:"bytes,8,opt,name=created_at" json:"created_at,omitempty"`
+}
+
+func (m *containerRecord) Reset()         { *m = containerRecord{} }
+func (m *containerRecord) String() string { return proto.CompactTextString(m) }
+func (*containerRecord) ProtoMessage()    {}
+
+type getContainerResponse struct {
+	Container *containerRecord `protobuf:"bytes,1,opt,name=container" json:"container,omitempty"`
+}
+
+func (m *getContainerResponse) Reset()         { *m = getContainerResponse{} }
+func (m *getContainerResponse) String() string { return proto.CompactTextString(m) }
+func (*getContainerResponse) ProtoMessage()    {}
+
+type getTaskRequest struct {
+	ContainerId string `protobuf:"bytes,1,opt,name=container_id" json:"container_id,omitempty"`
+}
+
+func (m *getTaskRequest) Reset()         { *m = getTaskRequest{} }
+func (m *getTaskRequest) String() string { return proto.CompactTextString(m) }
+func (*getTaskRequest) ProtoMessage()    {}
+
+// Status of a task.
+const taskRunning = 2
+
+type process struct {
+	ContainerId string `protobuf:"bytes,1,opt,name=container_id" json:"container_id,omitempty"`
+	Pid         uint32 `protobuf:"varint,3,opt,name=pid" json:"pid,omitempty"`
+	Status      int32  `protobuf:"varint,4,opt,name=status" json:"status,omitempty"`
+}
+
+func (m *process) Reset()         { *m = process{} }
+func (m *process) String() string { return proto.CompactTextString(m) }
+func (*process) ProtoMessage()    {}
+
+type getTaskResponse struct {
+	Process *process `protobuf:"bytes,1,opt,name=process" json:"process,omitempty"`
+}
+
+func (m *getTaskResponse) Reset()         { *m = getTaskResponse{} }
+func (m *getTaskResponse) String() string { return proto.CompactTextString(m) }
+func (*getTaskResponse) ProtoMessage()    {}
+
+type listImagesRequest struct {
+	Filters []string `protobuf:"bytes,1,rep,name=filters" json:"filters,omitempty"`
+}
+
+func (m *listImagesRequest) Reset()         { *m = listImagesRequest{} }
+func (m *listImagesRequest) String() string { return proto.CompactTextString(m) }
+func (*listImagesRequest) ProtoMessage()    {}
+
+// Descriptor of content of the content store.
+type descriptor struct {
+	MediaType string `protobuf:"bytes,1,opt,name=media_type" json:"media_type,omitempty"`
+	Digest    string `protobuf:"bytes,2,opt,name=digest" json:"digest,omitempty"`
+	Size      int64  `protobuf:"varint,3,opt,name=size" json:"size,omitempty"`
+}
+
+func (m *descriptor) Reset()         { *m = descriptor{} }
+func (m *descriptor) String() string { return proto.CompactTextString(m) }
+func (*descriptor) ProtoMessage()    {}
+
+type imageRecord struct {
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	// Manifest or index of the image.
+	Target    *descriptor `protobuf:"bytes,3,opt,name=target" json:"target,omitempty"`
+	CreatedAt *timestamp  `protobuf:"bytes,7,opt,name=created_at" json:"created_at,omitempty"`
+}
+
+func (m *imageRecord) Reset()         { *m = imageRecord{} }
+func (m *imageRecord) String() string { return proto.CompactTextString(m) }
+func (*imageRecord) ProtoMessage()    {}
+
+type listImagesResponse struct {
+	Images []*imageRecord `protobuf:"bytes,1,rep,name=images" json:"images,omitempty"`
+}
+
+func (m *listImagesResponse) Reset()         { *m = listImagesResponse{} }
+func (m *listImagesResponse) String() string { return proto.CompactTextString(m) }
+func (*listImagesResponse) ProtoMessage()    {}
+
+type readContentRequest struct {
+	Digest string `protobuf:"bytes,1,opt,name=digest" json:"digest,omitempty"`
+	Offset int64  `protobuf:"varint,2,opt,name=offset" json:"offset,omitempty"`
+	// Zero reads the whole content.
+	Size int64 `protobuf:"varint,3,opt,name=size" json:"size,omitempty"`
+}
+
+func (m *readContentRequest) Reset()         { *m = readContentRequest{} }
+func (m *readContentRequest) String() string { return proto.CompactTextString(m) }
+func (*readContentRequest) ProtoMessage()    {}
+
+type readContentResponse struct {
+	Offset int64  `protobuf:"varint,1,opt,name=offset" json:"offset,omitempty"`
+	Data   []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+}
+
+func (m *readContentResponse) Reset()         { *m = readContentResponse{} }
+func (m *readContentResponse) String() string { return proto.CompactTextString(m) }
+func (*readContentResponse) ProtoMessage()    {}
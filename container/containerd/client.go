@@ -0,0 +1,164 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containerd
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+var ArgContainerdEndpoint = flag.String("containerd", "/run/containerd/containerd.sock", "containerd endpoint")
+
+// The namespace under which containerd aliases are unique.
+const ContainerdNamespace = "containerd"
+
+const (
+	timeout = 2 * time.Second
+	// gRPC metadata key selecting the containerd namespace of a request.
+	namespaceHeader = "containerd-namespace"
+)
+
+type client struct {
+	conn *grpc.ClientConn
+}
+
+var (
+	containerdClient    *client
+	containerdClientErr error
+	once                sync.Once
+)
+
+func Client() (*client, error) {
+	once.Do(func() {
+		conn, err := grpc.Dial(*ArgContainerdEndpoint,
+			grpc.WithInsecure(),
+			grpc.WithTimeout(timeout),
+			grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+				return net.DialTimeout("unix", addr, timeout)
+			}))
+		if err != nil {
+			containerdClientErr = fmt.Errorf("containerd: cannot grpc Dial %q: %v", *ArgContainerdEndpoint, err)
+			return
+		}
+		containerdClient = &client{conn}
+	})
+	return containerdClient, containerdClientErr
+}
+
+// Returns a context for requests in the containerd namespace.
+func namespaceContext(namespace string) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	if namespace != "" {
+		ctx = metadata.NewContext(ctx, metadata.Pairs(namespaceHeader, namespace))
+	}
+	return ctx, cancel
+}
+
+func (self *client) Version() (string, error) {
+	ctx, cancel := namespaceContext("")
+	defer cancel()
+	resp := &versionResponse{}
+	if err := grpc.Invoke(ctx, versionMethod, &empty{}, resp, self.conn); err != nil {
+		return "", err
+	}
+	return resp.Version, nil
+}
+
+func (self *client) Namespaces() ([]string, error) {
+	ctx, cancel := namespaceContext("")
+	defer cancel()
+	resp := &listNamespacesResponse{}
+	if err := grpc.Invoke(ctx, listNamespacesMethod, &listNamespacesRequest{}, resp, self.conn); err != nil {
+		return nil, err
+	}
+	namespaces := make([]string, 0, len(resp.Namespaces))
+	for _, ns := range resp.Namespaces {
+		namespaces = append(namespaces, ns.Name)
+	}
+	return namespaces, nil
+}
+
+func (self *client) Container(namespace, id string) (*containerRecord, error) {
+	ctx, cancel := namespaceContext(namespace)
+	defer cancel()
+	resp := &getContainerResponse{}
+	if err := grpc.Invoke(ctx, getContainerMethod, &getContainerRequest{Id: id}, resp, self.conn); err != nil {
+		return nil, err
+	}
+	if resp.Container == nil {
+		return nil, fmt.Errorf("no container %q in namespace %q", id, namespace)
+	}
+	return resp.Container, nil
+}
+
+func (self *client) Task(namespace, id string) (*process, error) {
+	ctx, cancel := namespaceContext(namespace)
+	defer cancel()
+	resp := &getTaskResponse{}
+	if err := grpc.Invoke(ctx, getTaskMethod, &getTaskRequest{ContainerId: id}, resp, self.conn); err != nil {
+		return nil, err
+	}
+	if resp.Process == nil {
+		return nil, fmt.Errorf("no task for container %q in namespace %q", id, namespace)
+	}
+	return resp.Process, nil
+}
+
+func (self *client) Images(namespace string) ([]*imageRecord, error) {
+	ctx, cancel := namespaceContext(namespace)
+	defer cancel()
+	resp := &listImagesResponse{}
+	if err := grpc.Invoke(ctx, listImagesMethod, &listImagesRequest{}, resp, self.conn); err != nil {
+		return nil, err
+	}
+	return resp.Images, nil
+}
+
+// Reads the blob of the content store with the digest.
+func (self *client) ReadContent(namespace, digest string) ([]byte, error) {
+	ctx, cancel := namespaceContext(namespace)
+	defer cancel()
+	stream, err := grpc.NewClientStream(ctx, &grpc.StreamDesc{ServerStreams: true}, self.conn, readContentMethod)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.SendMsg(&readContentRequest{Digest: digest}); err != nil {
+		return nil, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+	var data bytes.Buffer
+	for {
+		resp := &readContentResponse{}
+		err := stream.RecvMsg(resp)
+		if err == io.EOF {
+			return data.Bytes(), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		data.Write(resp.Data)
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package containerd
+
+import (
+	"flag"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/google/cadvisor/container"
+	"github.com/google/cadvisor/container/libcontainer"
+	"github.com/google/cadvisor/fs"
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
+)
+
+var containerdEnvWhitelist = flag.String("containerd_env_metadata_whitelist", "", "a comma-separated list of environment variable keys that needs to be collected for containerd containers")
+
+// Containers created by Kubernetes and nerdctl have 64 character IDs, which end
+// their cgroup name: "/k8s.io/<id>" with the cgroupfs driver and
+// ".../cri-containerd-<id>.scope" with the systemd driver.
+var containerdCgroupRegexp = regexp.MustCompile(`([a-z0-9]{64})`)
+
+type containerdFactory struct {
+	machineInfoFactory info.MachineInfoFactory
+
+	client *client
+
+	cgroupSubsystems libcontainer.CgroupSubsystems
+
+	fsInfo fs.FsInfo
+
+	ignoreMetrics container.MetricSet
+}
+
+func (self *containerdFactory) String() string {
+	return ContainerdNamespace
+}
+
+func (self *containerdFactory) NewContainerHandler(name string, inHostNamespace bool) (container.ContainerHandler, error) {
+	namespace, ctnr, err := self.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	metadataEnvs := strings.Split(*containerdEnvWhitelist, ",")
+	return newContainerdContainerHandler(
+		self.client,
+		name,
+		namespace,
+		ctnr,
+		self.machineInfoFactory,
+		self.fsInfo,
+		&self.cgroupSubsystems,
+		inHostNamespace,
+		metadataEnvs,
+		self.ignoreMetrics,
+	)
+}
+
+func containerNameToId(name string) string {
+	id := path.Base(name)
+	if matches := containerdCgroupRegexp.FindStringSubmatch(id); matches != nil {
+		return matches[1]
+	}
+	return id
+}
+
+// Returns the containerd namespaces a container of the cgroup may be in, most
+// likely first. Containers with IDs that do not look generated are only
+// looked up in the namespace named by their parent cgroup, which is where
+// containerd puts them by default.
+func candidateNamespaces(name string, namespaces []string) []string {
+	parent := path.Base(path.Dir(name))
+	var candidates []string
+	for _, ns := range namespaces {
+		if ns == parent {
+			candidates = append([]string{ns}, candidates...)
+		} else if containerdCgroupRegexp.MatchString(path.Base(name)) {
+			candidates = append(candidates, ns)
+		}
+	}
+	return candidates
+}
+
+// Finds the running containerd container of the cgroup and its namespace.
+func (self *containerdFactory) lookup(name string) (string, *containerRecord, error) {
+	if name == "/" {
+		return "", nil, fmt.Errorf("not a containerd container")
+	}
+	namespaces, err := self.client.Namespaces()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to list containerd namespaces: %v", err)
+	}
+	id := containerNameToId(name)
+	for _, ns := range candidateNamespaces(name, namespaces) {
+		ctnr, err := self.client.Container(ns, id)
+		if err != nil {
+			continue
+		}
+		task, err := self.client.Task(ns, id)
+		if err != nil || task.Status != taskRunning {
+			return "", nil, fmt.Errorf("containerd container %q in namespace %q is not running", id, ns)
+		}
+		return ns, ctnr, nil
+	}
+	return "", nil, fmt.Errorf("no containerd container %q", id)
+}
+
+func (self *containerdFactory) CanHandleAndAccept(name string) (bool, bool, error) {
+	if _, _, err := self.lookup(name); err != nil {
+		return false, true, err
+	}
+	return true, true, nil
+}
+
+func (self *containerdFactory) DebugInfo() map[string][]string {
+	return map[string][]string{}
+}
+
+func Register(factory info.MachineInfoFactory, fsInfo fs.FsInfo, ignoreMetrics container.MetricSet) error {
+	client, err := Client()
+	if err != nil {
+		return fmt.Errorf("unable to communicate with containerd: %v", err)
+	}
+	version, err := client.Version()
+	if err != nil {
+		return fmt.Errorf("failed to get containerd version: %v", err)
+	}
+
+	cgroupSubsystems, err := libcontainer.GetCgroupSubsystems()
+	if err != nil {
+		return fmt.Errorf("failed to get cgroup subsystems: %v", err)
+	}
+
+	logging.Infof("Registering containerd factory for containerd %s", version)
+	f := &containerdFactory{
+		cgroupSubsystems:   cgroupSubsystems,
+		client:             client,
+		fsInfo:             fsInfo,
+		machineInfoFactory: factory,
+		ignoreMetrics:      ignoreMetrics,
+	}
+	container.RegisterContainerHandlerFactory(f)
+	return nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containerd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+)
+
+const testId = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+func TestContainerNameToId(t *testing.T) {
+	for name, id := range map[string]string{
+		"/k8s.io/" + testId: testId,
+		"/kubepods.slice/kubepods-pod1.slice/cri-containerd-" + testId + ".scope": testId,
+		"/default/redis": "redis",
+	} {
+		if actual := containerNameToId(name); actual != id {
+			t.Errorf("expected id %q for %q, got %q", id, name, actual)
+		}
+	}
+}
+
+func TestCandidateNamespaces(t *testing.T) {
+	namespaces := []string{"default", "k8s.io", "moby"}
+	for _, test := range []struct {
+		name     string
+		expected []string
+	}{
+		{"/k8s.io/" + testId, []string{"k8s.io", "default", "moby"}},
+		{"/kubepods/pod1/" + testId, []string{"default", "k8s.io", "moby"}},
+		{"/default/redis", []string{"default"}},
+		{"/system.slice/redis.service", nil},
+	} {
+		if actual := candidateNamespaces(test.name, namespaces); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("expected namespaces %v for %q, got %v", test.expected, test.name, actual)
+		}
+	}
+}
+
+func TestContainerRecordEncoding(t *testing.T) {
+	expected := &getContainerResponse{
+		Container: &containerRecord{
+			Id:     testId,
+			Labels: map[string]string{"nerdctl/name": "redis"},
+			Image:  "docker.io/library/redis:latest",
+			Spec: &any{
+				TypeUrl: "types.containerd.io/opencontainers/runtime-spec/1/Spec",
+				Value:   []byte(`{"process":{"env":["A=b"]}}`),
+			},
+			CreatedAt: &timestamp{Seconds: 1450000000, Nanos: 5},
+		},
+	}
+	data, err := proto.Marshal(expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual := &getContainerResponse{}
+	if err := proto.Unmarshal(data, actual); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %+v, got %+v", expected.Container, actual.Container)
+	}
+}
+
+func TestTaskDecoding(t *testing.T) {
+	// GetResponse of containerd's tasks service for the running task "redis"
+	// with pid 1234 and a terminal.
+	data := []byte{
+		0x0a, 0x15, // process
+		0x0a, 0x05, 'r', 'e', 'd', 'i', 's', // container_id
+		0x12, 0x05, 'r', 'e', 'd', 'i', 's', // id
+		0x18, 0xd2, 0x09, // pid
+		0x20, 0x02, // status RUNNING
+		0x40, 0x01, // terminal
+	}
+	resp := &getTaskResponse{}
+	if err := proto.Unmarshal(data, resp); err != nil {
+		t.Fatal(err)
+	}
+	expected := &process{ContainerId: "redis", Pid: 1234, Status: taskRunning}
+	if !reflect.DeepEqual(resp.Process, expected) {
+		t.Errorf("expected %+v, got %+v", expected, resp.Process)
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+// Handler for containerd containers.
+package containerd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/cadvisor/container"
+	"github.com/google/cadvisor/container/common"
+	containerlibcontainer "github.com/google/cadvisor/container/libcontainer"
+	"github.com/google/cadvisor/fs"
+	info "github.com/google/cadvisor/info/v1"
+
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	cgroupfs "github.com/opencontainers/runc/libcontainer/cgroups/fs"
+	libcontainerconfigs "github.com/opencontainers/runc/libcontainer/configs"
+)
+
+// Labels naming containers, added as aliases.
+var nameLabels = []string{"io.kubernetes.container.name", "nerdctl/name"}
+
+// Parts of the OCI runtime spec of a container read by the handler.
+type ociSpec struct {
+	Process struct {
+		Env []string `json:"env"`
+	} `json:"process"`
+	Linux struct {
+		Namespaces []struct {
+			Type string `json:"type"`
+			// Path of the namespace joined, empty if a new one is created.
+			Path string `json:"path"`
+		} `json:"namespaces"`
+	} `json:"linux"`
+}
+
+type containerdContainerHandler struct {
+	client             *client
+	name               string
+	id                 string
+	aliases            []string
+	machineInfoFactory info.MachineInfoFactory
+
+	// Absolute path to the cgroup hierarchies of this container.
+	// (e.g.: "cpu" -> "/sys/fs/cgroup/cpu/test")
+	cgroupPaths map[string]string
+
+	// Manager of this container's cgroups.
+	cgroupManager cgroups.Manager
+
+	fsInfo fs.FsInfo
+
+	// Time at which this container was created.
+	creationTime time.Time
+
+	// Metadata associated with the container.
+	labels map[string]string
+	envs   map[string]string
+
+	// The container PID used to switch namespaces as required
+	pid int
+
+	// Image name used for this container.
+	image string
+
+	// The host root FS to read
+	rootFs string
+
+	// Whether the container joins the network namespace of another one, like
+	// the containers of a Kubernetes pod.
+	sharedNetwork bool
+
+	ignoreMetrics container.MetricSet
+}
+
+func newContainerdContainerHandler(
+	client *client,
+	name string,
+	namespace string,
+	ctnr *containerRecord,
+	machineInfoFactory info.MachineInfoFactory,
+	fsInfo fs.FsInfo,
+	cgroupSubsystems *containerlibcontainer.CgroupSubsystems,
+	inHostNamespace bool,
+	metadataEnvs []string,
+	ignoreMetrics container.MetricSet,
+) (container.ContainerHandler, error) {
+	cgroupPaths := common.MakeCgroupPaths(cgroupSubsystems.MountPoints, name)
+
+	// Generate the equivalent cgroup manager for this container.
+	cgroupManager := &cgroupfs.Manager{
+		Cgroups: &libcontainerconfigs.Cgroup{
+			Name: name,
+		},
+		Paths: cgroupPaths,
+	}
+
+	rootFs := "/"
+	if !inHostNamespace {
+		rootFs = "/rootfs"
+	}
+
+	task, err := client.Task(namespace, ctnr.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task of containerd container %q: %v", ctnr.Id, err)
+	}
+
+	handler := &containerdContainerHandler{
+		client:             client,
+		name:               name,
+		id:                 ctnr.Id,
+		machineInfoFactory: machineInfoFactory,
+		cgroupPaths:        cgroupPaths,
+		cgroupManager:      cgroupManager,
+		fsInfo:             fsInfo,
+		labels:             ctnr.Labels,
+		envs:               make(map[string]string),
+		pid:                int(task.Pid),
+		image:              ctnr.Image,
+		rootFs:             rootFs,
+		ignoreMetrics:      ignoreMetrics,
+	}
+	if ctnr.CreatedAt != nil {
+		handler.creationTime = time.Unix(ctnr.CreatedAt.Seconds, int64(ctnr.CreatedAt.Nanos))
+	}
+
+	// Add the names and bare ID as aliases of the container.
+	for _, label := range nameLabels {
+		if name, ok := ctnr.Labels[label]; ok {
+			handler.aliases = append(handler.aliases, name)
+		}
+	}
+	handler.aliases = append(handler.aliases, ctnr.Id)
+
+	if ctnr.Spec != nil {
+		spec := &ociSpec{}
+		if err := json.Unmarshal(ctnr.Spec.Value, spec); err != nil {
+			return nil, fmt.Errorf("failed to parse the spec of containerd container %q: %v", ctnr.Id, err)
+		}
+		for _, ns := range spec.Linux.Namespaces {
+			if ns.Type == "network" && ns.Path != "" {
+				handler.sharedNetwork = true
+			}
+		}
+		// split env vars to get metadata map.
+		for _, exposedEnv := range metadataEnvs {
+			for _, envVar := range spec.Process.Env {
+				splits := strings.SplitN(envVar, "=", 2)
+				if len(splits) == 2 && splits[0] == exposedEnv {
+					handler.envs[strings.ToLower(exposedEnv)] = splits[1]
+				}
+			}
+		}
+	}
+
+	return handler, nil
+}
+
+func (self *containerdContainerHandler) Start() {}
+
+func (self *containerdContainerHandler) Cleanup() {}
+
+func (self *containerdContainerHandler) ContainerReference() (info.ContainerReference, error) {
+	return info.ContainerReference{
+		Id:        self.id,
+		Name:      self.name,
+		Aliases:   self.aliases,
+		Namespace: ContainerdNamespace,
+		Labels:    self.labels,
+		Envs:      self.envs,
+	}, nil
+}
+
+func (self *containerdContainerHandler) needNet() bool {
+	if !self.ignoreMetrics.Has(container.NetworkUsageMetrics) {
+		return !self.sharedNetwork
+	}
+	return false
+}
+
+func (self *containerdContainerHandler) GetSpec() (info.ContainerSpec, error) {
+	// TODO: Track the usage of the snapshot of the container.
+	spec, err := common.GetSpec(self.cgroupPaths, self.machineInfoFactory, self.needNet(), false)
+
+	spec.Labels = self.labels
+	spec.Envs = self.envs
+	spec.Image = self.image
+	if !self.creationTime.IsZero() {
+		spec.CreationTime = self.creationTime
+	}
+
+	return spec, err
+}
+
+func (self *containerdContainerHandler) GetStats() (*info.ContainerStats, error) {
+	stats, err := containerlibcontainer.GetStats(self.cgroupManager, self.rootFs, self.pid, self.ignoreMetrics)
+	if err != nil {
+		return stats, err
+	}
+	// Containers that share the network of another one would report its
+	// network stats again.
+	if !self.needNet() {
+		stats.Network = info.NetworkStats{}
+	}
+	return stats, nil
+}
+
+func (self *containerdContainerHandler) ListContainers(listType container.ListType) ([]info.ContainerReference, error) {
+	// No-op for containerd driver.
+	return []info.ContainerReference{}, nil
+}
+
+func (self *containerdContainerHandler) GetCgroupPath(resource string) (string, error) {
+	path, ok := self.cgroupPaths[resource]
+	if !ok {
+		return "", fmt.Errorf("could not find path for resource %q for container %q\n", resource, self.name)
+	}
+	return path, nil
+}
+
+func (self *containerdContainerHandler) ListThreads(listType container.ListType) ([]int, error) {
+	return nil, nil
+}
+
+func (self *containerdContainerHandler) GetContainerLabels() map[string]string {
+	return self.labels
+}
+
+func (self *containerdContainerHandler) ListProcesses(listType container.ListType) ([]int, error) {
+	return containerlibcontainer.GetProcesses(self.cgroupManager)
+}
+
+func (self *containerdContainerHandler) WatchSubcontainers(events chan container.SubcontainerEvent) error {
+	return fmt.Errorf("watch is unimplemented in the containerd container driver")
+}
+
+func (self *containerdContainerHandler) StopWatchingSubcontainers() error {
+	// No-op for containerd driver.
+	return nil
+}
+
+func (self *containerdContainerHandler) Exists() bool {
+	return common.CgroupExists(self.cgroupPaths)
+}
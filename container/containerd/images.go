@@ -0,0 +1,151 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containerd
+
+import (
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"sort"
+	"time"
+
+	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/logging"
+)
+
+// Media types of the targets of images.
+const (
+	ociIndexType           = "application/vnd.oci.image.index.v1+json"
+	dockerManifestListType = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+// Index of the manifests of an image for several platforms.
+type imageIndex struct {
+	Manifests []struct {
+		MediaType string `json:"mediaType"`
+		Digest    string `json:"digest"`
+		Platform  struct {
+			Architecture string `json:"architecture"`
+			OS           string `json:"os"`
+		} `json:"platform"`
+	} `json:"manifests"`
+}
+
+type imageManifest struct {
+	Config struct {
+		Size int64 `json:"size"`
+	} `json:"config"`
+	Layers []struct {
+		Size int64 `json:"size"`
+	} `json:"layers"`
+}
+
+// Returns the digest of the manifest of the platform in the index.
+func selectManifest(data []byte, os, arch string) (string, error) {
+	index := imageIndex{}
+	if err := json.Unmarshal(data, &index); err != nil {
+		return "", err
+	}
+	for _, manifest := range index.Manifests {
+		if manifest.Platform.OS == os && manifest.Platform.Architecture == arch {
+			return manifest.Digest, nil
+		}
+	}
+	return "", fmt.Errorf("no manifest for %s/%s", os, arch)
+}
+
+// Returns the number of layers and the size of the image of the manifest.
+func parseManifest(data []byte) (int, int64, error) {
+	manifest := imageManifest{}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return 0, 0, err
+	}
+	size := manifest.Config.Size
+	for _, layer := range manifest.Layers {
+		size += layer.Size
+	}
+	return len(manifest.Layers), size, nil
+}
+
+// Returns the number of layers and the size of the image for the platform of
+// the host.
+func (self *client) imageLayers(namespace string, target *descriptor) (int, int64, error) {
+	digest := target.Digest
+	if target.MediaType == ociIndexType || target.MediaType == dockerManifestListType {
+		data, err := self.ReadContent(namespace, digest)
+		if err != nil {
+			return 0, 0, err
+		}
+		if digest, err = selectManifest(data, runtime.GOOS, runtime.GOARCH); err != nil {
+			return 0, 0, err
+		}
+	}
+	data, err := self.ReadContent(namespace, digest)
+	if err != nil {
+		return 0, 0, err
+	}
+	return parseManifest(data)
+}
+
+// Images lists the images of all the containerd namespaces. The names of an
+// image are its repo tags.
+func Images() ([]v2.Image, error) {
+	client, err := Client()
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := client.Namespaces()
+	if err != nil {
+		return nil, err
+	}
+	images := []v2.Image{}
+	for _, namespace := range namespaces {
+		records, err := client.Images(namespace)
+		if err != nil {
+			return nil, err
+		}
+		// Images with several names share their target.
+		byDigest := make(map[string]*v2.Image)
+		var digests []string
+		for _, record := range records {
+			if record.Target == nil {
+				continue
+			}
+			image, ok := byDigest[record.Target.Digest]
+			if !ok {
+				image = &v2.Image{
+					Runtime:   ContainerdNamespace,
+					Namespace: namespace,
+					Id:        record.Target.Digest,
+				}
+				if record.CreatedAt != nil {
+					image.Created = time.Unix(record.CreatedAt.Seconds, int64(record.CreatedAt.Nanos))
+				}
+				image.Layers, image.Size, err = client.imageLayers(namespace, record.Target)
+				if err != nil {
+					logging.V(2).Infof("Failed to get the layers of containerd image %q: %v", record.Name, err)
+				}
+				byDigest[record.Target.Digest] = image
+				digests = append(digests, record.Target.Digest)
+			}
+			image.RepoTags = append(image.RepoTags, record.Name)
+		}
+		sort.Strings(digests)
+		for _, digest := range digests {
+			images = append(images, *byDigest[digest])
+		}
+	}
+	return images, nil
+}
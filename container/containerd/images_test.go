@@ -0,0 +1,39 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package containerd
+
+import (
+	"testing"
+)
+
+func TestParseManifest(t *testing.T) {
+	index := []byte(`{"manifests": [
+		{"digest": "sha256:arm", "platform": {"architecture": "arm64", "os": "linux"}},
+		{"digest": "sha256:amd", "platform": {"architecture": "amd64", "os": "linux"}}
+	]}`)
+	digest, err := selectManifest(index, "linux", "amd64")
+	if err != nil || digest != "sha256:amd" {
+		t.Errorf("expected manifest sha256:amd, got %q (%v)", digest, err)
+	}
+	if _, err := selectManifest(index, "windows", "amd64"); err == nil {
+		t.Errorf("expected no manifest for windows")
+	}
+
+	manifest := []byte(`{"config": {"size": 100}, "layers": [{"size": 1000}, {"size": 2000}]}`)
+	layers, size, err := parseManifest(manifest)
+	if err != nil || layers != 2 || size != 3100 {
+		t.Errorf("expected 2 layers of 3100 bytes, got %d layers of %d bytes (%v)", layers, size, err)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Messages of the Kubernetes CRI RuntimeService used by the handler. Only the
+// fields cAdvisor reads are declared, the others are skipped when decoding.
+package cri
+
+import (
+	"github.com/golang/protobuf/proto"
+)
+
+// Versions of the RuntimeService, newest first.
+var runtimeServices = []string{"runtime.v1.RuntimeService", "runtime.v1alpha2.RuntimeService"}
+
+// States of a container.
+const (
+	containerCreated = 0
+	containerRunning = 1
+	containerExited  = 2
+)
+
+type versionRequest struct {
+	Version string `protobuf:"bytes,1,opt,name=version" json:"version,omitempty"`
+}
+
+func (m *versionRequest) Reset()         { *m = versionRequest{} }
+func (m *versionRequest) String() string { return proto.CompactTextString(m) }
+func (*versionRequest) ProtoMessage()    {}
+
+type versionResponse struct {
+	Version           string `protobuf:"bytes,1,opt,name=version" json:"version,omitempty"`
+	RuntimeName       string `protobuf:"bytes,2,opt,name=runtime_name" json:"runtime_name,omitempty"`
+	RuntimeVersion    string `protobuf:"bytes,3,opt,name=runtime_version" json:"runtime_version,omitempty"`
+	RuntimeApiVersion string `protobuf:"bytes,4,opt,name=runtime_api_version" json:"runtime_api_version,omitempty"`
+}
+
+func (m *versionResponse) Reset()         { *m = versionResponse{} }
+func (m *versionResponse) String() string { return proto.CompactTextString(m) }
+func (*versionResponse) ProtoMessage()    {}
+
+type containerMetadata struct {
+	Name    string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Attempt uint32 `protobuf:"varint,2,opt,name=attempt" json:"attempt,omitempty"`
+}
+
+func (m *containerMetadata) Reset()         { *m = containerMetadata{} }
+func (m *containerMetadata) String() string { return proto.CompactTextString(m) }
+func (*containerMetadata) ProtoMessage()    {}
+
+type imageSpec struct {
+	Image string `protobuf:"bytes,1,opt,name=image" json:"image,omitempty"`
+}
+
+func (m *imageSpec) Reset()         { *m = imageSpec{} }
+func (m *imageSpec) String() string { return proto.CompactTextString(m) }
+func (*imageSpec) ProtoMessage()    {}
+
+type containerFilter struct {
+	Id           string `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	PodSandboxId string `protobuf:"bytes,3,opt,name=pod_sandbox_id" json:"pod_sandbox_id,omitempty"`
+}
+
+func (m *containerFilter) Reset()         { *m = containerFilter{} }
+func (m *containerFilter) String() string { return proto.CompactTextString(m) }
+func (*containerFilter) ProtoMessage()    {}
+
+type listContainersRequest struct {
+	Filter *containerFilter `protobuf:"bytes,1,opt,name=filter" json:"filter,omitempty"`
+}
+
+func (m *listContainersRequest) Reset()         { *m = listContainersRequest{} }
+func (m *listContainersRequest) String() string { return proto.CompactTextString(m) }
+func (*listContainersRequest) ProtoMessage()    {}
+
+type criContainer struct {
+	Id           string             `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	PodSandboxId string             `protobuf:"bytes,2,opt,name=pod_sandbox_id" json:"pod_sandbox_id,omitempty"`
+	Metadata     *containerMetadata `protobuf:"bytes,3,opt,name=metadata" json:"metadata,omitempty"`
+	Image        *imageSpec         `protobuf:"bytes,4,opt,name=image" json:"image,omitempty"`
+	State        int32              `protobuf:"varint,6,opt,name=state" json:"state,omitempty"`
+	Labels       map[string]string  `protobuf:"bytes,8,rep,name=labels" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+}
+
+func (m *criContainer) Reset()         { *m = criContainer{} }
+func (m *criContainer) String() string { return proto.CompactTextString(m) }
+func (*criContainer) ProtoMessage()    {}
+
+type listContainersResponse struct {
+	Containers []*criContainer `protobuf:"bytes,1,rep,name=containers" json:"containers,omitempty"`
+}
+
+func (m *listContainersResponse) Reset()         { *m = listContainersResponse{} }
+func (m *listContainersResponse) String() string { return proto.CompactTextString(m) }
+func (*listContainersResponse) ProtoMessage()    {}
+
+type containerStatusRequest struct {
+	ContainerId string `protobuf:"bytes,1,opt,name=container_id" json:"container_id,omitempty"`
+	Verbose     bool   `protobuf:"varint,2,opt,name=verbose" json:"verbose,omitempty"`
+}
+
+func (m *containerStatusRequest) Reset()         { *m = containerStatusRequest{} }
+func (m *containerStatusRequest) String() string { return proto.CompactTextString(m) }
+func (*containerStatusRequest) ProtoMessage()    {}
+
+type containerStatus struct {
+	Id       string             `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	Metadata *containerMetadata `protobuf:"bytes,2,opt,name=metadata" json:"metadata,omitempty"`
+	State    int32              `protobuf:"varint,3,opt,name=state" json:"state,omitempty"`
+	// Creation time in nanoseconds since the epoch.
+	CreatedAt int64             `protobuf:"varint,4,opt,name=created_at" json:"created_at,omitempty"`
+	Image     *imageSpec        `protobuf:"bytes,8,opt,name=image" json:"image,omitempty"`
+	Labels    map[string]string `protobuf:"bytes,12,rep,name=labels" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+}
+
+func (m *containerStatus) Reset()         { *m = containerStatus{} }
+func (m *containerStatus) String() string { return proto.CompactTextString(m) }
+func (*containerStatus) ProtoMessage()    {}
+
+type containerStatusResponse struct {
+	Status *containerStatus `protobuf:"bytes,1,opt,name=status" json:"status,omitempty"`
+	// Runtime specific information when verbose, e.g. "info" holding a JSON
+	// object with the pid of the container.
+	Info map[string]string `protobuf:"bytes,2,rep,name=info" json:"info,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+}
+
+func (m *containerStatusResponse) Reset()         { *m = containerStatusResponse{} }
+func (m *containerStatusResponse) String() string { return proto.CompactTextString(m) }
+func (*containerStatusResponse) ProtoMessage()    {}
+
+type podSandboxStatusRequest struct {
+	PodSandboxId string `protobuf:"bytes,1,opt,name=pod_sandbox_id" json:"pod_sandbox_id,omitempty"`
+}
+
+func (m *podSandboxStatusRequest) Reset()         { *m = podSandboxStatusRequest{} }
+func (m *podSandboxStatusRequest) String() string { return proto.CompactTextString(m) }
+func (*podSandboxStatusRequest) ProtoMessage()    {}
+
+type podSandboxMetadata struct {
+	Name      string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Uid       string `protobuf:"bytes,2,opt,name=uid" json:"uid,omitempty"`
+	Namespace string `protobuf:"bytes,3,opt,name=namespace" json:"namespace,omitempty"`
+	Attempt   uint32 `protobuf:"varint,4,opt,name=attempt" json:"attempt,omitempty"`
+}
+
+func (m *podSandboxMetadata) Reset()         { *m = podSandboxMetadata{} }
+func (m *podSandboxMetadata) String() string { return proto.CompactTextString(m) }
+func (*podSandboxMetadata) ProtoMessage()    {}
+
+type podSandboxStatus struct {
+	Id       string              `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
+	Metadata *podSandboxMetadata `protobuf:"bytes,2,opt,name=metadata" json:"metadata,omitempty"`
+	Labels   map[string]string   `protobuf:"bytes,7,rep,name=labels" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+}
+
+func (m *podSandboxStatus) Reset()         { *m = podSandboxStatus{} }
+func (m *podSandboxStatus) String() string { return proto.CompactTextString(m) }
+func (*podSandboxStatus) ProtoMessage()    {}
+
+type podSandboxStatusResponse struct {
+	Status *podSandboxStatus `protobuf:"bytes,1,opt,name=status" json:"status,omitempty"`
+}
+
+func (m *podSandboxStatusResponse) Reset()         { *m = podSandboxStatusResponse{} }
+func (m *podSandboxStatusResponse) String() string { return proto.CompactTextString(m) }
+func (*podSandboxStatusResponse) ProtoMessage()    {}
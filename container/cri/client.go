@@ -0,0 +1,130 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cri
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+var ArgCriEndpoint = flag.String("cri", "", "CRI runtime endpoint. Empty uses the first socket of containerd and CRI-O found")
+
+// Sockets of CRI runtimes looked for when no endpoint is given.
+var defaultCriEndpoints = []string{
+	"/run/containerd/containerd.sock",
+	"/var/run/crio/crio.sock",
+}
+
+const timeout = 2 * time.Second
+
+type client struct {
+	conn *grpc.ClientConn
+	// Name of the RuntimeService the runtime implements.
+	service string
+}
+
+var (
+	criClient    *client
+	criClientErr error
+	once         sync.Once
+)
+
+func Client() (*client, error) {
+	once.Do(func() {
+		endpoint := strings.TrimPrefix(*ArgCriEndpoint, "unix://")
+		if endpoint == "" {
+			for _, e := range defaultCriEndpoints {
+				if _, err := os.Stat(e); err == nil {
+					endpoint = e
+					break
+				}
+			}
+			if endpoint == "" {
+				criClientErr = fmt.Errorf("cri: no runtime socket found in %v", defaultCriEndpoints)
+				return
+			}
+		}
+		conn, err := grpc.Dial(endpoint,
+			grpc.WithInsecure(),
+			grpc.WithTimeout(timeout),
+			grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+				return net.DialTimeout("unix", addr, timeout)
+			}))
+		if err != nil {
+			criClientErr = fmt.Errorf("cri: cannot grpc Dial %q: %v", endpoint, err)
+			return
+		}
+		criClient = &client{conn: conn}
+	})
+	return criClient, criClientErr
+}
+
+func (self *client) invoke(method string, request, response interface{}) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return grpc.Invoke(ctx, "/"+self.service+"/"+method, request, response, self.conn)
+}
+
+// Version returns the version of the runtime, and picks the newest version of
+// the RuntimeService it implements.
+func (self *client) Version() (*versionResponse, error) {
+	var err error
+	for _, service := range runtimeServices {
+		self.service = service
+		resp := &versionResponse{}
+		if err = self.invoke("Version", &versionRequest{}, resp); err == nil {
+			return resp, nil
+		}
+	}
+	return nil, err
+}
+
+func (self *client) ListContainers() ([]*criContainer, error) {
+	resp := &listContainersResponse{}
+	if err := self.invoke("ListContainers", &listContainersRequest{}, resp); err != nil {
+		return nil, err
+	}
+	return resp.Containers, nil
+}
+
+func (self *client) ContainerStatus(id string) (*containerStatusResponse, error) {
+	resp := &containerStatusResponse{}
+	if err := self.invoke("ContainerStatus", &containerStatusRequest{ContainerId: id, Verbose: true}, resp); err != nil {
+		return nil, err
+	}
+	if resp.Status == nil {
+		return nil, fmt.Errorf("no status for container %q", id)
+	}
+	return resp, nil
+}
+
+func (self *client) PodSandboxStatus(id string) (*podSandboxStatus, error) {
+	resp := &podSandboxStatusResponse{}
+	if err := self.invoke("PodSandboxStatus", &podSandboxStatusRequest{PodSandboxId: id}, resp); err != nil {
+		return nil, err
+	}
+	if resp.Status == nil {
+		return nil, fmt.Errorf("no status for pod sandbox %q", id)
+	}
+	return resp.Status, nil
+}
@@ -0,0 +1,157 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cri
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sync"
+
+	"github.com/google/cadvisor/container"
+	"github.com/google/cadvisor/container/libcontainer"
+	"github.com/google/cadvisor/fs"
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
+)
+
+const CriNamespace = "cri"
+
+// CRI runtimes name containers with 64 character IDs, which end their cgroup
+// name, e.g. ".../crio-<id>.scope" or "/kubepods/burstable/pod<uid>/<id>".
+var criCgroupRegexp = regexp.MustCompile(`([a-z0-9]{64})`)
+
+type criFactory struct {
+	machineInfoFactory info.MachineInfoFactory
+
+	client *client
+
+	// Name and version of the runtime.
+	runtime string
+
+	cgroupSubsystems libcontainer.CgroupSubsystems
+
+	fsInfo fs.FsInfo
+
+	ignoreMetrics container.MetricSet
+
+	// Containers of the runtime keyed by ID, as last listed.
+	containers     map[string]*criContainer
+	containersLock sync.Mutex
+}
+
+func (self *criFactory) String() string {
+	return CriNamespace
+}
+
+func (self *criFactory) NewContainerHandler(name string, inHostNamespace bool) (container.ContainerHandler, error) {
+	ctnr, err := self.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	return newCriContainerHandler(
+		self.client,
+		name,
+		ctnr,
+		self.machineInfoFactory,
+		&self.cgroupSubsystems,
+		inHostNamespace,
+		self.ignoreMetrics,
+	)
+}
+
+func containerNameToId(name string) (string, bool) {
+	if matches := criCgroupRegexp.FindStringSubmatch(path.Base(name)); matches != nil {
+		return matches[1], true
+	}
+	return "", false
+}
+
+// Returns the running container of the cgroup, listing the containers of the
+// runtime again if it is not known yet.
+func (self *criFactory) lookup(name string) (*criContainer, error) {
+	id, ok := containerNameToId(name)
+	if !ok {
+		return nil, fmt.Errorf("invalid container name")
+	}
+	self.containersLock.Lock()
+	defer self.containersLock.Unlock()
+	ctnr, ok := self.containers[id]
+	if !ok {
+		containers, err := self.client.ListContainers()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list CRI containers: %v", err)
+		}
+		self.containers = make(map[string]*criContainer, len(containers))
+		for _, c := range containers {
+			self.containers[c.Id] = c
+		}
+		if ctnr, ok = self.containers[id]; !ok {
+			return nil, fmt.Errorf("no CRI container %q", id)
+		}
+	}
+	if ctnr.State != containerRunning {
+		// The state may be outdated.
+		status, err := self.client.ContainerStatus(id)
+		if err != nil || status.Status.State != containerRunning {
+			return nil, fmt.Errorf("CRI container %q is not running", id)
+		}
+		ctnr.State = containerRunning
+	}
+	return ctnr, nil
+}
+
+func (self *criFactory) CanHandleAndAccept(name string) (bool, bool, error) {
+	if _, err := self.lookup(name); err != nil {
+		return false, true, err
+	}
+	return true, true, nil
+}
+
+func (self *criFactory) DebugInfo() map[string][]string {
+	return map[string][]string{
+		"CRI runtime": {self.runtime},
+	}
+}
+
+func Register(factory info.MachineInfoFactory, fsInfo fs.FsInfo, ignoreMetrics container.MetricSet) error {
+	client, err := Client()
+	if err != nil {
+		return fmt.Errorf("unable to communicate with the CRI runtime: %v", err)
+	}
+	version, err := client.Version()
+	if err != nil {
+		return fmt.Errorf("failed to get the CRI runtime version: %v", err)
+	}
+
+	cgroupSubsystems, err := libcontainer.GetCgroupSubsystems()
+	if err != nil {
+		return fmt.Errorf("failed to get cgroup subsystems: %v", err)
+	}
+
+	runtime := fmt.Sprintf("%s %s (%s)", version.RuntimeName, version.RuntimeVersion, client.service)
+	logging.Infof("Registering CRI factory for %s", runtime)
+	f := &criFactory{
+		cgroupSubsystems:   cgroupSubsystems,
+		client:             client,
+		runtime:            runtime,
+		fsInfo:             fsInfo,
+		machineInfoFactory: factory,
+		ignoreMetrics:      ignoreMetrics,
+		containers:         make(map[string]*criContainer),
+	}
+	container.RegisterContainerHandlerFactory(f)
+	return nil
+}
@@ -0,0 +1,274 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Handler for containers of CRI runtimes.
+package cri
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/cadvisor/container"
+	"github.com/google/cadvisor/container/common"
+	containerlibcontainer "github.com/google/cadvisor/container/libcontainer"
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
+
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	cgroupfs "github.com/opencontainers/runc/libcontainer/cgroups/fs"
+	libcontainerconfigs "github.com/opencontainers/runc/libcontainer/configs"
+)
+
+// Labels of the pod metadata, set by the kubelet on containers.
+const (
+	podNameLabel      = "io.kubernetes.pod.name"
+	podNamespaceLabel = "io.kubernetes.pod.namespace"
+	podUidLabel       = "io.kubernetes.pod.uid"
+)
+
+type criContainerHandler struct {
+	client             *client
+	name               string
+	id                 string
+	aliases            []string
+	machineInfoFactory info.MachineInfoFactory
+
+	// Absolute path to the cgroup hierarchies of this container.
+	// (e.g.: "cpu" -> "/sys/fs/cgroup/cpu/test")
+	cgroupPaths map[string]string
+
+	// Manager of this container's cgroups.
+	cgroupManager cgroups.Manager
+
+	// Time at which this container was created.
+	creationTime time.Time
+
+	// Metadata associated with the container, including the metadata and
+	// labels of its pod.
+	labels map[string]string
+
+	// The container PID used to switch namespaces as required
+	pid int
+
+	// Image name used for this container.
+	image string
+
+	// The host root FS to read
+	rootFs string
+
+	// Whether the container is in a pod, whose sandbox owns the network.
+	inPod bool
+
+	ignoreMetrics container.MetricSet
+}
+
+func newCriContainerHandler(
+	client *client,
+	name string,
+	ctnr *criContainer,
+	machineInfoFactory info.MachineInfoFactory,
+	cgroupSubsystems *containerlibcontainer.CgroupSubsystems,
+	inHostNamespace bool,
+	ignoreMetrics container.MetricSet,
+) (container.ContainerHandler, error) {
+	cgroupPaths := common.MakeCgroupPaths(cgroupSubsystems.MountPoints, name)
+
+	// Generate the equivalent cgroup manager for this container.
+	cgroupManager := &cgroupfs.Manager{
+		Cgroups: &libcontainerconfigs.Cgroup{
+			Name: name,
+		},
+		Paths: cgroupPaths,
+	}
+
+	rootFs := "/"
+	if !inHostNamespace {
+		rootFs = "/rootfs"
+	}
+
+	resp, err := client.ContainerStatus(ctnr.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status of CRI container %q: %v", ctnr.Id, err)
+	}
+	status := resp.Status
+
+	handler := &criContainerHandler{
+		client:             client,
+		name:               name,
+		id:                 ctnr.Id,
+		machineInfoFactory: machineInfoFactory,
+		cgroupPaths:        cgroupPaths,
+		cgroupManager:      cgroupManager,
+		creationTime:       time.Unix(0, status.CreatedAt),
+		labels:             make(map[string]string),
+		rootFs:             rootFs,
+		inPod:              ctnr.PodSandboxId != "",
+		ignoreMetrics:      ignoreMetrics,
+	}
+	for k, v := range status.Labels {
+		handler.labels[k] = v
+	}
+	if status.Image != nil {
+		handler.image = status.Image.Image
+	}
+
+	if ctnr.PodSandboxId != "" {
+		pod, err := client.PodSandboxStatus(ctnr.PodSandboxId)
+		if err != nil {
+			logging.Warningf("Failed to get the pod of CRI container %q: %v", ctnr.Id, err)
+		} else {
+			addPodLabels(handler.labels, pod)
+		}
+	}
+
+	// Add the Kubernetes name and bare ID as aliases of the container.
+	if status.Metadata != nil {
+		handler.aliases = append(handler.aliases, kubernetesName(status.Metadata, handler.labels))
+	}
+	handler.aliases = append(handler.aliases, ctnr.Id)
+
+	handler.pid = infoPid(resp.Info)
+	if handler.pid == 0 {
+		// Runtimes that do not report the pid.
+		pids, err := cgroupManager.GetPids()
+		if err != nil || len(pids) == 0 {
+			return nil, fmt.Errorf("failed to find the pid of CRI container %q: %v", ctnr.Id, err)
+		}
+		handler.pid = pids[0]
+	}
+
+	return handler, nil
+}
+
+// Adds the metadata and labels of the pod to the labels of a container,
+// keeping the labels of the container.
+func addPodLabels(labels map[string]string, pod *podSandboxStatus) {
+	for k, v := range pod.Labels {
+		if _, ok := labels[k]; !ok {
+			labels[k] = v
+		}
+	}
+	if pod.Metadata == nil {
+		return
+	}
+	for k, v := range map[string]string{
+		podNameLabel:      pod.Metadata.Name,
+		podNamespaceLabel: pod.Metadata.Namespace,
+		podUidLabel:       pod.Metadata.Uid,
+	} {
+		if _, ok := labels[k]; !ok && v != "" {
+			labels[k] = v
+		}
+	}
+}
+
+// Returns the name the kubelet gives containers with dockershim, e.g.
+// "k8s_nginx_web-1_default_<pod uid>_0".
+func kubernetesName(metadata *containerMetadata, labels map[string]string) string {
+	return fmt.Sprintf("k8s_%s_%s_%s_%s_%d", metadata.Name, labels[podNameLabel], labels[podNamespaceLabel], labels[podUidLabel], metadata.Attempt)
+}
+
+// Returns the pid in the verbose info of the container status, as reported by
+// containerd and CRI-O, or 0.
+func infoPid(statusInfo map[string]string) int {
+	var verbose struct {
+		Pid int `json:"pid"`
+	}
+	if err := json.Unmarshal([]byte(statusInfo["info"]), &verbose); err != nil {
+		return 0
+	}
+	return verbose.Pid
+}
+
+func (self *criContainerHandler) Start() {}
+
+func (self *criContainerHandler) Cleanup() {}
+
+func (self *criContainerHandler) ContainerReference() (info.ContainerReference, error) {
+	return info.ContainerReference{
+		Id:        self.id,
+		Name:      self.name,
+		Aliases:   self.aliases,
+		Namespace: CriNamespace,
+		Labels:    self.labels,
+	}, nil
+}
+
+func (self *criContainerHandler) needNet() bool {
+	if !self.ignoreMetrics.Has(container.NetworkUsageMetrics) {
+		return !self.inPod
+	}
+	return false
+}
+
+func (self *criContainerHandler) GetSpec() (info.ContainerSpec, error) {
+	spec, err := common.GetSpec(self.cgroupPaths, self.machineInfoFactory, self.needNet(), false)
+
+	spec.Labels = self.labels
+	spec.Image = self.image
+	spec.CreationTime = self.creationTime
+
+	return spec, err
+}
+
+func (self *criContainerHandler) GetStats() (*info.ContainerStats, error) {
+	stats, err := containerlibcontainer.GetStats(self.cgroupManager, self.rootFs, self.pid, self.ignoreMetrics)
+	if err != nil {
+		return stats, err
+	}
+	// The network of pods is reported by their sandbox.
+	if !self.needNet() {
+		stats.Network = info.NetworkStats{}
+	}
+	return stats, nil
+}
+
+func (self *criContainerHandler) ListContainers(listType container.ListType) ([]info.ContainerReference, error) {
+	// No-op for CRI driver.
+	return []info.ContainerReference{}, nil
+}
+
+func (self *criContainerHandler) GetCgroupPath(resource string) (string, error) {
+	path, ok := self.cgroupPaths[resource]
+	if !ok {
+		return "", fmt.Errorf("could not find path for resource %q for container %q\n", resource, self.name)
+	}
+	return path, nil
+}
+
+func (self *criContainerHandler) ListThreads(listType container.ListType) ([]int, error) {
+	return nil, nil
+}
+
+func (self *criContainerHandler) GetContainerLabels() map[string]string {
+	return self.labels
+}
+
+func (self *criContainerHandler) ListProcesses(listType container.ListType) ([]int, error) {
+	return containerlibcontainer.GetProcesses(self.cgroupManager)
+}
+
+func (self *criContainerHandler) WatchSubcontainers(events chan container.SubcontainerEvent) error {
+	return fmt.Errorf("watch is unimplemented in the CRI container driver")
+}
+
+func (self *criContainerHandler) StopWatchingSubcontainers() error {
+	// No-op for CRI driver.
+	return nil
+}
+
+func (self *criContainerHandler) Exists() bool {
+	return common.CgroupExists(self.cgroupPaths)
+}
@@ -0,0 +1,79 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cri
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestContainerNameToId(t *testing.T) {
+	const id = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	for _, name := range []string{
+		"/kubepods/burstable/pod1/" + id,
+		"/kubepods.slice/kubepods-pod1.slice/crio-" + id + ".scope",
+	} {
+		if actual, ok := containerNameToId(name); !ok || actual != id {
+			t.Errorf("expected id %q for %q, got %q", id, name, actual)
+		}
+	}
+	if _, ok := containerNameToId("/system.slice/crio.service"); ok {
+		t.Errorf("unexpected id for a service")
+	}
+}
+
+func TestAddPodLabels(t *testing.T) {
+	labels := map[string]string{
+		"app":            "container",
+		podNameLabel:     "web-1",
+		"container-only": "yes",
+	}
+	addPodLabels(labels, &podSandboxStatus{
+		Metadata: &podSandboxMetadata{
+			Name:      "web-1",
+			Namespace: "default",
+			Uid:       "1234",
+		},
+		Labels: map[string]string{
+			"app":      "pod",
+			"pod-only": "yes",
+		},
+	})
+	expected := map[string]string{
+		"app":             "container",
+		"container-only":  "yes",
+		"pod-only":        "yes",
+		podNameLabel:      "web-1",
+		podNamespaceLabel: "default",
+		podUidLabel:       "1234",
+	}
+	if !reflect.DeepEqual(labels, expected) {
+		t.Errorf("expected labels %v, got %v", expected, labels)
+	}
+
+	name := kubernetesName(&containerMetadata{Name: "nginx", Attempt: 2}, labels)
+	if name != "k8s_nginx_web-1_default_1234_2" {
+		t.Errorf("unexpected Kubernetes name %q", name)
+	}
+}
+
+func TestInfoPid(t *testing.T) {
+	if pid := infoPid(map[string]string{"info": `{"pid": 42, "sandboxID": "abc"}`}); pid != 42 {
+		t.Errorf("expected pid 42, got %d", pid)
+	}
+	if pid := infoPid(nil); pid != 0 {
+		t.Errorf("expected no pid without info, got %d", pid)
+	}
+}
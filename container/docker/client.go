@@ -18,7 +18,16 @@
 package docker
 
 import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
 	"sync"
+	"time"
 
 	dclient "github.com/fsouza/go-dockerclient"
 )
@@ -27,11 +36,106 @@ var (
 	dockerClient    *dclient.Client
 	dockerClientErr error
 	once            sync.Once
+
+	dockerRawClient    *rawClient
+	dockerRawClientErr error
+	rawOnce            sync.Once
 )
 
 func Client() (*dclient.Client, error) {
 	once.Do(func() {
+		if *argDockerTls {
+			dockerClient, dockerClientErr = dclient.NewTLSClient(*ArgDockerEndpoint, *argDockerCert, *argDockerKey, *argDockerCa)
+			return
+		}
 		dockerClient, dockerClientErr = dclient.NewClient(*ArgDockerEndpoint)
 	})
 	return dockerClient, dockerClientErr
 }
+
+// Returns the TLS configuration authenticating cAdvisor to the Docker daemon
+// with --docker_tls_cert and --docker_tls_key, and verifying the daemon with
+// --docker_tls_ca.
+func tlsConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(*argDockerCert, *argDockerKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the Docker client certificate: %v", err)
+	}
+	ca, err := ioutil.ReadFile(*argDockerCa)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the Docker CA: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificate found in the Docker CA %q", *argDockerCa)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+	}, nil
+}
+
+// Client of the Docker API for the fields that go-dockerclient does not
+// decode.
+type rawClient struct {
+	client  *http.Client
+	baseURL string
+}
+
+func getRawClient() (*rawClient, error) {
+	rawOnce.Do(func() {
+		var config *tls.Config
+		if *argDockerTls {
+			if config, dockerRawClientErr = tlsConfig(); dockerRawClientErr != nil {
+				return
+			}
+		}
+		dockerRawClient, dockerRawClientErr = newRawClient(*ArgDockerEndpoint, config)
+	})
+	return dockerRawClient, dockerRawClientErr
+}
+
+// Connects with TLS if config is set.
+func newRawClient(endpoint string, config *tls.Config) (*rawClient, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	client := &rawClient{
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		client.client.Transport = &http.Transport{
+			Dial: func(_, _ string) (net.Conn, error) {
+				return net.Dial("unix", socket)
+			},
+		}
+		client.baseURL = "http://docker"
+	case "tcp", "http", "https":
+		client.baseURL = "http://" + u.Host
+		if config != nil || u.Scheme == "https" {
+			client.client.Transport = &http.Transport{
+				TLSClientConfig: config,
+			}
+			client.baseURL = "https://" + u.Host
+		}
+	default:
+		return nil, fmt.Errorf("unsupported Docker endpoint %q", endpoint)
+	}
+	return client, nil
+}
+
+// Decodes the inspection of the container into v.
+func (self *rawClient) InspectContainer(id string, v interface{}) error {
+	resp, err := self.client.Get(self.baseURL + "/containers/" + url.QueryEscape(id) + "/json")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to inspect container %q: %s", id, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,202 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/google/cadvisor/container"
+	"github.com/google/cadvisor/logging"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+var argDockerEvents = flag.Bool("docker_events", true, "Create and destroy Docker containers as soon as Docker reports them started and dead, instead of waiting for the discovery of their cgroups")
+
+// Docker actions reported for containers.
+const (
+	actionStart   = "start"
+	actionDie     = "die"
+	actionDestroy = "destroy"
+	// Followed by the new health check status.
+	actionHealthStatus = "health_status: "
+)
+
+// Translates Docker events into subcontainer events.
+type eventWatcher struct {
+	client *docker.Client
+	rootFs string
+	events chan container.SubcontainerEvent
+	// Called with the cgroup name of containers whose health check status
+	// changes, and their new status.
+	onHealth func(name, status string)
+	// Cgroup names of the started containers keyed by ID.
+	names map[string]string
+	lock  sync.Mutex
+}
+
+// WatchEvents sends an add event with the cgroup of Docker containers when
+// they start, and a delete event when they die, if --docker_events is set.
+// Short-lived containers are tracked even if they exit before the cgroup
+// watchers see them. onHealth is called when the health check status of a
+// container changes.
+func WatchEvents(events chan container.SubcontainerEvent, onHealth func(name, status string), inHostNamespace bool) error {
+	if !*argDockerEvents {
+		return nil
+	}
+	client, err := Client()
+	if err != nil {
+		return err
+	}
+	w := &eventWatcher{
+		client:   client,
+		rootFs:   "/",
+		events:   events,
+		onHealth: onHealth,
+		names:    make(map[string]string),
+	}
+	if !inHostNamespace {
+		w.rootFs = "/rootfs"
+	}
+	listener := make(chan *docker.APIEvents, 64)
+	if err := client.AddEventListener(listener); err != nil {
+		return fmt.Errorf("failed to listen to Docker events: %v", err)
+	}
+	go func() {
+		for event := range listener {
+			w.handle(event)
+		}
+	}()
+	return nil
+}
+
+func (self *eventWatcher) handle(event *docker.APIEvents) {
+	// Events of Docker < 1.10 have no type and use the status as action.
+	if event.Type != "" && event.Type != "container" {
+		return
+	}
+	action, id := event.Action, event.Actor.ID
+	if action == "" {
+		action, id = event.Status, event.ID
+	}
+	if strings.HasPrefix(action, actionHealthStatus) {
+		self.handleHealth(id, strings.TrimPrefix(action, actionHealthStatus))
+		return
+	}
+	switch action {
+	case actionStart:
+		name, err := self.cgroupName(id)
+		if err != nil {
+			logging.V(2).Infof("Failed to find the cgroup of started Docker container %q: %v", id, err)
+			return
+		}
+		self.lock.Lock()
+		self.names[id] = name
+		self.lock.Unlock()
+		self.events <- container.SubcontainerEvent{
+			EventType: container.SubcontainerAdd,
+			Name:      name,
+		}
+	case actionDie, actionDestroy:
+		self.lock.Lock()
+		name, ok := self.names[id]
+		delete(self.names, id)
+		self.lock.Unlock()
+		if ok {
+			self.events <- container.SubcontainerEvent{
+				EventType: container.SubcontainerDelete,
+				Name:      name,
+			}
+		}
+	}
+}
+
+func (self *eventWatcher) handleHealth(id, status string) {
+	if self.onHealth == nil {
+		return
+	}
+	self.lock.Lock()
+	name, ok := self.names[id]
+	self.lock.Unlock()
+	// Containers started before cAdvisor are not known yet.
+	if !ok {
+		var err error
+		if name, err = self.cgroupName(id); err != nil {
+			logging.V(2).Infof("Failed to find the cgroup of Docker container %q: %v", id, err)
+			return
+		}
+		self.lock.Lock()
+		self.names[id] = name
+		self.lock.Unlock()
+	}
+	self.onHealth(name, status)
+}
+
+// Returns the cgroup name of the running container, as found in the cgroups
+// of its init process, or its name if the containers are monitored through the
+// Docker API only.
+func (self *eventWatcher) cgroupName(id string) (string, error) {
+	if apiOnly {
+		return remoteNamePrefix + id, nil
+	}
+	ctnr, err := self.client.InspectContainer(id)
+	if err != nil {
+		return "", err
+	}
+	if ctnr.State.Pid == 0 {
+		return "", fmt.Errorf("container is not running")
+	}
+	f, err := os.Open(path.Join(self.rootFs, "proc", fmt.Sprint(ctnr.State.Pid), "cgroup"))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return parseCgroupName(bufio.NewScanner(f))
+}
+
+// Parses the cgroup of the cpu hierarchy, or the unified hierarchy, from the
+// lines of a /proc/<pid>/cgroup file.
+func parseCgroupName(scanner *bufio.Scanner) (string, error) {
+	unified := ""
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if fields[0] == "0" && fields[1] == "" {
+			unified = fields[2]
+			continue
+		}
+		for _, controller := range strings.Split(fields[1], ",") {
+			if controller == "cpu" {
+				return fields[2], nil
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	if unified == "" {
+		return "", fmt.Errorf("no cpu cgroup")
+	}
+	return unified, nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/google/cadvisor/container"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+func TestParseCgroupName(t *testing.T) {
+	for input, expected := range map[string]string{
+		"11:memory:/docker/abc\n4:cpu,cpuacct:/docker/abc\n1:name=systemd:/docker/abc\n": "/docker/abc",
+		"0::/system.slice/docker-abc.scope\n":                                            "/system.slice/docker-abc.scope",
+	} {
+		name, err := parseCgroupName(bufio.NewScanner(strings.NewReader(input)))
+		if err != nil || name != expected {
+			t.Errorf("expected cgroup %q for %q, got %q (%v)", expected, input, name, err)
+		}
+	}
+	if _, err := parseCgroupName(bufio.NewScanner(strings.NewReader("1:name=systemd:/\n"))); err == nil {
+		t.Errorf("expected an error without cpu cgroup")
+	}
+}
+
+func TestHandleDeleteEvents(t *testing.T) {
+	events := make(chan container.SubcontainerEvent, 2)
+	w := &eventWatcher{
+		events: events,
+		names:  map[string]string{"abc": "/docker/abc"},
+	}
+	w.handle(&docker.APIEvents{Type: "container", Action: "die", Actor: docker.APIActor{ID: "abc"}})
+	// Only the first of die and destroy is reported.
+	w.handle(&docker.APIEvents{Status: "destroy", ID: "abc"})
+	w.handle(&docker.APIEvents{Type: "network", Action: "destroy", Actor: docker.APIActor{ID: "abc"}})
+	close(events)
+	var received []container.SubcontainerEvent
+	for event := range events {
+		received = append(received, event)
+	}
+	if len(received) != 1 || received[0].EventType != container.SubcontainerDelete || received[0].Name != "/docker/abc" {
+		t.Errorf("unexpected events %+v", received)
+	}
+}
+
+func TestHandleHealthEvents(t *testing.T) {
+	var statuses []string
+	w := &eventWatcher{
+		names: map[string]string{"abc": "/docker/abc"},
+		onHealth: func(name, status string) {
+			statuses = append(statuses, name+" "+status)
+		},
+	}
+	w.handle(&docker.APIEvents{Type: "container", Action: "health_status: unhealthy", Actor: docker.APIActor{ID: "abc"}})
+	if len(statuses) != 1 || statuses[0] != "/docker/abc unhealthy" {
+		t.Errorf("unexpected health transitions %v", statuses)
+	}
+}
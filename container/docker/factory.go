@@ -12,55 +12,25 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// +build linux
+
 package docker
 
 import (
-	"flag"
 	"fmt"
-	"path"
-	"regexp"
-	"strconv"
+	"os"
 	"strings"
 
 	"github.com/google/cadvisor/container"
+	"github.com/google/cadvisor/container/common"
 	"github.com/google/cadvisor/container/libcontainer"
 	"github.com/google/cadvisor/fs"
 	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
 
 	docker "github.com/fsouza/go-dockerclient"
-	"github.com/golang/glog"
 )
 
-var ArgDockerEndpoint = flag.String("docker", "unix:///var/run/docker.sock", "docker endpoint")
-
-// The namespace under which Docker aliases are unique.
-var DockerNamespace = "docker"
-
-// Basepath to all container specific information that libcontainer stores.
-// TODO: Deprecate this flag
-var dockerRootDir = flag.String("docker_root", "/var/lib/docker", "Absolute path to the Docker state root directory (default: /var/lib/docker)")
-var dockerRunDir = flag.String("docker_run", "/var/run/docker", "Absolute path to the Docker run directory (default: /var/run/docker)")
-
-// Regexp that identifies docker cgroups, containers started with
-// --cgroup-parent have another prefix than 'docker'
-var dockerCgroupRegexp = regexp.MustCompile(`([a-z0-9]{64})`)
-
-var dockerEnvWhitelist = flag.String("docker_env_metadata_whitelist", "", "a comma-separated list of environment variable keys that needs to be collected for docker containers")
-
-// TODO(vmarmol): Export run dir too for newer Dockers.
-// Directory holding Docker container state information.
-func DockerStateDir() string {
-	return libcontainer.DockerStateDir(*dockerRootDir)
-}
-
-const (
-	dockerRootDirKey = "Root Dir"
-)
-
-func RootDir() string {
-	return *dockerRootDir
-}
-
 type storageDriver string
 
 const (
@@ -79,6 +49,10 @@ type dockerFactory struct {
 
 	client *docker.Client
 
+	// Creates the handlers reading the stats of containers from the Docker
+	// stats API, for containers whose cgroups cannot be read.
+	api *remoteFactory
+
 	// Information about the mounted cgroup subsystems.
 	cgroupSubsystems libcontainer.CgroupSubsystems
 
@@ -100,6 +74,11 @@ func (self *dockerFactory) NewContainerHandler(name string, inHostNamespace bool
 		return
 	}
 
+	if !cgroupsReadable(common.MakeCgroupPaths(self.cgroupSubsystems.MountPoints, name)) {
+		logging.Warningf("Cannot read the cgroups of Docker container %q, falling back to the Docker stats API with only CPU, memory, network and disk IO stats", name)
+		return newRemoteContainerHandler(self.api, name, ContainerNameToDockerId(name))
+	}
+
 	metadataEnvs := strings.Split(*dockerEnvWhitelist, ",")
 
 	handler, err = newDockerContainerHandler(
@@ -118,19 +97,24 @@ func (self *dockerFactory) NewContainerHandler(name string, inHostNamespace bool
 	return
 }
 
-// Returns the Docker ID from the full container name.
-func ContainerNameToDockerId(name string) string {
-	id := path.Base(name)
-
-	if matches := dockerCgroupRegexp.FindStringSubmatch(id); matches != nil {
-		return matches[1]
+// Returns whether the cpu or memory cgroup of a container can be read.
+func cgroupsReadable(cgroupPaths map[string]string) bool {
+	for _, subsystem := range []string{"cpu", "cpuacct", "memory"} {
+		dir, ok := cgroupPaths[subsystem]
+		if !ok {
+			continue
+		}
+		f, err := os.Open(dir)
+		if err != nil {
+			continue
+		}
+		_, err = f.Readdirnames(1)
+		f.Close()
+		if err == nil {
+			return true
+		}
 	}
-
-	return id
-}
-
-func isContainerName(name string) bool {
-	return dockerCgroupRegexp.MatchString(path.Base(name))
+	return false
 }
 
 // Docker handles all containers under /docker
@@ -158,29 +142,6 @@ func (self *dockerFactory) DebugInfo() map[string][]string {
 	return map[string][]string{}
 }
 
-var (
-	version_regexp_string = `(\d+)\.(\d+)\.(\d+)`
-	version_re            = regexp.MustCompile(version_regexp_string)
-)
-
-// TODO: switch to a semantic versioning library.
-func parseDockerVersion(full_version_string string) ([]int, error) {
-	matches := version_re.FindAllStringSubmatch(full_version_string, -1)
-	if len(matches) != 1 {
-		return nil, fmt.Errorf("version string \"%v\" doesn't match expected regular expression: \"%v\"", full_version_string, version_regexp_string)
-	}
-	version_string_array := matches[0][1:]
-	version_array := make([]int, 3)
-	for index, version_string := range version_string_array {
-		version, err := strconv.Atoi(version_string)
-		if err != nil {
-			return nil, fmt.Errorf("error while parsing \"%v\" in \"%v\"", version_string, full_version_string)
-		}
-		version_array[index] = version
-	}
-	return version_array, nil
-}
-
 // Register root container before running this function!
 func Register(factory info.MachineInfoFactory, fsInfo fs.FsInfo, ignoreMetrics container.MetricSet) error {
 	client, err := Client()
@@ -200,15 +161,26 @@ func Register(factory info.MachineInfoFactory, fsInfo fs.FsInfo, ignoreMetrics c
 	if storageDir == "" {
 		storageDir = *dockerRootDir
 	}
+	api := newRemoteFactory(client, dockerInfo, ignoreMetrics)
+	if *argDockerRemote {
+		logging.Infof("Registering the remote Docker factory for %s", *ArgDockerEndpoint)
+		apiOnly = true
+		container.RegisterContainerHandlerFactory(api)
+		return nil
+	}
 	cgroupSubsystems, err := libcontainer.GetCgroupSubsystems()
 	if err != nil {
-		return fmt.Errorf("failed to get cgroup subsystems: %v", err)
+		logging.Warningf("Failed to get cgroup subsystems, falling back to the Docker stats API with only CPU, memory, network and disk IO stats: %v", err)
+		apiOnly = true
+		container.RegisterContainerHandlerFactory(api)
+		return nil
 	}
 
-	glog.Infof("Registering Docker factory")
+	logging.Infof("Registering Docker factory")
 	f := &dockerFactory{
 		cgroupSubsystems:   cgroupSubsystems,
 		client:             client,
+		api:                api,
 		dockerVersion:      dockerVersion,
 		fsInfo:             fsInfo,
 		machineInfoFactory: factory,
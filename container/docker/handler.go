@@ -12,6 +12,8 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// +build linux
+
 // Handler for Docker containers.
 package docker
 
@@ -27,9 +29,9 @@ import (
 	containerlibcontainer "github.com/google/cadvisor/container/libcontainer"
 	"github.com/google/cadvisor/fs"
 	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
 
 	docker "github.com/fsouza/go-dockerclient"
-	"github.com/golang/glog"
 	"github.com/opencontainers/runc/libcontainer/cgroups"
 	cgroupfs "github.com/opencontainers/runc/libcontainer/cgroups/fs"
 	libcontainerconfigs "github.com/opencontainers/runc/libcontainer/configs"
@@ -69,6 +71,10 @@ type dockerContainerHandler struct {
 	// The directories in use by this container
 	baseDirs  []string
 	extraDirs []string
+	// The host directories bind-mounted in the container.
+	mountDirs []string
+	// The host paths of the volumes, keyed by their path in the container.
+	volumes map[string]string
 
 	// The container PID used to switch namespaces as required
 	pid int
@@ -76,6 +82,9 @@ type dockerContainerHandler struct {
 	// Image name used for this container.
 	image string
 
+	// Restart policy of the container, e.g. "always".
+	restartPolicy string
+
 	// The host root FS to read
 	rootFs string
 
@@ -172,6 +181,7 @@ func newDockerContainerHandler(
 	handler.labels = ctnr.Config.Labels
 	handler.image = ctnr.Config.Image
 	handler.networkMode = ctnr.HostConfig.NetworkMode
+	handler.restartPolicy = ctnr.HostConfig.RestartPolicy.Name
 
 	// split env vars to get metadata map.
 	for _, exposedEnv := range metadataEnvs {
@@ -189,15 +199,19 @@ func newDockerContainerHandler(
 
 	// Docker API >= 1.20 exposes a "Mounts" list of structures representing mounts
 	// (https://docs.docker.com/engine/reference/api/docker_remote_api_v1.20/)
+	handler.volumes = make(map[string]string)
 	for _, mount := range ctnr.Mounts {
-		handler.baseDirs = append(handler.baseDirs, path.Join(rootFs, mount.Source))
+		handler.mountDirs = append(handler.mountDirs, path.Join(rootFs, mount.Source))
+		handler.volumes[mount.Destination] = mount.Source
 	}
 
 	// Docker API < 1.20 exposes a "Volumes" mapping of container paths to host paths.
 	// (https://docs.docker.com/engine/reference/api/docker_remote_api_v1.19/)
-	for _, hostPath := range ctnr.Volumes {
-		handler.baseDirs = append(handler.baseDirs, path.Join(rootFs, hostPath))
+	for containerPath, hostPath := range ctnr.Volumes {
+		handler.mountDirs = append(handler.mountDirs, path.Join(rootFs, hostPath))
+		handler.volumes[containerPath] = hostPath
 	}
+	handler.baseDirs = append(handler.baseDirs, handler.mountDirs...)
 
 	// Now, handle the rootfs
 	rootfsStorageDir := ""
@@ -212,7 +226,7 @@ func newDockerContainerHandler(
 	if rootfsStorageDir != "" {
 		handler.baseDirs = append(handler.baseDirs, rootfsStorageDir)
 	} else {
-		glog.Warningf("Unable to find root mount for container %q (storageDriver: %q)", name, storageDriver)
+		logging.Warningf("Unable to find root mount for container %q (storageDriver: %q)", name, storageDriver)
 	}
 
 	// Now, handle the storage dir
@@ -221,7 +235,7 @@ func newDockerContainerHandler(
 
 	// And start DiskUsageMetrics (if enabled)
 	if !ignoreMetrics.Has(container.DiskUsageMetrics) {
-		handler.fsHandler = common.NewFsHandler(time.Minute, handler.baseDirs, handler.extraDirs, fsInfo)
+		handler.fsHandler = common.NewFsHandler(*common.DiskUsagePeriod, handler.baseDirs, handler.extraDirs, fsInfo)
 	}
 
 	return handler, nil
@@ -246,6 +260,7 @@ func (self *dockerContainerHandler) ContainerReference() (info.ContainerReferenc
 		Aliases:   self.aliases,
 		Namespace: DockerNamespace,
 		Labels:    self.labels,
+		Envs:      self.envs,
 	}, nil
 }
 
@@ -263,11 +278,51 @@ func (self *dockerContainerHandler) GetSpec() (info.ContainerSpec, error) {
 	spec.Labels = self.labels
 	spec.Envs = self.envs
 	spec.Image = self.image
+	spec.RestartPolicy = self.restartPolicy
+	if state, stateErr := self.getState(); stateErr == nil {
+		spec.Health = state.State.Health.Status
+		spec.RestartCount = state.RestartCount
+	} else {
+		logging.V(4).Infof("Failed to get the state of Docker container %q: %v", self.id, stateErr)
+	}
 
 	return spec, err
 }
 
+// Parts of the inspection of a container that change while it runs.
+type containerState struct {
+	RestartCount int
+	State        struct {
+		Health struct {
+			// "starting", "healthy" or "unhealthy", empty without health
+			// check.
+			Status string
+		}
+	}
+}
+
+func (self *dockerContainerHandler) getState() (*containerState, error) {
+	client, err := getRawClient()
+	if err != nil {
+		return nil, err
+	}
+	state := &containerState{}
+	if err := client.InspectContainer(self.id, state); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
 func (self *dockerContainerHandler) getFsStats(stats *info.ContainerStats) error {
+	// The network filesystems of the bind mounts.
+	if len(self.mountDirs) > 0 {
+		networkFs, err := common.NetworkFsStats(self.fsInfo, self.mountDirs)
+		if err != nil {
+			return err
+		}
+		stats.Filesystem = append(stats.Filesystem, networkFs...)
+	}
+
 	if self.fsHandler == nil {
 		return nil
 	}
@@ -301,9 +356,14 @@ func (self *dockerContainerHandler) GetStats() (*info.ContainerStats, error) {
 	// Get filesystem stats.
 	err = self.getFsStats(stats)
 	if err != nil {
+		stats.SetError(info.FilesystemStatsSubsystem, err)
 		return stats, err
 	}
 
+	if !self.ignoreMetrics.Has(container.VolumeUsageMetrics) {
+		stats.Volumes = common.GetVolumeStats(self.rootFs, self.pid, self.volumes)
+	}
+
 	return stats, nil
 }
 
@@ -345,71 +405,3 @@ func (self *dockerContainerHandler) StopWatchingSubcontainers() error {
 func (self *dockerContainerHandler) Exists() bool {
 	return common.CgroupExists(self.cgroupPaths)
 }
-
-func DockerInfo() (docker.DockerInfo, error) {
-	client, err := Client()
-	if err != nil {
-		return docker.DockerInfo{}, fmt.Errorf("unable to communicate with docker daemon: %v", err)
-	}
-	info, err := client.Info()
-	if err != nil {
-		return docker.DockerInfo{}, err
-	}
-	return *info, nil
-}
-
-func DockerImages() ([]docker.APIImages, error) {
-	client, err := Client()
-	if err != nil {
-		return nil, fmt.Errorf("unable to communicate with docker daemon: %v", err)
-	}
-	images, err := client.ListImages(docker.ListImagesOptions{All: false})
-	if err != nil {
-		return nil, err
-	}
-	return images, nil
-}
-
-// Checks whether the dockerInfo reflects a valid docker setup, and returns it if it does, or an
-// error otherwise.
-func ValidateInfo() (*docker.DockerInfo, error) {
-	client, err := Client()
-	if err != nil {
-		return nil, fmt.Errorf("unable to communicate with docker daemon: %v", err)
-	}
-
-	dockerInfo, err := client.Info()
-	if err != nil {
-		return nil, fmt.Errorf("failed to detect Docker info: %v", err)
-	}
-
-	// Fall back to version API if ServerVersion is not set in info.
-	if dockerInfo.ServerVersion == "" {
-		version, err := client.Version()
-		if err != nil {
-			return nil, fmt.Errorf("unable to get docker version: %v", err)
-		}
-		dockerInfo.ServerVersion = version.Get("Version")
-	}
-	version, err := parseDockerVersion(dockerInfo.ServerVersion)
-	if err != nil {
-		return nil, err
-	}
-
-	if version[0] < 1 {
-		return nil, fmt.Errorf("cAdvisor requires docker version %v or above but we have found version %v reported as %q", []int{1, 0, 0}, version, dockerInfo.ServerVersion)
-	}
-
-	// Check that the libcontainer execdriver is used if the version is < 1.11
-	// (execution drivers are no longer supported as of 1.11).
-	if version[0] <= 1 && version[1] <= 10 &&
-		!strings.HasPrefix(dockerInfo.ExecutionDriver, "native") {
-		return nil, fmt.Errorf("docker found, but not using native exec driver")
-	}
-
-	if dockerInfo.Driver == "" {
-		return nil, fmt.Errorf("failed to find docker storage driver")
-	}
-
-	return dockerInfo, nil
-}
@@ -48,3 +48,17 @@ func TestStorageDirDetectionWithNewVersions(t *testing.T) {
 	as.Equal(rwLayer, randomizedID)
 
 }
+
+func TestCgroupsReadable(t *testing.T) {
+	as := assert.New(t)
+	testDir, err := ioutil.TempDir("", "")
+	as.Nil(err)
+	defer os.RemoveAll(testDir)
+	cpu := path.Join(testDir, "cpu", "docker", "abcd")
+	as.Nil(os.MkdirAll(cpu, 0755))
+	as.Nil(ioutil.WriteFile(path.Join(cpu, "cpu.shares"), []byte("1024"), 0644))
+
+	as.True(cgroupsReadable(map[string]string{"cpu": cpu}))
+	as.False(cgroupsReadable(map[string]string{"cpu": path.Join(testDir, "missing")}))
+	as.False(cgroupsReadable(map[string]string{"blkio": cpu}))
+}
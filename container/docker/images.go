@@ -0,0 +1,55 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/logging"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// Images lists the Docker images with their layers.
+func Images() ([]v2.Image, error) {
+	client, err := Client()
+	if err != nil {
+		return nil, fmt.Errorf("unable to communicate with docker daemon: %v", err)
+	}
+	apiImages, err := client.ListImages(docker.ListImagesOptions{All: false})
+	if err != nil {
+		return nil, err
+	}
+	images := make([]v2.Image, 0, len(apiImages))
+	for _, apiImage := range apiImages {
+		image := v2.Image{
+			Runtime:  DockerNamespace,
+			Id:       apiImage.ID,
+			RepoTags: apiImage.RepoTags,
+			Created:  time.Unix(apiImage.Created, 0),
+			Size:     apiImage.VirtualSize,
+		}
+		inspected, err := client.InspectImage(apiImage.ID)
+		if err != nil {
+			logging.V(2).Infof("Failed to inspect Docker image %q: %v", apiImage.ID, err)
+		} else if inspected.RootFS != nil {
+			image.Layers = len(inspected.RootFS.Layers)
+		}
+		images = append(images, image)
+	}
+	return images, nil
+}
@@ -0,0 +1,317 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Handlers for the containers of a remote Docker daemon, or whose cgroups
+// cannot be read, monitored through the Docker API instead of the cgroups.
+package docker
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/cadvisor/container"
+	info "github.com/google/cadvisor/info/v1"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// Remote containers are named "/docker/<id>". The remote factory also handles
+// the root container, whose subcontainers are the running containers.
+const remoteNamePrefix = "/docker/"
+
+// Whether all the containers are monitored through the Docker API, because
+// the daemon is remote or the cgroups cannot be read.
+var apiOnly bool
+
+type remoteFactory struct {
+	client *docker.Client
+
+	// Number of cpus and memory of the remote host.
+	numCores       int
+	memoryCapacity uint64
+
+	metadataEnvs  []string
+	ignoreMetrics container.MetricSet
+}
+
+func (self *remoteFactory) String() string {
+	return DockerNamespace
+}
+
+// Returns the ID of the remote container.
+func parseRemoteName(name string) (string, bool) {
+	if !strings.HasPrefix(name, remoteNamePrefix) {
+		return "", false
+	}
+	id := strings.TrimPrefix(name, remoteNamePrefix)
+	return id, id != "" && !strings.Contains(id, "/")
+}
+
+func (self *remoteFactory) NewContainerHandler(name string, inHostNamespace bool) (container.ContainerHandler, error) {
+	if name == "/" {
+		return &remoteRootHandler{factory: self}, nil
+	}
+	id, ok := parseRemoteName(name)
+	if !ok {
+		return nil, fmt.Errorf("invalid remote Docker container name %q", name)
+	}
+	return newRemoteContainerHandler(self, name, id)
+}
+
+func (self *remoteFactory) CanHandleAndAccept(name string) (bool, bool, error) {
+	if name == "/" {
+		return true, true, nil
+	}
+	if _, ok := parseRemoteName(name); !ok {
+		return false, false, nil
+	}
+	return true, true, nil
+}
+
+func (self *remoteFactory) DebugInfo() map[string][]string {
+	return map[string][]string{}
+}
+
+func newRemoteFactory(client *docker.Client, dockerInfo *docker.DockerInfo, ignoreMetrics container.MetricSet) *remoteFactory {
+	return &remoteFactory{
+		client:         client,
+		numCores:       dockerInfo.NCPU,
+		memoryCapacity: uint64(dockerInfo.MemTotal),
+		metadataEnvs:   strings.Split(*dockerEnvWhitelist, ","),
+		ignoreMetrics:  ignoreMetrics,
+	}
+}
+
+// Returns the spec of the remote host, that bounds the containers without
+// limits.
+func (self *remoteFactory) hostSpec() info.ContainerSpec {
+	spec := info.ContainerSpec{
+		HasCpu:    true,
+		HasMemory: true,
+	}
+	spec.Cpu.Limit = 1024
+	if self.numCores > 0 {
+		spec.Cpu.Mask = fmt.Sprintf("0-%d", self.numCores-1)
+	}
+	spec.Memory.Limit = self.memoryCapacity
+	return spec
+}
+
+type remoteContainerHandler struct {
+	factory      *remoteFactory
+	name         string
+	id           string
+	aliases      []string
+	creationTime time.Time
+	labels       map[string]string
+	envs         map[string]string
+	image        string
+}
+
+func newRemoteContainerHandler(factory *remoteFactory, name, id string) (container.ContainerHandler, error) {
+	ctnr, err := factory.client.InspectContainer(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container %q: %v", id, err)
+	}
+	handler := &remoteContainerHandler{
+		factory:      factory,
+		name:         name,
+		id:           id,
+		aliases:      []string{strings.TrimPrefix(ctnr.Name, "/"), id},
+		creationTime: ctnr.Created,
+		labels:       ctnr.Config.Labels,
+		envs:         make(map[string]string),
+		image:        ctnr.Config.Image,
+	}
+	for _, exposedEnv := range factory.metadataEnvs {
+		for _, envVar := range ctnr.Config.Env {
+			splits := strings.SplitN(envVar, "=", 2)
+			if splits[0] == exposedEnv {
+				handler.envs[strings.ToLower(exposedEnv)] = splits[1]
+			}
+		}
+	}
+	return handler, nil
+}
+
+func (self *remoteContainerHandler) Start() {}
+
+func (self *remoteContainerHandler) Cleanup() {}
+
+func (self *remoteContainerHandler) ContainerReference() (info.ContainerReference, error) {
+	return info.ContainerReference{
+		Id:        self.id,
+		Name:      self.name,
+		Aliases:   self.aliases,
+		Namespace: DockerNamespace,
+		Labels:    self.labels,
+		Envs:      self.envs,
+	}, nil
+}
+
+// The limits are read from the inspection of the container, which is
+// repeated so that changes of the limits are seen.
+func (self *remoteContainerHandler) GetSpec() (info.ContainerSpec, error) {
+	spec := self.factory.hostSpec()
+	spec.CreationTime = self.creationTime
+	spec.Labels = self.labels
+	spec.Envs = self.envs
+	spec.Image = self.image
+	spec.HasNetwork = !self.factory.ignoreMetrics.Has(container.NetworkUsageMetrics)
+	spec.HasDiskIo = !self.factory.ignoreMetrics.Has(container.DiskIOMetrics)
+
+	ctnr, err := self.factory.client.InspectContainer(self.id)
+	if err != nil {
+		return spec, fmt.Errorf("failed to inspect container %q: %v", self.id, err)
+	}
+	spec.RestartCount = ctnr.RestartCount
+	if ctnr.Config != nil && ctnr.Config.MemoryReservation > 0 {
+		spec.Memory.Reservation = uint64(ctnr.Config.MemoryReservation)
+	}
+	if hostConfig := ctnr.HostConfig; hostConfig != nil {
+		spec.RestartPolicy = hostConfig.RestartPolicy.Name
+		if hostConfig.NetworkMode == "host" || strings.HasPrefix(hostConfig.NetworkMode, "container:") {
+			spec.HasNetwork = false
+		}
+		if hostConfig.CPUShares > 0 {
+			spec.Cpu.Limit = uint64(hostConfig.CPUShares)
+		}
+		if hostConfig.CPUQuota > 0 {
+			spec.Cpu.Quota = uint64(hostConfig.CPUQuota)
+			spec.Cpu.Period = uint64(hostConfig.CPUPeriod)
+		}
+		if hostConfig.CPUSetCPUs != "" {
+			spec.Cpu.Mask = hostConfig.CPUSetCPUs
+		}
+		spec.Cpu.Mems = hostConfig.CPUSetMEMs
+		if hostConfig.Memory > 0 {
+			spec.Memory.Limit = uint64(hostConfig.Memory)
+		}
+		if hostConfig.MemorySwap > 0 {
+			spec.Memory.SwapLimit = uint64(hostConfig.MemorySwap)
+		}
+	}
+	return spec, nil
+}
+
+func (self *remoteContainerHandler) GetStats() (*info.ContainerStats, error) {
+	return getApiStats(self.factory.client, self.id, self.factory.ignoreMetrics)
+}
+
+func (self *remoteContainerHandler) ListContainers(listType container.ListType) ([]info.ContainerReference, error) {
+	// No-op for remote Docker containers.
+	return []info.ContainerReference{}, nil
+}
+
+func (self *remoteContainerHandler) GetCgroupPath(resource string) (string, error) {
+	return "", fmt.Errorf("the cgroups of remote Docker containers are not accessible")
+}
+
+func (self *remoteContainerHandler) ListThreads(listType container.ListType) ([]int, error) {
+	return nil, nil
+}
+
+func (self *remoteContainerHandler) GetContainerLabels() map[string]string {
+	return self.labels
+}
+
+func (self *remoteContainerHandler) ListProcesses(listType container.ListType) ([]int, error) {
+	return nil, nil
+}
+
+func (self *remoteContainerHandler) WatchSubcontainers(events chan container.SubcontainerEvent) error {
+	return fmt.Errorf("watch is unimplemented in the remote Docker container driver")
+}
+
+func (self *remoteContainerHandler) StopWatchingSubcontainers() error {
+	// No-op for remote Docker containers.
+	return nil
+}
+
+func (self *remoteContainerHandler) Exists() bool {
+	ctnr, err := self.factory.client.InspectContainer(self.id)
+	return err == nil && ctnr.State.Running
+}
+
+// Handler of the root container, whose subcontainers are the running
+// containers of the remote daemon. The Docker API has no stats of the host.
+type remoteRootHandler struct {
+	factory *remoteFactory
+}
+
+func (self *remoteRootHandler) Start() {}
+
+func (self *remoteRootHandler) Cleanup() {}
+
+func (self *remoteRootHandler) ContainerReference() (info.ContainerReference, error) {
+	return info.ContainerReference{
+		Name: "/",
+	}, nil
+}
+
+func (self *remoteRootHandler) GetSpec() (info.ContainerSpec, error) {
+	return self.factory.hostSpec(), nil
+}
+
+func (self *remoteRootHandler) GetStats() (*info.ContainerStats, error) {
+	return &info.ContainerStats{
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// Lists the running containers. They are discovered at each global
+// housekeeping, or when Docker reports them started with --docker_events.
+func (self *remoteRootHandler) ListContainers(listType container.ListType) ([]info.ContainerReference, error) {
+	ctnrs, err := self.factory.client.ListContainers(docker.ListContainersOptions{})
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]info.ContainerReference, 0, len(ctnrs))
+	for _, ctnr := range ctnrs {
+		refs = append(refs, info.ContainerReference{
+			Name: remoteNamePrefix + ctnr.ID,
+		})
+	}
+	return refs, nil
+}
+
+func (self *remoteRootHandler) GetCgroupPath(resource string) (string, error) {
+	return "", fmt.Errorf("the cgroups of the remote Docker host are not accessible")
+}
+
+func (self *remoteRootHandler) ListThreads(listType container.ListType) ([]int, error) {
+	return nil, nil
+}
+
+func (self *remoteRootHandler) GetContainerLabels() map[string]string {
+	return map[string]string{}
+}
+
+func (self *remoteRootHandler) ListProcesses(listType container.ListType) ([]int, error) {
+	return nil, nil
+}
+
+// Containers are polled by ListContainers.
+func (self *remoteRootHandler) WatchSubcontainers(events chan container.SubcontainerEvent) error {
+	return nil
+}
+
+func (self *remoteRootHandler) StopWatchingSubcontainers() error {
+	return nil
+}
+
+func (self *remoteRootHandler) Exists() bool {
+	return true
+}
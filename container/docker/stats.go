@@ -0,0 +1,153 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/cadvisor/container"
+	info "github.com/google/cadvisor/info/v1"
+
+	docker "github.com/fsouza/go-dockerclient"
+)
+
+// Timeout of the requests of the stats of containers to the Docker API.
+const statsTimeout = 10 * time.Second
+
+// Returns the stats of the container reported by the Docker stats API, for
+// containers whose cgroups cannot be read.
+func getApiStats(client *docker.Client, id string, ignoreMetrics container.MetricSet) (*info.ContainerStats, error) {
+	statsChan := make(chan *docker.Stats, 1)
+	errChan := make(chan error, 1)
+	go func() {
+		errChan <- client.Stats(docker.StatsOptions{
+			ID:      id,
+			Stats:   statsChan,
+			Stream:  false,
+			Timeout: statsTimeout,
+		})
+	}()
+	stats, ok := <-statsChan
+	if err := <-errChan; err != nil {
+		return nil, fmt.Errorf("failed to get the stats of container %q: %v", id, err)
+	}
+	if !ok || stats == nil {
+		return nil, fmt.Errorf("no stats for container %q", id)
+	}
+	return apiStatsToContainerStats(stats, ignoreMetrics), nil
+}
+
+// Converts stats of the Docker API to container stats. The Docker API reports
+// no load, task, filesystem or tcp stats.
+func apiStatsToContainerStats(stats *docker.Stats, ignoreMetrics container.MetricSet) *info.ContainerStats {
+	// The clock of a remote daemon may differ from the local one.
+	ret := &info.ContainerStats{
+		Timestamp: time.Now(),
+	}
+
+	cpu := stats.CPUStats
+	ret.Cpu.Usage.Total = cpu.CPUUsage.TotalUsage
+	ret.Cpu.Usage.PerCpu = cpu.CPUUsage.PercpuUsage
+	ret.Cpu.Usage.User = cpu.CPUUsage.UsageInUsermode
+	ret.Cpu.Usage.System = cpu.CPUUsage.UsageInKernelmode
+	ret.Cpu.Usage.Throttled = cpu.ThrottlingData.ThrottledTime
+
+	memory := stats.MemoryStats
+	ret.Memory.Usage = memory.Usage
+	ret.Memory.Failcnt = memory.Failcnt
+	ret.Memory.Cache = memory.Stats.Cache
+	ret.Memory.RSS = memory.Stats.Rss + memory.Stats.MappedFile
+	ret.Memory.ContainerData.Pgfault = memory.Stats.Pgfault
+	ret.Memory.ContainerData.Pgmajfault = memory.Stats.Pgmajfault
+	ret.Memory.HierarchicalData = ret.Memory.ContainerData
+	// Same as the working set read from the memory cgroup.
+	workingSet := memory.Usage
+	for _, inactive := range []uint64{memory.Stats.TotalInactiveAnon, memory.Stats.TotalInactiveFile} {
+		if workingSet < inactive {
+			workingSet = 0
+		} else {
+			workingSet -= inactive
+		}
+	}
+	ret.Memory.WorkingSet = workingSet
+
+	if !ignoreMetrics.Has(container.NetworkUsageMetrics) {
+		names := make([]string, 0, len(stats.Networks))
+		for name := range stats.Networks {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			network := stats.Networks[name]
+			ret.Network.Interfaces = append(ret.Network.Interfaces, info.InterfaceStats{
+				Name:      name,
+				RxBytes:   network.RxBytes,
+				RxPackets: network.RxPackets,
+				RxErrors:  network.RxErrors,
+				RxDropped: network.RxDropped,
+				TxBytes:   network.TxBytes,
+				TxPackets: network.TxPackets,
+				TxErrors:  network.TxErrors,
+				TxDropped: network.TxDropped,
+			})
+		}
+		// For backwards compatibility.
+		if len(ret.Network.Interfaces) > 0 {
+			ret.Network.InterfaceStats = ret.Network.Interfaces[0]
+		}
+	}
+
+	if !ignoreMetrics.Has(container.DiskIOMetrics) {
+		blkio := stats.BlkioStats
+		ret.DiskIo = info.DiskIoStats{
+			IoServiceBytes: toPerDiskStats(blkio.IOServiceBytesRecursive),
+			IoServiced:     toPerDiskStats(blkio.IOServicedRecursive),
+			IoQueued:       toPerDiskStats(blkio.IOQueueRecursive),
+			Sectors:        toPerDiskStats(blkio.SectorsRecursive),
+			IoServiceTime:  toPerDiskStats(blkio.IOServiceTimeRecursive),
+			IoWaitTime:     toPerDiskStats(blkio.IOWaitTimeRecursive),
+			IoMerged:       toPerDiskStats(blkio.IOMergedRecursive),
+			IoTime:         toPerDiskStats(blkio.IOTimeRecursive),
+		}
+	}
+	return ret
+}
+
+// Groups the blkio entries of the Docker API by device.
+func toPerDiskStats(entries []docker.BlkioStatsEntry) []info.PerDiskStats {
+	var disks []info.PerDiskStats
+	index := make(map[[2]uint64]int)
+	for _, entry := range entries {
+		device := [2]uint64{entry.Major, entry.Minor}
+		i, ok := index[device]
+		if !ok {
+			i = len(disks)
+			index[device] = i
+			disks = append(disks, info.PerDiskStats{
+				Major: entry.Major,
+				Minor: entry.Minor,
+				Stats: make(map[string]uint64),
+			})
+		}
+		op := entry.Op
+		if op == "" {
+			op = "Count"
+		}
+		disks[i].Stats[op] = entry.Value
+	}
+	return disks
+}
@@ -0,0 +1,82 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package docker
+
+import (
+	"testing"
+
+	"github.com/google/cadvisor/container"
+	info "github.com/google/cadvisor/info/v1"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiStatsToContainerStats(t *testing.T) {
+	stats := &docker.Stats{}
+	stats.CPUStats.CPUUsage.TotalUsage = 300
+	stats.CPUStats.CPUUsage.PercpuUsage = []uint64{100, 200}
+	stats.CPUStats.CPUUsage.UsageInUsermode = 120
+	stats.CPUStats.CPUUsage.UsageInKernelmode = 80
+	stats.MemoryStats.Usage = 1000
+	stats.MemoryStats.Stats.Rss = 400
+	stats.MemoryStats.Stats.MappedFile = 50
+	stats.MemoryStats.Stats.TotalInactiveFile = 300
+	stats.MemoryStats.Stats.TotalInactiveAnon = 100
+	stats.Networks = map[string]docker.NetworkStats{
+		"eth1": {RxBytes: 2},
+		"eth0": {RxBytes: 1, TxBytes: 3},
+	}
+	stats.BlkioStats.IOServiceBytesRecursive = []docker.BlkioStatsEntry{
+		{Major: 8, Minor: 0, Op: "Read", Value: 10},
+		{Major: 8, Minor: 0, Op: "Write", Value: 20},
+		{Major: 8, Minor: 16, Op: "Read", Value: 30},
+	}
+
+	ret := apiStatsToContainerStats(stats, container.MetricSet{})
+	assert.Equal(t, uint64(300), ret.Cpu.Usage.Total)
+	assert.Equal(t, []uint64{100, 200}, ret.Cpu.Usage.PerCpu)
+	assert.Equal(t, uint64(120), ret.Cpu.Usage.User)
+	assert.Equal(t, uint64(80), ret.Cpu.Usage.System)
+	assert.Equal(t, uint64(1000), ret.Memory.Usage)
+	assert.Equal(t, uint64(450), ret.Memory.RSS)
+	assert.Equal(t, uint64(600), ret.Memory.WorkingSet)
+	assert.Equal(t, []info.InterfaceStats{
+		{Name: "eth0", RxBytes: 1, TxBytes: 3},
+		{Name: "eth1", RxBytes: 2},
+	}, ret.Network.Interfaces)
+	assert.Equal(t, ret.Network.Interfaces[0], ret.Network.InterfaceStats)
+	assert.Equal(t, []info.PerDiskStats{
+		{Major: 8, Minor: 0, Stats: map[string]uint64{"Read": 10, "Write": 20}},
+		{Major: 8, Minor: 16, Stats: map[string]uint64{"Read": 30}},
+	}, ret.DiskIo.IoServiceBytes)
+	assert.False(t, ret.Timestamp.IsZero())
+
+	ignored := container.MetricSet{container.NetworkUsageMetrics: struct{}{}, container.DiskIOMetrics: struct{}{}}
+	ret = apiStatsToContainerStats(stats, ignored)
+	assert.Empty(t, ret.Network.Interfaces)
+	assert.Empty(t, ret.DiskIo.IoServiceBytes)
+}
+
+func TestParseRemoteName(t *testing.T) {
+	id, ok := parseRemoteName("/docker/abcd")
+	assert.True(t, ok)
+	assert.Equal(t, "abcd", id)
+
+	for _, name := range []string{"/", "/docker/", "/docker/abcd/efgh", "/system.slice/abcd"} {
+		_, ok := parseRemoteName(name)
+		assert.False(t, ok, name)
+	}
+}
@@ -18,7 +18,7 @@ import (
 	"fmt"
 	"sync"
 
-	"github.com/golang/glog"
+	"github.com/google/cadvisor/logging"
 )
 
 type ContainerHandlerFactory interface {
@@ -44,9 +44,11 @@ const (
 	CpuLoadMetrics         MetricKind = "cpuLoad"
 	DiskIOMetrics          MetricKind = "diskIO"
 	DiskUsageMetrics       MetricKind = "disk"
+	VolumeUsageMetrics     MetricKind = "volume"
 	NetworkUsageMetrics    MetricKind = "network"
 	NetworkTcpUsageMetrics MetricKind = "tcp"
 	AppMetrics             MetricKind = "app"
+	SchedulerMetrics       MetricKind = "sched"
 )
 
 func (mk MetricKind) String() string {
@@ -97,18 +99,18 @@ func NewContainerHandler(name string, inHostNamespace bool) (ContainerHandler, b
 	for _, factory := range factories {
 		canHandle, canAccept, err := factory.CanHandleAndAccept(name)
 		if err != nil {
-			glog.V(4).Infof("Error trying to work out if we can handle %s: %v", name, err)
+			logging.V(4).Infof("Error trying to work out if we can handle %s: %v", name, err)
 		}
 		if canHandle {
 			if !canAccept {
-				glog.V(3).Infof("Factory %q can handle container %q, but ignoring.", factory, name)
+				logging.V(3).Infof("Factory %q can handle container %q, but ignoring.", factory, name)
 				return nil, false, nil
 			}
-			glog.V(3).Infof("Using factory %q for container %q", factory, name)
+			logging.V(3).Infof("Using factory %q for container %q", factory, name)
 			handle, err := factory.NewContainerHandler(name, inHostNamespace)
 			return handle, canAccept, err
 		} else {
-			glog.V(4).Infof("Factory %q was unable to handle container %q", factory, name)
+			logging.V(4).Infof("Factory %q was unable to handle container %q", factory, name)
 		}
 	}
 
@@ -0,0 +1,203 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Config is the content of the --fake_containers file.
+type Config struct {
+	Containers []ContainerConfig `json:"containers"`
+}
+
+// ContainerConfig describes synthetic containers and the patterns of their
+// stats. The containers are named "/fake/<name>", or "/fake/<name>-<index>"
+// when there are several of them.
+type ContainerConfig struct {
+	Name string `json:"name"`
+	// Number of identical containers, 1 by default.
+	Count  int               `json:"count,omitempty"`
+	Image  string            `json:"image,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+	// Times after the start of cAdvisor at which the containers are created
+	// and exit, e.g. "30s". The containers are created at start and never
+	// exit by default.
+	Start string `json:"start,omitempty"`
+	Stop  string `json:"stop,omitempty"`
+	// Limits, in bytes. Zero for none.
+	MemoryLimit     uint64 `json:"memory_limit,omitempty"`
+	FilesystemLimit uint64 `json:"filesystem_limit,omitempty"`
+	// Patterns of the cpu usage in cores, of the memory and filesystem usage
+	// in bytes, and of the network throughput in bytes per second. The stats
+	// of the resources without a pattern are not collected.
+	Cpu        *PatternConfig `json:"cpu,omitempty"`
+	Memory     *PatternConfig `json:"memory,omitempty"`
+	Filesystem *PatternConfig `json:"filesystem,omitempty"`
+	NetworkRx  *PatternConfig `json:"network_rx,omitempty"`
+	NetworkTx  *PatternConfig `json:"network_tx,omitempty"`
+}
+
+// Shapes of patterns.
+const (
+	// Always Value.
+	ShapeConstant = "constant"
+	// From Min to Max linearly over each period.
+	ShapeRamp = "ramp"
+	// Sinusoid between Min and Max.
+	ShapeSine = "sine"
+	// Max during the first half of each period and Min during the second.
+	ShapeSquare = "square"
+	// Uniformly random between Min and Max at each collection.
+	ShapeRandom = "random"
+	// Each of Steps in turn for a period, cycling.
+	ShapeSteps = "steps"
+)
+
+// PatternConfig is a value varying over time.
+type PatternConfig struct {
+	Shape string    `json:"shape"`
+	Value float64   `json:"value,omitempty"`
+	Min   float64   `json:"min,omitempty"`
+	Max   float64   `json:"max,omitempty"`
+	Steps []float64 `json:"steps,omitempty"`
+	// Period of the pattern, e.g. "5m".
+	Period string `json:"period,omitempty"`
+}
+
+type pattern struct {
+	PatternConfig
+	period time.Duration
+}
+
+func newPattern(config *PatternConfig) (*pattern, error) {
+	if config == nil {
+		return nil, nil
+	}
+	p := &pattern{PatternConfig: *config}
+	switch config.Shape {
+	case ShapeConstant, ShapeRandom:
+	case ShapeRamp, ShapeSine, ShapeSquare, ShapeSteps:
+		period, err := time.ParseDuration(config.Period)
+		if err != nil || period <= 0 {
+			return nil, fmt.Errorf("invalid period %q of %s pattern", config.Period, config.Shape)
+		}
+		p.period = period
+	default:
+		return nil, fmt.Errorf("unknown pattern shape %q, expected one of %s", config.Shape, strings.Join([]string{ShapeConstant, ShapeRamp, ShapeSine, ShapeSquare, ShapeRandom, ShapeSteps}, ", "))
+	}
+	if config.Shape == ShapeSteps && len(config.Steps) == 0 {
+		return nil, fmt.Errorf("steps pattern without steps")
+	}
+	return p, nil
+}
+
+// Returns the value of the pattern at the given time since its start. Values
+// are never negative.
+func (self *pattern) value(elapsed time.Duration) float64 {
+	var value float64
+	switch self.Shape {
+	case ShapeConstant:
+		value = self.Value
+	case ShapeRamp:
+		value = self.Min + (self.Max-self.Min)*float64(elapsed%self.period)/float64(self.period)
+	case ShapeSine:
+		phase := 2 * math.Pi * float64(elapsed%self.period) / float64(self.period)
+		value = self.Min + (self.Max-self.Min)*(1+math.Sin(phase))/2
+	case ShapeSquare:
+		value = self.Min
+		if elapsed%self.period < self.period/2 {
+			value = self.Max
+		}
+	case ShapeRandom:
+		value = self.Min + (self.Max-self.Min)*rand.Float64()
+	case ShapeSteps:
+		value = self.Steps[int(elapsed/self.period)%len(self.Steps)]
+	}
+	return math.Max(value, 0)
+}
+
+// A synthetic container, parsed from its configuration.
+type containerConfig struct {
+	name   string
+	config *ContainerConfig
+	start  time.Duration
+	stop   time.Duration
+	// Nil for the resources without a pattern.
+	cpu, memory, filesystem, networkRx, networkTx *pattern
+}
+
+// Returns whether the container exists at the given time since the start.
+func (self *containerConfig) exists(elapsed time.Duration) bool {
+	return elapsed >= self.start && (self.stop == 0 || elapsed < self.stop)
+}
+
+func parseConfig(config *Config) ([]*containerConfig, error) {
+	var containers []*containerConfig
+	names := make(map[string]bool)
+	for i := range config.Containers {
+		c := &config.Containers[i]
+		if c.Name == "" || strings.Contains(c.Name, "/") {
+			return nil, fmt.Errorf("invalid fake container name %q", c.Name)
+		}
+		parsed := &containerConfig{config: c}
+		var err error
+		for _, d := range []struct {
+			value  string
+			parsed *time.Duration
+		}{{c.Start, &parsed.start}, {c.Stop, &parsed.stop}} {
+			if d.value == "" {
+				continue
+			}
+			if *d.parsed, err = time.ParseDuration(d.value); err != nil {
+				return nil, fmt.Errorf("invalid time %q of fake container %q: %v", d.value, c.Name, err)
+			}
+		}
+		if parsed.stop != 0 && parsed.stop <= parsed.start {
+			return nil, fmt.Errorf("fake container %q stops before it starts", c.Name)
+		}
+		for _, p := range []struct {
+			config *PatternConfig
+			parsed **pattern
+		}{{c.Cpu, &parsed.cpu}, {c.Memory, &parsed.memory}, {c.Filesystem, &parsed.filesystem}, {c.NetworkRx, &parsed.networkRx}, {c.NetworkTx, &parsed.networkTx}} {
+			if *p.parsed, err = newPattern(p.config); err != nil {
+				return nil, fmt.Errorf("fake container %q: %v", c.Name, err)
+			}
+		}
+
+		count := c.Count
+		if count == 0 {
+			count = 1
+		}
+		for index := 0; index < count; index++ {
+			container := *parsed
+			if count > 1 {
+				container.name = fmt.Sprintf("%s-%d", c.Name, index)
+			} else {
+				container.name = c.Name
+			}
+			if names[container.name] {
+				return nil, fmt.Errorf("duplicate fake container %q", container.name)
+			}
+			names[container.name] = true
+			containers = append(containers, &container)
+		}
+	}
+	return containers, nil
+}
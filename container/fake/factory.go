@@ -0,0 +1,133 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fake implements synthetic containers with scripted stats, to test
+// the consumers of the API and of the storage drivers without a container
+// runtime.
+package fake
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/google/cadvisor/container"
+	"github.com/google/cadvisor/fs"
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
+)
+
+var argConfigFile = flag.String("fake_containers", "", "JSON file of synthetic containers with scripted stats, replacing the containers of the host for testing. Empty disables them")
+
+const FakeNamespace = "fake"
+
+// Fake containers are named "/fake/<name>". The factory also handles the root
+// container, whose subcontainers are the existing fake containers.
+const namePrefix = "/fake/"
+
+type fakeFactory struct {
+	machineInfoFactory info.MachineInfoFactory
+	containers         map[string]*containerConfig
+	// Time the containers are scheduled from.
+	start time.Time
+	now   func() time.Time
+}
+
+func newFakeFactory(config *Config, machineInfoFactory info.MachineInfoFactory, now func() time.Time) (*fakeFactory, error) {
+	containers, err := parseConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	self := &fakeFactory{
+		machineInfoFactory: machineInfoFactory,
+		containers:         make(map[string]*containerConfig, len(containers)),
+		start:              now(),
+		now:                now,
+	}
+	for _, c := range containers {
+		self.containers[namePrefix+c.name] = c
+	}
+	return self, nil
+}
+
+func (self *fakeFactory) String() string {
+	return FakeNamespace
+}
+
+// Returns the time elapsed since the start of the fake containers.
+func (self *fakeFactory) elapsed() time.Duration {
+	return self.now().Sub(self.start)
+}
+
+func (self *fakeFactory) NewContainerHandler(name string, inHostNamespace bool) (container.ContainerHandler, error) {
+	if name == "/" {
+		return newRootHandler(self), nil
+	}
+	c, ok := self.containers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown fake container %q", name)
+	}
+	return newFakeContainerHandler(self, c), nil
+}
+
+func (self *fakeFactory) CanHandleAndAccept(name string) (bool, bool, error) {
+	if name == "/" {
+		return true, true, nil
+	}
+	if !strings.HasPrefix(name, namePrefix) {
+		return false, false, nil
+	}
+	_, ok := self.containers[name]
+	return true, ok, nil
+}
+
+func (self *fakeFactory) DebugInfo() map[string][]string {
+	names := make([]string, 0, len(self.containers))
+	for name := range self.containers {
+		names = append(names, name)
+	}
+	return map[string][]string{"Fake containers": names}
+}
+
+// Enabled returns whether fake containers replace the containers of the host.
+func Enabled() bool {
+	return *argConfigFile != ""
+}
+
+// Register registers the fake container factory if --fake_containers is set.
+// It must be registered first, to handle the root container.
+func Register(factory info.MachineInfoFactory, fsInfo fs.FsInfo, ignoreMetrics container.MetricSet) error {
+	if !Enabled() {
+		return fmt.Errorf("fake containers are disabled")
+	}
+	data, err := ioutil.ReadFile(*argConfigFile)
+	if err != nil {
+		return fmt.Errorf("failed to read fake containers: %v", err)
+	}
+	config := &Config{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return fmt.Errorf("failed to parse fake containers: %v", err)
+	}
+	f, err := newFakeFactory(config, factory, time.Now)
+	if err != nil {
+		return err
+	}
+
+	logging.Infof("Registering fake factory with %d containers", len(f.containers))
+	container.RegisterContainerHandlerFactory(f)
+	return nil
+}
@@ -0,0 +1,151 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fake
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	info "github.com/google/cadvisor/info/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeMachineInfoFactory struct{}
+
+func (fakeMachineInfoFactory) GetMachineInfo() (*info.MachineInfo, error) {
+	return &info.MachineInfo{NumCores: 2, MemoryCapacity: 1 << 30}, nil
+}
+
+func (fakeMachineInfoFactory) GetVersionInfo() (*info.VersionInfo, error) {
+	return &info.VersionInfo{}, nil
+}
+
+func TestPatterns(t *testing.T) {
+	for _, test := range []struct {
+		config   PatternConfig
+		elapsed  time.Duration
+		expected float64
+	}{
+		{PatternConfig{Shape: ShapeConstant, Value: 3}, time.Hour, 3},
+		{PatternConfig{Shape: ShapeRamp, Min: 10, Max: 20, Period: "1m"}, 90 * time.Second, 15},
+		{PatternConfig{Shape: ShapeSine, Min: 0, Max: 2, Period: "1m"}, 15 * time.Second, 2},
+		{PatternConfig{Shape: ShapeSquare, Min: 1, Max: 5, Period: "1m"}, 10 * time.Second, 5},
+		{PatternConfig{Shape: ShapeSquare, Min: 1, Max: 5, Period: "1m"}, 40 * time.Second, 1},
+		{PatternConfig{Shape: ShapeSteps, Steps: []float64{1, 2, 3}, Period: "10s"}, 45 * time.Second, 2},
+		{PatternConfig{Shape: ShapeRandom, Min: 4, Max: 4}, time.Minute, 4},
+		// Values are never negative.
+		{PatternConfig{Shape: ShapeConstant, Value: -1}, 0, 0},
+	} {
+		p, err := newPattern(&test.config)
+		require.NoError(t, err)
+		assert.InDelta(t, test.expected, p.value(test.elapsed), 1e-9, "%+v", test.config)
+	}
+
+	for _, config := range []PatternConfig{{Shape: "triangle"}, {Shape: ShapeRamp}, {Shape: ShapeSteps, Period: "1s"}} {
+		_, err := newPattern(&config)
+		assert.Error(t, err, "%+v", config)
+	}
+}
+
+func TestFakeContainers(t *testing.T) {
+	var config Config
+	require.NoError(t, json.Unmarshal([]byte(`{"containers": [
+		{"name": "web", "count": 2, "image": "nginx", "labels": {"app": "web"},
+		 "cpu": {"shape": "constant", "value": 0.5},
+		 "network_rx": {"shape": "constant", "value": 1000},
+		 "memory": {"shape": "ramp", "min": 0, "max": 2048, "period": "1m"}, "memory_limit": 1024},
+		{"name": "job", "start": "1m", "stop": "2m", "filesystem": {"shape": "constant", "value": 100}, "filesystem_limit": 1000}
+	]}`), &config))
+
+	now := time.Unix(1000, 0)
+	f, err := newFakeFactory(&config, fakeMachineInfoFactory{}, func() time.Time { return now })
+	require.NoError(t, err)
+
+	handle, accept, err := f.CanHandleAndAccept("/fake/web-1")
+	assert.True(t, handle && accept)
+	assert.NoError(t, err)
+	handle, accept, _ = f.CanHandleAndAccept("/fake/web")
+	assert.True(t, handle && !accept)
+	handle, _, _ = f.CanHandleAndAccept("/docker/web")
+	assert.False(t, handle)
+
+	root, err := f.NewContainerHandler("/", true)
+	require.NoError(t, err)
+	list := func() []string {
+		refs, err := root.ListContainers(0)
+		require.NoError(t, err)
+		var names []string
+		for _, ref := range refs {
+			names = append(names, ref.Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"/fake/web-0", "/fake/web-1"}, list())
+
+	web, err := f.NewContainerHandler("/fake/web-0", true)
+	require.NoError(t, err)
+	ref, err := web.ContainerReference()
+	require.NoError(t, err)
+	assert.Equal(t, info.ContainerReference{Name: "/fake/web-0", Aliases: []string{"web-0"}, Namespace: FakeNamespace, Labels: map[string]string{"app": "web"}}, ref)
+	spec, err := web.GetSpec()
+	require.NoError(t, err)
+	assert.True(t, spec.HasCpu && spec.HasMemory && spec.HasNetwork)
+	assert.False(t, spec.HasFilesystem)
+	assert.Equal(t, "nginx", spec.Image)
+
+	// Counters integrate the rates since the previous collection.
+	now = now.Add(10 * time.Second)
+	stats, err := web.GetStats()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5*time.Second), stats.Cpu.Usage.Total)
+	assert.Equal(t, uint64(10000), stats.Network.RxBytes)
+	assert.Equal(t, uint64(0), stats.Network.TxBytes)
+	now = now.Add(20 * time.Second)
+	stats, err = web.GetStats()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(15*time.Second), stats.Cpu.Usage.Total)
+	assert.Equal(t, uint64(30000), stats.Network.RxBytes)
+	// Half way through the ramp, capped by the limit.
+	assert.Equal(t, uint64(1024), stats.Memory.Usage)
+
+	// The job only exists between its start and stop times.
+	job, err := f.NewContainerHandler("/fake/job", true)
+	require.NoError(t, err)
+	assert.False(t, job.Exists())
+	now = now.Add(45 * time.Second)
+	assert.True(t, job.Exists())
+	assert.Equal(t, []string{"/fake/job", "/fake/web-0", "/fake/web-1"}, list())
+	stats, err = job.GetStats()
+	require.NoError(t, err)
+	assert.Equal(t, []info.FsStats{{Device: fakeDevice, Type: "vfs", Limit: 1000, Usage: 100, BaseUsage: 100, Available: 900}}, stats.Filesystem)
+	now = now.Add(time.Minute)
+	assert.False(t, job.Exists())
+	assert.Equal(t, []string{"/fake/web-0", "/fake/web-1"}, list())
+
+	for _, invalid := range []string{
+		`{"containers": [{"name": "a/b"}]}`,
+		`{"containers": [{"name": "a"}, {"name": "a"}]}`,
+		`{"containers": [{"name": "a", "start": "2m", "stop": "1m"}]}`,
+		`{"containers": [{"name": "a", "cpu": {"shape": "sine"}}]}`,
+	} {
+		var config Config
+		require.NoError(t, json.Unmarshal([]byte(invalid), &config))
+		_, err := newFakeFactory(&config, fakeMachineInfoFactory{}, time.Now)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,271 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Handler for fake containers.
+package fake
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/cadvisor/container"
+	info "github.com/google/cadvisor/info/v1"
+)
+
+// Name of the network interface and device of the filesystem of fake
+// containers.
+const (
+	fakeInterface = "eth0"
+	fakeDevice    = "/dev/fake"
+)
+
+// Generates the stats of a fake container from the patterns of its
+// configuration. The cumulative counters integrate the rates of the patterns
+// between collections.
+type fakeContainerHandler struct {
+	factory *fakeFactory
+	config  *containerConfig
+
+	lock sync.Mutex
+	// Time of the last collection, and the counters at that time.
+	lastCollection time.Time
+	cpuUsage       float64
+	rxBytes        float64
+	txBytes        float64
+}
+
+func newFakeContainerHandler(factory *fakeFactory, config *containerConfig) container.ContainerHandler {
+	return &fakeContainerHandler{
+		factory: factory,
+		config:  config,
+		// The counters start at the creation of the container.
+		lastCollection: factory.start.Add(config.start),
+	}
+}
+
+func (self *fakeContainerHandler) Start() {}
+
+func (self *fakeContainerHandler) Cleanup() {}
+
+func (self *fakeContainerHandler) ContainerReference() (info.ContainerReference, error) {
+	return info.ContainerReference{
+		Name:      namePrefix + self.config.name,
+		Aliases:   []string{self.config.name},
+		Namespace: FakeNamespace,
+		Labels:    self.config.config.Labels,
+	}, nil
+}
+
+func (self *fakeContainerHandler) GetSpec() (info.ContainerSpec, error) {
+	spec := info.ContainerSpec{
+		CreationTime:  self.factory.start.Add(self.config.start),
+		Labels:        self.config.config.Labels,
+		Image:         self.config.config.Image,
+		HasCpu:        self.config.cpu != nil,
+		HasMemory:     self.config.memory != nil,
+		HasNetwork:    self.config.networkRx != nil || self.config.networkTx != nil,
+		HasFilesystem: self.config.filesystem != nil,
+	}
+	spec.Cpu.Limit = 1024
+	spec.Memory.Limit = self.config.config.MemoryLimit
+	return spec, nil
+}
+
+func (self *fakeContainerHandler) GetStats() (*info.ContainerStats, error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+
+	now := self.factory.now()
+	// The patterns start at the creation of the container.
+	elapsed := now.Sub(self.factory.start) - self.config.start
+	interval := now.Sub(self.lastCollection).Seconds()
+	if interval < 0 {
+		interval = 0
+	}
+	self.lastCollection = now
+
+	stats := &info.ContainerStats{Timestamp: now}
+	if self.config.cpu != nil {
+		self.cpuUsage += self.config.cpu.value(elapsed) * interval * float64(time.Second)
+		stats.Cpu.Usage.Total = uint64(self.cpuUsage)
+		// A fifth of the cpu time is spent in the kernel.
+		stats.Cpu.Usage.System = stats.Cpu.Usage.Total / 5
+		stats.Cpu.Usage.User = stats.Cpu.Usage.Total - stats.Cpu.Usage.System
+		stats.Cpu.Usage.PerCpu = []uint64{stats.Cpu.Usage.Total}
+	}
+	if self.config.memory != nil {
+		stats.Memory.Usage = uint64(self.config.memory.value(elapsed))
+		if limit := self.config.config.MemoryLimit; limit > 0 && stats.Memory.Usage > limit {
+			stats.Memory.Usage = limit
+		}
+		stats.Memory.WorkingSet = stats.Memory.Usage
+		stats.Memory.RSS = stats.Memory.Usage
+	}
+	if self.config.networkRx != nil || self.config.networkTx != nil {
+		if self.config.networkRx != nil {
+			self.rxBytes += self.config.networkRx.value(elapsed) * interval
+		}
+		if self.config.networkTx != nil {
+			self.txBytes += self.config.networkTx.value(elapsed) * interval
+		}
+		iface := info.InterfaceStats{
+			Name:    fakeInterface,
+			RxBytes: uint64(self.rxBytes),
+			TxBytes: uint64(self.txBytes),
+		}
+		// Full sized packets.
+		iface.RxPackets = iface.RxBytes / 1500
+		iface.TxPackets = iface.TxBytes / 1500
+		stats.Network.InterfaceStats = iface
+		stats.Network.Interfaces = []info.InterfaceStats{iface}
+	}
+	if self.config.filesystem != nil {
+		fsStats := info.FsStats{
+			Device: fakeDevice,
+			Type:   "vfs",
+			Limit:  self.config.config.FilesystemLimit,
+			Usage:  uint64(self.config.filesystem.value(elapsed)),
+		}
+		if fsStats.Limit > 0 && fsStats.Usage > fsStats.Limit {
+			fsStats.Usage = fsStats.Limit
+		}
+		fsStats.BaseUsage = fsStats.Usage
+		if fsStats.Limit > 0 {
+			fsStats.Available = fsStats.Limit - fsStats.Usage
+		}
+		stats.Filesystem = []info.FsStats{fsStats}
+	}
+	return stats, nil
+}
+
+func (self *fakeContainerHandler) ListContainers(listType container.ListType) ([]info.ContainerReference, error) {
+	return []info.ContainerReference{}, nil
+}
+
+func (self *fakeContainerHandler) GetCgroupPath(resource string) (string, error) {
+	return "", fmt.Errorf("fake containers have no cgroups")
+}
+
+func (self *fakeContainerHandler) ListThreads(listType container.ListType) ([]int, error) {
+	return nil, nil
+}
+
+func (self *fakeContainerHandler) GetContainerLabels() map[string]string {
+	return self.config.config.Labels
+}
+
+func (self *fakeContainerHandler) ListProcesses(listType container.ListType) ([]int, error) {
+	return nil, nil
+}
+
+func (self *fakeContainerHandler) WatchSubcontainers(events chan container.SubcontainerEvent) error {
+	return fmt.Errorf("fake containers have no subcontainers")
+}
+
+func (self *fakeContainerHandler) StopWatchingSubcontainers() error {
+	return nil
+}
+
+func (self *fakeContainerHandler) Exists() bool {
+	return self.config.exists(self.factory.elapsed())
+}
+
+// Handler of the root container, whose subcontainers are the existing fake
+// containers.
+type rootHandler struct {
+	factory *fakeFactory
+}
+
+func newRootHandler(factory *fakeFactory) container.ContainerHandler {
+	return &rootHandler{
+		factory: factory,
+	}
+}
+
+func (self *rootHandler) Start() {}
+
+func (self *rootHandler) Cleanup() {}
+
+func (self *rootHandler) ContainerReference() (info.ContainerReference, error) {
+	return info.ContainerReference{
+		Name: "/",
+	}, nil
+}
+
+func (self *rootHandler) GetSpec() (info.ContainerSpec, error) {
+	spec := info.ContainerSpec{}
+	if machineInfo, err := self.factory.machineInfoFactory.GetMachineInfo(); err == nil {
+		spec.Cpu.Limit = 1024
+		spec.Cpu.Mask = fmt.Sprintf("0-%d", machineInfo.NumCores-1)
+		spec.Memory.Limit = machineInfo.MemoryCapacity
+	}
+	return spec, nil
+}
+
+func (self *rootHandler) GetStats() (*info.ContainerStats, error) {
+	return &info.ContainerStats{
+		Timestamp: self.factory.now(),
+	}, nil
+}
+
+// Lists the existing fake containers. They are created and exit as scheduled,
+// discovered at each global housekeeping.
+func (self *rootHandler) ListContainers(listType container.ListType) ([]info.ContainerReference, error) {
+	elapsed := self.factory.elapsed()
+	var names []string
+	for name, c := range self.factory.containers {
+		if c.exists(elapsed) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	refs := make([]info.ContainerReference, 0, len(names))
+	for _, name := range names {
+		refs = append(refs, info.ContainerReference{
+			Name: name,
+		})
+	}
+	return refs, nil
+}
+
+func (self *rootHandler) GetCgroupPath(resource string) (string, error) {
+	return "", fmt.Errorf("fake containers have no cgroups")
+}
+
+func (self *rootHandler) ListThreads(listType container.ListType) ([]int, error) {
+	return nil, nil
+}
+
+func (self *rootHandler) GetContainerLabels() map[string]string {
+	return map[string]string{}
+}
+
+func (self *rootHandler) ListProcesses(listType container.ListType) ([]int, error) {
+	return nil, nil
+}
+
+// Fake containers are polled by ListContainers.
+func (self *rootHandler) WatchSubcontainers(events chan container.SubcontainerEvent) error {
+	return nil
+}
+
+func (self *rootHandler) StopWatchingSubcontainers() error {
+	return nil
+}
+
+func (self *rootHandler) Exists() bool {
+	return true
+}
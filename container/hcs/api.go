@@ -0,0 +1,163 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Compute systems of the Windows Host Compute Service (HCS) and their stats.
+package hcs
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	info "github.com/google/cadvisor/info/v1"
+)
+
+// Queries of the version 1 schema of HCS.
+const (
+	containersQuery = `{"Types":["Container"]}`
+	statisticsQuery = `{"PropertyTypes":["Statistics"]}`
+)
+
+// HCS counts time in units of 100ns.
+const hundredNanoseconds = 100
+
+// Properties of a compute system.
+type ComputeSystem struct {
+	Id         string `json:"Id"`
+	Name       string `json:"Name,omitempty"`
+	SystemType string `json:"SystemType,omitempty"`
+	// Tool that created the compute system, e.g. "docker".
+	Owner      string     `json:"Owner,omitempty"`
+	Stopped    bool       `json:"Stopped,omitempty"`
+	Statistics Statistics `json:"Statistics,omitempty"`
+}
+
+type Statistics struct {
+	Timestamp          time.Time      `json:"Timestamp,omitempty"`
+	ContainerStartTime time.Time      `json:"ContainerStartTime,omitempty"`
+	Processor          ProcessorStats `json:"Processor,omitempty"`
+	Memory             MemoryStats    `json:"Memory,omitempty"`
+	Storage            StorageStats   `json:"Storage,omitempty"`
+	Network            []NetworkStats `json:"Network,omitempty"`
+}
+
+type ProcessorStats struct {
+	TotalRuntime100ns  uint64 `json:"TotalRuntime100ns,omitempty"`
+	RuntimeUser100ns   uint64 `json:"RuntimeUser100ns,omitempty"`
+	RuntimeKernel100ns uint64 `json:"RuntimeKernel100ns,omitempty"`
+}
+
+type MemoryStats struct {
+	UsageCommitBytes            uint64 `json:"UsageCommitBytes,omitempty"`
+	UsageCommitPeakBytes        uint64 `json:"UsageCommitPeakBytes,omitempty"`
+	UsagePrivateWorkingSetBytes uint64 `json:"UsagePrivateWorkingSetBytes,omitempty"`
+}
+
+type StorageStats struct {
+	ReadCountNormalized  uint64 `json:"ReadCountNormalized,omitempty"`
+	ReadSizeBytes        uint64 `json:"ReadSizeBytes,omitempty"`
+	WriteCountNormalized uint64 `json:"WriteCountNormalized,omitempty"`
+	WriteSizeBytes       uint64 `json:"WriteSizeBytes,omitempty"`
+}
+
+type NetworkStats struct {
+	EndpointId             string `json:"EndpointId,omitempty"`
+	BytesReceived          uint64 `json:"BytesReceived,omitempty"`
+	BytesSent              uint64 `json:"BytesSent,omitempty"`
+	PacketsReceived        uint64 `json:"PacketsReceived,omitempty"`
+	PacketsSent            uint64 `json:"PacketsSent,omitempty"`
+	DroppedPacketsIncoming uint64 `json:"DroppedPacketsIncoming,omitempty"`
+	DroppedPacketsOutgoing uint64 `json:"DroppedPacketsOutgoing,omitempty"`
+}
+
+// Lists the running containers of HCS.
+func listComputeSystems() ([]ComputeSystem, error) {
+	out, err := enumerateComputeSystems(containersQuery)
+	if err != nil {
+		return nil, err
+	}
+	var systems []ComputeSystem
+	if err := json.Unmarshal(out, &systems); err != nil {
+		return nil, fmt.Errorf("failed to decode compute systems: %v", err)
+	}
+	running := systems[:0]
+	for _, system := range systems {
+		if !system.Stopped {
+			running = append(running, system)
+		}
+	}
+	return running, nil
+}
+
+// Returns the compute system with its statistics.
+func getComputeSystem(id string) (*ComputeSystem, error) {
+	out, err := computeSystemProperties(id, statisticsQuery)
+	if err != nil {
+		return nil, err
+	}
+	system := &ComputeSystem{}
+	if err := json.Unmarshal(out, system); err != nil {
+		return nil, fmt.Errorf("failed to decode properties of compute system %q: %v", id, err)
+	}
+	return system, nil
+}
+
+// Maps the HCS statistics into container stats.
+func toContainerStats(s *Statistics) *info.ContainerStats {
+	stats := &info.ContainerStats{
+		Timestamp: s.Timestamp,
+	}
+	if stats.Timestamp.IsZero() {
+		stats.Timestamp = time.Now()
+	}
+
+	stats.Cpu.Usage.Total = s.Processor.TotalRuntime100ns * hundredNanoseconds
+	stats.Cpu.Usage.User = s.Processor.RuntimeUser100ns * hundredNanoseconds
+	stats.Cpu.Usage.System = s.Processor.RuntimeKernel100ns * hundredNanoseconds
+
+	stats.Memory.Usage = s.Memory.UsageCommitBytes
+	stats.Memory.WorkingSet = s.Memory.UsagePrivateWorkingSetBytes
+
+	// HCS does not break storage down by device.
+	stats.DiskIo.IoServiceBytes = []info.PerDiskStats{{
+		Stats: map[string]uint64{
+			"Read":  s.Storage.ReadSizeBytes,
+			"Write": s.Storage.WriteSizeBytes,
+			"Total": s.Storage.ReadSizeBytes + s.Storage.WriteSizeBytes,
+		},
+	}}
+	stats.DiskIo.IoServiced = []info.PerDiskStats{{
+		Stats: map[string]uint64{
+			"Read":  s.Storage.ReadCountNormalized,
+			"Write": s.Storage.WriteCountNormalized,
+			"Total": s.Storage.ReadCountNormalized + s.Storage.WriteCountNormalized,
+		},
+	}}
+
+	for _, endpoint := range s.Network {
+		stats.Network.Interfaces = append(stats.Network.Interfaces, info.InterfaceStats{
+			Name:      endpoint.EndpointId,
+			RxBytes:   endpoint.BytesReceived,
+			RxPackets: endpoint.PacketsReceived,
+			RxDropped: endpoint.DroppedPacketsIncoming,
+			TxBytes:   endpoint.BytesSent,
+			TxPackets: endpoint.PacketsSent,
+			TxDropped: endpoint.DroppedPacketsOutgoing,
+		})
+	}
+	if len(stats.Network.Interfaces) > 0 {
+		stats.Network.InterfaceStats = stats.Network.Interfaces[0]
+	}
+	return stats
+}
@@ -0,0 +1,72 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hcs
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const testProperties = `{
+	"Id": "abc",
+	"SystemType": "Container",
+	"Owner": "docker",
+	"Statistics": {
+		"Timestamp": "2016-05-01T10:00:00Z",
+		"ContainerStartTime": "2016-05-01T09:00:00Z",
+		"Processor": {"TotalRuntime100ns": 30, "RuntimeUser100ns": 20, "RuntimeKernel100ns": 10},
+		"Memory": {"UsageCommitBytes": 1000, "UsageCommitPeakBytes": 2000, "UsagePrivateWorkingSetBytes": 500},
+		"Storage": {"ReadCountNormalized": 1, "ReadSizeBytes": 4096, "WriteCountNormalized": 2, "WriteSizeBytes": 8192},
+		"Network": [{"EndpointId": "ep1", "BytesReceived": 10, "BytesSent": 20, "PacketsReceived": 1, "PacketsSent": 2, "DroppedPacketsIncoming": 3, "DroppedPacketsOutgoing": 4}]
+	}
+}`
+
+func TestToContainerStats(t *testing.T) {
+	system := &ComputeSystem{}
+	if err := json.Unmarshal([]byte(testProperties), system); err != nil {
+		t.Fatal(err)
+	}
+	stats := toContainerStats(&system.Statistics)
+	if stats.Timestamp != system.Statistics.Timestamp || stats.Timestamp.IsZero() {
+		t.Errorf("unexpected timestamp %v", stats.Timestamp)
+	}
+	if stats.Cpu.Usage.Total != 3000 || stats.Cpu.Usage.User != 2000 || stats.Cpu.Usage.System != 1000 {
+		t.Errorf("unexpected cpu usage %+v", stats.Cpu.Usage)
+	}
+	if stats.Memory.Usage != 1000 || stats.Memory.WorkingSet != 500 {
+		t.Errorf("unexpected memory stats %+v", stats.Memory)
+	}
+	if total := stats.DiskIo.IoServiceBytes[0].Stats["Total"]; total != 12288 {
+		t.Errorf("expected 12288 bytes of storage io, got %d", total)
+	}
+	if total := stats.DiskIo.IoServiced[0].Stats["Total"]; total != 3 {
+		t.Errorf("expected 3 storage operations, got %d", total)
+	}
+	network := stats.Network.InterfaceStats
+	if len(stats.Network.Interfaces) != 1 || network.Name != "ep1" || network.RxBytes != 10 || network.TxBytes != 20 || network.RxDropped != 3 || network.TxDropped != 4 {
+		t.Errorf("unexpected network stats %+v", stats.Network)
+	}
+}
+
+func TestParseName(t *testing.T) {
+	if id, ok := parseName("/hcs/abc"); !ok || id != "abc" {
+		t.Errorf("expected ID abc, got %q", id)
+	}
+	for _, name := range []string{"/", "/hcs/", "/docker/abc", "/hcs/abc/def"} {
+		if _, ok := parseName(name); ok {
+			t.Errorf("unexpected HCS container name %q", name)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hcs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/cadvisor/container"
+	"github.com/google/cadvisor/fs"
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
+)
+
+const HcsNamespace = "hcs"
+
+// Containers of HCS are named "/hcs/<id>". Windows has no cgroups, so the
+// factory also handles the root container, whose subcontainers are the
+// running compute systems.
+const namePrefix = "/hcs/"
+
+type hcsFactory struct {
+	machineInfoFactory info.MachineInfoFactory
+
+	ignoreMetrics container.MetricSet
+}
+
+func (self *hcsFactory) String() string {
+	return HcsNamespace
+}
+
+// Returns the ID of the compute system of the container.
+func parseName(name string) (string, bool) {
+	if !strings.HasPrefix(name, namePrefix) {
+		return "", false
+	}
+	id := strings.TrimPrefix(name, namePrefix)
+	return id, id != "" && !strings.Contains(id, "/")
+}
+
+func (self *hcsFactory) NewContainerHandler(name string, inHostNamespace bool) (container.ContainerHandler, error) {
+	if name == "/" {
+		return newRootHandler(self.machineInfoFactory), nil
+	}
+	id, ok := parseName(name)
+	if !ok {
+		return nil, fmt.Errorf("invalid HCS container name %q", name)
+	}
+	return newHcsContainerHandler(name, id, self.machineInfoFactory, self.ignoreMetrics)
+}
+
+func (self *hcsFactory) CanHandleAndAccept(name string) (bool, bool, error) {
+	if name == "/" {
+		return true, true, nil
+	}
+	if _, ok := parseName(name); !ok {
+		return false, false, nil
+	}
+	return true, true, nil
+}
+
+func (self *hcsFactory) DebugInfo() map[string][]string {
+	return map[string][]string{}
+}
+
+// Register registers the HCS container factory on Windows hosts running the
+// Host Compute Service.
+func Register(factory info.MachineInfoFactory, fsInfo fs.FsInfo, ignoreMetrics container.MetricSet) error {
+	if err := available(); err != nil {
+		return err
+	}
+	if _, err := listComputeSystems(); err != nil {
+		return fmt.Errorf("unable to list HCS compute systems: %v", err)
+	}
+
+	logging.Infof("Registering HCS factory")
+	f := &hcsFactory{
+		machineInfoFactory: factory,
+		ignoreMetrics:      ignoreMetrics,
+	}
+	container.RegisterContainerHandlerFactory(f)
+	return nil
+}
@@ -0,0 +1,236 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Handler for the containers of the Windows Host Compute Service.
+package hcs
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/cadvisor/container"
+	info "github.com/google/cadvisor/info/v1"
+)
+
+// Labels of HCS containers.
+const (
+	ownerLabel      = "hcs.owner"
+	systemTypeLabel = "hcs.system_type"
+)
+
+type hcsContainerHandler struct {
+	name               string
+	id                 string
+	aliases            []string
+	creationTime       time.Time
+	labels             map[string]string
+	machineInfoFactory info.MachineInfoFactory
+	ignoreMetrics      container.MetricSet
+}
+
+func newHcsContainerHandler(
+	name string,
+	id string,
+	machineInfoFactory info.MachineInfoFactory,
+	ignoreMetrics container.MetricSet,
+) (container.ContainerHandler, error) {
+	system, err := getComputeSystem(id)
+	if err != nil {
+		return nil, err
+	}
+	handler := &hcsContainerHandler{
+		name:               name,
+		id:                 id,
+		aliases:            []string{id},
+		creationTime:       system.Statistics.ContainerStartTime,
+		labels:             map[string]string{},
+		machineInfoFactory: machineInfoFactory,
+		ignoreMetrics:      ignoreMetrics,
+	}
+	if system.Name != "" && system.Name != id {
+		handler.aliases = append(handler.aliases, system.Name)
+	}
+	if system.Owner != "" {
+		handler.labels[ownerLabel] = system.Owner
+	}
+	if system.SystemType != "" {
+		handler.labels[systemTypeLabel] = system.SystemType
+	}
+	return handler, nil
+}
+
+func (self *hcsContainerHandler) Start() {}
+
+func (self *hcsContainerHandler) Cleanup() {}
+
+func (self *hcsContainerHandler) ContainerReference() (info.ContainerReference, error) {
+	return info.ContainerReference{
+		Id:        self.id,
+		Name:      self.name,
+		Aliases:   self.aliases,
+		Namespace: HcsNamespace,
+		Labels:    self.labels,
+	}, nil
+}
+
+func (self *hcsContainerHandler) GetSpec() (info.ContainerSpec, error) {
+	spec := info.ContainerSpec{
+		CreationTime: self.creationTime,
+		Labels:       self.labels,
+		HasCpu:       true,
+		HasMemory:    true,
+		HasNetwork:   !self.ignoreMetrics.Has(container.NetworkUsageMetrics),
+		HasDiskIo:    !self.ignoreMetrics.Has(container.DiskIOMetrics),
+	}
+	// HCS does not report limits, containers are bounded by the machine.
+	if machineInfo, err := self.machineInfoFactory.GetMachineInfo(); err == nil {
+		spec.Cpu.Limit = 1024
+		spec.Cpu.Mask = fmt.Sprintf("0-%d", machineInfo.NumCores-1)
+		spec.Memory.Limit = machineInfo.MemoryCapacity
+	}
+	return spec, nil
+}
+
+func (self *hcsContainerHandler) GetStats() (*info.ContainerStats, error) {
+	system, err := getComputeSystem(self.id)
+	if err != nil {
+		return nil, err
+	}
+	stats := toContainerStats(&system.Statistics)
+	if self.ignoreMetrics.Has(container.NetworkUsageMetrics) {
+		stats.Network = info.NetworkStats{}
+	}
+	if self.ignoreMetrics.Has(container.DiskIOMetrics) {
+		stats.DiskIo = info.DiskIoStats{}
+	}
+	return stats, nil
+}
+
+func (self *hcsContainerHandler) ListContainers(listType container.ListType) ([]info.ContainerReference, error) {
+	// No-op for HCS driver.
+	return []info.ContainerReference{}, nil
+}
+
+func (self *hcsContainerHandler) GetCgroupPath(resource string) (string, error) {
+	return "", fmt.Errorf("HCS containers have no cgroups")
+}
+
+func (self *hcsContainerHandler) ListThreads(listType container.ListType) ([]int, error) {
+	return nil, nil
+}
+
+func (self *hcsContainerHandler) GetContainerLabels() map[string]string {
+	return self.labels
+}
+
+func (self *hcsContainerHandler) ListProcesses(listType container.ListType) ([]int, error) {
+	return nil, nil
+}
+
+func (self *hcsContainerHandler) WatchSubcontainers(events chan container.SubcontainerEvent) error {
+	return fmt.Errorf("watch is unimplemented in the HCS container driver")
+}
+
+func (self *hcsContainerHandler) StopWatchingSubcontainers() error {
+	// No-op for HCS driver.
+	return nil
+}
+
+func (self *hcsContainerHandler) Exists() bool {
+	system, err := getComputeSystem(self.id)
+	return err == nil && !system.Stopped
+}
+
+// Handler of the root container, whose subcontainers are the running compute
+// systems. HCS has no stats for the host itself.
+type rootHandler struct {
+	machineInfoFactory info.MachineInfoFactory
+}
+
+func newRootHandler(machineInfoFactory info.MachineInfoFactory) container.ContainerHandler {
+	return &rootHandler{
+		machineInfoFactory: machineInfoFactory,
+	}
+}
+
+func (self *rootHandler) Start() {}
+
+func (self *rootHandler) Cleanup() {}
+
+func (self *rootHandler) ContainerReference() (info.ContainerReference, error) {
+	return info.ContainerReference{
+		Name: "/",
+	}, nil
+}
+
+func (self *rootHandler) GetSpec() (info.ContainerSpec, error) {
+	spec := info.ContainerSpec{}
+	if machineInfo, err := self.machineInfoFactory.GetMachineInfo(); err == nil {
+		spec.Cpu.Limit = 1024
+		spec.Cpu.Mask = fmt.Sprintf("0-%d", machineInfo.NumCores-1)
+		spec.Memory.Limit = machineInfo.MemoryCapacity
+	}
+	return spec, nil
+}
+
+func (self *rootHandler) GetStats() (*info.ContainerStats, error) {
+	return &info.ContainerStats{
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// Lists the running compute systems. They are discovered at each global
+// housekeeping.
+func (self *rootHandler) ListContainers(listType container.ListType) ([]info.ContainerReference, error) {
+	systems, err := listComputeSystems()
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]info.ContainerReference, 0, len(systems))
+	for _, system := range systems {
+		refs = append(refs, info.ContainerReference{
+			Name: namePrefix + system.Id,
+		})
+	}
+	return refs, nil
+}
+
+func (self *rootHandler) GetCgroupPath(resource string) (string, error) {
+	return "", fmt.Errorf("HCS containers have no cgroups")
+}
+
+func (self *rootHandler) ListThreads(listType container.ListType) ([]int, error) {
+	return nil, nil
+}
+
+func (self *rootHandler) GetContainerLabels() map[string]string {
+	return map[string]string{}
+}
+
+func (self *rootHandler) ListProcesses(listType container.ListType) ([]int, error) {
+	return nil, nil
+}
+
+// Compute systems are polled by ListContainers.
+func (self *rootHandler) WatchSubcontainers(events chan container.SubcontainerEvent) error {
+	return nil
+}
+
+func (self *rootHandler) StopWatchingSubcontainers() error {
+	return nil
+}
+
+func (self *rootHandler) Exists() bool {
+	return true
+}
@@ -0,0 +1,36 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+// +build !windows
+
+package hcs
+
+import (
+	"fmt"
+)
+
+var errUnsupported = fmt.Errorf("the Host Compute Service is only available on Windows")
+
+func available() error {
+	return errUnsupported
+}
+
+func enumerateComputeSystems(query string) ([]byte, error) {
+	return nil, errUnsupported
+}
+
+func computeSystemProperties(id, query string) ([]byte, error) {
+	return nil, errUnsupported
+}
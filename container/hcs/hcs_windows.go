@@ -0,0 +1,114 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+// +build windows
+
+package hcs
+
+import (
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	modvmcompute = syscall.NewLazyDLL("vmcompute.dll")
+	modole32     = syscall.NewLazyDLL("ole32.dll")
+
+	procHcsEnumerateComputeSystems    = modvmcompute.NewProc("HcsEnumerateComputeSystems")
+	procHcsOpenComputeSystem          = modvmcompute.NewProc("HcsOpenComputeSystem")
+	procHcsGetComputeSystemProperties = modvmcompute.NewProc("HcsGetComputeSystemProperties")
+	procHcsCloseComputeSystem         = modvmcompute.NewProc("HcsCloseComputeSystem")
+	procCoTaskMemFree                 = modole32.NewProc("CoTaskMemFree")
+)
+
+// Returns an error if HCS is not available on this host.
+func available() error {
+	return procHcsEnumerateComputeSystems.Find()
+}
+
+func enumerateComputeSystems(query string) ([]byte, error) {
+	q, err := syscall.UTF16PtrFromString(query)
+	if err != nil {
+		return nil, err
+	}
+	var systems, result *uint16
+	r, _, _ := procHcsEnumerateComputeSystems.Call(
+		uintptr(unsafe.Pointer(q)),
+		uintptr(unsafe.Pointer(&systems)),
+		uintptr(unsafe.Pointer(&result)))
+	out, details := takeString(systems), takeString(result)
+	if failed(r) {
+		return nil, hcsError("HcsEnumerateComputeSystems", r, details)
+	}
+	return []byte(out), nil
+}
+
+func computeSystemProperties(id, query string) ([]byte, error) {
+	i, err := syscall.UTF16PtrFromString(id)
+	if err != nil {
+		return nil, err
+	}
+	q, err := syscall.UTF16PtrFromString(query)
+	if err != nil {
+		return nil, err
+	}
+	var handle syscall.Handle
+	var properties, result *uint16
+	r, _, _ := procHcsOpenComputeSystem.Call(
+		uintptr(unsafe.Pointer(i)),
+		uintptr(unsafe.Pointer(&handle)),
+		uintptr(unsafe.Pointer(&result)))
+	if details := takeString(result); failed(r) {
+		return nil, hcsError("HcsOpenComputeSystem", r, details)
+	}
+	defer procHcsCloseComputeSystem.Call(uintptr(handle))
+
+	r, _, _ = procHcsGetComputeSystemProperties.Call(
+		uintptr(handle),
+		uintptr(unsafe.Pointer(q)),
+		uintptr(unsafe.Pointer(&properties)),
+		uintptr(unsafe.Pointer(&result)))
+	out, details := takeString(properties), takeString(result)
+	if failed(r) {
+		return nil, hcsError("HcsGetComputeSystemProperties", r, details)
+	}
+	return []byte(out), nil
+}
+
+// Whether the HRESULT is an error.
+func failed(r uintptr) bool {
+	return int32(r) < 0
+}
+
+func hcsError(call string, r uintptr, details string) error {
+	if details != "" {
+		return fmt.Errorf("%s failed with HRESULT 0x%x: %s", call, uint32(r), details)
+	}
+	return fmt.Errorf("%s failed with HRESULT 0x%x", call, uint32(r))
+}
+
+// Converts a NUL-terminated UTF-16 string allocated by HCS, and frees it.
+func takeString(p *uint16) string {
+	if p == nil {
+		return ""
+	}
+	defer procCoTaskMemFree.Call(uintptr(unsafe.Pointer(p)))
+	var chars []uint16
+	for ptr := unsafe.Pointer(p); *(*uint16)(ptr) != 0; ptr = unsafe.Pointer(uintptr(ptr) + unsafe.Sizeof(*p)) {
+		chars = append(chars, *(*uint16)(ptr))
+	}
+	return syscall.UTF16ToString(chars)
+}
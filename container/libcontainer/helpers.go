@@ -12,6 +12,8 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// +build linux
+
 package libcontainer
 
 import (
@@ -26,8 +28,8 @@ import (
 
 	"github.com/google/cadvisor/container"
 	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
 
-	"github.com/golang/glog"
 	"github.com/opencontainers/runc/libcontainer"
 	"github.com/opencontainers/runc/libcontainer/cgroups"
 )
@@ -98,7 +100,10 @@ func GetStats(cgroupManager cgroups.Manager, rootFs string, pid int, ignoreMetri
 	if !ignoreMetrics.Has(container.NetworkUsageMetrics) {
 		netStats, err := networkStatsFromProc(rootFs, pid)
 		if err != nil {
-			glog.V(2).Infof("Unable to get network stats from pid %d: %v", pid, err)
+			logging.V(2).Infof("Unable to get network stats from pid %d: %v", pid, err)
+			stats.SetError(info.NetworkStatsSubsystem, err)
+		} else if len(stats.Network.Interfaces) == 0 {
+			stats.Network.Interfaces = netStats
 		} else {
 			stats.Network.Interfaces = append(stats.Network.Interfaces, netStats...)
 		}
@@ -106,14 +111,16 @@ func GetStats(cgroupManager cgroups.Manager, rootFs string, pid int, ignoreMetri
 	if !ignoreMetrics.Has(container.NetworkTcpUsageMetrics) {
 		t, err := tcpStatsFromProc(rootFs, pid, "net/tcp")
 		if err != nil {
-			glog.V(2).Infof("Unable to get tcp stats from pid %d: %v", pid, err)
+			logging.V(2).Infof("Unable to get tcp stats from pid %d: %v", pid, err)
+			stats.SetError(info.TcpStatsSubsystem, err)
 		} else {
 			stats.Network.Tcp = t
 		}
 
 		t6, err := tcpStatsFromProc(rootFs, pid, "net/tcp6")
 		if err != nil {
-			glog.V(2).Infof("Unable to get tcp6 stats from pid %d: %v", pid, err)
+			logging.V(2).Infof("Unable to get tcp6 stats from pid %d: %v", pid, err)
+			stats.SetError(info.TcpStatsSubsystem, err)
 		} else {
 			stats.Network.Tcp6 = t6
 		}
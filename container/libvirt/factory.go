@@ -0,0 +1,141 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package libvirt
+
+import (
+	"flag"
+	"fmt"
+	"os/exec"
+	"path"
+	"regexp"
+	"strconv"
+
+	"github.com/google/cadvisor/container"
+	"github.com/google/cadvisor/container/libcontainer"
+	"github.com/google/cadvisor/fs"
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
+)
+
+var argVirsh = flag.String("libvirt_virsh", "virsh", "virsh command used to get the names and the disk and network stats of libvirt domains")
+var argLibvirtUri = flag.String("libvirt_uri", "qemu:///system", "URI of the libvirt daemon managing the qemu domains")
+
+const LibvirtNamespace = "libvirt"
+
+// libvirt puts qemu domains in "machine-qemu\x2d<id>\x2d<name>.scope" units
+// with systemd, and in "<name>.libvirt-qemu" cgroups otherwise.
+var (
+	scopeCgroupRegexp    = regexp.MustCompile(`^machine-qemu\\x2d(\d+)\\x2d(.+)\.scope$`)
+	cgroupfsCgroupRegexp = regexp.MustCompile(`^(.+)\.libvirt-qemu$`)
+	escapeRegexp         = regexp.MustCompile(`\\x[0-9a-f]{2}`)
+)
+
+type libvirtFactory struct {
+	machineInfoFactory info.MachineInfoFactory
+
+	cgroupSubsystems *libcontainer.CgroupSubsystems
+
+	fsInfo fs.FsInfo
+
+	ignoreMetrics container.MetricSet
+}
+
+func (self *libvirtFactory) String() string {
+	return LibvirtNamespace
+}
+
+// Undoes the escaping of systemd unit names.
+func unescape(name string) string {
+	return escapeRegexp.ReplaceAllStringFunc(name, func(escaped string) string {
+		c, err := strconv.ParseUint(escaped[2:], 16, 8)
+		if err != nil {
+			return escaped
+		}
+		return string([]byte{byte(c)})
+	})
+}
+
+// Parses the domain of the cgroup of a domain. It returns the reference of
+// the domain for virsh, its ID if known or else its name, and its name as
+// found in the cgroup, which may be truncated.
+func parseName(name string) (string, string, bool) {
+	base := path.Base(name)
+	if matches := scopeCgroupRegexp.FindStringSubmatch(base); matches != nil {
+		return matches[1], unescape(matches[2]), true
+	}
+	if matches := cgroupfsCgroupRegexp.FindStringSubmatch(base); matches != nil {
+		return matches[1], matches[1], true
+	}
+	return "", "", false
+}
+
+// Returns whether the cgroup is below the cgroup of a domain, e.g. the
+// cgroups of the emulator and vcpu threads.
+func isDomainSubcgroup(name string) bool {
+	for dir := path.Dir(name); dir != "/" && dir != "."; dir = path.Dir(dir) {
+		if _, _, ok := parseName(dir); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (self *libvirtFactory) NewContainerHandler(name string, inHostNamespace bool) (container.ContainerHandler, error) {
+	domain, domainName, ok := parseName(name)
+	if !ok {
+		return nil, fmt.Errorf("invalid libvirt domain cgroup %q", name)
+	}
+	rootFs := "/"
+	if !inHostNamespace {
+		rootFs = "/rootfs"
+	}
+	return newLibvirtContainerHandler(name, domain, domainName, self.cgroupSubsystems, self.machineInfoFactory, self.fsInfo, rootFs, self.ignoreMetrics)
+}
+
+func (self *libvirtFactory) CanHandleAndAccept(name string) (bool, bool, error) {
+	// The threads of domains are accounted to the domain.
+	if isDomainSubcgroup(name) {
+		return true, false, nil
+	}
+	if _, _, ok := parseName(name); !ok {
+		return false, false, fmt.Errorf("%s not handled by libvirt handler", name)
+	}
+	return true, true, nil
+}
+
+func (self *libvirtFactory) DebugInfo() map[string][]string {
+	return map[string][]string{}
+}
+
+// Register registers the libvirt container factory if virsh is installed.
+func Register(factory info.MachineInfoFactory, fsInfo fs.FsInfo, ignoreMetrics container.MetricSet) error {
+	if _, err := exec.LookPath(*argVirsh); err != nil {
+		return fmt.Errorf("unable to find virsh: %v", err)
+	}
+	cgroupSubsystems, err := libcontainer.GetCgroupSubsystems()
+	if err != nil {
+		return fmt.Errorf("failed to get cgroup subsystems: %v", err)
+	}
+
+	logging.Infof("Registering libvirt factory")
+	f := &libvirtFactory{
+		cgroupSubsystems:   &cgroupSubsystems,
+		fsInfo:             fsInfo,
+		machineInfoFactory: factory,
+		ignoreMetrics:      ignoreMetrics,
+	}
+	container.RegisterContainerHandlerFactory(f)
+	return nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package libvirt
+
+import (
+	"testing"
+)
+
+func TestParseName(t *testing.T) {
+	for name, expected := range map[string][2]string{
+		`/machine.slice/machine-qemu\x2d3\x2dweb\x2d1.scope`: {"3", "web-1"},
+		"/machine/db.libvirt-qemu":                           {"db", "db"},
+	} {
+		domain, domainName, ok := parseName(name)
+		if !ok || domain != expected[0] || domainName != expected[1] {
+			t.Errorf("expected domain %v for %q, got %q, %q", expected, name, domain, domainName)
+		}
+	}
+	if _, _, ok := parseName("/machine.slice/machine-lxc.scope"); ok {
+		t.Errorf("unexpected libvirt domain")
+	}
+	if !isDomainSubcgroup(`/machine.slice/machine-qemu\x2d3\x2dweb.scope/libvirt/vcpu0`) {
+		t.Errorf("expected a domain subcgroup")
+	}
+	if isDomainSubcgroup(`/machine.slice/machine-qemu\x2d3\x2dweb.scope`) {
+		t.Errorf("unexpected domain subcgroup")
+	}
+}
+
+func TestParseDomainStats(t *testing.T) {
+	out := []byte(`Domain: 'web-1'
+  block.count=2
+  block.0.name=vda
+  block.0.rd.reqs=10
+  block.0.rd.bytes=4096
+  block.0.wr.reqs=5
+  block.0.wr.bytes=2048
+  block.1.name=vdb
+  block.1.rd.bytes=1
+  net.count=1
+  net.0.name=vnet0
+  net.0.rx.bytes=100
+  net.0.rx.pkts=2
+  net.0.rx.drop=1
+  net.0.tx.bytes=200
+  net.0.tx.pkts=3
+`)
+	stats, err := parseDomainStats(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.disks) != 2 || stats.disks[0].readBytes != 4096 || stats.disks[0].writeRequests != 5 || stats.disks[1].readBytes != 1 {
+		t.Errorf("unexpected disk stats %+v", stats.disks)
+	}
+	if len(stats.interfaces) != 1 {
+		t.Fatalf("expected 1 interface, got %+v", stats.interfaces)
+	}
+	iface := stats.interfaces[0]
+	if iface.Name != "vnet0" || iface.RxBytes != 100 || iface.RxPackets != 2 || iface.RxDropped != 1 || iface.TxBytes != 200 || iface.TxPackets != 3 {
+		t.Errorf("unexpected interface stats %+v", iface)
+	}
+	diskIo := toDiskIoStats(stats.disks)
+	if total := diskIo.IoServiceBytes[0].Stats["Total"]; total != 6144 {
+		t.Errorf("expected 6144 bytes of io, got %d", total)
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Handler for the qemu cgroups of libvirt domains.
+package libvirt
+
+import (
+	"fmt"
+
+	"github.com/google/cadvisor/container"
+	"github.com/google/cadvisor/container/libcontainer"
+	"github.com/google/cadvisor/container/raw"
+	"github.com/google/cadvisor/fs"
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
+)
+
+// Label of the name of the domain.
+const domainLabel = "libvirt.domain"
+
+// Reads the cpu and memory stats of the domain from its cgroup, and the stats
+// of its virtual disks and network interfaces from libvirt.
+type libvirtContainerHandler struct {
+	container.ContainerHandler
+
+	name string
+	// ID or name of the domain for virsh.
+	domain        string
+	domainName    string
+	labels        map[string]string
+	ignoreMetrics container.MetricSet
+}
+
+func newLibvirtContainerHandler(name, domain, domainName string, cgroupSubsystems *libcontainer.CgroupSubsystems, machineInfoFactory info.MachineInfoFactory, fsInfo fs.FsInfo, rootFs string, ignoreMetrics container.MetricSet) (container.ContainerHandler, error) {
+	rawHandler, err := raw.NewHandler(name, cgroupSubsystems, machineInfoFactory, fsInfo, rootFs, ignoreMetrics)
+	if err != nil {
+		return nil, err
+	}
+	// The cgroup may hold a truncated name.
+	if fullName, err := getDomainName(domain); err == nil && fullName != "" {
+		domainName = fullName
+	} else if err != nil {
+		logging.V(2).Infof("Failed to get the name of libvirt domain %q: %v", domain, err)
+	}
+	return &libvirtContainerHandler{
+		ContainerHandler: rawHandler,
+		name:             name,
+		domain:           domain,
+		domainName:       domainName,
+		labels: map[string]string{
+			domainLabel: domainName,
+		},
+		ignoreMetrics: ignoreMetrics,
+	}, nil
+}
+
+func (self *libvirtContainerHandler) ContainerReference() (info.ContainerReference, error) {
+	return info.ContainerReference{
+		Name:      self.name,
+		Aliases:   []string{self.domainName},
+		Namespace: LibvirtNamespace,
+		Labels:    self.labels,
+	}, nil
+}
+
+func (self *libvirtContainerHandler) GetSpec() (info.ContainerSpec, error) {
+	spec, err := self.ContainerHandler.GetSpec()
+	spec.Labels = self.labels
+	spec.HasNetwork = !self.ignoreMetrics.Has(container.NetworkUsageMetrics)
+	spec.HasDiskIo = !self.ignoreMetrics.Has(container.DiskIOMetrics)
+	return spec, err
+}
+
+func (self *libvirtContainerHandler) GetStats() (*info.ContainerStats, error) {
+	stats, err := self.ContainerHandler.GetStats()
+	if stats == nil {
+		return stats, err
+	}
+	if self.ignoreMetrics.Has(container.NetworkUsageMetrics) && self.ignoreMetrics.Has(container.DiskIOMetrics) {
+		return stats, err
+	}
+	domainStats, domainErr := getDomainStats(self.domain)
+	if domainErr != nil {
+		if err == nil {
+			err = domainErr
+		}
+		return stats, err
+	}
+	if !self.ignoreMetrics.Has(container.NetworkUsageMetrics) {
+		stats.Network = info.NetworkStats{
+			Interfaces: domainStats.interfaces,
+		}
+		if len(domainStats.interfaces) > 0 {
+			stats.Network.InterfaceStats = domainStats.interfaces[0]
+		}
+	}
+	if !self.ignoreMetrics.Has(container.DiskIOMetrics) {
+		stats.DiskIo = toDiskIoStats(domainStats.disks)
+	}
+	return stats, err
+}
+
+// Maps the virtual disks to devices of major number 0 and of their index as
+// minor number, the io of the qemu process on the host devices being
+// accounted to the cgroup of the domain.
+func toDiskIoStats(disks []diskStats) info.DiskIoStats {
+	diskIo := info.DiskIoStats{}
+	for i, disk := range disks {
+		diskIo.IoServiceBytes = append(diskIo.IoServiceBytes, info.PerDiskStats{
+			Minor: uint64(i),
+			Stats: map[string]uint64{
+				"Read":  disk.readBytes,
+				"Write": disk.writeBytes,
+				"Total": disk.readBytes + disk.writeBytes,
+			},
+		})
+		diskIo.IoServiced = append(diskIo.IoServiced, info.PerDiskStats{
+			Minor: uint64(i),
+			Stats: map[string]uint64{
+				"Read":  disk.readRequests,
+				"Write": disk.writeRequests,
+				"Total": disk.readRequests + disk.writeRequests,
+			},
+		})
+	}
+	return diskIo
+}
+
+func (self *libvirtContainerHandler) GetContainerLabels() map[string]string {
+	return self.labels
+}
+
+func (self *libvirtContainerHandler) WatchSubcontainers(events chan container.SubcontainerEvent) error {
+	return fmt.Errorf("watch is unimplemented in the libvirt container driver")
+}
+
+func (self *libvirtContainerHandler) StopWatchingSubcontainers() error {
+	// No-op for libvirt driver.
+	return nil
+}
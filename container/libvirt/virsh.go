@@ -0,0 +1,149 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package libvirt
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	info "github.com/google/cadvisor/info/v1"
+)
+
+// Runs virsh against the libvirt daemon of --libvirt_uri.
+func virsh(args ...string) ([]byte, error) {
+	cmd := exec.Command(*argVirsh, append([]string{"-c", *argLibvirtUri}, args...)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("virsh %s failed: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// Returns the name of the domain with the ID or name.
+func getDomainName(domain string) (string, error) {
+	out, err := virsh("domname", domain)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Stats of the virtual disks and network interfaces of a domain.
+type domainStats struct {
+	disks      []diskStats
+	interfaces []info.InterfaceStats
+}
+
+type diskStats struct {
+	readRequests, readBytes, writeRequests, writeBytes uint64
+}
+
+func getDomainStats(domain string) (*domainStats, error) {
+	out, err := virsh("domstats", "--block", "--interface", domain)
+	if err != nil {
+		return nil, err
+	}
+	return parseDomainStats(out)
+}
+
+// Parses the output of "virsh domstats --block --interface", made of
+// "block.<n>.<field>=<value>" and "net.<n>.<field>=<value>" lines.
+func parseDomainStats(out []byte) (*domainStats, error) {
+	stats := &domainStats{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			continue
+		}
+		key, value := line[:eq], line[eq+1:]
+		fields := strings.SplitN(key, ".", 3)
+		if len(fields) != 3 || (fields[0] != "block" && fields[0] != "net") {
+			continue
+		}
+		index, err := strconv.Atoi(fields[1])
+		if err != nil || index < 0 {
+			continue
+		}
+		if fields[0] == "block" {
+			for len(stats.disks) <= index {
+				stats.disks = append(stats.disks, diskStats{})
+			}
+			setDiskStat(&stats.disks[index], fields[2], value)
+		} else {
+			for len(stats.interfaces) <= index {
+				stats.interfaces = append(stats.interfaces, info.InterfaceStats{})
+			}
+			setInterfaceStat(&stats.interfaces[index], fields[2], value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func setDiskStat(disk *diskStats, field, value string) {
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return
+	}
+	switch field {
+	case "rd.reqs":
+		disk.readRequests = n
+	case "rd.bytes":
+		disk.readBytes = n
+	case "wr.reqs":
+		disk.writeRequests = n
+	case "wr.bytes":
+		disk.writeBytes = n
+	}
+}
+
+func setInterfaceStat(iface *info.InterfaceStats, field, value string) {
+	if field == "name" {
+		iface.Name = value
+		return
+	}
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return
+	}
+	switch field {
+	case "rx.bytes":
+		iface.RxBytes = n
+	case "rx.pkts":
+		iface.RxPackets = n
+	case "rx.errs":
+		iface.RxErrors = n
+	case "rx.drop":
+		iface.RxDropped = n
+	case "tx.bytes":
+		iface.TxBytes = n
+	case "tx.pkts":
+		iface.TxPackets = n
+	case "tx.errs":
+		iface.TxErrors = n
+	case "tx.drop":
+		iface.TxDropped = n
+	}
+}
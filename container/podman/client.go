@@ -0,0 +1,54 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podman
+
+import (
+	"fmt"
+	"sync"
+
+	dclient "github.com/fsouza/go-dockerclient"
+)
+
+var (
+	// Clients of the podman API sockets keyed by endpoint.
+	clients     = make(map[string]*dclient.Client)
+	clientsLock sync.Mutex
+)
+
+// Returns the endpoint of the API socket of the podman of the user, root for
+// uid 0.
+func endpoint(uid int) string {
+	if uid == 0 {
+		return *ArgPodmanEndpoint
+	}
+	return fmt.Sprintf("unix://%s/%d/podman/podman.sock", *argPodmanUserRunDir, uid)
+}
+
+// Client returns a client of the Docker compatible API of the podman of the
+// user.
+func Client(uid int) (*dclient.Client, error) {
+	clientsLock.Lock()
+	defer clientsLock.Unlock()
+	e := endpoint(uid)
+	if client, ok := clients[e]; ok {
+		return client, nil
+	}
+	client, err := dclient.NewClient(e)
+	if err != nil {
+		return nil, err
+	}
+	clients[e] = client
+	return client, nil
+}
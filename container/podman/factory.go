@@ -0,0 +1,146 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podman
+
+import (
+	"flag"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/cadvisor/container"
+	"github.com/google/cadvisor/container/libcontainer"
+	"github.com/google/cadvisor/fs"
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
+)
+
+var ArgPodmanEndpoint = flag.String("podman", "unix:///run/podman/podman.sock", "podman API endpoint of root containers")
+var argPodmanUserRunDir = flag.String("podman_user_run_dir", "/run/user", "directory of the runtime directories of users, holding the podman API sockets of rootless containers")
+var podmanEnvWhitelist = flag.String("podman_env_metadata_whitelist", "", "a comma-separated list of environment variable keys that needs to be collected for podman containers")
+
+const PodmanNamespace = "podman"
+
+// Podman puts containers in "libpod-<id>.scope" units with the systemd cgroup
+// manager and in "libpod-<id>" cgroups with cgroupfs. Their conmon monitor
+// runs in "libpod-conmon-<id>.scope".
+var (
+	podmanCgroupRegexp = regexp.MustCompile(`^libpod-([a-z0-9]{64})(\.scope)?$`)
+	conmonCgroupRegexp = regexp.MustCompile(`^libpod-conmon-[a-z0-9]{64}(\.scope)?$`)
+)
+
+// Rootless containers are under the slice of their user, e.g.
+// "/user.slice/user-1000.slice/user@1000.service/user.slice/libpod-<id>.scope".
+var userSliceRegexp = regexp.MustCompile(`/user-(\d+)\.slice/`)
+
+type podmanFactory struct {
+	machineInfoFactory info.MachineInfoFactory
+
+	cgroupSubsystems libcontainer.CgroupSubsystems
+
+	fsInfo fs.FsInfo
+
+	ignoreMetrics container.MetricSet
+}
+
+func (self *podmanFactory) String() string {
+	return PodmanNamespace
+}
+
+// Parses the ID of the container of a cgroup and the uid of the user running
+// it, 0 for root containers.
+func parseName(name string) (string, int, bool) {
+	matches := podmanCgroupRegexp.FindStringSubmatch(path.Base(name))
+	if matches == nil {
+		return "", 0, false
+	}
+	uid := 0
+	if user := userSliceRegexp.FindStringSubmatch(name); user != nil {
+		var err error
+		if uid, err = strconv.Atoi(user[1]); err != nil {
+			return "", 0, false
+		}
+	}
+	return matches[1], uid, true
+}
+
+func (self *podmanFactory) NewContainerHandler(name string, inHostNamespace bool) (container.ContainerHandler, error) {
+	id, uid, ok := parseName(name)
+	if !ok {
+		return nil, fmt.Errorf("invalid podman container name %q", name)
+	}
+	client, err := Client(uid)
+	if err != nil {
+		return nil, err
+	}
+	metadataEnvs := strings.Split(*podmanEnvWhitelist, ",")
+	return newPodmanContainerHandler(
+		client,
+		name,
+		id,
+		uid,
+		self.machineInfoFactory,
+		&self.cgroupSubsystems,
+		inHostNamespace,
+		metadataEnvs,
+		self.ignoreMetrics,
+	)
+}
+
+func (self *podmanFactory) CanHandleAndAccept(name string) (bool, bool, error) {
+	// Ignore the cgroups of the conmon monitors of the containers.
+	if conmonCgroupRegexp.MatchString(path.Base(name)) {
+		return true, false, nil
+	}
+	id, uid, ok := parseName(name)
+	if !ok {
+		return false, true, fmt.Errorf("invalid container name")
+	}
+	client, err := Client(uid)
+	if err != nil {
+		return false, true, err
+	}
+	ctnr, err := client.InspectContainer(id)
+	if err != nil || !ctnr.State.Running {
+		return false, true, fmt.Errorf("error inspecting container: %v", err)
+	}
+	return true, true, nil
+}
+
+func (self *podmanFactory) DebugInfo() map[string][]string {
+	return map[string][]string{}
+}
+
+// Register registers the podman container factory. Podman does not run a
+// daemon, so the factory is registered even if the API sockets of root and
+// users are not running yet.
+func Register(factory info.MachineInfoFactory, fsInfo fs.FsInfo, ignoreMetrics container.MetricSet) error {
+	cgroupSubsystems, err := libcontainer.GetCgroupSubsystems()
+	if err != nil {
+		return fmt.Errorf("failed to get cgroup subsystems: %v", err)
+	}
+
+	logging.Infof("Registering podman factory")
+	f := &podmanFactory{
+		cgroupSubsystems:   cgroupSubsystems,
+		fsInfo:             fsInfo,
+		machineInfoFactory: factory,
+		ignoreMetrics:      ignoreMetrics,
+	}
+	container.RegisterContainerHandlerFactory(f)
+	return nil
+}
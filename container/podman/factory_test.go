@@ -0,0 +1,53 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package podman
+
+import (
+	"testing"
+)
+
+func TestParseName(t *testing.T) {
+	const id = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	for _, test := range []struct {
+		name string
+		uid  int
+	}{
+		{"/machine.slice/libpod-" + id + ".scope", 0},
+		{"/libpod_parent/libpod-" + id, 0},
+		{"/user.slice/user-1000.slice/user@1000.service/user.slice/libpod-" + id + ".scope", 1000},
+	} {
+		actualId, uid, ok := parseName(test.name)
+		if !ok || actualId != id || uid != test.uid {
+			t.Errorf("expected id %q and uid %d for %q, got %q and %d", id, test.uid, test.name, actualId, uid)
+		}
+	}
+	for _, name := range []string{
+		"/machine.slice/libpod-conmon-" + id + ".scope",
+		"/docker/" + id,
+	} {
+		if _, _, ok := parseName(name); ok {
+			t.Errorf("unexpected podman container %q", name)
+		}
+	}
+}
+
+func TestEndpoint(t *testing.T) {
+	if e := endpoint(0); e != *ArgPodmanEndpoint {
+		t.Errorf("expected endpoint %q for root, got %q", *ArgPodmanEndpoint, e)
+	}
+	if e := endpoint(1000); e != "unix:///run/user/1000/podman/podman.sock" {
+		t.Errorf("unexpected endpoint %q for uid 1000", e)
+	}
+}
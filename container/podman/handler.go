@@ -0,0 +1,229 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Handler for podman containers, root and rootless.
+package podman
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/cadvisor/container"
+	"github.com/google/cadvisor/container/common"
+	containerlibcontainer "github.com/google/cadvisor/container/libcontainer"
+	info "github.com/google/cadvisor/info/v1"
+
+	docker "github.com/fsouza/go-dockerclient"
+	"github.com/opencontainers/runc/libcontainer/cgroups"
+	cgroupfs "github.com/opencontainers/runc/libcontainer/cgroups/fs"
+	libcontainerconfigs "github.com/opencontainers/runc/libcontainer/configs"
+)
+
+// Label added with the uid of the user running rootless containers.
+const uidLabel = "io.podman.uid"
+
+type podmanContainerHandler struct {
+	name               string
+	id                 string
+	aliases            []string
+	machineInfoFactory info.MachineInfoFactory
+
+	// Absolute path to the cgroup hierarchies of this container.
+	// (e.g.: "cpu" -> "/sys/fs/cgroup/cpu/test")
+	cgroupPaths map[string]string
+
+	// Manager of this container's cgroups.
+	cgroupManager cgroups.Manager
+
+	// Time at which this container was created.
+	creationTime time.Time
+
+	// Metadata associated with the container.
+	labels map[string]string
+	envs   map[string]string
+
+	// The container PID used to switch namespaces as required
+	pid int
+
+	// Image name used for this container.
+	image string
+
+	// The host root FS to read
+	rootFs string
+
+	// The network mode of the container
+	networkMode string
+
+	ignoreMetrics container.MetricSet
+}
+
+func newPodmanContainerHandler(
+	client *docker.Client,
+	name string,
+	id string,
+	uid int,
+	machineInfoFactory info.MachineInfoFactory,
+	cgroupSubsystems *containerlibcontainer.CgroupSubsystems,
+	inHostNamespace bool,
+	metadataEnvs []string,
+	ignoreMetrics container.MetricSet,
+) (container.ContainerHandler, error) {
+	// The cgroups of rootless containers are nested under the slices of
+	// their user, which are part of their name.
+	cgroupPaths := common.MakeCgroupPaths(cgroupSubsystems.MountPoints, name)
+
+	// Generate the equivalent cgroup manager for this container.
+	cgroupManager := &cgroupfs.Manager{
+		Cgroups: &libcontainerconfigs.Cgroup{
+			Name: name,
+		},
+		Paths: cgroupPaths,
+	}
+
+	rootFs := "/"
+	if !inHostNamespace {
+		rootFs = "/rootfs"
+	}
+
+	ctnr, err := client.InspectContainer(id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container %q: %v", id, err)
+	}
+
+	handler := &podmanContainerHandler{
+		name:               name,
+		id:                 id,
+		machineInfoFactory: machineInfoFactory,
+		cgroupPaths:        cgroupPaths,
+		cgroupManager:      cgroupManager,
+		creationTime:       ctnr.Created,
+		labels:             make(map[string]string),
+		envs:               make(map[string]string),
+		pid:                ctnr.State.Pid,
+		rootFs:             rootFs,
+		networkMode:        ctnr.HostConfig.NetworkMode,
+		ignoreMetrics:      ignoreMetrics,
+	}
+
+	// Add the name and bare ID as aliases of the container.
+	handler.aliases = append(handler.aliases, strings.TrimPrefix(ctnr.Name, "/"), id)
+	if ctnr.Config != nil {
+		for k, v := range ctnr.Config.Labels {
+			handler.labels[k] = v
+		}
+		handler.image = ctnr.Config.Image
+
+		// split env vars to get metadata map.
+		for _, exposedEnv := range metadataEnvs {
+			for _, envVar := range ctnr.Config.Env {
+				splits := strings.SplitN(envVar, "=", 2)
+				if len(splits) == 2 && splits[0] == exposedEnv {
+					handler.envs[strings.ToLower(exposedEnv)] = splits[1]
+				}
+			}
+		}
+	}
+	if uid != 0 {
+		handler.labels[uidLabel] = strconv.Itoa(uid)
+	}
+
+	return handler, nil
+}
+
+func (self *podmanContainerHandler) Start() {}
+
+func (self *podmanContainerHandler) Cleanup() {}
+
+func (self *podmanContainerHandler) ContainerReference() (info.ContainerReference, error) {
+	return info.ContainerReference{
+		Id:        self.id,
+		Name:      self.name,
+		Aliases:   self.aliases,
+		Namespace: PodmanNamespace,
+		Labels:    self.labels,
+		Envs:      self.envs,
+	}, nil
+}
+
+func (self *podmanContainerHandler) needNet() bool {
+	if !self.ignoreMetrics.Has(container.NetworkUsageMetrics) {
+		return !strings.HasPrefix(self.networkMode, "container:")
+	}
+	return false
+}
+
+func (self *podmanContainerHandler) GetSpec() (info.ContainerSpec, error) {
+	spec, err := common.GetSpec(self.cgroupPaths, self.machineInfoFactory, self.needNet(), false)
+
+	spec.Labels = self.labels
+	spec.Envs = self.envs
+	spec.Image = self.image
+	if !self.creationTime.IsZero() {
+		spec.CreationTime = self.creationTime
+	}
+
+	return spec, err
+}
+
+func (self *podmanContainerHandler) GetStats() (*info.ContainerStats, error) {
+	stats, err := containerlibcontainer.GetStats(self.cgroupManager, self.rootFs, self.pid, self.ignoreMetrics)
+	if err != nil {
+		return stats, err
+	}
+	// Containers of a pod share the network of its infra container.
+	if !self.needNet() {
+		stats.Network = info.NetworkStats{}
+	}
+	return stats, nil
+}
+
+func (self *podmanContainerHandler) ListContainers(listType container.ListType) ([]info.ContainerReference, error) {
+	// No-op for podman driver.
+	return []info.ContainerReference{}, nil
+}
+
+func (self *podmanContainerHandler) GetCgroupPath(resource string) (string, error) {
+	path, ok := self.cgroupPaths[resource]
+	if !ok {
+		return "", fmt.Errorf("could not find path for resource %q for container %q\n", resource, self.name)
+	}
+	return path, nil
+}
+
+func (self *podmanContainerHandler) ListThreads(listType container.ListType) ([]int, error) {
+	return nil, nil
+}
+
+func (self *podmanContainerHandler) GetContainerLabels() map[string]string {
+	return self.labels
+}
+
+func (self *podmanContainerHandler) ListProcesses(listType container.ListType) ([]int, error) {
+	return containerlibcontainer.GetProcesses(self.cgroupManager)
+}
+
+func (self *podmanContainerHandler) WatchSubcontainers(events chan container.SubcontainerEvent) error {
+	return fmt.Errorf("watch is unimplemented in the podman container driver")
+}
+
+func (self *podmanContainerHandler) StopWatchingSubcontainers() error {
+	// No-op for podman driver.
+	return nil
+}
+
+func (self *podmanContainerHandler) Exists() bool {
+	return common.CgroupExists(self.cgroupPaths)
+}
@@ -12,6 +12,8 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// +build linux
+
 package raw
 
 import (
@@ -23,8 +25,7 @@ import (
 	"github.com/google/cadvisor/container/libcontainer"
 	"github.com/google/cadvisor/fs"
 	info "github.com/google/cadvisor/info/v1"
-
-	"github.com/golang/glog"
+	"github.com/google/cadvisor/logging"
 )
 
 var dockerOnly = flag.Bool("docker_only", false, "Only report docker containers in addition to root stats")
@@ -59,7 +60,11 @@ func (self *rawFactory) NewContainerHandler(name string, inHostNamespace bool) (
 }
 
 // The raw factory can handle any container. If --docker_only is set to false, non-docker containers are ignored.
+// Pruned cgroups are ignored.
 func (self *rawFactory) CanHandleAndAccept(name string) (bool, bool, error) {
+	if pruner.prunes(name) {
+		return true, false, nil
+	}
 	accept := name == "/" || !*dockerOnly
 	return true, accept, nil
 }
@@ -77,12 +82,17 @@ func Register(machineInfoFactory info.MachineInfoFactory, fsInfo fs.FsInfo, igno
 		return fmt.Errorf("failed to find supported cgroup mounts for the raw factory")
 	}
 
+	pruner, err = newCgroupPruner(*maxContainerDepth, *containerPrune)
+	if err != nil {
+		return err
+	}
+
 	watcher, err := common.NewInotifyWatcher()
 	if err != nil {
 		return err
 	}
 
-	glog.Infof("Registering Raw factory")
+	logging.Infof("Registering Raw factory")
 	factory := &rawFactory{
 		machineInfoFactory: machineInfoFactory,
 		fsInfo:             fsInfo,
@@ -12,6 +12,8 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// +build linux
+
 // Handler for "raw" containers.
 package raw
 
@@ -26,9 +28,9 @@ import (
 	"github.com/google/cadvisor/container/libcontainer"
 	"github.com/google/cadvisor/fs"
 	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
 	"github.com/google/cadvisor/utils/machine"
 
-	"github.com/golang/glog"
 	"github.com/opencontainers/runc/libcontainer/cgroups"
 	cgroupfs "github.com/opencontainers/runc/libcontainer/cgroups/fs"
 	"github.com/opencontainers/runc/libcontainer/configs"
@@ -69,6 +71,16 @@ func isRootCgroup(name string) bool {
 	return name == "/"
 }
 
+// NewHandler returns a raw handler of a cgroup other than the root, for the
+// handlers of other factories to add metadata to. It can't watch
+// subcontainers.
+func NewHandler(name string, cgroupSubsystems *libcontainer.CgroupSubsystems, machineInfoFactory info.MachineInfoFactory, fsInfo fs.FsInfo, rootFs string, ignoreMetrics container.MetricSet) (container.ContainerHandler, error) {
+	if isRootCgroup(name) {
+		return nil, fmt.Errorf("the root cgroup must be handled by the raw factory")
+	}
+	return newRawContainerHandler(name, cgroupSubsystems, machineInfoFactory, fsInfo, nil, rootFs, ignoreMetrics)
+}
+
 func newRawContainerHandler(name string, cgroupSubsystems *libcontainer.CgroupSubsystems, machineInfoFactory info.MachineInfoFactory, fsInfo fs.FsInfo, watcher *common.InotifyWatcher, rootFs string, ignoreMetrics container.MetricSet) (container.ContainerHandler, error) {
 	cgroupPaths := common.MakeCgroupPaths(cgroupSubsystems.MountPoints, name)
 
@@ -158,7 +170,7 @@ func (self *rawContainerHandler) GetSpec() (info.ContainerSpec, error) {
 		// Get memory and swap limits of the running machine
 		memLimit, err := machine.GetMachineMemoryCapacity()
 		if err != nil {
-			glog.Warningf("failed to obtain memory limit for machine container")
+			logging.Warningf("failed to obtain memory limit for machine container")
 			spec.HasMemory = false
 		} else {
 			spec.Memory.Limit = uint64(memLimit)
@@ -168,7 +180,7 @@ func (self *rawContainerHandler) GetSpec() (info.ContainerSpec, error) {
 
 		swapLimit, err := machine.GetMachineSwapCapacity()
 		if err != nil {
-			glog.Warningf("failed to obtain swap limit for machine container")
+			logging.Warningf("failed to obtain swap limit for machine container")
 		} else {
 			spec.Memory.SwapLimit = uint64(swapLimit)
 		}
@@ -206,6 +218,11 @@ func (self *rawContainerHandler) getFsStats(stats *info.ContainerStats) error {
 					WeightedIoTime:  fs.DiskStats.WeightedIoTime,
 				})
 		}
+		networkFs, err := common.NetworkFsStats(self.fsInfo, nil)
+		if err != nil {
+			return err
+		}
+		stats.Filesystem = append(stats.Filesystem, networkFs...)
 	} else if len(self.externalMounts) > 0 {
 		var mountSet map[string]struct{}
 		mountSet = make(map[string]struct{})
@@ -237,6 +254,15 @@ func (self *rawContainerHandler) getFsStats(stats *info.ContainerStats) error {
 					WeightedIoTime:  fs.DiskStats.WeightedIoTime,
 				})
 		}
+		var hostDirs []string
+		for _, mount := range self.externalMounts {
+			hostDirs = append(hostDirs, mount.HostDir)
+		}
+		networkFs, err := common.NetworkFsStats(self.fsInfo, hostDirs)
+		if err != nil {
+			return err
+		}
+		stats.Filesystem = append(stats.Filesystem, networkFs...)
 	}
 	return nil
 }
@@ -250,6 +276,7 @@ func (self *rawContainerHandler) GetStats() (*info.ContainerStats, error) {
 	// Get filesystem stats.
 	err = self.getFsStats(stats)
 	if err != nil {
+		stats.SetError(info.FilesystemStatsSubsystem, err)
 		return stats, err
 	}
 
@@ -271,7 +298,7 @@ func (self *rawContainerHandler) GetContainerLabels() map[string]string {
 func (self *rawContainerHandler) ListContainers(listType container.ListType) ([]info.ContainerReference, error) {
 	containers := make(map[string]struct{})
 	for _, cgroupPath := range self.cgroupPaths {
-		err := common.ListDirectories(cgroupPath, self.name, listType == container.ListRecursive, containers)
+		err := common.ListDirectoriesPruned(cgroupPath, self.name, listType == container.ListRecursive, containers, pruner.prunes)
 		if err != nil {
 			return nil, err
 		}
@@ -311,7 +338,7 @@ func (self *rawContainerHandler) watchDirectory(dir string, containerName string
 		if cleanup {
 			_, err := self.watcher.RemoveWatch(containerName, dir)
 			if err != nil {
-				glog.Warningf("Failed to remove inotify watch for %q: %v", dir, err)
+				logging.Warningf("Failed to remove inotify watch for %q: %v", dir, err)
 			}
 		}
 	}()
@@ -323,7 +350,7 @@ func (self *rawContainerHandler) watchDirectory(dir string, containerName string
 		return alreadyWatching, err
 	}
 	for _, entry := range entries {
-		if entry.IsDir() {
+		if entry.IsDir() && !pruner.prunes(path.Join(containerName, entry.Name())) {
 			// TODO(vmarmol): We don't have to fail here, maybe we can recover and try to get as many registrations as we can.
 			_, err = self.watchDirectory(path.Join(dir, entry.Name()), path.Join(containerName, entry.Name()))
 			if err != nil {
@@ -365,6 +392,9 @@ func (self *rawContainerHandler) processEvent(event *inotify.Event, events chan
 	if containerName == "" {
 		return fmt.Errorf("unable to detect container from watch event on directory %q", event.Name)
 	}
+	if pruner.prunes(containerName) {
+		return nil
+	}
 
 	// Maintain the watch for the new or deleted container.
 	switch {
@@ -404,6 +434,9 @@ func (self *rawContainerHandler) processEvent(event *inotify.Event, events chan
 }
 
 func (self *rawContainerHandler) WatchSubcontainers(events chan container.SubcontainerEvent) error {
+	if self.watcher == nil {
+		return fmt.Errorf("watch is unimplemented for %q", self.name)
+	}
 	// Watch this container (all its cgroups) and all subdirectories.
 	for _, cgroupPath := range self.cgroupPaths {
 		_, err := self.watchDirectory(cgroupPath, self.name)
@@ -419,10 +452,10 @@ func (self *rawContainerHandler) WatchSubcontainers(events chan container.Subcon
 			case event := <-self.watcher.Event():
 				err := self.processEvent(event, events)
 				if err != nil {
-					glog.Warningf("Error while processing event (%+v): %v", event, err)
+					logging.Warningf("Error while processing event (%+v): %v", event, err)
 				}
 			case err := <-self.watcher.Error():
-				glog.Warningf("Error while watching %q:", self.name, err)
+				logging.Warningf("Error while watching %q: %v", self.name, err)
 			case <-self.stopWatcher:
 				err := self.watcher.Close()
 				if err == nil {
@@ -437,6 +470,9 @@ func (self *rawContainerHandler) WatchSubcontainers(events chan container.Subcon
 }
 
 func (self *rawContainerHandler) StopWatchingSubcontainers() error {
+	if self.watcher == nil {
+		return nil
+	}
 	// Rendezvous with the watcher thread.
 	self.stopWatcher <- nil
 	return <-self.stopWatcher
@@ -0,0 +1,74 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package raw
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var maxContainerDepth = flag.Int("max_container_depth", 0, "Max number of components of the names of the cgroups to track, e.g. 2 for /docker/<id>. Deeper cgroups are neither watched nor tracked. 0 tracks cgroups at any depth")
+var containerPrune = flag.String("container_prune", "", "comma separated list of regular expressions matching the names of cgroups that are neither watched nor tracked, with their subtree, e.g. \\.mount$,/session-[0-9]+\\.scope$")
+
+// Prunes the cgroups that are too deep or that match a prune regexp, with
+// their subtree. A nil pruner prunes nothing.
+type cgroupPruner struct {
+	maxDepth int
+	regexps  []*regexp.Regexp
+}
+
+// Set by Register.
+var pruner *cgroupPruner
+
+func newCgroupPruner(maxDepth int, prune string) (*cgroupPruner, error) {
+	p := &cgroupPruner{
+		maxDepth: maxDepth,
+	}
+	for _, expr := range strings.Split(prune, ",") {
+		if expr = strings.TrimSpace(expr); expr == "" {
+			continue
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --container_prune regexp %q: %v", expr, err)
+		}
+		p.regexps = append(p.regexps, re)
+	}
+	if p.maxDepth <= 0 && len(p.regexps) == 0 {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// Returns whether the cgroup of the container is pruned. Its subtree is then
+// pruned too.
+func (self *cgroupPruner) prunes(name string) bool {
+	if self == nil || name == "/" {
+		return false
+	}
+	if self.maxDepth > 0 && strings.Count(strings.TrimSuffix(name, "/"), "/") > self.maxDepth {
+		return true
+	}
+	for _, re := range self.regexps {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
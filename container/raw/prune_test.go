@@ -0,0 +1,52 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package raw
+
+import (
+	"testing"
+)
+
+func TestCgroupPruner(t *testing.T) {
+	p, err := newCgroupPruner(2, `\.mount$, /session-[0-9]+\.scope$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, expected := range map[string]bool{
+		"/":                                  false,
+		"/docker/abc":                        false,
+		"/docker/abc/sub":                    true,
+		"/system.slice/var-lib-docker.mount": true,
+		"/user.slice/session-3.scope":        true,
+		"/user.slice/user-1000.slice":        false,
+		"/user.slice/user-1000.slice/session-1.scope": true,
+	} {
+		if pruned := p.prunes(name); pruned != expected {
+			t.Errorf("expected %q to be pruned: %v, got %v", name, expected, pruned)
+		}
+	}
+
+	var nilPruner *cgroupPruner
+	if nilPruner.prunes("/a/b/c/d") {
+		t.Errorf("unexpected pruning without pruner")
+	}
+	if p, err := newCgroupPruner(0, ""); p != nil || err != nil {
+		t.Errorf("expected no pruner, got %+v (%v)", p, err)
+	}
+	if _, err := newCgroupPruner(0, "("); err == nil {
+		t.Errorf("expected an error for an invalid regexp")
+	}
+}
@@ -12,6 +12,8 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// +build linux
+
 // Handler for "rkt" containers.
 package rkt
 
@@ -19,7 +21,6 @@ import (
 	"fmt"
 	"os"
 	"path"
-	"time"
 
 	rktapi "github.com/coreos/rkt/api/v1alpha"
 	"github.com/google/cadvisor/container"
@@ -27,9 +28,9 @@ import (
 	"github.com/google/cadvisor/container/libcontainer"
 	"github.com/google/cadvisor/fs"
 	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
 	"golang.org/x/net/context"
 
-	"github.com/golang/glog"
 	"github.com/opencontainers/runc/libcontainer/cgroups"
 	cgroupfs "github.com/opencontainers/runc/libcontainer/cgroups/fs"
 	"github.com/opencontainers/runc/libcontainer/configs"
@@ -109,7 +110,7 @@ func newRktContainerHandler(name string, rktClient rktapi.PublicAPIClient, rktPa
 		} else {
 			var ok bool
 			if annotations, ok = findAnnotations(resp.Pod.Apps, parsed.Container); !ok {
-				glog.Warningf("couldn't find application in Pod matching %v", parsed.Container)
+				logging.Warningf("couldn't find application in Pod matching %v", parsed.Container)
 			}
 		}
 		labels = createLabels(annotations)
@@ -152,7 +153,7 @@ func newRktContainerHandler(name string, rktClient rktapi.PublicAPIClient, rktPa
 	}
 
 	if !ignoreMetrics.Has(container.DiskUsageMetrics) {
-		handler.fsHandler = common.NewFsHandler(time.Minute, []string{rootfsStorageDir}, []string{}, fsInfo)
+		handler.fsHandler = common.NewFsHandler(*common.DiskUsagePeriod, []string{rootfsStorageDir}, []string{}, fsInfo)
 	}
 
 	return handler, nil
@@ -225,6 +226,7 @@ func (handler *rktContainerHandler) GetStats() (*info.ContainerStats, error) {
 	// Get filesystem stats.
 	err = handler.getFsStats(stats)
 	if err != nil {
+		stats.SetError(info.FilesystemStatsSubsystem, err)
 		return stats, err
 	}
 
@@ -272,7 +274,7 @@ func (handler *rktContainerHandler) ListContainers(listType container.ListType)
 
 		labels := make(map[string]string)
 		if annotations, ok := findAnnotations(handler.apiPod.Apps, parsed.Container); !ok {
-			glog.Warningf("couldn't find application in Pod matching %v", parsed.Container)
+			logging.Warningf("couldn't find application in Pod matching %v", parsed.Container)
 		} else {
 			labels = createLabels(annotations)
 		}
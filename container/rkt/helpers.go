@@ -20,7 +20,7 @@ import (
 	"path"
 	"strings"
 
-	"github.com/golang/glog"
+	"github.com/google/cadvisor/logging"
 )
 
 type parsedName struct {
@@ -80,7 +80,7 @@ func getRootFs(root string, parsed *parsedName) string {
 
 	bytes, err := ioutil.ReadFile(tree)
 	if err != nil {
-		glog.Infof("ReadFile failed, couldn't read %v to get upper dir: %v", tree, err)
+		logging.Infof("ReadFile failed, couldn't read %v to get upper dir: %v", tree, err)
 		return ""
 	}
 
@@ -15,24 +15,76 @@
 package systemd
 
 import (
+	"flag"
 	"fmt"
+	"path"
 	"strings"
 
 	"github.com/google/cadvisor/container"
+	"github.com/google/cadvisor/container/libcontainer"
 	"github.com/google/cadvisor/fs"
 	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
 
-	"github.com/golang/glog"
+	"github.com/coreos/go-systemd/dbus"
 )
 
-type systemdFactory struct{}
+var argSystemdUnits = flag.Bool("systemd_units", false, "Track the cgroups of systemd services, scopes and slices as systemd containers, aliased by their unit name and with metadata from D-Bus")
+
+const SystemdNamespace = "systemd"
+
+// Suffixes of the cgroups of the units tracked with --systemd_units.
+var unitSuffixes = []string{".service", ".scope", ".slice"}
+
+type systemdFactory struct {
+	machineInfoFactory info.MachineInfoFactory
+
+	cgroupSubsystems *libcontainer.CgroupSubsystems
+
+	fsInfo fs.FsInfo
+
+	ignoreMetrics container.MetricSet
+
+	// Connection to the system instance of systemd, nil if D-Bus is not
+	// available.
+	conn *dbus.Conn
+}
 
 func (f *systemdFactory) String() string {
 	return "systemd"
 }
 
 func (f *systemdFactory) NewContainerHandler(name string, inHostNamespace bool) (container.ContainerHandler, error) {
-	return nil, fmt.Errorf("Not yet supported")
+	if !*argSystemdUnits {
+		return nil, fmt.Errorf("Not yet supported")
+	}
+	rootFs := "/"
+	if !inHostNamespace {
+		rootFs = "/rootfs"
+	}
+	return newSystemdContainerHandler(name, f.conn, f.cgroupSubsystems, f.machineInfoFactory, f.fsInfo, rootFs, f.ignoreMetrics)
+}
+
+// Returns whether the cgroup is the one of a systemd unit tracked with
+// --systemd_units.
+func isUnit(name string) bool {
+	for _, suffix := range unitSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns whether the unit of the cgroup is managed by the systemd instance of
+// a user, e.g. "/user.slice/user-1000.slice/user@1000.service/app.slice".
+func isUserUnit(name string) bool {
+	for dir := path.Dir(name); dir != "/" && dir != "."; dir = path.Dir(dir) {
+		if base := path.Base(dir); strings.HasPrefix(base, "user@") && strings.HasSuffix(base, ".service") {
+			return true
+		}
+	}
+	return false
 }
 
 func (f *systemdFactory) CanHandleAndAccept(name string) (bool, bool, error) {
@@ -41,6 +93,9 @@ func (f *systemdFactory) CanHandleAndAccept(name string) (bool, bool, error) {
 	if strings.HasSuffix(name, ".mount") {
 		return true, false, nil
 	}
+	if *argSystemdUnits && isUnit(name) {
+		return true, true, nil
+	}
 	return false, false, fmt.Errorf("%s not handled by systemd handler", name)
 }
 
@@ -50,8 +105,23 @@ func (f *systemdFactory) DebugInfo() map[string][]string {
 
 // Register registers the systemd container factory.
 func Register(machineInfoFactory info.MachineInfoFactory, fsInfo fs.FsInfo, ignoreMetrics container.MetricSet) error {
-	glog.Infof("Registering systemd factory")
-	factory := &systemdFactory{}
+	logging.Infof("Registering systemd factory")
+	factory := &systemdFactory{
+		machineInfoFactory: machineInfoFactory,
+		fsInfo:             fsInfo,
+		ignoreMetrics:      ignoreMetrics,
+	}
+	if *argSystemdUnits {
+		cgroupSubsystems, err := libcontainer.GetCgroupSubsystems()
+		if err != nil {
+			return fmt.Errorf("failed to get cgroup subsystems: %v", err)
+		}
+		factory.cgroupSubsystems = &cgroupSubsystems
+		factory.conn, err = dbus.New()
+		if err != nil {
+			logging.Warningf("Failed to connect to systemd over D-Bus, systemd units will have no metadata: %v", err)
+		}
+	}
 	container.RegisterContainerHandlerFactory(factory)
 	return nil
 }
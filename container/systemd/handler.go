@@ -0,0 +1,121 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Handler for the cgroups of systemd units.
+package systemd
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/google/cadvisor/container"
+	"github.com/google/cadvisor/container/libcontainer"
+	"github.com/google/cadvisor/container/raw"
+	"github.com/google/cadvisor/fs"
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
+
+	"github.com/coreos/go-systemd/dbus"
+)
+
+// Labels of the metadata of units.
+const (
+	unitLabel        = "systemd.unit"
+	descriptionLabel = "systemd.description"
+	unitFileLabel    = "systemd.unit_file"
+)
+
+// Reads the stats and subcontainers of the unit as a raw cgroup and adds its
+// metadata.
+type systemdContainerHandler struct {
+	container.ContainerHandler
+
+	name   string
+	unit   string
+	labels map[string]string
+	// Time the unit was activated, zero if unknown.
+	activeSince time.Time
+}
+
+func newSystemdContainerHandler(name string, conn *dbus.Conn, cgroupSubsystems *libcontainer.CgroupSubsystems, machineInfoFactory info.MachineInfoFactory, fsInfo fs.FsInfo, rootFs string, ignoreMetrics container.MetricSet) (container.ContainerHandler, error) {
+	rawHandler, err := raw.NewHandler(name, cgroupSubsystems, machineInfoFactory, fsInfo, rootFs, ignoreMetrics)
+	if err != nil {
+		return nil, err
+	}
+	unit := path.Base(name)
+	handler := &systemdContainerHandler{
+		ContainerHandler: rawHandler,
+		name:             name,
+		unit:             unit,
+		labels: map[string]string{
+			unitLabel: unit,
+		},
+	}
+	// Units of the instances of users are not known to the system instance.
+	if conn != nil && !isUserUnit(name) {
+		props, err := conn.GetUnitProperties(unit)
+		if err != nil {
+			logging.Warningf("Failed to get the properties of systemd unit %q: %v", unit, err)
+		} else {
+			handler.addProperties(props)
+		}
+	}
+	return handler, nil
+}
+
+// Adds the metadata of the D-Bus properties of the unit.
+func (self *systemdContainerHandler) addProperties(props map[string]interface{}) {
+	if description, ok := props["Description"].(string); ok && description != "" {
+		self.labels[descriptionLabel] = description
+	}
+	if unitFile, ok := props["FragmentPath"].(string); ok && unitFile != "" {
+		self.labels[unitFileLabel] = unitFile
+	}
+	// In microseconds since the epoch.
+	if since, ok := props["ActiveEnterTimestamp"].(uint64); ok && since != 0 {
+		self.activeSince = time.Unix(0, int64(since)*int64(time.Microsecond))
+	}
+}
+
+func (self *systemdContainerHandler) ContainerReference() (info.ContainerReference, error) {
+	return info.ContainerReference{
+		Name:      self.name,
+		Aliases:   []string{self.unit},
+		Namespace: SystemdNamespace,
+		Labels:    self.labels,
+	}, nil
+}
+
+func (self *systemdContainerHandler) GetSpec() (info.ContainerSpec, error) {
+	spec, err := self.ContainerHandler.GetSpec()
+	spec.Labels = self.labels
+	if !self.activeSince.IsZero() {
+		spec.CreationTime = self.activeSince
+	}
+	return spec, err
+}
+
+func (self *systemdContainerHandler) GetContainerLabels() map[string]string {
+	return self.labels
+}
+
+func (self *systemdContainerHandler) WatchSubcontainers(events chan container.SubcontainerEvent) error {
+	return fmt.Errorf("watch is unimplemented in the systemd container driver")
+}
+
+func (self *systemdContainerHandler) StopWatchingSubcontainers() error {
+	// No-op for systemd driver.
+	return nil
+}
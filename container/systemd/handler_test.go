@@ -0,0 +1,70 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package systemd
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsUnit(t *testing.T) {
+	for name, expected := range map[string]bool{
+		"/system.slice":                true,
+		"/system.slice/nginx.service":  true,
+		"/user.slice/session-1.scope":  true,
+		"/system.slice/var-lib.mount":  false,
+		"/docker/0123456789abcdef":     false,
+		"/system.slice/nginx.service/": false,
+	} {
+		if actual := isUnit(name); actual != expected {
+			t.Errorf("expected isUnit(%q) to be %v", name, expected)
+		}
+	}
+}
+
+func TestIsUserUnit(t *testing.T) {
+	for name, expected := range map[string]bool{
+		"/user.slice/user-1000.slice/user@1000.service/app.slice":             true,
+		"/user.slice/user-1000.slice/user@1000.service/app.slice/foo.service": true,
+		"/user.slice/user-1000.slice/user@1000.service":                       false,
+		"/system.slice/nginx.service":                                         false,
+	} {
+		if actual := isUserUnit(name); actual != expected {
+			t.Errorf("expected isUserUnit(%q) to be %v", name, expected)
+		}
+	}
+}
+
+func TestAddProperties(t *testing.T) {
+	handler := &systemdContainerHandler{
+		labels: map[string]string{
+			unitLabel: "nginx.service",
+		},
+	}
+	handler.addProperties(map[string]interface{}{
+		"Description":          "A high performance web server",
+		"FragmentPath":         "/lib/systemd/system/nginx.service",
+		"ActiveEnterTimestamp": uint64(1450000000000000),
+	})
+	if handler.labels[descriptionLabel] != "A high performance web server" {
+		t.Errorf("unexpected description %q", handler.labels[descriptionLabel])
+	}
+	if handler.labels[unitFileLabel] != "/lib/systemd/system/nginx.service" {
+		t.Errorf("unexpected unit file %q", handler.labels[unitFileLabel])
+	}
+	if !handler.activeSince.Equal(time.Unix(1450000000, 0)) {
+		t.Errorf("unexpected activation time %v", handler.activeSince)
+	}
+}
@@ -22,9 +22,8 @@ import (
 	"time"
 
 	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
 	"github.com/google/cadvisor/utils"
-
-	"github.com/golang/glog"
 )
 
 type byTimestamp []*info.Event
@@ -103,6 +102,8 @@ type events struct {
 	lastId int
 	// Event storage policy.
 	storagePolicy StoragePolicy
+	// Persistent storage of the events, if enabled.
+	store *persistentStore
 }
 
 // initialized by a call to WatchEvents(), a watch struct will then be added
@@ -156,6 +157,32 @@ func NewEventManager(storagePolicy StoragePolicy) *events {
 	}
 }
 
+// returns a pointer to an initialized Events object that also keeps events
+// in the file at path for maxAge. Stored events still within the in-memory
+// limits of the storage policy are loaded back.
+func NewPersistentEventManager(storagePolicy StoragePolicy, path string, maxAge time.Duration) (*events, error) {
+	store, err := newPersistentStore(path, maxAge)
+	if err != nil {
+		return nil, err
+	}
+	self := NewEventManager(storagePolicy)
+	self.store = store
+	now := time.Now()
+	loaded := 0
+	err = store.scan(func(e *info.Event) {
+		if e.Timestamp.After(now.Add(-self.maxAge(e.EventType))) {
+			self.updateEventStore(e)
+			loaded++
+		}
+	})
+	if err != nil {
+		store.close()
+		return nil, err
+	}
+	logging.Infof("Loaded %d events from %q", loaded, path)
+	return self, nil
+}
+
 // returns a pointer to an initialized Request object
 func NewRequest() *Request {
 	return &Request{
@@ -231,14 +258,22 @@ func checkIfEventSatisfiesRequest(request *Request, event *info.Event) bool {
 // adds it to a slice of *Event objects that is returned. If both MaxEventsReturned
 // and StartTime/EndTime are specified in the request object, then only
 // up to the most recent MaxEventsReturned events in that time range are returned.
+// Requests starting before the in-memory limits are served from the persistent
+// storage, if enabled.
 func (self *events) GetEvents(request *Request) ([]*info.Event, error) {
 	returnEventList := []*info.Event{}
+	storedTypes := make(map[info.EventType]bool)
+	now := time.Now()
 	self.eventsLock.RLock()
 	defer self.eventsLock.RUnlock()
 	for eventType, fetch := range request.EventType {
 		if !fetch {
 			continue
 		}
+		if self.store != nil && !request.StartTime.IsZero() && request.StartTime.Before(now.Add(-self.maxAge(eventType))) {
+			storedTypes[eventType] = true
+			continue
+		}
 		evs, ok := self.eventStore[eventType]
 		if !ok {
 			continue
@@ -252,6 +287,13 @@ func (self *events) GetEvents(request *Request) ([]*info.Event, error) {
 			}
 		}
 	}
+	if len(storedTypes) != 0 {
+		stored, err := self.store.get(request, storedTypes)
+		if err != nil {
+			return nil, err
+		}
+		returnEventList = append(returnEventList, stored...)
+	}
 	returnEventList = getMaxEventsReturned(request, returnEventList)
 	return returnEventList, nil
 }
@@ -276,16 +318,21 @@ func (self *events) WatchEvents(request *Request) (*EventChannel, error) {
 	return returnEventChannel, nil
 }
 
+// returns how long events of the given type are kept in memory
+func (self *events) maxAge(eventType info.EventType) time.Duration {
+	if age, ok := self.storagePolicy.PerTypeMaxAge[eventType]; ok {
+		return age
+	}
+	return self.storagePolicy.DefaultMaxAge
+}
+
 // helper function to update the event manager's eventStore
 func (self *events) updateEventStore(e *info.Event) {
 	self.eventsLock.Lock()
 	defer self.eventsLock.Unlock()
 	if _, ok := self.eventStore[e.EventType]; !ok {
-		maxAge := self.storagePolicy.DefaultMaxAge
+		maxAge := self.maxAge(e.EventType)
 		maxNumEvents := self.storagePolicy.DefaultMaxNumEvents
-		if age, ok := self.storagePolicy.PerTypeMaxAge[e.EventType]; ok {
-			maxAge = age
-		}
 		if numEvents, ok := self.storagePolicy.PerTypeMaxNumEvents[e.EventType]; ok {
 			maxNumEvents = numEvents
 		}
@@ -311,13 +358,18 @@ func (self *events) findValidWatchers(e *info.Event) []*watch {
 // held by the manager if it satisfies the request keys of the channels
 func (self *events) AddEvent(e *info.Event) error {
 	self.updateEventStore(e)
+	if self.store != nil {
+		if err := self.store.add(e); err != nil {
+			logging.Warningf("Failed to persist event %v: %v", e, err)
+		}
+	}
 	self.watcherLock.RLock()
 	defer self.watcherLock.RUnlock()
 	watchesToSend := self.findValidWatchers(e)
 	for _, watchObject := range watchesToSend {
 		watchObject.eventChannel.GetChannel() <- e
 	}
-	glog.V(4).Infof("Added event %v", e)
+	logging.V(4).Infof("Added event %v", e)
 	return nil
 }
 
@@ -327,7 +379,7 @@ func (self *events) StopWatch(watchId int) {
 	defer self.watcherLock.Unlock()
 	_, ok := self.watchers[watchId]
 	if !ok {
-		glog.Errorf("Could not find watcher instance %v", watchId)
+		logging.Errorf("Could not find watcher instance %v", watchId)
 	}
 	close(self.watchers[watchId].eventChannel.GetChannel())
 	delete(self.watchers, watchId)
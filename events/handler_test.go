@@ -15,6 +15,9 @@
 package events
 
 import (
+	"io/ioutil"
+	"os"
+	"path"
 	"testing"
 	"time"
 
@@ -195,3 +198,40 @@ func TestGetEventsForNoTypeRequested(t *testing.T) {
 	assert.Nil(t, err)
 	checkNumberOfEvents(t, 0, len(receivedEvents))
 }
+
+func TestPersistentEvents(t *testing.T) {
+	dir, err := ioutil.TempDir("", "events")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := path.Join(dir, "events")
+
+	now := time.Now()
+	policy := DefaultStoragePolicy()
+	policy.DefaultMaxAge = time.Hour
+	myEventHolder, err := NewPersistentEventManager(policy, file, 7*24*time.Hour)
+	assert.NoError(t, err)
+	myEventHolder.AddEvent(makeEvent(now.Add(-30*24*time.Hour), "/expired"))
+	myEventHolder.AddEvent(makeEvent(now.Add(-48*time.Hour), "/old"))
+	myEventHolder.AddEvent(makeEvent(now.Add(-time.Minute), "/recent"))
+	assert.NoError(t, myEventHolder.store.close())
+
+	// Recent events are loaded back in memory and expired ones are dropped.
+	myEventHolder, err = NewPersistentEventManager(policy, file, 7*24*time.Hour)
+	assert.NoError(t, err)
+	defer myEventHolder.store.close()
+	myRequest := NewRequest()
+	myRequest.EventType[info.EventOom] = true
+	myRequest.MaxEventsReturned = -1
+	returnedEvents, err := myEventHolder.GetEvents(myRequest)
+	assert.NoError(t, err)
+	checkNumberOfEvents(t, 1, len(returnedEvents))
+	assert.Equal(t, "/recent", returnedEvents[0].ContainerName)
+
+	// Older events are read from the file.
+	myRequest.StartTime = now.Add(-72 * time.Hour)
+	returnedEvents, err = myEventHolder.GetEvents(myRequest)
+	assert.NoError(t, err)
+	checkNumberOfEvents(t, 2, len(returnedEvents))
+	assert.Equal(t, "/old", returnedEvents[0].ContainerName)
+	assert.Equal(t, "/recent", returnedEvents[1].ContainerName)
+}
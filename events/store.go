@@ -0,0 +1,174 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
+)
+
+// How often expired events are removed from the file.
+const compactInterval = time.Hour
+
+// persistentStore keeps events in a file of JSON lines so that they survive
+// restarts and can be queried beyond the in-memory retention.
+type persistentStore struct {
+	lock sync.Mutex
+	path string
+	file *os.File
+	// Events older than maxAge are dropped when compacting.
+	maxAge      time.Duration
+	lastCompact time.Time
+	now         func() time.Time
+}
+
+func newPersistentStore(path string, maxAge time.Duration) (*persistentStore, error) {
+	self := &persistentStore{
+		path:   path,
+		maxAge: maxAge,
+		now:    time.Now,
+	}
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if err := self.compact(); err != nil {
+		return nil, err
+	}
+	return self, nil
+}
+
+// Calls fn with the stored events, oldest first. Lines that can not be
+// decoded, such as one truncated by a crash, are skipped.
+func (self *persistentStore) scan(fn func(e *info.Event)) error {
+	f, err := os.Open(self.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open event storage file: %v", err)
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		e := &info.Event{}
+		if err := json.Unmarshal(scanner.Bytes(), e); err != nil {
+			logging.V(4).Infof("Skipping malformed event in %q: %v", self.path, err)
+			continue
+		}
+		fn(e)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read event storage file: %v", err)
+	}
+	return nil
+}
+
+// Rewrites the file without the expired events and reopens it for appending.
+// Must be called with the lock held.
+func (self *persistentStore) compact() error {
+	now := self.now()
+	cutoff := now.Add(-self.maxAge)
+	tmpPath := self.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to compact event storage file: %v", err)
+	}
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	err = self.scan(func(e *info.Event) {
+		if e.Timestamp.Before(cutoff) {
+			return
+		}
+		if err := enc.Encode(e); err != nil {
+			logging.Warningf("Failed to rewrite event %v: %v", e, err)
+		}
+	})
+	if err == nil {
+		err = w.Flush()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, self.path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to compact event storage file: %v", err)
+	}
+
+	if self.file != nil {
+		self.file.Close()
+	}
+	self.file, err = os.OpenFile(self.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		self.file = nil
+		return fmt.Errorf("failed to open event storage file: %v", err)
+	}
+	self.lastCompact = now
+	return nil
+}
+
+// Appends the event to the file.
+func (self *persistentStore) add(e *info.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if self.now().Sub(self.lastCompact) >= compactInterval {
+		if err := self.compact(); err != nil {
+			return err
+		}
+	}
+	if self.file == nil {
+		return fmt.Errorf("event storage file %q is not open", self.path)
+	}
+	_, err = self.file.Write(append(data, '\n'))
+	return err
+}
+
+// Returns the stored events of the given types in the time range of the
+// request, oldest first.
+func (self *persistentStore) get(request *Request, eventTypes map[info.EventType]bool) ([]*info.Event, error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	result := []*info.Event{}
+	err := self.scan(func(e *info.Event) {
+		if eventTypes[e.EventType] && checkIfEventSatisfiesRequest(request, e) {
+			result = append(result, e)
+		}
+	})
+	return result, err
+}
+
+func (self *persistentStore) close() error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if self.file == nil {
+		return nil
+	}
+	err := self.file.Close()
+	self.file = nil
+	return err
+}
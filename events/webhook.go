@@ -0,0 +1,171 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
+)
+
+var argWebhookURL = flag.String("event_webhook_url", "", "URL to POST container events to as JSON. Empty disables the webhook")
+var argWebhookTypes = flag.String("event_webhook_types", "containerCreation,containerDeletion,oom,oomKill", "comma separated list of the event types to POST to --event_webhook_url")
+var argWebhookContainers = flag.String("event_webhook_containers", "", "regular expression matching the absolute names of the containers whose events are POSTed to --event_webhook_url. Empty matches all containers")
+var argWebhookMaxRetries = flag.Int("event_webhook_max_retries", 5, "max number of times the delivery of an event to --event_webhook_url is retried, with exponential backoff")
+
+const (
+	// Events waiting for delivery beyond this are dropped.
+	webhookQueueSize      = 1000
+	webhookInitialBackoff = time.Second
+	webhookMaxBackoff     = time.Minute
+)
+
+// WebhookEvent is the JSON body POSTed for each event.
+type WebhookEvent struct {
+	Hostname string      `json:"hostname"`
+	Event    *info.Event `json:"event"`
+}
+
+type webhook struct {
+	url        string
+	hostname   string
+	containers *regexp.Regexp
+	maxRetries int
+	// Backoff before the first retry, doubled for each retry.
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	client         *http.Client
+	queue          chan *info.Event
+}
+
+// StartWebhook POSTs the events of the event manager that match the filters
+// given on the command line to --event_webhook_url. It does nothing if no URL
+// was given.
+func StartWebhook(m EventManager) error {
+	if *argWebhookURL == "" {
+		return nil
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return err
+	}
+	w := &webhook{
+		url:            *argWebhookURL,
+		hostname:       hostname,
+		maxRetries:     *argWebhookMaxRetries,
+		initialBackoff: webhookInitialBackoff,
+		maxBackoff:     webhookMaxBackoff,
+		client:         &http.Client{Timeout: 10 * time.Second},
+		queue:          make(chan *info.Event, webhookQueueSize),
+	}
+	if *argWebhookContainers != "" {
+		w.containers, err = regexp.Compile(*argWebhookContainers)
+		if err != nil {
+			return fmt.Errorf("invalid --event_webhook_containers: %v", err)
+		}
+	}
+	request := NewRequest()
+	request.ContainerName = "/"
+	request.IncludeSubcontainers = true
+	for _, eventType := range strings.Split(*argWebhookTypes, ",") {
+		if eventType = strings.TrimSpace(eventType); eventType != "" {
+			request.EventType[info.EventType(eventType)] = true
+		}
+	}
+	eventChannel, err := m.WatchEvents(request)
+	if err != nil {
+		return err
+	}
+	logging.Infof("POSTing events to %q", w.url)
+	go w.deliver()
+	go func() {
+		for event := range eventChannel.GetChannel() {
+			w.enqueue(event)
+		}
+	}()
+	return nil
+}
+
+// Queues the event for delivery unless it is filtered out or the queue is full.
+func (self *webhook) enqueue(event *info.Event) {
+	if self.containers != nil && !self.containers.MatchString(event.ContainerName) {
+		return
+	}
+	select {
+	case self.queue <- event:
+	default:
+		logging.Warningf("Dropping event %v, too many events waiting for delivery to %q", event, self.url)
+	}
+}
+
+// Delivers the queued events in order.
+func (self *webhook) deliver() {
+	for event := range self.queue {
+		if err := self.post(event); err != nil {
+			logging.Warningf("Failed to POST event %v to %q: %v", event, self.url, err)
+		}
+	}
+}
+
+// POSTs the event, retrying with exponential backoff on errors that may be
+// transient.
+func (self *webhook) post(event *info.Event) error {
+	body, err := json.Marshal(&WebhookEvent{
+		Hostname: self.hostname,
+		Event:    event,
+	})
+	if err != nil {
+		return err
+	}
+	backoff := self.initialBackoff
+	for retry := 0; ; retry++ {
+		retryable, err := self.postOnce(body)
+		if err == nil {
+			return nil
+		}
+		if !retryable || retry >= self.maxRetries {
+			return err
+		}
+		logging.V(2).Infof("Retrying POST of event to %q in %v: %v", self.url, backoff, err)
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > self.maxBackoff {
+			backoff = self.maxBackoff
+		}
+	}
+}
+
+// Returns whether the POST may succeed if retried.
+func (self *webhook) postOnce(body []byte) (bool, error) {
+	resp, err := self.client.Post(self.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("event rejected with status %q", resp.Status)
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests, err
+}
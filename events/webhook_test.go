@@ -0,0 +1,86 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	info "github.com/google/cadvisor/info/v1"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestWebhook(url string) *webhook {
+	return &webhook{
+		url:            url,
+		hostname:       "node1",
+		maxRetries:     2,
+		initialBackoff: time.Millisecond,
+		maxBackoff:     time.Millisecond,
+		client:         &http.Client{},
+		queue:          make(chan *info.Event, 1),
+	}
+}
+
+func TestWebhookRetries(t *testing.T) {
+	attempts := 0
+	failures := 2
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		received := &WebhookEvent{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(received))
+		assert.Equal(t, "node1", received.Hostname)
+		assert.Equal(t, "/docker/a", received.Event.ContainerName)
+		if attempts <= failures {
+			w.WriteHeader(status)
+		}
+	}))
+	defer server.Close()
+
+	w := newTestWebhook(server.URL)
+	event := makeEvent(time.Now(), "/docker/a")
+	assert.NoError(t, w.post(event))
+	assert.Equal(t, 3, attempts)
+
+	// Gives up after the max number of retries.
+	attempts = 0
+	failures = 10
+	assert.Error(t, w.post(event))
+	assert.Equal(t, 3, attempts)
+
+	// Client errors are not retried.
+	attempts = 0
+	status = http.StatusBadRequest
+	assert.Error(t, w.post(event))
+	assert.Equal(t, 1, attempts)
+}
+
+func TestWebhookFilter(t *testing.T) {
+	w := newTestWebhook("")
+	w.containers = regexp.MustCompile("^/docker/")
+	w.enqueue(makeEvent(time.Now(), "/system"))
+	assert.Len(t, w.queue, 0)
+	w.enqueue(makeEvent(time.Now(), "/docker/a"))
+	assert.Len(t, w.queue, 1)
+	// Events are dropped when the queue is full.
+	w.enqueue(makeEvent(time.Now(), "/docker/b"))
+	assert.Len(t, w.queue, 1)
+}
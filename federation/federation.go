@@ -0,0 +1,238 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package federation merges the machine and container listings of peer
+// cAdvisor instances and proxies requests to them.
+package federation
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/info/v2"
+)
+
+var argPeers = flag.String("federation_peers", "", "comma separated list of base URLs (e.g. http://node1:8080) of peer cAdvisors to federate")
+var argTimeout = flag.Duration("federation_timeout", 5*time.Second, "timeout of requests to federation peers")
+var argTokenFile = flag.String("federation_token_file", "", "file holding the bearer token cAdvisor authenticates to federation peers with. The credentials of the clients are never forwarded to peers. Empty sends no credentials")
+
+// Listing of a single node of the federation.
+type NodeInfo struct {
+	// Base URL of the node. Empty for the local node.
+	URL string `json:"url,omitempty"`
+	// Machine information of the node.
+	Machine *info.MachineInfo `json:"machine,omitempty"`
+	// Specs of all the containers of the node, keyed by absolute name.
+	Containers map[string]v2.ContainerSpec `json:"containers,omitempty"`
+	// Why the node could not be listed.
+	Error string `json:"error,omitempty"`
+}
+
+type Federation struct {
+	lock sync.RWMutex
+	// Base URL of the peers keyed by peer name.
+	peers  map[string]*url.URL
+	client *http.Client
+	// Bearer token sent to the peers, if any.
+	token string
+}
+
+// New returns a federation of the peers given on the command line.
+func New() (*Federation, error) {
+	var peers []string
+	if *argPeers != "" {
+		peers = strings.Split(*argPeers, ",")
+	}
+	token := ""
+	if *argTokenFile != "" {
+		b, err := ioutil.ReadFile(*argTokenFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the federation token: %v", err)
+		}
+		token = string(bytes.TrimSpace(b))
+	}
+	return newFederation(peers, *argTimeout, token)
+}
+
+func newFederation(peers []string, timeout time.Duration, token string) (*Federation, error) {
+	self := &Federation{
+		peers: make(map[string]*url.URL),
+		client: &http.Client{
+			Timeout: timeout,
+		},
+		token: token,
+	}
+	for _, peer := range peers {
+		if _, err := self.AddPeer(peer); err != nil {
+			return nil, err
+		}
+	}
+	return self, nil
+}
+
+// AddPeer adds the cAdvisor listening at the given base URL to the
+// federation and returns the name it is known by: the host and port of the URL.
+func (self *Federation) AddPeer(rawurl string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawurl))
+	if err != nil {
+		return "", fmt.Errorf("invalid peer URL %q: %v", rawurl, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid peer URL %q: expected http(s)://host[:port][/path]", rawurl)
+	}
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.peers[u.Host] = u
+	return u.Host, nil
+}
+
+// Peers returns the sorted names of the peers.
+func (self *Federation) Peers() []string {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	names := make([]string, 0, len(self.peers))
+	for name := range self.peers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (self *Federation) getPeer(name string) (*url.URL, error) {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	u, ok := self.peers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown peer %q", name)
+	}
+	return u, nil
+}
+
+// Returns the URL of the given API path on the peer.
+func apiURL(base *url.URL, apiPath string) *url.URL {
+	u := *base
+	u.Path = path.Join(u.Path, "/api", apiPath)
+	return &u
+}
+
+// Decodes the JSON response of the given API path on the peer.
+func (self *Federation) get(base *url.URL, apiPath string, query url.Values, out interface{}) error {
+	u := apiURL(base, apiPath)
+	u.RawQuery = query.Encode()
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return err
+	}
+	self.authenticate(req)
+	resp, err := self.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to %q failed with status %q", base.Host, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (self *Federation) listNode(base *url.URL) NodeInfo {
+	node := NodeInfo{
+		URL: base.String(),
+	}
+	machine := &info.MachineInfo{}
+	if err := self.get(base, "/v2.0/machine", nil, machine); err != nil {
+		node.Error = err.Error()
+		return node
+	}
+	node.Machine = machine
+	specs := make(map[string]v2.ContainerSpec)
+	if err := self.get(base, "/v2.0/spec/", url.Values{"recursive": {"true"}}, &specs); err != nil {
+		node.Error = err.Error()
+		return node
+	}
+	node.Containers = specs
+	return node
+}
+
+// ListPeers lists the machine and containers of all the peers concurrently.
+// Peers that fail to answer are reported with an error.
+func (self *Federation) ListPeers() map[string]NodeInfo {
+	self.lock.RLock()
+	peers := make(map[string]*url.URL, len(self.peers))
+	for name, u := range self.peers {
+		peers[name] = u
+	}
+	self.lock.RUnlock()
+
+	var wg sync.WaitGroup
+	var resultLock sync.Mutex
+	result := make(map[string]NodeInfo, len(peers))
+	for name, u := range peers {
+		wg.Add(1)
+		go func(name string, u *url.URL) {
+			defer wg.Done()
+			node := self.listNode(u)
+			resultLock.Lock()
+			result[name] = node
+			resultLock.Unlock()
+		}(name, u)
+	}
+	wg.Wait()
+	return result
+}
+
+// Replaces the credentials of the request with the ones of cAdvisor.
+func (self *Federation) authenticate(req *http.Request) {
+	req.Header.Del("Authorization")
+	req.Header.Del("Cookie")
+	if self.token != "" {
+		req.Header.Set("Authorization", "Bearer "+self.token)
+	}
+}
+
+// Proxy forwards the request to the given API path of the named peer, with
+// the credentials of cAdvisor instead of the client's. The caller checks that
+// the client may make the proxied request.
+func (self *Federation) Proxy(name string, apiPath string, w http.ResponseWriter, r *http.Request) error {
+	base, err := self.getPeer(name)
+	if err != nil {
+		return err
+	}
+	target := apiURL(base, apiPath)
+	proxy := &httputil.ReverseProxy{
+		Director: func(req *http.Request) {
+			req.URL.Scheme = target.Scheme
+			req.URL.Host = target.Host
+			req.URL.Path = target.Path
+			req.Host = target.Host
+			self.authenticate(req)
+		},
+		// Flush periodically so that streamed responses reach the client.
+		FlushInterval: 100 * time.Millisecond,
+	}
+	proxy.ServeHTTP(w, r)
+	return nil
+}
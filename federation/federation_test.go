@@ -0,0 +1,112 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package federation
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPeer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v2.0/machine":
+			fmt.Fprint(w, `{"num_cores": 4}`)
+		case "/api/v2.0/spec":
+			assert.Equal(t, "true", r.URL.Query().Get("recursive"))
+			fmt.Fprint(w, `{"/docker/abc": {"image": "nginx"}}`)
+		default:
+			fmt.Fprintf(w, "%s?%s %s", r.URL.Path, r.URL.RawQuery, r.Header.Get("Authorization"))
+		}
+	}))
+}
+
+func TestListPeers(t *testing.T) {
+	peer := newPeer(t)
+	defer peer.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	defer down.Close()
+
+	fed, err := newFederation([]string{peer.URL, down.URL}, time.Second, "")
+	require.NoError(t, err)
+	assert.Len(t, fed.Peers(), 2)
+
+	nodes := fed.ListPeers()
+	node := nodes[strings.TrimPrefix(peer.URL, "http://")]
+	assert.Empty(t, node.Error)
+	assert.Equal(t, 4, node.Machine.NumCores)
+	assert.Equal(t, "nginx", node.Containers["/docker/abc"].Image)
+
+	node = nodes[strings.TrimPrefix(down.URL, "http://")]
+	assert.NotEmpty(t, node.Error)
+	assert.Nil(t, node.Machine)
+}
+
+func TestProxy(t *testing.T) {
+	peer := newPeer(t)
+	defer peer.Close()
+	fed, err := newFederation([]string{peer.URL}, time.Second, "")
+	require.NoError(t, err)
+	name := fed.Peers()[0]
+
+	rec := httptest.NewRecorder()
+	r, err := http.NewRequest("GET", "http://localhost/api/v2.1/federate/"+name+"/v2.1/stats/docker/abc?count=1", nil)
+	require.NoError(t, err)
+	require.NoError(t, fed.Proxy(name, "/v2.1/stats/docker/abc", rec, r))
+	body, err := ioutil.ReadAll(rec.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "/api/v2.1/stats/docker/abc?count=1 ", string(body))
+
+	assert.Error(t, fed.Proxy("unknown:8080", "/v2.1/machine", rec, r))
+}
+
+func TestProxyCredentials(t *testing.T) {
+	peer := newPeer(t)
+	defer peer.Close()
+	fed, err := newFederation([]string{peer.URL}, time.Second, "peer-token")
+	require.NoError(t, err)
+	name := fed.Peers()[0]
+
+	rec := httptest.NewRecorder()
+	r, err := http.NewRequest("GET", "http://localhost/api/v2.1/federate/"+name+"/v2.1/machine", nil)
+	require.NoError(t, err)
+	r.Header.Set("Authorization", "Bearer client-token")
+	r.Header.Set("Cookie", "session=client")
+	require.NoError(t, fed.Proxy(name, "/v2.1/version", rec, r))
+	body, err := ioutil.ReadAll(rec.Body)
+	require.NoError(t, err)
+	// The peer gets the token of the federation, not the client's.
+	assert.Equal(t, "/api/v2.1/version? Bearer peer-token", string(body))
+}
+
+func TestAddPeer(t *testing.T) {
+	fed, err := newFederation(nil, time.Second, "")
+	require.NoError(t, err)
+	name, err := fed.AddPeer("http://node1:8080/cadvisor")
+	assert.NoError(t, err)
+	assert.Equal(t, "node1:8080", name)
+	assert.Equal(t, "/cadvisor/api/v2.0/machine", apiURL(fed.peers[name], "/v2.0/machine").Path)
+
+	_, err = fed.AddPeer("node2:8080")
+	assert.Error(t, err)
+}
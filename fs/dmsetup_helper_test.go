@@ -84,3 +84,38 @@ func TestParseDMStatus(t *testing.T) {
 		}
 	}
 }
+
+func TestParseThinPools(t *testing.T) {
+	table := `docker-thinpool: 0 209715200 thin-pool 253:0 253:1 128 32768 1 skip_block_zeroing
+vg0-pool: 0 409534464 thin-pool 253:3 253:4 512 32768 1 skip_block_zeroing
+failed-pool: 0 409534464 thin-pool 253:5 253:6 128 32768 1 skip_block_zeroing
+`
+	status := `docker-thinpool: 0 209715200 thin-pool 707 1215/524288 30282/1638400 - rw discard_passdown queue_if_no_space -
+vg0-pool: 0 409534464 thin-pool 64085 3705/4161600 88106/88106 - out_of_data_space no_discard_passdown queue_if_no_space -
+failed-pool: 0 409534464 thin-pool Fail
+`
+	pools, err := parseThinPools(table, status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []ThinPool{
+		{Name: "docker-thinpool", DataUsed: 30282 * 65536, DataTotal: 1638400 * 65536, MetadataUsed: 1215 * 4096, MetadataTotal: 524288 * 4096, Mode: "rw"},
+		{Name: "vg0-pool", DataUsed: 88106 * 262144, DataTotal: 88106 * 262144, MetadataUsed: 3705 * 4096, MetadataTotal: 4161600 * 4096, Mode: "out_of_data_space"},
+		{Name: "failed-pool", Mode: "Fail"},
+	}
+	if len(pools) != len(expected) {
+		t.Fatalf("expected %d pools, got %+v", len(expected), pools)
+	}
+	for i := range expected {
+		if pools[i] != expected[i] {
+			t.Errorf("expected pool %+v, got %+v", expected[i], pools[i])
+		}
+	}
+
+	if pools, err := parseThinPools("No devices found\n", "No devices found\n"); err != nil || len(pools) != 0 {
+		t.Errorf("expected no pools, got %+v and %v", pools, err)
+	}
+	if _, err := parseThinPools(table, "vg0-pool: 0 409534464 thin-pool 64085 3705 88106/88106 - rw\n"); err == nil {
+		t.Errorf("expected an error for an invalid status")
+	}
+}
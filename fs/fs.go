@@ -19,6 +19,7 @@ package fs
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -30,24 +31,13 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/golang/glog"
+	"github.com/google/cadvisor/logging"
 )
 
 type RealFsInfo struct {
 	partitionCache PartitionCache
 	fsStatsCache   FsStatsCache
-}
-
-type Context struct {
-	// docker root directory.
-	Docker  DockerContext
-	RktPath string
-}
-
-type DockerContext struct {
-	Root         string
-	Driver       string
-	DriverStatus map[string]string
+	networkFs      networkFsTracker
 }
 
 func NewFsInfo(context Context) (FsInfo, error) {
@@ -56,9 +46,9 @@ func NewFsInfo(context Context) (FsInfo, error) {
 		fsStatsCache:   NewFsStatsCache(),
 	}
 
-	glog.Infof("Listing filesystem partitions:")
+	logging.Infof("Listing filesystem partitions:")
 	fsInfo.partitionCache.ApplyOverPartitions(func(d string, p partition) error {
-		glog.Infof("%s: %+v", d, p)
+		logging.Infof("%s: %+v", d, p)
 		return nil
 	})
 
@@ -68,7 +58,7 @@ func NewFsInfo(context Context) (FsInfo, error) {
 func (self *RealFsInfo) RefreshCache() {
 	err := self.partitionCache.Refresh()
 	if err != nil {
-		glog.Warningf("Failed to refresh partition cache: %s")
+		logging.Warningf("Failed to refresh partition cache: %s", err)
 	}
 }
 
@@ -112,7 +102,7 @@ func (self *RealFsInfo) getFilteredFsInfo(filter func(_ string, _ partition) boo
 
 		if err != nil {
 			// Only log, don't return an error, move on to the next FS
-			glog.Errorf("Stat fs for %q failed. Error: %v", device, err)
+			logging.Errorf("Stat fs for %q failed. Error: %v", device, err)
 			return nil
 		}
 
@@ -144,7 +134,7 @@ func (self *RealFsInfo) getFilteredFsInfo(filter func(_ string, _ partition) boo
 				// report are the disk stats for the underlying physical volume, not
 				// the ecryptfs one. We should (probably) handle ecryptfs a little
 				// differently here, and look at the disk stats for the lower layer.
-				// glog.Warningf("Disk stats for %q not found", fs.DeviceInfo.Device)
+				// logging.Warningf("Disk stats for %q not found", fs.DeviceInfo.Device)
 				continue
 			}
 			fs.DiskStats = diskStats
@@ -181,7 +171,7 @@ func getDiskStatsMap(diskStatsFile string) (map[string]DiskStats, error) {
 	file, err := os.Open(diskStatsFile)
 	if err != nil {
 		if os.IsNotExist(err) {
-			glog.Infof("not collecting filesystem statistics because file %q was not available", diskStatsFile)
+			logging.Infof("not collecting filesystem statistics because file %q was not available", diskStatsFile)
 			return diskStatsMap, nil
 		}
 		return nil, err
@@ -252,11 +242,28 @@ func (self *RealFsInfo) GetDirFsDevice(dir string) (*DeviceInfo, error) {
 	return deviceInfo, nil
 }
 
+var (
+	duNice        = flag.Int("du_nice", 19, "Niceness of the du scans of the disk usage of containers")
+	duIoniceClass = flag.Int("du_ionice_class", 3, "IO scheduling class of the du scans of the disk usage of containers: 1 (realtime), 2 (best-effort) or 3 (idle). 0 runs them in the default class")
+)
+
+// Returns the du command scanning dir, at the configured CPU and IO
+// niceness.
+func duCommand(dir string) *exec.Cmd {
+	args := []string{"nice", "-n", strconv.Itoa(*duNice), "du", "-s", dir}
+	if *duIoniceClass > 0 {
+		if _, err := exec.LookPath("ionice"); err == nil {
+			args = append([]string{"ionice", "-c", strconv.Itoa(*duIoniceClass)}, args...)
+		}
+	}
+	return exec.Command(args[0], args[1:]...)
+}
+
 func (self *RealFsInfo) GetDirUsage(dir string, timeout time.Duration) (uint64, error) {
 	if dir == "" {
 		return 0, fmt.Errorf("invalid directory")
 	}
-	cmd := exec.Command("nice", "-n", "19", "du", "-s", dir)
+	cmd := duCommand(dir)
 	stdoutp, err := cmd.StdoutPipe()
 	if err != nil {
 		return 0, fmt.Errorf("failed to setup stdout for cmd %v - %v", cmd.Args, err)
@@ -272,7 +279,7 @@ func (self *RealFsInfo) GetDirUsage(dir string, timeout time.Duration) (uint64,
 	stdoutb, souterr := ioutil.ReadAll(stdoutp)
 	stderrb, _ := ioutil.ReadAll(stderrp)
 	timer := time.AfterFunc(timeout, func() {
-		glog.Infof("killing cmd %v due to timeout(%s)", cmd.Args, timeout.String())
+		logging.Infof("killing cmd %v due to timeout(%s)", cmd.Args, timeout.String())
 		cmd.Process.Kill()
 	})
 	err = cmd.Wait()
@@ -282,7 +289,7 @@ func (self *RealFsInfo) GetDirUsage(dir string, timeout time.Duration) (uint64,
 	}
 	stdout := string(stdoutb)
 	if souterr != nil {
-		glog.Errorf("failed to read from stdout for cmd %v - %v", cmd.Args, souterr)
+		logging.Errorf("failed to read from stdout for cmd %v - %v", cmd.Args, souterr)
 	}
 	usageInKb, err := strconv.ParseUint(strings.Fields(stdout)[0], 10, 64)
 	if err != nil {
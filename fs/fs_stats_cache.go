@@ -18,7 +18,7 @@ package fs
 
 import (
 	"fmt"
-	"github.com/golang/glog"
+	"github.com/google/cadvisor/logging"
 	zfs "github.com/mistifyio/go-zfs"
 	"os/exec"
 	"sync"
@@ -80,7 +80,7 @@ func (self *realFsStatsCache) FsStats(dev string, part partition) (FsType, uint6
 	if ok {
 		// We have the data in cache. Return it if it's recent enough
 		if time.Since(e.createdAt) < self.cacheLifetime {
-			glog.V(2).Infof("Consuming stats cache for %q: %+v", cacheKey, e)
+			logging.V(2).Infof("Consuming stats cache for %q: %+v", cacheKey, e)
 			return unwrapCacheEntry(e)
 		}
 	}
@@ -88,7 +88,7 @@ func (self *realFsStatsCache) FsStats(dev string, part partition) (FsType, uint6
 	// Our cache entry is too old, or it doesn't exist. Replace it. Note:
 	// this doesn't do anything to prevent a thundering herd. It's up to
 	// the consumer to do so.
-	glog.V(2).Infof("Refreshing stats cache for %q", cacheKey)
+	logging.V(2).Infof("Refreshing stats cache for %q", cacheKey)
 	e = cacheEntry{}
 
 	var err error
@@ -17,6 +17,7 @@ package fs
 import (
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -101,3 +102,27 @@ func TestDirUsage(t *testing.T) {
 	as.NoError(err)
 	as.True(expectedSize <= size, "expected dir size to be at-least %d; got size: %d", expectedSize, size)
 }
+
+func TestParseMountStats(t *testing.T) {
+	mountStats := `device rootfs mounted on / with fstype rootfs
+device server:/export mounted on /mnt/data with fstype nfs4 statvers=1.1
+	opts:	rw,vers=4.1,rsize=1048576,wsize=1048576
+	age:	1204
+	per-op statistics
+	        NULL: 0 0 0 0 0 0 0 0
+	        READ: 120 121 0 17280 125829120 12 840 860
+	       WRITE: 40 40 0 41948160 6400 3 360 370
+device //server/share mounted on /mnt/share with fstype cifs
+`
+	ops, err := parseMountStats(strings.NewReader(mountStats))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]NetworkFsOps{
+		"/mnt/data": {Reads: 120, ReadRtt: 840, Writes: 40, WriteRtt: 360},
+	}, ops)
+}
+
+func TestNetworkMountsOf(t *testing.T) {
+	mountpoints := []string{"/mnt", "/mnt/data", "/srv/share"}
+	assert.Equal(t, []string{"/mnt/data", "/mnt"}, networkMountsOf([]string{"/mnt/data/db", "/mnt/other", "/mnt/data/"}, mountpoints))
+	assert.Empty(t, networkMountsOf([]string{"/srv/shared", "/var/lib"}, mountpoints))
+}
@@ -0,0 +1,70 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !linux
+
+package fs
+
+import (
+	"fmt"
+	"time"
+)
+
+var errUnsupported = fmt.Errorf("filesystem stats are only supported on Linux")
+
+// Reports no filesystems: their stats are only read on Linux.
+type unsupportedFsInfo struct{}
+
+func NewFsInfo(context Context) (FsInfo, error) {
+	return &unsupportedFsInfo{}, nil
+}
+
+func (self *unsupportedFsInfo) RefreshCache() {
+}
+
+func (self *unsupportedFsInfo) GetGlobalFsInfo(withIoStats bool) ([]Fs, error) {
+	return []Fs{}, nil
+}
+
+func (self *unsupportedFsInfo) GetFsInfoForMounts(mountSet map[string]struct{}, withIoStats bool) ([]Fs, error) {
+	return []Fs{}, nil
+}
+
+func (self *unsupportedFsInfo) GetFsInfoForDevices(deviceSet map[string]struct{}, withIoStats bool) ([]Fs, error) {
+	return []Fs{}, nil
+}
+
+func (self *unsupportedFsInfo) GetDirUsage(dir string, timeout time.Duration) (uint64, error) {
+	return 0, errUnsupported
+}
+
+func (self *unsupportedFsInfo) GetDirFsDevice(dir string) (*DeviceInfo, error) {
+	return nil, errUnsupported
+}
+
+func (self *unsupportedFsInfo) GetDeviceForLabel(label string) (string, error) {
+	return "", errUnsupported
+}
+
+func (self *unsupportedFsInfo) GetLabelsForDevice(device string) ([]string, error) {
+	return []string{}, nil
+}
+
+func (self *unsupportedFsInfo) GetMountpointForDevice(device string) (string, error) {
+	return "", errUnsupported
+}
+
+func (self *unsupportedFsInfo) GetNetworkFsInfo(dirs []string) ([]NetworkFs, error) {
+	return []NetworkFs{}, nil
+}
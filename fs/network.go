@@ -0,0 +1,223 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package fs
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/cadvisor/logging"
+)
+
+var networkFilesystems = flag.Bool("network_filesystems", false, "Whether to collect the usage, availability and operation latency of the NFS and CIFS mounts, for the root container and the containers bind-mounting them")
+
+// Filesystem types of the network mounts.
+var networkFsTypes = map[string]bool{
+	"nfs":  true,
+	"nfs4": true,
+	"cifs": true,
+	"smb3": true,
+}
+
+const (
+	mountStatsFile = "/proc/self/mountstats"
+	// statfs of a network mount blocks while its server is unreachable.
+	networkStatfsTimeout = 2 * time.Second
+)
+
+// Tracks the network mounts whose statfs timed out, skipped until it returns.
+type networkFsTracker struct {
+	lock    sync.Mutex
+	pending map[string]bool
+}
+
+// Returns the stats of the network mount, or false if its statfs is still
+// pending from a previous timeout or times out now.
+func (self *RealFsInfo) statNetworkFs(source string, part partition) (FsType, uint64, uint64, uint64, uint64, uint64, bool) {
+	tracker := &self.networkFs
+	tracker.lock.Lock()
+	if tracker.pending[part.mountpoint] {
+		tracker.lock.Unlock()
+		return "", 0, 0, 0, 0, 0, false
+	}
+	if tracker.pending == nil {
+		tracker.pending = make(map[string]bool)
+	}
+	tracker.pending[part.mountpoint] = true
+	tracker.lock.Unlock()
+
+	type result struct {
+		fsType                                        FsType
+		capacity, free, available, inodes, inodesFree uint64
+		err                                           error
+	}
+	done := make(chan result, 1)
+	go func() {
+		var r result
+		r.fsType, r.capacity, r.free, r.available, r.inodes, r.inodesFree, r.err = self.fsStatsCache.FsStats(source, part)
+		tracker.lock.Lock()
+		delete(tracker.pending, part.mountpoint)
+		tracker.lock.Unlock()
+		done <- r
+	}()
+	select {
+	case r := <-done:
+		if r.err != nil {
+			logging.V(4).Infof("Stat fs for network mount %q failed: %v", part.mountpoint, r.err)
+			return "", 0, 0, 0, 0, 0, false
+		}
+		return r.fsType, r.capacity, r.free, r.available, r.inodes, r.inodesFree, true
+	case <-time.After(networkStatfsTimeout):
+		logging.Warningf("Stat fs for network mount %q timed out, skipping it until it returns", part.mountpoint)
+		return "", 0, 0, 0, 0, 0, false
+	}
+}
+
+func (self *RealFsInfo) GetNetworkFsInfo(dirs []string) ([]NetworkFs, error) {
+	if !*networkFilesystems {
+		return nil, nil
+	}
+	mounts, err := (&defaultMountInfoClient{}).GetMounts()
+	if err != nil {
+		return nil, err
+	}
+	var mountpoints []string
+	sources := make(map[string]string)
+	fsTypes := make(map[string]string)
+	for _, mount := range mounts {
+		if !networkFsTypes[mount.Fstype] {
+			continue
+		}
+		mountpoints = append(mountpoints, mount.Mountpoint)
+		sources[mount.Mountpoint] = mount.Source
+		fsTypes[mount.Mountpoint] = mount.Fstype
+	}
+	if len(mountpoints) == 0 {
+		return nil, nil
+	}
+	if dirs != nil {
+		mountpoints = networkMountsOf(dirs, mountpoints)
+	}
+
+	var ops map[string]NetworkFsOps
+	if f, err := os.Open(mountStatsFile); err == nil {
+		ops, err = parseMountStats(f)
+		f.Close()
+		if err != nil {
+			logging.Warningf("Failed to parse %s: %v", mountStatsFile, err)
+		}
+	}
+
+	var filesystems []NetworkFs
+	for _, mountpoint := range mountpoints {
+		part := partition{mountpoint: mountpoint, fsType: fsTypes[mountpoint]}
+		_, capacity, free, available, inodes, inodesFree, ok := self.statNetworkFs(sources[mountpoint], part)
+		if !ok {
+			continue
+		}
+		fs := NetworkFs{
+			Source:     sources[mountpoint],
+			Mountpoint: mountpoint,
+			Type:       fsTypes[mountpoint],
+			Capacity:   capacity,
+			Free:       free,
+			Available:  available,
+			Inodes:     inodes,
+			InodesFree: inodesFree,
+		}
+		if mountOps, ok := ops[mountpoint]; ok {
+			fs.Ops = &mountOps
+		}
+		filesystems = append(filesystems, fs)
+	}
+	return filesystems, nil
+}
+
+// Returns the network mountpoints on which the dirs are, each once.
+func networkMountsOf(dirs []string, mountpoints []string) []string {
+	seen := make(map[string]bool)
+	var matched []string
+	for _, dir := range dirs {
+		dir = filepath.Clean(dir)
+		// The longest mountpoint containing the dir.
+		best := ""
+		for _, mountpoint := range mountpoints {
+			if (dir == mountpoint || strings.HasPrefix(dir, strings.TrimSuffix(mountpoint, "/")+"/")) && len(mountpoint) > len(best) {
+				best = mountpoint
+			}
+		}
+		if best != "" && !seen[best] {
+			seen[best] = true
+			matched = append(matched, best)
+		}
+	}
+	return matched
+}
+
+// Parses the cumulative READ and WRITE operation counts and round trip times
+// of the NFS mounts in the format of /proc/self/mountstats, keyed by
+// mountpoint.
+func parseMountStats(r io.Reader) (map[string]NetworkFsOps, error) {
+	ops := make(map[string]NetworkFsOps)
+	mountpoint := ""
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		// device <source> mounted on <mountpoint> with fstype <type> ...
+		if fields[0] == "device" {
+			mountpoint = ""
+			if len(fields) >= 8 && fields[2] == "mounted" && fields[3] == "on" && strings.HasPrefix(fields[7], "nfs") {
+				mountpoint = fields[4]
+			}
+			continue
+		}
+		if mountpoint == "" || (fields[0] != "READ:" && fields[0] != "WRITE:") {
+			continue
+		}
+		// <op>: ops transmissions timeouts bytes_sent bytes_received queue rtt execute
+		if len(fields) < 9 {
+			return nil, fmt.Errorf("unexpected per-op statistics %q", scanner.Text())
+		}
+		count, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse per-op statistics %q: %v", scanner.Text(), err)
+		}
+		rtt, err := strconv.ParseUint(fields[7], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse per-op statistics %q: %v", scanner.Text(), err)
+		}
+		mountOps := ops[mountpoint]
+		if fields[0] == "READ:" {
+			mountOps.Reads, mountOps.ReadRtt = count, rtt
+		} else {
+			mountOps.Writes, mountOps.WriteRtt = count, rtt
+		}
+		ops[mountpoint] = mountOps
+	}
+	return ops, scanner.Err()
+}
@@ -19,7 +19,7 @@ package fs
 import (
 	"fmt"
 	dockerMountInfo "github.com/docker/docker/pkg/mount"
-	"github.com/golang/glog"
+	"github.com/google/cadvisor/logging"
 	"path"
 	"path/filepath"
 	"strings"
@@ -111,7 +111,7 @@ func (self *RealPartitionCache) Refresh() error {
 
 func (self *RealPartitionCache) ApplyOverPartitions(f func(d string, p partition) error) error {
 	if len(self.partitions) == 0 {
-		glog.Infof("Partition cache is empty: updating")
+		logging.Infof("Partition cache is empty: updating")
 		err := self.Refresh()
 		if err != nil {
 			return err
@@ -130,7 +130,7 @@ func (self *RealPartitionCache) ApplyOverPartitions(f func(d string, p partition
 
 func (self *RealPartitionCache) ApplyOverLabels(f func(l string, d string) error) error {
 	if len(self.labels) == 0 {
-		glog.Infof("Partition cache is empty: updating")
+		logging.Infof("Partition cache is empty: updating")
 		err := self.Refresh()
 		if err != nil {
 			return err
@@ -153,7 +153,7 @@ func (self *RealPartitionCache) PartitionForDevice(device string) (partition, er
 		return p, nil
 	}
 
-	glog.Infof("Partition cache miss for device %q, refreshing partition cache", device)
+	logging.Infof("Partition cache miss for device %q, refreshing partition cache", device)
 	err := self.Refresh()
 	if err != nil {
 		return partition{}, err
@@ -198,7 +198,7 @@ func (self *RealPartitionCache) DeviceNameForLabel(label string) (string, error)
 		return d, nil
 	}
 
-	glog.Infof("Partition cache miss for label %q, refreshing partition cache", label)
+	logging.Infof("Partition cache miss for label %q, refreshing partition cache", label)
 	err := self.Refresh()
 	if err != nil {
 		return "", err
@@ -247,7 +247,7 @@ func addDockerImagesLabel(context Context, dmsetup dmsetupClient,
 ) {
 	dockerDev, dockerPartition, err := getDockerDeviceMapperInfo(context.Docker, dmsetup)
 	if err != nil {
-		glog.Warningf("Could not get Docker devicemapper device: %v", err)
+		logging.Warningf("Could not get Docker devicemapper device: %v", err)
 	}
 	if len(dockerDev) > 0 && dockerPartition != nil {
 		partitions[dockerDev] = *dockerPartition
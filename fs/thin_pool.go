@@ -0,0 +1,130 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fs
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Size of a block of thin-pool metadata.
+const thinPoolMetadataBlockSize = 4096
+
+// Usage of a devicemapper thin-pool, in bytes.
+type ThinPool struct {
+	Name          string
+	DataUsed      uint64
+	DataTotal     uint64
+	MetadataUsed  uint64
+	MetadataTotal uint64
+	// "rw", "ro" when the metadata is exhausted, "out_of_data_space" or
+	// "Fail".
+	Mode string
+}
+
+// Returns the usage of all the thin-pools of the machine.
+func GetThinPools() ([]ThinPool, error) {
+	table, err := exec.Command("dmsetup", "table", "--target", "thin-pool").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the thin-pool tables: %v", err)
+	}
+	status, err := exec.Command("dmsetup", "status", "--target", "thin-pool").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the thin-pool statuses: %v", err)
+	}
+	return parseThinPools(string(table), string(status))
+}
+
+// Parses the output of dmsetup table and status for the thin-pool target.
+// The table lines are "<name>: <start> <length> thin-pool <metadata dev>
+// <data dev> <data block size> ...", the block size in sectors of 512 bytes.
+// The status lines are "<name>: <start> <length> thin-pool <transaction id>
+// <used>/<total metadata blocks> <used>/<total data blocks> <held root>
+// <mode> ...", or end with "Fail" for a failed pool.
+func parseThinPools(table, status string) ([]ThinPool, error) {
+	blockSizes := make(map[string]uint64)
+	for _, line := range thinPoolLines(table) {
+		name, fields := line[0], line[1:]
+		if len(fields) < 6 || fields[2] != "thin-pool" {
+			return nil, fmt.Errorf("invalid thin-pool table %q", strings.Join(line, " "))
+		}
+		blockSize, err := strconv.ParseUint(fields[5], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid thin-pool table %q: %v", strings.Join(line, " "), err)
+		}
+		blockSizes[name] = blockSize * 512
+	}
+
+	var pools []ThinPool
+	for _, line := range thinPoolLines(status) {
+		name, fields := line[0], line[1:]
+		if len(fields) < 3 || fields[2] != "thin-pool" {
+			return nil, fmt.Errorf("invalid thin-pool status %q", strings.Join(line, " "))
+		}
+		pool := ThinPool{Name: name}
+		if len(fields) == 4 && fields[3] == "Fail" {
+			pool.Mode = fields[3]
+			pools = append(pools, pool)
+			continue
+		}
+		blockSize, ok := blockSizes[name]
+		if len(fields) < 8 || !ok {
+			return nil, fmt.Errorf("invalid thin-pool status %q", strings.Join(line, " "))
+		}
+		var err error
+		if pool.MetadataUsed, pool.MetadataTotal, err = parseUsedTotal(fields[4], thinPoolMetadataBlockSize); err != nil {
+			return nil, fmt.Errorf("invalid thin-pool status %q: %v", strings.Join(line, " "), err)
+		}
+		if pool.DataUsed, pool.DataTotal, err = parseUsedTotal(fields[5], blockSize); err != nil {
+			return nil, fmt.Errorf("invalid thin-pool status %q: %v", strings.Join(line, " "), err)
+		}
+		pool.Mode = fields[7]
+		pools = append(pools, pool)
+	}
+	return pools, nil
+}
+
+// Splits dmsetup output into the fields of its lines, the device name
+// first. Returns nothing for "No devices found".
+func thinPoolLines(out string) [][]string {
+	var lines [][]string
+	for _, line := range strings.Split(out, "\n") {
+		i := strings.Index(line, ": ")
+		if i < 0 {
+			continue
+		}
+		lines = append(lines, append([]string{line[:i]}, strings.Fields(line[i+2:])...))
+	}
+	return lines
+}
+
+// Parses "<used>/<total>" blocks into bytes.
+func parseUsedTotal(s string, blockSize uint64) (uint64, uint64, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("expected <used>/<total>, got %q", s)
+	}
+	used, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	total, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return used * blockSize, total * blockSize, nil
+}
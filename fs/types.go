@@ -16,6 +16,18 @@ package fs
 
 import "time"
 
+type Context struct {
+	// docker root directory.
+	Docker  DockerContext
+	RktPath string
+}
+
+type DockerContext struct {
+	Root         string
+	Driver       string
+	DriverStatus map[string]string
+}
+
 type partition struct {
 	mountpoint string
 	major      uint
@@ -53,6 +65,30 @@ type Fs struct {
 	DiskStats  DiskStats
 }
 
+// A network filesystem mount, e.g. of NFS or CIFS.
+type NetworkFs struct {
+	// The remote share, e.g. server:/export.
+	Source     string
+	Mountpoint string
+	Type       string
+	Capacity   uint64
+	Free       uint64
+	Available  uint64
+	Inodes     uint64
+	InodesFree uint64
+	// Operation counts and latencies, nil if not reported, e.g. for CIFS.
+	Ops *NetworkFsOps
+}
+
+// Cumulative READ and WRITE operation counts of a network filesystem and
+// their cumulative round trip times in milliseconds.
+type NetworkFsOps struct {
+	Reads    uint64
+	ReadRtt  uint64
+	Writes   uint64
+	WriteRtt uint64
+}
+
 type DiskStats struct {
 	ReadsCompleted  uint64
 	ReadsMerged     uint64
@@ -94,6 +130,11 @@ type FsInfo interface {
 
 	// Returns the mountpoint associated with a particular device.
 	GetMountpointForDevice(device string) (string, error)
+
+	// Returns the stats of the network filesystems on which the dirs are, of
+	// all of them if dirs is nil. None unless network filesystems are
+	// monitored.
+	GetNetworkFsInfo(dirs []string) ([]NetworkFs, error)
 }
 
 type PartitionCache interface {
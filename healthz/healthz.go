@@ -15,18 +15,53 @@
 package healthz
 
 import (
+	"encoding/json"
 	"net/http"
 
 	httpmux "github.com/google/cadvisor/http/mux"
+	"github.com/google/cadvisor/info/v2"
 )
 
-func handleHealthz(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("ok"))
+// The checks of the health of cAdvisor, implemented by its manager.
+type Checker interface {
+	Healthz() []v2.HealthCheck
+	Readyz() []v2.HealthCheck
 }
 
-// Register simple HTTP /healthz handler to return "ok".
-func RegisterHandler(mux httpmux.Mux) error {
-	mux.HandleFunc("/healthz", handleHealthz)
+// Responds "ok" if all checks pass, or 503 with the JSON list of the checks
+// otherwise. The list is also returned for passing checks with ?verbose.
+func handleChecks(checks func() []v2.HealthCheck) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		results := checks()
+		healthy := true
+		for _, check := range results {
+			healthy = healthy && check.Healthy
+		}
+		_, verbose := r.URL.Query()["verbose"]
+		if healthy && !verbose {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("ok"))
+			return
+		}
+		out, err := json.Marshal(results)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if healthy {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		w.Write(out)
+	}
+}
+
+// Register the HTTP /healthz handler checking that cAdvisor is alive, and the
+// /readyz handler checking that it is ready to serve.
+func RegisterHandler(mux httpmux.Mux, m Checker) error {
+	mux.HandleFunc("/healthz", handleChecks(m.Healthz))
+	mux.HandleFunc("/readyz", handleChecks(m.Readyz))
 	return nil
 }
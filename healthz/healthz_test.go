@@ -0,0 +1,64 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package healthz
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/manager"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandlers(t *testing.T) {
+	m := &manager.ManagerMock{}
+	healthy := []v2.HealthCheck{{Name: "housekeeping", Healthy: true}}
+	unhealthy := []v2.HealthCheck{
+		{Name: "recovery", Healthy: true},
+		{Name: "docker", Error: "failed to ping the Docker daemon"},
+	}
+	m.On("Healthz").Return(healthy)
+	m.On("Readyz").Return(unhealthy)
+	mux := http.NewServeMux()
+	require.NoError(t, RegisterHandler(mux, m))
+
+	get := func(url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r, err := http.NewRequest("GET", url, nil)
+		require.NoError(t, err)
+		mux.ServeHTTP(w, r)
+		return w
+	}
+
+	w := get("/healthz")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ok", w.Body.String())
+
+	w = get("/healthz?verbose")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var checks []v2.HealthCheck
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &checks))
+	assert.Equal(t, healthy, checks)
+
+	w = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &checks))
+	assert.Equal(t, unhealthy, checks)
+}
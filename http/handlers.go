@@ -19,8 +19,10 @@ import (
 	"net/http"
 
 	"github.com/google/cadvisor/api"
+	apiauth "github.com/google/cadvisor/auth"
 	"github.com/google/cadvisor/healthz"
 	httpmux "github.com/google/cadvisor/http/mux"
+	"github.com/google/cadvisor/logging"
 	"github.com/google/cadvisor/manager"
 	"github.com/google/cadvisor/metrics"
 	"github.com/google/cadvisor/pages"
@@ -28,13 +30,12 @@ import (
 	"github.com/google/cadvisor/validate"
 
 	auth "github.com/abbot/go-http-auth"
-	"github.com/golang/glog"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 func RegisterHandlers(mux httpmux.Mux, containerManager manager.Manager, httpAuthFile, httpAuthRealm, httpDigestFile, httpDigestRealm string) error {
-	// Basic health handler.
-	if err := healthz.RegisterHandler(mux); err != nil {
+	// Health and readiness handlers.
+	if err := healthz.RegisterHandler(mux, containerManager); err != nil {
 		return fmt.Errorf("failed to register healthz handler: %s", err)
 	}
 
@@ -57,8 +58,22 @@ func RegisterHandlers(mux httpmux.Mux, containerManager manager.Manager, httpAut
 	var authenticated bool = false
 
 	// Setup the authenticator object
-	if httpAuthFile != "" {
-		glog.Infof("Using auth file %s", httpAuthFile)
+	oidc, err := apiauth.GetOIDC()
+	if err != nil {
+		return fmt.Errorf("failed to set up OpenID Connect: %s", err)
+	}
+	if oidc != nil {
+		logging.Infof("Authenticating UI users with OpenID Connect")
+		mux.HandleFunc(apiauth.OidcCallbackPath, oidc.HandleCallback)
+		oidcMux := &oidcMux{mux, oidc}
+		oidcMux.HandleFunc(static.StaticResource, staticHandlerNoAuth)
+		if err := pages.RegisterHandlersBasic(oidcMux, containerManager, nil); err != nil {
+			return fmt.Errorf("failed to register pages OpenID Connect handlers: %s", err)
+		}
+		authenticated = true
+	}
+	if !authenticated && httpAuthFile != "" {
+		logging.Infof("Using auth file %s", httpAuthFile)
 		secrets := auth.HtpasswdFileProvider(httpAuthFile)
 		authenticator := auth.NewBasicAuthenticator(httpAuthRealm, secrets)
 		mux.HandleFunc(static.StaticResource, authenticator.Wrap(staticHandler))
@@ -67,8 +82,8 @@ func RegisterHandlers(mux httpmux.Mux, containerManager manager.Manager, httpAut
 		}
 		authenticated = true
 	}
-	if httpAuthFile == "" && httpDigestFile != "" {
-		glog.Infof("Using digest file %s", httpDigestFile)
+	if !authenticated && httpDigestFile != "" {
+		logging.Infof("Using digest file %s", httpDigestFile)
 		secrets := auth.HtdigestFileProvider(httpDigestFile)
 		authenticator := auth.NewDigestAuthenticator(httpDigestRealm, secrets)
 		mux.HandleFunc(static.StaticResource, authenticator.Wrap(staticHandler))
@@ -89,9 +104,31 @@ func RegisterHandlers(mux httpmux.Mux, containerManager manager.Manager, httpAut
 	return nil
 }
 
+// Registers handlers requiring users to log in with OpenID Connect.
+type oidcMux struct {
+	httpmux.Mux
+	oidc *apiauth.OIDC
+}
+
+func (self *oidcMux) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	self.Mux.HandleFunc(pattern, self.oidc.Wrap(handler))
+}
+
+func (self *oidcMux) Handle(pattern string, handler http.Handler) {
+	self.Mux.HandleFunc(pattern, self.oidc.Wrap(handler.ServeHTTP))
+}
+
 func RegisterPrometheusHandler(mux httpmux.Mux, containerManager manager.Manager, prometheusEndpoint string, containerNameToLabelsFunc metrics.ContainerNameToLabelsFunc) {
 	collector := metrics.NewPrometheusCollector(containerManager, containerNameToLabelsFunc)
 	prometheus.MustRegister(collector)
+	prometheus.MustRegister(manager.HousekeepingTimeouts)
+	prometheus.MustRegister(manager.ContainerEventQueueDepth)
+	prometheus.MustRegister(manager.ContainerEvents)
+	prometheus.MustRegister(manager.ContainerEventsCoalesced)
+	prometheus.MustRegister(manager.ContainerCreationLatency)
+	prometheus.MustRegister(manager.ContainerFirstStatsLatency)
+	prometheus.MustRegister(manager.ContainerDestructionLatency)
+	prometheus.MustRegister(manager.StorageEventsDropped)
 	mux.Handle(prometheusEndpoint, prometheus.Handler())
 }
 
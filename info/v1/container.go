@@ -23,6 +23,7 @@ type CpuSpec struct {
 	Limit    uint64 `json:"limit"`
 	MaxLimit uint64 `json:"max_limit"`
 	Mask     string `json:"mask,omitempty"`
+	Mems     string `json:"mems,omitempty"`
 	Quota    uint64 `json:"quota,omitempty"`
 	Period   uint64 `json:"period,omitempty"`
 }
@@ -45,6 +46,9 @@ type ContainerSpec struct {
 	// Time at which the container was created.
 	CreationTime time.Time `json:"creation_time,omitempty"`
 
+	// Time at which the container exited, if it is retained after its exit.
+	ExitTime time.Time `json:"exit_time,omitempty"`
+
 	// Metadata labels associated with this container.
 	Labels map[string]string `json:"labels,omitempty"`
 	// Metadata envs associated with this container. Only whitelisted envs are added.
@@ -68,6 +72,22 @@ type ContainerSpec struct {
 
 	// Image name used for this container.
 	Image string `json:"image,omitempty"`
+
+	// Health check status of the container: "starting", "healthy" or
+	// "unhealthy". Empty if the container has no health check.
+	Health string `json:"health,omitempty"`
+	// Number of times the container was restarted by its runtime.
+	RestartCount int `json:"restart_count,omitempty"`
+	// Policy under which the runtime restarts the container, e.g. "always".
+	RestartPolicy string `json:"restart_policy,omitempty"`
+
+	// Version of the spec, incremented when the cpu affinity, limits,
+	// labels or image of the container change at runtime.
+	Generation uint64 `json:"generation,omitempty"`
+
+	// Name of the container owning the network namespace this container
+	// shares, whose network stats include the usage of this container.
+	NetworkOwner string `json:"network_owner,omitempty"`
 }
 
 // Container reference contains enough information to uniquely identify a container
@@ -87,6 +107,9 @@ type ContainerReference struct {
 	Namespace string `json:"namespace,omitempty"`
 
 	Labels map[string]string `json:"labels,omitempty"`
+	// Whitelisted environment variables of the container, exported with its
+	// stats by the storage drivers.
+	Envs map[string]string `json:"envs,omitempty"`
 }
 
 // Sorts by container name.
@@ -291,9 +314,24 @@ type CpuStats struct {
 	Usage CpuUsage `json:"usage"`
 	// Smoothed average of number of runnable threads x 1000.
 	// We multiply by thousand to avoid using floats, but preserving precision.
-	// Load is smoothed over the last 10 seconds. Instantaneous value can be read
-	// from LoadStats.NrRunning.
+	// Load is smoothed over the last --load_average_window, 10 seconds by
+	// default. Instantaneous values can be read from the TaskStats.
 	LoadAverage int32 `json:"load_average"`
+	// Scheduler statistics aggregated over the tasks of the container.
+	Schedstat CpuSchedstat `json:"schedstat"`
+}
+
+// Cumulative scheduler statistics of the tasks of a container, from
+// /proc/<pid>/task/<tid>/schedstat, including the tasks that exited.
+type CpuSchedstat struct {
+	// Time spent running on a cpu.
+	// Units: nanoseconds
+	RunTime uint64 `json:"run_time"`
+	// Time spent runnable, waiting for a cpu, which measures cpu starvation.
+	// Units: nanoseconds
+	RunqueueTime uint64 `json:"runqueue_time"`
+	// Number of timeslices run on a cpu.
+	RunPeriods uint64 `json:"run_periods"`
 }
 
 type PerDiskStats struct {
@@ -475,6 +513,50 @@ type FsStats struct {
 	// last update of this field.  This can provide an easy measure of both
 	// I/O completion time and the backlog that may be accumulating.
 	WeightedIoTime uint64 `json:"weighted_io_time"`
+
+	// Operation stats of a network filesystem, e.g. NFS. Only set for
+	// network filesystems reporting them.
+	NetworkOps *NetworkFsOps `json:"network_ops,omitempty"`
+}
+
+// Cumulative operation stats of a network filesystem.
+type NetworkFsOps struct {
+	// Number of read operations.
+	Reads uint64 `json:"reads"`
+
+	// Number of milliseconds of round trip time of all reads.
+	ReadRtt uint64 `json:"read_rtt"`
+
+	// Number of write operations.
+	Writes uint64 `json:"writes"`
+
+	// Number of milliseconds of round trip time of all writes.
+	WriteRtt uint64 `json:"write_rtt"`
+}
+
+// Usage of a volume mounted in a container, as seen from within the
+// container's mount namespace.
+type VolumeStats struct {
+	// Path of the volume inside the container.
+	MountPoint string `json:"mount_point"`
+
+	// Host path of the volume.
+	Source string `json:"source,omitempty"`
+
+	// Number of bytes of the filesystem of the volume.
+	Limit uint64 `json:"capacity"`
+
+	// Number of bytes used on the filesystem of the volume.
+	Usage uint64 `json:"usage"`
+
+	// Number of bytes available for non-root user.
+	Available uint64 `json:"available"`
+
+	// Number of Inodes.
+	Inodes uint64 `json:"inodes"`
+
+	// Number of available Inodes.
+	InodesFree uint64 `json:"inodes_free"`
 }
 
 type ContainerStats struct {
@@ -488,11 +570,72 @@ type ContainerStats struct {
 	// Filesystem statistics
 	Filesystem []FsStats `json:"filesystem,omitempty"`
 
+	// Usage of the volumes mounted in the container.
+	Volumes []VolumeStats `json:"volumes,omitempty"`
+
 	// Task load stats
 	TaskStats LoadStats `json:"task_stats,omitempty"`
 
 	//Custom metrics from all collectors
 	CustomMetrics map[string][]MetricVal `json:"custom_metrics,omitempty"`
+
+	// Errors of the subsystems whose stats could not be collected, by
+	// subsystem. Their stats are not valid rather than zero.
+	Errors map[string]string `json:"errors,omitempty"`
+
+	// Time of the previous stats of the container, set when they were
+	// collected so long before these that samples are missing, e.g. after a
+	// stall of the housekeeping or a pause of the machine.
+	GapStart *time.Time `json:"gap_start,omitempty"`
+
+	// The cumulative counters that decreased since the previous stats, e.g.
+	// after a restart of the container: "cpu", "network" or "diskio".
+	CounterResets []string `json:"counter_resets,omitempty"`
+
+	// The time of this stat point on the monotonic clock of cAdvisor: the
+	// wall time at which it started plus the time elapsed since. It doesn't
+	// step with corrections of the wall clock. Set with --monotonic_timestamps.
+	MonotonicTimestamp *time.Time `json:"monotonic_timestamp,omitempty"`
+}
+
+// Cumulative counters of the stats whose resets are annotated.
+const (
+	CpuCounter     = "cpu"
+	NetworkCounter = "network"
+	DiskIoCounter  = "diskio"
+)
+
+// Subsystems of the stats of a container collected independently of the
+// cgroup stats, which may fail on their own.
+const (
+	NetworkStatsSubsystem    = "network"
+	TcpStatsSubsystem        = "tcp"
+	FilesystemStatsSubsystem = "filesystem"
+	SchedstatSubsystem       = "sched"
+	CustomMetricsSubsystem   = "custom"
+)
+
+// Returns the time elapsed between the previous stats and these, on the
+// monotonic clock when both have a monotonic timestamp.
+func (self *ContainerStats) Since(previous *ContainerStats) time.Duration {
+	if self.MonotonicTimestamp != nil && previous.MonotonicTimestamp != nil {
+		return self.MonotonicTimestamp.Sub(*previous.MonotonicTimestamp)
+	}
+	return self.Timestamp.Sub(previous.Timestamp)
+}
+
+// Records that the stats of the subsystem could not be collected.
+func (self *ContainerStats) SetError(subsystem string, err error) {
+	if self.Errors == nil {
+		self.Errors = make(map[string]string)
+	}
+	self.Errors[subsystem] = err.Error()
+}
+
+// Returns whether the stats of the subsystem were collected.
+func (self *ContainerStats) Valid(subsystem string) bool {
+	_, failed := self.Errors[subsystem]
+	return !failed
 }
 
 func timeEq(t1, t2 time.Time, tolerance time.Duration) bool {
@@ -568,16 +711,43 @@ type Event struct {
 type EventType string
 
 const (
-	EventOom               EventType = "oom"
-	EventOomKill                     = "oomKill"
-	EventContainerCreation           = "containerCreation"
-	EventContainerDeletion           = "containerDeletion"
+	EventOom                 EventType = "oom"
+	EventOomKill                       = "oomKill"
+	EventContainerCreation             = "containerCreation"
+	EventContainerDeletion             = "containerDeletion"
+	EventAnomaly                       = "anomaly"
+	EventContainerExit                 = "containerExit"
+	EventContainerHealth               = "containerHealth"
+	EventHousekeepingTimeout           = "housekeepingTimeout"
+	EventMachineChange                 = "machineChange"
+	EventThinPoolExhaustion            = "thinPoolExhaustion"
+	EventCpusetChange                  = "cpusetChange"
+	EventLimitChange                   = "limitChange"
+	EventSelfMemoryPressure            = "selfMemoryPressure"
 )
 
 // Extra information about an event. Only one type will be set.
 type EventData struct {
 	// Information about an OOM kill event.
 	OomKill *OomKillEventData `json:"oom,omitempty"`
+	// Information about an anomaly event.
+	Anomaly *AnomalyEventData `json:"anomaly,omitempty"`
+	// Information about a container exit event.
+	ContainerExit *ContainerExitEventData `json:"container_exit,omitempty"`
+	// Information about a health check status transition.
+	Health *HealthEventData `json:"health,omitempty"`
+	// Information about a housekeeping timeout.
+	HousekeepingTimeout *HousekeepingTimeoutEventData `json:"housekeeping_timeout,omitempty"`
+	// Information about a change of the hardware of the machine.
+	MachineChange *MachineChangeEventData `json:"machine_change,omitempty"`
+	// Information about a devicemapper thin-pool nearing exhaustion.
+	ThinPoolExhaustion *ThinPoolExhaustionEventData `json:"thin_pool_exhaustion,omitempty"`
+	// Information about a change of the cpu affinity of a container.
+	CpusetChange *CpusetChangeEventData `json:"cpuset_change,omitempty"`
+	// Information about a change of the cpu or memory limits of a container.
+	LimitChange *LimitChangeEventData `json:"limit_change,omitempty"`
+	// Information about cAdvisor nearing --max_self_memory.
+	SelfMemoryPressure *SelfMemoryPressureEventData `json:"self_memory_pressure,omitempty"`
 }
 
 // Information related to an OOM kill instance
@@ -588,3 +758,113 @@ type OomKillEventData struct {
 	// The name of the killed process
 	ProcessName string `json:"process_name"`
 }
+
+// Information related to a metric deviating from its recent baseline.
+type AnomalyEventData struct {
+	// The deviating metric: "cpu" in millicores or "memory" working set in bytes.
+	Metric string `json:"metric"`
+
+	// The value of the metric.
+	Value float64 `json:"value"`
+
+	// Mean and standard deviation of the metric over the baseline window.
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"std_dev"`
+
+	// Number of standard deviations between the value and the mean.
+	ZScore float64 `json:"z_score"`
+}
+
+// Information related to the exit of a container retained after its exit.
+type ContainerExitEventData struct {
+	// The last stats collected for the container, with its final cumulative
+	// usage.
+	Stats *ContainerStats `json:"stats,omitempty"`
+}
+
+// Information related to a transition of the health check status of a
+// container.
+type HealthEventData struct {
+	// The new status: "starting", "healthy" or "unhealthy".
+	Status string `json:"status"`
+}
+
+// Information related to a housekeeping of a container that did not complete
+// within the panic timeout. The container is skipped until it completes.
+type HousekeepingTimeoutEventData struct {
+	// The timeout, in nanoseconds.
+	Timeout time.Duration `json:"timeout"`
+}
+
+// Information related to a change of the disks, network devices or PCI
+// devices of the machine.
+type MachineChangeEventData struct {
+	// The generation of the machine info after the change.
+	Generation uint64 `json:"generation"`
+
+	// The parts of the machine info that changed: "disks", "network_devices"
+	// or "pci_devices".
+	Changes []string `json:"changes"`
+}
+
+// Information related to a devicemapper thin-pool whose data or metadata
+// usage reached the threshold, or that is no longer writable.
+type ThinPoolExhaustionEventData struct {
+	// Name of the thin-pool device.
+	Pool string `json:"pool"`
+
+	// Percentage of the data and metadata space used.
+	DataUsage     float64 `json:"data_usage"`
+	MetadataUsage float64 `json:"metadata_usage"`
+
+	// The threshold percentage.
+	Threshold float64 `json:"threshold"`
+
+	// Mode of the pool: "rw", "ro", "out_of_data_space" or "Fail".
+	Mode string `json:"mode"`
+}
+
+// Information related to a change of the cpus or memory nodes of the cpuset
+// of a container at runtime.
+type CpusetChangeEventData struct {
+	// The generation of the spec after the change.
+	Generation uint64 `json:"generation"`
+
+	// The cpus and memory nodes before the change.
+	OldCpus string `json:"old_cpus"`
+	OldMems string `json:"old_mems"`
+
+	// The cpus and memory nodes after the change.
+	Cpus string `json:"cpus"`
+	Mems string `json:"mems"`
+}
+
+// Information related to a change of the cpu or memory limits of a container
+// at runtime.
+type LimitChangeEventData struct {
+	// The generation of the spec after the change.
+	Generation uint64 `json:"generation"`
+
+	// The limits before the change.
+	OldCpu    CpuSpec    `json:"old_cpu"`
+	OldMemory MemorySpec `json:"old_memory"`
+
+	// The limits after the change.
+	Cpu    CpuSpec    `json:"cpu"`
+	Memory MemorySpec `json:"memory"`
+}
+
+// Information related to the resident memory of cAdvisor nearing its
+// --max_self_memory, after which it evicted cached stats and lengthened the
+// housekeeping intervals.
+type SelfMemoryPressureEventData struct {
+	// Resident memory of cAdvisor and its limit, in bytes.
+	Rss   uint64 `json:"rss"`
+	Limit uint64 `json:"limit"`
+
+	// Byte budget the cached stats were shrunk to.
+	CacheBudget int64 `json:"cache_budget"`
+
+	// Factor by which the housekeeping intervals are lengthened.
+	HousekeepingBackoff int `json:"housekeeping_backoff"`
+}
@@ -36,6 +36,19 @@ type Node struct {
 	Caches []Cache `json:"caches"`
 }
 
+// A NUMA node of the machine.
+type NumaNode struct {
+	Id int `json:"node_id"`
+	// Memory local to the node, in bytes.
+	Memory uint64 `json:"memory"`
+	// Ids of the cpus (hardware threads) of the node.
+	Cpus []int `json:"cpus"`
+	// Relative distances from the node to every node, in node id order, as
+	// reported by the firmware. The distance of a node to itself is
+	// usually 10.
+	Distances []int `json:"distances"`
+}
+
 type Core struct {
 	Id      int     `json:"core_id"`
 	Threads []int   `json:"thread_ids"`
@@ -102,6 +115,12 @@ type DiskInfo struct {
 
 	// I/O Scheduler - one of "none", "noop", "cfq", "deadline"
 	Scheduler string `json:"scheduler"`
+
+	// Model reported by the device, if any.
+	Model string `json:"model,omitempty"`
+
+	// Whether the device is rotational, i.e. a spinning disk.
+	Rotational bool `json:"rotational"`
 }
 
 type NetInfo struct {
@@ -116,6 +135,22 @@ type NetInfo struct {
 
 	// Maximum Transmission Unit
 	Mtu int64 `json:"mtu"`
+
+	// Duplex mode: "full", "half" or "unknown". Empty if not reported.
+	Duplex string `json:"duplex,omitempty"`
+}
+
+type PciDevice struct {
+	// Address of the device, e.g. "0000:00:1f.6".
+	Address string `json:"address"`
+
+	// Class, vendor and device ids, e.g. "0x020000", "0x8086" and "0x15b7".
+	Class  string `json:"class"`
+	Vendor string `json:"vendor"`
+	Device string `json:"device"`
+
+	// Driver bound to the device, if any.
+	Driver string `json:"driver,omitempty"`
 }
 
 type CloudProvider string
@@ -169,10 +204,28 @@ type MachineInfo struct {
 	// Network devices
 	NetworkDevices []NetInfo `json:"network_devices"`
 
+	// PCI devices, sorted by address.
+	PciDevices []PciDevice `json:"pci_devices,omitempty"`
+
 	// Machine Topology
 	// Describes cpu/memory layout and hierarchy.
 	Topology []Node `json:"topology"`
 
+	// The number of cpu sockets and of physical cores in this machine.
+	NumSockets       int `json:"num_sockets"`
+	NumPhysicalCores int `json:"num_physical_cores"`
+
+	// The number of hardware threads per physical core, more than 1 when
+	// simultaneous multithreading (SMT) is enabled.
+	ThreadsPerCore int `json:"threads_per_core"`
+
+	// NUMA nodes, with their cpus and distances.
+	NumaNodes []NumaNode `json:"numa_nodes,omitempty"`
+
+	// Incremented every time the disks, network devices or PCI devices of
+	// the machine change.
+	Generation uint64 `json:"generation"`
+
 	// Cloud provider the machine belongs to.
 	CloudProvider CloudProvider `json:"cloud_provider"`
 
@@ -181,6 +234,14 @@ type MachineInfo struct {
 
 	// ID of cloud instance (e.g. instance-1) given to it by the cloud provider.
 	InstanceID InstanceID `json:"instance_id"`
+
+	// Zone and region of the cloud instance, e.g. us-east-1a and us-east-1.
+	Zone   string `json:"zone,omitempty"`
+	Region string `json:"region,omitempty"`
+
+	// Tags of the cloud instance exposed by the metadata service of the
+	// cloud provider.
+	InstanceTags map[string]string `json:"instance_tags,omitempty"`
 }
 
 type VersionInfo struct {
@@ -36,6 +36,8 @@ type CpuSpec struct {
 	// Cpu affinity mask.
 	// TODO(rjnagal): Add a library to convert mask string to set of cpu bitmask.
 	Mask string `json:"mask,omitempty"`
+	// Memory nodes of the cpuset of the container.
+	Mems string `json:"mems,omitempty"`
 	// CPUQuota Default is disabled
 	Quota uint64 `json:"quota,omitempty"`
 	// Period is the CPU reference time in ns e.g the quota is compared aginst this.
@@ -67,6 +69,8 @@ type ContainerInfo struct {
 type ContainerSpec struct {
 	// Time at which the container was created.
 	CreationTime time.Time `json:"creation_time,omitempty"`
+	// Time at which the container exited, if it is retained after its exit.
+	ExitTime time.Time `json:"exit_time,omitempty"`
 
 	// Other names by which the container is known within a certain namespace.
 	// This is unique within that namespace.
@@ -97,6 +101,46 @@ type ContainerSpec struct {
 
 	// Image name used for this container.
 	Image string `json:"image,omitempty"`
+
+	// Health check status of the container: "starting", "healthy" or
+	// "unhealthy". Empty if the container has no health check.
+	Health string `json:"health,omitempty"`
+	// Number of times the container was restarted by its runtime.
+	RestartCount int `json:"restart_count,omitempty"`
+	// Policy under which the runtime restarts the container, e.g. "always".
+	RestartPolicy string `json:"restart_policy,omitempty"`
+
+	// Version of the spec, incremented when the cpu affinity, limits,
+	// labels or image of the container change at runtime.
+	Generation uint64 `json:"generation,omitempty"`
+
+	// Name of the container owning the network namespace this container
+	// shares, whose network stats include the usage of this container.
+	NetworkOwner string `json:"network_owner,omitempty"`
+}
+
+// A version of the spec of a container.
+type ContainerSpecVersion struct {
+	// Time at which the version was first seen.
+	Timestamp time.Time `json:"timestamp"`
+	// The kinds of changes from the previous version: "cpuset", "limits",
+	// "labels" or "image". Empty for the first version.
+	Changes []string      `json:"changes,omitempty"`
+	Spec    ContainerSpec `json:"spec"`
+}
+
+// The errors of the collection of the stats of a container.
+type ContainerHealth struct {
+	// Number of failures to get the stats of the container.
+	Errors uint64 `json:"errors"`
+	// Number of failures since the stats were last collected, 0 if the last
+	// collection succeeded.
+	ConsecutiveErrors uint64 `json:"consecutive_errors"`
+	// The last failure and when it happened.
+	LastError     string    `json:"last_error,omitempty"`
+	LastErrorTime time.Time `json:"last_error_time"`
+	// Time at which the stats were last collected.
+	LastSuccessTime time.Time `json:"last_success_time"`
 }
 
 type DeprecatedContainerStats struct {
@@ -131,6 +175,9 @@ type DeprecatedContainerStats struct {
 type ContainerStats struct {
 	// The time of this stat point.
 	Timestamp time.Time `json:"timestamp"`
+	// The time of this stat point on the monotonic clock of cAdvisor, with
+	// --monotonic_timestamps.
+	MonotonicTimestamp *time.Time `json:"monotonic_timestamp,omitempty"`
 	// CPU statistics
 	// In nanoseconds (aggregated)
 	Cpu *v1.CpuStats `json:"cpu,omitempty"`
@@ -144,10 +191,29 @@ type ContainerStats struct {
 	Network *NetworkStats `json:"network,omitempty"`
 	// Filesystem statistics
 	Filesystem *FilesystemStats `json:"filesystem,omitempty"`
+	// Usage of the volumes mounted in the container
+	Volumes []v1.VolumeStats `json:"volumes,omitempty"`
 	// Task load statistics
 	Load *v1.LoadStats `json:"load_stats,omitempty"`
 	// Custom Metrics
 	CustomMetrics map[string][]v1.MetricVal `json:"custom_metrics,omitempty"`
+	// Errors of the subsystems whose stats could not be collected, by
+	// subsystem: "network", "tcp", "filesystem", "sched" or "custom". Their
+	// stats are omitted.
+	Errors map[string]string `json:"errors,omitempty"`
+	// Time of the previous stats, when samples are missing since then.
+	GapStart *time.Time `json:"gap_start,omitempty"`
+	// The cumulative counters that decreased since the previous stats.
+	CounterResets []string `json:"counter_resets,omitempty"`
+}
+
+// Returns the time elapsed between the previous stats and these, on the
+// monotonic clock when both have a monotonic timestamp.
+func (self *ContainerStats) Since(previous *ContainerStats) time.Duration {
+	if self.MonotonicTimestamp != nil && previous.MonotonicTimestamp != nil {
+		return self.MonotonicTimestamp.Sub(*previous.MonotonicTimestamp)
+	}
+	return self.Timestamp.Sub(previous.Timestamp)
 }
 
 type Percentiles struct {
@@ -198,6 +264,120 @@ type DerivedStats struct {
 	DayUsage Usage `json:"day_usage"`
 }
 
+// Derived stats of a container, with the aggregate of those of its
+// subcontainers.
+type ContainerSummary struct {
+	DerivedStats
+	// Sums of the derived stats of the direct subcontainers, whose usage each
+	// includes their own subcontainers'. The sums of the percentiles are upper
+	// bounds of the percentiles of the combined usage. Omitted for
+	// containers without subcontainers.
+	Subcontainers *DerivedStats `json:"subcontainers,omitempty"`
+	// Number of direct subcontainers aggregated.
+	NumSubcontainers int `json:"num_subcontainers,omitempty"`
+}
+
+// Short-term forecast of the usage of a resource, from a linear regression
+// over the recent minute averages.
+type ResourceForecast struct {
+	// Latest usage. Cpu in cpu milliseconds/second, memory in bytes.
+	Current uint64 `json:"current"`
+	// Change of the usage per minute. Negative if the usage decreases.
+	RatePerMinute float64 `json:"rate_per_minute"`
+	// Usage expected in 5, 15 and 60 minutes at the current rate.
+	In5Minutes  uint64 `json:"in_5_minutes"`
+	In15Minutes uint64 `json:"in_15_minutes"`
+	In60Minutes uint64 `json:"in_60_minutes"`
+	// Limit of the container. Unset if the container is not limited.
+	Limit uint64 `json:"limit,omitempty"`
+	// Minutes until the usage reaches the limit at the current rate. Unset if
+	// the container is not limited or its usage does not grow.
+	MinutesUntilLimit *float64 `json:"minutes_until_limit,omitempty"`
+}
+
+type Prediction struct {
+	// Time of generation of the prediction.
+	Timestamp time.Time `json:"timestamp"`
+	// Number of minute averages the forecasts are based on.
+	Samples int               `json:"samples"`
+	Cpu     *ResourceForecast `json:"cpu,omitempty"`
+	Memory  *ResourceForecast `json:"memory,omitempty"`
+}
+
+// Recommended request and limit of a resource.
+type ResourceRecommendation struct {
+	// 95th percentile of the usage over the window, plus headroom.
+	Request uint64 `json:"request"`
+	// Max usage over the window, plus headroom.
+	Limit uint64 `json:"limit"`
+}
+
+type Recommendation struct {
+	// Time of generation of the recommendation.
+	Timestamp time.Time `json:"timestamp"`
+	// Window of usage the recommendation is based on: "hour" or "day".
+	Window string `json:"window"`
+	// Percentage of the window for which usage was observed [0-100].
+	PercentComplete int32 `json:"percent_complete"`
+	// Fraction of the usage added as headroom.
+	Headroom float64 `json:"headroom"`
+	// Cpu in cpu milliseconds/second.
+	Cpu *ResourceRecommendation `json:"cpu,omitempty"`
+	// Memory in bytes.
+	Memory *ResourceRecommendation `json:"memory,omitempty"`
+}
+
+// Resource usage of a container over a time window.
+type ContainerUsage struct {
+	// Absolute name of the container.
+	Name string `json:"name"`
+	// Other names by which the container is known within its namespace.
+	Aliases []string `json:"aliases,omitempty"`
+	// Namespace under which the aliases of the container are unique.
+	Namespace string `json:"namespace,omitempty"`
+	// Average cpu rate in cpu milliseconds/second.
+	Cpu uint64 `json:"cpu"`
+	// Latest memory working set in bytes.
+	Memory uint64 `json:"memory"`
+	// Average network traffic, received and transmitted, in bytes/second.
+	Network uint64 `json:"network"`
+}
+
+// Image of a container runtime.
+type Image struct {
+	// Runtime storing the image: "docker" or "containerd".
+	Runtime string `json:"runtime"`
+	// containerd namespace of the image.
+	Namespace string `json:"namespace,omitempty"`
+	// ID of the image for Docker, digest of its manifest for containerd.
+	Id       string    `json:"id"`
+	RepoTags []string  `json:"repo_tags,omitempty"`
+	Created  time.Time `json:"created,omitempty"`
+	// Size of the image in bytes, including the layers it shares with other
+	// images. Uncompressed for Docker, compressed for containerd.
+	Size int64 `json:"size"`
+	// Number of layers of the image.
+	Layers int `json:"layers"`
+	// Absolute names of the containers running the image.
+	Containers []string `json:"containers,omitempty"`
+}
+
+// Usage of a Kubernetes pod, aggregated over its containers.
+type PodUsage struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace"`
+	Uid       string            `json:"uid"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	// Average cpu rate of the containers in cpu milliseconds/second.
+	Cpu uint64 `json:"cpu"`
+	// Latest memory working set of the containers in bytes.
+	Memory uint64 `json:"memory"`
+	// Average network traffic of the pod, received and transmitted, in bytes/second.
+	Network uint64 `json:"network"`
+	// Usage of the containers of the pod.
+	Containers []ContainerUsage `json:"containers"`
+}
+
 type FsInfo struct {
 	// The block device name associated with the filesystem.
 	Device string `json:"device"`
@@ -225,6 +405,14 @@ type RequestOptions struct {
 	Count int `json:"count"`
 	// Whether to include stats for child subcontainers.
 	Recursive bool `json:"recursive"`
+	// Whether to compute the cpu, memory, network and load stats of
+	// containers with subcontainers as the sums of those of their
+	// subcontainers instead of their own cgroup accounting.
+	Computed bool `json:"computed"`
+	// If set, only the stats added after this revision of the stats, or the
+	// containers added or changed after this revision of the specs, are
+	// returned.
+	Since uint64 `json:"since,omitempty"`
 }
 
 type ProcessInfo struct {
@@ -238,6 +426,7 @@ type ProcessInfo struct {
 	VirtualSize   uint64  `json:"virtual_size"`
 	Status        string  `json:"status"`
 	RunningTime   string  `json:"running_time"`
+	CpuTime       uint64  `json:"cpu_time"`
 	CgroupPath    string  `json:"cgroup_path"`
 	Cmd           string  `json:"cmd"`
 }
@@ -296,3 +485,14 @@ type FilesystemStats struct {
 	// Number of bytes consumed by a container through its root filesystem.
 	BaseUsageBytes *uint64 `json:"baseUsageBytes,omitempty"`
 }
+
+// Result of a check of the health of cAdvisor itself.
+type HealthCheck struct {
+	// "recovery", "docker", "housekeeping" or "storage".
+	Name    string `json:"name"`
+	Healthy bool   `json:"healthy"`
+	// Why the check failed.
+	Error string `json:"error,omitempty"`
+	// Absolute names of the containers whose housekeeping is not progressing.
+	StaleContainers []string `json:"stale_containers,omitempty"`
+}
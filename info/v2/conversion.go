@@ -18,8 +18,8 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/golang/glog"
 	"github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
 )
 
 func machineFsStatsFromV1(fsStats []v1.FsStats) []MachineFsStats {
@@ -65,7 +65,7 @@ func MachineStatsFromV1(cont *v1.ContainerInfo) []MachineStats {
 			stat.Cpu = &val.Cpu
 			cpuInst, err := InstCpuStats(last, val)
 			if err != nil {
-				glog.Warningf("Could not get instant cpu stats: %v", err)
+				logging.Warningf("Could not get instant cpu stats: %v", err)
 			} else {
 				stat.CpuInst = cpuInst
 			}
@@ -96,13 +96,17 @@ func ContainerStatsFromV1(spec *v1.ContainerSpec, stats []*v1.ContainerStats) []
 	var last *v1.ContainerStats
 	for _, val := range stats {
 		stat := &ContainerStats{
-			Timestamp: val.Timestamp,
+			Timestamp:          val.Timestamp,
+			MonotonicTimestamp: val.MonotonicTimestamp,
+			Errors:             val.Errors,
+			GapStart:           val.GapStart,
+			CounterResets:      val.CounterResets,
 		}
 		if spec.HasCpu {
 			stat.Cpu = &val.Cpu
 			cpuInst, err := InstCpuStats(last, val)
 			if err != nil {
-				glog.Warningf("Could not get instant cpu stats: %v", err)
+				logging.Warningf("Could not get instant cpu stats: %v", err)
 			} else {
 				stat.CpuInst = cpuInst
 			}
@@ -111,27 +115,35 @@ func ContainerStatsFromV1(spec *v1.ContainerSpec, stats []*v1.ContainerStats) []
 		if spec.HasMemory {
 			stat.Memory = &val.Memory
 		}
-		if spec.HasNetwork {
+		if spec.HasNetwork && val.Valid(v1.NetworkStatsSubsystem) {
 			// TODO: Handle TcpStats
 			stat.Network = &NetworkStats{
 				Interfaces: val.Network.Interfaces,
 			}
 		}
 		if spec.HasFilesystem {
-			if len(val.Filesystem) == 1 {
+			if !val.Valid(v1.FilesystemStatsSubsystem) {
+				// The usage of the filesystem is unknown.
+			} else if len(val.Filesystem) == 1 {
 				stat.Filesystem = &FilesystemStats{
 					TotalUsageBytes: &val.Filesystem[0].Usage,
 					BaseUsageBytes:  &val.Filesystem[0].BaseUsage,
 				}
 			} else if len(val.Filesystem) > 1 {
 				// Cannot handle multiple devices per container.
-				glog.V(2).Infof("failed to handle multiple devices for container. Skipping Filesystem stats")
+				logging.V(2).Infof("failed to handle multiple devices for container. Skipping Filesystem stats")
 			}
+			stat.Volumes = val.Volumes
 		}
 		if spec.HasDiskIo {
 			stat.DiskIo = &val.DiskIo
 		}
-		if spec.HasCustomMetrics {
+		// The raw task counts are only sampled by the load reader.
+		if val.TaskStats != (v1.LoadStats{}) {
+			taskStats := val.TaskStats
+			stat.Load = &taskStats
+		}
+		if spec.HasCustomMetrics && val.Valid(v1.CustomMetricsSubsystem) {
 			stat.CustomMetrics = val.CustomMetrics
 		}
 		// TODO(rjnagal): Handle load stats.
@@ -157,7 +169,7 @@ func DeprecatedStatsFromV1(cont *v1.ContainerInfo) []DeprecatedContainerStats {
 			stat.Cpu = val.Cpu
 			cpuInst, err := InstCpuStats(last, val)
 			if err != nil {
-				glog.Warningf("Could not get instant cpu stats: %v", err)
+				logging.Warningf("Could not get instant cpu stats: %v", err)
 			} else {
 				stat.CpuInst = cpuInst
 			}
@@ -188,13 +200,13 @@ func InstCpuStats(last, cur *v1.ContainerStats) (*CpuInstStats, error) {
 	if last == nil {
 		return nil, nil
 	}
-	if !cur.Timestamp.After(last.Timestamp) {
+	timeDelta := cur.Since(last)
+	if timeDelta <= 0 {
 		return nil, fmt.Errorf("container stats move backwards in time")
 	}
 	if len(last.Cpu.Usage.PerCpu) != len(cur.Cpu.Usage.PerCpu) {
 		return nil, fmt.Errorf("different number of cpus")
 	}
-	timeDelta := cur.Timestamp.Sub(last.Timestamp)
 	if timeDelta <= 100*time.Millisecond {
 		return nil, fmt.Errorf("time delta unexpectedly small")
 	}
@@ -242,6 +254,7 @@ func InstCpuStats(last, cur *v1.ContainerStats) (*CpuInstStats, error) {
 func ContainerSpecFromV1(specV1 *v1.ContainerSpec, aliases []string, namespace string) ContainerSpec {
 	specV2 := ContainerSpec{
 		CreationTime:     specV1.CreationTime,
+		ExitTime:         specV1.ExitTime,
 		HasCpu:           specV1.HasCpu,
 		HasMemory:        specV1.HasMemory,
 		HasFilesystem:    specV1.HasFilesystem,
@@ -250,11 +263,18 @@ func ContainerSpecFromV1(specV1 *v1.ContainerSpec, aliases []string, namespace s
 		HasCustomMetrics: specV1.HasCustomMetrics,
 		Image:            specV1.Image,
 		Labels:           specV1.Labels,
+		Envs:             specV1.Envs,
+		Health:           specV1.Health,
+		RestartCount:     specV1.RestartCount,
+		RestartPolicy:    specV1.RestartPolicy,
+		Generation:       specV1.Generation,
+		NetworkOwner:     specV1.NetworkOwner,
 	}
 	if specV1.HasCpu {
 		specV2.Cpu.Limit = specV1.Cpu.Limit
 		specV2.Cpu.MaxLimit = specV1.Cpu.MaxLimit
 		specV2.Cpu.Mask = specV1.Cpu.Mask
+		specV2.Cpu.Mems = specV1.Cpu.Mems
 	}
 	if specV1.HasMemory {
 		specV2.Memory.Limit = specV1.Memory.Limit
@@ -273,13 +293,13 @@ func instCpuStats(last, cur *v1.ContainerStats) (*CpuInstStats, error) {
 	if last == nil {
 		return nil, nil
 	}
-	if !cur.Timestamp.After(last.Timestamp) {
+	timeDelta := cur.Since(last)
+	if timeDelta <= 0 {
 		return nil, fmt.Errorf("container stats move backwards in time")
 	}
 	if len(last.Cpu.Usage.PerCpu) != len(cur.Cpu.Usage.PerCpu) {
 		return nil, fmt.Errorf("different number of cpus")
 	}
-	timeDelta := cur.Timestamp.Sub(last.Timestamp)
 	if timeDelta <= 100*time.Millisecond {
 		return nil, fmt.Errorf("time delta unexpectedly small")
 	}
@@ -15,6 +15,7 @@
 package v2
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
 	"time"
@@ -26,12 +27,14 @@ import (
 var (
 	timestamp = time.Date(1987, time.August, 10, 0, 0, 0, 0, time.UTC)
 	labels    = map[string]string{"foo": "bar"}
+	envs      = map[string]string{"SERVICE_NAME": "web"}
 )
 
 func TestContanierSpecFromV1(t *testing.T) {
 	v1Spec := v1.ContainerSpec{
 		CreationTime: timestamp,
 		Labels:       labels,
+		Envs:         envs,
 		HasCpu:       true,
 		Cpu: v1.CpuSpec{
 			Limit:    2048,
@@ -63,6 +66,7 @@ func TestContanierSpecFromV1(t *testing.T) {
 	expectedV2Spec := ContainerSpec{
 		CreationTime: timestamp,
 		Labels:       labels,
+		Envs:         envs,
 		HasCpu:       true,
 		Cpu: CpuSpec{
 			Limit:    2048,
@@ -173,6 +177,11 @@ func TestContainerStatsFromV1(t *testing.T) {
 			Available:  300,
 			InodesFree: 100,
 		}},
+		TaskStats: v1.LoadStats{
+			NrSleeping:        3,
+			NrRunning:         2,
+			NrUninterruptible: 1,
+		},
 	}
 	expectedV2Stats := ContainerStats{
 		Timestamp: timestamp,
@@ -186,6 +195,7 @@ func TestContainerStatsFromV1(t *testing.T) {
 			TotalUsageBytes: &v1Stats.Filesystem[0].Usage,
 			BaseUsageBytes:  &v1Stats.Filesystem[0].BaseUsage,
 		},
+		Load: &v1Stats.TaskStats,
 	}
 
 	v2Stats := ContainerStatsFromV1(&v1Spec, []*v1.ContainerStats{&v1Stats})
@@ -194,6 +204,26 @@ func TestContainerStatsFromV1(t *testing.T) {
 	if !reflect.DeepEqual(expectedV2Stats, actualV2Stats) {
 		t.Errorf("Converted stats differs from expectation!\nExpected: %+v\n Got: %+v\n", expectedV2Stats, actualV2Stats)
 	}
+
+	// The stats of the subsystems that could not be collected are omitted.
+	v1Stats.SetError(v1.NetworkStatsSubsystem, fmt.Errorf("no such file"))
+	v1Stats.SetError(v1.FilesystemStatsSubsystem, fmt.Errorf("no such device"))
+	expectedV2Stats.Network = nil
+	expectedV2Stats.Filesystem = nil
+	expectedV2Stats.Errors = map[string]string{
+		v1.NetworkStatsSubsystem:    "no such file",
+		v1.FilesystemStatsSubsystem: "no such device",
+	}
+	v2Stats = ContainerStatsFromV1(&v1Spec, []*v1.ContainerStats{&v1Stats})
+	actualV2Stats = *v2Stats[0]
+
+	if !reflect.DeepEqual(expectedV2Stats, actualV2Stats) {
+		t.Errorf("Converted stats with errors differs from expectation!\nExpected: %+v\n Got: %+v\n", expectedV2Stats, actualV2Stats)
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
 }
 
 func TestInstCpuStats(t *testing.T) {
@@ -350,6 +380,41 @@ func TestInstCpuStats(t *testing.T) {
 				},
 			},
 		},
+		// Wall clock stepped back, one second elapsed on the monotonic clock
+		{
+			&v1.ContainerStats{
+				Timestamp:          time.Unix(100, 0),
+				MonotonicTimestamp: timePtr(time.Unix(50, 0)),
+				Cpu: v1.CpuStats{
+					Usage: v1.CpuUsage{
+						Total:  300,
+						PerCpu: []uint64{100, 200},
+						User:   250,
+						System: 50,
+					},
+				},
+			},
+			&v1.ContainerStats{
+				Timestamp:          time.Unix(90, 0),
+				MonotonicTimestamp: timePtr(time.Unix(51, 0)),
+				Cpu: v1.CpuStats{
+					Usage: v1.CpuUsage{
+						Total:  500,
+						PerCpu: []uint64{200, 300},
+						User:   400,
+						System: 100,
+					},
+				},
+			},
+			&CpuInstStats{
+				Usage: CpuInstUsage{
+					Total:  200,
+					PerCpu: []uint64{100, 100},
+					User:   150,
+					System: 50,
+				},
+			},
+		},
 	}
 	for _, c := range tests {
 		got, err := instCpuStats(c.last, c.cur)
@@ -362,3 +427,34 @@ func TestInstCpuStats(t *testing.T) {
 		assert.Equal(t, c.want, got)
 	}
 }
+
+func TestDownsampleStats(t *testing.T) {
+	var stats []*ContainerStats
+	for i := 0; i < 5; i++ {
+		stats = append(stats, &ContainerStats{
+			Timestamp: timestamp.Add(time.Duration(i*10) * time.Second),
+			Cpu:       &v1.CpuStats{Usage: v1.CpuUsage{Total: uint64(i * 100)}},
+			Memory:    &v1.MemoryStats{Usage: uint64(i * 10), WorkingSet: uint64(i)},
+		})
+	}
+
+	// Steps of 30s hold samples 0-2 and 3-4.
+	avg, err := DownsampleStats(stats, 30*time.Second, DownsampleAvg)
+	assert.NoError(t, err)
+	assert.Len(t, avg, 2)
+	assert.Equal(t, stats[2].Timestamp, avg[0].Timestamp)
+	assert.Equal(t, uint64(200), avg[0].Cpu.Usage.Total)
+	assert.Equal(t, uint64(10), avg[0].Memory.Usage)
+	assert.Equal(t, uint64(35), avg[1].Memory.Usage)
+
+	max, err := DownsampleStats(stats, 30*time.Second, DownsampleMax)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(20), max[0].Memory.Usage)
+	assert.Equal(t, uint64(4), max[1].Memory.WorkingSet)
+
+	// The input is left untouched.
+	assert.Equal(t, uint64(20), stats[2].Memory.Usage)
+
+	_, err = DownsampleStats(stats, 30*time.Second, "min")
+	assert.Error(t, err)
+}
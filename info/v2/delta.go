@@ -0,0 +1,260 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Samples of a container encoded as the first one followed by the changes of
+// each sample to the previous one, which are much smaller than full samples
+// for high resolution windows.
+//
+// A change is the JSON object of the fields of the sample which differ from
+// the previous one. Objects are changed field by field, with removed fields
+// set to null. Integers are changed by the difference to their previous
+// value. Arrays of the same length as the previous one are changed element
+// by element, with 0 for unchanged integers and {} for unchanged objects.
+// Other values, and the fields absent from the previous sample, are set to
+// their new value.
+type DeltaStats struct {
+	First  *ContainerStats   `json:"first,omitempty"`
+	Deltas []json.RawMessage `json:"deltas,omitempty"`
+}
+
+// Info of a container with delta encoded stats.
+type DeltaContainerInfo struct {
+	Spec  ContainerSpec `json:"spec,omitempty"`
+	Stats DeltaStats    `json:"stats"`
+}
+
+// Returns the delta encoding of the samples, from first to last.
+func DeltaEncodeStats(stats []*ContainerStats) (DeltaStats, error) {
+	if len(stats) == 0 {
+		return DeltaStats{}, nil
+	}
+	encoded := DeltaStats{
+		First:  stats[0],
+		Deltas: make([]json.RawMessage, 0, len(stats)-1),
+	}
+	prev, err := toJsonValue(stats[0])
+	if err != nil {
+		return DeltaStats{}, err
+	}
+	for _, s := range stats[1:] {
+		cur, err := toJsonValue(s)
+		if err != nil {
+			return DeltaStats{}, err
+		}
+		delta, _ := diffJsonValues(prev, cur)
+		out, err := json.Marshal(delta)
+		if err != nil {
+			return DeltaStats{}, err
+		}
+		encoded.Deltas = append(encoded.Deltas, out)
+		prev = cur
+	}
+	return encoded, nil
+}
+
+// Expand returns the samples, from first to last.
+func (self *DeltaStats) Expand() ([]*ContainerStats, error) {
+	if self.First == nil {
+		return nil, nil
+	}
+	stats := make([]*ContainerStats, 0, len(self.Deltas)+1)
+	stats = append(stats, self.First)
+	value, err := toJsonValue(self.First)
+	if err != nil {
+		return nil, err
+	}
+	for i, raw := range self.Deltas {
+		delta, err := decodeJsonValue(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid delta %d: %v", i, err)
+		}
+		value = applyJsonDelta(value, delta)
+		out, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		s := &ContainerStats{}
+		if err := json.Unmarshal(out, s); err != nil {
+			return nil, fmt.Errorf("invalid delta %d: %v", i, err)
+		}
+		stats = append(stats, s)
+	}
+	return stats, nil
+}
+
+// Returns the delta encoding of the stats of the container.
+func DeltaEncodeInfo(cinfo ContainerInfo) (DeltaContainerInfo, error) {
+	stats, err := DeltaEncodeStats(cinfo.Stats)
+	if err != nil {
+		return DeltaContainerInfo{}, err
+	}
+	return DeltaContainerInfo{
+		Spec:  cinfo.Spec,
+		Stats: stats,
+	}, nil
+}
+
+// Expand returns the info of the container with all its samples.
+func (self *DeltaContainerInfo) Expand() (ContainerInfo, error) {
+	stats, err := self.Stats.Expand()
+	if err != nil {
+		return ContainerInfo{}, err
+	}
+	return ContainerInfo{
+		Spec:  self.Spec,
+		Stats: stats,
+	}, nil
+}
+
+// Returns the generic JSON value of v, with numbers kept as they are
+// written.
+func toJsonValue(v interface{}) (interface{}, error) {
+	out, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return decodeJsonValue(out)
+}
+
+func decodeJsonValue(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// Returns the integer the number is, if it is one.
+func jsonInteger(v interface{}) (*big.Int, bool) {
+	n, ok := v.(json.Number)
+	if !ok || strings.ContainsAny(string(n), ".eE") {
+		return nil, false
+	}
+	return new(big.Int).SetString(string(n), 10)
+}
+
+// Returns the change from prev to cur, and whether there is one.
+func diffJsonValues(prev, cur interface{}) (interface{}, bool) {
+	if p, ok := jsonInteger(prev); ok {
+		if c, ok := jsonInteger(cur); ok {
+			d := new(big.Int).Sub(c, p)
+			return json.Number(d.String()), d.Sign() != 0
+		}
+	}
+	switch c := cur.(type) {
+	case map[string]interface{}:
+		p, ok := prev.(map[string]interface{})
+		if !ok {
+			break
+		}
+		delta := map[string]interface{}{}
+		for k, cv := range c {
+			pv, ok := p[k]
+			if !ok {
+				delta[k] = cv
+			} else if d, changed := diffJsonValues(pv, cv); changed {
+				delta[k] = d
+			}
+		}
+		for k := range p {
+			if _, ok := c[k]; !ok {
+				delta[k] = nil
+			}
+		}
+		return delta, len(delta) > 0
+	case []interface{}:
+		p, ok := prev.([]interface{})
+		if !ok || len(p) != len(c) {
+			break
+		}
+		delta := make([]interface{}, len(c))
+		changed := false
+		for i := range c {
+			d, ch := diffJsonValues(p[i], c[i])
+			delta[i] = d
+			changed = changed || ch
+		}
+		return delta, changed
+	}
+	// Set to the new value.
+	return cur, !jsonEqual(prev, cur)
+}
+
+func jsonEqual(a, b interface{}) bool {
+	switch av := a.(type) {
+	case json.Number:
+		bv, ok := b.(json.Number)
+		return ok && av == bv
+	case string:
+		bv, ok := b.(string)
+		return ok && av == bv
+	case bool:
+		bv, ok := b.(bool)
+		return ok && av == bv
+	case nil:
+		return b == nil
+	}
+	// Objects and arrays of different types or lengths.
+	return false
+}
+
+// Returns prev changed by the delta. prev may be modified.
+func applyJsonDelta(prev, delta interface{}) interface{} {
+	if p, ok := jsonInteger(prev); ok {
+		if d, ok := jsonInteger(delta); ok {
+			return json.Number(p.Add(p, d).String())
+		}
+	}
+	switch d := delta.(type) {
+	case map[string]interface{}:
+		p, ok := prev.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for k, dv := range d {
+			pv, ok := p[k]
+			switch {
+			case dv == nil:
+				delete(p, k)
+			case ok:
+				p[k] = applyJsonDelta(pv, dv)
+			default:
+				p[k] = dv
+			}
+		}
+		return p
+	case []interface{}:
+		p, ok := prev.([]interface{})
+		if !ok || len(p) != len(d) {
+			break
+		}
+		for i := range d {
+			p[i] = applyJsonDelta(p[i], d[i])
+		}
+		return p
+	}
+	return delta
+}
@@ -0,0 +1,93 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/cadvisor/info/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func deltaTestStats() []*ContainerStats {
+	total := uint64(math.MaxUint64 - 10)
+	stats := []*ContainerStats{}
+	for i := 0; i < 6; i++ {
+		s := &ContainerStats{
+			Timestamp: timestamp.Add(time.Duration(i) * time.Second),
+			Cpu: &v1.CpuStats{
+				Usage: v1.CpuUsage{
+					Total:  total,
+					PerCpu: []uint64{uint64(100 * i), 7, uint64(3 * i)},
+				},
+				LoadAverage: int32(i % 2),
+			},
+			Memory: &v1.MemoryStats{Usage: uint64(1000 - i)},
+			Network: &NetworkStats{
+				Interfaces: []v1.InterfaceStats{{Name: "eth0", RxBytes: uint64(10 * i)}},
+			},
+			CustomMetrics: map[string][]v1.MetricVal{
+				"ratio": {{FloatValue: float64(i) / 2, Timestamp: timestamp}},
+			},
+		}
+		total += 7
+		stats = append(stats, s)
+	}
+	// Fields and interfaces appear and disappear.
+	stats[2].Network.Interfaces = append(stats[2].Network.Interfaces, v1.InterfaceStats{Name: "eth1", TxBytes: 3})
+	stats[3].Memory = nil
+	stats[4].Errors = map[string]string{"network": "failed"}
+	stats[4].Network = nil
+	return stats
+}
+
+func TestDeltaStats(t *testing.T) {
+	stats := deltaTestStats()
+	encoded, err := DeltaEncodeStats(stats)
+	require.NoError(t, err)
+	assert.Len(t, encoded.Deltas, len(stats)-1)
+	// Large counters are changed by their difference, even when they wrap.
+	assert.Contains(t, string(encoded.Deltas[0]), `"total":7`)
+	assert.Contains(t, string(encoded.Deltas[1]), `"total":-18446744073709551609`)
+
+	out, err := json.Marshal(encoded)
+	require.NoError(t, err)
+	full, err := json.Marshal(stats)
+	require.NoError(t, err)
+	assert.True(t, len(out) < len(full), "%d bytes delta encoded, %d bytes in full", len(out), len(full))
+
+	var decoded DeltaStats
+	require.NoError(t, json.Unmarshal(out, &decoded))
+	expanded, err := decoded.Expand()
+	require.NoError(t, err)
+	actual, err := json.Marshal(expanded)
+	require.NoError(t, err)
+	assert.Equal(t, string(full), string(actual))
+}
+
+func TestDeltaEncodeEmpty(t *testing.T) {
+	encoded, err := DeltaEncodeInfo(ContainerInfo{})
+	require.NoError(t, err)
+	cinfo, err := encoded.Expand()
+	require.NoError(t, err)
+	assert.Empty(t, cinfo.Stats)
+
+	_, err = (&DeltaStats{First: &ContainerStats{}, Deltas: []json.RawMessage{json.RawMessage("{")}}).Expand()
+	assert.Error(t, err)
+}
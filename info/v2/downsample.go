@@ -0,0 +1,183 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v2
+
+import (
+	"fmt"
+	"time"
+)
+
+// Functions combining the samples of a downsampling step.
+const (
+	DownsampleAvg = "avg"
+	DownsampleMax = "max"
+)
+
+type aggregator func(values []uint64) uint64
+
+func avgValue(values []uint64) uint64 {
+	var sum uint64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / uint64(len(values))
+}
+
+func maxValue(values []uint64) uint64 {
+	var m uint64
+	for _, v := range values {
+		if v > m {
+			m = v
+		}
+	}
+	return m
+}
+
+// Combines the samples falling in the same step into a single sample.
+// Samples must be ordered from oldest to newest. Steps are aligned on
+// multiples of step since the epoch.
+//
+// Each returned sample has the timestamp and cumulative counters of the last
+// sample of its step. Instantaneous values (cpu rates, memory usage, load and
+// filesystem usage) are combined with fn.
+func DownsampleStats(stats []*ContainerStats, step time.Duration, fn string) ([]*ContainerStats, error) {
+	var agg aggregator
+	switch fn {
+	case DownsampleAvg:
+		agg = avgValue
+	case DownsampleMax:
+		agg = maxValue
+	default:
+		return nil, fmt.Errorf("unknown downsampling function %q", fn)
+	}
+	if step <= 0 || len(stats) == 0 {
+		return stats, nil
+	}
+
+	result := []*ContainerStats{}
+	start := 0
+	for i := 1; i <= len(stats); i++ {
+		if i < len(stats) && stats[i].Timestamp.Truncate(step).Equal(stats[start].Timestamp.Truncate(step)) {
+			continue
+		}
+		result = append(result, combineStats(stats[start:i], agg))
+		start = i
+	}
+	return result, nil
+}
+
+func combineStats(group []*ContainerStats, agg aggregator) *ContainerStats {
+	last := group[len(group)-1]
+	if len(group) == 1 {
+		return last
+	}
+	out := *last
+
+	// Applies agg to the values extracted from the samples that have them.
+	combine := func(get func(s *ContainerStats) (uint64, bool)) uint64 {
+		values := make([]uint64, 0, len(group))
+		for _, s := range group {
+			if v, ok := get(s); ok {
+				values = append(values, v)
+			}
+		}
+		return agg(values)
+	}
+
+	if last.CpuInst != nil {
+		inst := *last.CpuInst
+		inst.Usage.Total = combine(func(s *ContainerStats) (uint64, bool) {
+			if s.CpuInst == nil {
+				return 0, false
+			}
+			return s.CpuInst.Usage.Total, true
+		})
+		inst.Usage.User = combine(func(s *ContainerStats) (uint64, bool) {
+			if s.CpuInst == nil {
+				return 0, false
+			}
+			return s.CpuInst.Usage.User, true
+		})
+		inst.Usage.System = combine(func(s *ContainerStats) (uint64, bool) {
+			if s.CpuInst == nil {
+				return 0, false
+			}
+			return s.CpuInst.Usage.System, true
+		})
+		out.CpuInst = &inst
+	}
+	if last.Memory != nil {
+		memory := *last.Memory
+		memory.Usage = combine(func(s *ContainerStats) (uint64, bool) {
+			if s.Memory == nil {
+				return 0, false
+			}
+			return s.Memory.Usage, true
+		})
+		memory.Cache = combine(func(s *ContainerStats) (uint64, bool) {
+			if s.Memory == nil {
+				return 0, false
+			}
+			return s.Memory.Cache, true
+		})
+		memory.RSS = combine(func(s *ContainerStats) (uint64, bool) {
+			if s.Memory == nil {
+				return 0, false
+			}
+			return s.Memory.RSS, true
+		})
+		memory.WorkingSet = combine(func(s *ContainerStats) (uint64, bool) {
+			if s.Memory == nil {
+				return 0, false
+			}
+			return s.Memory.WorkingSet, true
+		})
+		out.Memory = &memory
+	}
+	if last.Load != nil {
+		load := *last.Load
+		load.NrRunning = combine(func(s *ContainerStats) (uint64, bool) {
+			if s.Load == nil {
+				return 0, false
+			}
+			return s.Load.NrRunning, true
+		})
+		load.NrUninterruptible = combine(func(s *ContainerStats) (uint64, bool) {
+			if s.Load == nil {
+				return 0, false
+			}
+			return s.Load.NrUninterruptible, true
+		})
+		load.NrIoWait = combine(func(s *ContainerStats) (uint64, bool) {
+			if s.Load == nil {
+				return 0, false
+			}
+			return s.Load.NrIoWait, true
+		})
+		out.Load = &load
+	}
+	if last.Filesystem != nil && last.Filesystem.TotalUsageBytes != nil {
+		fs := *last.Filesystem
+		total := combine(func(s *ContainerStats) (uint64, bool) {
+			if s.Filesystem == nil || s.Filesystem.TotalUsageBytes == nil {
+				return 0, false
+			}
+			return *s.Filesystem.TotalUsageBytes, true
+		})
+		fs.TotalUsageBytes = &total
+		out.Filesystem = &fs
+	}
+	return &out
+}
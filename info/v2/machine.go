@@ -58,15 +58,36 @@ type Attributes struct {
 	// Network devices
 	NetworkDevices []v1.NetInfo `json:"network_devices"`
 
+	// PCI devices, sorted by address.
+	PciDevices []v1.PciDevice `json:"pci_devices,omitempty"`
+
 	// Machine Topology
 	// Describes cpu/memory layout and hierarchy.
 	Topology []v1.Node `json:"topology"`
 
+	// The number of cpu sockets and of physical cores in this machine.
+	NumSockets       int `json:"num_sockets"`
+	NumPhysicalCores int `json:"num_physical_cores"`
+
+	// The number of hardware threads per physical core, more than 1 when
+	// simultaneous multithreading (SMT) is enabled.
+	ThreadsPerCore int `json:"threads_per_core"`
+
+	// NUMA nodes, with their cpus and distances.
+	NumaNodes []v1.NumaNode `json:"numa_nodes,omitempty"`
+
 	// Cloud provider the machine belongs to
 	CloudProvider v1.CloudProvider `json:"cloud_provider"`
 
 	// Type of cloud instance (e.g. GCE standard) the machine is.
 	InstanceType v1.InstanceType `json:"instance_type"`
+
+	// Zone and region of the cloud instance.
+	Zone   string `json:"zone,omitempty"`
+	Region string `json:"region,omitempty"`
+
+	// Tags of the cloud instance.
+	InstanceTags map[string]string `json:"instance_tags,omitempty"`
 }
 
 func GetAttributes(mi *v1.MachineInfo, vi *v1.VersionInfo) Attributes {
@@ -83,9 +104,17 @@ func GetAttributes(mi *v1.MachineInfo, vi *v1.VersionInfo) Attributes {
 		Filesystems:        mi.Filesystems,
 		DiskMap:            mi.DiskMap,
 		NetworkDevices:     mi.NetworkDevices,
+		PciDevices:         mi.PciDevices,
 		Topology:           mi.Topology,
+		NumSockets:         mi.NumSockets,
+		NumPhysicalCores:   mi.NumPhysicalCores,
+		ThreadsPerCore:     mi.ThreadsPerCore,
+		NumaNodes:          mi.NumaNodes,
 		CloudProvider:      mi.CloudProvider,
 		InstanceType:       mi.InstanceType,
+		Zone:               mi.Zone,
+		Region:             mi.Region,
+		InstanceTags:       mi.InstanceTags,
 	}
 }
 
@@ -188,3 +217,115 @@ type DiskStats struct {
 	// I/O completion time and the backlog that may be accumulating.
 	WeightedIoDuration *time.Duration `json:"weighted_io_duration,omitempty"`
 }
+
+// HostStats contains node-wide kernel statistics that don't belong to any
+// container. Statistics the kernel doesn't report are omitted.
+type HostStats struct {
+	// The time of this stat point.
+	Timestamp time.Time `json:"timestamp"`
+
+	// Bits of entropy available in the kernel random pool.
+	EntropyAvailable *uint64 `json:"entropy_available,omitempty"`
+
+	// Allocated file handles and their maximum.
+	FileHandlesAllocated *uint64 `json:"file_handles_allocated,omitempty"`
+	FileHandlesMax       *uint64 `json:"file_handles_max,omitempty"`
+
+	// Entries in the connection tracking table and its maximum.
+	ConntrackEntries *uint64 `json:"conntrack_entries,omitempty"`
+	ConntrackMax     *uint64 `json:"conntrack_max,omitempty"`
+
+	// Entries in the ARP cache.
+	ArpEntries *uint64 `json:"arp_entries,omitempty"`
+
+	// Temperatures of the hardware sensors.
+	Temperatures []Temperature `json:"temperatures,omitempty"`
+
+	// Cumulative energy counters of the RAPL zones.
+	Energy []EnergyCounter `json:"energy,omitempty"`
+
+	// Power of the cpu packages in watts over the last energy sampling
+	// interval, and its share attributed to each container by cpu usage.
+	// Only set when energy sampling is enabled.
+	PackagePower   *float64           `json:"package_power_watts,omitempty"`
+	ContainerPower map[string]float64 `json:"container_power_watts,omitempty"`
+
+	// Usage of the devicemapper thin-pools at their last check. Only set
+	// when thin-pools are checked.
+	ThinPools []ThinPoolStats `json:"thin_pools,omitempty"`
+}
+
+// The usage of a devicemapper thin-pool.
+type ThinPoolStats struct {
+	// Name of the thin-pool device.
+	Name string `json:"name"`
+	// Used and total data space in bytes.
+	DataUsed  uint64 `json:"data_used"`
+	DataTotal uint64 `json:"data_total"`
+	// Used and total metadata space in bytes.
+	MetadataUsed  uint64 `json:"metadata_used"`
+	MetadataTotal uint64 `json:"metadata_total"`
+	// "rw", "ro" when the metadata is exhausted, "out_of_data_space" or
+	// "Fail".
+	Mode string `json:"mode"`
+}
+
+// The temperature of a hardware sensor.
+type Temperature struct {
+	// Name of the sensor chip, e.g. "coretemp".
+	Sensor string `json:"sensor"`
+	// Label of the input, e.g. "Package id 0" or "Core 1".
+	Label string `json:"label"`
+	// Temperature in degrees Celsius.
+	Celsius float64 `json:"celsius"`
+}
+
+// A cumulative energy counter of a RAPL zone.
+type EnergyCounter struct {
+	// The zone, e.g. "intel-rapl:0" or "intel-rapl:0:1".
+	Zone string `json:"zone"`
+	// The power domain of the zone, e.g. "package-0", "core" or "dram".
+	Domain string `json:"domain"`
+	// Energy consumed in joules. Wraps around to 0 after MaxJoules.
+	Joules    float64 `json:"joules"`
+	MaxJoules float64 `json:"max_joules"`
+}
+
+// SelfStats contains the resource usage of cAdvisor itself.
+type SelfStats struct {
+	// The time of this stat point.
+	Timestamp time.Time `json:"timestamp"`
+	// The time cAdvisor started.
+	StartTime time.Time `json:"start_time"`
+
+	// Number of goroutines.
+	Goroutines int `json:"goroutines"`
+	// Number of containers tracked by the manager, aliases excluded.
+	Containers int `json:"containers"`
+
+	// Bytes of allocated heap objects and of the heap obtained from the
+	// system, and the number of allocated heap objects.
+	HeapAlloc   uint64 `json:"heap_alloc"`
+	HeapSys     uint64 `json:"heap_sys"`
+	HeapObjects uint64 `json:"heap_objects"`
+	// Cumulative bytes and number of heap objects allocated.
+	TotalAlloc uint64 `json:"total_alloc"`
+	Mallocs    uint64 `json:"mallocs"`
+	// Bytes of memory obtained from the system by the Go runtime.
+	Sys uint64 `json:"sys"`
+
+	// Number of completed garbage collections, their cumulative pause and
+	// the pause of the last one in nanoseconds.
+	NumGC        uint32 `json:"num_gc"`
+	GCPauseTotal uint64 `json:"gc_pause_total_ns"`
+	GCPauseLast  uint64 `json:"gc_pause_last_ns"`
+	// Heap size of the next garbage collection.
+	NextGC uint64 `json:"next_gc"`
+	// Fraction of the cpu time used by the garbage collector since start.
+	GCCPUFraction float64 `json:"gc_cpu_fraction"`
+
+	// The container cAdvisor runs in and its last stats, when it runs in a
+	// container other than the root.
+	Container string            `json:"container,omitempty"`
+	Stats     []*ContainerStats `json:"stats,omitempty"`
+}
@@ -32,8 +32,8 @@ import (
 	"sync"
 	"time"
 
-	"github.com/golang/glog"
 	cadvisorApi "github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/logging"
 )
 
 // must be able to ssh into hosts without password
@@ -90,7 +90,7 @@ func RunSshCommand(cmd string, args ...string) error {
 
 func PushAndRunTests(host, testDir string) error {
 	// Push binary.
-	glog.Infof("Pushing cAdvisor binary to %q...", host)
+	logging.Infof("Pushing cAdvisor binary to %q...", host)
 
 	err := RunSshCommand("ssh", host, "--", "mkdir", "-p", testDir)
 	if err != nil {
@@ -99,7 +99,7 @@ func PushAndRunTests(host, testDir string) error {
 	defer func() {
 		err = RunSshCommand("ssh", host, "--", "rm", "-rf", testDir)
 		if err != nil {
-			glog.Errorf("Failed to cleanup test directory: %v", err)
+			logging.Errorf("Failed to cleanup test directory: %v", err)
 		}
 	}()
 
@@ -109,7 +109,7 @@ func PushAndRunTests(host, testDir string) error {
 	}
 
 	// Start cAdvisor.
-	glog.Infof("Running cAdvisor on %q...", host)
+	logging.Infof("Running cAdvisor on %q...", host)
 	portStr := strconv.Itoa(*port)
 	errChan := make(chan error)
 	go func() {
@@ -121,7 +121,7 @@ func PushAndRunTests(host, testDir string) error {
 	defer func() {
 		err = RunSshCommand("ssh", host, "--", "sudo", "pkill", cadvisorBinary)
 		if err != nil {
-			glog.Errorf("Failed to cleanup: %v", err)
+			logging.Errorf("Failed to cleanup: %v", err)
 		}
 	}()
 
@@ -152,12 +152,12 @@ func PushAndRunTests(host, testDir string) error {
 		return fmt.Errorf("%v - %q", err, host)
 	}
 	// Run the tests in a retry loop.
-	glog.Infof("Running integration tests targeting %q...", host)
+	logging.Infof("Running integration tests targeting %q...", host)
 	for i := 0; i <= *testRetryCount; i++ {
 		// Check if this is a retry
 		if i > 0 {
 			time.Sleep(time.Second * 15) // Wait 15 seconds before retrying
-			glog.Warningf("Retrying (%d of %d) tests on host %s due to error %v", i, *testRetryCount, host, err)
+			logging.Warningf("Retrying (%d of %d) tests on host %s due to error %v", i, *testRetryCount, host, err)
 		}
 		// Run the command
 
@@ -169,7 +169,7 @@ func PushAndRunTests(host, testDir string) error {
 
 		// Only retry on test failures caused by these known flaky failure conditions
 		if retryRegex == nil || !retryRegex.Match([]byte(err.Error())) {
-			glog.Warningf("Skipping retry for tests on host %s because error is not whitelisted", host)
+			logging.Warningf("Skipping retry for tests on host %s because error is not whitelisted", host)
 			break
 		}
 	}
@@ -185,7 +185,7 @@ func PushAndRunTests(host, testDir string) error {
 		if err2 != nil {
 			return fmt.Errorf("error reading local log file: %v for %v", err2, err)
 		}
-		glog.Errorf("----------------------\nLogs from Host: %q\n%v\n", host, string(logs))
+		logging.Errorf("----------------------\nLogs from Host: %q\n%v\n", host, string(logs))
 		err = fmt.Errorf("error on host %s: %v\n%+v", host, err, attributes)
 	}
 	return err
@@ -194,16 +194,16 @@ func PushAndRunTests(host, testDir string) error {
 func Run() error {
 	start := time.Now()
 	defer func() {
-		glog.Infof("Execution time %v", time.Since(start))
+		logging.Infof("Execution time %v", time.Since(start))
 	}()
-	defer glog.Flush()
+	defer logging.Flush()
 
 	hosts := flag.Args()
 	testDir := fmt.Sprintf("/tmp/cadvisor-%d", os.Getpid())
-	glog.Infof("Running integration tests on host(s) %q", strings.Join(hosts, ","))
+	logging.Infof("Running integration tests on host(s) %q", strings.Join(hosts, ","))
 
 	// Build cAdvisor.
-	glog.Infof("Building cAdvisor...")
+	logging.Infof("Building cAdvisor...")
 	err := RunCommand("godep", "go", "build", "github.com/google/cadvisor")
 	if err != nil {
 		return err
@@ -211,7 +211,7 @@ func Run() error {
 	defer func() {
 		err := RunCommand("rm", cadvisorBinary)
 		if err != nil {
-			glog.Error(err)
+			logging.Error(err)
 		}
 	}()
 
@@ -245,7 +245,7 @@ func Run() error {
 		return errors.New(buffer.String())
 	}
 
-	glog.Infof("All tests pass!")
+	logging.Infof("All tests pass!")
 	return nil
 }
 
@@ -257,7 +257,7 @@ func initRetryWhitelist() {
 
 	file, err := os.Open(*testRetryWhitelist)
 	if err != nil {
-		glog.Fatal(err)
+		logging.Fatal(err)
 	}
 	defer file.Close()
 
@@ -270,7 +270,7 @@ func initRetryWhitelist() {
 		}
 	}
 	if err := scanner.Err(); err != nil {
-		glog.Fatal(err)
+		logging.Fatal(err)
 	}
 	retryRegex = regexp.MustCompile(strings.Join(retryStrings, "|"))
 }
@@ -280,13 +280,13 @@ func main() {
 
 	// Check usage.
 	if len(flag.Args()) == 0 {
-		glog.Fatalf("USAGE: runner <hosts to test>")
+		logging.Fatalf("USAGE: runner <hosts to test>")
 	}
 	initRetryWhitelist()
 
 	// Run the tests.
 	err := Run()
 	if err != nil {
-		glog.Fatal(err)
+		logging.Fatal(err)
 	}
 }
@@ -0,0 +1,285 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package kubernetes lists the pods of the node from the kubelet or the API
+// server, to attach their metadata to the containers of the pods and group
+// the usage of the containers by pod.
+package kubernetes
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
+)
+
+var argPodsURL = flag.String("kubernetes_pods_url", "", "URL listing the pods of the node, e.g. https://localhost:10250/pods on the kubelet or https://<apiserver>/api/v1/pods?fieldSelector=spec.nodeName=<node> on the API server. Empty disables the Kubernetes integration")
+var argTokenFile = flag.String("kubernetes_token_file", "/var/run/secrets/kubernetes.io/serviceaccount/token", "file of the bearer token authenticating to --kubernetes_pods_url, if it exists")
+var argCAFile = flag.String("kubernetes_ca_file", "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt", "file of the CA certificates verifying --kubernetes_pods_url, if it exists")
+var argInsecureSkipVerify = flag.Bool("kubernetes_insecure_skip_verify", false, "don't verify the certificate of --kubernetes_pods_url, e.g. for kubelets with self-signed certificates")
+var argRefreshInterval = flag.Duration("kubernetes_pods_refresh_interval", 30*time.Second, "interval between listings of the pods of the node")
+
+// Labels of the pod metadata attached to containers, as set by the kubelet on
+// Docker and CRI containers.
+const (
+	PodNameLabel      = "io.kubernetes.pod.name"
+	PodNamespaceLabel = "io.kubernetes.pod.namespace"
+	PodUidLabel       = "io.kubernetes.pod.uid"
+)
+
+// Pods are listed again on demand at most this often.
+const minRefreshInterval = 5 * time.Second
+
+// The cgroups of pods are named after their UID, with underscores instead of
+// dashes with the systemd cgroup driver: "/kubepods/burstable/pod<uid>" or
+// "/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod<uid>.slice".
+var podCgroupRegexp = regexp.MustCompile(`pod([0-9a-f]{8}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{12})`)
+
+// Pod is the metadata of a pod read from the pod list.
+type Pod struct {
+	Metadata struct {
+		Name      string            `json:"name"`
+		Namespace string            `json:"namespace"`
+		Uid       string            `json:"uid"`
+		Labels    map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Status struct {
+		ContainerStatuses []struct {
+			Name string `json:"name"`
+			// ID prefixed by the runtime, e.g. "docker://<id>".
+			ContainerID string `json:"containerID"`
+		} `json:"containerStatuses"`
+	} `json:"status"`
+}
+
+type podList struct {
+	Items []*Pod `json:"items"`
+}
+
+// Pods keeps the list of the pods of the node up to date.
+type Pods struct {
+	url    string
+	client *http.Client
+	token  string
+
+	lock sync.RWMutex
+	// Pods keyed by UID.
+	pods map[string]*Pod
+	// Pods keyed by the IDs of their containers, without runtime prefix.
+	containers  map[string]*Pod
+	lastRefresh time.Time
+}
+
+// New lists the pods of the URL given on the command line. RefreshLoop lists
+// them again every --kubernetes_pods_refresh_interval. It returns nil if no
+// URL was given.
+func New() (*Pods, error) {
+	if *argPodsURL == "" {
+		return nil, nil
+	}
+	if *argRefreshInterval <= 0 {
+		return nil, fmt.Errorf("invalid --kubernetes_pods_refresh_interval %v, expected a positive duration", *argRefreshInterval)
+	}
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: *argInsecureSkipVerify,
+	}
+	if ca, err := ioutil.ReadFile(*argCAFile); err == nil {
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates in %q", *argCAFile)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	self := &Pods{
+		url: *argPodsURL,
+		client: &http.Client{
+			Timeout:   5 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+		pods:       make(map[string]*Pod),
+		containers: make(map[string]*Pod),
+	}
+	if token, err := ioutil.ReadFile(*argTokenFile); err == nil {
+		self.token = strings.TrimSpace(string(token))
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err := self.refresh(); err != nil {
+		// The kubelet may not be serving yet.
+		logging.Warningf("Failed to list the pods of the node: %v", err)
+	}
+	return self, nil
+}
+
+// RefreshLoop lists the pods of the node every
+// --kubernetes_pods_refresh_interval until quit.
+func (self *Pods) RefreshLoop(quit chan error) {
+	ticker := time.NewTicker(*argRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := self.refresh(); err != nil {
+				logging.Warningf("Failed to list the pods of the node: %v", err)
+			}
+		case <-quit:
+			quit <- nil
+			logging.Infof("Exiting pods refresh thread")
+			return
+		}
+	}
+}
+
+func (self *Pods) refresh() error {
+	self.lock.Lock()
+	self.lastRefresh = time.Now()
+	self.lock.Unlock()
+
+	req, err := http.NewRequest("GET", self.url, nil)
+	if err != nil {
+		return err
+	}
+	if self.token != "" {
+		req.Header.Set("Authorization", "Bearer "+self.token)
+	}
+	resp, err := self.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("pods listing failed with status %q", resp.Status)
+	}
+	list := &podList{}
+	if err := json.NewDecoder(resp.Body).Decode(list); err != nil {
+		return fmt.Errorf("failed to parse the pod list: %v", err)
+	}
+	self.update(list.Items)
+	return nil
+}
+
+func (self *Pods) update(list []*Pod) {
+	pods := make(map[string]*Pod, len(list))
+	containers := make(map[string]*Pod)
+	for _, pod := range list {
+		pods[pod.Metadata.Uid] = pod
+		for _, status := range pod.Status.ContainerStatuses {
+			if i := strings.Index(status.ContainerID, "://"); i >= 0 {
+				containers[status.ContainerID[i+3:]] = pod
+			}
+		}
+	}
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.pods = pods
+	self.containers = containers
+}
+
+// List returns the pods of the node.
+func (self *Pods) List() []*Pod {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	pods := make([]*Pod, 0, len(self.pods))
+	for _, pod := range self.pods {
+		pods = append(pods, pod)
+	}
+	return pods
+}
+
+// Returns the UID of the pod of the container from its labels or cgroup.
+func podUid(ref *info.ContainerReference) string {
+	if uid, ok := ref.Labels[PodUidLabel]; ok {
+		return uid
+	}
+	for _, part := range strings.Split(ref.Name, "/") {
+		if matches := podCgroupRegexp.FindStringSubmatch(part); matches != nil {
+			return strings.Replace(matches[1], "_", "-", -1)
+		}
+	}
+	return ""
+}
+
+func (self *Pods) lookup(ref *info.ContainerReference) *Pod {
+	self.lock.RLock()
+	defer self.lock.RUnlock()
+	if pod, ok := self.pods[podUid(ref)]; ok {
+		return pod
+	}
+	if pod, ok := self.containers[ref.Id]; ok {
+		return pod
+	}
+	for _, alias := range ref.Aliases {
+		if pod, ok := self.containers[alias]; ok {
+			return pod
+		}
+	}
+	return nil
+}
+
+// PodOf returns the pod of the container, or nil if it is not in a pod. Pods
+// are listed again if the container looks like it belongs to an unknown pod.
+func (self *Pods) PodOf(ref *info.ContainerReference) *Pod {
+	if pod := self.lookup(ref); pod != nil {
+		return pod
+	}
+	if podUid(ref) == "" {
+		return nil
+	}
+	self.lock.RLock()
+	stale := time.Since(self.lastRefresh) > minRefreshInterval
+	self.lock.RUnlock()
+	if !stale {
+		return nil
+	}
+	if err := self.refresh(); err != nil {
+		logging.Warningf("Failed to list the pods of the node: %v", err)
+		return nil
+	}
+	return self.lookup(ref)
+}
+
+// IsPodCgroup returns whether the container is the cgroup of a pod, which
+// holds the cgroups of its containers.
+func IsPodCgroup(name string) bool {
+	return podCgroupRegexp.MatchString(path.Base(name))
+}
+
+// Labels returns the labels of the container with the name, namespace, UID
+// and labels of its pod added. Labels of the container are kept.
+func Labels(labels map[string]string, pod *Pod) map[string]string {
+	out := make(map[string]string, len(labels)+len(pod.Metadata.Labels)+3)
+	for k, v := range pod.Metadata.Labels {
+		out[k] = v
+	}
+	out[PodNameLabel] = pod.Metadata.Name
+	out[PodNamespaceLabel] = pod.Metadata.Namespace
+	out[PodUidLabel] = pod.Metadata.Uid
+	for k, v := range labels {
+		out[k] = v
+	}
+	return out
+}
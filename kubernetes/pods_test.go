@@ -0,0 +1,106 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kubernetes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	info "github.com/google/cadvisor/info/v1"
+)
+
+const podListJSON = `{
+  "items": [
+    {
+      "metadata": {"name": "web-1", "namespace": "default", "uid": "1b4e28ba-2fa1-11d2-883f-0016d3cca427", "labels": {"app": "web"}},
+      "status": {"containerStatuses": [{"name": "nginx", "containerID": "containerd://abc123"}]}
+    }
+  ]
+}`
+
+func newTestPods(t *testing.T) *Pods {
+	list := &podList{}
+	if err := json.Unmarshal([]byte(podListJSON), list); err != nil {
+		t.Fatal(err)
+	}
+	pods := &Pods{}
+	pods.update(list.Items)
+	return pods
+}
+
+func TestPodOf(t *testing.T) {
+	pods := newTestPods(t)
+	for _, ref := range []info.ContainerReference{
+		{Name: "/docker/def456", Labels: map[string]string{PodUidLabel: "1b4e28ba-2fa1-11d2-883f-0016d3cca427"}},
+		{Name: "/kubepods/burstable/pod1b4e28ba-2fa1-11d2-883f-0016d3cca427"},
+		{Name: "/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod1b4e28ba_2fa1_11d2_883f_0016d3cca427.slice/cri-containerd-abc123.scope"},
+		{Name: "/k8s.io/abc123", Id: "abc123"},
+	} {
+		pod := pods.lookup(&ref)
+		if pod == nil || pod.Metadata.Name != "web-1" {
+			t.Errorf("expected pod web-1 for %+v, got %+v", ref, pod)
+		}
+	}
+	if pod := pods.lookup(&info.ContainerReference{Name: "/system.slice/docker.service"}); pod != nil {
+		t.Errorf("unexpected pod %+v", pod)
+	}
+}
+
+func TestLabels(t *testing.T) {
+	pod := newTestPods(t).List()[0]
+	labels := Labels(map[string]string{"app": "container"}, pod)
+	expected := map[string]string{
+		"app":             "container",
+		PodNameLabel:      "web-1",
+		PodNamespaceLabel: "default",
+		PodUidLabel:       "1b4e28ba-2fa1-11d2-883f-0016d3cca427",
+	}
+	if len(labels) != len(expected) {
+		t.Errorf("expected labels %v, got %v", expected, labels)
+	}
+	for k, v := range expected {
+		if labels[k] != v {
+			t.Errorf("expected label %q to be %q, got %q", k, v, labels[k])
+		}
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(podListJSON))
+	}))
+	defer server.Close()
+
+	pods := &Pods{
+		url:    server.URL,
+		client: http.DefaultClient,
+	}
+	if err := pods.refresh(); err == nil {
+		t.Errorf("expected an error without token")
+	}
+	pods.token = "secret"
+	if err := pods.refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if list := pods.List(); len(list) != 1 || list[0].Metadata.Namespace != "default" {
+		t.Errorf("unexpected pods %+v", list)
+	}
+}
@@ -0,0 +1,463 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package logging is the logging facade of cAdvisor. It logs through glog by
+// default, or as one JSON object per line on stderr with --log_format=json,
+// and supports verbosity levels per module changeable at runtime.
+//
+// A module is the path of a package relative to the cAdvisor repository,
+// e.g. "manager" or "container/docker". The level of a module also applies to
+// the packages under it, so "container" sets the level of all the container
+// handlers. Packages without a module level use the level of -v. The
+// -vmodule patterns of glog apply to the source files they match.
+package logging
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/glog"
+)
+
+// Import path of the packages of cAdvisor, trimmed from the module names.
+const importPrefix = "github.com/google/cadvisor/"
+
+// Level is the verbosity of a log message.
+type Level int32
+
+const (
+	textFormat = "text"
+	jsonFormat = "json"
+)
+
+var (
+	format = textFormat
+
+	// The -v flag of glog, the default level.
+	verbosity flag.Getter
+
+	levelsLock sync.RWMutex
+	// Levels of the modules, and the -vmodule patterns of glog setting the
+	// levels of source files. hasModuleLevel is set when either isn't empty.
+	moduleLevels   = make(map[string]Level)
+	filePatterns   []filePattern
+	hasModuleLevel int32
+
+	modulesLock sync.RWMutex
+	// Modules and base names of the source files, without the .go suffix, of
+	// the program counters of the callers.
+	modules = make(map[uintptr]string)
+	files   = make(map[uintptr]string)
+
+	outputLock sync.Mutex
+	// Where the JSON lines are written.
+	jsonOutput io.Writer = os.Stderr
+)
+
+func init() {
+	verbosity = flag.Lookup("v").Value.(flag.Getter)
+	flag.Var(formatFlag{}, "log_format", "format of the logs: 'text' for glog's, or 'json' for a JSON object per line on stderr with the time, level, module, caller and message")
+	flag.Var(levelsFlag{}, "log_levels", "comma-separated list of module=level verbosities overriding -v for the packages of cAdvisor under the modules, e.g. 'manager=4,storage=2,container/docker=5'")
+	if f := flag.Lookup("vmodule"); f != nil {
+		f.Value = vmoduleFlag{f.Value}
+	}
+}
+
+// A -vmodule pattern of glog: a glob matching the base names of source files
+// without their .go suffix.
+type filePattern struct {
+	pattern string
+	level   Level
+}
+
+// Wraps the -vmodule flag of glog, so that its patterns also set the levels of
+// the logs of the facade.
+type vmoduleFlag struct {
+	flag.Value
+}
+
+func (self vmoduleFlag) Set(value string) error {
+	var patterns []filePattern
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return fmt.Errorf("invalid file level %q, expected pattern=level", pair)
+		}
+		if _, err := filepath.Match(parts[0], ""); err != nil {
+			return fmt.Errorf("invalid file pattern %q: %v", parts[0], err)
+		}
+		level, err := strconv.ParseInt(parts[1], 10, 32)
+		if err != nil || level < 0 {
+			return fmt.Errorf("invalid level %q of file pattern %q", parts[1], parts[0])
+		}
+		patterns = append(patterns, filePattern{pattern: parts[0], level: Level(level)})
+	}
+	if err := self.Value.Set(value); err != nil {
+		return err
+	}
+	levelsLock.Lock()
+	defer levelsLock.Unlock()
+	filePatterns = patterns
+	updateHasModuleLevel()
+	return nil
+}
+
+type formatFlag struct{}
+
+func (formatFlag) String() string {
+	outputLock.Lock()
+	defer outputLock.Unlock()
+	return format
+}
+
+func (formatFlag) Set(value string) error {
+	if value != textFormat && value != jsonFormat {
+		return fmt.Errorf("unknown log format %q, expected %s or %s", value, textFormat, jsonFormat)
+	}
+	outputLock.Lock()
+	defer outputLock.Unlock()
+	format = value
+	return nil
+}
+
+type levelsFlag struct{}
+
+func (levelsFlag) String() string {
+	levels := ModuleLevels()
+	names := make([]string, 0, len(levels))
+	for module := range levels {
+		names = append(names, module)
+	}
+	sort.Strings(names)
+	pairs := make([]string, 0, len(names))
+	for _, module := range names {
+		pairs = append(pairs, fmt.Sprintf("%s=%d", module, levels[module]))
+	}
+	return strings.Join(pairs, ",")
+}
+
+// Replaces the levels of all the modules.
+func (levelsFlag) Set(value string) error {
+	levels := make(map[string]Level)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid module level %q, expected module=level", pair)
+		}
+		level, err := strconv.ParseInt(parts[1], 10, 32)
+		if err != nil || level < 0 {
+			return fmt.Errorf("invalid level %q of module %q", parts[1], parts[0])
+		}
+		levels[strings.Trim(parts[0], "/")] = Level(level)
+	}
+	levelsLock.Lock()
+	defer levelsLock.Unlock()
+	moduleLevels = levels
+	updateHasModuleLevel()
+	return nil
+}
+
+// Must be called with levelsLock held.
+func updateHasModuleLevel() {
+	if len(moduleLevels) > 0 || len(filePatterns) > 0 {
+		atomic.StoreInt32(&hasModuleLevel, 1)
+	} else {
+		atomic.StoreInt32(&hasModuleLevel, 0)
+	}
+}
+
+// DefaultLevel returns the level of -v, used by the modules without a level.
+func DefaultLevel() Level {
+	return Level(verbosity.Get().(glog.Level))
+}
+
+// SetDefaultLevel sets -v.
+func SetDefaultLevel(level Level) error {
+	if level < 0 {
+		return fmt.Errorf("invalid level %d", level)
+	}
+	return flag.Set("v", strconv.Itoa(int(level)))
+}
+
+// ModuleLevels returns the levels set per module.
+func ModuleLevels() map[string]Level {
+	levelsLock.RLock()
+	defer levelsLock.RUnlock()
+	levels := make(map[string]Level, len(moduleLevels))
+	for module, level := range moduleLevels {
+		levels[module] = level
+	}
+	return levels
+}
+
+// SetModuleLevel sets the level of the module and the packages under it.
+func SetModuleLevel(module string, level Level) error {
+	module = strings.Trim(module, "/")
+	if module == "" {
+		return fmt.Errorf("empty module name")
+	}
+	if level < 0 {
+		return fmt.Errorf("invalid level %d", level)
+	}
+	levelsLock.Lock()
+	defer levelsLock.Unlock()
+	moduleLevels[module] = level
+	updateHasModuleLevel()
+	return nil
+}
+
+// ClearModuleLevel makes the module use the default level again.
+func ClearModuleLevel(module string) {
+	levelsLock.Lock()
+	defer levelsLock.Unlock()
+	delete(moduleLevels, strings.Trim(module, "/"))
+	updateHasModuleLevel()
+}
+
+// Returns the level of the module: the one of the longest module it is in,
+// or the default level.
+func levelOf(module string) Level {
+	levelsLock.RLock()
+	defer levelsLock.RUnlock()
+	for prefix := module; prefix != ""; {
+		if level, ok := moduleLevels[prefix]; ok {
+			return level
+		}
+		i := strings.LastIndex(prefix, "/")
+		if i < 0 {
+			break
+		}
+		prefix = prefix[:i]
+	}
+	return DefaultLevel()
+}
+
+// Returns the level of the caller at the program counter. As in glog, the
+// first -vmodule pattern matching its source file raises its level above -v.
+// Otherwise, it is the level of its module.
+func levelOfCaller(pc uintptr) Level {
+	levelsLock.RLock()
+	patterns := filePatterns
+	levelsLock.RUnlock()
+	if len(patterns) > 0 {
+		file := fileOf(pc)
+		for _, pattern := range patterns {
+			if matched, _ := filepath.Match(pattern.pattern, file); matched {
+				if pattern.level < DefaultLevel() {
+					return DefaultLevel()
+				}
+				return pattern.level
+			}
+		}
+	}
+	return levelOf(moduleOf(pc))
+}
+
+// Returns the base name, without the .go suffix, of the source file of the
+// function at the program counter.
+func fileOf(pc uintptr) string {
+	modulesLock.RLock()
+	file, ok := files[pc]
+	modulesLock.RUnlock()
+	if ok {
+		return file
+	}
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		file, _ = fn.FileLine(pc)
+		file = strings.TrimSuffix(path.Base(file), ".go")
+	}
+	modulesLock.Lock()
+	files[pc] = file
+	modulesLock.Unlock()
+	return file
+}
+
+// Returns the module of the function at the program counter.
+func moduleOf(pc uintptr) string {
+	modulesLock.RLock()
+	module, ok := modules[pc]
+	modulesLock.RUnlock()
+	if ok {
+		return module
+	}
+	module = "unknown"
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		module = packageOf(fn.Name())
+	}
+	modulesLock.Lock()
+	modules[pc] = module
+	modulesLock.Unlock()
+	return module
+}
+
+// Returns the module of a function name as reported by the runtime, e.g.
+// "manager" for "github.com/google/cadvisor/manager.(*manager).Start".
+func packageOf(funcName string) string {
+	slash := strings.LastIndex(funcName, "/")
+	if dot := strings.Index(funcName[slash+1:], "."); dot >= 0 {
+		funcName = funcName[:slash+1+dot]
+	}
+	return strings.TrimPrefix(funcName, importPrefix)
+}
+
+// Verbose logs the info messages of a verbosity when it is enabled, like
+// glog.Verbose.
+type Verbose bool
+
+// V returns whether the messages of the level are logged for the module of
+// the caller.
+func V(level Level) Verbose {
+	if atomic.LoadInt32(&hasModuleLevel) == 0 {
+		return Verbose(level <= DefaultLevel())
+	}
+	var pcs [1]uintptr
+	if runtime.Callers(2, pcs[:]) == 0 {
+		return Verbose(level <= DefaultLevel())
+	}
+	return Verbose(level <= levelOfCaller(pcs[0]))
+}
+
+func (v Verbose) Info(args ...interface{}) {
+	if v {
+		output(infoSeverity, 1, fmt.Sprint(args...))
+	}
+}
+
+func (v Verbose) Infof(format string, args ...interface{}) {
+	if v {
+		output(infoSeverity, 1, fmt.Sprintf(format, args...))
+	}
+}
+
+func Info(args ...interface{}) {
+	output(infoSeverity, 1, fmt.Sprint(args...))
+}
+
+func Infof(format string, args ...interface{}) {
+	output(infoSeverity, 1, fmt.Sprintf(format, args...))
+}
+
+func Warning(args ...interface{}) {
+	output(warningSeverity, 1, fmt.Sprint(args...))
+}
+
+func Warningf(format string, args ...interface{}) {
+	output(warningSeverity, 1, fmt.Sprintf(format, args...))
+}
+
+func Error(args ...interface{}) {
+	output(errorSeverity, 1, fmt.Sprint(args...))
+}
+
+func Errorf(format string, args ...interface{}) {
+	output(errorSeverity, 1, fmt.Sprintf(format, args...))
+}
+
+// Fatal logs the message, then exits with status 255.
+func Fatal(args ...interface{}) {
+	output(fatalSeverity, 1, fmt.Sprint(args...))
+}
+
+// Fatalf logs the message, then exits with status 255.
+func Fatalf(format string, args ...interface{}) {
+	output(fatalSeverity, 1, fmt.Sprintf(format, args...))
+}
+
+// Flush writes the buffered logs of glog.
+func Flush() {
+	glog.Flush()
+}
+
+type severity string
+
+const (
+	infoSeverity    severity = "info"
+	warningSeverity severity = "warning"
+	errorSeverity   severity = "error"
+	fatalSeverity   severity = "fatal"
+)
+
+// A JSON log line.
+type entry struct {
+	Time    time.Time `json:"time"`
+	Level   severity  `json:"level"`
+	Module  string    `json:"module"`
+	Caller  string    `json:"caller"`
+	Message string    `json:"msg"`
+}
+
+// Logs the message of the caller depth frames above.
+func output(s severity, depth int, msg string) {
+	outputLock.Lock()
+	useJson := format == jsonFormat
+	outputLock.Unlock()
+	if !useJson {
+		switch s {
+		case infoSeverity:
+			glog.InfoDepth(depth+1, msg)
+		case warningSeverity:
+			glog.WarningDepth(depth+1, msg)
+		case errorSeverity:
+			glog.ErrorDepth(depth+1, msg)
+		case fatalSeverity:
+			glog.FatalDepth(depth+1, msg)
+		}
+		return
+	}
+	e := entry{
+		Time:    time.Now(),
+		Level:   s,
+		Module:  "unknown",
+		Message: msg,
+	}
+	if pc, file, line, ok := runtime.Caller(depth + 1); ok {
+		e.Module = moduleOf(pc)
+		e.Caller = fmt.Sprintf("%s:%d", file[strings.LastIndex(file, "/")+1:], line)
+	}
+	writeJson(&e)
+	if s == fatalSeverity {
+		glog.Flush()
+		os.Exit(255)
+	}
+}
+
+func writeJson(e *entry) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		data = []byte(fmt.Sprintf(`{"level":"error","msg":%q}`, "failed to encode log line: "+err.Error()))
+	}
+	outputLock.Lock()
+	defer outputLock.Unlock()
+	jsonOutput.Write(append(data, '\n'))
+}
@@ -0,0 +1,97 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPackageOf(t *testing.T) {
+	for funcName, expected := range map[string]string{
+		"github.com/google/cadvisor/manager.(*manager).Start":              "manager",
+		"github.com/google/cadvisor/manager.(*manager).Start.func1":        "manager",
+		"github.com/google/cadvisor/container/docker.newDockerContainer":   "container/docker",
+		"github.com/google/cadvisor/storage/influxdb.(*influxdbStorage).X": "storage/influxdb",
+		"main.main": "main",
+	} {
+		assert.Equal(t, expected, packageOf(funcName), funcName)
+	}
+}
+
+func TestModuleLevels(t *testing.T) {
+	defer levelsFlag{}.Set("")
+	assert.Error(t, levelsFlag{}.Set("manager"))
+	assert.Error(t, levelsFlag{}.Set("manager=-1"))
+	assert.NoError(t, levelsFlag{}.Set("container=2, container/docker=5,logging=3"))
+	assert.Equal(t, "container=2,container/docker=5,logging=3", levelsFlag{}.String())
+
+	assert.Equal(t, Level(2), levelOf("container/raw"))
+	assert.Equal(t, Level(5), levelOf("container/docker"))
+	assert.Equal(t, DefaultLevel(), levelOf("manager"))
+	assert.True(t, bool(V(3)))
+	assert.False(t, bool(V(4)))
+
+	assert.NoError(t, SetModuleLevel("/manager/", 4))
+	assert.Equal(t, Level(4), levelOf("manager"))
+	ClearModuleLevel("logging")
+	assert.Equal(t, V(DefaultLevel()+1), Verbose(false))
+	assert.Equal(t, map[string]Level{"container": 2, "container/docker": 5, "manager": 4}, ModuleLevels())
+}
+
+func TestVmodule(t *testing.T) {
+	defer flag.Set("vmodule", "")
+	assert.Error(t, flag.Set("vmodule", "logging_test"))
+	assert.Error(t, flag.Set("vmodule", "logging_test=x"))
+	assert.Error(t, flag.Set("vmodule", "[=1"))
+
+	// The patterns of glog match the source files of the callers.
+	assert.NoError(t, flag.Set("vmodule", "other=5,logging_t*=3"))
+	assert.True(t, bool(V(3)))
+	assert.False(t, bool(V(4)))
+	assert.NoError(t, flag.Set("vmodule", "logging_test=4"))
+	assert.True(t, bool(V(4)))
+
+	// They take precedence over the levels of the modules.
+	defer levelsFlag{}.Set("")
+	assert.NoError(t, levelsFlag{}.Set("logging=1"))
+	assert.True(t, bool(V(4)))
+	assert.NoError(t, flag.Set("vmodule", ""))
+	assert.False(t, bool(V(2)))
+}
+
+func TestJsonOutput(t *testing.T) {
+	var buf bytes.Buffer
+	jsonOutput = &buf
+	assert.NoError(t, formatFlag{}.Set(jsonFormat))
+	defer func() {
+		formatFlag{}.Set(textFormat)
+		jsonOutput = os.Stderr
+	}()
+	assert.Error(t, formatFlag{}.Set("xml"))
+
+	Warningf("disk %q is %d%% full", "sda", 90)
+	var e entry
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &e))
+	assert.Equal(t, warningSeverity, e.Level)
+	assert.Equal(t, "logging", e.Module)
+	assert.Contains(t, e.Caller, "logging_test.go:")
+	assert.Equal(t, `disk "sda" is 90% full`, e.Message)
+}
@@ -0,0 +1,173 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/cadvisor/logging"
+)
+
+var adaptiveHousekeeping = flag.Bool("adaptive_housekeeping", false, "Whether to lengthen the housekeeping intervals of all containers while the cpu usage of cAdvisor or the host load is above its threshold, and to shorten them back when both are low")
+var adaptiveHousekeepingCpuThreshold = flag.Float64("adaptive_housekeeping_cpu_threshold", 0.5, "Cpu usage of cAdvisor, in cores, above which adaptive housekeeping backs off")
+var adaptiveHousekeepingLoadThreshold = flag.Float64("adaptive_housekeeping_load_threshold", 1.0, "One minute load average of the host per core above which adaptive housekeeping backs off")
+var adaptiveHousekeepingInterval = flag.Duration("adaptive_housekeeping_interval", 10*time.Second, "Interval between the checks of the cpu usage of cAdvisor and of the host load by adaptive housekeeping")
+
+// Largest factor by which adaptive housekeeping lengthens the intervals.
+const maxHousekeepingBackoff = 32
+
+// Lengthens the housekeeping intervals of all containers while cAdvisor or
+// the host is busy. A nil backoff never lengthens them.
+type housekeepingBackoff struct {
+	cpuThreshold  float64
+	loadThreshold float64
+	numCores      int
+
+	lock sync.RWMutex
+	// Factor by which the housekeeping intervals are lengthened.
+	factor int
+	// Further factor while cAdvisor nears --max_self_memory.
+	memoryFactor int
+	// Cpu time of cAdvisor at the last check.
+	lastCpuTime   time.Duration
+	lastCheckTime time.Time
+}
+
+func newHousekeepingBackoff(cpuThreshold, loadThreshold float64, numCores int) *housekeepingBackoff {
+	if numCores < 1 {
+		numCores = 1
+	}
+	return &housekeepingBackoff{
+		cpuThreshold:  cpuThreshold,
+		loadThreshold: loadThreshold,
+		numCores:      numCores,
+		factor:        1,
+		memoryFactor:  1,
+	}
+}
+
+// Returns the housekeeping interval lengthened by the backoff, at most up to
+// maxInterval. Intervals already longer than maxInterval are not shortened.
+func (self *housekeepingBackoff) apply(interval, maxInterval time.Duration) time.Duration {
+	if self == nil {
+		return interval
+	}
+	self.lock.RLock()
+	factor := self.factor * self.memoryFactor
+	self.lock.RUnlock()
+	if factor > maxHousekeepingBackoff {
+		factor = maxHousekeepingBackoff
+	}
+	if factor == 1 || interval >= maxInterval {
+		return interval
+	}
+	return DurationMin(interval*time.Duration(factor), maxInterval)
+}
+
+// Doubles the factor when the cpu usage of cAdvisor (in cores) or the host
+// load per core is above its threshold, and halves it back when both are
+// below half their threshold.
+func (self *housekeepingBackoff) adjust(cpuUsage, loadPerCore float64) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	factor := self.factor
+	if cpuUsage > self.cpuThreshold || loadPerCore > self.loadThreshold {
+		if factor < maxHousekeepingBackoff {
+			factor *= 2
+		}
+	} else if cpuUsage < self.cpuThreshold/2 && loadPerCore < self.loadThreshold/2 && factor > 1 {
+		factor /= 2
+	}
+	if factor != self.factor {
+		logging.V(2).Infof("Adaptive housekeeping: cAdvisor uses %.3f cores, host load per core %.2f, housekeeping intervals lengthened %d times", cpuUsage, loadPerCore, factor)
+		self.factor = factor
+	}
+}
+
+// Doubles the memory factor, up to the largest backoff, and returns it.
+func (self *housekeepingBackoff) backOffMemory() int {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if self.memoryFactor < maxHousekeepingBackoff {
+		self.memoryFactor *= 2
+	}
+	return self.memoryFactor
+}
+
+// Resets the memory factor once the memory of cAdvisor went back down.
+func (self *housekeepingBackoff) resetMemory() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.memoryFactor = 1
+}
+
+// Measures the cpu usage of cAdvisor since the last check and the host load,
+// and adjusts the factor.
+func (self *housekeepingBackoff) check() error {
+	cpuTime, err := selfCpuTime()
+	if err != nil {
+		return fmt.Errorf("failed to get the cpu usage of cAdvisor: %v", err)
+	}
+	now := time.Now()
+	load, err := readLoadAverage()
+	if err != nil {
+		return err
+	}
+
+	lastCpuTime, lastCheckTime := self.lastCpuTime, self.lastCheckTime
+	self.lastCpuTime, self.lastCheckTime = cpuTime, now
+	if lastCheckTime.IsZero() {
+		return nil
+	}
+	cpuUsage := float64(cpuTime-lastCpuTime) / float64(now.Sub(lastCheckTime))
+	self.adjust(cpuUsage, load/float64(self.numCores))
+	return nil
+}
+
+func (self *housekeepingBackoff) loop(quit chan error) {
+	ticker := time.NewTicker(*adaptiveHousekeepingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := self.check(); err != nil {
+				logging.Warningf("Failed to adjust adaptive housekeeping: %v", err)
+			}
+		case <-quit:
+			quit <- nil
+			logging.Infof("Exiting adaptive housekeeping thread")
+			return
+		}
+	}
+}
+
+// Returns the one minute load average of the host.
+func readLoadAverage() (float64, error) {
+	out, err := ioutil.ReadFile("/proc/loadavg")
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("unexpected empty /proc/loadavg")
+	}
+	return strconv.ParseFloat(fields[0], 64)
+}
@@ -28,19 +28,22 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/cadvisor/cache/memory"
 	"github.com/google/cadvisor/collector"
 	"github.com/google/cadvisor/container"
+	"github.com/google/cadvisor/events"
 	info "github.com/google/cadvisor/info/v1"
 	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/logging"
 	"github.com/google/cadvisor/summary"
 	"github.com/google/cadvisor/utils"
 	"github.com/google/cadvisor/utils/cpuload"
 
 	units "github.com/docker/go-units"
-	"github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // Housekeeping interval.
@@ -49,8 +52,36 @@ var HousekeepingInterval = flag.Duration("housekeeping_interval", 1*time.Second,
 var enableLoadReader = flag.Bool("enable_load_reader", false, "Whether to enable cpu load reader")
 var LoadreaderInterval = flag.Duration("load_reader_interval", 1*time.Second, "Interval between load reader probes")
 var MaxLoadReaderInterval = flag.Duration("max_load_reader_interval", 60*time.Second, "Interval between load reader probes")
+var loadAverageWindow = flag.Duration("load_average_window", 10*time.Second, "Time constant of the exponential decay smoothing the load average of containers. The load average stabilizes on a new constant number of runnable threads within about this window. Must be positive")
+var monotonicTimestamps = flag.Bool("monotonic_timestamps", false, "Whether to add to the stats a monotonic_timestamp, the wall time at which cAdvisor started plus the time elapsed since on the monotonic clock, alongside the wall clock timestamp. Intervals between stats are then computed from it, unaffected by steps of the wall clock")
 
 var PanicTimeout = flag.Duration("panic_timeout", 1*time.Minute, "Delay after which cAdvisor should panic if housekeeping or LA probe hasn't completed")
+var panicTimeoutPolicy = flag.String("panic_timeout_policy", "panic", "What to do when the housekeeping or LA probe of a container hasn't completed after --panic_timeout: panic, or degrade to skip the container with a housekeepingTimeout event until it completes")
+
+// Values of the reloadable flags read by housekeeping. A reload sets the
+// flags concurrently with housekeeping, so housekeeping reads the values
+// from the settings, which are replaced as a whole.
+type housekeepingSettings struct {
+	interval           time.Duration
+	panicTimeout       time.Duration
+	panicTimeoutPolicy string
+}
+
+// The current *housekeepingSettings, stored by New and on reload.
+var currentHousekeepingSettings atomic.Value
+
+// Number of housekeeping timeouts per container, with --panic_timeout_policy=degrade.
+var HousekeepingTimeouts = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "cadvisor_housekeeping_timeouts_total",
+	Help: "Number of housekeepings or LA probes of a container that did not complete within the panic timeout.",
+}, []string{"container"})
+
+var specUpdateInterval = flag.Duration("spec_update_interval", time.Minute, "Interval at which housekeeping refreshes the spec of containers to detect changes of their cpu affinity, limits, labels or image at runtime. 0 only refreshes specs when they are requested")
+var specHistorySize = flag.Int("spec_history_size", 10, "Number of versions of the spec of each container kept in its history")
+
+var anomalyDetection = flag.Bool("anomaly_detection", false, "Whether to emit anomaly events when the cpu or memory usage of a container deviates from its recent baseline")
+var anomalyWindow = flag.Int("anomaly_window", 300, "Number of housekeeping samples in the baselines of anomaly detection. Must be positive")
+var anomalyThreshold = flag.Float64("anomaly_threshold", 4, "Number of standard deviations from the baseline beyond which usage is anomalous")
 
 var cgroupPathRegExp = regexp.MustCompile(`devices[^:]*:(.*?)[,;$]`)
 
@@ -61,17 +92,30 @@ type containerInfo struct {
 }
 
 type containerData struct {
-	handler                  container.ContainerHandler
-	info                     containerInfo
-	memoryCache              *memory.InMemoryCache
-	lock                     sync.Mutex
-	loadReader               cpuload.CpuLoadReader
-	summaryReader            *summary.StatsSummary
-	housekeepingInterval     time.Duration
-	maxHousekeepingInterval  time.Duration
+	// Revision of the specs of the manager the container was added or
+	// exited at, or its spec changed at. First for the alignment of atomic
+	// operations.
+	revision uint64
+	// Counter of the revisions of the specs, if they are counted.
+	specRevision *uint64
+
+	handler                 container.ContainerHandler
+	info                    containerInfo
+	memoryCache             *memory.InMemoryCache
+	lock                    sync.Mutex
+	loadReader              cpuload.CpuLoadReader
+	summaryReader           *summary.StatsSummary
+	housekeepingInterval    time.Duration
+	maxHousekeepingInterval time.Duration
+	// Baseline housekeeping interval, to which dynamic housekeeping lowers
+	// the interval back.
+	baseHousekeepingInterval time.Duration
 	allowDynamicHousekeeping bool
 	lastUpdatedTime          time.Time
 	lastErrorTime            time.Time
+	// Time at which stats were last stored, or at which the container was
+	// created. Protected by lock.
+	lastStatsTime time.Time
 
 	// smoothed load average seen so far.
 	loadAvg              float64
@@ -90,9 +134,111 @@ type containerData struct {
 
 	// Tells the container to stop.
 	stop chan bool
+	// Closed when the housekeeping loop returned, nil unless it was started
+	// in its own goroutine. Guarded by the containersLock of the manager.
+	housekeepingDone chan struct{}
 
 	// Runs custom metric collectors.
 	collectorManager collector.CollectorManager
+
+	// Receives anomaly events, if anomaly detection is enabled.
+	eventHandler events.EventManager
+
+	// Lengthens the housekeeping interval while the host is busy, if adaptive
+	// housekeeping is enabled.
+	backoff *housekeepingBackoff
+
+	// Runs the housekeeping and the load reader, if set. Otherwise they run
+	// in their own goroutines.
+	scheduler        *housekeepingScheduler
+	housekeepingTask *scheduledTask
+	loadReaderTask   *scheduledTask
+
+	// Set while a housekeeping or LA probe that timed out hasn't completed,
+	// with --panic_timeout_policy=degrade. The container is skipped meanwhile.
+	stuck int32
+	// Called when a housekeeping or LA probe of the container times out, with
+	// --panic_timeout_policy=degrade.
+	onTimeout func(containerName string, timeout time.Duration)
+
+	// Time of the last update of the spec. Guarded by lock.
+	lastSpecUpdate time.Time
+	// Aggregates the scheduler statistics of the tasks, nil unless they are
+	// collected. Only used by housekeeping.
+	schedstatReader *schedstatReader
+	// Versions of the spec, oldest first, up to --spec_history_size. Guarded
+	// by lock.
+	specHistory []specVersion
+	// Called when the cpuset, limits, labels or image of the container change
+	// after its first spec, with the kinds of changes.
+	onSpecChange func(containerName string, old, new *info.ContainerSpec, changes []string)
+	// Errors of the handler getting the stats. Guarded by lock.
+	health v2.ContainerHealth
+	// Time of the first housekeeping, and semaphore it holds, for the
+	// containers found on startup with --startup_ramp or
+	// --startup_concurrency. Only used by housekeeping.
+	firstHousekeeping time.Time
+	startupSlots      chan struct{}
+	// Time the watch event of the container was received, until its first
+	// stats are stored. Only used by housekeeping.
+	appeared time.Time
+}
+
+// Records that the container changed at a new revision of the specs.
+func (c *containerData) bumpRevision() {
+	if c.specRevision != nil {
+		atomic.StoreUint64(&c.revision, atomic.AddUint64(c.specRevision, 1))
+	}
+}
+
+// Returns the revision of the specs the container last changed at.
+func (c *containerData) Revision() uint64 {
+	return atomic.LoadUint64(&c.revision)
+}
+
+// Returns an error if a flag read by the containers is invalid.
+func validateContainerFlags() error {
+	if *anomalyWindow <= 0 {
+		return fmt.Errorf("invalid --anomaly_window %d, expected a positive number of samples", *anomalyWindow)
+	}
+	if *loadAverageWindow <= 0 {
+		return fmt.Errorf("invalid --load_average_window %v, expected a positive duration", *loadAverageWindow)
+	}
+	return nil
+}
+
+// Parses the housekeeping settings from the values of their flags.
+func parseHousekeepingSettings(value func(name string) string) (*housekeepingSettings, error) {
+	interval, err := parseHousekeepingInterval(value("housekeeping_interval"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid --housekeeping_interval: %v", err)
+	}
+	panicTimeout, err := time.ParseDuration(value("panic_timeout"))
+	if err != nil || panicTimeout <= 0 {
+		return nil, fmt.Errorf("invalid --panic_timeout %q, expected a positive duration", value("panic_timeout"))
+	}
+	policy := value("panic_timeout_policy")
+	if policy != "panic" && policy != "degrade" {
+		return nil, fmt.Errorf("invalid --panic_timeout_policy %q, expected panic or degrade", policy)
+	}
+	return &housekeepingSettings{
+		interval:           interval,
+		panicTimeout:       panicTimeout,
+		panicTimeoutPolicy: policy,
+	}, nil
+}
+
+// Returns the current housekeeping settings, or the ones of the flags before
+// New stored any.
+func getHousekeepingSettings() *housekeepingSettings {
+	if settings, ok := currentHousekeepingSettings.Load().(*housekeepingSettings); ok {
+		return settings
+	}
+	return &housekeepingSettings{
+		interval:           *HousekeepingInterval,
+		panicTimeout:       *PanicTimeout,
+		panicTimeoutPolicy: *panicTimeoutPolicy,
+	}
 }
 
 func DurationMin(d1 time.Duration, d2 time.Duration) time.Duration {
@@ -104,6 +250,11 @@ func DurationMin(d1 time.Duration, d2 time.Duration) time.Duration {
 }
 
 func (c *containerData) Start() error {
+	if c.scheduler != nil {
+		c.startScheduled()
+		return nil
+	}
+	c.housekeepingDone = make(chan struct{})
 	go c.doHousekeepingLoop()
 	go c.doLoadReaderLoop()
 	return nil
@@ -114,11 +265,90 @@ func (c *containerData) Stop() error {
 	if err != nil {
 		return err
 	}
-	c.stop <- true
-	c.loadStop <- true
+	c.stopHousekeeping()
 	return nil
 }
 
+// Stops the housekeeping and the load reader.
+func (c *containerData) stopHousekeeping() {
+	if c.scheduler == nil {
+		c.stop <- true
+		c.loadStop <- true
+		return
+	}
+	c.scheduler.cancel(c.housekeepingTask)
+	if c.loadReaderTask != nil {
+		c.scheduler.cancel(c.loadReaderTask)
+		c.loadReader.Stop()
+	}
+	c.handler.Cleanup()
+}
+
+// Exit stops the housekeeping of the container after collecting its final
+// stats, but keeps its info and stats until Remove is called. It returns the
+// last stats of the container, if any.
+func (c *containerData) Exit(exitTime time.Time) (*info.ContainerStats, error) {
+	c.stopHousekeeping()
+	if c.housekeepingDone != nil {
+		// Don't collect the final stats while the housekeeping loop still
+		// collects its own.
+		<-c.housekeepingDone
+	}
+	// The cgroup of the container may already be gone, in which case the last
+	// stats of the housekeeping are the final ones. Neither are collected
+	// while a timed out update is still running.
+	if atomic.LoadInt32(&c.stuck) == 0 {
+		if err := c.updateStats(); err != nil {
+			logging.V(2).Infof("Failed to update the final stats of %q: %v", c.info.Name, err)
+		}
+	}
+	c.lock.Lock()
+	c.info.Spec.ExitTime = exitTime
+	c.lock.Unlock()
+
+	var empty time.Time
+	stats, err := c.memoryCache.RecentStats(c.info.Name, empty, empty, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(stats) == 0 {
+		return nil, nil
+	}
+	return stats[0], nil
+}
+
+// Remove forgets the stats of an exited container.
+func (c *containerData) Remove() error {
+	return c.memoryCache.RemoveContainer(c.info.Name)
+}
+
+// Returns whether the housekeeping of the container stored stats within the
+// given number of its max housekeeping intervals. Containers skipped after a
+// housekeeping timeout are not checked.
+func (c *containerData) housekeepingProgressing(maxMissed int) bool {
+	if atomic.LoadInt32(&c.stuck) != 0 {
+		return true
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return time.Since(c.lastStatsTime) <= time.Duration(maxMissed)*c.longestHousekeepingInterval()
+}
+
+// Returns the longest interval between two housekeepings of the container.
+func (c *containerData) longestHousekeepingInterval() time.Duration {
+	if c.maxHousekeepingInterval < c.baseHousekeepingInterval {
+		return c.baseHousekeepingInterval
+	}
+	return c.maxHousekeepingInterval
+}
+
+// Returns the time at which the container exited, or zero if it is running.
+func (c *containerData) exitTime() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.info.Spec.ExitTime
+}
+
 func (c *containerData) allowErrorLogging() bool {
 	if time.Since(c.lastErrorTime) > time.Minute {
 		c.lastErrorTime = time.Now()
@@ -128,8 +358,8 @@ func (c *containerData) allowErrorLogging() bool {
 }
 
 func (c *containerData) GetInfo() (*containerInfo, error) {
-	// Get spec and subcontainers.
-	if time.Since(c.lastUpdatedTime) > 5*time.Second {
+	// Get spec and subcontainers, unless the container exited.
+	if c.exitTime().IsZero() && time.Since(c.lastUpdatedTime) > 5*time.Second {
 		err := c.updateSpec()
 		if err != nil {
 			return nil, err
@@ -153,13 +383,20 @@ func (c *containerData) DerivedStats() (v2.DerivedStats, error) {
 	return c.summaryReader.DerivedStats()
 }
 
+func (c *containerData) Prediction() (v2.Prediction, error) {
+	if c.summaryReader == nil {
+		return v2.Prediction{}, fmt.Errorf("derived stats not enabled for container %q", c.info.Name)
+	}
+	return c.summaryReader.Predict()
+}
+
 func (c *containerData) getCgroupPath(cgroups string) (string, error) {
 	if cgroups == "-" {
 		return "/", nil
 	}
 	matches := cgroupPathRegExp.FindSubmatch([]byte(cgroups))
 	if len(matches) != 2 {
-		glog.V(3).Infof("failed to get devices cgroup path from %q", cgroups)
+		logging.V(3).Infof("failed to get devices cgroup path from %q", cgroups)
 		// return root in case of failures - devices hierarchy might not be enabled.
 		return "/", nil
 	}
@@ -180,7 +417,7 @@ func (c *containerData) ReadFile(filepath string, inHostNamespace bool) ([]byte,
 	}
 	for _, pid := range pids {
 		filePath := path.Join(rootfs, "/proc", pid, "/root", filepath)
-		glog.V(3).Infof("Trying path %q", filePath)
+		logging.V(3).Infof("Trying path %q", filePath)
 		data, err := ioutil.ReadFile(filePath)
 		if err == nil {
 			return data, err
@@ -284,6 +521,10 @@ func (c *containerData) GetProcessList(cadvisorContainer string, inHostNamespace
 		}
 		// convert to bytes
 		vs *= 1024
+		cpuTime, err := parseCpuTime(fields[9])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cpu time %q: %v", fields[9], err)
+		}
 		cgroup, err := c.getCgroupPath(fields[11])
 		if err != nil {
 			return nil, fmt.Errorf("could not parse cgroup path from %q: %v", fields[11], err)
@@ -310,6 +551,7 @@ func (c *containerData) GetProcessList(cadvisorContainer string, inHostNamespace
 				VirtualSize:   vs,
 				Status:        fields[8],
 				RunningTime:   fields[9],
+				CpuTime:       cpuTime,
 				Cmd:           fields[10],
 				CgroupPath:    cgroupPath,
 			})
@@ -318,6 +560,33 @@ func (c *containerData) GetProcessList(cadvisorContainer string, inHostNamespace
 	return processes, nil
 }
 
+// Parses the cumulative cpu time reported by ps as [DD-]HH:MM:SS, or MM:SS
+// by some versions, into seconds.
+func parseCpuTime(value string) (uint64, error) {
+	var days uint64
+	if i := strings.Index(value, "-"); i >= 0 {
+		d, err := strconv.ParseUint(value[:i], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		days = d
+		value = value[i+1:]
+	}
+	var seconds uint64
+	parts := strings.Split(value, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("expected [DD-]HH:MM:SS")
+	}
+	for _, part := range parts {
+		n, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		seconds = seconds*60 + n
+	}
+	return days*24*60*60 + seconds, nil
+}
+
 func newContainerData(containerName string, memoryCache *memory.InMemoryCache, handler container.ContainerHandler, logUsage bool, collectorManager collector.CollectorManager, maxHousekeepingInterval time.Duration, allowDynamicHousekeeping bool) (*containerData, error) {
 	if memoryCache == nil {
 		return nil, fmt.Errorf("nil memory storage")
@@ -330,11 +599,13 @@ func newContainerData(containerName string, memoryCache *memory.InMemoryCache, h
 		return nil, err
 	}
 
+	interval := getHousekeepingSettings().interval
 	cont := &containerData{
 		handler:                  handler,
 		memoryCache:              memoryCache,
-		housekeepingInterval:     *HousekeepingInterval,
+		housekeepingInterval:     interval,
 		maxHousekeepingInterval:  maxHousekeepingInterval,
+		baseHousekeepingInterval: interval,
 		allowDynamicHousekeeping: allowDynamicHousekeeping,
 		logUsage:                 logUsage,
 		loadAvg:                  -1.0, // negative value indicates uninitialized
@@ -342,6 +613,7 @@ func newContainerData(containerName string, memoryCache *memory.InMemoryCache, h
 		loadStop:                 make(chan bool, 1),
 		stop:                     make(chan bool, 1),
 		collectorManager:         collectorManager,
+		lastStatsTime:            time.Now(),
 	}
 	cont.info.ContainerReference = ref
 
@@ -350,7 +622,7 @@ func newContainerData(containerName string, memoryCache *memory.InMemoryCache, h
 		loadReader, err := cpuload.New()
 		if err != nil {
 			// TODO(rjnagal): Promote to warning once we support cpu load inside namespaces.
-			glog.Infof("Could not initialize cpu load reader for %q: %s", ref.Name, err)
+			logging.Infof("Could not initialize cpu load reader for %q: %s", ref.Name, err)
 		} else {
 			cont.loadReader = loadReader
 		}
@@ -363,12 +635,51 @@ func newContainerData(containerName string, memoryCache *memory.InMemoryCache, h
 	cont.summaryReader, err = summary.New(cont.info.Spec)
 	if err != nil {
 		cont.summaryReader = nil
-		glog.Warningf("Failed to create summary reader for %q: %v", ref.Name, err)
+		logging.Warningf("Failed to create summary reader for %q: %v", ref.Name, err)
 	}
 
 	return cont, nil
 }
 
+// Emits events to the handler when the usage of the container deviates from
+// its baseline. Requires derived stats.
+func (c *containerData) enableAnomalyDetection(eventHandler events.EventManager) {
+	if c.summaryReader == nil {
+		return
+	}
+	c.summaryReader.EnableAnomalyDetection(*anomalyWindow, *anomalyThreshold)
+	c.eventHandler = eventHandler
+}
+
+func (c *containerData) addAnomalyEvents(timestamp time.Time) {
+	for _, anomaly := range c.summaryReader.DetectAnomalies() {
+		anomaly := anomaly
+		logging.V(3).Infof("Anomalous %s usage of %q: %v (mean %v, std dev %v)", anomaly.Metric, c.info.Name, anomaly.Value, anomaly.Mean, anomaly.StdDev)
+		err := c.eventHandler.AddEvent(&info.Event{
+			ContainerName: c.info.Name,
+			Timestamp:     timestamp,
+			EventType:     info.EventAnomaly,
+			EventData: info.EventData{
+				Anomaly: &anomaly,
+			},
+		})
+		if err != nil {
+			logging.Errorf("failed to add anomaly event for %q: %v", c.info.Name, err)
+		}
+	}
+}
+
+// Sets the baseline housekeeping interval of the container. Must be called
+// before Start. Dynamic housekeeping backs off up to the largest of the
+// interval and the max housekeeping interval.
+func (c *containerData) setHousekeepingInterval(interval time.Duration) {
+	c.housekeepingInterval = interval
+	c.baseHousekeepingInterval = interval
+	if c.maxHousekeepingInterval < interval {
+		c.maxHousekeepingInterval = interval
+	}
+}
+
 // Determine when the next housekeeping should occur.
 func (c *containerData) adjustHousekeepingInterval() error {
 	if !c.allowDynamicHousekeeping {
@@ -390,14 +701,62 @@ func (c *containerData) adjustHousekeepingInterval() error {
 		c.housekeepingInterval = DurationMin(c.housekeepingInterval*2, c.maxHousekeepingInterval)
 	} else {
 		// Lower interval back to the baseline.
-		c.housekeepingInterval = *HousekeepingInterval
+		c.housekeepingInterval = c.baseHousekeepingInterval
 	}
 
 	return nil
 }
 
+// Starts the housekeeping and the load reader on the scheduler.
+func (c *containerData) startScheduled() {
+	// Start any background goroutines - must be cleaned up in c.handler.Cleanup().
+	c.handler.Start()
+
+	logging.V(3).Infof("Start housekeeping for container %q\n", c.info.Name)
+	lastHousekeeping := c.firstHousekeepingTime()
+	c.housekeepingTask = c.scheduler.schedule(func() time.Time {
+		lastHousekeeping = c.nextHousekeeping(lastHousekeeping, c.housekeep())
+		return lastHousekeeping
+	}, lastHousekeeping)
+
+	if c.loadReader == nil {
+		return
+	}
+	// Initialize cpuload reader - must be cleaned up in c.loadReader.Stop()
+	err := c.loadReader.Start()
+	if err != nil {
+		logging.Warningf("Could not start cpu load stat collector for %q: %s", c.info.Name, err)
+	}
+	lastIteration := time.Now()
+	c.loadReaderTask = c.scheduler.schedule(func() time.Time {
+		c.doWithTimeout((*containerData).doLoadReaderIteration, getHousekeepingSettings().panicTimeout)
+		lastIteration = c.nextRun(lastIteration, c.loadreaderInterval)
+		return lastIteration
+	}, lastIteration)
+}
+
+// Returns the time of the next run of a periodic task, after a jittered
+// interval from its last run or right now if that time already passed.
+func (c *containerData) nextRun(last time.Time, interval time.Duration) time.Time {
+	next := last.Add(utils.Jitter(interval, 1.0))
+	if now := time.Now(); now.After(next) {
+		return now
+	}
+	return next
+}
+
+// Returns the time of the next housekeeping, aligned on the wall clock with
+// --align_housekeeping.
+func (c *containerData) nextHousekeeping(last time.Time, interval time.Duration) time.Time {
+	if *alignHousekeeping && interval > 0 {
+		return alignedRun(last, interval, time.Now())
+	}
+	return c.nextRun(last, interval)
+}
+
 // TODO(vmarmol): Implement stats collecting as a custom collector.
 func (c *containerData) doHousekeepingLoop() {
+	defer close(c.housekeepingDone)
 	// Start any background goroutines - must be cleaned up in c.handler.Cleanup().
 	c.handler.Start()
 	defer c.handler.Cleanup()
@@ -406,89 +765,101 @@ func (c *containerData) doHousekeepingLoop() {
 	if c.loadReader != nil {
 		err := c.loadReader.Start()
 		if err != nil {
-			glog.Warningf("Could not start cpu load stat collector for %q: %s", c.info.Name, err)
+			logging.Warningf("Could not start cpu load stat collector for %q: %s", c.info.Name, err)
 		}
 		defer c.loadReader.Stop()
 	}
 
-	// Long housekeeping is either 100ms or half of the housekeeping interval.
-	longHousekeeping := 100 * time.Millisecond
-	if *HousekeepingInterval/2 < longHousekeeping {
-		longHousekeeping = *HousekeepingInterval / 2
+	logging.V(3).Infof("Start housekeeping for container %q\n", c.info.Name)
+	if !c.waitFirstHousekeeping() {
+		return
 	}
-
-	glog.V(3).Infof("Start housekeeping for container %q\n", c.info.Name)
 	lastHousekeeping := time.Now()
 	for {
+		next := c.nextHousekeeping(lastHousekeeping, c.housekeep())
+
+		// Schedule the next housekeeping. Sleep until that time, unless
+		// signaled to stop.
+		timer := time.NewTimer(next.Sub(time.Now()))
 		select {
 		case <-c.stop:
-			// Stop housekeeping when signaled.
+			timer.Stop()
 			return
-		default:
-			// Perform housekeeping.
-			start := time.Now()
-			c.doWithTimeout((*containerData).updateStats, *PanicTimeout)
-
-			// Log if housekeeping took too long.
-			duration := time.Since(start)
-			if duration >= longHousekeeping {
-				glog.V(3).Infof("[%s] Housekeeping took %s", c.info.Name, duration)
-			}
+		case <-timer.C:
 		}
+		lastHousekeeping = next
+	}
+}
 
-		// Log usage if asked to do so.
-		if c.logUsage {
-			const numSamples = 60
-			var empty time.Time
-			stats, err := c.memoryCache.RecentStats(c.info.Name, empty, empty, numSamples)
-			if err != nil {
-				if c.allowErrorLogging() {
-					glog.Infof("[%s] Failed to get recent stats for logging usage: %v", c.info.Name, err)
-				}
-			} else if len(stats) < numSamples {
-				// Ignore, not enough stats yet.
-			} else {
-				usageCpuNs := uint64(0)
-				for i := range stats {
-					if i > 0 {
-						usageCpuNs += (stats[i].Cpu.Usage.Total - stats[i-1].Cpu.Usage.Total)
-					}
-				}
-				usageMemory := stats[numSamples-1].Memory.Usage
+// Performs the housekeeping of the container and returns the interval until
+// the next one.
+func (c *containerData) housekeep() time.Duration {
+	// Long housekeeping is either 100ms or half of the housekeeping interval.
+	longHousekeeping := 100 * time.Millisecond
+	if c.baseHousekeepingInterval/2 < longHousekeeping {
+		longHousekeeping = c.baseHousekeepingInterval / 2
+	}
 
-				instantUsageInCores := float64(stats[numSamples-1].Cpu.Usage.Total-stats[numSamples-2].Cpu.Usage.Total) / float64(stats[numSamples-1].Timestamp.Sub(stats[numSamples-2].Timestamp).Nanoseconds())
-				usageInCores := float64(usageCpuNs) / float64(stats[numSamples-1].Timestamp.Sub(stats[0].Timestamp).Nanoseconds())
-				usageInHuman := units.HumanSize(float64(usageMemory))
-				glog.Infof("[%s] %.3f cores (average: %.3f cores), %s of memory", c.info.Name, instantUsageInCores, usageInCores, usageInHuman)
-			}
-		}
+	release := c.takeStartupSlot()
+	start := time.Now()
+	c.doWithTimeout((*containerData).updateStats, getHousekeepingSettings().panicTimeout)
+	c.refreshSpec()
+	release()
 
-		err := c.adjustHousekeepingInterval()
-		if err != nil && c.allowErrorLogging() {
-			glog.Warningf("Failed to get RecentStats(%q) while determining the next housekeeping: %v", c.info.Name, err)
-		}
-		next := lastHousekeeping.Add(utils.Jitter(c.housekeepingInterval, 1.0))
+	// Log if housekeeping took too long.
+	duration := time.Since(start)
+	if duration >= longHousekeeping {
+		logging.V(3).Infof("[%s] Housekeeping took %s", c.info.Name, duration)
+	}
 
-		// Schedule the next housekeeping. Sleep until that time.
-		if time.Now().Before(next) {
-			time.Sleep(next.Sub(time.Now()))
+	// Log usage if asked to do so.
+	if c.logUsage {
+		const numSamples = 60
+		var empty time.Time
+		stats, err := c.memoryCache.RecentStats(c.info.Name, empty, empty, numSamples)
+		if err != nil {
+			if c.allowErrorLogging() {
+				logging.Infof("[%s] Failed to get recent stats for logging usage: %v", c.info.Name, err)
+			}
+		} else if len(stats) < numSamples {
+			// Ignore, not enough stats yet.
 		} else {
-			next = time.Now()
+			usageCpuNs := uint64(0)
+			for i := range stats {
+				if i > 0 {
+					usageCpuNs += (stats[i].Cpu.Usage.Total - stats[i-1].Cpu.Usage.Total)
+				}
+			}
+			usageMemory := stats[numSamples-1].Memory.Usage
+
+			instantUsageInCores := float64(stats[numSamples-1].Cpu.Usage.Total-stats[numSamples-2].Cpu.Usage.Total) / float64(stats[numSamples-1].Since(stats[numSamples-2]).Nanoseconds())
+			usageInCores := float64(usageCpuNs) / float64(stats[numSamples-1].Since(stats[0]).Nanoseconds())
+			usageInHuman := units.HumanSize(float64(usageMemory))
+			logging.Infof("[%s] %.3f cores (average: %.3f cores), %s of memory", c.info.Name, instantUsageInCores, usageInCores, usageInHuman)
 		}
-		lastHousekeeping = next
 	}
+
+	err := c.adjustHousekeepingInterval()
+	if err != nil && c.allowErrorLogging() {
+		logging.Warningf("Failed to get RecentStats(%q) while determining the next housekeeping: %v", c.info.Name, err)
+	}
+	return c.backoff.apply(c.housekeepingInterval, c.maxHousekeepingInterval)
 }
 
 type ContainerDataMethod func(c *containerData) error
 
 func (c *containerData) doWithTimeout(meth ContainerDataMethod, timeout time.Duration) {
+	if atomic.LoadInt32(&c.stuck) != 0 {
+		// Skip the container until its stuck update completes.
+		return
+	}
 	done := make(chan bool, 1)
 
 	go func() {
 		err := meth(c)
 		if err != nil {
 			if c.allowErrorLogging() {
-				glog.Infof("Failed to update stats for container \"%s\": %s", c.info.Name, err)
+				logging.Infof("Failed to update stats for container \"%s\": %s", c.info.Name, err)
 			}
 		}
 		done <- true
@@ -498,13 +869,35 @@ func (c *containerData) doWithTimeout(meth ContainerDataMethod, timeout time.Dur
 	case <-done:
 		// Nothing to do
 	case <-time.After(timeout):
+		if getHousekeepingSettings().panicTimeoutPolicy == "degrade" {
+			c.degrade(done, timeout)
+			return
+		}
 		// We timed out. Dump all goroutine stacks to facilitate troubleshooting, and panic.
-		glog.Errorf("Timed out for: %s", c.info.Name)
+		logging.Errorf("Timed out for: %s", c.info.Name)
 		pprof.Lookup("goroutine").WriteTo(os.Stderr, 1)
 		panic("Aborting!")
 	}
 }
 
+// Marks the container as stuck until its timed out update completes, which
+// done receives.
+func (c *containerData) degrade(done chan bool, timeout time.Duration) {
+	if !atomic.CompareAndSwapInt32(&c.stuck, 0, 1) {
+		// The other update of the container timed out already.
+		return
+	}
+	logging.Errorf("Timed out for: %s, skipping the container until the update completes", c.info.Name)
+	if c.onTimeout != nil {
+		c.onTimeout(c.info.Name, timeout)
+	}
+	go func() {
+		<-done
+		atomic.StoreInt32(&c.stuck, 0)
+		logging.Infof("Timed out update of %s completed, resuming its housekeeping", c.info.Name)
+	}()
+}
+
 func (c *containerData) updateSpec() error {
 	spec, err := c.handler.GetSpec()
 	if err != nil {
@@ -524,14 +917,53 @@ func (c *containerData) updateSpec() error {
 		spec.CustomMetrics = customMetrics
 	}
 	c.lock.Lock()
-	defer c.lock.Unlock()
+	old := c.info.Spec
+	first := c.lastSpecUpdate.IsZero()
+	spec.Generation = old.Generation
+	spec.NetworkOwner = old.NetworkOwner
+	var changes []string
+	if !first {
+		changes = specChanges(&old, &spec)
+		if len(changes) > 0 {
+			spec.Generation++
+		}
+	}
+	if first || len(changes) > 0 {
+		c.addSpecVersion(spec, changes)
+	}
 	c.info.Spec = spec
+	c.lastSpecUpdate = time.Now()
+	c.lock.Unlock()
+
+	if len(changes) > 0 {
+		logging.V(2).Infof("Spec of %q changed (%v), now at generation %d", c.info.Name, changes, spec.Generation)
+		c.bumpRevision()
+		if c.onSpecChange != nil {
+			c.onSpecChange(c.info.Name, &old, &spec, changes)
+		}
+	}
 	return nil
 }
 
+// Refreshes the spec of the container every --spec_update_interval.
+func (c *containerData) refreshSpec() {
+	if *specUpdateInterval <= 0 {
+		return
+	}
+	c.lock.Lock()
+	lastSpecUpdate := c.lastSpecUpdate
+	c.lock.Unlock()
+	if time.Since(lastSpecUpdate) < *specUpdateInterval {
+		return
+	}
+	if err := c.updateSpec(); err != nil && c.allowErrorLogging() {
+		logging.Warningf("Failed to update the spec of %q: %v", c.info.Name, err)
+	}
+}
+
 // Calculate new smoothed load average using the new sample of runnable threads.
 // The decay used ensures that the load will stabilize on a new constant value within
-// 10 seconds.
+// --load_average_window.
 func (c *containerData) updateLoadAvg(probeTime time.Time, newTaskStats info.LoadStats) {
 	c.loadLock.Lock()
 	defer c.loadLock.Unlock()
@@ -541,7 +973,7 @@ func (c *containerData) updateLoadAvg(probeTime time.Time, newTaskStats info.Loa
 		// We never saw a load average, just record this as the new authoritative value
 		c.loadAvg = float64(newLoad)
 	} else {
-		loadDecay := math.Exp(float64(-(probeTime.Sub(c.loadAvgLastProbeTime).Seconds() / 10)))
+		loadDecay := math.Exp(-probeTime.Sub(c.loadAvgLastProbeTime).Seconds() / loadAverageWindow.Seconds())
 		c.loadAvg = float64(newLoad)*(1.0-loadDecay) + c.loadAvg*loadDecay
 	}
 	c.loadAvgLastProbeTime = probeTime
@@ -602,18 +1034,12 @@ func (c *containerData) doLoadReaderLoop() {
 		case <-c.loadStop:
 			return
 		default:
-			c.doWithTimeout((*containerData).doLoadReaderIteration, *PanicTimeout)
+			c.doWithTimeout((*containerData).doLoadReaderIteration, getHousekeepingSettings().panicTimeout)
 		}
 
 		// Schedule the next housekeeping. Sleep until that time.
-		next := lastIteration.Add(utils.Jitter(c.loadreaderInterval, 1.0))
-
-		if time.Now().Before(next) {
-			time.Sleep(next.Sub(time.Now()))
-		} else {
-			next = time.Now()
-		}
-
+		next := c.nextRun(lastIteration, c.loadreaderInterval)
+		time.Sleep(next.Sub(time.Now()))
 		lastIteration = next
 	}
 }
@@ -625,13 +1051,20 @@ func (c *containerData) updateStats() error {
 		if !c.handler.Exists() {
 			return nil
 		}
+		c.recordStatsResult(statsErr)
 
 		// Stats may be partially populated, push those before we return an error.
 		statsErr = fmt.Errorf("%v, continuing to push stats", statsErr)
+	} else {
+		c.recordStatsResult(nil)
 	}
 	if stats == nil {
 		return statsErr
 	}
+	if *monotonicTimestamps {
+		monotonicNow := utils.MonotonicNow()
+		stats.MonotonicTimestamp = &monotonicNow
+	}
 	load := c.LoadAvg()
 	if load >= 0 {
 		// convert to 'milliLoad' to avoid floats and preserve precision.
@@ -641,11 +1074,32 @@ func (c *containerData) updateStats() error {
 	if &taskStats != nil {
 		stats.TaskStats = taskStats
 	}
+	if schedstat, err := c.schedstat(); err != nil {
+		logging.V(4).Infof("Failed to get the scheduler stats of %q: %v", c.info.Name, err)
+		stats.SetError(info.SchedstatSubsystem, err)
+	} else {
+		stats.Cpu.Schedstat = schedstat
+	}
+	// The network stats of a shared network namespace are reported by its
+	// owner.
+	if c.networkOwner() != "" {
+		stats.Network = info.NetworkStats{}
+	}
+	if *markStatsGaps {
+		c.markDiscontinuities(stats)
+	}
+	var derivedStats *v2.DerivedStats
 	if c.summaryReader != nil {
 		err := c.summaryReader.AddSample(*stats)
 		if err != nil {
 			// Ignore summary errors for now.
-			glog.V(2).Infof("Failed to add summary stats for %q: %v", c.info.Name, err)
+			logging.V(2).Infof("Failed to add summary stats for %q: %v", c.info.Name, err)
+		}
+		if c.eventHandler != nil {
+			c.addAnomalyEvents(stats.Timestamp)
+		}
+		if derived, ok := c.summaryReader.RolledOverDerivedStats(); ok && *storageDriverDerivedStats {
+			derivedStats = &derived
 		}
 	}
 	var customStatsErr error
@@ -657,6 +1111,7 @@ func (c *containerData) updateStats() error {
 				stats.CustomMetrics = customStats
 			}
 			if err != nil {
+				stats.SetError(info.CustomMetricsSubsystem, err)
 				customStatsErr = err
 			}
 		}
@@ -674,6 +1129,16 @@ func (c *containerData) updateStats() error {
 	if err != nil {
 		return err
 	}
+	if derivedStats != nil {
+		if err := c.memoryCache.AddDerivedStats(ref, derivedStats); err != nil {
+			logging.Errorf("failed to write derived stats of %q: %v", c.info.Name, err)
+		}
+	}
+	now := time.Now()
+	c.lock.Lock()
+	c.lastStatsTime = now
+	c.lock.Unlock()
+	c.observeFirstStats(now)
 	if statsErr != nil {
 		return statsErr
 	}
@@ -0,0 +1,55 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"time"
+
+	"github.com/google/cadvisor/info/v2"
+)
+
+// Records whether the handler of the container got its stats.
+func (c *containerData) recordStatsResult(err error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if err != nil {
+		c.health.Errors++
+		c.health.ConsecutiveErrors++
+		c.health.LastError = err.Error()
+		c.health.LastErrorTime = time.Now()
+		return
+	}
+	c.health.ConsecutiveErrors = 0
+	c.health.LastSuccessTime = time.Now()
+}
+
+// Returns the errors of the handler getting the stats of the container.
+func (c *containerData) Health() v2.ContainerHealth {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.health
+}
+
+func (self *manager) GetContainerHealth(containerName string, options v2.RequestOptions) (map[string]v2.ContainerHealth, error) {
+	conts, err := self.getRequestedContainers(containerName, options)
+	if err != nil {
+		return nil, err
+	}
+	health := make(map[string]v2.ContainerHealth, len(conts))
+	for name, cont := range conts {
+		health[name] = cont.Health()
+	}
+	return health, nil
+}
@@ -18,7 +18,9 @@ package manager
 
 import (
 	"fmt"
+	"math"
 	"reflect"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -28,6 +30,7 @@ import (
 	info "github.com/google/cadvisor/info/v1"
 	itest "github.com/google/cadvisor/info/v1/test"
 
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -143,6 +146,65 @@ func TestUpdateStats(t *testing.T) {
 	mockHandler.AssertExpectations(t)
 }
 
+func TestUpdateStatsTracksErrors(t *testing.T) {
+	statsList := itest.GenerateRandomStats(1, 4, 1*time.Second)
+	cd, mockHandler, _ := newTestContainerData(t)
+	mockHandler.On("GetStats").Return((*info.ContainerStats)(nil), fmt.Errorf("some error")).Twice()
+	mockHandler.On("Exists").Return(true)
+
+	assert.NotNil(t, cd.updateStats())
+	assert.NotNil(t, cd.updateStats())
+	health := cd.Health()
+	assert.Equal(t, uint64(2), health.Errors)
+	assert.Equal(t, uint64(2), health.ConsecutiveErrors)
+	assert.Equal(t, "some error", health.LastError)
+	assert.False(t, health.LastErrorTime.IsZero())
+	assert.True(t, health.LastSuccessTime.IsZero())
+
+	mockHandler.On("GetStats").Return(statsList[0], nil)
+	require.NoError(t, cd.updateStats())
+	health = cd.Health()
+	assert.Equal(t, uint64(2), health.Errors)
+	assert.Equal(t, uint64(0), health.ConsecutiveErrors)
+	assert.Equal(t, "some error", health.LastError)
+	assert.False(t, health.LastSuccessTime.IsZero())
+	mockHandler.AssertExpectations(t)
+}
+
+func TestMarkStatsGaps(t *testing.T) {
+	defer func(mark bool) { *markStatsGaps = mark }(*markStatsGaps)
+	*markStatsGaps = true
+
+	cd, mockHandler, memoryCache := newTestContainerData(t)
+	cd.maxHousekeepingInterval = 10 * time.Second
+	start := time.Now()
+	newStats := func(timestamp time.Time, cpu uint64) *info.ContainerStats {
+		stats := &info.ContainerStats{Timestamp: timestamp}
+		stats.Cpu.Usage.Total = cpu
+		return stats
+	}
+	latestStats := func(stats *info.ContainerStats) *info.ContainerStats {
+		mockHandler.On("GetStats").Return(stats, nil).Once()
+		require.NoError(t, cd.updateStats())
+		var empty time.Time
+		latest, err := memoryCache.RecentStats(containerName, empty, empty, 1)
+		require.NoError(t, err)
+		require.Equal(t, 1, len(latest))
+		return latest[0]
+	}
+
+	stats := latestStats(newStats(start, 100))
+	assert.Nil(t, stats.GapStart)
+	stats = latestStats(newStats(start.Add(10*time.Second), 200))
+	assert.Nil(t, stats.GapStart)
+	assert.Empty(t, stats.CounterResets)
+	stats = latestStats(newStats(start.Add(time.Minute), 50))
+	require.NotNil(t, stats.GapStart)
+	assert.Equal(t, start.Add(10*time.Second), *stats.GapStart)
+	assert.Equal(t, []string{info.CpuCounter}, stats.CounterResets)
+	mockHandler.AssertExpectations(t)
+}
+
 func TestUpdateSpec(t *testing.T) {
 	spec := itest.GenerateRandomContainerSpec(4)
 	cd, mockHandler, _ := newTestContainerData(t)
@@ -159,6 +221,87 @@ func TestUpdateSpec(t *testing.T) {
 	mockHandler.AssertExpectations(t)
 }
 
+func TestUpdateSpecTracksChanges(t *testing.T) {
+	defer func(size int) { *specHistorySize = size }(*specHistorySize)
+	*specHistorySize = 2
+	spec := itest.GenerateRandomContainerSpec(4)
+	spec.Cpu.Mask = "0-3"
+	spec.Cpu.Mems = "0"
+	mockHandler := container.NewMockContainerHandler(containerName)
+	mockHandler.On("GetSpec").Return(spec, nil).Once()
+	cd, err := newContainerData(containerName, memory.New(60, nil), mockHandler, false, &collector.GenericCollectorManager{}, 60*time.Second, true)
+	require.NoError(t, err)
+	var changes [][]string
+	cd.onSpecChange = func(name string, old, new *info.ContainerSpec, kinds []string) {
+		assert.Equal(t, containerName, name)
+		assert.Equal(t, old.Generation+1, new.Generation)
+		changes = append(changes, kinds)
+	}
+	specRevision := uint64(10)
+	cd.specRevision = &specRevision
+
+	// Unchanged specs are not new versions.
+	mockHandler.On("GetSpec").Return(spec, nil).Once()
+	require.NoError(t, cd.updateSpec())
+	assert.Empty(t, changes)
+	assert.Len(t, cd.SpecHistory(), 1)
+	assert.Equal(t, uint64(0), cd.Revision())
+
+	spec.Memory.Limit++
+	mockHandler.On("GetSpec").Return(spec, nil).Once()
+	require.NoError(t, cd.updateSpec())
+	assert.Equal(t, [][]string{{specChangeLimits}}, changes)
+	assert.Equal(t, uint64(1), cd.info.Spec.Generation)
+	// The container changed at a new revision of the specs.
+	assert.Equal(t, uint64(11), cd.Revision())
+	assert.Equal(t, uint64(11), specRevision)
+
+	spec.Cpu.Mask = "2-3"
+	spec.Image = "other"
+	mockHandler.On("GetSpec").Return(spec, nil).Once()
+	require.NoError(t, cd.updateSpec())
+	assert.Equal(t, [][]string{{specChangeLimits}, {specChangeCpuset, specChangeImage}}, changes)
+	assert.Equal(t, uint64(2), cd.info.Spec.Generation)
+
+	// The history keeps the latest --spec_history_size versions.
+	history := cd.SpecHistory()
+	require.Equal(t, 2, len(history))
+	assert.Equal(t, uint64(1), history[0].spec.Generation)
+	assert.Equal(t, []string{specChangeLimits}, history[0].changes)
+	assert.Equal(t, uint64(2), history[1].spec.Generation)
+	assert.Equal(t, "2-3", history[1].spec.Cpu.Mask)
+	mockHandler.AssertExpectations(t)
+}
+
+func TestLoadAverageWindow(t *testing.T) {
+	defer func(window time.Duration) { *loadAverageWindow = window }(*loadAverageWindow)
+	cd, _, _ := newTestContainerData(t)
+	start := time.Now()
+	cd.updateLoadAvg(start, info.LoadStats{})
+	assert.Equal(t, 0.0, cd.LoadAvg())
+
+	// A step of the runnable threads is reached at 1-1/e after one window.
+	*loadAverageWindow = time.Minute
+	cd.updateLoadAvg(start.Add(time.Minute), info.LoadStats{NrRunning: 3, NrUninterruptible: 1})
+	assert.InDelta(t, 4*(1-1/math.E), cd.LoadAvg(), 1e-9)
+}
+
+func TestValidateContainerFlags(t *testing.T) {
+	defer func(window int) { *anomalyWindow = window }(*anomalyWindow)
+	defer func(window time.Duration) { *loadAverageWindow = window }(*loadAverageWindow)
+	assert.NoError(t, validateContainerFlags())
+	for _, window := range []int{0, -5} {
+		*anomalyWindow = window
+		assert.Error(t, validateContainerFlags(), "window %d", window)
+	}
+
+	*anomalyWindow = 300
+	for _, window := range []time.Duration{0, -time.Second} {
+		*loadAverageWindow = window
+		assert.Error(t, validateContainerFlags(), "window %v", window)
+	}
+}
+
 func TestGetInfo(t *testing.T) {
 	spec := itest.GenerateRandomContainerSpec(4)
 	subcontainers := []info.ContainerReference{
@@ -204,3 +347,236 @@ func TestGetInfo(t *testing.T) {
 		t.Errorf("received wrong container name: received %v; should be %v", info.Name, mockHandler.Name)
 	}
 }
+
+func TestExit(t *testing.T) {
+	stats := itest.GenerateRandomStats(1, 4, 1*time.Second)[0]
+	cd, mockHandler, memoryCache := newTestContainerData(t)
+	mockHandler.On("GetStats").Return(
+		stats,
+		nil,
+	)
+
+	exitTime := time.Now()
+	finalStats, err := cd.Exit(exitTime)
+	require.Nil(t, err)
+	assert.Equal(t, stats, finalStats)
+
+	// The info of exited containers is not updated anymore.
+	cinfo, err := cd.GetInfo()
+	require.Nil(t, err)
+	assert.Equal(t, exitTime, cinfo.Spec.ExitTime)
+	checkNumStats(t, memoryCache, 1)
+
+	require.Nil(t, cd.Remove())
+	_, err = memoryCache.RecentStats(containerName, time.Time{}, time.Time{}, -1)
+	assert.NotNil(t, err)
+}
+
+// Records whether calls to GetStats overlap.
+type overlapHandler struct {
+	*container.MockContainerHandler
+	inFlight    int32
+	overlapping int32
+}
+
+func (self *overlapHandler) GetStats() (*info.ContainerStats, error) {
+	if atomic.AddInt32(&self.inFlight, 1) > 1 {
+		atomic.StoreInt32(&self.overlapping, 1)
+	}
+	defer atomic.AddInt32(&self.inFlight, -1)
+	time.Sleep(20 * time.Millisecond)
+	return self.MockContainerHandler.GetStats()
+}
+
+func TestExitWaitsForHousekeepingLoop(t *testing.T) {
+	mockHandler := container.NewMockContainerHandler(containerName)
+	mockHandler.On("GetSpec").Return(itest.GenerateRandomContainerSpec(4), nil)
+	mockHandler.On("GetStats").Return(itest.GenerateRandomStats(1, 4, time.Second)[0], nil)
+	handler := &overlapHandler{MockContainerHandler: mockHandler}
+	cd, err := newContainerData(containerName, memory.New(60, nil), handler, false, &collector.GenericCollectorManager{}, 60*time.Second, true)
+	require.NoError(t, err)
+
+	require.NoError(t, cd.Start())
+	// Exit while the first housekeeping collects stats.
+	time.Sleep(5 * time.Millisecond)
+	_, err = cd.Exit(time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&handler.overlapping))
+}
+
+func TestHousekeepingBackoff(t *testing.T) {
+	var noBackoff *housekeepingBackoff
+	assert.Equal(t, time.Second, noBackoff.apply(time.Second, time.Minute))
+
+	backoff := newHousekeepingBackoff(0.5, 1.0, 4)
+	assert.Equal(t, time.Second, backoff.apply(time.Second, time.Minute))
+
+	// Busy cAdvisor, then busy host.
+	backoff.adjust(0.6, 0.1)
+	assert.Equal(t, 2*time.Second, backoff.apply(time.Second, time.Minute))
+	backoff.adjust(0.1, 1.5)
+	assert.Equal(t, 4*time.Second, backoff.apply(time.Second, time.Minute))
+	// Capped by the max interval, and longer intervals are kept.
+	assert.Equal(t, 3*time.Second, backoff.apply(time.Second, 3*time.Second))
+	assert.Equal(t, 10*time.Second, backoff.apply(10*time.Second, 3*time.Second))
+
+	// Neither busy nor idle.
+	backoff.adjust(0.3, 0.1)
+	assert.Equal(t, 4*time.Second, backoff.apply(time.Second, time.Minute))
+	// Idle.
+	backoff.adjust(0.1, 0.1)
+	backoff.adjust(0.1, 0.1)
+	backoff.adjust(0.1, 0.1)
+	assert.Equal(t, time.Second, backoff.apply(time.Second, time.Minute))
+
+	for i := 0; i < 10; i++ {
+		backoff.adjust(1, 0)
+	}
+	assert.Equal(t, maxHousekeepingBackoff*time.Second, backoff.apply(time.Second, time.Hour))
+}
+
+func TestHousekeepingScheduler(t *testing.T) {
+	scheduler := newHousekeepingScheduler(2)
+	quit := make(chan error)
+	go scheduler.loop(quit)
+
+	runs := make(chan time.Time, 100)
+	task := scheduler.schedule(func() time.Time {
+		runs <- time.Now()
+		return time.Now().Add(10 * time.Millisecond)
+	}, time.Now())
+	// A task scheduled later does not delay the earlier one.
+	later := scheduler.schedule(func() time.Time {
+		t.Errorf("unexpected run of a task scheduled in an hour")
+		return time.Now()
+	}, time.Now().Add(time.Hour))
+
+	for i := 0; i < 3; i++ {
+		select {
+		case <-runs:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for run %d of the task", i)
+		}
+	}
+	scheduler.cancel(task)
+	scheduler.cancel(later)
+	numRuns := len(runs)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, numRuns, len(runs), "cancelled task ran again")
+
+	quit <- nil
+	require.Nil(t, <-quit)
+}
+
+func TestStartupStagger(t *testing.T) {
+	stagger := newStartupStagger(4, 400*time.Millisecond, 1)
+	var conts []*containerData
+	for i := 0; i < 4; i++ {
+		cont, _, _ := newTestContainerData(t)
+		stagger.stagger(cont)
+		conts = append(conts, cont)
+	}
+	for i, cont := range conts {
+		assert.Equal(t, time.Duration(i)*100*time.Millisecond, cont.firstHousekeeping.Sub(stagger.start))
+	}
+	assert.False(t, conts[3].firstHousekeepingTime().Before(stagger.start.Add(300*time.Millisecond)))
+
+	// Only one first housekeeping runs at once.
+	release := conts[0].takeStartupSlot()
+	taken := make(chan bool)
+	go func() {
+		conts[1].takeStartupSlot()()
+		taken <- true
+	}()
+	select {
+	case <-taken:
+		t.Fatal("took a second startup slot while the first one was held")
+	case <-time.After(20 * time.Millisecond):
+	}
+	release()
+	select {
+	case <-taken:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the released startup slot")
+	}
+	// The slot is only held by the first housekeeping.
+	assert.Nil(t, conts[0].startupSlots)
+	conts[0].takeStartupSlot()()
+}
+
+func TestFirstStatsLatency(t *testing.T) {
+	samples := func() uint64 {
+		var metric dto.Metric
+		require.NoError(t, ContainerFirstStatsLatency.Write(&metric))
+		return metric.GetHistogram().GetSampleCount()
+	}
+	before := samples()
+	cd, mockHandler, _ := newTestContainerData(t)
+	stats := itest.GenerateRandomStats(1, 4, 1*time.Second)[0]
+	mockHandler.On("GetStats").Return(stats, nil)
+	mockHandler.On("ContainerReference").Return(cd.info.ContainerReference, nil)
+	cd.appeared = time.Now().Add(-2 * time.Second)
+
+	// Only the first stats are recorded.
+	require.NoError(t, cd.updateStats())
+	require.NoError(t, cd.updateStats())
+	assert.Equal(t, before+1, samples())
+	assert.True(t, cd.appeared.IsZero())
+}
+
+func TestDegradeOnTimeout(t *testing.T) {
+	settings := getHousekeepingSettings()
+	defer currentHousekeepingSettings.Store(settings)
+	degrade := *settings
+	degrade.panicTimeoutPolicy = "degrade"
+	currentHousekeepingSettings.Store(&degrade)
+
+	cd, _, _ := newTestContainerData(t)
+	timeouts := 0
+	cd.onTimeout = func(name string, timeout time.Duration) {
+		assert.Equal(t, containerName, name)
+		timeouts++
+	}
+	release := make(chan bool)
+	stuck := func(c *containerData) error {
+		<-release
+		return nil
+	}
+	calls := 0
+	count := func(c *containerData) error {
+		calls++
+		return nil
+	}
+
+	cd.doWithTimeout(stuck, 10*time.Millisecond)
+	assert.Equal(t, 1, timeouts)
+	// Skipped while the update is stuck.
+	cd.doWithTimeout(count, time.Second)
+	assert.Equal(t, 0, calls)
+
+	close(release)
+	for i := 0; i < 100 && atomic.LoadInt32(&cd.stuck) != 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cd.doWithTimeout(count, time.Second)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 1, timeouts)
+}
+
+func TestParseCpuTime(t *testing.T) {
+	for value, expected := range map[string]uint64{
+		"00:00:00":    0,
+		"01:02:03":    3723,
+		"2-00:00:05":  172805,
+		"12:34":       754,
+		"100-01:00:0": 8643600,
+	} {
+		seconds, err := parseCpuTime(value)
+		assert.Nil(t, err, value)
+		assert.Equal(t, expected, seconds, value)
+	}
+	for _, value := range []string{"", "1", "a:b:c", "x-01:00:00", "1:2:3:4"} {
+		_, err := parseCpuTime(value)
+		assert.NotNil(t, err, value)
+	}
+}
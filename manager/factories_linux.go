@@ -0,0 +1,92 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package manager
+
+import (
+	"github.com/google/cadvisor/container/containerd"
+	"github.com/google/cadvisor/container/cri"
+	"github.com/google/cadvisor/container/docker"
+	"github.com/google/cadvisor/container/libvirt"
+	"github.com/google/cadvisor/container/podman"
+	"github.com/google/cadvisor/container/raw"
+	"github.com/google/cadvisor/container/rkt"
+	"github.com/google/cadvisor/container/systemd"
+	"github.com/google/cadvisor/logging"
+)
+
+// Registers the container factories reading the cgroups of the containers
+// and returns the number of container runtimes registered. The raw factory is
+// registered last as it handles all the cgroups that the other factories do
+// not, and is not counted as a runtime.
+func (self *manager) registerPlatformFactories() int {
+	runtimes := 0
+	err := docker.Register(self, self.fsInfo, self.ignoreMetrics)
+	if err != nil {
+		logging.Warningf("Docker container factory registration failed: %v.", err)
+	} else {
+		runtimes++
+	}
+	self.dockerRegistered = err == nil
+
+	err = cri.Register(self, self.fsInfo, self.ignoreMetrics)
+	if err != nil {
+		logging.Warningf("Registration of the CRI container factory failed: %v", err)
+	} else {
+		runtimes++
+	}
+
+	err = containerd.Register(self, self.fsInfo, self.ignoreMetrics)
+	if err != nil {
+		logging.Warningf("Registration of the containerd container factory failed: %v", err)
+	} else {
+		runtimes++
+	}
+
+	err = podman.Register(self, self.fsInfo, self.ignoreMetrics)
+	if err != nil {
+		logging.Warningf("Registration of the podman container factory failed: %v", err)
+	} else {
+		runtimes++
+	}
+
+	err = rkt.Register(self, self.fsInfo, self.ignoreMetrics)
+	if err != nil {
+		logging.Warningf("Registration of the rkt container factory failed: %v", err)
+	} else {
+		runtimes++
+	}
+
+	err = libvirt.Register(self, self.fsInfo, self.ignoreMetrics)
+	if err != nil {
+		logging.V(2).Infof("Registration of the libvirt container factory failed: %v", err)
+	} else {
+		runtimes++
+	}
+
+	err = systemd.Register(self, self.fsInfo, self.ignoreMetrics)
+	if err != nil {
+		logging.Warningf("Registration of the systemd container factory failed: %v", err)
+	} else {
+		runtimes++
+	}
+
+	err = raw.Register(self, self.fsInfo, self.ignoreMetrics)
+	if err != nil {
+		logging.Errorf("Registration of the raw container factory failed: %v", err)
+	}
+	return runtimes
+}
@@ -0,0 +1,34 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !linux
+
+package manager
+
+import (
+	"github.com/google/cadvisor/container/hcs"
+	"github.com/google/cadvisor/logging"
+)
+
+// Registers the HCS container factory, which handles the root container and
+// the containers of Windows, and returns the number of container runtimes
+// registered.
+func (self *manager) registerPlatformFactories() int {
+	err := hcs.Register(self, self.fsInfo, self.ignoreMetrics)
+	if err != nil {
+		logging.Errorf("Registration of the HCS container factory failed: %v", err)
+		return 0
+	}
+	return 1
+}
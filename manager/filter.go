@@ -0,0 +1,109 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"flag"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var containerWhitelist = flag.String("container_whitelist", "", "comma separated list of rules selecting the only containers to monitor: name=<regexp> matching the absolute name or an alias, label:<key>=<regexp> or image=<regexp>. Empty monitors all containers")
+var containerBlacklist = flag.String("container_blacklist", "", "comma separated list of rules selecting containers not to monitor, in the format of --container_whitelist, e.g. name=^k8s_POD_ to skip pause containers")
+
+// A rule selecting containers by name, label or image.
+type filterRule struct {
+	// "name", "label" or "image".
+	kind string
+	// Key of the label of label rules.
+	label  string
+	regexp *regexp.Regexp
+}
+
+// Selects the containers matching any of its rules.
+type containerFilter struct {
+	rules []filterRule
+}
+
+// Parses a comma separated list of rules. It returns nil if there are none.
+func parseContainerFilter(rules string) (*containerFilter, error) {
+	filter := &containerFilter{}
+	for _, rule := range strings.Split(rules, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		eq := strings.Index(rule, "=")
+		if eq < 0 {
+			return nil, fmt.Errorf("invalid container filter rule %q, expected <kind>=<regexp>", rule)
+		}
+		parsed := filterRule{kind: rule[:eq]}
+		if strings.HasPrefix(parsed.kind, "label:") {
+			parsed.label = strings.TrimPrefix(parsed.kind, "label:")
+			parsed.kind = "label"
+		}
+		if parsed.kind != "name" && parsed.kind != "image" && (parsed.kind != "label" || parsed.label == "") {
+			return nil, fmt.Errorf("invalid container filter rule %q, expected name, label:<key> or image", rule)
+		}
+		var err error
+		if parsed.regexp, err = regexp.Compile(rule[eq+1:]); err != nil {
+			return nil, fmt.Errorf("invalid regexp in container filter rule %q: %v", rule, err)
+		}
+		filter.rules = append(filter.rules, parsed)
+	}
+	if len(filter.rules) == 0 {
+		return nil, nil
+	}
+	return filter, nil
+}
+
+// Returns whether a name rule matches the name, before the container is
+// inspected.
+func (self *containerFilter) matchesName(name string) bool {
+	for _, rule := range self.rules {
+		if rule.kind == "name" && rule.regexp.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns whether a rule matches the absolute name or an alias, a label or the
+// image of the container.
+func (self *containerFilter) matches(cont *containerData) bool {
+	for _, rule := range self.rules {
+		switch rule.kind {
+		case "name":
+			if rule.regexp.MatchString(cont.info.Name) {
+				return true
+			}
+			for _, alias := range cont.info.Aliases {
+				if rule.regexp.MatchString(alias) {
+					return true
+				}
+			}
+		case "label":
+			if value, ok := cont.info.Labels[rule.label]; ok && rule.regexp.MatchString(value) {
+				return true
+			}
+		case "image":
+			if rule.regexp.MatchString(cont.info.Spec.Image) {
+				return true
+			}
+		}
+	}
+	return false
+}
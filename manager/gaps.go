@@ -0,0 +1,73 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"flag"
+	"time"
+
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
+)
+
+var markStatsGaps = flag.Bool("mark_stats_gaps", false, "Whether to annotate the stats collected after a gap of more than twice the max housekeeping interval, e.g. after a stall of the housekeeping or a pause of the machine, and the cumulative counters reset since the previous stats")
+
+// Stats collected more than this many max housekeeping intervals after the
+// previous ones follow a gap.
+const statsGapIntervals = 2
+
+// Annotates the stats with the gap since the previous stats of the container
+// and the counters reset since then.
+func (c *containerData) markDiscontinuities(stats *info.ContainerStats) {
+	var empty time.Time
+	previous, err := c.memoryCache.RecentStats(c.info.Name, empty, empty, 1)
+	if err != nil {
+		logging.V(4).Infof("Failed to get the previous stats of %q: %v", c.info.Name, err)
+		return
+	}
+	if len(previous) == 0 || stats.Since(previous[0]) <= 0 {
+		return
+	}
+	last := previous[0]
+	if stats.Since(last) > statsGapIntervals*c.longestHousekeepingInterval() {
+		gapStart := last.Timestamp
+		stats.GapStart = &gapStart
+	}
+	stats.CounterResets = counterResets(last, stats)
+}
+
+// Returns the cumulative counters that decreased between the stats.
+func counterResets(previous, stats *info.ContainerStats) []string {
+	var resets []string
+	if stats.Cpu.Usage.Total < previous.Cpu.Usage.Total {
+		resets = append(resets, info.CpuCounter)
+	}
+	if stats.Valid(info.NetworkStatsSubsystem) && previous.Valid(info.NetworkStatsSubsystem) &&
+		(stats.Network.RxBytes < previous.Network.RxBytes || stats.Network.TxBytes < previous.Network.TxBytes) {
+		resets = append(resets, info.NetworkCounter)
+	}
+	if diskIoBytes(stats) < diskIoBytes(previous) {
+		resets = append(resets, info.DiskIoCounter)
+	}
+	return resets
+}
+
+func diskIoBytes(stats *info.ContainerStats) uint64 {
+	var bytes uint64
+	for _, disk := range stats.DiskIo.IoServiceBytes {
+		bytes += disk.Stats["Read"] + disk.Stats["Write"]
+	}
+	return bytes
+}
@@ -0,0 +1,116 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"sync/atomic"
+
+	"github.com/google/cadvisor/container/docker"
+	"github.com/google/cadvisor/info/v2"
+)
+
+var healthzMissedHousekeepings = flag.Int("healthz_missed_housekeepings", 5, "Number of max housekeeping intervals without stats of a container after which /readyz reports its housekeeping as not progressing")
+
+// Healthz only checks that the process is alive: restarting cAdvisor fixes
+// neither a storage outage nor a stuck container, which Readyz reports.
+func (m *manager) Healthz() []v2.HealthCheck {
+	return []v2.HealthCheck{{Name: "process", Healthy: true}}
+}
+
+// Readyz checks that the recovery of the containers completed, that the
+// Docker daemon, if any, answers, that the housekeeping of the containers
+// progresses and that the writes to the storage driver succeed.
+func (m *manager) Readyz() []v2.HealthCheck {
+	checks := []v2.HealthCheck{m.checkRecovery()}
+	if m.dockerRegistered {
+		checks = append(checks, checkDocker())
+	}
+	return append(checks, m.checkHousekeeping(), m.checkStorage())
+}
+
+func (m *manager) checkRecovery() v2.HealthCheck {
+	check := v2.HealthCheck{
+		Name:    "recovery",
+		Healthy: atomic.LoadInt32(&m.recovered) != 0,
+	}
+	if !check.Healthy {
+		check.Error = "the recovery of the containers has not completed"
+	}
+	return check
+}
+
+func checkDocker() v2.HealthCheck {
+	check := v2.HealthCheck{
+		Name: "docker",
+	}
+	client, err := docker.Client()
+	if err == nil {
+		err = client.Ping()
+	}
+	if err != nil {
+		check.Error = fmt.Sprintf("failed to ping the Docker daemon: %v", err)
+		return check
+	}
+	check.Healthy = true
+	return check
+}
+
+func (m *manager) checkHousekeeping() v2.HealthCheck {
+	check := v2.HealthCheck{
+		Name: "housekeeping",
+	}
+	for _, cont := range m.uniqueContainers() {
+		if cont.exitTime().IsZero() && !cont.housekeepingProgressing(*healthzMissedHousekeepings) {
+			check.StaleContainers = append(check.StaleContainers, cont.info.Name)
+		}
+	}
+	check.Healthy = len(check.StaleContainers) == 0
+	if !check.Healthy {
+		sort.Strings(check.StaleContainers)
+		check.Error = fmt.Sprintf("no stats of %d containers for %d housekeeping intervals", len(check.StaleContainers), *healthzMissedHousekeepings)
+	}
+	return check
+}
+
+func (m *manager) checkStorage() v2.HealthCheck {
+	check := v2.HealthCheck{
+		Name:    "storage",
+		Healthy: true,
+	}
+	since, err := m.memoryCache.BackendError()
+	if err != nil {
+		check.Healthy = false
+		check.Error = fmt.Sprintf("writes to the storage driver fail since %v: %v", since, err)
+	}
+	return check
+}
+
+// Returns every container once, regardless of its aliases.
+func (m *manager) uniqueContainers() []*containerData {
+	m.containersLock.RLock()
+	defer m.containersLock.RUnlock()
+	seen := make(map[*containerData]bool, len(m.containers))
+	containers := make([]*containerData, 0, len(m.containers))
+	for _, cont := range m.containers {
+		if !seen[cont] {
+			seen[cont] = true
+			containers = append(containers, cont)
+		}
+	}
+	return containers
+}
@@ -0,0 +1,44 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"time"
+
+	"github.com/google/cadvisor/info/v2"
+)
+
+func (self *manager) HistoryAvailable() bool {
+	return self.memoryCache.SupportsHistory()
+}
+
+func (self *manager) GetHistoricalStats(containerName string, start, end time.Time, step time.Duration) (v2.ContainerInfo, error) {
+	cont, err := self.getContainerData(containerName)
+	if err != nil {
+		return v2.ContainerInfo{}, err
+	}
+	cinfo, err := cont.GetInfo()
+	if err != nil {
+		return v2.ContainerInfo{}, err
+	}
+	stats, err := self.memoryCache.HistoricalStats(cinfo.Name, start, end, step)
+	if err != nil {
+		return v2.ContainerInfo{}, err
+	}
+	return v2.ContainerInfo{
+		Spec:  self.getV2Spec(cinfo),
+		Stats: v2.ContainerStatsFromV1(&cinfo.Spec, stats),
+	}, nil
+}
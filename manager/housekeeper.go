@@ -0,0 +1,77 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"github.com/google/cadvisor/cache/memory"
+	"github.com/google/cadvisor/collector"
+	"github.com/google/cadvisor/container"
+)
+
+// Housekeeper runs the housekeeping of containers outside of a manager, e.g.
+// of synthetic containers to benchmark the scheduling, caching and storage of
+// their stats.
+type Housekeeper struct {
+	containers []*containerData
+	// Set unless --housekeeping_workers is 0.
+	scheduler     *housekeepingScheduler
+	quitScheduler chan error
+}
+
+// StartHousekeeping starts the housekeeping of the containers of the handlers,
+// scheduled as configured by the flags of the manager, caching their stats in
+// memoryCache. Unlike the manager, it neither detects the machine nor watches
+// the container runtimes.
+func StartHousekeeping(memoryCache *memory.InMemoryCache, handlers []container.ContainerHandler) (*Housekeeper, error) {
+	self := &Housekeeper{}
+	if *housekeepingWorkers > 0 {
+		self.scheduler = newHousekeepingScheduler(*housekeepingWorkers)
+		self.quitScheduler = make(chan error)
+		go self.scheduler.loop(self.quitScheduler)
+	}
+	for _, handler := range handlers {
+		ref, err := handler.ContainerReference()
+		if err != nil {
+			self.Stop()
+			return nil, err
+		}
+		cont, err := newContainerData(ref.Name, memoryCache, handler, false, &collector.GenericCollectorManager{}, getHousekeepingSettings().interval, false)
+		if err != nil {
+			self.Stop()
+			return nil, err
+		}
+		cont.scheduler = self.scheduler
+		if err := cont.Start(); err != nil {
+			self.Stop()
+			return nil, err
+		}
+		self.containers = append(self.containers, cont)
+	}
+	return self, nil
+}
+
+// Stop stops the housekeeping of the containers. Their stats are kept in the
+// memory cache.
+func (self *Housekeeper) Stop() {
+	for _, cont := range self.containers {
+		cont.stopHousekeeping()
+	}
+	self.containers = nil
+	if self.scheduler != nil {
+		self.quitScheduler <- nil
+		<-self.quitScheduler
+		self.scheduler = nil
+	}
+}
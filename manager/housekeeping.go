@@ -0,0 +1,116 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/cadvisor/logging"
+)
+
+var housekeepingIntervalOverrides = flag.String("housekeeping_interval_overrides", "", "comma separated list of <regexp>=<interval> setting the housekeeping interval of the containers whose absolute name or an alias matches the regexp, e.g. ^/system.slice/=10s. The first match applies. The io.cadvisor.housekeeping_interval label of a container takes precedence")
+
+var alignHousekeeping = flag.Bool("align_housekeeping", false, "Whether to run the housekeepings of containers at the multiples of their interval on the wall clock, e.g. at every second for 1s, so that the stats of all containers are sampled at the same times")
+var housekeepingAlignmentJitter = flag.Duration("housekeeping_alignment_jitter", 0, "Maximum random delay of the aligned housekeepings after the wall clock multiple of their interval, spreading the load of the housekeepings of all containers, with --align_housekeeping")
+
+// Label of a container setting its housekeeping interval.
+const HousekeepingIntervalLabel = "io.cadvisor.housekeeping_interval"
+
+// Sets the housekeeping interval of the containers with a matching name.
+type housekeepingOverride struct {
+	regexp   *regexp.Regexp
+	interval time.Duration
+}
+
+// Parses a comma separated list of <regexp>=<interval>.
+func parseHousekeepingOverrides(overrides string) ([]housekeepingOverride, error) {
+	var parsed []housekeepingOverride
+	for _, override := range strings.Split(overrides, ",") {
+		override = strings.TrimSpace(override)
+		if override == "" {
+			continue
+		}
+		// Regexps may contain '=', intervals may not.
+		eq := strings.LastIndex(override, "=")
+		if eq < 0 {
+			return nil, fmt.Errorf("invalid housekeeping interval override %q, expected <regexp>=<interval>", override)
+		}
+		re, err := regexp.Compile(override[:eq])
+		if err != nil {
+			return nil, fmt.Errorf("invalid regexp in housekeeping interval override %q: %v", override, err)
+		}
+		interval, err := parseHousekeepingInterval(override[eq+1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid housekeeping interval override %q: %v", override, err)
+		}
+		parsed = append(parsed, housekeepingOverride{regexp: re, interval: interval})
+	}
+	return parsed, nil
+}
+
+func parseHousekeepingInterval(value string) (time.Duration, error) {
+	interval, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+	if interval <= 0 {
+		return 0, fmt.Errorf("housekeeping interval must be positive, got %v", interval)
+	}
+	return interval, nil
+}
+
+// Returns the housekeeping interval of the container: the one of its label,
+// else the one of the first override matching its name or an alias, else
+// --housekeeping_interval.
+func housekeepingIntervalOf(cont *containerInfo, overrides []housekeepingOverride) time.Duration {
+	if value, ok := cont.Labels[HousekeepingIntervalLabel]; ok {
+		interval, err := parseHousekeepingInterval(value)
+		if err == nil {
+			return interval
+		}
+		logging.Warningf("Ignoring invalid %s label of %q: %v", HousekeepingIntervalLabel, cont.Name, err)
+	}
+	for _, override := range overrides {
+		if override.regexp.MatchString(cont.Name) {
+			return override.interval
+		}
+		for _, alias := range cont.Aliases {
+			if override.regexp.MatchString(alias) {
+				return override.interval
+			}
+		}
+	}
+	return getHousekeepingSettings().interval
+}
+
+// Returns the time of the housekeeping following the one at last: the first
+// multiple of the interval on the wall clock after last, delayed by up to
+// --housekeeping_alignment_jitter. Housekeepings late by more than an interval
+// resume at the next multiple after now.
+func alignedRun(last time.Time, interval time.Duration, now time.Time) time.Time {
+	next := last.Truncate(interval).Add(interval)
+	if now.After(next) {
+		next = now.Truncate(interval).Add(interval)
+	}
+	if *housekeepingAlignmentJitter > 0 {
+		next = next.Add(time.Duration(rand.Int63n(int64(*housekeepingAlignmentJitter))))
+	}
+	return next
+}
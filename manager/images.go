@@ -0,0 +1,105 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/cadvisor/container/containerd"
+	"github.com/google/cadvisor/container/docker"
+	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/logging"
+)
+
+// Prefix of the digests of image IDs.
+const digestPrefix = "sha256:"
+
+func (self *manager) GetImages() ([]v2.Image, error) {
+	var images []v2.Image
+	dockerImages, dockerErr := docker.Images()
+	if dockerErr != nil {
+		logging.V(2).Infof("Failed to list Docker images: %v", dockerErr)
+	}
+	images = append(images, dockerImages...)
+	containerdImages, containerdErr := containerd.Images()
+	if containerdErr != nil {
+		logging.V(2).Infof("Failed to list containerd images: %v", containerdErr)
+	}
+	images = append(images, containerdImages...)
+	if dockerErr != nil && containerdErr != nil {
+		return nil, fmt.Errorf("failed to list Docker images: %v, and containerd images: %v", dockerErr, containerdErr)
+	}
+
+	self.containersLock.RLock()
+	conts := make(map[string]*containerData, len(self.containers))
+	for _, cont := range self.containers {
+		conts[cont.info.Name] = cont
+	}
+	self.containersLock.RUnlock()
+
+	for i := range images {
+		image := &images[i]
+		for name, cont := range conts {
+			// Docker containers run Docker images, the others containerd
+			// images.
+			if (cont.info.Namespace == docker.DockerNamespace) != (image.Runtime == docker.DockerNamespace) {
+				continue
+			}
+			if imageMatches(cont.info.Spec.Image, image) {
+				image.Containers = append(image.Containers, name)
+			}
+		}
+	}
+	return images, nil
+}
+
+// Returns whether the image reference of a container, a name or an ID,
+// designates the image.
+func imageMatches(ref string, image *v2.Image) bool {
+	if ref == "" {
+		return false
+	}
+	// IDs may be truncated.
+	id := strings.TrimPrefix(image.Id, digestPrefix)
+	if refId := strings.TrimPrefix(ref, digestPrefix); len(refId) >= 12 && strings.HasPrefix(id, refId) {
+		return true
+	}
+	normalized := normalizeReference(ref)
+	for _, tag := range image.RepoTags {
+		if normalizeReference(tag) == normalized {
+			return true
+		}
+	}
+	return false
+}
+
+// Completes image names with the default registry and tag, e.g. "nginx"
+// becomes "docker.io/library/nginx:latest".
+func normalizeReference(ref string) string {
+	name := ref
+	components := strings.Split(name, "/")
+	if first := components[0]; len(components) == 1 || !(strings.ContainsAny(first, ".:") || first == "localhost") {
+		if len(components) == 1 {
+			name = "library/" + name
+		}
+		name = "docker.io/" + name
+	}
+	// Tags follow the last ":" after the registry host and port.
+	if !strings.Contains(name, "@") && !strings.Contains(name[strings.LastIndex(name, "/"):], ":") {
+		name += ":latest"
+	}
+	return name
+}
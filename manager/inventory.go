@@ -0,0 +1,115 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"flag"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
+	"github.com/google/cadvisor/utils/sysinfo"
+)
+
+var machineInfoRefreshInterval = flag.Duration("machine_info_refresh_interval", 5*time.Minute, "Interval at which the disks, network devices and PCI devices of the machine info are refreshed, with a machineChange event when they change. 0 disables the refresh")
+
+// Refreshes the hardware inventory of the machine info every interval.
+func (m *manager) refreshMachineInfoLoop(interval time.Duration, quit chan error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.refreshMachineInfo()
+		case <-quit:
+			quit <- nil
+			logging.Infof("Exiting machine info refresh thread")
+			return
+		}
+	}
+}
+
+// Reads the disks, network devices and PCI devices of the machine again and
+// updates the machine info if they changed. The parts that can't be read are
+// kept unchanged.
+func (m *manager) refreshMachineInfo() {
+	m.machineInfoLock.RLock()
+	current := m.machineInfo
+	m.machineInfoLock.RUnlock()
+
+	var changes []string
+	diskMap, err := sysinfo.GetBlockDeviceInfo(m.sysFs)
+	if err != nil {
+		logging.Warningf("Failed to refresh disk map: %v", err)
+	} else if reflect.DeepEqual(diskMap, current.DiskMap) {
+		diskMap = nil
+	} else {
+		changes = append(changes, "disks")
+	}
+	netDevices, err := sysinfo.GetNetworkDevices(m.sysFs)
+	if err != nil {
+		logging.Warningf("Failed to refresh network devices: %v", err)
+	} else if reflect.DeepEqual(netDevices, current.NetworkDevices) {
+		netDevices = nil
+	} else {
+		changes = append(changes, "network_devices")
+	}
+	pciDevices, err := sysinfo.GetPciDevices(m.sysFs)
+	if err != nil {
+		logging.Warningf("Failed to refresh PCI devices: %v", err)
+	} else if reflect.DeepEqual(pciDevices, current.PciDevices) {
+		pciDevices = nil
+	} else {
+		changes = append(changes, "pci_devices")
+	}
+	if len(changes) == 0 {
+		return
+	}
+
+	// Only the changed fields are written, the others are read without the
+	// lock.
+	m.machineInfoLock.Lock()
+	if diskMap != nil {
+		m.machineInfo.DiskMap = diskMap
+	}
+	if netDevices != nil {
+		m.machineInfo.NetworkDevices = netDevices
+	}
+	if pciDevices != nil {
+		m.machineInfo.PciDevices = pciDevices
+	}
+	m.machineInfo.Generation++
+	generation := m.machineInfo.Generation
+	m.machineInfoLock.Unlock()
+	atomic.AddUint64(&m.specRevision, 1)
+	logging.Infof("Machine info changed (%v), now at generation %d", changes, generation)
+
+	err = m.eventHandler.AddEvent(&info.Event{
+		ContainerName: "/",
+		Timestamp:     time.Now(),
+		EventType:     info.EventMachineChange,
+		EventData: info.EventData{
+			MachineChange: &info.MachineChangeEventData{
+				Generation: generation,
+				Changes:    changes,
+			},
+		},
+	})
+	if err != nil {
+		logging.Errorf("failed to add machine change event: %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Buckets of the latencies, from 10ms to about 3 minutes.
+var containerLatencyBuckets = prometheus.ExponentialBuckets(0.01, 2, 15)
+
+// Latencies of the creation and destruction of the containers found by
+// watch events, measured from the receipt of their events, which closely
+// follows the creation or removal of their cgroup.
+var ContainerCreationLatency = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Name:    "cadvisor_container_creation_latency_seconds",
+	Help:    "Time from the watch event of a new container to the creation of its handler and spec.",
+	Buckets: containerLatencyBuckets,
+})
+var ContainerFirstStatsLatency = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Name:    "cadvisor_container_first_stats_latency_seconds",
+	Help:    "Time from the watch event of a new container to its first stats sample, during which its usage isn't observed.",
+	Buckets: containerLatencyBuckets,
+})
+var ContainerDestructionLatency = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Name:    "cadvisor_container_destruction_latency_seconds",
+	Help:    "Time from the watch event of a deleted container to its destruction.",
+	Buckets: containerLatencyBuckets,
+})
+
+// Records the latency of the first stats of a container found by a watch
+// event.
+func (c *containerData) observeFirstStats(now time.Time) {
+	if c.appeared.IsZero() {
+		return
+	}
+	ContainerFirstStatsLatency.Observe(now.Sub(c.appeared).Seconds())
+	c.appeared = time.Time{}
+}
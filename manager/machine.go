@@ -15,27 +15,26 @@
 package manager
 
 import (
-	"bytes"
 	"flag"
 	"io/ioutil"
 	"path/filepath"
 	"strings"
-	"syscall"
 
 	"github.com/google/cadvisor/container/docker"
 	"github.com/google/cadvisor/fs"
 	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/logging"
 	"github.com/google/cadvisor/utils/cloudinfo"
 	"github.com/google/cadvisor/utils/machine"
 	"github.com/google/cadvisor/utils/sysfs"
 	"github.com/google/cadvisor/utils/sysinfo"
 	version "github.com/google/cadvisor/version"
-
-	"github.com/golang/glog"
 )
 
 var machineIdFilePath = flag.String("machine_id_file", "/etc/machine-id,/var/lib/dbus/machine-id", "Comma-separated list of files to check for machine-id. Use the first one that exists.")
 var bootIdFilePath = flag.String("boot_id_file", "/proc/sys/kernel/random/boot_id", "Comma-separated list of files to check for boot-id. Use the first one that exists.")
+var storageCloudTags = flag.Bool("storage_driver_cloud_tags", false, "Whether to tag the writes of the storage drivers with the cloud provider, instance type and id, zone, region and instance tags of the machine")
 
 func getInfoFromFiles(filePaths string) string {
 	if len(filePaths) == 0 {
@@ -47,7 +46,7 @@ func getInfoFromFiles(filePaths string) string {
 			return strings.TrimSpace(string(id))
 		}
 	}
-	glog.Infof("Couldn't collect info from any of the files in %q", filePaths)
+	logging.Infof("Couldn't collect info from any of the files in %q", filePaths)
 	return ""
 }
 
@@ -70,27 +69,38 @@ func getMachineInfo(sysFs sysfs.SysFs, fsInfo fs.FsInfo, inHostNamespace bool) (
 
 	filesystems, err := fsInfo.GetGlobalFsInfo(false)
 	if err != nil {
-		glog.Errorf("Failed to get global filesystem information: %v", err)
+		logging.Errorf("Failed to get global filesystem information: %v", err)
 	}
 
 	diskMap, err := sysinfo.GetBlockDeviceInfo(sysFs)
 	if err != nil {
-		glog.Errorf("Failed to get disk map: %v", err)
+		logging.Errorf("Failed to get disk map: %v", err)
 	}
 
 	netDevices, err := sysinfo.GetNetworkDevices(sysFs)
 	if err != nil {
-		glog.Errorf("Failed to get network devices: %v", err)
+		logging.Errorf("Failed to get network devices: %v", err)
 	}
 
 	topology, numCores, err := machine.GetTopology(sysFs, string(cpuinfo))
 	if err != nil {
-		glog.Errorf("Failed to get topology information: %v", err)
+		logging.Errorf("Failed to get topology information: %v", err)
+	}
+
+	pciDevices, err := sysinfo.GetPciDevices(sysFs)
+	if err != nil {
+		logging.Errorf("Failed to get PCI devices: %v", err)
+	}
+
+	numaNodes, err := machine.GetNumaTopology(sysFs)
+	if err != nil {
+		logging.Warningf("Failed to get NUMA topology: %v", err)
 	}
+	numSockets, numPhysicalCores, threadsPerCore := machine.GetCoreCounts(topology)
 
 	systemUUID, err := sysinfo.GetSystemUUID(sysFs)
 	if err != nil {
-		glog.Errorf("Failed to get system UUID: %v", err)
+		logging.Errorf("Failed to get system UUID: %v", err)
 	}
 
 	realCloudInfo := cloudinfo.NewRealCloudInfo()
@@ -99,18 +109,26 @@ func getMachineInfo(sysFs sysfs.SysFs, fsInfo fs.FsInfo, inHostNamespace bool) (
 	instanceID := realCloudInfo.GetInstanceID()
 
 	machineInfo := &info.MachineInfo{
-		NumCores:       numCores,
-		CpuFrequency:   clockSpeed,
-		MemoryCapacity: memoryCapacity,
-		DiskMap:        diskMap,
-		NetworkDevices: netDevices,
-		Topology:       topology,
-		MachineID:      getInfoFromFiles(filepath.Join(rootFs, *machineIdFilePath)),
-		SystemUUID:     systemUUID,
-		BootID:         getInfoFromFiles(filepath.Join(rootFs, *bootIdFilePath)),
-		CloudProvider:  cloudProvider,
-		InstanceType:   instanceType,
-		InstanceID:     instanceID,
+		NumCores:         numCores,
+		CpuFrequency:     clockSpeed,
+		MemoryCapacity:   memoryCapacity,
+		DiskMap:          diskMap,
+		NetworkDevices:   netDevices,
+		PciDevices:       pciDevices,
+		Topology:         topology,
+		NumSockets:       numSockets,
+		NumPhysicalCores: numPhysicalCores,
+		ThreadsPerCore:   threadsPerCore,
+		NumaNodes:        numaNodes,
+		MachineID:        getInfoFromFiles(filepath.Join(rootFs, *machineIdFilePath)),
+		SystemUUID:       systemUUID,
+		BootID:           getInfoFromFiles(filepath.Join(rootFs, *bootIdFilePath)),
+		CloudProvider:    cloudProvider,
+		InstanceType:     instanceType,
+		InstanceID:       instanceID,
+		Zone:             realCloudInfo.GetZone(),
+		Region:           realCloudInfo.GetRegion(),
+		InstanceTags:     realCloudInfo.GetTags(),
 	}
 
 	for _, fs := range filesystems {
@@ -120,6 +138,41 @@ func getMachineInfo(sysFs sysfs.SysFs, fsInfo fs.FsInfo, inHostNamespace bool) (
 	return machineInfo, nil
 }
 
+// Returns the tags of the cloud instance added to the writes of the storage
+// drivers. The instance tags are prefixed with "tag_".
+func cloudStorageTags(machineInfo *info.MachineInfo) map[string]string {
+	tags := make(map[string]string)
+	for name, value := range map[string]string{
+		"cloud_provider": string(machineInfo.CloudProvider),
+		"instance_type":  string(machineInfo.InstanceType),
+		"instance_id":    string(machineInfo.InstanceID),
+		"zone":           machineInfo.Zone,
+		"region":         machineInfo.Region,
+	} {
+		if value != "" {
+			tags[name] = value
+		}
+	}
+	for name, value := range machineInfo.InstanceTags {
+		tags["tag_"+name] = value
+	}
+	return tags
+}
+
+func (m *manager) GetHostStats() (v2.HostStats, error) {
+	rootFs := "/"
+	if !m.inHostNamespace {
+		rootFs = "/rootfs"
+	}
+	stats, err := machine.GetHostStats(rootFs)
+	if err != nil {
+		return stats, err
+	}
+	m.addPowerStats(&stats)
+	m.addThinPoolStats(&stats)
+	return stats, nil
+}
+
 func getVersionInfo() (*info.VersionInfo, error) {
 
 	kernel_version := getKernelVersion()
@@ -163,21 +216,3 @@ func getDockerVersion() string {
 	}
 	return docker_version
 }
-
-func getKernelVersion() string {
-	uname := &syscall.Utsname{}
-
-	if err := syscall.Uname(uname); err != nil {
-		return "Unknown"
-	}
-
-	release := make([]byte, len(uname.Release))
-	i := 0
-	for _, c := range uname.Release {
-		release[i] = byte(c)
-		i++
-	}
-	release = release[:bytes.IndexByte(release, 0)]
-
-	return string(release)
-}
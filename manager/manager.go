@@ -18,35 +18,44 @@ package manager
 import (
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/cadvisor/cache/memory"
 	"github.com/google/cadvisor/collector"
+	"github.com/google/cadvisor/config"
 	"github.com/google/cadvisor/container"
 	"github.com/google/cadvisor/container/docker"
-	"github.com/google/cadvisor/container/raw"
+	"github.com/google/cadvisor/container/fake"
 	"github.com/google/cadvisor/container/rkt"
-	"github.com/google/cadvisor/container/systemd"
 	"github.com/google/cadvisor/events"
 	"github.com/google/cadvisor/fs"
 	info "github.com/google/cadvisor/info/v1"
 	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/kubernetes"
+	"github.com/google/cadvisor/logging"
+	"github.com/google/cadvisor/storage"
 	"github.com/google/cadvisor/utils/oomparser"
 	"github.com/google/cadvisor/utils/sysfs"
-
-	"github.com/golang/glog"
-	"github.com/opencontainers/runc/libcontainer/cgroups"
 )
 
 var globalHousekeepingInterval = flag.Duration("global_housekeeping_interval", 1*time.Minute, "Interval between global housekeepings")
 var logCadvisorUsage = flag.Bool("log_cadvisor_usage", false, "Whether to log the usage of the cAdvisor container")
 var eventStorageAgeLimit = flag.String("event_storage_age_limit", "default=24h", "Max length of time for which to store events (per type). Value is a comma separated list of key values, where the keys are event types (e.g.: creation, oom) or \"default\" and the value is a duration. Default is applied to all non-specified event types")
 var eventStorageEventLimit = flag.String("event_storage_event_limit", "default=100000", "Max number of events to store (per type). Value is a comma separated list of key values, where the keys are event types (e.g.: creation, oom) or \"default\" and the value is an integer. Default is applied to all non-specified event types")
+var oomCgroupNotifications = flag.Bool("oom_cgroup_notifications", true, "Whether to detect OOMs with the notifications of the memory cgroups of containers in addition to the kernel log")
+var storageDriverEvents = flag.Bool("storage_driver_events", false, "Whether to also write container creation, deletion and OOM events to the storage driver, if it supports events (influxdb, elasticsearch, kafka and stdout)")
+var storageDriverDerivedStats = flag.Bool("storage_driver_derived_stats", false, "Whether to also write the mean, max, 50th, 90th and 95th percentiles of the cpu and memory usage of containers over the last minute, hour and day to the storage driver when a minute window rolls over, if it supports them (influxdb, elasticsearch, kafka and stdout)")
+var eventStorageFile = flag.String("event_storage_file", "", "File in which to persist events so that they survive restarts and can be queried beyond the in-memory limits. Empty keeps events in memory only")
+var eventStorageFileAgeLimit = flag.Duration("event_storage_file_age_limit", 7*24*time.Hour, "Max length of time for which to keep events in --event_storage_file")
+var exitedContainerRetention = flag.Duration("exited_container_retention", 0, "Duration for which to keep the info and final stats of containers after they exit, with a containerExit event. Zero forgets containers as soon as they exit")
 var applicationMetricsCountLimit = flag.Int("application_metrics_count_limit", 100, "Max number of application metrics to store (per container)")
 
 // The Manager interface defines operations for starting a manager and getting
@@ -77,12 +86,65 @@ type Manager interface {
 	// Gets spec for all containers based on request options.
 	GetContainerSpec(containerName string, options v2.RequestOptions) (map[string]v2.ContainerSpec, error)
 
+	// Gets the history of the spec of all containers based on request
+	// options, oldest version first.
+	GetContainerSpecHistory(containerName string, options v2.RequestOptions) (map[string][]v2.ContainerSpecVersion, error)
+
+	// Gets the errors of the collection of the stats of all containers based
+	// on request options.
+	GetContainerHealth(containerName string, options v2.RequestOptions) (map[string]v2.ContainerHealth, error)
+
 	// Gets summary stats for all containers based on request options.
 	GetDerivedStats(containerName string, options v2.RequestOptions) (map[string]v2.DerivedStats, error)
 
+	// Gets summary stats for all containers based on request options, with
+	// the aggregate of those of the subcontainers of each. Subcontainers
+	// without summary stats are skipped.
+	GetSummaries(containerName string, options v2.RequestOptions) (map[string]v2.ContainerSummary, error)
+
+	// Gets short-term usage forecasts for all containers based on request options.
+	GetPredictions(containerName string, options v2.RequestOptions) (map[string]v2.Prediction, error)
+
+	// Gets cpu and memory requests and limits recommended from the usage of
+	// all containers based on request options, over the window ("hour" or
+	// "day") and with the given fraction of headroom.
+	GetRecommendations(containerName string, options v2.RequestOptions, window string, headroom float64) (map[string]v2.Recommendation, error)
+
+	// Gets the usage of the pods of the node, aggregated over their containers
+	// over the window. Only the pods of the namespace are returned if it is
+	// not empty.
+	GetPods(namespace string, window time.Duration) ([]v2.PodUsage, error)
+
+	// Gets the images of Docker and containerd, with the containers running
+	// them.
+	GetImages() ([]v2.Image, error)
+
+	// Gets the node-wide kernel statistics that don't belong to any
+	// container.
+	GetHostStats() (v2.HostStats, error)
+
+	// Gets the runtime statistics of cAdvisor and the usage of the container
+	// it runs in.
+	GetSelfStats() (v2.SelfStats, error)
+
+	// Checks that the cAdvisor process is alive.
+	Healthz() []v2.HealthCheck
+
+	// Checks that cAdvisor is ready to serve: the recovery of the
+	// containers, the connection to Docker, the progress of the housekeeping
+	// and the writes to the storage driver.
+	Readyz() []v2.HealthCheck
+
+	// Gets up to options.Count subcontainers of the requested container with the
+	// highest usage of the given resource over the window, heaviest first.
+	GetTopContainers(containerName string, options v2.RequestOptions, by string, window time.Duration) ([]v2.ContainerUsage, error)
+
 	// Get info for all requested containers based on the request options.
 	GetRequestedContainersInfo(containerName string, options v2.RequestOptions) (map[string]*info.ContainerInfo, error)
 
+	// Get the sorted absolute names of all requested containers based on the request options.
+	GetRequestedContainerNames(containerName string, options v2.RequestOptions) ([]string, error)
+
 	// Returns true if the named container exists.
 	Exists(containerName string) bool
 
@@ -99,6 +161,10 @@ type Manager interface {
 	// Get ps output for a container.
 	GetProcessList(containerName string, options v2.RequestOptions) ([]v2.ProcessInfo, error)
 
+	// Sends the signal, "TERM" or "KILL", to a process of the container. Fails
+	// unless signals are enabled.
+	SignalProcess(containerName string, pid int, signal string) error
+
 	// Get events streamed through passedChannel that fit the request.
 	WatchForEvents(request *events.Request) (*events.EventChannel, error)
 
@@ -107,14 +173,42 @@ type Manager interface {
 
 	CloseEventChannel(watch_id int)
 
+	// Get stats samples streamed as they are added to the cache for the requested containers.
+	WatchForStats(containerName string, options v2.RequestOptions) (*memory.StatsChannel, error)
+
+	// Stops streaming stats samples to the channel with the given watch id.
+	CloseStatsChannel(watchId int)
+
 	// Get status information about docker.
 	DockerInfo() (DockerStatus, error)
 
 	// Get details about interesting docker images.
 	DockerImages() ([]DockerImage, error)
 
+	// Writes the stats cached in memory, with the specs of the containers, as
+	// a snapshot.
+	WriteSnapshot(w io.Writer) error
+
+	// Adds the stats of a snapshot, of at most maxSize bytes decompressed
+	// unless 0, to the stats cached in memory. Returns the number of
+	// containers whose stats were added.
+	RestoreSnapshot(r io.Reader, maxSize int64) (int, error)
+
+	// Returns whether the storage driver can read back stats older than the
+	// ones cached in memory.
+	HistoryAvailable() bool
+
+	// Reads the stats of the container between start and end, one per step,
+	// from the storage driver.
+	GetHistoricalStats(containerName string, start, end time.Time, step time.Duration) (v2.ContainerInfo, error)
+
 	// Returns debugging information. Map of lines per category.
 	DebugInfo() map[string][]string
+
+	// Returns counters of the changes of the machine info and the specs of
+	// the containers, and of their stats. The responses computed from them
+	// are unchanged while their revisions are.
+	Revisions() (specs, stats uint64)
 }
 
 // New takes a memory storage and returns a new manager.
@@ -124,19 +218,21 @@ func New(memoryCache *memory.InMemoryCache, sysfs sysfs.SysFs, maxHousekeepingIn
 	}
 
 	// Detect the container we are running on.
-	selfContainer, err := cgroups.GetThisCgroupDir("cpu")
+	selfContainer, err := getSelfContainer()
 	if err != nil {
 		return nil, err
 	}
-	glog.Infof("cAdvisor running in container: %q", selfContainer)
+	logging.Infof("cAdvisor running in container: %q", selfContainer)
 
+	// Docker is only one of the container runtimes, Start fails if none is
+	// found.
 	dockerInfo, err := dockerInfo()
 	if err != nil {
-		glog.Fatalf("Unable to connect to Docker: %v", err)
+		logging.Warningf("Unable to connect to Docker: %v", err)
 	}
 	rktPath, err := rkt.RktPath()
 	if err != nil {
-		glog.Warningf("unable to connect to Rkt api service: %v", err)
+		logging.Warningf("unable to connect to Rkt api service: %v", err)
 	}
 
 	context := fs.Context{
@@ -170,22 +266,71 @@ func New(memoryCache *memory.InMemoryCache, sysfs sysfs.SysFs, maxHousekeepingIn
 		maxHousekeepingInterval:  maxHousekeepingInterval,
 		allowDynamicHousekeeping: allowDynamicHousekeeping,
 		ignoreMetrics:            ignoreMetricsSet,
+		specRevision:             uint64(time.Now().UnixNano()),
 	}
 
+	newManager.whitelist, err = parseContainerFilter(*containerWhitelist)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --container_whitelist: %v", err)
+	}
+	newManager.blacklist, err = parseContainerFilter(*containerBlacklist)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --container_blacklist: %v", err)
+	}
+	settings, err := parseHousekeepingSettings(func(name string) string {
+		return flag.Lookup(name).Value.String()
+	})
+	if err != nil {
+		return nil, err
+	}
+	currentHousekeepingSettings.Store(settings)
+	if err := validateContainerFlags(); err != nil {
+		return nil, err
+	}
+	newManager.housekeepingOverrides, err = parseHousekeepingOverrides(*housekeepingIntervalOverrides)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --housekeeping_interval_overrides: %v", err)
+	}
+	config.RegisterReloadable([]string{"container_whitelist", "container_blacklist", "housekeeping_interval", "housekeeping_interval_overrides", "panic_timeout", "panic_timeout_policy"}, newManager.reloadConfig)
+
 	machineInfo, err := getMachineInfo(sysfs, fsInfo, inHostNamespace)
 	if err != nil {
 		return nil, err
 	}
 	newManager.machineInfo = *machineInfo
-	glog.Infof("Machine: %+v", newManager.machineInfo)
+	newManager.sysFs = sysfs
+	if *storageCloudTags {
+		storage.SetMachineTags(cloudStorageTags(machineInfo))
+	}
+	logging.Infof("Machine: %+v", newManager.machineInfo)
+
+	if *housekeepingWorkers > 0 {
+		newManager.scheduler = newHousekeepingScheduler(*housekeepingWorkers)
+	}
+	if *adaptiveHousekeeping && *adaptiveHousekeepingInterval <= 0 {
+		return nil, fmt.Errorf("invalid --adaptive_housekeeping_interval %v, expected a positive duration", *adaptiveHousekeepingInterval)
+	}
+	if *maxSelfMemory > 0 && *selfMemoryCheckInterval <= 0 {
+		return nil, fmt.Errorf("invalid --self_memory_check_interval %v, expected a positive duration", *selfMemoryCheckInterval)
+	}
+	if *adaptiveHousekeeping || *maxSelfMemory > 0 {
+		newManager.housekeepingBackoff = newHousekeepingBackoff(*adaptiveHousekeepingCpuThreshold, *adaptiveHousekeepingLoadThreshold, machineInfo.NumCores)
+	}
 
 	versionInfo, err := getVersionInfo()
 	if err != nil {
 		return nil, err
 	}
-	glog.Infof("Version: %+v", *versionInfo)
+	logging.Infof("Version: %+v", *versionInfo)
 
-	newManager.eventHandler = events.NewEventManager(parseEventsStoragePolicy())
+	if *eventStorageFile != "" {
+		newManager.eventHandler, err = events.NewPersistentEventManager(parseEventsStoragePolicy(), *eventStorageFile, *eventStorageFileAgeLimit)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		newManager.eventHandler = events.NewEventManager(parseEventsStoragePolicy())
+	}
 	return newManager, nil
 }
 
@@ -199,11 +344,20 @@ type namespacedContainerName struct {
 }
 
 type manager struct {
+	// Incremented whenever containers are created, exit or are destroyed,
+	// and when their spec or the machine info changes. Starts from the time
+	// the manager is created in nanoseconds, which keeps it increasing
+	// across restarts. First for the alignment of atomic operations.
+	specRevision             uint64
 	containers               map[namespacedContainerName]*containerData
 	containersLock           sync.RWMutex
 	memoryCache              *memory.InMemoryCache
 	fsInfo                   fs.FsInfo
 	machineInfo              info.MachineInfo
+	machineInfoLock          sync.RWMutex
+	sysFs                    sysfs.SysFs
+	powerSampler             *powerSampler
+	thinPoolMonitor          *thinPoolMonitor
 	quitChannels             []chan error
 	cadvisorContainer        string
 	inHostNamespace          bool
@@ -212,28 +366,49 @@ type manager struct {
 	maxHousekeepingInterval  time.Duration
 	allowDynamicHousekeeping bool
 	ignoreMetrics            container.MetricSet
+	// Watches the memory cgroups of containers for OOMs, if enabled.
+	oomWatcher *oomparser.CgroupWatcher
+	// Times kernel log OOMs were last received at, keyed by container name.
+	kernelOoms     map[string]time.Time
+	kernelOomsLock sync.Mutex
+	// Pods of the node, if the Kubernetes integration is enabled.
+	pods *kubernetes.Pods
+	// Containers to monitor exclusively, and containers not to monitor. Nil
+	// if not set. Guarded by containersLock, like housekeepingOverrides.
+	whitelist *containerFilter
+	blacklist *containerFilter
+	// Names of the containers filtered out, which are not inspected again
+	// until they are deleted or the filters are reloaded.
+	rejected map[string]bool
+	// Per-container housekeeping intervals, from --housekeeping_interval_overrides.
+	housekeepingOverrides []housekeepingOverride
+	// Set if adaptive housekeeping is enabled.
+	housekeepingBackoff *housekeepingBackoff
+	// Runs the housekeeping of all containers, unless --housekeeping_workers is 0.
+	scheduler *housekeepingScheduler
+	// Set once the recovery of the containers completed.
+	recovered int32
+	// Staggers the first housekeepings of the containers created during
+	// the recovery, if enabled. Guarded by containersLock.
+	startup *startupStagger
+	// Whether the Docker factory is registered.
+	dockerRegistered bool
 }
 
 // Start the container manager.
 func (self *manager) Start() error {
-	err := docker.Register(self, self.fsInfo, self.ignoreMetrics)
+	// Fake containers replace the containers of the host, including the root.
+	err := fake.Register(self, self.fsInfo, self.ignoreMetrics)
 	if err != nil {
-		glog.Fatalf("Docker container factory registration failed: %v.", err)
+		logging.V(2).Infof("Registration of the fake container factory failed: %v", err)
 	}
 
-	err = rkt.Register(self, self.fsInfo, self.ignoreMetrics)
-	if err != nil {
-		glog.Errorf("Registration of the rkt container factory failed: %v", err)
+	runtimes := self.registerPlatformFactories()
+	if err == nil {
+		runtimes++
 	}
-
-	err = systemd.Register(self, self.fsInfo, self.ignoreMetrics)
-	if err != nil {
-		glog.Errorf("Registration of the systemd container factory failed: %v", err)
-	}
-
-	err = raw.Register(self, self.fsInfo, self.ignoreMetrics)
-	if err != nil {
-		glog.Errorf("Registration of the raw container factory failed: %v", err)
+	if runtimes == 0 {
+		return fmt.Errorf("no container runtime factory registered")
 	}
 
 	self.DockerInfo()
@@ -242,25 +417,59 @@ func (self *manager) Start() error {
 	// Watch for OOMs.
 	err = self.watchForNewOoms()
 	if err != nil {
-		glog.Warningf("Could not configure a source for OOM detection, disabling OOM events: %v", err)
+		logging.Warningf("Could not configure a source for OOM detection, disabling OOM events: %v", err)
+	}
+
+	if *storageDriverDerivedStats && !self.memoryCache.SupportsDerivedStats() {
+		logging.Warningf("The storage driver does not support derived stats, ignoring --storage_driver_derived_stats")
+	}
+
+	if *storageDriverEvents {
+		err = self.writeEventsToStorage()
+		if err != nil {
+			return err
+		}
+	}
+
+	err = events.StartWebhook(self.eventHandler)
+	if err != nil {
+		return err
+	}
+
+	self.pods, err = kubernetes.New()
+	if err != nil {
+		return err
+	}
+	if self.pods != nil {
+		quitPodsRefresh := make(chan error)
+		self.quitChannels = append(self.quitChannels, quitPodsRefresh)
+		go self.pods.RefreshLoop(quitPodsRefresh)
 	}
 
 	// If there are no factories, don't start any housekeeping and serve the information we do have.
 	if !container.HasFactories() {
+		atomic.StoreInt32(&self.recovered, 1)
 		return nil
 	}
 
+	if self.scheduler != nil {
+		quitScheduler := make(chan error)
+		self.quitChannels = append(self.quitChannels, quitScheduler)
+		go self.scheduler.loop(quitScheduler)
+	}
+
 	// Create root and then recover all containers.
 	err = self.createContainer("/")
 	if err != nil {
 		return err
 	}
-	glog.Infof("Starting recovery of all containers")
-	err = self.detectSubcontainers("/")
+	logging.Infof("Starting recovery of all containers")
+	err = self.recoverContainers()
 	if err != nil {
 		return err
 	}
-	glog.Infof("Recovery completed")
+	logging.Infof("Recovery completed")
+	atomic.StoreInt32(&self.recovered, 1)
 
 	// Watch for new container.
 	quitWatcher := make(chan error)
@@ -280,6 +489,38 @@ func (self *manager) Start() error {
 	self.quitChannels = append(self.quitChannels, quitFsInfoCacheManager)
 	go self.fsInfoCacheRefreshLoop(quitFsInfoCacheManager)
 
+	if *adaptiveHousekeeping {
+		quitAdaptiveHousekeeping := make(chan error)
+		self.quitChannels = append(self.quitChannels, quitAdaptiveHousekeeping)
+		go self.housekeepingBackoff.loop(quitAdaptiveHousekeeping)
+	}
+
+	if *maxSelfMemory > 0 {
+		quitSelfMemoryCheck := make(chan error)
+		self.quitChannels = append(self.quitChannels, quitSelfMemoryCheck)
+		go self.checkSelfMemoryLoop(uint64(*maxSelfMemory), *selfMemoryCheckInterval, quitSelfMemoryCheck)
+	}
+
+	if *energySampleInterval > 0 {
+		self.powerSampler = &powerSampler{}
+		quitEnergySampling := make(chan error)
+		self.quitChannels = append(self.quitChannels, quitEnergySampling)
+		go self.samplePowerLoop(*energySampleInterval, quitEnergySampling)
+	}
+
+	if *machineInfoRefreshInterval > 0 {
+		quitMachineInfoRefresh := make(chan error)
+		self.quitChannels = append(self.quitChannels, quitMachineInfoRefresh)
+		go self.refreshMachineInfoLoop(*machineInfoRefreshInterval, quitMachineInfoRefresh)
+	}
+
+	if *thinPoolCheckInterval > 0 && dmsetupAvailable() {
+		self.thinPoolMonitor = &thinPoolMonitor{exhausted: make(map[string]bool)}
+		quitThinPoolCheck := make(chan error)
+		self.quitChannels = append(self.quitChannels, quitThinPoolCheck)
+		go self.checkThinPoolsLoop(*thinPoolCheckInterval, quitThinPoolCheck)
+	}
+
 	return nil
 }
 
@@ -296,6 +537,21 @@ func (self *manager) Stop() error {
 		}
 	}
 	self.quitChannels = make([]chan error, 0, 3)
+
+	// Stop the housekeeping of all containers and flush their last stats.
+	self.containersLock.RLock()
+	stopped := make(map[*containerData]bool, len(self.containers))
+	for _, cont := range self.containers {
+		if stopped[cont] || !cont.exitTime().IsZero() {
+			continue
+		}
+		cont.stopHousekeeping()
+		stopped[cont] = true
+	}
+	self.containersLock.RUnlock()
+	if err := self.memoryCache.Flush(); err != nil {
+		return fmt.Errorf("failed to flush the storage driver: %v", err)
+	}
 	return nil
 }
 
@@ -308,7 +564,7 @@ func (self *manager) fsInfoCacheRefreshLoop(quit chan error) {
 			self.fsInfo.RefreshCache()
 		case <-quit:
 			quit <- nil
-			glog.Infof("Exiting fsInfoCacheRefreshLoop")
+			logging.Infof("Exiting fsInfoCacheRefreshLoop")
 			return
 		}
 	}
@@ -330,18 +586,33 @@ func (self *manager) globalHousekeeping(quit chan error) {
 			// Check for new containers.
 			err := self.detectSubcontainers("/")
 			if err != nil {
-				glog.Errorf("Failed to detect containers: %s", err)
+				logging.Errorf("Failed to detect containers: %s", err)
+			}
+			if *exitedContainerRetention > 0 {
+				self.removeExitedContainers()
+			}
+			// Drop the stats loaded from snapshots of the containers
+			// that no longer exist.
+			dropped := self.memoryCache.DropSnapshotContainers(func(name string) bool {
+				_, err := self.getContainerData(name)
+				return err == nil
+			})
+			if dropped > 0 {
+				logging.V(2).Infof("Dropped the snapshot stats of %d containers that no longer exist", dropped)
+			}
+			if *detectSharedNetworkNamespaces {
+				self.updateNetworkOwners()
 			}
 
 			// Log if housekeeping took too long.
 			duration := time.Since(start)
 			if duration >= longHousekeeping {
-				glog.V(3).Infof("Global Housekeeping(%d) took %s", t.Unix(), duration)
+				logging.V(3).Infof("Global Housekeeping(%d) took %s", t.Unix(), duration)
 			}
 		case <-quit:
 			// Quit if asked to do so.
 			quit <- nil
-			glog.Infof("Exiting global housekeeping thread")
+			logging.Infof("Exiting global housekeeping thread")
 			return
 		}
 	}
@@ -381,6 +652,22 @@ func (self *manager) GetDerivedStats(containerName string, options v2.RequestOpt
 	return stats, nil
 }
 
+func (self *manager) GetPredictions(containerName string, options v2.RequestOptions) (map[string]v2.Prediction, error) {
+	conts, err := self.getRequestedContainers(containerName, options)
+	if err != nil {
+		return nil, err
+	}
+	predictions := make(map[string]v2.Prediction)
+	for name, cont := range conts {
+		p, err := cont.Prediction()
+		if err != nil {
+			return nil, err
+		}
+		predictions[name] = p
+	}
+	return predictions, nil
+}
+
 func (self *manager) GetContainerSpec(containerName string, options v2.RequestOptions) (map[string]v2.ContainerSpec, error) {
 	conts, err := self.getRequestedContainers(containerName, options)
 	if err != nil {
@@ -388,6 +675,9 @@ func (self *manager) GetContainerSpec(containerName string, options v2.RequestOp
 	}
 	specs := make(map[string]v2.ContainerSpec)
 	for name, cont := range conts {
+		if cont.Revision() <= options.Since {
+			continue
+		}
 		cinfo, err := cont.GetInfo()
 		if err != nil {
 			return nil, err
@@ -595,17 +885,43 @@ func (self *manager) GetRequestedContainersInfo(containerName string, options v2
 	query := info.ContainerInfoRequest{
 		NumStats: options.Count,
 	}
+	if options.Since != 0 {
+		query.NumStats = 0
+	}
 	for name, data := range containers {
 		info, err := self.containerDataToContainerInfo(data, &query)
 		if err != nil {
 			// Skip containers with errors, we try to degrade gracefully.
 			continue
 		}
+		if options.Since != 0 {
+			// Only the stats added since, and the containers with some.
+			info.Stats, err = self.memoryCache.StatsSince(info.Name, options.Since, options.Count)
+			if err != nil || len(info.Stats) == 0 {
+				continue
+			}
+		}
+		if options.Computed {
+			info.Stats = self.computeStats(info.Subcontainers, info.Stats)
+		}
 		containersMap[name] = info
 	}
 	return containersMap, nil
 }
 
+func (self *manager) GetRequestedContainerNames(containerName string, options v2.RequestOptions) ([]string, error) {
+	containers, err := self.getRequestedContainers(containerName, options)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(containers))
+	for name := range containers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
 func (self *manager) getRequestedContainers(containerName string, options v2.RequestOptions) (map[string]*containerData, error) {
 	containersMap := make(map[string]*containerData)
 	switch options.IdType {
@@ -684,7 +1000,14 @@ func (self *manager) GetFsInfo(label string) ([]v2.FsInfo, error) {
 
 func (m *manager) GetMachineInfo() (*info.MachineInfo, error) {
 	// Copy and return the MachineInfo.
-	return &m.machineInfo, nil
+	m.machineInfoLock.RLock()
+	defer m.machineInfoLock.RUnlock()
+	machineInfo := m.machineInfo
+	return &machineInfo, nil
+}
+
+func (m *manager) Revisions() (specs, stats uint64) {
+	return atomic.LoadUint64(&m.specRevision), m.memoryCache.Revision()
 }
 
 func (m *manager) GetVersionInfo() (*info.VersionInfo, error) {
@@ -737,28 +1060,28 @@ func (m *manager) registerCollectors(collectorConfigs map[string]string, cont *c
 		if err != nil {
 			return fmt.Errorf("failed to read config file %q for config %q, container %q: %v", k, v, cont.info.Name, err)
 		}
-		glog.V(3).Infof("Got config from %q: %q", v, configFile)
+		logging.V(3).Infof("Got config from %q: %q", v, configFile)
 
 		if strings.HasPrefix(k, "prometheus") || strings.HasPrefix(k, "Prometheus") {
 			newCollector, err := collector.NewPrometheusCollector(k, configFile, *applicationMetricsCountLimit)
 			if err != nil {
-				glog.Infof("failed to create collector for container %q, config %q: %v", cont.info.Name, k, err)
+				logging.Infof("failed to create collector for container %q, config %q: %v", cont.info.Name, k, err)
 				return err
 			}
 			err = cont.collectorManager.RegisterCollector(newCollector)
 			if err != nil {
-				glog.Infof("failed to register collector for container %q, config %q: %v", cont.info.Name, k, err)
+				logging.Infof("failed to register collector for container %q, config %q: %v", cont.info.Name, k, err)
 				return err
 			}
 		} else {
 			newCollector, err := collector.NewCollector(k, configFile, *applicationMetricsCountLimit)
 			if err != nil {
-				glog.Infof("failed to create collector for container %q, config %q: %v", cont.info.Name, k, err)
+				logging.Infof("failed to create collector for container %q, config %q: %v", cont.info.Name, k, err)
 				return err
 			}
 			err = cont.collectorManager.RegisterCollector(newCollector)
 			if err != nil {
-				glog.Infof("failed to register collector for container %q, config %q: %v", cont.info.Name, k, err)
+				logging.Infof("failed to register collector for container %q, config %q: %v", cont.info.Name, k, err)
 				return err
 			}
 		}
@@ -768,15 +1091,32 @@ func (m *manager) registerCollectors(collectorConfigs map[string]string, cont *c
 
 // Create a container.
 func (m *manager) createContainer(containerName string) error {
-	m.containersLock.Lock()
-	defer m.containersLock.Unlock()
+	return m.createWatchedContainer(containerName, time.Time{})
+}
 
+// Creates a container whose watch event was received at the given time, if
+// not zero, recording the latencies of its creation and first stats.
+func (m *manager) createWatchedContainer(containerName string, appeared time.Time) error {
 	namespacedName := namespacedContainerName{
 		Name: containerName,
 	}
 
-	// Check that the container didn't already exist.
-	if _, ok := m.containers[namespacedName]; ok {
+	// Check that the container didn't already exist. The container is
+	// inspected without holding the lock, so that the creation of other
+	// containers proceeds meanwhile.
+	m.containersLock.RLock()
+	existing, ok := m.containers[namespacedName]
+	rejected := m.rejected[containerName]
+	blacklist := m.blacklist
+	m.containersLock.RUnlock()
+	if (ok && existing.exitTime().IsZero()) || rejected {
+		return nil
+	}
+
+	// Skip blacklisted names before inspecting the container.
+	if blacklist != nil && containerName != "/" && blacklist.matchesName(containerName) {
+		logging.V(4).Infof("ignoring blacklisted container %q", containerName)
+		m.reject(containerName)
 		return nil
 	}
 
@@ -786,7 +1126,7 @@ func (m *manager) createContainer(containerName string) error {
 	}
 	if !accept {
 		// ignoring this container.
-		glog.V(4).Infof("ignoring container %q", containerName)
+		logging.V(4).Infof("ignoring container %q", containerName)
 		return nil
 	}
 	collectorManager, err := collector.NewCollectorManager()
@@ -800,12 +1140,61 @@ func (m *manager) createContainer(containerName string) error {
 		return err
 	}
 
+	if !m.monitors(cont) {
+		logging.V(4).Infof("ignoring filtered out container %q", containerName)
+		m.reject(containerName)
+		return nil
+	}
+
+	m.containersLock.Lock()
+	defer m.containersLock.Unlock()
+	if existing, ok := m.containers[namespacedName]; ok {
+		if existing.exitTime().IsZero() {
+			// Created concurrently while the container was inspected.
+			return nil
+		}
+		// A new container reuses the name of an exited one.
+		if err := m.removeContainer(namespacedName, existing); err != nil {
+			return err
+		}
+	}
+
+	if interval := housekeepingIntervalOf(&cont.info, m.housekeepingOverrides); interval != cont.baseHousekeepingInterval {
+		logging.V(3).Infof("Housekeeping interval of %q: %v", containerName, interval)
+		cont.setHousekeepingInterval(interval)
+	}
+	cont.backoff = m.housekeepingBackoff
+	cont.scheduler = m.scheduler
+	cont.onTimeout = m.addHousekeepingTimeoutEvent
+	cont.onSpecChange = m.addSpecChangeEvents
+	cont.specRevision = &m.specRevision
+	if m.startup != nil {
+		m.startup.stagger(cont)
+	}
+	if !m.ignoreMetrics.Has(container.SchedulerMetrics) {
+		procRoot := "/proc"
+		if !m.inHostNamespace {
+			procRoot = "/rootfs/proc"
+		}
+		cont.schedstatReader = newSchedstatReader(procRoot)
+	}
+
+	if *anomalyDetection {
+		cont.enableAnomalyDetection(m.eventHandler)
+	}
+
+	if m.pods != nil {
+		if pod := m.pods.PodOf(&cont.info.ContainerReference); pod != nil {
+			cont.info.Labels = kubernetes.Labels(cont.info.Labels, pod)
+		}
+	}
+
 	// Add collectors
 	labels := handler.GetContainerLabels()
 	collectorConfigs := collector.GetCollectorConfigs(labels)
 	err = m.registerCollectors(collectorConfigs, cont)
 	if err != nil {
-		glog.Infof("failed to register collectors for %q: %v", containerName, err)
+		logging.Infof("failed to register collectors for %q: %v", containerName, err)
 	}
 
 	// Add the container name and all its aliases. The aliases must be within the namespace of the factory.
@@ -817,7 +1206,8 @@ func (m *manager) createContainer(containerName string) error {
 		}] = cont
 	}
 
-	glog.V(3).Infof("Added container: %q (aliases: %v, namespace: %q)", containerName, cont.info.Aliases, cont.info.Namespace)
+	cont.bumpRevision()
+	logging.V(3).Infof("Added container: %q (aliases: %v, namespace: %q)", containerName, cont.info.Aliases, cont.info.Namespace)
 
 	contSpec, err := cont.handler.GetSpec()
 	if err != nil {
@@ -839,6 +1229,19 @@ func (m *manager) createContainer(containerName string) error {
 		return err
 	}
 
+	if m.oomWatcher != nil {
+		if memoryCgroup, err := cont.handler.GetCgroupPath("memory"); err == nil {
+			if err := m.oomWatcher.Watch(contRef.Name, memoryCgroup); err != nil {
+				logging.V(2).Infof("Failed to watch %q for OOMs: %v", contRef.Name, err)
+			}
+		}
+	}
+
+	if !appeared.IsZero() {
+		ContainerCreationLatency.Observe(time.Since(appeared).Seconds())
+		cont.appeared = appeared
+	}
+
 	// Start the container's housekeeping.
 	return cont.Start()
 }
@@ -850,18 +1253,142 @@ func (m *manager) destroyContainer(containerName string) error {
 	namespacedName := namespacedContainerName{
 		Name: containerName,
 	}
+	delete(m.rejected, containerName)
 	cont, ok := m.containers[namespacedName]
-	if !ok {
-		// Already destroyed, done.
+	if !ok || !cont.exitTime().IsZero() {
+		// Already destroyed or exited, done.
 		return nil
 	}
+	m.forgetKernelOoms(containerName)
+	if *exitedContainerRetention > 0 {
+		return m.exitContainer(cont)
+	}
 
 	// Tell the container to stop.
 	err := cont.Stop()
 	if err != nil {
 		return err
 	}
+	if m.oomWatcher != nil {
+		m.oomWatcher.StopWatching(cont.info.Name)
+	}
+	return m.forgetContainer(namespacedName, cont)
+}
 
+// Validates the reloaded container filters and housekeeping settings, which
+// apply to the containers created from now on, and the panic timeout, which
+// applies to the next housekeepings.
+func (m *manager) reloadConfig(value func(name string) string) (func(), error) {
+	whitelist, err := parseContainerFilter(value("container_whitelist"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid --container_whitelist: %v", err)
+	}
+	blacklist, err := parseContainerFilter(value("container_blacklist"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid --container_blacklist: %v", err)
+	}
+	overrides, err := parseHousekeepingOverrides(value("housekeeping_interval_overrides"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid --housekeeping_interval_overrides: %v", err)
+	}
+	settings, err := parseHousekeepingSettings(value)
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		currentHousekeepingSettings.Store(settings)
+		m.containersLock.Lock()
+		defer m.containersLock.Unlock()
+		m.whitelist, m.blacklist, m.housekeepingOverrides = whitelist, blacklist, overrides
+		// The new filters may accept the containers rejected so far.
+		m.rejected = nil
+	}, nil
+}
+
+// Records that the container is filtered out.
+func (m *manager) reject(containerName string) {
+	m.containersLock.Lock()
+	defer m.containersLock.Unlock()
+	if m.rejected == nil {
+		m.rejected = make(map[string]bool)
+	}
+	m.rejected[containerName] = true
+}
+
+// Returns whether the container passes --container_whitelist and
+// --container_blacklist. The root container is always monitored.
+func (m *manager) monitors(cont *containerData) bool {
+	if cont.info.Name == "/" {
+		return true
+	}
+	// A reload replaces the filters under containersLock.
+	m.containersLock.RLock()
+	whitelist, blacklist := m.whitelist, m.blacklist
+	m.containersLock.RUnlock()
+	if whitelist != nil && !whitelist.matches(cont) {
+		return false
+	}
+	return blacklist == nil || !blacklist.matches(cont)
+}
+
+// Stops the housekeeping of the container but keeps it for
+// --exited_container_retention. Must be called with containersLock held.
+func (m *manager) exitContainer(cont *containerData) error {
+	exitTime := time.Now()
+	stats, err := cont.Exit(exitTime)
+	cont.bumpRevision()
+	if err != nil {
+		logging.V(2).Infof("Failed to get the final stats of %q: %v", cont.info.Name, err)
+	}
+	if m.oomWatcher != nil {
+		m.oomWatcher.StopWatching(cont.info.Name)
+	}
+	logging.V(3).Infof("Container exited: %q (aliases: %v, namespace: %q)", cont.info.Name, cont.info.Aliases, cont.info.Namespace)
+
+	return m.eventHandler.AddEvent(&info.Event{
+		ContainerName: cont.info.Name,
+		Timestamp:     exitTime,
+		EventType:     info.EventContainerExit,
+		EventData: info.EventData{
+			ContainerExit: &info.ContainerExitEventData{
+				Stats: stats,
+			},
+		},
+	})
+}
+
+// Removes the exited containers retained for longer than
+// --exited_container_retention.
+func (m *manager) removeExitedContainers() {
+	m.containersLock.Lock()
+	defer m.containersLock.Unlock()
+
+	for name, cont := range m.containers {
+		// Aliases are removed with the container.
+		if name.Namespace != "" {
+			continue
+		}
+		exitTime := cont.exitTime()
+		if exitTime.IsZero() || time.Since(exitTime) < *exitedContainerRetention {
+			continue
+		}
+		if err := m.removeContainer(name, cont); err != nil {
+			logging.Errorf("Failed to remove exited container %q: %v", name.Name, err)
+		}
+	}
+}
+
+// Forgets an exited container. Must be called with containersLock held.
+func (m *manager) removeContainer(namespacedName namespacedContainerName, cont *containerData) error {
+	if err := cont.Remove(); err != nil {
+		return err
+	}
+	return m.forgetContainer(namespacedName, cont)
+}
+
+// Removes the stopped container from our records and emits its deletion event.
+// Must be called with containersLock held.
+func (m *manager) forgetContainer(namespacedName namespacedContainerName, cont *containerData) error {
 	// Remove the container from our records (and all its aliases).
 	delete(m.containers, namespacedName)
 	for _, alias := range cont.info.Aliases {
@@ -870,7 +1397,8 @@ func (m *manager) destroyContainer(containerName string) error {
 			Name:      alias,
 		})
 	}
-	glog.V(3).Infof("Destroyed container: %q (aliases: %v, namespace: %q)", containerName, cont.info.Aliases, cont.info.Namespace)
+	atomic.AddUint64(&m.specRevision, 1)
+	logging.V(3).Infof("Destroyed container: %q (aliases: %v, namespace: %q)", namespacedName.Name, cont.info.Aliases, cont.info.Namespace)
 
 	contRef, err := cont.handler.ContainerReference()
 	if err != nil {
@@ -916,9 +1444,21 @@ func (m *manager) getContainersDiff(containerName string) (added []info.Containe
 		}
 	}
 
+	// The rejected containers below this one, which are not added again.
+	rejectedSet := make(map[string]bool)
+	for name := range m.rejected {
+		if containerName == "/" || name == containerName || strings.HasPrefix(name, containerName+"/") {
+			rejectedSet[name] = true
+		}
+	}
+
 	// Added containers
 	for _, c := range allContainers {
 		delete(allContainersSet, c.Name)
+		if rejectedSet[c.Name] {
+			delete(rejectedSet, c.Name)
+			continue
+		}
 		_, ok := m.containers[namespacedContainerName{
 			Name: c.Name,
 		}]
@@ -927,10 +1467,14 @@ func (m *manager) getContainersDiff(containerName string) (added []info.Containe
 		}
 	}
 
-	// Removed ones are no longer in the container listing.
+	// Removed ones are no longer in the container listing. Destroying the
+	// rejected ones forgets them.
 	for _, d := range allContainersSet {
 		removed = append(removed, d.info.ContainerReference)
 	}
+	for name := range rejectedSet {
+		removed = append(removed, info.ContainerReference{Name: name})
+	}
 
 	return
 }
@@ -946,7 +1490,7 @@ func (m *manager) detectSubcontainers(containerName string) error {
 	for _, cont := range added {
 		err = m.createContainer(cont.Name)
 		if err != nil {
-			glog.Errorf("Failed to create existing container: %s: %s", cont.Name, err)
+			logging.Errorf("Failed to create existing container: %s: %s", cont.Name, err)
 		}
 	}
 
@@ -954,13 +1498,50 @@ func (m *manager) detectSubcontainers(containerName string) error {
 	for _, cont := range removed {
 		err = m.destroyContainer(cont.Name)
 		if err != nil {
-			glog.Errorf("Failed to destroy existing container: %s: %s", cont.Name, err)
+			logging.Errorf("Failed to destroy existing container: %s: %s", cont.Name, err)
 		}
 	}
 
 	return nil
 }
 
+// Emits an event for the transition of the health check status of the
+// container.
+func (self *manager) addHealthEvent(containerName, status string) {
+	err := self.eventHandler.AddEvent(&info.Event{
+		ContainerName: containerName,
+		Timestamp:     time.Now(),
+		EventType:     info.EventContainerHealth,
+		EventData: info.EventData{
+			Health: &info.HealthEventData{
+				Status: status,
+			},
+		},
+	})
+	if err != nil {
+		logging.Errorf("failed to add health event for %q: %v", containerName, err)
+	}
+}
+
+// Counts the housekeeping timeouts of the containers degraded by
+// --panic_timeout_policy=degrade, and emits an event for each.
+func (self *manager) addHousekeepingTimeoutEvent(containerName string, timeout time.Duration) {
+	HousekeepingTimeouts.WithLabelValues(containerName).Inc()
+	err := self.eventHandler.AddEvent(&info.Event{
+		ContainerName: containerName,
+		Timestamp:     time.Now(),
+		EventType:     info.EventHousekeepingTimeout,
+		EventData: info.EventData{
+			HousekeepingTimeout: &info.HousekeepingTimeoutEventData{
+				Timeout: timeout,
+			},
+		},
+	})
+	if err != nil {
+		logging.Errorf("failed to add housekeeping timeout event for %q: %v", containerName, err)
+	}
+}
+
 // Watches for new containers started in the system. Runs forever unless there is a setup error.
 func (self *manager) watchForNewContainers(quit chan error) error {
 	var root *containerData
@@ -982,6 +1563,10 @@ func (self *manager) watchForNewContainers(quit chan error) error {
 	if err != nil {
 		return err
 	}
+	err = docker.WatchEvents(eventsChannel, self.addHealthEvent, self.inHostNamespace)
+	if err != nil {
+		logging.Warningf("Docker containers will only be discovered from their cgroups: %v", err)
+	}
 
 	// There is a race between starting the watch and new container creation so we do a detection before we read new containers.
 	err = self.detectSubcontainers("/")
@@ -989,26 +1574,21 @@ func (self *manager) watchForNewContainers(quit chan error) error {
 		return err
 	}
 
-	// Listen to events from the container handler.
+	// Listen to events from the container handler, processed by the workers
+	// of the queue.
+	queue := newContainerEventQueue(*containerEventDebounce, *containerEventWorkers, self.processContainerEvents)
 	go func() {
 		for {
 			select {
 			case event := <-eventsChannel:
-				switch {
-				case event.EventType == container.SubcontainerAdd:
-					err = self.createContainer(event.Name)
-				case event.EventType == container.SubcontainerDelete:
-					err = self.destroyContainer(event.Name)
-				}
-				if err != nil {
-					glog.Warningf("Failed to process watch event: %v", err)
-				}
+				queue.add(event)
 			case <-quit:
 				// Stop processing events if asked to quit.
 				err := root.handler.StopWatchingSubcontainers()
 				quit <- err
 				if err == nil {
-					glog.Infof("Exiting thread watching subcontainers")
+					queue.stop()
+					logging.Infof("Exiting thread watching subcontainers")
 					return
 				}
 			}
@@ -1018,16 +1598,42 @@ func (self *manager) watchForNewContainers(quit chan error) error {
 }
 
 func (self *manager) watchForNewOoms() error {
-	glog.Infof("Started watching for new ooms in manager")
+	logging.Infof("Started watching for new ooms in manager")
+	self.kernelOoms = make(map[string]time.Time)
+	if *oomCgroupNotifications {
+		cgroupStream := make(chan *oomparser.CgroupOom, 10)
+		watcher, err := oomparser.NewCgroupWatcher(cgroupStream)
+		if err != nil {
+			logging.Warningf("Could not watch memory cgroups for OOMs: %v", err)
+		} else {
+			self.oomWatcher = watcher
+			go func() {
+				for oom := range cgroupStream {
+					go self.addCgroupOom(oom)
+				}
+			}()
+		}
+	}
+
 	outStream := make(chan *oomparser.OomInstance, 10)
 	oomLog, err := oomparser.New()
 	if err != nil {
+		if self.oomWatcher != nil {
+			logging.Warningf("Could not read the kernel log, OOM kill events will not report the killed process: %v", err)
+			return nil
+		}
 		return err
 	}
 	go oomLog.StreamOoms(outStream)
 
 	go func() {
 		for oomInstance := range outStream {
+			self.kernelOomsLock.Lock()
+			now := time.Now()
+			self.kernelOoms[oomInstance.ContainerName] = now
+			self.kernelOoms[oomInstance.VictimContainerName] = now
+			self.kernelOomsLock.Unlock()
+
 			// Surface OOM and OOM kill events.
 			newEvent := &info.Event{
 				ContainerName: oomInstance.ContainerName,
@@ -1036,9 +1642,9 @@ func (self *manager) watchForNewOoms() error {
 			}
 			err := self.eventHandler.AddEvent(newEvent)
 			if err != nil {
-				glog.Errorf("failed to add OOM event for %q: %v", oomInstance.ContainerName, err)
+				logging.Errorf("failed to add OOM event for %q: %v", oomInstance.ContainerName, err)
 			}
-			glog.V(3).Infof("Created an OOM event in container %q at %v", oomInstance.ContainerName, oomInstance.TimeOfDeath)
+			logging.V(3).Infof("Created an OOM event in container %q at %v", oomInstance.ContainerName, oomInstance.TimeOfDeath)
 
 			newEvent = &info.Event{
 				ContainerName: oomInstance.VictimContainerName,
@@ -1053,13 +1659,72 @@ func (self *manager) watchForNewOoms() error {
 			}
 			err = self.eventHandler.AddEvent(newEvent)
 			if err != nil {
-				glog.Errorf("failed to add OOM kill event for %q: %v", oomInstance.ContainerName, err)
+				logging.Errorf("failed to add OOM kill event for %q: %v", oomInstance.ContainerName, err)
 			}
 		}
 	}()
 	return nil
 }
 
+// How long the kernel log is given to report an OOM notified by a memory
+// cgroup, with the killed process.
+const kernelOomGracePeriod = 2 * time.Second
+
+// Forgets when the kernel log last reported an OOM of the destroyed container,
+// once the memory cgroup notifications it covers have been handled.
+func (self *manager) forgetKernelOoms(containerName string) {
+	self.kernelOomsLock.Lock()
+	last, ok := self.kernelOoms[containerName]
+	self.kernelOomsLock.Unlock()
+	if !ok {
+		return
+	}
+	time.AfterFunc(kernelOomGracePeriod, func() {
+		self.kernelOomsLock.Lock()
+		defer self.kernelOomsLock.Unlock()
+		// Keep the OOMs of a new container with the same name.
+		if self.kernelOoms[containerName] == last {
+			delete(self.kernelOoms, containerName)
+		}
+	})
+}
+
+// Surfaces an OOM notified by the memory cgroup of a container unless the
+// kernel log reported it.
+func (self *manager) addCgroupOom(oom *oomparser.CgroupOom) {
+	time.Sleep(kernelOomGracePeriod)
+	self.kernelOomsLock.Lock()
+	last, ok := self.kernelOoms[oom.ContainerName]
+	self.kernelOomsLock.Unlock()
+	if ok && !last.Before(oom.Time.Add(-kernelOomGracePeriod)) {
+		return
+	}
+
+	err := self.eventHandler.AddEvent(&info.Event{
+		ContainerName: oom.ContainerName,
+		Timestamp:     oom.Time,
+		EventType:     info.EventOom,
+	})
+	if err != nil {
+		logging.Errorf("failed to add OOM event for %q: %v", oom.ContainerName, err)
+	}
+	logging.V(3).Infof("Created an OOM event in container %q at %v from its memory cgroup", oom.ContainerName, oom.Time)
+	if !oom.Killed {
+		return
+	}
+	err = self.eventHandler.AddEvent(&info.Event{
+		ContainerName: oom.ContainerName,
+		Timestamp:     oom.Time,
+		EventType:     info.EventOomKill,
+		EventData: info.EventData{
+			OomKill: &info.OomKillEventData{},
+		},
+	})
+	if err != nil {
+		logging.Errorf("failed to add OOM kill event for %q: %v", oom.ContainerName, err)
+	}
+}
+
 // can be called by the api which will take events returned on the channel
 func (self *manager) WatchForEvents(request *events.Request) (*events.EventChannel, error) {
 	return self.eventHandler.WatchEvents(request)
@@ -1075,6 +1740,42 @@ func (self *manager) CloseEventChannel(watch_id int) {
 	self.eventHandler.StopWatch(watch_id)
 }
 
+// can be called by the api which will take stats samples returned on the channel
+func (self *manager) WatchForStats(containerName string, options v2.RequestOptions) (*memory.StatsChannel, error) {
+	var filter memory.StatsFilter
+	switch {
+	case options.IdType == v2.TypeDocker && options.Recursive:
+		if containerName != "/" {
+			return nil, fmt.Errorf("invalid request for docker container %q with subcontainers", containerName)
+		}
+		filter = func(ref info.ContainerReference) bool {
+			return ref.Namespace == docker.DockerNamespace
+		}
+	case options.Recursive:
+		filter = func(ref info.ContainerReference) bool {
+			return containerName == "/" || strings.HasPrefix(ref.Name+"/", containerName+"/")
+		}
+	default:
+		conts, err := self.getRequestedContainers(containerName, options)
+		if err != nil {
+			return nil, err
+		}
+		names := make(map[string]bool, len(conts))
+		for name := range conts {
+			names[name] = true
+		}
+		filter = func(ref info.ContainerReference) bool {
+			return names[ref.Name]
+		}
+	}
+	return self.memoryCache.WatchStats(filter), nil
+}
+
+// called by the api when a client is no longer listening to the stats channel
+func (self *manager) CloseStatsChannel(watchId int) {
+	self.memoryCache.StopWatch(watchId)
+}
+
 // Parses the events StoragePolicy from the flags.
 func parseEventsStoragePolicy() events.StoragePolicy {
 	policy := events.DefaultStoragePolicy()
@@ -1084,12 +1785,12 @@ func parseEventsStoragePolicy() events.StoragePolicy {
 	for _, part := range parts {
 		items := strings.Split(part, "=")
 		if len(items) != 2 {
-			glog.Warningf("Unknown event storage policy %q when parsing max age", part)
+			logging.Warningf("Unknown event storage policy %q when parsing max age", part)
 			continue
 		}
 		dur, err := time.ParseDuration(items[1])
 		if err != nil {
-			glog.Warningf("Unable to parse event max age duration %q: %v", items[1], err)
+			logging.Warningf("Unable to parse event max age duration %q: %v", items[1], err)
 			continue
 		}
 		if items[0] == "default" {
@@ -1104,12 +1805,12 @@ func parseEventsStoragePolicy() events.StoragePolicy {
 	for _, part := range parts {
 		items := strings.Split(part, "=")
 		if len(items) != 2 {
-			glog.Warningf("Unknown event storage policy %q when parsing max event limit", part)
+			logging.Warningf("Unknown event storage policy %q when parsing max event limit", part)
 			continue
 		}
 		val, err := strconv.Atoi(items[1])
 		if err != nil {
-			glog.Warningf("Unable to parse integer from %q: %v", items[1], err)
+			logging.Warningf("Unable to parse integer from %q: %v", items[1], err)
 			continue
 		}
 		if items[0] == "default" {
@@ -17,6 +17,10 @@
 package manager
 
 import (
+	"io"
+	"time"
+
+	"github.com/google/cadvisor/cache/memory"
 	"github.com/google/cadvisor/events"
 	info "github.com/google/cadvisor/info/v1"
 	"github.com/google/cadvisor/info/v2"
@@ -63,16 +67,76 @@ func (c *ManagerMock) GetContainerSpec(containerName string, options v2.RequestO
 	return args.Get(0).(map[string]v2.ContainerSpec), args.Error(1)
 }
 
+func (c *ManagerMock) GetContainerHealth(containerName string, options v2.RequestOptions) (map[string]v2.ContainerHealth, error) {
+	args := c.Called(containerName, options)
+	return args.Get(0).(map[string]v2.ContainerHealth), args.Error(1)
+}
+
+func (c *ManagerMock) GetContainerSpecHistory(containerName string, options v2.RequestOptions) (map[string][]v2.ContainerSpecVersion, error) {
+	args := c.Called(containerName, options)
+	return args.Get(0).(map[string][]v2.ContainerSpecVersion), args.Error(1)
+}
+
 func (c *ManagerMock) GetDerivedStats(containerName string, options v2.RequestOptions) (map[string]v2.DerivedStats, error) {
 	args := c.Called(containerName, options)
 	return args.Get(0).(map[string]v2.DerivedStats), args.Error(1)
 }
 
+func (c *ManagerMock) GetSummaries(containerName string, options v2.RequestOptions) (map[string]v2.ContainerSummary, error) {
+	args := c.Called(containerName, options)
+	return args.Get(0).(map[string]v2.ContainerSummary), args.Error(1)
+}
+
+func (c *ManagerMock) GetPredictions(containerName string, options v2.RequestOptions) (map[string]v2.Prediction, error) {
+	args := c.Called(containerName, options)
+	return args.Get(0).(map[string]v2.Prediction), args.Error(1)
+}
+
+func (c *ManagerMock) GetRecommendations(containerName string, options v2.RequestOptions, window string, headroom float64) (map[string]v2.Recommendation, error) {
+	args := c.Called(containerName, options, window, headroom)
+	return args.Get(0).(map[string]v2.Recommendation), args.Error(1)
+}
+
+func (c *ManagerMock) GetPods(namespace string, window time.Duration) ([]v2.PodUsage, error) {
+	args := c.Called(namespace, window)
+	return args.Get(0).([]v2.PodUsage), args.Error(1)
+}
+
+func (c *ManagerMock) GetHostStats() (v2.HostStats, error) {
+	args := c.Called()
+	return args.Get(0).(v2.HostStats), args.Error(1)
+}
+
+func (c *ManagerMock) GetImages() ([]v2.Image, error) {
+	args := c.Called()
+	return args.Get(0).([]v2.Image), args.Error(1)
+}
+
+func (c *ManagerMock) Healthz() []v2.HealthCheck {
+	args := c.Called()
+	return args.Get(0).([]v2.HealthCheck)
+}
+
+func (c *ManagerMock) Readyz() []v2.HealthCheck {
+	args := c.Called()
+	return args.Get(0).([]v2.HealthCheck)
+}
+
+func (c *ManagerMock) GetTopContainers(containerName string, options v2.RequestOptions, by string, window time.Duration) ([]v2.ContainerUsage, error) {
+	args := c.Called(containerName, options, by, window)
+	return args.Get(0).([]v2.ContainerUsage), args.Error(1)
+}
+
 func (c *ManagerMock) GetRequestedContainersInfo(containerName string, options v2.RequestOptions) (map[string]*info.ContainerInfo, error) {
 	args := c.Called(containerName, options)
 	return args.Get(0).(map[string]*info.ContainerInfo), args.Error(1)
 }
 
+func (c *ManagerMock) GetRequestedContainerNames(containerName string, options v2.RequestOptions) ([]string, error) {
+	args := c.Called(containerName, options)
+	return args.Get(0).([]string), args.Error(1)
+}
+
 func (c *ManagerMock) Exists(name string) bool {
 	args := c.Called(name)
 	return args.Get(0).(bool)
@@ -88,6 +152,15 @@ func (c *ManagerMock) GetPastEvents(queryuest *events.Request) ([]*info.Event, e
 	return args.Get(0).([]*info.Event), args.Error(1)
 }
 
+func (c *ManagerMock) WatchForStats(containerName string, options v2.RequestOptions) (*memory.StatsChannel, error) {
+	args := c.Called(containerName, options)
+	return args.Get(0).(*memory.StatsChannel), args.Error(1)
+}
+
+func (c *ManagerMock) CloseStatsChannel(watchId int) {
+	c.Called(watchId)
+}
+
 func (c *ManagerMock) GetMachineInfo() (*info.MachineInfo, error) {
 	args := c.Called()
 	return args.Get(0).(*info.MachineInfo), args.Error(1)
@@ -117,3 +190,38 @@ func (c *ManagerMock) DockerImages() ([]DockerImage, error) {
 	args := c.Called()
 	return args.Get(0).([]DockerImage), args.Error(1)
 }
+
+func (c *ManagerMock) WriteSnapshot(w io.Writer) error {
+	args := c.Called(w)
+	return args.Error(0)
+}
+
+func (c *ManagerMock) RestoreSnapshot(r io.Reader, maxSize int64) (int, error) {
+	args := c.Called(r, maxSize)
+	return args.Int(0), args.Error(1)
+}
+
+func (c *ManagerMock) HistoryAvailable() bool {
+	args := c.Called()
+	return args.Bool(0)
+}
+
+func (c *ManagerMock) GetHistoricalStats(containerName string, start, end time.Time, step time.Duration) (v2.ContainerInfo, error) {
+	args := c.Called(containerName, start, end, step)
+	return args.Get(0).(v2.ContainerInfo), args.Error(1)
+}
+
+func (c *ManagerMock) SignalProcess(containerName string, pid int, signal string) error {
+	args := c.Called(containerName, pid, signal)
+	return args.Error(0)
+}
+
+func (c *ManagerMock) Revisions() (uint64, uint64) {
+	args := c.Called()
+	return args.Get(0).(uint64), args.Get(1).(uint64)
+}
+
+func (c *ManagerMock) GetSelfStats() (v2.SelfStats, error) {
+	args := c.Called()
+	return args.Get(0).(v2.SelfStats), args.Error(1)
+}
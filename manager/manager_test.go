@@ -26,8 +26,12 @@ import (
 	"github.com/google/cadvisor/collector"
 	"github.com/google/cadvisor/container"
 	"github.com/google/cadvisor/container/docker"
+	"github.com/google/cadvisor/events"
+	"github.com/google/cadvisor/fs"
 	info "github.com/google/cadvisor/info/v1"
 	itest "github.com/google/cadvisor/info/v1/test"
+	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/utils/sysfs"
 	"github.com/google/cadvisor/utils/sysfs/fakesysfs"
 )
 
@@ -144,6 +148,9 @@ func TestGetContainerInfo(t *testing.T) {
 		handler.AssertExpectations(t)
 		returned := returnedInfos[container]
 		expected := infosMap[container]
+		// The limits of the random spec the container was created with
+		// changed.
+		expected.Spec.Generation = 1
 		if !reflect.DeepEqual(returned, expected) {
 			t.Errorf("returned unexpected info for container %v; returned %+v; expected %+v", container, returned, expected)
 		}
@@ -210,3 +217,613 @@ func TestNewNilManager(t *testing.T) {
 		t.Fatalf("Expected nil manager to return error")
 	}
 }
+
+func TestContainerUsage(t *testing.T) {
+	start := time.Unix(1450000000, 0)
+	stats := []*info.ContainerStats{
+		{Timestamp: start},
+		{Timestamp: start.Add(10 * time.Second)},
+	}
+	stats[0].Cpu.Usage.Total = 1000000000
+	stats[1].Cpu.Usage.Total = 6000000000
+	stats[0].Network.Interfaces = []info.InterfaceStats{{RxBytes: 100, TxBytes: 100}}
+	stats[1].Network.Interfaces = []info.InterfaceStats{{RxBytes: 1100, TxBytes: 100}, {TxBytes: 1000}}
+	stats[1].Memory.WorkingSet = 4096
+
+	usage := containerUsage(stats)
+	// 5 seconds of cpu time over 10 seconds.
+	if usage.Cpu != 500 {
+		t.Errorf("expected cpu usage of 500, got %d", usage.Cpu)
+	}
+	if usage.Memory != 4096 {
+		t.Errorf("expected memory usage of 4096, got %d", usage.Memory)
+	}
+	if usage.Network != 200 {
+		t.Errorf("expected network usage of 200, got %d", usage.Network)
+	}
+
+	// A single sample only has memory usage.
+	usage = containerUsage(stats[1:])
+	if usage.Cpu != 0 || usage.Network != 0 || usage.Memory != 4096 {
+		t.Errorf("unexpected usage for a single sample: %+v", usage)
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	usage := v2.Usage{
+		PercentComplete: 50,
+		Cpu: v2.Percentiles{
+			Present:    true,
+			NinetyFive: 200,
+			Max:        400,
+		},
+	}
+	r := recommend(usage, 0.5)
+	if r.PercentComplete != 50 || r.Headroom != 0.5 {
+		t.Errorf("unexpected recommendation %+v", r)
+	}
+	if r.Cpu == nil || r.Cpu.Request != 300 || r.Cpu.Limit != 600 {
+		t.Errorf("expected cpu request of 300 and limit of 600, got %+v", r.Cpu)
+	}
+	// No recommendation without memory usage.
+	if r.Memory != nil {
+		t.Errorf("unexpected memory recommendation %+v", r.Memory)
+	}
+}
+
+func TestImageMatches(t *testing.T) {
+	image := &v2.Image{
+		Id:       "sha256:4e8db158f18dc71307f95260e532df39a9b604b51d4e697468e82845c50cfe0d",
+		RepoTags: []string{"docker.io/library/nginx:latest", "quay.io/app/web:1.2"},
+	}
+	for _, ref := range []string{"nginx", "nginx:latest", "library/nginx", "quay.io/app/web:1.2", "4e8db158f18d", "sha256:4e8db158f18dc71307f95260e532df39a9b604b51d4e697468e82845c50cfe0d"} {
+		if !imageMatches(ref, image) {
+			t.Errorf("expected %q to designate the image", ref)
+		}
+	}
+	for _, ref := range []string{"", "nginx:1.9", "quay.io/app/web", "4e8db1", "redis"} {
+		if imageMatches(ref, image) {
+			t.Errorf("unexpected match of %q", ref)
+		}
+	}
+}
+
+func TestContainerFilter(t *testing.T) {
+	filter, err := parseContainerFilter("name=^k8s_POD_, label:io.kubernetes.pod.namespace=^kube-system$,image=pause")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cont := &containerData{}
+	cont.info.Name = "/docker/abc"
+	if filter.matches(cont) {
+		t.Errorf("unexpected match of %+v", cont.info)
+	}
+	cont.info.Aliases = []string{"k8s_POD_web"}
+	if !filter.matches(cont) {
+		t.Errorf("expected the alias to match")
+	}
+	cont.info.Aliases = nil
+	cont.info.Labels = map[string]string{"io.kubernetes.pod.namespace": "kube-system"}
+	if !filter.matches(cont) {
+		t.Errorf("expected the label to match")
+	}
+	cont.info.Labels = nil
+	cont.info.Spec.Image = "gcr.io/google_containers/pause:2.0"
+	if !filter.matches(cont) {
+		t.Errorf("expected the image to match")
+	}
+
+	if filter, err := parseContainerFilter(""); filter != nil || err != nil {
+		t.Errorf("expected no filter, got %+v (%v)", filter, err)
+	}
+	for _, rules := range []string{"name", "label:=x", "size=1", "image=("} {
+		if _, err := parseContainerFilter(rules); err == nil {
+			t.Errorf("expected an error for %q", rules)
+		}
+	}
+}
+
+func TestRejectedContainers(t *testing.T) {
+	m := createManagerAndAddContainers(
+		memory.New(time.Minute, nil),
+		&fakesysfs.FakeSysFs{},
+		[]string{"/"},
+		func(h *container.MockContainerHandler) {
+			h.On("ListContainers", container.ListRecursive).Return(
+				[]info.ContainerReference{{Name: "/a"}, {Name: "/b"}},
+				nil,
+			)
+		},
+		t,
+	)
+	var err error
+	if m.blacklist, err = parseContainerFilter("name=^/a$,name=^/gone$"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"/a", "/gone"} {
+		if err := m.createContainer(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !m.rejected["/a"] || !m.rejected["/gone"] {
+		t.Fatalf("expected /a and /gone to be rejected, got %v", m.rejected)
+	}
+
+	// Rejected containers are not added again, and are removed once they
+	// are no longer listed.
+	added, removed, err := m.getContainersDiff("/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(added) != 1 || added[0].Name != "/b" {
+		t.Errorf("expected only /b to be added, got %+v", added)
+	}
+	if len(removed) != 1 || removed[0].Name != "/gone" {
+		t.Errorf("expected /gone to be removed, got %+v", removed)
+	}
+	if err := m.destroyContainer("/gone"); err != nil {
+		t.Fatal(err)
+	}
+	if m.rejected["/gone"] || !m.rejected["/a"] {
+		t.Errorf("expected only /gone to be forgotten, got %v", m.rejected)
+	}
+}
+
+func TestHousekeepingIntervalOf(t *testing.T) {
+	overrides, err := parseHousekeepingOverrides("^/system.slice/=10s, k8s_POD_=30s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cont := &containerInfo{}
+	cont.Name = "/docker/abc"
+	if interval := housekeepingIntervalOf(cont, overrides); interval != *HousekeepingInterval {
+		t.Errorf("expected the default interval, got %v", interval)
+	}
+	cont.Aliases = []string{"k8s_POD_web"}
+	if interval := housekeepingIntervalOf(cont, overrides); interval != 30*time.Second {
+		t.Errorf("expected the interval of the alias, got %v", interval)
+	}
+	cont.Labels = map[string]string{HousekeepingIntervalLabel: "5s"}
+	if interval := housekeepingIntervalOf(cont, overrides); interval != 5*time.Second {
+		t.Errorf("expected the interval of the label, got %v", interval)
+	}
+	cont.Labels[HousekeepingIntervalLabel] = "-1s"
+	if interval := housekeepingIntervalOf(cont, overrides); interval != 30*time.Second {
+		t.Errorf("expected an invalid label to be ignored, got %v", interval)
+	}
+
+	for _, overrides := range []string{"^/a", "(=1s", "^/a=x", "^/a=0s"} {
+		if _, err := parseHousekeepingOverrides(overrides); err == nil {
+			t.Errorf("expected an error for %q", overrides)
+		}
+	}
+}
+
+func TestAlignedRun(t *testing.T) {
+	defer func(jitter time.Duration) { *housekeepingAlignmentJitter = jitter }(*housekeepingAlignmentJitter)
+	*housekeepingAlignmentJitter = 0
+
+	start := time.Unix(1000, 300*int64(time.Millisecond))
+	if next := alignedRun(start, time.Second, start); !next.Equal(time.Unix(1001, 0)) {
+		t.Errorf("expected the next second, got %v", next)
+	}
+	if next := alignedRun(time.Unix(1001, 0), 10*time.Second, time.Unix(1001, 0)); !next.Equal(time.Unix(1010, 0)) {
+		t.Errorf("expected the next multiple of 10s, got %v", next)
+	}
+	// A late housekeeping skips the missed multiples.
+	if next := alignedRun(start, time.Second, time.Unix(1003, 500*int64(time.Millisecond))); !next.Equal(time.Unix(1004, 0)) {
+		t.Errorf("expected the multiple following now, got %v", next)
+	}
+
+	*housekeepingAlignmentJitter = 100 * time.Millisecond
+	for i := 0; i < 10; i++ {
+		next := alignedRun(start, time.Second, start)
+		if next.Before(time.Unix(1001, 0)) || !next.Before(time.Unix(1001, 100*int64(time.Millisecond))) {
+			t.Errorf("expected the next second delayed by less than the jitter, got %v", next)
+		}
+	}
+}
+
+func TestRefreshMachineInfo(t *testing.T) {
+	sysFs := &fakesysfs.FakeSysFs{}
+	m := &manager{
+		sysFs:        sysFs,
+		eventHandler: events.NewEventManager(events.DefaultStoragePolicy()),
+	}
+	request := &events.Request{
+		EventType:         map[info.EventType]bool{info.EventMachineChange: true},
+		MaxEventsReturned: 10,
+		ContainerName:     "/",
+	}
+
+	// The first refresh finds all the devices.
+	m.refreshMachineInfo()
+	machineInfo, _ := m.GetMachineInfo()
+	if machineInfo.Generation != 1 || len(machineInfo.DiskMap) != 1 {
+		t.Fatalf("expected the disks to be found at generation 1, got %+v", machineInfo)
+	}
+	if disk := machineInfo.DiskMap["8:0"]; disk.Model != "Fake Disk" || !disk.Rotational {
+		t.Errorf("unexpected disk %+v", disk)
+	}
+
+	// Nothing changed.
+	m.refreshMachineInfo()
+	machineInfo, _ = m.GetMachineInfo()
+	if machineInfo.Generation != 1 {
+		t.Errorf("expected generation 1 without change, got %d", machineInfo.Generation)
+	}
+
+	// A PCI device is plugged.
+	sysFs.SetPciDevices(map[string]sysfs.PciDeviceInfo{
+		"0000:00:1f.6": {Class: "0x020000", Vendor: "0x8086", Device: "0x15b7", Driver: "e1000e"},
+	})
+	m.refreshMachineInfo()
+	machineInfo, _ = m.GetMachineInfo()
+	if machineInfo.Generation != 2 || len(machineInfo.PciDevices) != 1 || machineInfo.PciDevices[0].Driver != "e1000e" {
+		t.Errorf("expected the PCI device at generation 2, got %+v", machineInfo)
+	}
+	evs, err := m.eventHandler.GetEvents(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(evs) != 2 || !reflect.DeepEqual(evs[1].EventData.MachineChange.Changes, []string{"pci_devices"}) {
+		t.Errorf("expected 2 machine change events, the last one of the PCI devices, got %+v", evs)
+	}
+}
+
+func TestCloudStorageTags(t *testing.T) {
+	tags := cloudStorageTags(&info.MachineInfo{
+		CloudProvider: info.AWS,
+		InstanceType:  "m5.large",
+		InstanceID:    "i-0123",
+		Zone:          "us-east-1a",
+		Region:        "us-east-1",
+		InstanceTags:  map[string]string{"team": "data"},
+	})
+	expected := map[string]string{
+		"cloud_provider": "AWS",
+		"instance_type":  "m5.large",
+		"instance_id":    "i-0123",
+		"zone":           "us-east-1a",
+		"region":         "us-east-1",
+		"tag_team":       "data",
+	}
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("expected tags %v, got %v", expected, tags)
+	}
+
+	// Unknown values are left out.
+	tags = cloudStorageTags(&info.MachineInfo{CloudProvider: info.Baremetal})
+	if !reflect.DeepEqual(tags, map[string]string{"cloud_provider": "Baremetal"}) {
+		t.Errorf("expected only the cloud provider, got %v", tags)
+	}
+}
+
+func TestCheckThinPools(t *testing.T) {
+	m := &manager{
+		eventHandler:    events.NewEventManager(events.DefaultStoragePolicy()),
+		thinPoolMonitor: &thinPoolMonitor{exhausted: make(map[string]bool)},
+	}
+	request := &events.Request{
+		EventType:         map[info.EventType]bool{info.EventThinPoolExhaustion: true},
+		MaxEventsReturned: 10,
+		ContainerName:     "/",
+	}
+	pool := fs.ThinPool{Name: "pool", DataUsed: 50, DataTotal: 100, MetadataUsed: 10, MetadataTotal: 100, Mode: "rw"}
+
+	m.checkThinPools([]fs.ThinPool{pool}, 90)
+	if evs, _ := m.eventHandler.GetEvents(request); len(evs) != 0 {
+		t.Fatalf("expected no event under the threshold, got %+v", evs)
+	}
+
+	// The metadata reaches the threshold, reported once.
+	pool.MetadataUsed = 95
+	m.checkThinPools([]fs.ThinPool{pool}, 90)
+	m.checkThinPools([]fs.ThinPool{pool}, 90)
+	evs, _ := m.eventHandler.GetEvents(request)
+	if len(evs) != 1 {
+		t.Fatalf("expected one event, got %+v", evs)
+	}
+	if data := evs[0].EventData.ThinPoolExhaustion; data == nil || data.Pool != "pool" || data.MetadataUsage != 95 {
+		t.Errorf("unexpected event data %+v", evs[0].EventData)
+	}
+
+	// Back under the threshold, then out of data space.
+	pool.MetadataUsed = 10
+	m.checkThinPools([]fs.ThinPool{pool}, 90)
+	pool.Mode = "out_of_data_space"
+	m.checkThinPools([]fs.ThinPool{pool}, 90)
+	if evs, _ := m.eventHandler.GetEvents(request); len(evs) != 2 {
+		t.Errorf("expected a second event, got %+v", evs)
+	}
+
+	var stats v2.HostStats
+	m.addThinPoolStats(&stats)
+	if len(stats.ThinPools) != 1 || stats.ThinPools[0].Mode != "out_of_data_space" {
+		t.Errorf("unexpected thin-pool stats %+v", stats.ThinPools)
+	}
+}
+
+func TestGetSelfStats(t *testing.T) {
+	query := &info.ContainerInfoRequest{
+		NumStats: 16,
+	}
+	m, infosMap, _ := expectManagerWithContainers([]string{"/", "/cadvisor"}, query, t)
+	m.cadvisorContainer = "/cadvisor"
+
+	stats, err := m.GetSelfStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Goroutines == 0 || stats.HeapAlloc == 0 || stats.StartTime.After(stats.Timestamp) {
+		t.Errorf("unexpected runtime stats %+v", stats)
+	}
+	if stats.Containers != 2 {
+		t.Errorf("expected 2 containers, got %d", stats.Containers)
+	}
+	if stats.Container != "/cadvisor" || len(stats.Stats) != 2 {
+		t.Fatalf("expected the last 2 stats of /cadvisor, got %q with %d stats", stats.Container, len(stats.Stats))
+	}
+	expected := infosMap["/cadvisor"].Stats
+	if !stats.Stats[1].Timestamp.Equal(expected[len(expected)-1].Timestamp) {
+		t.Errorf("expected the last stats at %v, got %v", expected[len(expected)-1].Timestamp, stats.Stats[1].Timestamp)
+	}
+
+	m.cadvisorContainer = "/"
+	stats, err = m.GetSelfStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Container != "" || stats.Stats != nil {
+		t.Errorf("expected no container stats for the root container, got %q", stats.Container)
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	usage := func(cpu uint64, complete int32) v2.DerivedStats {
+		percentiles := v2.Percentiles{Present: true, Mean: cpu, Max: cpu, Fifty: cpu, Ninety: cpu, NinetyFive: cpu}
+		return v2.DerivedStats{
+			LatestUsage: v2.InstantUsage{Cpu: cpu},
+			MinuteUsage: v2.Usage{PercentComplete: complete, Cpu: percentiles},
+		}
+	}
+	// /docker/b/c is aggregated at /docker/b only, /docker/a and /docker/b
+	// at /docker and /docker at /.
+	summaries := summarize(map[string]v2.DerivedStats{
+		"/":           usage(1000, 100),
+		"/docker":     usage(600, 100),
+		"/docker/a":   usage(100, 100),
+		"/docker/b":   usage(400, 50),
+		"/docker/b/c": usage(300, 100),
+	})
+	expected := map[string]struct {
+		subcontainers int
+		cpu           uint64
+		complete      int32
+	}{
+		"/":           {1, 600, 100},
+		"/docker":     {2, 500, 50},
+		"/docker/a":   {0, 0, 0},
+		"/docker/b":   {1, 300, 100},
+		"/docker/b/c": {0, 0, 0},
+	}
+	for name, e := range expected {
+		summary := summaries[name]
+		if summary.NumSubcontainers != e.subcontainers {
+			t.Errorf("expected %d subcontainers of %q, got %d", e.subcontainers, name, summary.NumSubcontainers)
+		}
+		if e.subcontainers == 0 {
+			if summary.Subcontainers != nil {
+				t.Errorf("expected no aggregate for %q, got %+v", name, summary.Subcontainers)
+			}
+			continue
+		}
+		sum := summary.Subcontainers
+		if sum.LatestUsage.Cpu != e.cpu || sum.MinuteUsage.Cpu.NinetyFive != e.cpu || sum.MinuteUsage.PercentComplete != e.complete {
+			t.Errorf("expected cpu %d, %d%% complete for %q, got %+v", e.cpu, e.complete, name, sum)
+		}
+		if sum.HourUsage.Cpu.Present {
+			t.Errorf("expected no hour usage for %q, got %+v", name, sum.HourUsage)
+		}
+	}
+}
+
+func TestComputedStats(t *testing.T) {
+	memoryCache := memory.New(time.Hour, nil)
+	start := time.Now().Add(-time.Minute)
+	addStats := func(ref info.ContainerReference, offset time.Duration, cpu, memory uint64) {
+		stats := &info.ContainerStats{Timestamp: start.Add(offset)}
+		stats.Cpu.Usage.Total = cpu
+		stats.Memory.Usage = memory
+		stats.DiskIo.IoServiced = []info.PerDiskStats{{Stats: map[string]uint64{"Total": 7}}}
+		if err := memoryCache.AddStats(ref, stats); err != nil {
+			t.Fatal(err)
+		}
+	}
+	subcontainers := map[string][]info.ContainerReference{
+		"/p": {{Name: "/p/a"}, {Name: "/p/b"}},
+	}
+	m := createManagerAndAddContainers(
+		memoryCache,
+		&fakesysfs.FakeSysFs{},
+		[]string{"/p", "/p/a", "/p/b"},
+		func(h *container.MockContainerHandler) {
+			ref, _ := h.ContainerReference()
+			switch h.Name {
+			case "/p":
+				addStats(ref, 0, 1, 1)
+				addStats(ref, 10*time.Second, 1, 1)
+			case "/p/a":
+				addStats(ref, -time.Second, 100, 1000)
+				addStats(ref, 9*time.Second, 200, 2000)
+			case "/p/b":
+				// Starts after the first stats of /p.
+				addStats(ref, 5*time.Second, 10, 100)
+			}
+			h.On("ListContainers", container.ListSelf).Return(subcontainers[h.Name], nil)
+			h.On("GetSpec").Return(itest.GenerateRandomContainerSpec(4), nil)
+		},
+		t,
+	)
+
+	infos, err := m.GetRequestedContainersInfo("/p", v2.RequestOptions{IdType: v2.TypeName, Count: 10, Computed: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := infos["/p"].Stats
+	if len(stats) != 2 {
+		t.Fatalf("expected 2 stats, got %d", len(stats))
+	}
+	expected := []struct{ cpu, memory uint64 }{{100, 1000}, {210, 2100}}
+	for i, e := range expected {
+		if stats[i].Cpu.Usage.Total != e.cpu || stats[i].Memory.Usage != e.memory {
+			t.Errorf("expected cpu %d and memory %d at %d, got %d and %d", e.cpu, e.memory, i, stats[i].Cpu.Usage.Total, stats[i].Memory.Usage)
+		}
+		if len(stats[i].DiskIo.IoServiced) != 1 {
+			t.Errorf("expected the own disk stats to be kept, got %+v", stats[i].DiskIo)
+		}
+	}
+
+	// The cached stats are left untouched.
+	infos, err = m.GetRequestedContainersInfo("/p", v2.RequestOptions{IdType: v2.TypeName, Count: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if own := infos["/p"].Stats[0]; own.Cpu.Usage.Total != 1 {
+		t.Errorf("expected the own stats of /p, got cpu %d", own.Cpu.Usage.Total)
+	}
+}
+
+func TestCheckSelfMemory(t *testing.T) {
+	m := &manager{
+		eventHandler:        events.NewEventManager(events.DefaultStoragePolicy()),
+		memoryCache:         memory.New(time.Hour, nil),
+		housekeepingBackoff: newHousekeepingBackoff(0.5, 1.0, 4),
+	}
+	for i := 0; i < 100; i++ {
+		ref := info.ContainerReference{Name: "/" + strings.Repeat("c", i%10+1)}
+		m.memoryCache.AddStats(ref, &info.ContainerStats{Timestamp: time.Now().Add(time.Duration(i) * time.Second)})
+	}
+	cached := m.memoryCache.Bytes()
+	request := &events.Request{
+		EventType:         map[info.EventType]bool{info.EventSelfMemoryPressure: true},
+		MaxEventsReturned: 10,
+		ContainerName:     "/",
+	}
+	guard := &selfMemoryGuard{limit: 1000}
+
+	m.checkSelfMemory(guard, 700)
+	if evs, _ := m.eventHandler.GetEvents(request); len(evs) != 0 {
+		t.Fatalf("expected no event under the high watermark, got %+v", evs)
+	}
+
+	// Above the high watermark, the cache and the sampling rate are halved,
+	// reported once.
+	m.checkSelfMemory(guard, 850)
+	if budget := m.memoryCache.ByteBudget(); budget != cached/2 || m.memoryCache.Bytes() > budget {
+		t.Errorf("expected the cache to shrink to %d bytes, got a budget of %d and %d bytes", cached/2, budget, m.memoryCache.Bytes())
+	}
+	if interval := m.housekeepingBackoff.apply(time.Second, time.Minute); interval != 2*time.Second {
+		t.Errorf("expected the housekeeping interval to double, got %v", interval)
+	}
+	cached = m.memoryCache.Bytes()
+	m.checkSelfMemory(guard, 900)
+	if budget := m.memoryCache.ByteBudget(); budget != cached/2 {
+		t.Errorf("expected the cache to shrink further to %d bytes, got %d", cached/2, budget)
+	}
+	evs, _ := m.eventHandler.GetEvents(request)
+	if len(evs) != 1 {
+		t.Fatalf("expected one event, got %+v", evs)
+	}
+	if data := evs[0].EventData.SelfMemoryPressure; data == nil || data.Rss != 850 || data.Limit != 1000 || data.HousekeepingBackoff != 2 {
+		t.Errorf("unexpected event data %+v", evs[0].EventData)
+	}
+
+	// Between the watermarks the pressure holds, under the low one it's
+	// relieved.
+	m.checkSelfMemory(guard, 700)
+	if m.memoryCache.ByteBudget() != cached/2 {
+		t.Errorf("expected the cache budget to hold between the watermarks")
+	}
+	m.checkSelfMemory(guard, 500)
+	if budget := m.memoryCache.ByteBudget(); budget != 0 {
+		t.Errorf("expected the cache budget to be restored, got %d", budget)
+	}
+	if interval := m.housekeepingBackoff.apply(time.Second, time.Minute); interval != time.Second {
+		t.Errorf("expected the housekeeping interval to be restored, got %v", interval)
+	}
+}
+
+func TestContainerEventQueue(t *testing.T) {
+	type call struct {
+		name            string
+		destroy, create bool
+	}
+	calls := make(chan call, 10)
+	release := make(chan bool)
+	queue := newContainerEventQueue(50*time.Millisecond, 2, func(name string, events pendingContainerEvents) {
+		if events.destroy == events.deleted.IsZero() || events.create == events.added.IsZero() {
+			t.Errorf("unexpected times of the events %+v", events)
+		}
+		calls <- call{name, events.destroy, events.create}
+		if name == "/slow" {
+			<-release
+		}
+	})
+	defer queue.stop()
+	next := func() call {
+		select {
+		case c := <-calls:
+			return c
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the events to be processed")
+		}
+		return call{}
+	}
+
+	// A container created and destroyed within the delay is only destroyed,
+	// which is a no-op for an unknown container.
+	queue.add(container.SubcontainerEvent{EventType: container.SubcontainerAdd, Name: "/churn"})
+	queue.add(container.SubcontainerEvent{EventType: container.SubcontainerDelete, Name: "/churn"})
+	// A container destroyed and created again is recreated.
+	queue.add(container.SubcontainerEvent{EventType: container.SubcontainerDelete, Name: "/recreated"})
+	queue.add(container.SubcontainerEvent{EventType: container.SubcontainerAdd, Name: "/recreated"})
+	got := map[string]call{}
+	for i := 0; i < 2; i++ {
+		c := next()
+		got[c.name] = c
+	}
+	if got["/churn"] != (call{"/churn", true, false}) || got["/recreated"] != (call{"/recreated", true, true}) {
+		t.Errorf("unexpected coalesced events %+v", got)
+	}
+
+	// The events received while a container is processed wait for it.
+	queue.add(container.SubcontainerEvent{EventType: container.SubcontainerAdd, Name: "/slow"})
+	if c := next(); c != (call{"/slow", false, true}) {
+		t.Fatalf("unexpected call %+v", c)
+	}
+	queue.add(container.SubcontainerEvent{EventType: container.SubcontainerDelete, Name: "/slow"})
+	select {
+	case c := <-calls:
+		t.Fatalf("processed %+v before the previous events of the container", c)
+	case <-time.After(100 * time.Millisecond):
+	}
+	release <- true
+	if c := next(); c != (call{"/slow", true, false}) {
+		t.Errorf("unexpected call %+v", c)
+	}
+	release <- true
+}
+
+func TestSignalProcessRootContainer(t *testing.T) {
+	enabled := *EnableProcessSignals
+	*EnableProcessSignals = true
+	defer func() {
+		*EnableProcessSignals = enabled
+	}()
+	m := &manager{}
+	err := m.SignalProcess("/", 1, "KILL")
+	if err == nil || !strings.Contains(err.Error(), "root container") {
+		t.Errorf("expected the root container to be rejected, got %v", err)
+	}
+}
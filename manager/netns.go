@@ -0,0 +1,153 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"flag"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/google/cadvisor/container"
+	"github.com/google/cadvisor/logging"
+)
+
+var detectSharedNetworkNamespaces = flag.Bool("detect_shared_network_namespaces", true, "Whether global housekeeping detects the containers sharing a network namespace, e.g. the containers of a pod or run with --net=container or --net=host, so that its network stats are only reported by the container owning it")
+
+// Returns the network namespace of a process, e.g. "net:[4026531993]".
+func readNetworkNamespace(rootFs string, pid int) (string, error) {
+	return os.Readlink(path.Join(rootFs, "proc", strconv.Itoa(pid), "ns", "net"))
+}
+
+// Returns the network namespace of the processes of the container, or an
+// empty string if it has no processes.
+func (c *containerData) networkNamespace(rootFs string) (string, error) {
+	pids, err := c.handler.ListProcesses(container.ListSelf)
+	if err != nil {
+		return "", err
+	}
+	for _, pid := range pids {
+		netns, err := readNetworkNamespace(rootFs, pid)
+		if err == nil {
+			return netns, nil
+		}
+	}
+	return "", nil
+}
+
+func (c *containerData) networkMember() networkMember {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return networkMember{
+		cont:         c,
+		name:         c.info.Name,
+		hasNetwork:   c.info.Spec.HasNetwork,
+		creationTime: c.info.Spec.CreationTime,
+	}
+}
+
+func (c *containerData) networkOwner() string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.info.Spec.NetworkOwner
+}
+
+func (c *containerData) setNetworkOwner(owner string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.info.Spec.NetworkOwner != owner {
+		logging.V(2).Infof("Network stats of %q are now reported by %q", c.info.Name, owner)
+	}
+	c.info.Spec.NetworkOwner = owner
+}
+
+// A container in a network namespace.
+type networkMember struct {
+	cont         *containerData
+	name         string
+	hasNetwork   bool
+	creationTime time.Time
+}
+
+// Returns the owner of a network namespace shared by containers: the root
+// container if it is one of them, else the oldest one with network stats, e.g.
+// the infrastructure container of a pod.
+func networkNamespaceOwner(members []networkMember) networkMember {
+	owner := members[0]
+	for _, member := range members[1:] {
+		if ownsNetworkBefore(member, owner) {
+			owner = member
+		}
+	}
+	return owner
+}
+
+func ownsNetworkBefore(a, b networkMember) bool {
+	if a.name == "/" || b.name == "/" {
+		return a.name == "/"
+	}
+	if a.hasNetwork != b.hasNetwork {
+		return a.hasNetwork
+	}
+	if !a.creationTime.Equal(b.creationTime) {
+		return a.creationTime.Before(b.creationTime)
+	}
+	return a.name < b.name
+}
+
+// Groups the running containers by network namespace and sets the owner of
+// the namespace in the spec of the other containers of each group.
+func (m *manager) updateNetworkOwners() {
+	rootFs := "/"
+	if !m.inHostNamespace {
+		rootFs = "/rootfs"
+	}
+
+	var conts []*containerData
+	func() {
+		m.containersLock.RLock()
+		defer m.containersLock.RUnlock()
+		for name, cont := range m.containers {
+			// Aliases are the same containers.
+			if name.Namespace == "" && cont.exitTime().IsZero() {
+				conts = append(conts, cont)
+			}
+		}
+	}()
+
+	namespaces := make(map[string][]networkMember)
+	for _, cont := range conts {
+		netns, err := cont.networkNamespace(rootFs)
+		if err != nil {
+			logging.V(4).Infof("Failed to get the network namespace of %q: %v", cont.info.Name, err)
+		}
+		if netns == "" {
+			cont.setNetworkOwner("")
+			continue
+		}
+		namespaces[netns] = append(namespaces[netns], cont.networkMember())
+	}
+	for _, members := range namespaces {
+		owner := networkNamespaceOwner(members)
+		for _, member := range members {
+			if member.cont == owner.cont {
+				member.cont.setNetworkOwner("")
+			} else {
+				member.cont.setNetworkOwner(owner.name)
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadNetworkNamespace(t *testing.T) {
+	rootFs, err := ioutil.TempDir("", "netns")
+	require.NoError(t, err)
+	defer os.RemoveAll(rootFs)
+	require.NoError(t, os.MkdirAll(path.Join(rootFs, "proc", "42", "ns"), 0755))
+	require.NoError(t, os.Symlink("net:[4026531993]", path.Join(rootFs, "proc", "42", "ns", "net")))
+
+	netns, err := readNetworkNamespace(rootFs, 42)
+	require.NoError(t, err)
+	assert.Equal(t, "net:[4026531993]", netns)
+
+	_, err = readNetworkNamespace(rootFs, 43)
+	assert.Error(t, err)
+}
+
+func TestNetworkNamespaceOwner(t *testing.T) {
+	now := time.Now()
+	pause := networkMember{name: "/docker/pause", hasNetwork: true, creationTime: now}
+	app := networkMember{name: "/docker/app", creationTime: now.Add(-time.Minute)}
+	sidecar := networkMember{name: "/docker/sidecar", hasNetwork: true, creationTime: now.Add(time.Minute)}
+	assert.Equal(t, pause, networkNamespaceOwner([]networkMember{app, sidecar, pause}))
+
+	// The root container owns the namespace of the host.
+	root := networkMember{name: "/", hasNetwork: true, creationTime: now.Add(time.Hour)}
+	assert.Equal(t, root, networkNamespaceOwner([]networkMember{pause, root, app}))
+
+	// Ties are broken by name.
+	other := networkMember{name: "/docker/other", hasNetwork: true, creationTime: now}
+	assert.Equal(t, other, networkNamespaceOwner([]networkMember{pause, other}))
+}
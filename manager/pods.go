@@ -0,0 +1,108 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/kubernetes"
+)
+
+func (self *manager) GetPods(namespace string, window time.Duration) ([]v2.PodUsage, error) {
+	if self.pods == nil {
+		return nil, fmt.Errorf("the Kubernetes integration is not enabled")
+	}
+	usages := make(map[string]*v2.PodUsage)
+	for _, pod := range self.pods.List() {
+		if namespace != "" && pod.Metadata.Namespace != namespace {
+			continue
+		}
+		usages[pod.Metadata.Uid] = &v2.PodUsage{
+			Name:       pod.Metadata.Name,
+			Namespace:  pod.Metadata.Namespace,
+			Uid:        pod.Metadata.Uid,
+			Labels:     pod.Metadata.Labels,
+			Containers: []v2.ContainerUsage{},
+		}
+	}
+
+	self.containersLock.RLock()
+	conts := make(map[string]*containerData, len(self.containers))
+	for _, cont := range self.containers {
+		conts[cont.info.Name] = cont
+	}
+	self.containersLock.RUnlock()
+
+	start := time.Now().Add(-window)
+	for name, cont := range conts {
+		// The cgroups of pods hold the cgroups of their containers, which
+		// are aggregated instead.
+		if kubernetes.IsPodCgroup(name) {
+			continue
+		}
+		usage, ok := usages[cont.info.Labels[kubernetes.PodUidLabel]]
+		if !ok {
+			continue
+		}
+		stats, err := self.memoryCache.RecentStats(name, start, time.Time{}, -1)
+		if err != nil || len(stats) == 0 {
+			continue
+		}
+		addContainerUsage(usage, name, &cont.info.ContainerReference, stats)
+	}
+
+	pods := make([]v2.PodUsage, 0, len(usages))
+	for _, usage := range usages {
+		sort.Sort(containerUsageSorter{usage.Containers, func(a, b *v2.ContainerUsage) bool { return a.Name < b.Name }})
+		pods = append(pods, *usage)
+	}
+	sort.Sort(podUsageSorter(pods))
+	return pods, nil
+}
+
+// Adds the usage of the container over the samples to the usage of its pod.
+func addContainerUsage(pod *v2.PodUsage, name string, ref *info.ContainerReference, stats []*info.ContainerStats) {
+	usage := containerUsage(stats)
+	usage.Name = name
+	usage.Aliases = ref.Aliases
+	usage.Namespace = ref.Namespace
+	pod.Cpu += usage.Cpu
+	pod.Memory += usage.Memory
+	// Only the container owning the network of the pod reports traffic.
+	pod.Network += usage.Network
+	pod.Containers = append(pod.Containers, usage)
+}
+
+// Sorts pods by namespace and name.
+type podUsageSorter []v2.PodUsage
+
+func (self podUsageSorter) Len() int {
+	return len(self)
+}
+
+func (self podUsageSorter) Swap(i, j int) {
+	self[i], self[j] = self[j], self[i]
+}
+
+func (self podUsageSorter) Less(i, j int) bool {
+	if self[i].Namespace != self[j].Namespace {
+		return self[i].Namespace < self[j].Namespace
+	}
+	return self[i].Name < self[j].Name
+}
@@ -0,0 +1,126 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"flag"
+	"sync"
+	"time"
+
+	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/logging"
+	"github.com/google/cadvisor/utils/machine"
+)
+
+var energySampleInterval = flag.Duration("energy_sample_interval", 0, "Interval at which the RAPL energy counters are sampled to compute the power of the cpu packages and attribute it to the containers by cpu usage. 0 disables the sampling")
+
+// The power of the cpu packages over the last sampling interval and its
+// share attributed to each container.
+type powerSampler struct {
+	lock           sync.Mutex
+	last           []v2.EnergyCounter
+	lastTime       time.Time
+	packagePower   *float64
+	containerPower map[string]float64
+}
+
+// Samples the energy counters every interval.
+func (m *manager) samplePowerLoop(interval time.Duration, quit chan error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := m.samplePower(); err != nil {
+				logging.Warningf("Failed to sample energy counters: %v", err)
+			}
+		case <-quit:
+			quit <- nil
+			logging.Infof("Exiting energy sampling thread")
+			return
+		}
+	}
+}
+
+// Reads the energy counters and, from the previous reading, computes the
+// power of the cpu packages and attributes it to the containers in
+// proportion of their cpu usage over their last two samples relative to the
+// one of the root container. Nested containers are attributed power that is
+// also attributed to their parents.
+func (m *manager) samplePower() error {
+	counters, err := machine.GetEnergy()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	sampler := m.powerSampler
+	sampler.lock.Lock()
+	defer sampler.lock.Unlock()
+	last, lastTime := sampler.last, sampler.lastTime
+	sampler.last, sampler.lastTime = counters, now
+	if last == nil {
+		return nil
+	}
+	power, err := machine.PackagePower(last, counters, now.Sub(lastTime).Seconds())
+	if err != nil {
+		return err
+	}
+	sampler.packagePower = &power
+
+	rootCores := m.recentCpuCores("/")
+	containerPower := make(map[string]float64)
+	if rootCores > 0 {
+		for _, cont := range m.uniqueContainers() {
+			if cores := m.recentCpuCores(cont.info.Name); cores > 0 {
+				containerPower[cont.info.Name] = power * cores / rootCores
+			}
+		}
+	}
+	sampler.containerPower = containerPower
+	return nil
+}
+
+// Returns the cpu usage of the container in cores between its last two
+// samples, 0 if unknown.
+func (m *manager) recentCpuCores(name string) float64 {
+	var zero time.Time
+	stats, err := m.memoryCache.RecentStats(name, zero, zero, 2)
+	if err != nil || len(stats) < 2 {
+		return 0
+	}
+	elapsed := stats[1].Since(stats[0])
+	if elapsed <= 0 || stats[1].Cpu.Usage.Total < stats[0].Cpu.Usage.Total {
+		return 0
+	}
+	return float64(stats[1].Cpu.Usage.Total-stats[0].Cpu.Usage.Total) / float64(elapsed.Nanoseconds())
+}
+
+// Adds the temperatures, energy counters and sampled power to the host stats.
+func (m *manager) addPowerStats(stats *v2.HostStats) {
+	var err error
+	if stats.Temperatures, err = machine.GetTemperatures(); err != nil {
+		logging.V(4).Infof("Failed to read temperatures: %v", err)
+	}
+	if stats.Energy, err = machine.GetEnergy(); err != nil {
+		logging.V(4).Infof("Failed to read energy counters: %v", err)
+	}
+	if m.powerSampler == nil {
+		return
+	}
+	m.powerSampler.lock.Lock()
+	defer m.powerSampler.lock.Unlock()
+	stats.PackagePower = m.powerSampler.packagePower
+	stats.ContainerPower = m.powerSampler.containerPower
+}
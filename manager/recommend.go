@@ -0,0 +1,77 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/google/cadvisor/info/v2"
+)
+
+// Windows of usage recommendations can be based on.
+const (
+	RecommendWindowHour = "hour"
+	RecommendWindowDay  = "day"
+)
+
+func (self *manager) GetRecommendations(containerName string, options v2.RequestOptions, window string, headroom float64) (map[string]v2.Recommendation, error) {
+	if window != RecommendWindowHour && window != RecommendWindowDay {
+		return nil, fmt.Errorf("unknown window %q", window)
+	}
+	if headroom < 0 {
+		return nil, fmt.Errorf("invalid headroom %v", headroom)
+	}
+	stats, err := self.GetDerivedStats(containerName, options)
+	if err != nil {
+		return nil, err
+	}
+	recommendations := make(map[string]v2.Recommendation, len(stats))
+	for name, derived := range stats {
+		usage := derived.DayUsage
+		if window == RecommendWindowHour {
+			usage = derived.HourUsage
+		}
+		r := recommend(usage, headroom)
+		r.Timestamp = derived.Timestamp
+		r.Window = window
+		recommendations[name] = r
+	}
+	return recommendations, nil
+}
+
+// Recommends requests at the 95th percentile of the usage and limits at its
+// max, both with the headroom added.
+func recommend(usage v2.Usage, headroom float64) v2.Recommendation {
+	return v2.Recommendation{
+		PercentComplete: usage.PercentComplete,
+		Headroom:        headroom,
+		Cpu:             recommendResource(usage.Cpu, headroom),
+		Memory:          recommendResource(usage.Memory, headroom),
+	}
+}
+
+func recommendResource(percentiles v2.Percentiles, headroom float64) *v2.ResourceRecommendation {
+	if !percentiles.Present {
+		return nil
+	}
+	withHeadroom := func(value uint64) uint64 {
+		return uint64(math.Ceil(float64(value) * (1 + headroom)))
+	}
+	return &v2.ResourceRecommendation{
+		Request: withHeadroom(percentiles.NinetyFive),
+		Limit:   withHeadroom(percentiles.Max),
+	}
+}
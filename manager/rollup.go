@@ -0,0 +1,154 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"time"
+
+	info "github.com/google/cadvisor/info/v1"
+)
+
+// Stats of a subcontainer, current for up to its max housekeeping interval.
+type subcontainerStats struct {
+	stats  []*info.ContainerStats
+	maxAge time.Duration
+}
+
+// Returns the stats of a container computed as the sums of the cpu, memory,
+// network and load stats of its subcontainers, themselves computed down to
+// the containers without subcontainers, at the timestamps of its own stats.
+// The other stats remain the container's own. The own stats are not
+// modified: they are shared with the cache.
+func (self *manager) computeStats(subcontainers []info.ContainerReference, own []*info.ContainerStats) []*info.ContainerStats {
+	if len(subcontainers) == 0 || len(own) == 0 {
+		return own
+	}
+	var children []subcontainerStats
+	for _, ref := range subcontainers {
+		cont, err := self.getContainer(ref.Name)
+		if err != nil {
+			// The subcontainer is gone.
+			continue
+		}
+		query := info.ContainerInfoRequest{
+			NumStats: -1,
+			Start:    own[0].Timestamp.Add(-cont.maxHousekeepingInterval),
+			End:      own[len(own)-1].Timestamp,
+		}
+		cinfo, err := self.containerDataToContainerInfo(cont, &query)
+		if err != nil {
+			continue
+		}
+		children = append(children, subcontainerStats{
+			stats:  self.computeStats(cinfo.Subcontainers, cinfo.Stats),
+			maxAge: cont.maxHousekeepingInterval,
+		})
+	}
+
+	computed := make([]*info.ContainerStats, 0, len(own))
+	for _, stats := range own {
+		sum := *stats
+		sum.Cpu.Usage = info.CpuUsage{}
+		sum.Cpu.Schedstat = info.CpuSchedstat{}
+		sum.Memory = info.MemoryStats{}
+		sum.Network.InterfaceStats = info.InterfaceStats{Name: stats.Network.Name}
+		sum.TaskStats = info.LoadStats{}
+		sum.Errors = nil
+		copyErrors(&sum, stats, false)
+		for _, child := range children {
+			if childStats := statsAt(child, stats.Timestamp); childStats != nil {
+				addComputedStats(&sum, childStats)
+			}
+		}
+		computed = append(computed, &sum)
+	}
+	return computed
+}
+
+// Returns the latest stats of the subcontainer at the time, nil if it has
+// none current then.
+func statsAt(child subcontainerStats, timestamp time.Time) *info.ContainerStats {
+	for i := len(child.stats) - 1; i >= 0; i-- {
+		stats := child.stats[i]
+		if stats.Timestamp.After(timestamp) {
+			continue
+		}
+		if timestamp.Sub(stats.Timestamp) > child.maxAge {
+			return nil
+		}
+		return stats
+	}
+	return nil
+}
+
+// Subsystems whose stats are computed from those of the subcontainers.
+var summedSubsystems = map[string]bool{
+	info.NetworkStatsSubsystem: true,
+	info.SchedstatSubsystem:    true,
+}
+
+// Copies the errors of the summed subsystems, or of the others, of the stats.
+func copyErrors(dst *info.ContainerStats, src *info.ContainerStats, summed bool) {
+	for subsystem, err := range src.Errors {
+		if summedSubsystems[subsystem] != summed {
+			continue
+		}
+		if dst.Errors == nil {
+			dst.Errors = make(map[string]string)
+		}
+		dst.Errors[subsystem] = err
+	}
+}
+
+func addComputedStats(sum *info.ContainerStats, stats *info.ContainerStats) {
+	// The sum is not valid if the stats of a subcontainer are not.
+	copyErrors(sum, stats, true)
+	sum.Cpu.Usage.Total += stats.Cpu.Usage.Total
+	sum.Cpu.Usage.User += stats.Cpu.Usage.User
+	sum.Cpu.Usage.System += stats.Cpu.Usage.System
+	sum.Cpu.Usage.Throttled += stats.Cpu.Usage.Throttled
+	for i, usage := range stats.Cpu.Usage.PerCpu {
+		if i == len(sum.Cpu.Usage.PerCpu) {
+			sum.Cpu.Usage.PerCpu = append(sum.Cpu.Usage.PerCpu, 0)
+		}
+		sum.Cpu.Usage.PerCpu[i] += usage
+	}
+	addSchedstat(&sum.Cpu.Schedstat, stats.Cpu.Schedstat)
+
+	sum.Memory.Usage += stats.Memory.Usage
+	sum.Memory.Cache += stats.Memory.Cache
+	sum.Memory.RSS += stats.Memory.RSS
+	sum.Memory.WorkingSet += stats.Memory.WorkingSet
+	sum.Memory.Failcnt += stats.Memory.Failcnt
+	sum.Memory.ContainerData.Pgfault += stats.Memory.ContainerData.Pgfault
+	sum.Memory.ContainerData.Pgmajfault += stats.Memory.ContainerData.Pgmajfault
+	sum.Memory.HierarchicalData.Pgfault += stats.Memory.HierarchicalData.Pgfault
+	sum.Memory.HierarchicalData.Pgmajfault += stats.Memory.HierarchicalData.Pgmajfault
+
+	sum.Network.RxBytes += stats.Network.RxBytes
+	sum.Network.RxPackets += stats.Network.RxPackets
+	sum.Network.RxErrors += stats.Network.RxErrors
+	sum.Network.RxDropped += stats.Network.RxDropped
+	sum.Network.TxBytes += stats.Network.TxBytes
+	sum.Network.TxPackets += stats.Network.TxPackets
+	sum.Network.TxErrors += stats.Network.TxErrors
+	sum.Network.TxDropped += stats.Network.TxDropped
+
+	sum.TaskStats.NrSleeping += stats.TaskStats.NrSleeping
+	sum.TaskStats.NrRunning += stats.TaskStats.NrRunning
+	sum.TaskStats.NrStopped += stats.TaskStats.NrStopped
+	sum.TaskStats.NrUninterruptible += stats.TaskStats.NrUninterruptible
+	sum.TaskStats.NrIoWait += stats.TaskStats.NrIoWait
+}
@@ -0,0 +1,120 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/google/cadvisor/container"
+	info "github.com/google/cadvisor/info/v1"
+)
+
+// Aggregates the scheduler statistics of the tasks of a container into
+// cumulative counters. The statistics of the tasks that exited are kept so
+// that the counters do not decrease.
+type schedstatReader struct {
+	// Root of the procfs of the pid namespace of the cgroups.
+	procRoot string
+	// Last statistics of the live tasks, keyed by thread ID.
+	tasks map[string]info.CpuSchedstat
+	// Sum of the last statistics of the tasks that exited.
+	exited info.CpuSchedstat
+}
+
+func newSchedstatReader(procRoot string) *schedstatReader {
+	return &schedstatReader{
+		procRoot: procRoot,
+		tasks:    make(map[string]info.CpuSchedstat),
+	}
+}
+
+// Returns the cumulative scheduler statistics of the tasks of the processes.
+func (self *schedstatReader) read(pids []int) info.CpuSchedstat {
+	tasks := make(map[string]info.CpuSchedstat, len(self.tasks))
+	for _, pid := range pids {
+		taskDir := path.Join(self.procRoot, strconv.Itoa(pid), "task")
+		tids, err := ioutil.ReadDir(taskDir)
+		if err != nil {
+			// The process exited.
+			continue
+		}
+		for _, tid := range tids {
+			stat, err := readSchedstat(path.Join(taskDir, tid.Name(), "schedstat"))
+			if err != nil {
+				continue
+			}
+			tasks[tid.Name()] = stat
+		}
+	}
+	for tid, stat := range self.tasks {
+		if _, ok := tasks[tid]; !ok {
+			addSchedstat(&self.exited, stat)
+		}
+	}
+	self.tasks = tasks
+
+	total := self.exited
+	for _, stat := range tasks {
+		addSchedstat(&total, stat)
+	}
+	return total
+}
+
+func addSchedstat(sum *info.CpuSchedstat, stat info.CpuSchedstat) {
+	sum.RunTime += stat.RunTime
+	sum.RunqueueTime += stat.RunqueueTime
+	sum.RunPeriods += stat.RunPeriods
+}
+
+// Parses a schedstat file: the time spent on the cpu and waiting on a
+// runqueue in nanoseconds, and the number of timeslices run.
+func readSchedstat(file string) (info.CpuSchedstat, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return info.CpuSchedstat{}, err
+	}
+	fields := strings.Fields(string(content))
+	if len(fields) != 3 {
+		return info.CpuSchedstat{}, fmt.Errorf("unexpected schedstat %q in %q", content, file)
+	}
+	var values [3]uint64
+	for i, field := range fields {
+		if values[i], err = strconv.ParseUint(field, 10, 64); err != nil {
+			return info.CpuSchedstat{}, fmt.Errorf("failed to parse schedstat %q in %q: %v", content, file, err)
+		}
+	}
+	return info.CpuSchedstat{
+		RunTime:      values[0],
+		RunqueueTime: values[1],
+		RunPeriods:   values[2],
+	}, nil
+}
+
+// Returns the scheduler statistics of the tasks of the container, if they are
+// collected.
+func (c *containerData) schedstat() (info.CpuSchedstat, error) {
+	if c.schedstatReader == nil {
+		return info.CpuSchedstat{}, nil
+	}
+	pids, err := c.handler.ListProcesses(container.ListSelf)
+	if err != nil {
+		return info.CpuSchedstat{}, err
+	}
+	return c.schedstatReader.read(pids), nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeSchedstat(t *testing.T, procRoot, pid, tid, content string) {
+	dir := path.Join(procRoot, pid, "task", tid)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, ioutil.WriteFile(path.Join(dir, "schedstat"), []byte(content), 0644))
+}
+
+func TestSchedstatReader(t *testing.T) {
+	procRoot, err := ioutil.TempDir("", "schedstat")
+	require.NoError(t, err)
+	defer os.RemoveAll(procRoot)
+
+	writeSchedstat(t, procRoot, "1", "1", "100 10 1\n")
+	writeSchedstat(t, procRoot, "1", "2", "200 20 2\n")
+	writeSchedstat(t, procRoot, "2", "3", "300 30 3\n")
+
+	reader := newSchedstatReader(procRoot)
+	assert.Equal(t, info.CpuSchedstat{RunTime: 600, RunqueueTime: 60, RunPeriods: 6}, reader.read([]int{1, 2}))
+
+	// Thread 2 progresses, thread 3 exits with its process.
+	writeSchedstat(t, procRoot, "1", "2", "400 40 4\n")
+	require.NoError(t, os.RemoveAll(path.Join(procRoot, "2")))
+	assert.Equal(t, info.CpuSchedstat{RunTime: 800, RunqueueTime: 80, RunPeriods: 8}, reader.read([]int{1, 2}))
+
+	// The statistics of the exited thread are only counted once.
+	assert.Equal(t, info.CpuSchedstat{RunTime: 800, RunqueueTime: 80, RunPeriods: 8}, reader.read([]int{1}))
+}
+
+func TestReadSchedstatMalformed(t *testing.T) {
+	procRoot, err := ioutil.TempDir("", "schedstat")
+	require.NoError(t, err)
+	defer os.RemoveAll(procRoot)
+
+	writeSchedstat(t, procRoot, "1", "1", "100 10\n")
+	writeSchedstat(t, procRoot, "1", "2", "100 ten 1\n")
+	writeSchedstat(t, procRoot, "1", "3", "100 10 1\n")
+
+	_, err = readSchedstat(path.Join(procRoot, "1", "task", "1", "schedstat"))
+	assert.Error(t, err)
+	_, err = readSchedstat(path.Join(procRoot, "1", "task", "2", "schedstat"))
+	assert.Error(t, err)
+
+	// Malformed threads are skipped.
+	reader := newSchedstatReader(procRoot)
+	assert.Equal(t, info.CpuSchedstat{RunTime: 100, RunqueueTime: 10, RunPeriods: 1}, reader.read([]int{1}))
+}
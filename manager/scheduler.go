@@ -0,0 +1,191 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"container/heap"
+	"flag"
+	"sync"
+	"time"
+
+	"github.com/google/cadvisor/logging"
+)
+
+var housekeepingWorkers = flag.Int("housekeeping_workers", 16, "Number of workers performing the housekeeping of all containers, in the order of their next housekeeping. 0 runs the housekeeping of each container in its own goroutines")
+
+// A task run periodically by the scheduler.
+type scheduledTask struct {
+	// Runs the task and returns the time of its next run.
+	run func() time.Time
+	// Time of the next run.
+	next time.Time
+	// Index in the queue, or -1 if the task is not queued.
+	index int
+	// Set when the task is cancelled, so that it is not run nor queued again.
+	cancelled bool
+	// Held while the task runs.
+	running sync.Mutex
+}
+
+// Priority queue of tasks by time of their next run.
+type taskQueue []*scheduledTask
+
+func (self taskQueue) Len() int           { return len(self) }
+func (self taskQueue) Less(i, j int) bool { return self[i].next.Before(self[j].next) }
+func (self taskQueue) Swap(i, j int) {
+	self[i], self[j] = self[j], self[i]
+	self[i].index = i
+	self[j].index = j
+}
+
+func (self *taskQueue) Push(x interface{}) {
+	task := x.(*scheduledTask)
+	task.index = len(*self)
+	*self = append(*self, task)
+}
+
+func (self *taskQueue) Pop() interface{} {
+	old := *self
+	task := old[len(old)-1]
+	old[len(old)-1] = nil
+	task.index = -1
+	*self = old[:len(old)-1]
+	return task
+}
+
+// Runs the periodic tasks of all containers on a bounded pool of workers,
+// instead of a goroutine and a timer per task.
+type housekeepingScheduler struct {
+	workers int
+
+	lock  sync.Mutex
+	queue taskQueue
+	// Signals the dispatcher that the queue changed.
+	wakeup chan struct{}
+}
+
+func newHousekeepingScheduler(workers int) *housekeepingScheduler {
+	return &housekeepingScheduler{
+		workers: workers,
+		wakeup:  make(chan struct{}, 1),
+	}
+}
+
+// Schedules a task, first run at the given time.
+func (self *housekeepingScheduler) schedule(run func() time.Time, first time.Time) *scheduledTask {
+	task := &scheduledTask{
+		run:   run,
+		next:  first,
+		index: -1,
+	}
+	self.lock.Lock()
+	heap.Push(&self.queue, task)
+	self.lock.Unlock()
+	self.notify()
+	return task
+}
+
+// Cancels a task. If the task is running, waits for the run to complete.
+func (self *housekeepingScheduler) cancel(task *scheduledTask) {
+	self.lock.Lock()
+	task.cancelled = true
+	if task.index >= 0 {
+		heap.Remove(&self.queue, task.index)
+	}
+	self.lock.Unlock()
+	task.running.Lock()
+	task.running.Unlock()
+}
+
+func (self *housekeepingScheduler) notify() {
+	select {
+	case self.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// Dispatches the due tasks to the workers until asked to quit.
+func (self *housekeepingScheduler) loop(quit chan error) {
+	work := make(chan *scheduledTask)
+	defer close(work)
+	for i := 0; i < self.workers; i++ {
+		go self.worker(work)
+	}
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		self.lock.Lock()
+		var task *scheduledTask
+		wait := time.Hour
+		if len(self.queue) > 0 {
+			if wait = self.queue[0].next.Sub(time.Now()); wait <= 0 {
+				task = heap.Pop(&self.queue).(*scheduledTask)
+			}
+		}
+		self.lock.Unlock()
+
+		if task != nil {
+			select {
+			case work <- task:
+			case <-quit:
+				quit <- nil
+				logging.Infof("Exiting housekeeping scheduler")
+				return
+			}
+			continue
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+		select {
+		case <-timer.C:
+		case <-self.wakeup:
+		case <-quit:
+			quit <- nil
+			logging.Infof("Exiting housekeeping scheduler")
+			return
+		}
+	}
+}
+
+func (self *housekeepingScheduler) worker(work chan *scheduledTask) {
+	for task := range work {
+		task.running.Lock()
+		self.lock.Lock()
+		cancelled := task.cancelled
+		self.lock.Unlock()
+		if cancelled {
+			task.running.Unlock()
+			continue
+		}
+
+		next := task.run()
+
+		self.lock.Lock()
+		if !task.cancelled {
+			task.next = next
+			heap.Push(&self.queue, task)
+		}
+		self.lock.Unlock()
+		task.running.Unlock()
+		self.notify()
+	}
+}
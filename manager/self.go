@@ -0,0 +1,79 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/google/cadvisor/info/v2"
+)
+
+// The time cAdvisor started.
+var startTime = time.Now()
+
+func (self *manager) GetSelfStats() (v2.SelfStats, error) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	stats := v2.SelfStats{
+		Timestamp:     time.Now(),
+		StartTime:     startTime,
+		Goroutines:    runtime.NumGoroutine(),
+		HeapAlloc:     mem.HeapAlloc,
+		HeapSys:       mem.HeapSys,
+		HeapObjects:   mem.HeapObjects,
+		TotalAlloc:    mem.TotalAlloc,
+		Mallocs:       mem.Mallocs,
+		Sys:           mem.Sys,
+		NumGC:         mem.NumGC,
+		GCPauseTotal:  mem.PauseTotalNs,
+		NextGC:        mem.NextGC,
+		GCCPUFraction: mem.GCCPUFraction,
+	}
+	if mem.NumGC > 0 {
+		stats.GCPauseLast = mem.PauseNs[(mem.NumGC+255)%256]
+	}
+
+	self.containersLock.RLock()
+	conts := make(map[*containerData]struct{}, len(self.containers))
+	for _, cont := range self.containers {
+		conts[cont] = struct{}{}
+	}
+	self.containersLock.RUnlock()
+	stats.Containers = len(conts)
+
+	// The usage of the root container is the one of the whole machine, and
+	// the container of cAdvisor may not be tracked, e.g. with
+	// --docker_only.
+	if self.cadvisorContainer == "/" {
+		return stats, nil
+	}
+	cont, err := self.getContainerData(self.cadvisorContainer)
+	if err != nil {
+		return stats, nil
+	}
+	cinfo, err := cont.GetInfo()
+	if err != nil {
+		return stats, err
+	}
+	var nilTime time.Time // Ignored.
+	recent, err := self.memoryCache.RecentStats(cinfo.Name, nilTime, nilTime, 2)
+	if err != nil {
+		return stats, err
+	}
+	stats.Container = cinfo.Name
+	stats.Stats = v2.ContainerStatsFromV1(&cinfo.Spec, recent)
+	return stats, nil
+}
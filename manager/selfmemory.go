@@ -0,0 +1,139 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
+)
+
+var maxSelfMemory = flag.Int64("max_self_memory", 0, "Resident memory of cAdvisor in bytes to stay under: above 80% of it, cAdvisor evicts half of its cached stats and lengthens the housekeeping intervals, with a selfMemoryPressure event, until it is back under 60%. 0 disables the check")
+var selfMemoryCheckInterval = flag.Duration("self_memory_check_interval", 5*time.Second, "Interval at which the resident memory of cAdvisor is checked against --max_self_memory")
+
+// Fractions of --max_self_memory above which cAdvisor sheds memory, and
+// under which it restores the cache budget and the housekeeping intervals.
+const (
+	selfMemoryHighWatermark = 0.8
+	selfMemoryLowWatermark  = 0.6
+)
+
+// Keeps the resident memory of cAdvisor under its limit, rather than being
+// OOM-killed and losing all the cached stats.
+type selfMemoryGuard struct {
+	limit uint64
+	// Byte budget of the cache before the pressure, restored after it.
+	budget int64
+	// Whether cAdvisor is shedding memory.
+	pressure bool
+}
+
+// Sheds memory while the resident memory of cAdvisor is above the high
+// watermark: each check halves the cached stats, doubles the housekeeping
+// intervals and returns the memory freed to the OS. Adds a
+// selfMemoryPressure event when the pressure starts.
+func (m *manager) checkSelfMemory(guard *selfMemoryGuard, rss uint64) {
+	if rss < uint64(float64(guard.limit)*selfMemoryHighWatermark) {
+		if guard.pressure && rss < uint64(float64(guard.limit)*selfMemoryLowWatermark) {
+			logging.Infof("cAdvisor uses %d bytes of its %d bytes of memory, restoring the cached stats budget and the housekeeping intervals", rss, guard.limit)
+			m.memoryCache.SetByteBudget(guard.budget)
+			m.housekeepingBackoff.resetMemory()
+			guard.pressure = false
+		}
+		return
+	}
+
+	if !guard.pressure {
+		guard.budget = m.memoryCache.ByteBudget()
+	}
+	budget := m.memoryCache.Bytes() / 2
+	if current := m.memoryCache.ByteBudget(); current > 0 && current < budget {
+		budget = current / 2
+	}
+	if budget < 1 {
+		budget = 1
+	}
+	m.memoryCache.SetByteBudget(budget)
+	factor := m.housekeepingBackoff.backOffMemory()
+	debug.FreeOSMemory()
+	logging.Warningf("cAdvisor uses %d bytes of its %d bytes of memory, shrinking the cached stats to %d bytes and lengthening the housekeeping intervals %d times", rss, guard.limit, budget, factor)
+	if guard.pressure {
+		return
+	}
+	guard.pressure = true
+	err := m.eventHandler.AddEvent(&info.Event{
+		ContainerName: "/",
+		Timestamp:     time.Now(),
+		EventType:     info.EventSelfMemoryPressure,
+		EventData: info.EventData{
+			SelfMemoryPressure: &info.SelfMemoryPressureEventData{
+				Rss:                 rss,
+				Limit:               guard.limit,
+				CacheBudget:         budget,
+				HousekeepingBackoff: factor,
+			},
+		},
+	})
+	if err != nil {
+		logging.Errorf("failed to add self memory pressure event: %v", err)
+	}
+}
+
+// Checks the resident memory of cAdvisor every interval.
+func (m *manager) checkSelfMemoryLoop(limit uint64, interval time.Duration, quit chan error) {
+	guard := &selfMemoryGuard{limit: limit}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			rss, err := readSelfRss()
+			if err != nil {
+				logging.Warningf("Failed to check the memory of cAdvisor: %v", err)
+				continue
+			}
+			m.checkSelfMemory(guard, rss)
+		case <-quit:
+			quit <- nil
+			logging.Infof("Exiting self memory check thread")
+			return
+		}
+	}
+}
+
+// Returns the resident memory of cAdvisor in bytes.
+func readSelfRss() (uint64, error) {
+	out, err := ioutil.ReadFile("/proc/self/statm")
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("unexpected /proc/self/statm %q", out)
+	}
+	pages, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return pages * uint64(os.Getpagesize()), nil
+}
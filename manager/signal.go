@@ -0,0 +1,71 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"syscall"
+
+	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/logging"
+)
+
+var EnableProcessSignals = flag.Bool("enable_process_signals", false, "allow the API and the web UI to send SIGTERM and SIGKILL to the processes of the containers. Requires API authentication, the requests need the admin role")
+
+// Signals that can be sent to processes, by name.
+var processSignals = map[string]syscall.Signal{
+	"TERM": syscall.SIGTERM,
+	"KILL": syscall.SIGKILL,
+}
+
+func (m *manager) SignalProcess(containerName string, pid int, signalName string) error {
+	if !*EnableProcessSignals {
+		return fmt.Errorf("sending signals to processes is disabled")
+	}
+	signal, ok := processSignals[signalName]
+	if !ok {
+		return fmt.Errorf("unknown signal %q", signalName)
+	}
+	// The root container lists every process of the machine.
+	if containerName == "/" {
+		return fmt.Errorf("processes of the root container cannot be signaled")
+	}
+	// Only signal the processes listed for the container, so that a pid
+	// can't be used to reach outside of it.
+	ps, err := m.GetProcessList(containerName, v2.RequestOptions{IdType: v2.TypeName, Count: 1})
+	if err != nil {
+		return err
+	}
+	if !hasProcess(ps, pid) {
+		return fmt.Errorf("process %d is not in container %q", pid, containerName)
+	}
+	logging.Infof("Sending SIG%s to process %d of container %q", signalName, pid, containerName)
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return p.Signal(signal)
+}
+
+func hasProcess(ps []v2.ProcessInfo, pid int) bool {
+	for _, p := range ps {
+		if p.Pid == pid {
+			return true
+		}
+	}
+	return false
+}
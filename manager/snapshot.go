@@ -0,0 +1,41 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"io"
+
+	info "github.com/google/cadvisor/info/v1"
+)
+
+func (self *manager) WriteSnapshot(w io.Writer) error {
+	return self.memoryCache.WriteSnapshot(w, func(name string) *info.ContainerSpec {
+		cont, err := self.getContainerData(name)
+		if err != nil {
+			// The container is gone, only its stats remain.
+			return nil
+		}
+		cinfo, err := cont.GetInfo()
+		if err != nil {
+			return nil
+		}
+		spec := self.getAdjustedSpec(cinfo)
+		return &spec
+	})
+}
+
+func (self *manager) RestoreSnapshot(r io.Reader, maxSize int64) (int, error) {
+	return self.memoryCache.ReadSnapshot(r, maxSize)
+}
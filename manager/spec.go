@@ -0,0 +1,149 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"reflect"
+	"time"
+
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/logging"
+)
+
+// Kinds of changes of the spec of a container tracked in its history.
+const (
+	specChangeCpuset = "cpuset"
+	specChangeLimits = "limits"
+	specChangeLabels = "labels"
+	specChangeImage  = "image"
+)
+
+// A version of the spec of a container.
+type specVersion struct {
+	timestamp time.Time
+	changes   []string
+	spec      info.ContainerSpec
+}
+
+// Returns the kinds of changes from old to new, in a stable order.
+func specChanges(old, new *info.ContainerSpec) []string {
+	var changes []string
+	if old.Cpu.Mask != new.Cpu.Mask || old.Cpu.Mems != new.Cpu.Mems {
+		changes = append(changes, specChangeCpuset)
+	}
+	if limitsChanged(old, new) {
+		changes = append(changes, specChangeLimits)
+	}
+	if !reflect.DeepEqual(old.Labels, new.Labels) {
+		changes = append(changes, specChangeLabels)
+	}
+	if old.Image != new.Image {
+		changes = append(changes, specChangeImage)
+	}
+	return changes
+}
+
+func limitsChanged(old, new *info.ContainerSpec) bool {
+	return old.Cpu.Limit != new.Cpu.Limit ||
+		old.Cpu.MaxLimit != new.Cpu.MaxLimit ||
+		old.Cpu.Quota != new.Cpu.Quota ||
+		old.Cpu.Period != new.Cpu.Period ||
+		old.Memory.Limit != new.Memory.Limit ||
+		old.Memory.Reservation != new.Memory.Reservation ||
+		old.Memory.SwapLimit != new.Memory.SwapLimit
+}
+
+// Records a version of the spec, dropping the oldest ones beyond
+// --spec_history_size. Must be called with the lock held.
+func (c *containerData) addSpecVersion(spec info.ContainerSpec, changes []string) {
+	c.specHistory = append(c.specHistory, specVersion{
+		timestamp: time.Now(),
+		changes:   changes,
+		spec:      spec,
+	})
+	if size := *specHistorySize; size > 0 && len(c.specHistory) > size {
+		c.specHistory = append([]specVersion(nil), c.specHistory[len(c.specHistory)-size:]...)
+	}
+}
+
+// Returns the versions of the spec of the container, oldest first.
+func (c *containerData) SpecHistory() []specVersion {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]specVersion(nil), c.specHistory...)
+}
+
+// Emits the events for the changes of the spec of the container.
+func (self *manager) addSpecChangeEvents(containerName string, old, new *info.ContainerSpec, changes []string) {
+	for _, change := range changes {
+		event := &info.Event{
+			ContainerName: containerName,
+			Timestamp:     time.Now(),
+		}
+		switch change {
+		case specChangeCpuset:
+			event.EventType = info.EventCpusetChange
+			event.EventData.CpusetChange = &info.CpusetChangeEventData{
+				Generation: new.Generation,
+				OldCpus:    old.Cpu.Mask,
+				OldMems:    old.Cpu.Mems,
+				Cpus:       new.Cpu.Mask,
+				Mems:       new.Cpu.Mems,
+			}
+		case specChangeLimits:
+			event.EventType = info.EventLimitChange
+			event.EventData.LimitChange = &info.LimitChangeEventData{
+				Generation: new.Generation,
+				OldCpu:     old.Cpu,
+				OldMemory:  old.Memory,
+				Cpu:        new.Cpu,
+				Memory:     new.Memory,
+			}
+		default:
+			continue
+		}
+		if err := self.eventHandler.AddEvent(event); err != nil {
+			logging.Errorf("failed to add %s change event for %q: %v", change, containerName, err)
+		}
+	}
+}
+
+func (self *manager) GetContainerSpecHistory(containerName string, options v2.RequestOptions) (map[string][]v2.ContainerSpecVersion, error) {
+	conts, err := self.getRequestedContainers(containerName, options)
+	if err != nil {
+		return nil, err
+	}
+	histories := make(map[string][]v2.ContainerSpecVersion, len(conts))
+	for name, cont := range conts {
+		cinfo, err := cont.GetInfo()
+		if err != nil {
+			return nil, err
+		}
+		versions := cont.SpecHistory()
+		history := make([]v2.ContainerSpecVersion, 0, len(versions))
+		for _, version := range versions {
+			versionInfo := *cinfo
+			versionInfo.Spec = version.spec
+			history = append(history, v2.ContainerSpecVersion{
+				Timestamp: version.timestamp,
+				Changes:   version.changes,
+				Spec:      self.getV2Spec(&versionInfo),
+			})
+		}
+		histories[name] = history
+	}
+	return histories, nil
+}
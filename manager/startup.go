@@ -0,0 +1,121 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"flag"
+	"time"
+
+	"github.com/google/cadvisor/logging"
+)
+
+var startupRamp = flag.Duration("startup_ramp", 0, "Period over which the first housekeepings of the containers found on startup are spread, instead of all running at once. 0 starts them all immediately")
+var startupConcurrency = flag.Int("startup_concurrency", 0, "Max number of containers found on startup whose first stats and spec are collected at once. 0 doesn't limit them")
+
+// Spreads the first housekeepings of the containers found on startup, which
+// otherwise all run at once and load the host and the Docker daemon.
+type startupStagger struct {
+	start time.Time
+	ramp  time.Duration
+	// Number of containers found, and of those already given their first
+	// housekeeping.
+	total   int
+	created int
+	// Semaphore of the first housekeepings, nil if they aren't limited.
+	slots chan struct{}
+}
+
+func newStartupStagger(total int, ramp time.Duration, concurrency int) *startupStagger {
+	stagger := &startupStagger{
+		start: time.Now(),
+		ramp:  ramp,
+		total: total,
+	}
+	if concurrency > 0 {
+		stagger.slots = make(chan struct{}, concurrency)
+	}
+	return stagger
+}
+
+// Sets the time of the first housekeeping of the next container found on
+// startup, and the semaphore it holds.
+func (self *startupStagger) stagger(cont *containerData) {
+	if self.total > 0 && self.created < self.total {
+		cont.firstHousekeeping = self.start.Add(self.ramp * time.Duration(self.created) / time.Duration(self.total))
+	}
+	cont.startupSlots = self.slots
+	self.created++
+}
+
+// Creates the containers found on startup, staggering their first
+// housekeepings with --startup_ramp and --startup_concurrency.
+func (m *manager) recoverContainers() error {
+	added, _, err := m.getContainersDiff("/")
+	if err != nil {
+		return err
+	}
+	if *startupRamp > 0 || *startupConcurrency > 0 {
+		logging.Infof("Spreading the first housekeepings of %d containers over %v, %d at most at once", len(added), *startupRamp, *startupConcurrency)
+		m.startup = newStartupStagger(len(added), *startupRamp, *startupConcurrency)
+	}
+	for _, cont := range added {
+		err = m.createContainer(cont.Name)
+		if err != nil {
+			logging.Errorf("Failed to create existing container: %s: %s", cont.Name, err)
+		}
+	}
+	m.containersLock.Lock()
+	m.startup = nil
+	m.containersLock.Unlock()
+	return nil
+}
+
+// Returns the time of the first housekeeping of the container, right now
+// unless it was found on startup.
+func (c *containerData) firstHousekeepingTime() time.Time {
+	if now := time.Now(); c.firstHousekeeping.Before(now) {
+		return now
+	}
+	return c.firstHousekeeping
+}
+
+// Waits for the first housekeeping of the container. Returns false if the
+// housekeeping is stopped meanwhile.
+func (c *containerData) waitFirstHousekeeping() bool {
+	delay := c.firstHousekeepingTime().Sub(time.Now())
+	if delay <= 0 {
+		return true
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-c.stop:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// Takes a slot of the first housekeepings on startup, if the container was
+// found then and they are limited. Returns the function releasing it.
+func (c *containerData) takeStartupSlot() func() {
+	slots := c.startupSlots
+	if slots == nil {
+		return func() {}
+	}
+	c.startupSlots = nil
+	slots <- struct{}{}
+	return func() { <-slots }
+}
@@ -0,0 +1,91 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"github.com/google/cadvisor/events"
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Events waiting to be written to the storage driver beyond this are dropped.
+const storageEventQueueSize = 1000
+
+var StorageEventsDropped = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "cadvisor_storage_events_dropped_total",
+	Help: "Number of events not written to the storage driver because too many were waiting.",
+})
+
+// Writes events to the storage driver from its own goroutine, so that a slow
+// backend doesn't hold up the event watchers and the container lifecycle.
+type storageEventWriter struct {
+	queue chan *info.Event
+	write func(event *info.Event) error
+}
+
+func newStorageEventWriter(write func(event *info.Event) error, queueSize int) *storageEventWriter {
+	w := &storageEventWriter{
+		queue: make(chan *info.Event, queueSize),
+		write: write,
+	}
+	go w.loop()
+	return w
+}
+
+// Queues the event unless the queue is full.
+func (self *storageEventWriter) enqueue(event *info.Event) {
+	select {
+	case self.queue <- event:
+	default:
+		StorageEventsDropped.Inc()
+		logging.V(2).Infof("Dropping event %v, too many events waiting to be written to storage", event)
+	}
+}
+
+// Writes the queued events in order.
+func (self *storageEventWriter) loop() {
+	for event := range self.queue {
+		if err := self.write(event); err != nil {
+			logging.Errorf("failed to write event to storage: %v", err)
+		}
+	}
+}
+
+// Writes all the events to the storage driver as they occur.
+func (self *manager) writeEventsToStorage() error {
+	if !self.memoryCache.SupportsEvents() {
+		logging.Warningf("The storage driver does not support events, not writing events to it")
+		return nil
+	}
+	request := events.NewRequest()
+	request.ContainerName = "/"
+	request.IncludeSubcontainers = true
+	for _, eventType := range []info.EventType{info.EventOom, info.EventOomKill, info.EventContainerCreation, info.EventContainerDeletion, info.EventAnomaly, info.EventContainerExit, info.EventContainerHealth, info.EventHousekeepingTimeout, info.EventMachineChange, info.EventThinPoolExhaustion, info.EventCpusetChange, info.EventLimitChange, info.EventSelfMemoryPressure} {
+		request.EventType[eventType] = true
+	}
+	eventChannel, err := self.eventHandler.WatchEvents(request)
+	if err != nil {
+		return err
+	}
+	writer := newStorageEventWriter(self.memoryCache.AddEvent, storageEventQueueSize)
+	go func() {
+		for event := range eventChannel.GetChannel() {
+			writer.enqueue(event)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"testing"
+	"time"
+
+	info "github.com/google/cadvisor/info/v1"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorageEventWriter(t *testing.T) {
+	dropped := func() float64 {
+		var metric dto.Metric
+		require.NoError(t, StorageEventsDropped.Write(&metric))
+		return metric.GetCounter().GetValue()
+	}
+	before := dropped()
+
+	// The first write blocks until released, the next event waits in the
+	// queue and the last one is dropped.
+	release := make(chan struct{})
+	written := make(chan *info.Event, 3)
+	w := newStorageEventWriter(func(event *info.Event) error {
+		<-release
+		written <- event
+		return nil
+	}, 1)
+	events := []*info.Event{{ContainerName: "/a"}, {ContainerName: "/b"}, {ContainerName: "/c"}}
+	w.enqueue(events[0])
+	deadline := time.Now().Add(5 * time.Second)
+	for len(w.queue) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the first write")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	w.enqueue(events[1])
+	w.enqueue(events[2])
+	assert.Equal(t, before+1, dropped())
+
+	close(release)
+	assert.Equal(t, events[0], <-written)
+	assert.Equal(t, events[1], <-written)
+	select {
+	case event := <-written:
+		t.Errorf("unexpected write of %v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"path"
+
+	"github.com/google/cadvisor/info/v2"
+)
+
+func (self *manager) GetSummaries(containerName string, options v2.RequestOptions) (map[string]v2.ContainerSummary, error) {
+	conts, err := self.getRequestedContainers(containerName, options)
+	if err != nil {
+		return nil, err
+	}
+	stats := make(map[string]v2.DerivedStats, len(conts))
+	for name, cont := range conts {
+		if cont.summaryReader == nil && options.Recursive {
+			continue
+		}
+		d, err := cont.DerivedStats()
+		if err != nil {
+			return nil, err
+		}
+		stats[name] = d
+	}
+	return summarize(stats), nil
+}
+
+// Aggregates the derived stats of the direct subcontainers of each container,
+// its closest descendants among the containers.
+func summarize(stats map[string]v2.DerivedStats) map[string]v2.ContainerSummary {
+	summaries := make(map[string]v2.ContainerSummary, len(stats))
+	for name, derived := range stats {
+		summaries[name] = v2.ContainerSummary{DerivedStats: derived}
+	}
+	for name, derived := range stats {
+		parent, ok := closestAncestor(name, stats)
+		if !ok {
+			continue
+		}
+		summary := summaries[parent]
+		if summary.Subcontainers == nil {
+			summary.Subcontainers = &v2.DerivedStats{}
+			// Windows are only complete if they are for every subcontainer.
+			summary.Subcontainers.MinuteUsage.PercentComplete = 100
+			summary.Subcontainers.HourUsage.PercentComplete = 100
+			summary.Subcontainers.DayUsage.PercentComplete = 100
+		}
+		addDerivedStats(summary.Subcontainers, derived)
+		summary.NumSubcontainers++
+		summaries[parent] = summary
+	}
+	return summaries
+}
+
+// Returns the closest ancestor of the container among the containers.
+func closestAncestor(name string, stats map[string]v2.DerivedStats) (string, bool) {
+	for name != "/" {
+		name = path.Dir(name)
+		if _, ok := stats[name]; ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func addDerivedStats(sum *v2.DerivedStats, derived v2.DerivedStats) {
+	if derived.Timestamp.After(sum.Timestamp) {
+		sum.Timestamp = derived.Timestamp
+	}
+	sum.LatestUsage.Cpu += derived.LatestUsage.Cpu
+	sum.LatestUsage.Memory += derived.LatestUsage.Memory
+	addUsage(&sum.MinuteUsage, derived.MinuteUsage)
+	addUsage(&sum.HourUsage, derived.HourUsage)
+	addUsage(&sum.DayUsage, derived.DayUsage)
+}
+
+func addUsage(sum *v2.Usage, usage v2.Usage) {
+	if usage.PercentComplete < sum.PercentComplete {
+		sum.PercentComplete = usage.PercentComplete
+	}
+	addPercentiles(&sum.Cpu, usage.Cpu)
+	addPercentiles(&sum.Memory, usage.Memory)
+}
+
+func addPercentiles(sum *v2.Percentiles, percentiles v2.Percentiles) {
+	if !percentiles.Present {
+		return
+	}
+	sum.Present = true
+	sum.Mean += percentiles.Mean
+	sum.Max += percentiles.Max
+	sum.Fifty += percentiles.Fifty
+	sum.Ninety += percentiles.Ninety
+	sum.NinetyFive += percentiles.NinetyFive
+}
@@ -0,0 +1,35 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !linux
+
+package manager
+
+import (
+	"fmt"
+	"time"
+)
+
+// cAdvisor is not in a cgroup outside of Linux.
+func getSelfContainer() (string, error) {
+	return "/", nil
+}
+
+func selfCpuTime() (time.Duration, error) {
+	return 0, fmt.Errorf("the cpu usage of processes is only read on Linux")
+}
+
+func getKernelVersion() string {
+	return "Unknown"
+}
@@ -0,0 +1,139 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"flag"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/google/cadvisor/fs"
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/logging"
+)
+
+var (
+	thinPoolCheckInterval = flag.Duration("thin_pool_check_interval", time.Minute, "Interval at which the data and metadata usage of the devicemapper thin-pools is checked. 0 disables the check")
+	thinPoolThreshold     = flag.Float64("thin_pool_threshold", 90, "Percentage of data or metadata usage of a devicemapper thin-pool at which a thinPoolExhaustion event is added")
+)
+
+// The devicemapper thin-pools at their last check and the ones that reached
+// the threshold.
+type thinPoolMonitor struct {
+	lock      sync.Mutex
+	pools     []fs.ThinPool
+	exhausted map[string]bool
+}
+
+// Returns whether dmsetup, used to read the thin-pools, is installed.
+func dmsetupAvailable() bool {
+	if _, err := exec.LookPath("dmsetup"); err != nil {
+		logging.Infof("Not checking devicemapper thin-pools: %v", err)
+		return false
+	}
+	return true
+}
+
+// Checks the thin-pools every interval.
+func (m *manager) checkThinPoolsLoop(interval time.Duration, quit chan error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			pools, err := fs.GetThinPools()
+			if err != nil {
+				logging.Warningf("Failed to check devicemapper thin-pools: %v", err)
+				continue
+			}
+			m.checkThinPools(pools, *thinPoolThreshold)
+		case <-quit:
+			quit <- nil
+			logging.Infof("Exiting thin-pool check thread")
+			return
+		}
+	}
+}
+
+// Records the usage of the thin-pools and adds a thinPoolExhaustion event
+// for each pool whose data or metadata usage reached the threshold, or that
+// is no longer writable, since its previous check. A pool is reported again
+// once it went back under the threshold.
+func (m *manager) checkThinPools(pools []fs.ThinPool, threshold float64) {
+	monitor := m.thinPoolMonitor
+	monitor.lock.Lock()
+	defer monitor.lock.Unlock()
+	monitor.pools = pools
+	exhausted := make(map[string]bool, len(pools))
+	for _, pool := range pools {
+		dataUsage := percentOf(pool.DataUsed, pool.DataTotal)
+		metadataUsage := percentOf(pool.MetadataUsed, pool.MetadataTotal)
+		if dataUsage < threshold && metadataUsage < threshold && pool.Mode == "rw" {
+			continue
+		}
+		exhausted[pool.Name] = true
+		if monitor.exhausted[pool.Name] {
+			continue
+		}
+		logging.Warningf("Thin-pool %q is nearly exhausted: %.1f%% of data and %.1f%% of metadata used, mode %q", pool.Name, dataUsage, metadataUsage, pool.Mode)
+		err := m.eventHandler.AddEvent(&info.Event{
+			ContainerName: "/",
+			Timestamp:     time.Now(),
+			EventType:     info.EventThinPoolExhaustion,
+			EventData: info.EventData{
+				ThinPoolExhaustion: &info.ThinPoolExhaustionEventData{
+					Pool:          pool.Name,
+					DataUsage:     dataUsage,
+					MetadataUsage: metadataUsage,
+					Threshold:     threshold,
+					Mode:          pool.Mode,
+				},
+			},
+		})
+		if err != nil {
+			logging.Errorf("failed to add thin-pool exhaustion event: %v", err)
+		}
+	}
+	monitor.exhausted = exhausted
+}
+
+func percentOf(used, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(used) * 100 / float64(total)
+}
+
+// Adds the usage of the thin-pools at their last check, if they are checked.
+func (m *manager) addThinPoolStats(stats *v2.HostStats) {
+	monitor := m.thinPoolMonitor
+	if monitor == nil {
+		return
+	}
+	monitor.lock.Lock()
+	defer monitor.lock.Unlock()
+	for _, pool := range monitor.pools {
+		stats.ThinPools = append(stats.ThinPools, v2.ThinPoolStats{
+			Name:          pool.Name,
+			DataUsed:      pool.DataUsed,
+			DataTotal:     pool.DataTotal,
+			MetadataUsed:  pool.MetadataUsed,
+			MetadataTotal: pool.MetadataTotal,
+			Mode:          pool.Mode,
+		})
+	}
+}
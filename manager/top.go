@@ -0,0 +1,126 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/info/v2"
+)
+
+// Resources containers can be ranked by.
+const (
+	TopByCpu     = "cpu"
+	TopByMemory  = "memory"
+	TopByNetwork = "network"
+)
+
+func (self *manager) GetTopContainers(containerName string, options v2.RequestOptions, by string, window time.Duration) ([]v2.ContainerUsage, error) {
+	var less func(a, b *v2.ContainerUsage) bool
+	switch by {
+	case TopByCpu:
+		less = func(a, b *v2.ContainerUsage) bool { return a.Cpu > b.Cpu }
+	case TopByMemory:
+		less = func(a, b *v2.ContainerUsage) bool { return a.Memory > b.Memory }
+	case TopByNetwork:
+		less = func(a, b *v2.ContainerUsage) bool { return a.Network > b.Network }
+	default:
+		return nil, fmt.Errorf("unknown resource %q", by)
+	}
+
+	options.Recursive = true
+	conts, err := self.getRequestedContainers(containerName, options)
+	if err != nil {
+		return nil, err
+	}
+	start := time.Now().Add(-window)
+	usages := make([]v2.ContainerUsage, 0, len(conts))
+	for name, cont := range conts {
+		// Only rank the subcontainers of the requested container.
+		if options.IdType == v2.TypeName && name == containerName {
+			continue
+		}
+		stats, err := self.memoryCache.RecentStats(name, start, time.Time{}, -1)
+		if err != nil || len(stats) == 0 {
+			continue
+		}
+		usage := containerUsage(stats)
+		usage.Name = name
+		usage.Aliases = cont.info.Aliases
+		usage.Namespace = cont.info.Namespace
+		usages = append(usages, usage)
+	}
+
+	sort.Sort(containerUsageSorter{usages, less})
+	if options.Count >= 0 && len(usages) > options.Count {
+		usages = usages[:options.Count]
+	}
+	return usages, nil
+}
+
+// Computes the usage over the given samples, ordered from oldest to newest.
+func containerUsage(stats []*info.ContainerStats) v2.ContainerUsage {
+	first := stats[0]
+	last := stats[len(stats)-1]
+	usage := v2.ContainerUsage{
+		Memory: last.Memory.WorkingSet,
+	}
+	elapsed := last.Since(first)
+	if elapsed <= 0 {
+		return usage
+	}
+	if last.Cpu.Usage.Total > first.Cpu.Usage.Total {
+		// Nanoseconds of cpu time per second are cpu milliseconds per second scaled by 10^6.
+		usage.Cpu = uint64(float64(last.Cpu.Usage.Total-first.Cpu.Usage.Total) / elapsed.Seconds() / 1e6)
+	}
+	firstBytes := networkBytes(&first.Network)
+	lastBytes := networkBytes(&last.Network)
+	if lastBytes > firstBytes {
+		usage.Network = uint64(float64(lastBytes-firstBytes) / elapsed.Seconds())
+	}
+	return usage
+}
+
+// Total bytes received and transmitted over all interfaces.
+func networkBytes(stats *info.NetworkStats) uint64 {
+	if len(stats.Interfaces) == 0 {
+		return stats.RxBytes + stats.TxBytes
+	}
+	var total uint64
+	for _, iface := range stats.Interfaces {
+		total += iface.RxBytes + iface.TxBytes
+	}
+	return total
+}
+
+type containerUsageSorter struct {
+	usages []v2.ContainerUsage
+	less   func(a, b *v2.ContainerUsage) bool
+}
+
+func (self containerUsageSorter) Len() int {
+	return len(self.usages)
+}
+
+func (self containerUsageSorter) Swap(i, j int) {
+	self.usages[i], self.usages[j] = self.usages[j], self.usages[i]
+}
+
+func (self containerUsageSorter) Less(i, j int) bool {
+	return self.less(&self.usages[i], &self.usages[j])
+}
@@ -0,0 +1,212 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"flag"
+	"sync"
+	"time"
+
+	"github.com/google/cadvisor/container"
+	"github.com/google/cadvisor/logging"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var containerEventDebounce = flag.Duration("container_event_debounce", 0, "Delay after the last watch event of a container before its handler is created or destroyed, during which its events are coalesced, e.g. skipping the containers created and destroyed within it. 0 processes the events as soon as a worker is free")
+var containerEventWorkers = flag.Int("container_event_workers", 4, "Number of workers creating and destroying the containers on watch events")
+
+// Number of containers whose watch events wait to be processed.
+var ContainerEventQueueDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+	Name: "cadvisor_container_events_queue_depth",
+	Help: "Number of containers whose watch events wait to be processed.",
+})
+
+// Number of watch events received, and coalesced with a later event of the
+// same container.
+var ContainerEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "cadvisor_container_events_total",
+	Help: "Number of container watch events received, by type.",
+}, []string{"type"})
+var ContainerEventsCoalesced = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "cadvisor_container_events_coalesced_total",
+	Help: "Number of container watch events coalesced with a later event of the same container.",
+})
+
+// The watch events of a container not processed yet, coalesced.
+type pendingContainerEvents struct {
+	// Whether to destroy the container, then whether to create it. A
+	// deletion followed by an addition recreates the container.
+	destroy bool
+	create  bool
+	// Times the deletion and the addition were received, zero without them.
+	deleted time.Time
+	added   time.Time
+	// Time after which the events are processed.
+	due time.Time
+	// Whether the container is queued for the workers.
+	queued bool
+}
+
+// Coalesces the watch events of each container during the debounce delay
+// and processes them on a pool of workers, so that the watches aren't
+// blocked by the creation of containers. The events of a container are
+// processed in order, one at a time.
+type containerEventQueue struct {
+	debounce time.Duration
+	process  func(name string, events pendingContainerEvents)
+
+	lock     sync.Mutex
+	cond     *sync.Cond
+	pending  map[string]*pendingContainerEvents
+	inFlight map[string]bool
+	// Containers whose events are due, in order.
+	queue  []string
+	closed bool
+}
+
+func newContainerEventQueue(debounce time.Duration, workers int, process func(name string, events pendingContainerEvents)) *containerEventQueue {
+	self := &containerEventQueue{
+		debounce: debounce,
+		process:  process,
+		pending:  make(map[string]*pendingContainerEvents),
+		inFlight: make(map[string]bool),
+	}
+	self.cond = sync.NewCond(&self.lock)
+	if workers < 1 {
+		workers = 1
+	}
+	for i := 0; i < workers; i++ {
+		go self.work()
+	}
+	return self
+}
+
+// Adds a watch event, coalesced with the pending events of its container.
+func (self *containerEventQueue) add(event container.SubcontainerEvent) {
+	eventType := "add"
+	if event.EventType == container.SubcontainerDelete {
+		eventType = "delete"
+	}
+	ContainerEvents.WithLabelValues(eventType).Inc()
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if self.closed {
+		return
+	}
+	pending, ok := self.pending[event.Name]
+	if ok {
+		ContainerEventsCoalesced.Inc()
+	} else {
+		pending = &pendingContainerEvents{}
+		self.pending[event.Name] = pending
+		ContainerEventQueueDepth.Set(float64(len(self.pending)))
+	}
+	now := time.Now()
+	if event.EventType == container.SubcontainerDelete {
+		if !pending.destroy {
+			pending.deleted = now
+		}
+		pending.destroy = true
+		pending.create = false
+		pending.added = time.Time{}
+	} else {
+		if !pending.create {
+			pending.added = now
+		}
+		pending.create = true
+	}
+	pending.due = now.Add(self.debounce)
+	if self.debounce <= 0 {
+		self.release(event.Name)
+		return
+	}
+	time.AfterFunc(self.debounce, func() {
+		self.lock.Lock()
+		defer self.lock.Unlock()
+		self.release(event.Name)
+	})
+}
+
+// Queues the events of the container for the workers once they are due and
+// its previous events were processed. Must be called with lock held.
+func (self *containerEventQueue) release(name string) {
+	pending, ok := self.pending[name]
+	if !ok || pending.queued || self.closed || self.inFlight[name] || time.Now().Before(pending.due) {
+		return
+	}
+	pending.queued = true
+	self.queue = append(self.queue, name)
+	self.cond.Signal()
+}
+
+func (self *containerEventQueue) work() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	for {
+		for len(self.queue) == 0 && !self.closed {
+			self.cond.Wait()
+		}
+		if self.closed {
+			return
+		}
+		name := self.queue[0]
+		self.queue = self.queue[1:]
+		pending := self.pending[name]
+		delete(self.pending, name)
+		ContainerEventQueueDepth.Set(float64(len(self.pending)))
+		self.inFlight[name] = true
+
+		self.lock.Unlock()
+		self.process(name, *pending)
+		self.lock.Lock()
+
+		delete(self.inFlight, name)
+		// Events received meanwhile.
+		self.release(name)
+	}
+}
+
+// Stops the workers once they processed their current events. The pending
+// events are dropped.
+func (self *containerEventQueue) stop() {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.closed = true
+	self.cond.Broadcast()
+	if len(self.pending) > 0 {
+		logging.V(2).Infof("Dropping the pending watch events of %d containers", len(self.pending))
+	}
+}
+
+// Destroys, then creates the container, on its watch events.
+func (self *manager) processContainerEvents(name string, events pendingContainerEvents) {
+	if events.destroy {
+		self.containersLock.RLock()
+		_, tracked := self.containers[namespacedContainerName{Name: name}]
+		self.containersLock.RUnlock()
+		if err := self.destroyContainer(name); err != nil {
+			logging.Warningf("Failed to process watch event: %v", err)
+		} else if tracked {
+			ContainerDestructionLatency.Observe(time.Since(events.deleted).Seconds())
+		}
+	}
+	if events.create {
+		if err := self.createWatchedContainer(name, events.added); err != nil {
+			logging.Warningf("Failed to process watch event: %v", err)
+		}
+	}
+}
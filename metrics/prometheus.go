@@ -20,8 +20,9 @@ import (
 	"time"
 
 	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/logging"
 
-	"github.com/golang/glog"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -29,6 +30,8 @@ import (
 type infoProvider interface {
 	// Get information about all subcontainers of the specified container (includes self).
 	SubcontainersInfo(containerName string, query *info.ContainerInfoRequest) ([]*info.ContainerInfo, error)
+	// Get the errors of the collection of the stats of the specified containers.
+	GetContainerHealth(containerName string, options v2.RequestOptions) (map[string]v2.ContainerHealth, error)
 	// Get information about the version.
 	GetVersionInfo() (*info.VersionInfo, error)
 	// Get information about the machine.
@@ -44,6 +47,15 @@ type metricValue struct {
 
 type metricValues []metricValue
 
+// schedstatValues is a helper method for the scheduler statistics, which are
+// not collected by default.
+func schedstatValues(s *info.ContainerStats, value float64) metricValues {
+	if s.Cpu.Schedstat == (info.CpuSchedstat{}) {
+		return nil
+	}
+	return metricValues{{value: value}}
+}
+
 // fsValues is a helper method for assembling per-filesystem stats.
 func fsValues(fsStats []info.FsStats, valueFn func(*info.FsStats) float64) metricValues {
 	values := make(metricValues, 0, len(fsStats))
@@ -63,7 +75,10 @@ type containerMetric struct {
 	help        string
 	valueType   prometheus.ValueType
 	extraLabels []string
-	getValues   func(s *info.ContainerStats) metricValues
+	// Subsystem of the stats the values are read from, whose metrics are
+	// skipped when they could not be collected. Empty for the cgroup stats.
+	subsystem string
+	getValues func(s *info.ContainerStats) metricValues
 }
 
 func (cm *containerMetric) desc(baseLabels []string) *prometheus.Desc {
@@ -127,6 +142,30 @@ func NewPrometheusCollector(infoProvider infoProvider, f ContainerNameToLabelsFu
 					}
 					return values
 				},
+			}, {
+				name:      "container_cpu_schedstat_run_seconds_total",
+				subsystem: info.SchedstatSubsystem,
+				help:      "Time duration the processes of the container have run on the CPU.",
+				valueType: prometheus.CounterValue,
+				getValues: func(s *info.ContainerStats) metricValues {
+					return schedstatValues(s, float64(s.Cpu.Schedstat.RunTime)/float64(time.Second))
+				},
+			}, {
+				name:      "container_cpu_schedstat_runqueue_seconds_total",
+				subsystem: info.SchedstatSubsystem,
+				help:      "Time duration processes of the container have been waiting on a runqueue.",
+				valueType: prometheus.CounterValue,
+				getValues: func(s *info.ContainerStats) metricValues {
+					return schedstatValues(s, float64(s.Cpu.Schedstat.RunqueueTime)/float64(time.Second))
+				},
+			}, {
+				name:      "container_cpu_schedstat_run_periods_total",
+				subsystem: info.SchedstatSubsystem,
+				help:      "Number of times processes of the cgroup have run on the cpu",
+				valueType: prometheus.CounterValue,
+				getValues: func(s *info.ContainerStats) metricValues {
+					return schedstatValues(s, float64(s.Cpu.Schedstat.RunPeriods))
+				},
 			}, {
 				name:      "container_memory_cache",
 				help:      "Number of bytes of page cache memory.",
@@ -189,6 +228,7 @@ func NewPrometheusCollector(infoProvider infoProvider, f ContainerNameToLabelsFu
 				},
 			}, {
 				name:        "container_fs_limit_bytes",
+				subsystem:   info.FilesystemStatsSubsystem,
 				help:        "Number of bytes that can be consumed by the container on this filesystem.",
 				valueType:   prometheus.GaugeValue,
 				extraLabels: []string{"device"},
@@ -199,6 +239,7 @@ func NewPrometheusCollector(infoProvider infoProvider, f ContainerNameToLabelsFu
 				},
 			}, {
 				name:        "container_fs_usage_bytes",
+				subsystem:   info.FilesystemStatsSubsystem,
 				help:        "Number of bytes that are consumed by the container on this filesystem.",
 				valueType:   prometheus.GaugeValue,
 				extraLabels: []string{"device"},
@@ -209,6 +250,7 @@ func NewPrometheusCollector(infoProvider infoProvider, f ContainerNameToLabelsFu
 				},
 			}, {
 				name:        "container_fs_reads_total",
+				subsystem:   info.FilesystemStatsSubsystem,
 				help:        "Cumulative count of reads completed",
 				valueType:   prometheus.CounterValue,
 				extraLabels: []string{"device"},
@@ -219,6 +261,7 @@ func NewPrometheusCollector(infoProvider infoProvider, f ContainerNameToLabelsFu
 				},
 			}, {
 				name:        "container_fs_sector_reads_total",
+				subsystem:   info.FilesystemStatsSubsystem,
 				help:        "Cumulative count of sector reads completed",
 				valueType:   prometheus.CounterValue,
 				extraLabels: []string{"device"},
@@ -229,6 +272,7 @@ func NewPrometheusCollector(infoProvider infoProvider, f ContainerNameToLabelsFu
 				},
 			}, {
 				name:        "container_fs_reads_merged_total",
+				subsystem:   info.FilesystemStatsSubsystem,
 				help:        "Cumulative count of reads merged",
 				valueType:   prometheus.CounterValue,
 				extraLabels: []string{"device"},
@@ -239,6 +283,7 @@ func NewPrometheusCollector(infoProvider infoProvider, f ContainerNameToLabelsFu
 				},
 			}, {
 				name:        "container_fs_read_seconds_total",
+				subsystem:   info.FilesystemStatsSubsystem,
 				help:        "Cumulative count of seconds spent reading",
 				valueType:   prometheus.CounterValue,
 				extraLabels: []string{"device"},
@@ -249,6 +294,7 @@ func NewPrometheusCollector(infoProvider infoProvider, f ContainerNameToLabelsFu
 				},
 			}, {
 				name:        "container_fs_writes_total",
+				subsystem:   info.FilesystemStatsSubsystem,
 				help:        "Cumulative count of writes completed",
 				valueType:   prometheus.CounterValue,
 				extraLabels: []string{"device"},
@@ -259,6 +305,7 @@ func NewPrometheusCollector(infoProvider infoProvider, f ContainerNameToLabelsFu
 				},
 			}, {
 				name:        "container_fs_sector_writes_total",
+				subsystem:   info.FilesystemStatsSubsystem,
 				help:        "Cumulative count of sector writes completed",
 				valueType:   prometheus.CounterValue,
 				extraLabels: []string{"device"},
@@ -269,6 +316,7 @@ func NewPrometheusCollector(infoProvider infoProvider, f ContainerNameToLabelsFu
 				},
 			}, {
 				name:        "container_fs_writes_merged_total",
+				subsystem:   info.FilesystemStatsSubsystem,
 				help:        "Cumulative count of writes merged",
 				valueType:   prometheus.CounterValue,
 				extraLabels: []string{"device"},
@@ -279,6 +327,7 @@ func NewPrometheusCollector(infoProvider infoProvider, f ContainerNameToLabelsFu
 				},
 			}, {
 				name:        "container_fs_write_seconds_total",
+				subsystem:   info.FilesystemStatsSubsystem,
 				help:        "Cumulative count of seconds spent writing",
 				valueType:   prometheus.CounterValue,
 				extraLabels: []string{"device"},
@@ -289,6 +338,7 @@ func NewPrometheusCollector(infoProvider infoProvider, f ContainerNameToLabelsFu
 				},
 			}, {
 				name:        "container_fs_io_current",
+				subsystem:   info.FilesystemStatsSubsystem,
 				help:        "Number of I/Os currently in progress",
 				valueType:   prometheus.GaugeValue,
 				extraLabels: []string{"device"},
@@ -299,6 +349,7 @@ func NewPrometheusCollector(infoProvider infoProvider, f ContainerNameToLabelsFu
 				},
 			}, {
 				name:        "container_fs_io_time_seconds_total",
+				subsystem:   info.FilesystemStatsSubsystem,
 				help:        "Cumulative count of seconds spent doing I/Os",
 				valueType:   prometheus.CounterValue,
 				extraLabels: []string{"device"},
@@ -309,6 +360,7 @@ func NewPrometheusCollector(infoProvider infoProvider, f ContainerNameToLabelsFu
 				},
 			}, {
 				name:        "container_fs_io_time_weighted_seconds_total",
+				subsystem:   info.FilesystemStatsSubsystem,
 				help:        "Cumulative weighted I/O time in seconds",
 				valueType:   prometheus.CounterValue,
 				extraLabels: []string{"device"},
@@ -319,6 +371,7 @@ func NewPrometheusCollector(infoProvider infoProvider, f ContainerNameToLabelsFu
 				},
 			}, {
 				name:        "container_network_receive_bytes_total",
+				subsystem:   info.NetworkStatsSubsystem,
 				help:        "Cumulative count of bytes received",
 				valueType:   prometheus.CounterValue,
 				extraLabels: []string{"interface"},
@@ -334,6 +387,7 @@ func NewPrometheusCollector(infoProvider infoProvider, f ContainerNameToLabelsFu
 				},
 			}, {
 				name:        "container_network_receive_packets_total",
+				subsystem:   info.NetworkStatsSubsystem,
 				help:        "Cumulative count of packets received",
 				valueType:   prometheus.CounterValue,
 				extraLabels: []string{"interface"},
@@ -349,6 +403,7 @@ func NewPrometheusCollector(infoProvider infoProvider, f ContainerNameToLabelsFu
 				},
 			}, {
 				name:        "container_network_receive_packets_dropped_total",
+				subsystem:   info.NetworkStatsSubsystem,
 				help:        "Cumulative count of packets dropped while receiving",
 				valueType:   prometheus.CounterValue,
 				extraLabels: []string{"interface"},
@@ -364,6 +419,7 @@ func NewPrometheusCollector(infoProvider infoProvider, f ContainerNameToLabelsFu
 				},
 			}, {
 				name:        "container_network_receive_errors_total",
+				subsystem:   info.NetworkStatsSubsystem,
 				help:        "Cumulative count of errors encountered while receiving",
 				valueType:   prometheus.CounterValue,
 				extraLabels: []string{"interface"},
@@ -379,6 +435,7 @@ func NewPrometheusCollector(infoProvider infoProvider, f ContainerNameToLabelsFu
 				},
 			}, {
 				name:        "container_network_transmit_bytes_total",
+				subsystem:   info.NetworkStatsSubsystem,
 				help:        "Cumulative count of bytes transmitted",
 				valueType:   prometheus.CounterValue,
 				extraLabels: []string{"interface"},
@@ -394,6 +451,7 @@ func NewPrometheusCollector(infoProvider infoProvider, f ContainerNameToLabelsFu
 				},
 			}, {
 				name:        "container_network_transmit_packets_total",
+				subsystem:   info.NetworkStatsSubsystem,
 				help:        "Cumulative count of packets transmitted",
 				valueType:   prometheus.CounterValue,
 				extraLabels: []string{"interface"},
@@ -409,6 +467,7 @@ func NewPrometheusCollector(infoProvider infoProvider, f ContainerNameToLabelsFu
 				},
 			}, {
 				name:        "container_network_transmit_packets_dropped_total",
+				subsystem:   info.NetworkStatsSubsystem,
 				help:        "Cumulative count of packets dropped while transmitting",
 				valueType:   prometheus.CounterValue,
 				extraLabels: []string{"interface"},
@@ -424,6 +483,7 @@ func NewPrometheusCollector(infoProvider infoProvider, f ContainerNameToLabelsFu
 				},
 			}, {
 				name:        "container_network_transmit_errors_total",
+				subsystem:   info.NetworkStatsSubsystem,
 				help:        "Cumulative count of errors encountered while transmitting",
 				valueType:   prometheus.CounterValue,
 				extraLabels: []string{"interface"},
@@ -503,9 +563,14 @@ func (c *PrometheusCollector) collectContainersInfo(ch chan<- prometheus.Metric)
 	containers, err := c.infoProvider.SubcontainersInfo("/", &info.ContainerInfoRequest{NumStats: 1})
 	if err != nil {
 		c.errors.Set(1)
-		glog.Warningf("Couldn't get containers: %s", err)
+		logging.Warningf("Couldn't get containers: %s", err)
 		return
 	}
+	health, err := c.infoProvider.GetContainerHealth("/", v2.RequestOptions{IdType: v2.TypeName, Recursive: true})
+	if err != nil {
+		c.errors.Set(1)
+		logging.Warningf("Couldn't get the health of containers: %s", err)
+	}
 	for _, container := range containers {
 		baseLabels := []string{"id"}
 		id := container.Name
@@ -559,9 +624,20 @@ func (c *PrometheusCollector) collectContainersInfo(ch chan<- prometheus.Metric)
 			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, specMemoryValue(container.Spec.Memory.SwapLimit), baseLabelValues...)
 		}
 
+		// Errors of the collection of the stats
+		if containerHealth, ok := health[container.Name]; ok {
+			desc = prometheus.NewDesc("container_stats_errors_total", "Cumulative count of failures to get the stats of the container.", baseLabels, nil)
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(containerHealth.Errors), baseLabelValues...)
+			desc = prometheus.NewDesc("container_stats_consecutive_errors", "Number of failures to get the stats of the container since they were last collected.", baseLabels, nil)
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(containerHealth.ConsecutiveErrors), baseLabelValues...)
+		}
+
 		// Now for the actual metrics
 		stats := container.Stats[0]
 		for _, cm := range c.containerMetrics {
+			if cm.subsystem != "" && !stats.Valid(cm.subsystem) {
+				continue
+			}
 			desc := cm.desc(baseLabels)
 			for _, metricValue := range cm.getValues(stats) {
 				ch <- prometheus.MustNewConstMetric(desc, cm.valueType, float64(metricValue.value), append(baseLabelValues, metricValue.labels...)...)
@@ -574,7 +650,7 @@ func (c *PrometheusCollector) collectVersionInfo(ch chan<- prometheus.Metric) {
 	versionInfo, err := c.infoProvider.GetVersionInfo()
 	if err != nil {
 		c.errors.Set(1)
-		glog.Warningf("Couldn't get version info: %s", err)
+		logging.Warningf("Couldn't get version info: %s", err)
 		return
 	}
 	ch <- prometheus.MustNewConstMetric(versionInfoDesc, prometheus.GaugeValue, 1, []string{versionInfo.KernelVersion, versionInfo.ContainerOsVersion, versionInfo.DockerVersion, versionInfo.CadvisorVersion, versionInfo.CadvisorRevision}...)
@@ -584,7 +660,7 @@ func (c *PrometheusCollector) collectMachineInfo(ch chan<- prometheus.Metric) {
 	machineInfo, err := c.infoProvider.GetMachineInfo()
 	if err != nil {
 		c.errors.Set(1)
-		glog.Warningf("Couldn't get machine info: %s", err)
+		logging.Warningf("Couldn't get machine info: %s", err)
 		return
 	}
 	ch <- prometheus.MustNewConstMetric(machineInfoCoresDesc, prometheus.GaugeValue, float64(machineInfo.NumCores))
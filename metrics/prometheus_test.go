@@ -15,6 +15,7 @@
 package metrics
 
 import (
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -24,6 +25,7 @@ import (
 	"time"
 
 	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/info/v2"
 
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -47,6 +49,16 @@ func (p testSubcontainersInfoProvider) GetMachineInfo() (*info.MachineInfo, erro
 	}, nil
 }
 
+func (p testSubcontainersInfoProvider) GetContainerHealth(string, v2.RequestOptions) (map[string]v2.ContainerHealth, error) {
+	return map[string]v2.ContainerHealth{
+		"testcontainer": {
+			Errors:            5,
+			ConsecutiveErrors: 2,
+			LastError:         "failed to read cgroup",
+		},
+	}, nil
+}
+
 func (p testSubcontainersInfoProvider) SubcontainersInfo(string, *info.ContainerInfoRequest) ([]*info.ContainerInfo, error) {
 	return []*info.ContainerInfo{
 		{
@@ -79,6 +91,11 @@ func (p testSubcontainersInfoProvider) SubcontainersInfo(string, *info.Container
 							User:   6,
 							System: 7,
 						},
+						Schedstat: info.CpuSchedstat{
+							RunTime:      1e9,
+							RunqueueTime: 2e9,
+							RunPeriods:   3,
+						},
 					},
 					Memory: info.MemoryStats{
 						Usage:      8,
@@ -206,3 +223,39 @@ func TestPrometheusCollector(t *testing.T) {
 		}
 	}
 }
+
+type failingNetworkInfoProvider struct {
+	testSubcontainersInfoProvider
+}
+
+func (p failingNetworkInfoProvider) SubcontainersInfo(name string, query *info.ContainerInfoRequest) ([]*info.ContainerInfo, error) {
+	containers, err := p.testSubcontainersInfoProvider.SubcontainersInfo(name, query)
+	for _, container := range containers {
+		container.Stats[0].SetError(info.NetworkStatsSubsystem, fmt.Errorf("no such file"))
+	}
+	return containers, err
+}
+
+func TestPrometheusCollectorSkipsInvalidStats(t *testing.T) {
+	c := NewPrometheusCollector(failingNetworkInfoProvider{}, nil)
+	ch := make(chan prometheus.Metric, 1000)
+	c.Collect(ch)
+	close(ch)
+
+	var cpu, network int
+	for metric := range ch {
+		desc := metric.Desc().String()
+		if strings.Contains(desc, `"container_network_`) {
+			network++
+		}
+		if strings.Contains(desc, `"container_cpu_usage_seconds_total"`) {
+			cpu++
+		}
+	}
+	if network != 0 {
+		t.Errorf("expected no network metrics when the network stats could not be collected, got %d", network)
+	}
+	if cpu == 0 {
+		t.Error("expected the cpu metrics despite the network error")
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Page for /compare/
+package pages
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
+	"github.com/google/cadvisor/manager"
+)
+
+const ComparePage = "/compare/"
+
+// Number of containers that can be compared at once.
+const (
+	minComparedContainers = 2
+	maxComparedContainers = 6
+)
+
+type compareOption struct {
+	Name     string
+	Text     string
+	Selected bool
+}
+
+type comparePageData struct {
+	Root          string
+	ContainersUrl string
+	// All the containers, to select the compared ones.
+	Containers []compareOption
+	// Escaped names of the compared containers.
+	Compared []string
+	Error    string
+}
+
+// Gets the names of the compared containers from the "container" options.
+func getComparedContainers(m manager.Manager, u *url.URL) ([]string, error) {
+	names := u.Query()["container"]
+	if len(names) == 0 {
+		return nil, nil
+	}
+	if len(names) < minComparedContainers || len(names) > maxComparedContainers {
+		return nil, fmt.Errorf("select between %d and %d containers to compare", minComparedContainers, maxComparedContainers)
+	}
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		if seen[name] {
+			return nil, fmt.Errorf("container %q is selected more than once", name)
+		}
+		seen[name] = true
+		if !m.Exists(name) {
+			return nil, fmt.Errorf("unknown container %q", name)
+		}
+	}
+	return names, nil
+}
+
+func serveComparePage(m manager.Manager, w http.ResponseWriter, u *url.URL) error {
+	containers, err := m.SubcontainersInfo("/", &info.ContainerInfoRequest{NumStats: 0})
+	if err != nil {
+		return fmt.Errorf("failed to list the containers: %v", err)
+	}
+	sort.Sort(byContainerName(containers))
+
+	rootDir := getRootDir("/")
+	data := &comparePageData{
+		Root:          rootDir,
+		ContainersUrl: path.Join(rootDir, ContainersPage),
+	}
+	compared, err := getComparedContainers(m, u)
+	if err != nil {
+		data.Error = err.Error()
+	}
+	selected := make(map[string]bool, len(compared))
+	for _, name := range compared {
+		selected[name] = true
+		data.Compared = append(data.Compared, escapeContainerName(name))
+	}
+	for _, cont := range containers {
+		data.Containers = append(data.Containers, compareOption{
+			Name:     cont.Name,
+			Text:     getContainerDisplayName(cont.ContainerReference),
+			Selected: selected[cont.Name],
+		})
+	}
+
+	err = compareTemplate.Execute(w, data)
+	if err != nil {
+		logging.Errorf("Failed to apply template: %s", err)
+	}
+	return nil
+}
+
+type byContainerName []*info.ContainerInfo
+
+func (s byContainerName) Len() int           { return len(s) }
+func (s byContainerName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byContainerName) Less(i, j int) bool { return s[i].Name < s[j].Name }
@@ -0,0 +1,101 @@
+// Copyright 2014 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pages
+
+const compareHtmlTemplate = `
+<html>
+  <head>
+    <title>cAdvisor - Compare containers</title>
+    <!-- Latest compiled and minified CSS -->
+    <link rel="stylesheet" href="{{.Root}}static/bootstrap-3.1.1.min.css">
+
+    <!-- Optional theme -->
+    <link rel="stylesheet" href="{{.Root}}static/bootstrap-theme-3.1.1.min.css">
+
+    <link rel="stylesheet" href="{{.Root}}static/containers.css">
+
+    <!-- Latest compiled and minified JavaScript -->
+    <script src="{{.Root}}static/jquery-1.10.2.min.js"></script>
+    <script src="{{.Root}}static/bootstrap-3.1.1.min.js"></script>
+    <script type="text/javascript" src="{{.Root}}static/google-jsapi.js"></script>
+
+    <script type="text/javascript" src="{{.Root}}static/containers.js"></script>
+  </head>
+  <body>
+    <div class="container theme-showcase" >
+      <a href="{{.Root}}" class="col-sm-12" id="logo">
+      </a>
+      <div class="col-sm-12">
+	<div class="page-header">
+	  <h1>Compare containers</h1>
+	</div>
+	<ol class="breadcrumb">
+	  <li><a href="{{.ContainersUrl}}">root</a></li>
+	  <li class="active">compare</li>
+	</ol>
+	<form id="compare-selection" method="get">
+	  <div class="form-group">
+	    <label for="compare-containers">Containers</label>
+	    <select id="compare-containers" name="container" class="form-control" multiple size="10">
+	      {{range $container := .Containers}}
+	      <option value="{{$container.Name}}"{{if $container.Selected}} selected{{end}}>{{$container.Text}}</option>
+	      {{end}}
+	    </select>
+	    <p class="help-block">Select 2 to 6 containers.</p>
+	  </div>
+	  <button type="submit" class="btn btn-primary btn-sm">Compare</button>
+	</form>
+	{{if .Error}}
+	<div class="alert alert-danger">{{.Error}}</div>
+	{{end}}
+      </div>
+      {{if .Compared}}
+      <div class="col-sm-12">
+	<div class="panel panel-primary">
+	  <div class="panel-heading">
+	    <h3 class="panel-title">CPU</h3>
+	  </div>
+	  <div class="panel-body">
+	    <h4>Total Usage</h4>
+	    <div id="compare-cpu-chart"></div>
+	  </div>
+	</div>
+	<div class="panel panel-primary">
+	  <div class="panel-heading">
+	    <h3 class="panel-title">Memory</h3>
+	  </div>
+	  <div class="panel-body">
+	    <h4>Working Set</h4>
+	    <div id="compare-memory-chart"></div>
+	  </div>
+	</div>
+	<div class="panel panel-primary">
+	  <div class="panel-heading">
+	    <h3 class="panel-title">Network</h3>
+	  </div>
+	  <div class="panel-body">
+	    <h4>Throughput</h4>
+	    <div id="compare-network-chart"></div>
+	  </div>
+	</div>
+      </div>
+      {{end}}
+    </div>
+    <script type="text/javascript">
+      startComparePage({{.Root}}, {{.Compared}});
+    </script>
+  </body>
+</html>
+`
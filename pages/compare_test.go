@@ -0,0 +1,60 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pages
+
+import (
+	"html"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComparePage(t *testing.T) {
+	m := newPagesManager("/a", "/b", "/c")
+
+	page := getPage(t, compareHandlerNoAuth(m), "/compare/")
+	for _, name := range []string{"/", "/a", "/b", "/c"} {
+		assert.True(t, strings.Contains(page, `<option value="`+name+`">`), "%s is not listed", name)
+	}
+	assert.False(t, strings.Contains(page, "alert-danger"))
+	assert.False(t, strings.Contains(page, `id="compare-cpu-chart"`))
+
+	page = getPage(t, compareHandlerNoAuth(m), "/compare/?container=/a&container=/c")
+	assert.True(t, strings.Contains(page, `<option value="/a" selected>`))
+	assert.True(t, strings.Contains(page, `<option value="/b">`))
+	assert.True(t, strings.Contains(page, `<option value="/c" selected>`))
+	assert.False(t, strings.Contains(page, "alert-danger"))
+	assert.True(t, strings.Contains(page, `id="compare-cpu-chart"`))
+}
+
+func TestComparePageInvalidSelection(t *testing.T) {
+	m := newPagesManager("/a", "/b", "/c")
+	for query, expected := range map[string]string{
+		"container=/a":                          "select between 2 and 6 containers to compare",
+		"container=/a&container=/a":             `container "/a" is selected more than once`,
+		"container=/a&container=/missing":       `unknown container "/missing"`,
+		"container=/a&container=/b&container=/": "",
+	} {
+		page := getPage(t, compareHandlerNoAuth(m), "/compare/?"+query)
+		if expected == "" {
+			assert.False(t, strings.Contains(page, "alert-danger"), "%s", query)
+			continue
+		}
+		assert.True(t, strings.Contains(page, html.EscapeString(expected)), "%s", query)
+		// Nothing is compared until the selection is fixed.
+		assert.False(t, strings.Contains(page, `id="compare-cpu-chart"`), "%s", query)
+	}
+}
@@ -46,13 +46,80 @@ const containersHtmlTemplate = `
 	  <li><a href="{{$parentContainer.Link}}">{{$parentContainer.Text}}</a></li>
 	  {{end}}
 	</ol>
+	<div id="settings">
+	  <button class="btn btn-default btn-sm" type="button" data-toggle="collapse" data-target="#settings-panel" aria-expanded="false" aria-controls="settings-panel">Settings</button>
+	  {{if .ResourcesAvailable}}
+	  <a class="btn btn-default btn-sm" href="{{.Root}}api/v2.1/export{{.ContainerName}}?format=csv">Export CSV</a>
+	  <a class="btn btn-default btn-sm" href="{{.Root}}api/v2.1/export{{.ContainerName}}?format=png">Export PNG</a>
+	  {{end}}
+	  <div class="collapse" id="settings-panel">
+	    <div class="well">
+	      <form id="settings-form" class="form-inline">
+		<div class="form-group">
+		  <label for="settings-refresh-interval">Refresh every</label>
+		  <select id="settings-refresh-interval" class="form-control input-sm">
+		    <option value="1000">1s</option>
+		    <option value="2000">2s</option>
+		    <option value="5000">5s</option>
+		    <option value="10000">10s</option>
+		    <option value="30000">30s</option>
+		    <option value="0">Paused</option>
+		  </select>
+		</div>
+		<div class="form-group">
+		  <label for="settings-time-window">Time window</label>
+		  <select id="settings-time-window" class="form-control input-sm">
+		    <option value="60">1 minute</option>
+		    <option value="120">2 minutes</option>
+		    <option value="300">5 minutes</option>
+		    <option value="900">15 minutes</option>
+		    <option value="3600">1 hour</option>
+		    {{if .HistoryAvailable}}
+		    <option value="21600">6 hours</option>
+		    <option value="86400">24 hours</option>
+		    <option value="604800">7 days</option>
+		    {{end}}
+		  </select>
+		</div>
+		<div class="form-group">
+		  <label for="settings-theme">Theme</label>
+		  <select id="settings-theme" class="form-control input-sm">
+		    <option value="light">Light</option>
+		    <option value="dark">Dark</option>
+		  </select>
+		</div>
+	      </form>
+	      <p class="help-block">Windows longer than --storage_duration show the stats still in memory.</p>
+	    </div>
+	  </div>
+	</div>
       </div>
       {{if .IsRoot}}
       <div class="col-sm-12">
         <h4><a href="../docker">Docker Containers</a></h4>
+        <h4><a href="../compare">Compare Containers</a></h4>
       </div>
       {{end}}
-      {{if .Subcontainers}}
+      {{if .IsRoot}}
+      <div class="col-sm-12">
+	<div class="page-header">
+	  <h3>Containers</h3>
+	</div>
+	<div id="overview-controls" class="form-inline">
+	  <input type="text" id="overview-filter" class="form-control input-sm" placeholder="Filter by name or alias">
+	  <span id="overview-column-selection"></span>
+	</div>
+	<div id="containers-overview"></div>
+	{{if .Subcontainers}}
+	<button class="btn btn-link" type="button" data-toggle="collapse" data-target="#subcontainers" aria-expanded="false" aria-controls="subcontainers">Subcontainers</button>
+	<div class="list-group collapse" id="subcontainers">
+	  {{range $subcontainer := .Subcontainers}}
+	  <a href="{{$subcontainer.Link}}" class="list-group-item">{{$subcontainer.Text}}</a>
+	  {{end}}
+	</div>
+	{{end}}
+      </div>
+      {{else if .Subcontainers}}
       <div class="col-sm-12">
 	<div class="page-header">
 	  <h3>Subcontainers</h3>
@@ -235,7 +302,7 @@ const containersHtmlTemplate = `
       {{end}}
     </div>
     <script type="text/javascript">
-      startPage({{.ContainerName}}, {{.CpuAvailable}}, {{.MemoryAvailable}}, {{.Root}}, {{.IsRoot}});
+      startPage({{.ContainerName}}, {{.CpuAvailable}}, {{.MemoryAvailable}}, {{.Root}}, {{.IsRoot}}, {{.HistoryAvailable}}, {{.ProcessSignals}});
       drawImages({{.DockerImages}});
     </script>
   </body>
@@ -0,0 +1,121 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pages
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/manager"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Manager serving a fixed set of containers to the pages.
+type pagesManager struct {
+	manager.Manager
+	containers map[string]*info.ContainerInfo
+}
+
+func newPagesManager(names ...string) *pagesManager {
+	m := &pagesManager{
+		containers: make(map[string]*info.ContainerInfo),
+	}
+	for _, name := range append([]string{"/"}, names...) {
+		m.containers[name] = &info.ContainerInfo{
+			ContainerReference: info.ContainerReference{Name: name},
+			Spec: info.ContainerSpec{
+				HasCpu:    true,
+				HasMemory: true,
+			},
+		}
+		if name != "/" {
+			root := m.containers["/"]
+			root.Subcontainers = append(root.Subcontainers, info.ContainerReference{Name: name})
+		}
+	}
+	return m
+}
+
+func (self *pagesManager) GetContainerInfo(containerName string, query *info.ContainerInfoRequest) (*info.ContainerInfo, error) {
+	cont, ok := self.containers[containerName]
+	if !ok {
+		return nil, fmt.Errorf("unknown container %q", containerName)
+	}
+	return cont, nil
+}
+
+func (self *pagesManager) SubcontainersInfo(containerName string, query *info.ContainerInfoRequest) ([]*info.ContainerInfo, error) {
+	conts := make([]*info.ContainerInfo, 0, len(self.containers))
+	for _, cont := range self.containers {
+		conts = append(conts, cont)
+	}
+	return conts, nil
+}
+
+func (self *pagesManager) GetMachineInfo() (*info.MachineInfo, error) {
+	return &info.MachineInfo{NumCores: 2, MemoryCapacity: 1 << 30}, nil
+}
+
+func (self *pagesManager) Exists(containerName string) bool {
+	_, ok := self.containers[containerName]
+	return ok
+}
+
+func (self *pagesManager) HistoryAvailable() bool {
+	return false
+}
+
+// Serves path with handler and returns the body of the page.
+func getPage(t *testing.T, handler http.Handler, path string) string {
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + path)
+	require.Nil(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := ioutil.ReadAll(resp.Body)
+	require.Nil(t, err)
+	return string(body)
+}
+
+func TestContainersPageSettings(t *testing.T) {
+	m := newPagesManager("/docker")
+	for _, path := range []string{"/containers/", "/containers/docker"} {
+		page := getPage(t, containerHandlerNoAuth(m), path)
+		for _, id := range []string{"settings-panel", "settings-refresh-interval", "settings-time-window", "settings-theme"} {
+			assert.True(t, strings.Contains(page, `id="`+id+`"`), "%s has no %s", path, id)
+		}
+	}
+}
+
+func TestContainersPageOverview(t *testing.T) {
+	m := newPagesManager("/docker")
+	page := getPage(t, containerHandlerNoAuth(m), "/containers/")
+	assert.True(t, strings.Contains(page, `id="containers-overview"`))
+	assert.True(t, strings.Contains(page, `id="overview-filter"`))
+
+	// Only the root page has the overview of all containers.
+	page = getPage(t, containerHandlerNoAuth(m), "/containers/docker")
+	assert.False(t, strings.Contains(page, `id="containers-overview"`))
+	assert.False(t, strings.Contains(page, `id="overview-filter"`))
+}
@@ -24,9 +24,8 @@ import (
 
 	"github.com/google/cadvisor/container/docker"
 	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
 	"github.com/google/cadvisor/manager"
-
-	"github.com/golang/glog"
 )
 
 const DockerPage = "/docker/"
@@ -142,15 +141,17 @@ func serveDockerPage(m manager.Manager, w http.ResponseWriter, u *url.URL) error
 			NetworkAvailable:       cont.Spec.HasNetwork,
 			FsAvailable:            cont.Spec.HasFilesystem,
 			CustomMetricsAvailable: cont.Spec.HasCustomMetrics,
+			HistoryAvailable:       m.HistoryAvailable(),
+			ProcessSignals:         *manager.EnableProcessSignals,
 			Root: rootDir,
 		}
 	}
 
 	err := pageTemplate.Execute(w, data)
 	if err != nil {
-		glog.Errorf("Failed to apply template: %s", err)
+		logging.Errorf("Failed to apply template: %s", err)
 	}
 
-	glog.V(5).Infof("Request took %s", time.Since(start))
+	logging.V(5).Infof("Request took %s", time.Since(start))
 	return nil
 }
@@ -23,13 +23,14 @@ import (
 
 	httpmux "github.com/google/cadvisor/http/mux"
 	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
 	"github.com/google/cadvisor/manager"
 
 	auth "github.com/abbot/go-http-auth"
-	"github.com/golang/glog"
 )
 
 var pageTemplate *template.Template
+var compareTemplate *template.Template
 
 type link struct {
 	// Text to show in the link.
@@ -59,6 +60,8 @@ type pageData struct {
 	NetworkAvailable       bool
 	FsAvailable            bool
 	CustomMetricsAvailable bool
+	HistoryAvailable       bool
+	ProcessSignals         bool
 	Root                   string
 	DockerStatus           []keyVal
 	DockerDriverStatus     []keyVal
@@ -69,7 +72,12 @@ func init() {
 	pageTemplate = template.New("containersTemplate").Funcs(funcMap)
 	_, err := pageTemplate.Parse(containersHtmlTemplate)
 	if err != nil {
-		glog.Fatalf("Failed to parse template: %s", err)
+		logging.Fatalf("Failed to parse template: %s", err)
+	}
+	compareTemplate = template.New("compareTemplate").Funcs(funcMap)
+	_, err = compareTemplate.Parse(compareHtmlTemplate)
+	if err != nil {
+		logging.Fatalf("Failed to parse template: %s", err)
 	}
 }
 
@@ -109,15 +117,35 @@ func dockerHandler(containerManager manager.Manager) auth.AuthenticatedHandlerFu
 	}
 }
 
+func compareHandlerNoAuth(containerManager manager.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := serveComparePage(containerManager, w, r.URL)
+		if err != nil {
+			fmt.Fprintf(w, "%s", err)
+		}
+	}
+}
+
+func compareHandler(containerManager manager.Manager) auth.AuthenticatedHandlerFunc {
+	return func(w http.ResponseWriter, r *auth.AuthenticatedRequest) {
+		err := serveComparePage(containerManager, w, r.URL)
+		if err != nil {
+			fmt.Fprintf(w, "%s", err)
+		}
+	}
+}
+
 // Register http handlers
 func RegisterHandlersDigest(mux httpmux.Mux, containerManager manager.Manager, authenticator *auth.DigestAuth) error {
 	// Register the handler for the containers page.
 	if authenticator != nil {
 		mux.HandleFunc(ContainersPage, authenticator.Wrap(containerHandler(containerManager)))
 		mux.HandleFunc(DockerPage, authenticator.Wrap(dockerHandler(containerManager)))
+		mux.HandleFunc(ComparePage, authenticator.Wrap(compareHandler(containerManager)))
 	} else {
 		mux.HandleFunc(ContainersPage, containerHandlerNoAuth(containerManager))
 		mux.HandleFunc(DockerPage, dockerHandlerNoAuth(containerManager))
+		mux.HandleFunc(ComparePage, compareHandlerNoAuth(containerManager))
 	}
 	return nil
 }
@@ -127,9 +155,11 @@ func RegisterHandlersBasic(mux httpmux.Mux, containerManager manager.Manager, au
 	if authenticator != nil {
 		mux.HandleFunc(ContainersPage, authenticator.Wrap(containerHandler(containerManager)))
 		mux.HandleFunc(DockerPage, authenticator.Wrap(dockerHandler(containerManager)))
+		mux.HandleFunc(ComparePage, authenticator.Wrap(compareHandler(containerManager)))
 	} else {
 		mux.HandleFunc(ContainersPage, containerHandlerNoAuth(containerManager))
 		mux.HandleFunc(DockerPage, dockerHandlerNoAuth(containerManager))
+		mux.HandleFunc(ComparePage, compareHandlerNoAuth(containerManager))
 	}
 	return nil
 }
@@ -50,6 +50,64 @@ const containersCss = `
     margin-top: 3px;
     margin-bottom: 3px;
 }
+#overview-controls {
+    margin-bottom: 10px;
+}
+#overview-filter {
+    margin-right: 15px;
+    width: 250px;
+}
+#settings {
+    margin-bottom: 10px;
+}
+#settings-form .form-group {
+    margin-right: 15px;
+}
+#compare-selection {
+    margin-bottom: 20px;
+}
+#compare-selection select {
+    max-width: 600px;
+}
+body.dark {
+    background-color: #1e1e1e;
+    color: #dddddd;
+}
+body.dark .page-header {
+    border-bottom-color: #444444;
+}
+body.dark .breadcrumb,
+body.dark .list-group-item,
+body.dark .panel,
+body.dark .well,
+body.dark .dropdown-menu,
+body.dark .form-control {
+    background-color: #2a2a2a;
+    background-image: none;
+    border-color: #444444;
+    color: #dddddd;
+}
+body.dark .list-group-item.active {
+    background-color: #2f5f8f;
+}
+body.dark a.list-group-item:hover,
+body.dark .dropdown-menu > li > a {
+    background-color: #333333;
+    color: #dddddd;
+}
+body.dark .active-cpu {
+    color: #ffffff;
+}
+body.dark .google-visualization-table-table,
+body.dark .google-visualization-table-table td,
+body.dark .google-visualization-table-table th,
+body.dark .google-visualization-table-tr-head,
+body.dark .google-visualization-table-tr-even,
+body.dark .google-visualization-table-tr-odd {
+    background-color: #2a2a2a;
+    background-image: none;
+    color: #dddddd;
+}
 #logo {
     height: 200px;
     margin-top: 20px;
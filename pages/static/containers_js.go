@@ -34,8 +34,332 @@ function humanizeMetric(num) {
 	return ret[0].toFixed(2) + " " + ret[1];
 }
 
+// The UI settings, persisted in the local storage of the browser.
+var defaultSettings = {
+	// Interval between refreshes of the stats in milliseconds, 0 to pause.
+	refreshInterval: 1000,
+	// "light" or "dark".
+	theme: "light",
+	// Time window of the charts in seconds.
+	timeWindow: 60,
+	// Ids of the columns hidden from the container overview.
+	hiddenColumns: [],
+};
+var settingsKey = "cadvisor.settings";
+
+function loadSettings() {
+	var settings = $.extend({}, defaultSettings);
+	try {
+		var saved = JSON.parse(window.localStorage.getItem(settingsKey));
+		if (saved) {
+			$.extend(settings, saved);
+		}
+	} catch (e) {
+		// The local storage is unavailable or holds invalid settings.
+	}
+	return settings;
+}
+
+function saveSettings(settings) {
+	try {
+		window.localStorage.setItem(settingsKey, JSON.stringify(settings));
+	} catch (e) {
+		console.log("Unable to save the settings: ", e);
+	}
+}
+
+function isDarkTheme() {
+	return window.cadvisor.settings.theme == "dark";
+}
+
+function applyTheme() {
+	$("body").toggleClass("dark", isDarkTheme());
+}
+
+// Adds the colors of the theme to the options of a chart.
+function themeChartOptions(opts) {
+	if (!isDarkTheme()) {
+		return opts;
+	}
+	var text = {color: "#cccccc"};
+	opts.backgroundColor = "transparent";
+	opts.legend = $.extend({}, opts.legend, {textStyle: text});
+	opts.hAxis = $.extend({}, opts.hAxis, {textStyle: text, titleTextStyle: text, gridlines: {color: "#444444"}});
+	opts.vAxis = $.extend({}, opts.vAxis, {textStyle: text, titleTextStyle: text, gridlines: {color: "#444444"}});
+	return opts;
+}
+
+// Fills the settings panel and saves the changes.
+function startSettings() {
+	var settings = window.cadvisor.settings;
+	$("#settings-refresh-interval").val(String(settings.refreshInterval));
+	$("#settings-theme").val(settings.theme);
+	$("#settings-time-window").val(String(settings.timeWindow));
+	$("#settings-form select").change(function() {
+		settings.refreshInterval = parseInt($("#settings-refresh-interval").val(), 10);
+		settings.theme = $("#settings-theme").val();
+		settings.timeWindow = parseInt($("#settings-time-window").val(), 10);
+		saveSettings(settings);
+		applyTheme();
+		scheduleRefresh();
+		if (window.cadvisor.machineInfo) {
+			refreshPage();
+		}
+	});
+}
+
+// Refreshes the stats every refresh interval of the settings.
+function scheduleRefresh() {
+	if (window.cadvisor.refreshTimer) {
+		clearInterval(window.cadvisor.refreshTimer);
+		window.cadvisor.refreshTimer = null;
+	}
+	if (!window.cadvisor.machineInfo || window.cadvisor.settings.refreshInterval <= 0) {
+		return;
+	}
+	window.cadvisor.refreshTimer = setInterval(function() {
+		refreshPage();
+	}, window.cadvisor.settings.refreshInterval);
+}
+
+// Refreshes the charts, the processes and, on the root page, the container
+// overview. The compare page only refreshes its charts.
+function refreshPage() {
+	if (window.cadvisor.compare) {
+		refreshCompare();
+		return;
+	}
+	refreshStats();
+	refreshProcesses(false);
+	if (window.cadvisor.overview) {
+		refreshOverview();
+	}
+}
+
+// Formats a duration in seconds as its two largest units, e.g. "3d 4h".
+function humanizeDuration(seconds) {
+	var units = [["d", 86400], ["h", 3600], ["m", 60], ["s", 1]];
+	var parts = [];
+	for (var i = 0; i < units.length && parts.length < 2; i++) {
+		var n = Math.floor(seconds / units[i][1]);
+		if (n > 0 || parts.length > 0) {
+			parts.push(n + units[i][0]);
+			seconds -= n * units[i][1];
+		}
+	}
+	if (parts.length == 0) {
+		return "0s";
+	}
+	return parts.join(" ");
+}
+
+// Columns of the container overview. Each computes its cell for a container
+// from its spec and its last two stats.
+var overviewColumns = [
+	{id: "name", title: "Container", type: "string", fixed: true, cell: function(name, cont, cur, prev) {
+		var text = name;
+		if (cont.spec.aliases && cont.spec.aliases.length > 0) {
+			text = cont.spec.aliases[0];
+		}
+		var link = '<a href="' + window.cadvisor.rootDir + 'containers' + encodeURI(name) + '" title="' + $("<span>").text(name).html() + '">' + $("<span>").text(text).html() + '</a>';
+		return {v: text, f: link};
+	}},
+	{id: "cpu", title: "CPU %", type: "number", cell: function(name, cont, cur, prev) {
+		if (!cont.spec.has_cpu || !cur.cpu_inst) {
+			return null;
+		}
+		var percent = cur.cpu_inst.usage.total / 1e9 / window.cadvisor.machineInfo.num_cores * 100;
+		return {v: percent, f: percent.toFixed(2)};
+	}},
+	{id: "memory", title: "Memory", type: "number", cell: function(name, cont, cur, prev) {
+		if (!cont.spec.has_memory || !cur.memory) {
+			return null;
+		}
+		return {v: cur.memory.working_set, f: humanizeIEC(cur.memory.working_set)};
+	}},
+	{id: "memory_percent", title: "Memory %", type: "number", cell: function(name, cont, cur, prev) {
+		if (!cont.spec.has_memory || !cur.memory) {
+			return null;
+		}
+		// Saturate to the machine size.
+		var limit = window.cadvisor.machineInfo.memory_capacity;
+		if (cont.spec.memory.limit && cont.spec.memory.limit < limit) {
+			limit = cont.spec.memory.limit;
+		}
+		var percent = cur.memory.working_set * 100 / limit;
+		return {v: percent, f: percent.toFixed(2)};
+	}},
+	{id: "rx", title: "Net Rx/s", type: "number", cell: function(name, cont, cur, prev) {
+		return networkRate(cont, cur, prev, "rx_bytes");
+	}},
+	{id: "tx", title: "Net Tx/s", type: "number", cell: function(name, cont, cur, prev) {
+		return networkRate(cont, cur, prev, "tx_bytes");
+	}},
+	{id: "restarts", title: "Restarts", type: "number", cell: function(name, cont, cur, prev) {
+		var restarts = cont.spec.restart_count || 0;
+		if (restarts == 0) {
+			return {v: 0, f: "0"};
+		}
+		return {v: restarts, f: '<span class="label label-warning">' + restarts + '</span>'};
+	}},
+	{id: "uptime", title: "Uptime", type: "number", cell: function(name, cont, cur, prev) {
+		var uptime = (new Date().getTime() - new Date(cont.spec.creation_time).getTime()) / 1000;
+		if (uptime < 0) {
+			uptime = 0;
+		}
+		var text = humanizeDuration(uptime);
+		// Flag the containers started in the last 10 minutes.
+		if (uptime < 600) {
+			text = '<span class="label label-info">' + text + '</span>';
+		}
+		return {v: uptime, f: text};
+	}},
+];
+
+// Computes the rate of a network counter summed over the interfaces of the
+// container between its last two stats.
+function networkRate(cont, cur, prev, counter) {
+	if (!cont.spec.has_network || !prev || !cur.network || !prev.network) {
+		return null;
+	}
+	var sum = function(stats) {
+		var total = 0;
+		var interfaces = stats.network.interfaces || [];
+		for (var i = 0; i < interfaces.length; i++) {
+			total += interfaces[i][counter];
+		}
+		return total;
+	};
+	var intervalInSec = getInterval(cur.timestamp, prev.timestamp) / 1000000000;
+	if (intervalInSec <= 0) {
+		return null;
+	}
+	var rate = Math.max(0, (sum(cur) - sum(prev)) / intervalInSec);
+	return {v: rate, f: humanizeMetric(rate)};
+}
+
+function isColumnShown(column) {
+	return column.fixed || window.cadvisor.settings.hiddenColumns.indexOf(column.id) < 0;
+}
+
+// Starts the sortable, filterable table of all the containers on the root
+// page, with a checkbox per optional column.
+function startOverview() {
+	window.cadvisor.overview = {
+		containers: {},
+		filter: "",
+		// Sorted by CPU usage by default.
+		sortColumn: "cpu",
+		sortAscending: false,
+	};
+
+	var selection = $("#overview-column-selection");
+	for (var i = 0; i < overviewColumns.length; i++) {
+		var column = overviewColumns[i];
+		if (column.fixed) {
+			continue;
+		}
+		var checkbox = $("<input>")
+			.attr("type", "checkbox")
+			.prop("checked", isColumnShown(column))
+			.change(toggleColumn.bind(null, column.id));
+		selection.append($("<label>")
+			.addClass("checkbox-inline")
+			.append(checkbox)
+			.append(document.createTextNode(column.title)));
+	}
+	$("#overview-filter").on("input", function() {
+		window.cadvisor.overview.filter = $(this).val().toLowerCase();
+		drawOverview();
+	});
+	refreshOverview();
+}
+
+function toggleColumn(id) {
+	var hidden = window.cadvisor.settings.hiddenColumns;
+	var index = hidden.indexOf(id);
+	if (index < 0) {
+		hidden.push(id);
+	} else {
+		hidden.splice(index, 1);
+	}
+	saveSettings(window.cadvisor.settings);
+	drawOverview();
+}
+
+// Get the spec and last two stats of all the containers.
+function refreshOverview() {
+	$.getJSON(window.cadvisor.rootDir + "api/v2.1/stats/?recursive=true&count=2")
+	.done(function(data) {
+		window.cadvisor.overview.containers = data;
+		drawOverview();
+	});
+}
+
+// Draws the overview table of the containers matching the filter.
+function drawOverview() {
+	var overview = window.cadvisor.overview;
+	var columns = [];
+	for (var i = 0; i < overviewColumns.length; i++) {
+		if (isColumnShown(overviewColumns[i])) {
+			columns.push(overviewColumns[i]);
+		}
+	}
+
+	var dataTable = new google.visualization.DataTable();
+	var sortIndex = -1;
+	for (var i = 0; i < columns.length; i++) {
+		dataTable.addColumn(columns[i].type, columns[i].title);
+		if (columns[i].id == overview.sortColumn) {
+			sortIndex = i;
+		}
+	}
+	for (var name in overview.containers) {
+		var cont = overview.containers[name];
+		var matches = name.toLowerCase().indexOf(overview.filter) >= 0;
+		var aliases = cont.spec.aliases || [];
+		for (var j = 0; j < aliases.length && !matches; j++) {
+			matches = aliases[j].toLowerCase().indexOf(overview.filter) >= 0;
+		}
+		if (!matches || !cont.stats || cont.stats.length == 0) {
+			continue;
+		}
+		var cur = cont.stats[cont.stats.length - 1];
+		var prev = cont.stats.length > 1 ? cont.stats[cont.stats.length - 2] : null;
+		var row = [];
+		for (var j = 0; j < columns.length; j++) {
+			row.push(columns[j].cell(name, cont, cur, prev));
+		}
+		dataTable.addRow(row);
+	}
+
+	if (!overview.table) {
+		overview.table = new google.visualization.Table(document.getElementById("containers-overview"));
+		google.visualization.events.addListener(overview.table, "sort", function(e) {
+			overview.sortColumn = overview.columns[e.column].id;
+			overview.sortAscending = e.ascending;
+		});
+	}
+	overview.columns = columns;
+	var opts = {
+		allowHtml: true,
+		page: "enable",
+		pageSize: 50,
+		sortAscending: overview.sortAscending,
+		cssClassNames: {
+			'headerRow': '',
+			'tableRow': 'table-row',
+			'oddTableRow': 'table-row'
+		},
+	};
+	if (sortIndex >= 0) {
+		opts.sortColumn = sortIndex;
+	}
+	overview.table.draw(dataTable, themeChartOptions(opts));
+}
+
 // Draw a table.
-function drawTable(seriesTitles, titleTypes, data, elementId, numPages, sortIndex) {
+function drawTable(seriesTitles, titleTypes, data, elementId, numPages, sortIndex, sortAscending) {
 	var dataTable = new google.visualization.DataTable();
 	for (var i = 0; i < seriesTitles.length; i++) {
 		dataTable.addColumn(titleTypes[i], seriesTitles[i]);
@@ -56,10 +380,10 @@ function drawTable(seriesTitles, titleTypes, data, elementId, numPages, sortInde
 		pageSize: numPages,
 		allowHtml: true,
 		sortColumn: sortIndex,
-		sortAscending: false,
+		sortAscending: !!sortAscending,
 		cssClassNames: cssClassNames,
 	};
-	window.charts[elementId].draw(dataTable, opts);
+	window.charts[elementId].draw(dataTable, themeChartOptions(opts));
 }
 
 // Draw a line chart.
@@ -75,6 +399,9 @@ function drawLineChart(seriesTitles, data, elementId, unit) {
 		// Find min, max.
 		for (var j = 1; j < data[i].length; j++) {
 			var val = data[i][j];
+			if (val == null) {
+				continue;
+			}
 			if (val < min) {
 				min = val;
 			}
@@ -108,6 +435,7 @@ function drawLineChart(seriesTitles, data, elementId, unit) {
 	var opts = {
 		curveType: 'function',
 		height: 300,
+		interpolateNulls: true,
 		legend:{position:"none"},
 		focusTarget: "category",
 		vAxis: {
@@ -126,7 +454,7 @@ function drawLineChart(seriesTitles, data, elementId, unit) {
 		opts.vAxis.viewWindow.min = 0.9 * max
 	}
 
-	window.charts[elementId].draw(dataTable, opts);
+	window.charts[elementId].draw(dataTable, themeChartOptions(opts));
 }
 
 // Gets the length of the interval in nanoseconds.
@@ -163,7 +491,7 @@ function drawGauges(elementId, gauges) {
 		}
 	};
 	var chart = new google.visualization.Gauge(document.getElementById(elementId));
-	chart.draw(data, options);
+	chart.draw(data, themeChartOptions(options));
 }
 
 // Get the machine info.
@@ -186,17 +514,95 @@ function getProcessInfo(rootDir, containerName, callback) {
 
 // Get the container stats for the specified container.
 function getStats(rootDir, containerName, callback) {
-	// Request 60s of container history and no samples.
+	// Request the container history of the time window and no samples.
+	var start = new Date(new Date().getTime() - window.cadvisor.settings.timeWindow * 1000);
 	var request = JSON.stringify({
-                // Update main.statsRequestedByUI while updating "num_stats" here.
-		"num_stats": 60,
+		"num_stats": -1,
+		"start": start.toISOString(),
 		"num_samples": 0
 	});
 	$.post(rootDir + "api/v1.0/containers" + containerName, request, function(data) {
-		callback(data);
+		if (!useHistory()) {
+			callback(data);
+			return;
+		}
+		getHistory(rootDir, containerName, function(history) {
+			callback(mergeHistory(data, history));
+		}, function() {
+			callback(data);
+		});
 	}, "json");
 }
 
+// Windows longer than the stats cached in memory are read from the storage driver.
+var maxLiveTimeWindow = 3600;
+// Number of samples requested from the storage driver for a window.
+var historySamples = 300;
+
+function useHistory() {
+	return window.cadvisor.historyAvailable && window.cadvisor.settings.timeWindow > maxLiveTimeWindow;
+}
+
+// Get the stats of the time window from the storage driver. They are fetched
+// again once a step has passed, at most every minute.
+function getHistory(rootDir, containerName, callback, fail) {
+	var now = new Date();
+	var timeWindow = window.cadvisor.settings.timeWindow;
+	var step = Math.max(1, Math.floor(timeWindow / historySamples));
+	var cached = window.cadvisor.history;
+	if (cached && cached.timeWindow == timeWindow && now.getTime() - cached.fetched < Math.min(step, 60) * 1000) {
+		callback(cached.data);
+		return;
+	}
+	var start = new Date(now.getTime() - timeWindow * 1000);
+	var url = rootDir + "api/v2.1/history" + containerName + "?start=" + encodeURIComponent(start.toISOString()) + "&step=" + step + "s";
+	$.getJSON(url)
+	.done(function(data) {
+		window.cadvisor.history = {
+			timeWindow: timeWindow,
+			fetched: now.getTime(),
+			data: data
+		};
+		callback(data);
+	})
+	.fail(function() {
+		fail();
+	});
+}
+
+// Prepend the stats read from the storage driver, older than the stats cached
+// in memory, to the ones of the container. The storage driver only keeps the
+// stats of the default network interface.
+function mergeHistory(containerInfo, history) {
+	if (containerInfo.stats.length == 0 || !history.stats) {
+		return containerInfo;
+	}
+	var first = new Date(containerInfo.stats[0].timestamp).getTime();
+	var defaultInterface = containerInfo.stats[containerInfo.stats.length - 1].network.name;
+	var stats = [];
+	for (var i = 0; i < history.stats.length; i++) {
+		var sample = history.stats[i];
+		if (new Date(sample.timestamp).getTime() >= first) {
+			break;
+		}
+		var interfaces = [];
+		if (sample.network && sample.network.interfaces) {
+			for (var j = 0; j < sample.network.interfaces.length; j++) {
+				var iface = $.extend({}, sample.network.interfaces[j]);
+				iface.name = defaultInterface;
+				interfaces.push(iface);
+			}
+		}
+		stats.push({
+			timestamp: sample.timestamp,
+			cpu: sample.cpu,
+			memory: sample.memory,
+			network: {interfaces: interfaces}
+		});
+	}
+	return $.extend({}, containerInfo, {stats: stats.concat(containerInfo.stats)});
+}
+
 // Draw the graph for CPU usage.
 function drawCpuTotalUsage(elementId, machineInfo, stats) {
 	if (stats.spec.has_cpu && !hasResource(stats, "cpu")) {
@@ -250,6 +656,10 @@ function drawCpuPerCoreUsage(elementId, machineInfo, stats) {
 	for (var i = 1; i < stats.stats.length; i++) {
 		var cur = stats.stats[i];
 		var prev = stats.stats[i - 1];
+		if (!cur.cpu.usage.per_cpu_usage || !prev.cpu.usage.per_cpu_usage) {
+			// Not kept by the storage driver.
+			continue;
+		}
 		var intervalInNs = getInterval(cur.timestamp, prev.timestamp);
 
 		var elements = [];
@@ -385,18 +795,28 @@ function getNetworkInterfaceIndex(interfaceName, interfaces) {
 	return -1;
 }
 
+// Get the stats of the interface with the specified name in a sample, null if
+// it has none.
+function getNetworkInterface(interfaceName, sample) {
+	if (!sample.network || !sample.network.interfaces) {
+		return null;
+	}
+	var index = getNetworkInterfaceIndex(interfaceName, sample.network.interfaces);
+	if (index < 0) {
+		return null;
+	}
+	return sample.network.interfaces[index];
+}
+
 // Draw the graph for network tx/rx bytes.
 function drawNetworkBytes(elementId, machineInfo, stats) {
 	if (stats.spec.has_network && !hasResource(stats, "network")) {
 		return;
 	}
 
-	// Get interface index.
-	var interfaceIndex = -1;
-	if (stats.stats.length > 0) {
-		interfaceIndex = getNetworkInterfaceIndex(window.cadvisor.network.interface, stats.stats[0].network.interfaces);
-	}
-	if (interfaceIndex < 0) {
+	// Get the interface of the latest stats.
+	var interfaceName = window.cadvisor.network.interface;
+	if (stats.stats.length == 0 || !getNetworkInterface(interfaceName, stats.stats[stats.stats.length - 1])) {
 		console.log("Unable to find interface\"", interfaceName, "\" in ", stats.stats.network);
 		return;
 	}
@@ -404,14 +824,17 @@ function drawNetworkBytes(elementId, machineInfo, stats) {
 	var titles = ["Time", "Tx bytes", "Rx bytes"];
 	var data = [];
 	for (var i = 1; i < stats.stats.length; i++) {
-		var cur = stats.stats[i];
-		var prev = stats.stats[i - 1];
-		var intervalInSec = getInterval(cur.timestamp, prev.timestamp) / 1000000000;
+		var cur = getNetworkInterface(interfaceName, stats.stats[i]);
+		var prev = getNetworkInterface(interfaceName, stats.stats[i - 1]);
+		if (!cur || !prev) {
+			continue;
+		}
+		var intervalInSec = getInterval(stats.stats[i].timestamp, stats.stats[i - 1].timestamp) / 1000000000;
 
 		var elements = [];
-		elements.push(cur.timestamp);
-		elements.push((cur.network.interfaces[interfaceIndex].tx_bytes - prev.network.interfaces[interfaceIndex].tx_bytes) / intervalInSec);
-		elements.push((cur.network.interfaces[interfaceIndex].rx_bytes - prev.network.interfaces[interfaceIndex].rx_bytes) / intervalInSec);
+		elements.push(stats.stats[i].timestamp);
+		elements.push((cur.tx_bytes - prev.tx_bytes) / intervalInSec);
+		elements.push((cur.rx_bytes - prev.rx_bytes) / intervalInSec);
 		data.push(elements);
 	}
 	drawLineChart(titles, data, elementId, "Bytes per second");
@@ -423,12 +846,9 @@ function drawNetworkErrors(elementId, machineInfo, stats) {
 		return;
 	}
 
-	// Get interface index.
-	var interfaceIndex = -1;
-	if (stats.stats.length > 0) {
-		interfaceIndex = getNetworkInterfaceIndex(window.cadvisor.network.interface, stats.stats[0].network.interfaces);
-	}
-	if (interfaceIndex < 0) {
+	// Get the interface of the latest stats.
+	var interfaceName = window.cadvisor.network.interface;
+	if (stats.stats.length == 0 || !getNetworkInterface(interfaceName, stats.stats[stats.stats.length - 1])) {
 		console.log("Unable to find interface\"", interfaceName, "\" in ", stats.stats.network);
 		return;
 	}
@@ -436,14 +856,17 @@ function drawNetworkErrors(elementId, machineInfo, stats) {
 	var titles = ["Time", "Tx", "Rx"];
 	var data = [];
 	for (var i = 1; i < stats.stats.length; i++) {
-		var cur = stats.stats[i];
-		var prev = stats.stats[i - 1];
-		var intervalInSec = getInterval(cur.timestamp, prev.timestamp) / 1000000000;
+		var cur = getNetworkInterface(interfaceName, stats.stats[i]);
+		var prev = getNetworkInterface(interfaceName, stats.stats[i - 1]);
+		if (!cur || !prev) {
+			continue;
+		}
+		var intervalInSec = getInterval(stats.stats[i].timestamp, stats.stats[i - 1].timestamp) / 1000000000;
 
 		var elements = [];
-		elements.push(cur.timestamp);
-		elements.push((cur.network.interfaces[interfaceIndex].tx_errors - prev.network.interfaces[interfaceIndex].tx_errors) / intervalInSec);
-		elements.push((cur.network.interfaces[interfaceIndex].rx_errors - prev.network.interfaces[interfaceIndex].rx_errors) / intervalInSec);
+		elements.push(stats.stats[i].timestamp);
+		elements.push((cur.tx_errors - prev.tx_errors) / intervalInSec);
+		elements.push((cur.rx_errors - prev.rx_errors) / intervalInSec);
 		data.push(elements);
 	}
 	drawLineChart(titles, data, elementId, "Errors per second");
@@ -499,18 +922,61 @@ function drawImages(images) {
 	drawTable(titles, titleTypes, data, "docker-images", 30, sortIndex);
 }
 
+// Minimum interval between two refreshes of the processes, in milliseconds.
+var processRefreshInterval = 5000;
+
+// Gets the processes again if they weren't refreshed in the last interval.
+function refreshProcesses(force) {
+	var processes = window.cadvisor.processes;
+	var now = new Date().getTime();
+	if (!force && now - processes.fetched < processRefreshInterval) {
+		return;
+	}
+	processes.fetched = now;
+	getProcessInfo(window.cadvisor.rootDir, window.cadvisor.containerName, function(processInfo) {
+		drawProcesses(processes.isRoot, window.cadvisor.rootDir, processInfo);
+	});
+}
+
+// Sends a signal to a process after confirming it, then refreshes the processes.
+function signalProcess(pid, signal) {
+	if (!confirm("Send SIG" + signal + " to process " + pid + "?")) {
+		return;
+	}
+	$.ajax({
+		type: "POST",
+		url: window.cadvisor.rootDir + "api/v2.1/signal" + window.cadvisor.containerName + "?pid=" + pid + "&signal=" + signal,
+		headers: {"X-Requested-With": "XMLHttpRequest"},
+	})
+	.done(function() {
+		refreshProcesses(true);
+	})
+	.fail(function(jqxhr) {
+		alert("Failed to send SIG" + signal + " to process " + pid + ": " + jqxhr.responseText);
+	});
+}
+
 function drawProcesses(isRoot, rootDir, processInfo) {
 	if (processInfo.length == 0) {
 		$("#processes-top").text("No processes found");
 		return;
 	}
-	var titles = ["User", "PID", "PPID", "Start Time", "CPU %", "MEM %", "RSS", "Virtual Size", "Status", "Running Time", "Command"];
-	var titleTypes = ['string', 'number', 'number', 'string', 'number', 'number', 'number', 'number', 'string', 'string', 'string'];
-	var sortIndex = 4
+	var processes = window.cadvisor.processes;
+	var ids = ["user", "pid", "ppid", "start_time", "cpu", "mem", "rss", "vsz", "status", "cpu_time", "cmd"];
+	var titles = ["User", "PID", "PPID", "Start Time", "CPU %", "MEM %", "RSS", "Virtual Size", "Status", "CPU Time", "Command"];
+	var titleTypes = ['string', 'number', 'number', 'string', 'number', 'number', 'number', 'number', 'string', 'number', 'string'];
 	if (isRoot) {
+		ids.push("container");
 		titles.push("Container");
 		titleTypes.push('string');
 	}
+	// The processes of the root container cannot be signaled.
+	var signals = processes.signals && !isRoot;
+	if (signals) {
+		ids.push("signal");
+		titles.push("Signal");
+		titleTypes.push('string');
+	}
 	var data = []
 	for (var i = 0; i < processInfo.length; i++) {
 		var elements = [];
@@ -523,7 +989,7 @@ function drawProcesses(isRoot, rootDir, processInfo) {
 		elements.push({ v:processInfo[i].rss, f:humanizeIEC(processInfo[i].rss)});
 		elements.push({ v:processInfo[i].virtual_size, f:humanizeIEC(processInfo[i].virtual_size)});
 		elements.push(processInfo[i].status);
-		elements.push(processInfo[i].running_time);
+		elements.push({ v:processInfo[i].cpu_time, f:processInfo[i].running_time});
 		elements.push(processInfo[i].cmd);
 		if (isRoot) {
 			var cgroup = processInfo[i].cgroup_path
@@ -531,9 +997,24 @@ function drawProcesses(isRoot, rootDir, processInfo) {
 			var cgroupLink = '<a href="' + rootDir + 'containers/' + cgroup +'">' + cgroup.substr(0,30) + ' </a>';
 			elements.push({v:cgroup, f:cgroupLink});
 		}
+		if (signals) {
+			var pid = processInfo[i].pid;
+			var buttons = '<button type="button" class="btn btn-warning btn-xs process-signal" data-pid="' + pid + '" data-signal="TERM">TERM</button> ' +
+				'<button type="button" class="btn btn-danger btn-xs process-signal" data-pid="' + pid + '" data-signal="KILL">KILL</button>';
+			elements.push({v:"", f:buttons});
+		}
 		data.push(elements);
 	}
-	drawTable(titles, titleTypes, data, "processes-top", 25, sortIndex);
+	drawTable(titles, titleTypes, data, "processes-top", 25, Math.max(0, ids.indexOf(processes.sortColumn)), processes.sortAscending);
+
+	// Remember the column sorted by the user across refreshes.
+	processes.columns = ids;
+	if (!processes.sortListener) {
+		processes.sortListener = google.visualization.events.addListener(window.charts["processes-top"], "sort", function(e) {
+			processes.sortColumn = processes.columns[e.column];
+			processes.sortAscending = e.ascending;
+		});
+	}
 }
 
 // Draw the filesystem usage nodes.
@@ -844,38 +1325,186 @@ function drawCustomMetrics(elementId, containerInfo, metricsInfo) {
 }
 
 // Executed when the page finishes loading.
-function startPage(containerName, hasCpu, hasMemory, rootDir, isRoot) {
+function startPage(containerName, hasCpu, hasMemory, rootDir, isRoot, historyAvailable, processSignals) {
+	window.charts = {};
+	window.cadvisor = {};
+	window.cadvisor.settings = loadSettings();
+	window.cadvisor.historyAvailable = historyAvailable;
+	if (!historyAvailable && window.cadvisor.settings.timeWindow > maxLiveTimeWindow) {
+		window.cadvisor.settings.timeWindow = maxLiveTimeWindow;
+	}
+	applyTheme();
+	startSettings();
+
 	// Don't fetch data if we don't have any resource.
 	if (!hasCpu && !hasMemory) {
 		return;
 	}
 
-	window.charts = {};
-	window.cadvisor = {};
 	window.cadvisor.firstRun = true;
 	window.cadvisor.rootDir = rootDir;
 	window.cadvisor.containerName = containerName;
+	window.cadvisor.overview = null;
 
 	window.cadvisor.firstCustomCollection = true;
 	window.cadvisor.metricLabelPair = [];	
 	window.cadvisor.maxCustomMetrics = 10;
 
-	// Draw process information at start and refresh every 60s.
-	getProcessInfo(rootDir, containerName, function(processInfo) {
-		drawProcesses(isRoot, rootDir, processInfo)
+	// Draw process information at start, then refresh it with the stats.
+	window.cadvisor.processes = {
+		isRoot: isRoot,
+		signals: processSignals,
+		sortColumn: "cpu",
+		sortAscending: false,
+		fetched: 0
+	};
+	if (processSignals) {
+		$("#processes-top").on("click", ".process-signal", function() {
+			signalProcess($(this).data("pid"), $(this).data("signal"));
+		});
+	}
+	refreshProcesses(true);
+
+	// Get machine info, then get the stats every refresh interval.
+	getMachineInfo(rootDir, function(machineInfo) {
+		window.cadvisor.machineInfo = machineInfo;
+		if (isRoot) {
+			startOverview();
+		}
+		refreshStats();
+		scheduleRefresh();
 	});
-	setInterval(function() {
-		getProcessInfo(rootDir, containerName, function(processInfo) {
-			drawProcesses(isRoot, rootDir, processInfo)
+}
+
+// Series overlaid on the compare page. Each value is computed from a sample
+// and the previous one, null when the container doesn't have the resource.
+var compareSeries = [
+	{
+		elementId: "compare-cpu-chart",
+		unit: "Cores",
+		value: function(cur, prev, intervalInNs) {
+			if (!cur.cpu || !prev.cpu) {
+				return null;
+			}
+			return (cur.cpu.usage.total - prev.cpu.usage.total) / intervalInNs;
+		}
+	},
+	{
+		elementId: "compare-memory-chart",
+		unit: "Megabytes",
+		value: function(cur, prev, intervalInNs) {
+			if (!cur.memory) {
+				return null;
+			}
+			return cur.memory.working_set / oneMegabyte;
+		}
+	},
+	{
+		elementId: "compare-network-chart",
+		unit: "Bytes per second",
+		value: function(cur, prev, intervalInNs) {
+			if (!cur.network || !prev.network) {
+				return null;
+			}
+			var bytes = cur.network.rx_bytes + cur.network.tx_bytes - prev.network.rx_bytes - prev.network.tx_bytes;
+			return bytes / (intervalInNs / 1000000000);
+		}
+	}
+];
+
+// Gets the alias of a container, or its name when it has none.
+function getContainerLabel(containerInfo) {
+	if (containerInfo.aliases && containerInfo.aliases.length > 0) {
+		return containerInfo.aliases[0];
+	}
+	return containerInfo.name;
+}
+
+// Get the stats of the compared containers, in their order.
+function getCompareStats(rootDir, containerNames, callback) {
+	var start = new Date(new Date().getTime() - window.cadvisor.settings.timeWindow * 1000);
+	var request = JSON.stringify({
+		"num_stats": -1,
+		"start": start.toISOString(),
+		"num_samples": 0
+	});
+	var requests = [];
+	for (var i = 0; i < containerNames.length; i++) {
+		requests.push($.post(rootDir + "api/v1.0/containers" + containerNames[i], request, null, "json"));
+	}
+	$.when.apply($, requests).done(function() {
+		// Each argument holds the data, status and request of a container.
+		var results = requests.length == 1 ? [arguments] : arguments;
+		var containerInfos = [];
+		for (var i = 0; i < results.length; i++) {
+			containerInfos.push(results[i][0]);
+		}
+		callback(containerInfos);
+	});
+}
+
+// Draw the series of the compared containers on common axes, one column per
+// container.
+function drawCompare(containerInfos) {
+	var titles = ["Time"];
+	for (var i = 0; i < containerInfos.length; i++) {
+		titles.push(getContainerLabel(containerInfos[i]));
+	}
+	for (var s = 0; s < compareSeries.length; s++) {
+		var series = compareSeries[s];
+		var rows = {};
+		for (var c = 0; c < containerInfos.length; c++) {
+			var stats = containerInfos[c].stats;
+			for (var i = 1; i < stats.length; i++) {
+				var value = series.value(stats[i], stats[i - 1], getInterval(stats[i].timestamp, stats[i - 1].timestamp));
+				if (value == null) {
+					continue;
+				}
+				var time = new Date(stats[i].timestamp).getTime();
+				if (!(time in rows)) {
+					rows[time] = [time];
+					for (var j = 0; j < containerInfos.length; j++) {
+						rows[time].push(null);
+					}
+				}
+				rows[time][c + 1] = value;
+			}
+		}
+		var times = Object.keys(rows).sort(function(a, b) {
+			return a - b;
 		});
-	}, 60000);
+		var data = [];
+		for (var i = 0; i < times.length; i++) {
+			data.push(rows[times[i]]);
+		}
+		drawLineChart(titles, data, series.elementId, series.unit);
+	}
+}
+
+// Refresh the charts of the compare page.
+function refreshCompare() {
+	getCompareStats(window.cadvisor.rootDir, window.cadvisor.compare.containers, function(containerInfos) {
+		drawCompare(containerInfos);
+	});
+}
+
+function startComparePage(rootDir, containerNames) {
+	window.charts = {};
+	window.cadvisor = {};
+	window.cadvisor.settings = loadSettings();
+	applyTheme();
+	if (!containerNames || containerNames.length == 0) {
+		return;
+	}
 
-	// Get machine info, then get the stats every 1s.
+	window.cadvisor.rootDir = rootDir;
+	window.cadvisor.compare = {
+		containers: containerNames
+	};
 	getMachineInfo(rootDir, function(machineInfo) {
 		window.cadvisor.machineInfo = machineInfo;
-		setInterval(function() {
-			refreshStats();
-		}, 1000);
+		refreshCompare();
+		scheduleRefresh();
 	});
 }
 `
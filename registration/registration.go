@@ -0,0 +1,171 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package registration periodically registers this cAdvisor, its machine and
+// its containers with a central inventory service.
+package registration
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/logging"
+	"github.com/google/cadvisor/manager"
+)
+
+var argURL = flag.String("register_url", "", "URL of a central inventory service to periodically POST machine info, containers and health to. Empty disables registration")
+var argInterval = flag.Duration("register_interval", time.Minute, "interval between registrations with --register_url")
+var argSecretFile = flag.String("register_secret_file", "", "file holding the key registrations are signed with using HMAC-SHA256")
+
+// Header holding the hex encoded HMAC-SHA256 of the request body.
+const SignatureHeader = "X-Cadvisor-Signature"
+
+const (
+	// Time a registration is given to complete, whatever the interval.
+	registrationTimeout = 30 * time.Second
+	// Bytes of the response of a rejected registration reported.
+	maxResponseSize = 4096
+)
+
+// Registration is the JSON body POSTed to the inventory service.
+type Registration struct {
+	Hostname  string    `json:"hostname"`
+	Timestamp time.Time `json:"timestamp"`
+	// cAdvisor, kernel and Docker versions.
+	Version *info.VersionInfo `json:"version,omitempty"`
+	Machine *info.MachineInfo `json:"machine,omitempty"`
+	// Specs of all the containers keyed by absolute name.
+	Containers map[string]v2.ContainerSpec `json:"containers,omitempty"`
+	Health     Health                      `json:"health"`
+}
+
+type Health struct {
+	Healthy bool `json:"healthy"`
+	// What failed while gathering the registration.
+	Errors []string `json:"errors,omitempty"`
+}
+
+type registrar struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// Start registers with the service given on the command line every
+// --register_interval. It does nothing if no service was given.
+func Start(m manager.Manager) error {
+	if *argURL == "" {
+		return nil
+	}
+	if *argInterval <= 0 {
+		return fmt.Errorf("invalid --register_interval %v, expected a positive duration", *argInterval)
+	}
+	r := &registrar{
+		url: *argURL,
+		client: &http.Client{
+			Timeout: registrationTimeout,
+		},
+	}
+	if *argSecretFile != "" {
+		secret, err := ioutil.ReadFile(*argSecretFile)
+		if err != nil {
+			return fmt.Errorf("failed to read registration secret: %v", err)
+		}
+		r.secret = bytes.TrimSpace(secret)
+	}
+	logging.Infof("Registering with %q every %v", r.url, *argInterval)
+	go func() {
+		for {
+			if err := r.register(newRegistration(m)); err != nil {
+				logging.Warningf("Failed to register with %q: %v", r.url, err)
+			}
+			time.Sleep(*argInterval)
+		}
+	}()
+	return nil
+}
+
+func newRegistration(m manager.Manager) *Registration {
+	reg := &Registration{
+		Timestamp: time.Now(),
+	}
+	var errs []string
+	hostname, err := os.Hostname()
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("failed to get hostname: %v", err))
+	}
+	reg.Hostname = hostname
+	reg.Version, err = m.GetVersionInfo()
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("failed to get version info: %v", err))
+	}
+	reg.Machine, err = m.GetMachineInfo()
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("failed to get machine info: %v", err))
+	}
+	reg.Containers, err = m.GetContainerSpec("/", v2.RequestOptions{IdType: v2.TypeName, Recursive: true})
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("failed to list containers: %v", err))
+	}
+	reg.Health = Health{
+		Healthy: len(errs) == 0,
+		Errors:  errs,
+	}
+	return reg
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of body with the given key.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (self *registrar) register(reg *Registration) error {
+	body, err := json.Marshal(reg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", self.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(self.secret) != 0 {
+		req.Header.Set(SignatureHeader, Sign(self.secret, body))
+	}
+	resp, err := self.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+		return fmt.Errorf("registration rejected with status %q: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registration
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegister(t *testing.T) {
+	secret := []byte("secret")
+	var received Registration
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		if r.Header.Get(SignatureHeader) != Sign(secret, body) {
+			http.Error(w, "bad signature", http.StatusForbidden)
+			return
+		}
+		assert.NoError(t, json.Unmarshal(body, &received))
+	}))
+	defer server.Close()
+
+	reg := &Registration{
+		Hostname:  "node1",
+		Timestamp: time.Unix(1450000000, 0),
+		Health:    Health{Healthy: true},
+	}
+	r := &registrar{
+		url:    server.URL,
+		secret: secret,
+		client: http.DefaultClient,
+	}
+	assert.NoError(t, r.register(reg))
+	assert.Equal(t, "node1", received.Hostname)
+	assert.True(t, received.Health.Healthy)
+
+	r.secret = []byte("wrong")
+	assert.Error(t, r.register(reg))
+}
+
+func TestRegisterRejectedResponseIsBounded(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(strings.Repeat("x", 10*maxResponseSize)))
+	}))
+	defer server.Close()
+
+	r := &registrar{
+		url:    server.URL,
+		client: http.DefaultClient,
+	}
+	err := r.register(&Registration{Hostname: "node1"})
+	if assert.Error(t, err) {
+		assert.True(t, len(err.Error()) < 2*maxResponseSize, "error of %d bytes", len(err.Error()))
+	}
+}
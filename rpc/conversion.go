@@ -0,0 +1,188 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"sort"
+	"time"
+
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/rpc/pb"
+)
+
+func timestampToProto(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// Converts the machine info to its protocol buffer.
+func MachineInfoToProto(mi *info.MachineInfo) *pb.MachineInfo {
+	out := &pb.MachineInfo{
+		NumCores:        int32(mi.NumCores),
+		CpuFrequencyKhz: mi.CpuFrequency,
+		MemoryCapacity:  mi.MemoryCapacity,
+		MachineId:       mi.MachineID,
+		SystemUuid:      mi.SystemUUID,
+		BootId:          mi.BootID,
+		CloudProvider:   string(mi.CloudProvider),
+		InstanceType:    string(mi.InstanceType),
+	}
+	for _, fs := range mi.Filesystems {
+		out.Filesystems = append(out.Filesystems, &pb.FsInfo{
+			Device:   fs.Device,
+			Type:     fs.Type,
+			Capacity: fs.Capacity,
+			Inodes:   fs.Inodes,
+		})
+	}
+	for _, dev := range mi.NetworkDevices {
+		out.NetworkDevices = append(out.NetworkDevices, &pb.NetInfo{
+			Name:       dev.Name,
+			MacAddress: dev.MacAddress,
+			Speed:      dev.Speed,
+			Mtu:        dev.Mtu,
+		})
+	}
+	return out
+}
+
+func containerSpecToProto(spec *v2.ContainerSpec) *pb.ContainerSpec {
+	return &pb.ContainerSpec{
+		CreationTime: timestampToProto(spec.CreationTime),
+		Aliases:      spec.Aliases,
+		Namespace:    spec.Namespace,
+		Labels:       spec.Labels,
+		HasCpu:       spec.HasCpu,
+		Cpu: &pb.CpuSpec{
+			Limit:    spec.Cpu.Limit,
+			MaxLimit: spec.Cpu.MaxLimit,
+			Mask:     spec.Cpu.Mask,
+			Quota:    spec.Cpu.Quota,
+			Period:   spec.Cpu.Period,
+		},
+		HasMemory: spec.HasMemory,
+		Memory: &pb.MemorySpec{
+			Limit:       spec.Memory.Limit,
+			Reservation: spec.Memory.Reservation,
+			SwapLimit:   spec.Memory.SwapLimit,
+		},
+		HasNetwork:       spec.HasNetwork,
+		HasFilesystem:    spec.HasFilesystem,
+		HasDiskio:        spec.HasDiskIo,
+		HasCustomMetrics: spec.HasCustomMetrics,
+		Image:            spec.Image,
+	}
+}
+
+func containerStatsToProto(stats *v2.ContainerStats) *pb.ContainerStats {
+	out := &pb.ContainerStats{
+		Timestamp: timestampToProto(stats.Timestamp),
+	}
+	if stats.Cpu != nil {
+		out.Cpu = &pb.CpuStats{
+			Usage: &pb.CpuUsage{
+				Total:       stats.Cpu.Usage.Total,
+				PerCpuUsage: stats.Cpu.Usage.PerCpu,
+				User:        stats.Cpu.Usage.User,
+				System:      stats.Cpu.Usage.System,
+			},
+			LoadAverage: stats.Cpu.LoadAverage,
+		}
+	}
+	if stats.CpuInst != nil {
+		out.CpuInst = &pb.CpuUsage{
+			Total:       stats.CpuInst.Usage.Total,
+			PerCpuUsage: stats.CpuInst.Usage.PerCpu,
+			User:        stats.CpuInst.Usage.User,
+			System:      stats.CpuInst.Usage.System,
+		}
+	}
+	if stats.Memory != nil {
+		out.Memory = &pb.MemoryStats{
+			Usage:      stats.Memory.Usage,
+			Cache:      stats.Memory.Cache,
+			Rss:        stats.Memory.RSS,
+			WorkingSet: stats.Memory.WorkingSet,
+			Failcnt:    stats.Memory.Failcnt,
+		}
+	}
+	if stats.Network != nil {
+		out.Network = &pb.NetworkStats{}
+		for _, iface := range stats.Network.Interfaces {
+			out.Network.Interfaces = append(out.Network.Interfaces, &pb.InterfaceStats{
+				Name:      iface.Name,
+				RxBytes:   iface.RxBytes,
+				RxPackets: iface.RxPackets,
+				RxErrors:  iface.RxErrors,
+				RxDropped: iface.RxDropped,
+				TxBytes:   iface.TxBytes,
+				TxPackets: iface.TxPackets,
+				TxErrors:  iface.TxErrors,
+				TxDropped: iface.TxDropped,
+			})
+		}
+	}
+	if stats.Filesystem != nil {
+		out.Filesystem = &pb.FilesystemStats{}
+		if stats.Filesystem.TotalUsageBytes != nil {
+			out.Filesystem.TotalUsageBytes = *stats.Filesystem.TotalUsageBytes
+		}
+		if stats.Filesystem.BaseUsageBytes != nil {
+			out.Filesystem.BaseUsageBytes = *stats.Filesystem.BaseUsageBytes
+		}
+	}
+	if stats.Load != nil {
+		out.LoadStats = &pb.LoadStats{
+			NrSleeping:        stats.Load.NrSleeping,
+			NrRunning:         stats.Load.NrRunning,
+			NrStopped:         stats.Load.NrStopped,
+			NrUninterruptible: stats.Load.NrUninterruptible,
+			NrIoWait:          stats.Load.NrIoWait,
+		}
+	}
+	return out
+}
+
+// Converts the info of the named container to its protocol buffer.
+func ContainerInfoToProto(name string, cinfo *v2.ContainerInfo) *pb.ContainerInfo {
+	out := &pb.ContainerInfo{
+		Name: name,
+		Spec: containerSpecToProto(&cinfo.Spec),
+	}
+	for _, stats := range cinfo.Stats {
+		out.Stats = append(out.Stats, containerStatsToProto(stats))
+	}
+	return out
+}
+
+// Converts the info of containers by name to a protocol buffer listing them
+// in a stable order.
+func ContainersToProto(infos map[string]v2.ContainerInfo) *pb.ContainerInfoResponse {
+	names := make([]string, 0, len(infos))
+	for name := range infos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	resp := &pb.ContainerInfoResponse{}
+	for _, name := range names {
+		cinfo := infos[name]
+		resp.Containers = append(resp.Containers, ContainerInfoToProto(name, &cinfo))
+	}
+	return resp
+}
@@ -0,0 +1,87 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"testing"
+	"time"
+
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/rpc/pb"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContainerInfoToProto(t *testing.T) {
+	now := time.Unix(1450000000, 0)
+	usage := uint64(4096)
+	cinfo := &v2.ContainerInfo{
+		Spec: v2.ContainerSpec{
+			CreationTime: now,
+			Labels:       map[string]string{"app": "web"},
+			HasCpu:       true,
+			Cpu:          v2.CpuSpec{Limit: 1024},
+			HasMemory:    true,
+			Memory:       v2.MemorySpec{Limit: 2048},
+		},
+		Stats: []*v2.ContainerStats{
+			{
+				Timestamp: now,
+				Cpu: &info.CpuStats{
+					Usage: info.CpuUsage{Total: 100, PerCpu: []uint64{40, 60}},
+				},
+				Memory:     &info.MemoryStats{Usage: 512, WorkingSet: 256},
+				Filesystem: &v2.FilesystemStats{TotalUsageBytes: &usage},
+			},
+		},
+	}
+
+	out := ContainerInfoToProto("/docker/abc", cinfo)
+
+	// Round trip through the wire format.
+	data, err := proto.Marshal(out)
+	assert.Nil(t, err)
+	decoded := &pb.ContainerInfo{}
+	assert.Nil(t, proto.Unmarshal(data, decoded))
+
+	assert.Equal(t, "/docker/abc", decoded.Name)
+	assert.Equal(t, now.UnixNano(), decoded.Spec.CreationTime)
+	assert.Equal(t, "web", decoded.Spec.Labels["app"])
+	assert.Equal(t, uint64(1024), decoded.Spec.Cpu.Limit)
+	assert.Equal(t, uint64(2048), decoded.Spec.Memory.Limit)
+	assert.Len(t, decoded.Stats, 1)
+	assert.Equal(t, []uint64{40, 60}, decoded.Stats[0].Cpu.Usage.PerCpuUsage)
+	assert.Equal(t, uint64(256), decoded.Stats[0].Memory.WorkingSet)
+	assert.Equal(t, usage, decoded.Stats[0].Filesystem.TotalUsageBytes)
+	assert.Nil(t, decoded.Stats[0].Network)
+}
+
+func TestGetRequestOptions(t *testing.T) {
+	opt, err := getRequestOptions(&pb.ContainerInfoRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, v2.TypeName, opt.IdType)
+	assert.Equal(t, defaultStatsCount, opt.Count)
+
+	opt, err = getRequestOptions(&pb.ContainerInfoRequest{Type: "docker", Count: 3, Recursive: true})
+	assert.Nil(t, err)
+	assert.Equal(t, v2.TypeDocker, opt.IdType)
+	assert.Equal(t, 3, opt.Count)
+	assert.True(t, opt.Recursive)
+
+	_, err = getRequestOptions(&pb.ContainerInfoRequest{Type: "bogus"})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,64 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"net/http"
+	"net/url"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// The gRPC name of the cAdvisor service.
+const serviceName = "pb.Cadvisor"
+
+// A Guard decides whether an RPC is served. It is handed the RPC as an HTTP
+// POST of the method path carrying the metadata as headers, the peer address
+// and the TLS state, so the checks of the HTTP API can be applied as is. A
+// non-nil error is returned to the client instead of serving the RPC.
+type Guard func(r *http.Request) error
+
+// Returns the HTTP request equivalent to the RPC of method in ctx.
+func guardRequest(ctx context.Context, method string) *http.Request {
+	path := "/" + serviceName + "/" + method
+	r := &http.Request{
+		Method:     "POST",
+		URL:        &url.URL{Path: path},
+		RequestURI: path,
+		Proto:      "HTTP/2.0",
+		ProtoMajor: 2,
+		Header:     make(http.Header),
+	}
+	if md, ok := metadata.FromContext(ctx); ok {
+		for k, values := range md {
+			for _, v := range values {
+				r.Header.Add(k, v)
+			}
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if p.Addr != nil {
+			r.RemoteAddr = p.Addr.String()
+		}
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			state := info.State
+			r.TLS = &state
+		}
+	}
+	return r
+}
@@ -0,0 +1,71 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rpc
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/google/cadvisor/rpc/pb"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestGuardRequest(t *testing.T) {
+	ctx := metadata.NewContext(context.Background(), metadata.MD{
+		"authorization": {"Bearer secret"},
+	})
+	ctx = peer.NewContext(ctx, &peer.Peer{
+		Addr:     &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 4000},
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{ServerName: "cadvisor"}},
+	})
+
+	r := guardRequest(ctx, "Stats")
+	assert.Equal(t, "POST", r.Method)
+	assert.Equal(t, "/pb.Cadvisor/Stats", r.URL.Path)
+	assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+	assert.Equal(t, "10.0.0.1:4000", r.RemoteAddr)
+	if assert.NotNil(t, r.TLS) {
+		assert.Equal(t, "cadvisor", r.TLS.ServerName)
+	}
+
+	r = guardRequest(context.Background(), "Stats")
+	assert.Empty(t, r.Header)
+	assert.Nil(t, r.TLS)
+}
+
+func TestServerGuard(t *testing.T) {
+	errDenied := errors.New("denied")
+	var methods []string
+	s := &server{
+		guard: func(r *http.Request) error {
+			methods = append(methods, r.URL.Path)
+			return errDenied
+		},
+	}
+
+	_, err := s.GetMachineInfo(context.Background(), &pb.MachineInfoRequest{})
+	assert.Equal(t, errDenied, err)
+	_, err = s.GetContainerInfo(context.Background(), &pb.ContainerInfoRequest{})
+	assert.Equal(t, errDenied, err)
+	assert.Equal(t, []string{"/pb.Cadvisor/GetMachineInfo", "/pb.Cadvisor/GetContainerInfo"}, methods)
+}
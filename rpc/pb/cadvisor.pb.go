@@ -0,0 +1,573 @@
+// Code generated by protoc-gen-go.
+// source: cadvisor.proto
+// DO NOT EDIT!
+
+/*
+Package pb is a generated protocol buffer package.
+
+It is generated from these files:
+
+	cadvisor.proto
+
+It has these top-level messages:
+
+	FsInfo
+	NetInfo
+	MachineInfo
+	CpuSpec
+	MemorySpec
+	ContainerSpec
+	CpuUsage
+	CpuStats
+	MemoryStats
+	InterfaceStats
+	NetworkStats
+	FilesystemStats
+	LoadStats
+	ContainerStats
+	ContainerInfo
+	MachineInfoRequest
+	ContainerInfoRequest
+	ContainerInfoResponse
+	StatsResponse
+*/
+package pb
+
+import proto "github.com/golang/protobuf/proto"
+import fmt "fmt"
+import math "math"
+
+import (
+	context "golang.org/x/net/context"
+	grpc "google.golang.org/grpc"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+const _ = proto.ProtoPackageIsVersion1
+
+type FsInfo struct {
+	Device   string `protobuf:"bytes,1,opt,name=device" json:"device,omitempty"`
+	Type     string `protobuf:"bytes,2,opt,name=type" json:"type,omitempty"`
+	Capacity uint64 `protobuf:"varint,3,opt,name=capacity" json:"capacity,omitempty"`
+	Inodes   uint64 `protobuf:"varint,4,opt,name=inodes" json:"inodes,omitempty"`
+}
+
+func (m *FsInfo) Reset()         { *m = FsInfo{} }
+func (m *FsInfo) String() string { return proto.CompactTextString(m) }
+func (*FsInfo) ProtoMessage()    {}
+
+type NetInfo struct {
+	Name       string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	MacAddress string `protobuf:"bytes,2,opt,name=mac_address" json:"mac_address,omitempty"`
+	Speed      int64  `protobuf:"varint,3,opt,name=speed" json:"speed,omitempty"`
+	Mtu        int64  `protobuf:"varint,4,opt,name=mtu" json:"mtu,omitempty"`
+}
+
+func (m *NetInfo) Reset()         { *m = NetInfo{} }
+func (m *NetInfo) String() string { return proto.CompactTextString(m) }
+func (*NetInfo) ProtoMessage()    {}
+
+type MachineInfo struct {
+	NumCores        int32      `protobuf:"varint,1,opt,name=num_cores" json:"num_cores,omitempty"`
+	CpuFrequencyKhz uint64     `protobuf:"varint,2,opt,name=cpu_frequency_khz" json:"cpu_frequency_khz,omitempty"`
+	MemoryCapacity  uint64     `protobuf:"varint,3,opt,name=memory_capacity" json:"memory_capacity,omitempty"`
+	MachineId       string     `protobuf:"bytes,4,opt,name=machine_id" json:"machine_id,omitempty"`
+	SystemUuid      string     `protobuf:"bytes,5,opt,name=system_uuid" json:"system_uuid,omitempty"`
+	BootId          string     `protobuf:"bytes,6,opt,name=boot_id" json:"boot_id,omitempty"`
+	Filesystems     []*FsInfo  `protobuf:"bytes,7,rep,name=filesystems" json:"filesystems,omitempty"`
+	NetworkDevices  []*NetInfo `protobuf:"bytes,8,rep,name=network_devices" json:"network_devices,omitempty"`
+	CloudProvider   string     `protobuf:"bytes,9,opt,name=cloud_provider" json:"cloud_provider,omitempty"`
+	InstanceType    string     `protobuf:"bytes,10,opt,name=instance_type" json:"instance_type,omitempty"`
+}
+
+func (m *MachineInfo) Reset()         { *m = MachineInfo{} }
+func (m *MachineInfo) String() string { return proto.CompactTextString(m) }
+func (*MachineInfo) ProtoMessage()    {}
+
+func (m *MachineInfo) GetFilesystems() []*FsInfo {
+	if m != nil {
+		return m.Filesystems
+	}
+	return nil
+}
+
+func (m *MachineInfo) GetNetworkDevices() []*NetInfo {
+	if m != nil {
+		return m.NetworkDevices
+	}
+	return nil
+}
+
+type CpuSpec struct {
+	Limit    uint64 `protobuf:"varint,1,opt,name=limit" json:"limit,omitempty"`
+	MaxLimit uint64 `protobuf:"varint,2,opt,name=max_limit" json:"max_limit,omitempty"`
+	Mask     string `protobuf:"bytes,3,opt,name=mask" json:"mask,omitempty"`
+	Quota    uint64 `protobuf:"varint,4,opt,name=quota" json:"quota,omitempty"`
+	Period   uint64 `protobuf:"varint,5,opt,name=period" json:"period,omitempty"`
+}
+
+func (m *CpuSpec) Reset()         { *m = CpuSpec{} }
+func (m *CpuSpec) String() string { return proto.CompactTextString(m) }
+func (*CpuSpec) ProtoMessage()    {}
+
+type MemorySpec struct {
+	Limit       uint64 `protobuf:"varint,1,opt,name=limit" json:"limit,omitempty"`
+	Reservation uint64 `protobuf:"varint,2,opt,name=reservation" json:"reservation,omitempty"`
+	SwapLimit   uint64 `protobuf:"varint,3,opt,name=swap_limit" json:"swap_limit,omitempty"`
+}
+
+func (m *MemorySpec) Reset()         { *m = MemorySpec{} }
+func (m *MemorySpec) String() string { return proto.CompactTextString(m) }
+func (*MemorySpec) ProtoMessage()    {}
+
+type ContainerSpec struct {
+	CreationTime     int64             `protobuf:"varint,1,opt,name=creation_time" json:"creation_time,omitempty"`
+	Aliases          []string          `protobuf:"bytes,2,rep,name=aliases" json:"aliases,omitempty"`
+	Namespace        string            `protobuf:"bytes,3,opt,name=namespace" json:"namespace,omitempty"`
+	Labels           map[string]string `protobuf:"bytes,4,rep,name=labels" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	HasCpu           bool              `protobuf:"varint,5,opt,name=has_cpu" json:"has_cpu,omitempty"`
+	Cpu              *CpuSpec          `protobuf:"bytes,6,opt,name=cpu" json:"cpu,omitempty"`
+	HasMemory        bool              `protobuf:"varint,7,opt,name=has_memory" json:"has_memory,omitempty"`
+	Memory           *MemorySpec       `protobuf:"bytes,8,opt,name=memory" json:"memory,omitempty"`
+	HasNetwork       bool              `protobuf:"varint,9,opt,name=has_network" json:"has_network,omitempty"`
+	HasFilesystem    bool              `protobuf:"varint,10,opt,name=has_filesystem" json:"has_filesystem,omitempty"`
+	HasDiskio        bool              `protobuf:"varint,11,opt,name=has_diskio" json:"has_diskio,omitempty"`
+	HasCustomMetrics bool              `protobuf:"varint,12,opt,name=has_custom_metrics" json:"has_custom_metrics,omitempty"`
+	Image            string            `protobuf:"bytes,13,opt,name=image" json:"image,omitempty"`
+}
+
+func (m *ContainerSpec) Reset()         { *m = ContainerSpec{} }
+func (m *ContainerSpec) String() string { return proto.CompactTextString(m) }
+func (*ContainerSpec) ProtoMessage()    {}
+
+func (m *ContainerSpec) GetLabels() map[string]string {
+	if m != nil {
+		return m.Labels
+	}
+	return nil
+}
+
+func (m *ContainerSpec) GetCpu() *CpuSpec {
+	if m != nil {
+		return m.Cpu
+	}
+	return nil
+}
+
+func (m *ContainerSpec) GetMemory() *MemorySpec {
+	if m != nil {
+		return m.Memory
+	}
+	return nil
+}
+
+type CpuUsage struct {
+	Total       uint64   `protobuf:"varint,1,opt,name=total" json:"total,omitempty"`
+	PerCpuUsage []uint64 `protobuf:"varint,2,rep,packed,name=per_cpu_usage" json:"per_cpu_usage,omitempty"`
+	User        uint64   `protobuf:"varint,3,opt,name=user" json:"user,omitempty"`
+	System      uint64   `protobuf:"varint,4,opt,name=system" json:"system,omitempty"`
+}
+
+func (m *CpuUsage) Reset()         { *m = CpuUsage{} }
+func (m *CpuUsage) String() string { return proto.CompactTextString(m) }
+func (*CpuUsage) ProtoMessage()    {}
+
+type CpuStats struct {
+	Usage       *CpuUsage `protobuf:"bytes,1,opt,name=usage" json:"usage,omitempty"`
+	LoadAverage int32     `protobuf:"varint,2,opt,name=load_average" json:"load_average,omitempty"`
+}
+
+func (m *CpuStats) Reset()         { *m = CpuStats{} }
+func (m *CpuStats) String() string { return proto.CompactTextString(m) }
+func (*CpuStats) ProtoMessage()    {}
+
+func (m *CpuStats) GetUsage() *CpuUsage {
+	if m != nil {
+		return m.Usage
+	}
+	return nil
+}
+
+type MemoryStats struct {
+	Usage      uint64 `protobuf:"varint,1,opt,name=usage" json:"usage,omitempty"`
+	Cache      uint64 `protobuf:"varint,2,opt,name=cache" json:"cache,omitempty"`
+	Rss        uint64 `protobuf:"varint,3,opt,name=rss" json:"rss,omitempty"`
+	WorkingSet uint64 `protobuf:"varint,4,opt,name=working_set" json:"working_set,omitempty"`
+	Failcnt    uint64 `protobuf:"varint,5,opt,name=failcnt" json:"failcnt,omitempty"`
+}
+
+func (m *MemoryStats) Reset()         { *m = MemoryStats{} }
+func (m *MemoryStats) String() string { return proto.CompactTextString(m) }
+func (*MemoryStats) ProtoMessage()    {}
+
+type InterfaceStats struct {
+	Name      string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	RxBytes   uint64 `protobuf:"varint,2,opt,name=rx_bytes" json:"rx_bytes,omitempty"`
+	RxPackets uint64 `protobuf:"varint,3,opt,name=rx_packets" json:"rx_packets,omitempty"`
+	RxErrors  uint64 `protobuf:"varint,4,opt,name=rx_errors" json:"rx_errors,omitempty"`
+	RxDropped uint64 `protobuf:"varint,5,opt,name=rx_dropped" json:"rx_dropped,omitempty"`
+	TxBytes   uint64 `protobuf:"varint,6,opt,name=tx_bytes" json:"tx_bytes,omitempty"`
+	TxPackets uint64 `protobuf:"varint,7,opt,name=tx_packets" json:"tx_packets,omitempty"`
+	TxErrors  uint64 `protobuf:"varint,8,opt,name=tx_errors" json:"tx_errors,omitempty"`
+	TxDropped uint64 `protobuf:"varint,9,opt,name=tx_dropped" json:"tx_dropped,omitempty"`
+}
+
+func (m *InterfaceStats) Reset()         { *m = InterfaceStats{} }
+func (m *InterfaceStats) String() string { return proto.CompactTextString(m) }
+func (*InterfaceStats) ProtoMessage()    {}
+
+type NetworkStats struct {
+	Interfaces []*InterfaceStats `protobuf:"bytes,1,rep,name=interfaces" json:"interfaces,omitempty"`
+}
+
+func (m *NetworkStats) Reset()         { *m = NetworkStats{} }
+func (m *NetworkStats) String() string { return proto.CompactTextString(m) }
+func (*NetworkStats) ProtoMessage()    {}
+
+func (m *NetworkStats) GetInterfaces() []*InterfaceStats {
+	if m != nil {
+		return m.Interfaces
+	}
+	return nil
+}
+
+type FilesystemStats struct {
+	TotalUsageBytes uint64 `protobuf:"varint,1,opt,name=total_usage_bytes" json:"total_usage_bytes,omitempty"`
+	BaseUsageBytes  uint64 `protobuf:"varint,2,opt,name=base_usage_bytes" json:"base_usage_bytes,omitempty"`
+}
+
+func (m *FilesystemStats) Reset()         { *m = FilesystemStats{} }
+func (m *FilesystemStats) String() string { return proto.CompactTextString(m) }
+func (*FilesystemStats) ProtoMessage()    {}
+
+type LoadStats struct {
+	NrSleeping        uint64 `protobuf:"varint,1,opt,name=nr_sleeping" json:"nr_sleeping,omitempty"`
+	NrRunning         uint64 `protobuf:"varint,2,opt,name=nr_running" json:"nr_running,omitempty"`
+	NrStopped         uint64 `protobuf:"varint,3,opt,name=nr_stopped" json:"nr_stopped,omitempty"`
+	NrUninterruptible uint64 `protobuf:"varint,4,opt,name=nr_uninterruptible" json:"nr_uninterruptible,omitempty"`
+	NrIoWait          uint64 `protobuf:"varint,5,opt,name=nr_io_wait" json:"nr_io_wait,omitempty"`
+}
+
+func (m *LoadStats) Reset()         { *m = LoadStats{} }
+func (m *LoadStats) String() string { return proto.CompactTextString(m) }
+func (*LoadStats) ProtoMessage()    {}
+
+type ContainerStats struct {
+	Timestamp  int64            `protobuf:"varint,1,opt,name=timestamp" json:"timestamp,omitempty"`
+	Cpu        *CpuStats        `protobuf:"bytes,2,opt,name=cpu" json:"cpu,omitempty"`
+	CpuInst    *CpuUsage        `protobuf:"bytes,3,opt,name=cpu_inst" json:"cpu_inst,omitempty"`
+	Memory     *MemoryStats     `protobuf:"bytes,4,opt,name=memory" json:"memory,omitempty"`
+	Network    *NetworkStats    `protobuf:"bytes,5,opt,name=network" json:"network,omitempty"`
+	Filesystem *FilesystemStats `protobuf:"bytes,6,opt,name=filesystem" json:"filesystem,omitempty"`
+	LoadStats  *LoadStats       `protobuf:"bytes,7,opt,name=load_stats" json:"load_stats,omitempty"`
+}
+
+func (m *ContainerStats) Reset()         { *m = ContainerStats{} }
+func (m *ContainerStats) String() string { return proto.CompactTextString(m) }
+func (*ContainerStats) ProtoMessage()    {}
+
+func (m *ContainerStats) GetCpu() *CpuStats {
+	if m != nil {
+		return m.Cpu
+	}
+	return nil
+}
+
+func (m *ContainerStats) GetCpuInst() *CpuUsage {
+	if m != nil {
+		return m.CpuInst
+	}
+	return nil
+}
+
+func (m *ContainerStats) GetMemory() *MemoryStats {
+	if m != nil {
+		return m.Memory
+	}
+	return nil
+}
+
+func (m *ContainerStats) GetNetwork() *NetworkStats {
+	if m != nil {
+		return m.Network
+	}
+	return nil
+}
+
+func (m *ContainerStats) GetFilesystem() *FilesystemStats {
+	if m != nil {
+		return m.Filesystem
+	}
+	return nil
+}
+
+func (m *ContainerStats) GetLoadStats() *LoadStats {
+	if m != nil {
+		return m.LoadStats
+	}
+	return nil
+}
+
+type ContainerInfo struct {
+	Name  string            `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Spec  *ContainerSpec    `protobuf:"bytes,2,opt,name=spec" json:"spec,omitempty"`
+	Stats []*ContainerStats `protobuf:"bytes,3,rep,name=stats" json:"stats,omitempty"`
+}
+
+func (m *ContainerInfo) Reset()         { *m = ContainerInfo{} }
+func (m *ContainerInfo) String() string { return proto.CompactTextString(m) }
+func (*ContainerInfo) ProtoMessage()    {}
+
+func (m *ContainerInfo) GetSpec() *ContainerSpec {
+	if m != nil {
+		return m.Spec
+	}
+	return nil
+}
+
+func (m *ContainerInfo) GetStats() []*ContainerStats {
+	if m != nil {
+		return m.Stats
+	}
+	return nil
+}
+
+// Request for GetMachineInfo().
+type MachineInfoRequest struct {
+}
+
+func (m *MachineInfoRequest) Reset()         { *m = MachineInfoRequest{} }
+func (m *MachineInfoRequest) String() string { return proto.CompactTextString(m) }
+func (*MachineInfoRequest) ProtoMessage()    {}
+
+// Request for GetContainerInfo() and Stats().
+type ContainerInfoRequest struct {
+	// Absolute container name, or Docker name/id when type is "docker".
+	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	// Type of container identifier: "name" (default) or "docker".
+	Type string `protobuf:"bytes,2,opt,name=type" json:"type,omitempty"`
+	// Number of stats to return. Defaults to 64.
+	Count int32 `protobuf:"varint,3,opt,name=count" json:"count,omitempty"`
+	// Whether to include child subcontainers.
+	Recursive bool `protobuf:"varint,4,opt,name=recursive" json:"recursive,omitempty"`
+}
+
+func (m *ContainerInfoRequest) Reset()         { *m = ContainerInfoRequest{} }
+func (m *ContainerInfoRequest) String() string { return proto.CompactTextString(m) }
+func (*ContainerInfoRequest) ProtoMessage()    {}
+
+// Response for GetContainerInfo().
+type ContainerInfoResponse struct {
+	Containers []*ContainerInfo `protobuf:"bytes,1,rep,name=containers" json:"containers,omitempty"`
+}
+
+func (m *ContainerInfoResponse) Reset()         { *m = ContainerInfoResponse{} }
+func (m *ContainerInfoResponse) String() string { return proto.CompactTextString(m) }
+func (*ContainerInfoResponse) ProtoMessage()    {}
+
+func (m *ContainerInfoResponse) GetContainers() []*ContainerInfo {
+	if m != nil {
+		return m.Containers
+	}
+	return nil
+}
+
+// Message streamed by Stats().
+type StatsResponse struct {
+	Name  string          `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Stats *ContainerStats `protobuf:"bytes,2,opt,name=stats" json:"stats,omitempty"`
+}
+
+func (m *StatsResponse) Reset()         { *m = StatsResponse{} }
+func (m *StatsResponse) String() string { return proto.CompactTextString(m) }
+func (*StatsResponse) ProtoMessage()    {}
+
+func (m *StatsResponse) GetStats() *ContainerStats {
+	if m != nil {
+		return m.Stats
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*FsInfo)(nil), "pb.FsInfo")
+	proto.RegisterType((*NetInfo)(nil), "pb.NetInfo")
+	proto.RegisterType((*MachineInfo)(nil), "pb.MachineInfo")
+	proto.RegisterType((*CpuSpec)(nil), "pb.CpuSpec")
+	proto.RegisterType((*MemorySpec)(nil), "pb.MemorySpec")
+	proto.RegisterType((*ContainerSpec)(nil), "pb.ContainerSpec")
+	proto.RegisterType((*CpuUsage)(nil), "pb.CpuUsage")
+	proto.RegisterType((*CpuStats)(nil), "pb.CpuStats")
+	proto.RegisterType((*MemoryStats)(nil), "pb.MemoryStats")
+	proto.RegisterType((*InterfaceStats)(nil), "pb.InterfaceStats")
+	proto.RegisterType((*NetworkStats)(nil), "pb.NetworkStats")
+	proto.RegisterType((*FilesystemStats)(nil), "pb.FilesystemStats")
+	proto.RegisterType((*LoadStats)(nil), "pb.LoadStats")
+	proto.RegisterType((*ContainerStats)(nil), "pb.ContainerStats")
+	proto.RegisterType((*ContainerInfo)(nil), "pb.ContainerInfo")
+	proto.RegisterType((*MachineInfoRequest)(nil), "pb.MachineInfoRequest")
+	proto.RegisterType((*ContainerInfoRequest)(nil), "pb.ContainerInfoRequest")
+	proto.RegisterType((*ContainerInfoResponse)(nil), "pb.ContainerInfoResponse")
+	proto.RegisterType((*StatsResponse)(nil), "pb.StatsResponse")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// Client API for Cadvisor service
+
+type CadvisorClient interface {
+	// GetMachineInfo returns the static information about the machine.
+	GetMachineInfo(ctx context.Context, in *MachineInfoRequest, opts ...grpc.CallOption) (*MachineInfo, error)
+	// GetContainerInfo returns the spec and recent stats of the requested containers.
+	GetContainerInfo(ctx context.Context, in *ContainerInfoRequest, opts ...grpc.CallOption) (*ContainerInfoResponse, error)
+	// Stats streams every new stats sample of the requested containers as it is collected.
+	Stats(ctx context.Context, in *ContainerInfoRequest, opts ...grpc.CallOption) (Cadvisor_StatsClient, error)
+}
+
+type cadvisorClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewCadvisorClient(cc *grpc.ClientConn) CadvisorClient {
+	return &cadvisorClient{cc}
+}
+
+func (c *cadvisorClient) GetMachineInfo(ctx context.Context, in *MachineInfoRequest, opts ...grpc.CallOption) (*MachineInfo, error) {
+	out := new(MachineInfo)
+	err := grpc.Invoke(ctx, "/pb.Cadvisor/GetMachineInfo", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cadvisorClient) GetContainerInfo(ctx context.Context, in *ContainerInfoRequest, opts ...grpc.CallOption) (*ContainerInfoResponse, error) {
+	out := new(ContainerInfoResponse)
+	err := grpc.Invoke(ctx, "/pb.Cadvisor/GetContainerInfo", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cadvisorClient) Stats(ctx context.Context, in *ContainerInfoRequest, opts ...grpc.CallOption) (Cadvisor_StatsClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Cadvisor_serviceDesc.Streams[0], c.cc, "/pb.Cadvisor/Stats", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &cadvisorStatsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Cadvisor_StatsClient interface {
+	Recv() (*StatsResponse, error)
+	grpc.ClientStream
+}
+
+type cadvisorStatsClient struct {
+	grpc.ClientStream
+}
+
+func (x *cadvisorStatsClient) Recv() (*StatsResponse, error) {
+	m := new(StatsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Server API for Cadvisor service
+
+type CadvisorServer interface {
+	// GetMachineInfo returns the static information about the machine.
+	GetMachineInfo(context.Context, *MachineInfoRequest) (*MachineInfo, error)
+	// GetContainerInfo returns the spec and recent stats of the requested containers.
+	GetContainerInfo(context.Context, *ContainerInfoRequest) (*ContainerInfoResponse, error)
+	// Stats streams every new stats sample of the requested containers as it is collected.
+	Stats(*ContainerInfoRequest, Cadvisor_StatsServer) error
+}
+
+func RegisterCadvisorServer(s *grpc.Server, srv CadvisorServer) {
+	s.RegisterService(&_Cadvisor_serviceDesc, srv)
+}
+
+func _Cadvisor_GetMachineInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(MachineInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(CadvisorServer).GetMachineInfo(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Cadvisor_GetContainerInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error) (interface{}, error) {
+	in := new(ContainerInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	out, err := srv.(CadvisorServer).GetContainerInfo(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func _Cadvisor_Stats_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ContainerInfoRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CadvisorServer).Stats(m, &cadvisorStatsServer{stream})
+}
+
+type Cadvisor_StatsServer interface {
+	Send(*StatsResponse) error
+	grpc.ServerStream
+}
+
+type cadvisorStatsServer struct {
+	grpc.ServerStream
+}
+
+func (x *cadvisorStatsServer) Send(m *StatsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _Cadvisor_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "pb.Cadvisor",
+	HandlerType: (*CadvisorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetMachineInfo",
+			Handler:    _Cadvisor_GetMachineInfo_Handler,
+		},
+		{
+			MethodName: "GetContainerInfo",
+			Handler:    _Cadvisor_GetContainerInfo_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Stats",
+			Handler:       _Cadvisor_Stats_Handler,
+			ServerStreams: true,
+		},
+	},
+}
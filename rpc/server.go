@@ -0,0 +1,166 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rpc provides a gRPC server exposing machine and container
+// information.
+package rpc
+
+import (
+	"time"
+
+	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/logging"
+	"github.com/google/cadvisor/manager"
+	"github.com/google/cadvisor/rpc/pb"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+const defaultStatsCount = 64
+
+type server struct {
+	m manager.Manager
+
+	// How often the memory cache is checked for new samples by Stats().
+	pollInterval time.Duration
+
+	// Checks every RPC before serving it, if set.
+	guard Guard
+}
+
+// NewServer returns a gRPC server with the cAdvisor service registered. Every
+// RPC is checked by guard, if not nil, before being served.
+func NewServer(m manager.Manager, guard Guard, opt ...grpc.ServerOption) *grpc.Server {
+	s := grpc.NewServer(opt...)
+	pb.RegisterCadvisorServer(s, &server{
+		m:            m,
+		pollInterval: *manager.HousekeepingInterval,
+		guard:        guard,
+	})
+	return s
+}
+
+// Returns the error of the guard for the RPC of method in ctx, if any.
+func (s *server) check(ctx context.Context, method string) error {
+	if s.guard == nil {
+		return nil
+	}
+	return s.guard(guardRequest(ctx, method))
+}
+
+func getRequestOptions(req *pb.ContainerInfoRequest) (v2.RequestOptions, error) {
+	opt := v2.RequestOptions{
+		IdType:    v2.TypeName,
+		Count:     defaultStatsCount,
+		Recursive: req.Recursive,
+	}
+	switch req.Type {
+	case "", v2.TypeName:
+	case v2.TypeDocker:
+		opt.IdType = v2.TypeDocker
+	default:
+		return opt, grpc.Errorf(codes.InvalidArgument, "unknown type %q", req.Type)
+	}
+	if req.Count > 0 {
+		opt.Count = int(req.Count)
+	}
+	return opt, nil
+}
+
+func containerName(req *pb.ContainerInfoRequest) string {
+	if req.Name == "" {
+		return "/"
+	}
+	return req.Name
+}
+
+func (s *server) GetMachineInfo(ctx context.Context, req *pb.MachineInfoRequest) (*pb.MachineInfo, error) {
+	if err := s.check(ctx, "GetMachineInfo"); err != nil {
+		return nil, err
+	}
+	logging.V(4).Infof("RPC - MachineInfo")
+	machineInfo, err := s.m.GetMachineInfo()
+	if err != nil {
+		return nil, err
+	}
+	return MachineInfoToProto(machineInfo), nil
+}
+
+func (s *server) GetContainerInfo(ctx context.Context, req *pb.ContainerInfoRequest) (*pb.ContainerInfoResponse, error) {
+	if err := s.check(ctx, "GetContainerInfo"); err != nil {
+		return nil, err
+	}
+	opt, err := getRequestOptions(req)
+	if err != nil {
+		return nil, err
+	}
+	name := containerName(req)
+	logging.V(4).Infof("RPC - ContainerInfo for container %q, options %+v", name, opt)
+	infos, err := s.m.GetContainerInfoV2(name, opt)
+	if err != nil {
+		return nil, grpc.Errorf(codes.NotFound, "%v", err)
+	}
+
+	return ContainersToProto(infos), nil
+}
+
+func (s *server) Stats(req *pb.ContainerInfoRequest, stream pb.Cadvisor_StatsServer) error {
+	if err := s.check(stream.Context(), "Stats"); err != nil {
+		return err
+	}
+	opt, err := getRequestOptions(req)
+	if err != nil {
+		return err
+	}
+	name := containerName(req)
+	logging.V(4).Infof("RPC - Stats stream for container %q, options %+v", name, opt)
+
+	// Only the latest sample is sent for containers not seen before.
+	lastSent := make(map[string]time.Time)
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		infos, err := s.m.GetContainerInfoV2(name, opt)
+		if err != nil {
+			return grpc.Errorf(codes.NotFound, "%v", err)
+		}
+		for cname, cinfo := range infos {
+			last, seen := lastSent[cname]
+			for i, stats := range cinfo.Stats {
+				if !seen && i != len(cinfo.Stats)-1 {
+					continue
+				}
+				if seen && !stats.Timestamp.After(last) {
+					continue
+				}
+				err := stream.Send(&pb.StatsResponse{
+					Name:  cname,
+					Stats: containerStatsToProto(stats),
+				})
+				if err != nil {
+					return err
+				}
+				lastSent[cname] = stats.Timestamp
+			}
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
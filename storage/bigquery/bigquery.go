@@ -15,7 +15,10 @@
 package bigquery
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
+	"sort"
 
 	info "github.com/google/cadvisor/info/v1"
 	"github.com/google/cadvisor/storage"
@@ -31,6 +34,8 @@ func init() {
 type bigqueryStorage struct {
 	client      *client.Client
 	machineName string
+	// Set with --storage_driver_dry_run, instead of the client.
+	dryRun *storage.DryRun
 }
 
 const (
@@ -187,6 +192,13 @@ func (self *bigqueryStorage) GetSchema() *bigquery.TableSchema {
 		Type: typeInteger,
 		Name: colFsUsage,
 	}
+	// Static tags of --storage_driver_tags.
+	for _, tag := range staticTagNames() {
+		fields = append(fields, &bigquery.TableFieldSchema{
+			Type: typeString,
+			Name: tag,
+		})
+	}
 	return &bigquery.TableSchema{
 		Fields: fields,
 	}
@@ -246,6 +258,8 @@ func (self *bigqueryStorage) containerStatsToRows(
 
 	// TODO(jnagal): Handle per-cpu stats.
 
+	addStaticTags(row)
+
 	return
 }
 
@@ -258,11 +272,42 @@ func (self *bigqueryStorage) containerFilesystemStatsToRows(
 		row[colFsDevice] = fsStat.Device
 		row[colFsLimit] = fsStat.Limit
 		row[colFsUsage] = fsStat.Usage
+		addStaticTags(row)
 		rows = append(rows, row)
 	}
 	return rows
 }
 
+// Returns the sorted names of the static tags, each a column of the table.
+func staticTagNames() []string {
+	tags := storage.StaticTags()
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Checks that no static tag has the name of a column of the stats.
+func checkStaticTags() error {
+	columns := make(map[string]bool)
+	for _, field := range (&bigqueryStorage{}).GetSchema().Fields {
+		if columns[field.Name] {
+			return fmt.Errorf("static tag %q conflicts with a column of the table", field.Name)
+		}
+		columns[field.Name] = true
+	}
+	return nil
+}
+
+// Sets the columns of the static tags of the row.
+func addStaticTags(row map[string]interface{}) {
+	for name, value := range storage.StaticTags() {
+		row[name] = value
+	}
+}
+
 func (self *bigqueryStorage) AddStats(ref info.ContainerReference, stats *info.ContainerStats) error {
 	if stats == nil {
 		return nil
@@ -271,6 +316,12 @@ func (self *bigqueryStorage) AddStats(ref info.ContainerReference, stats *info.C
 	rows = append(rows, self.containerStatsToRows(ref, stats))
 	rows = append(rows, self.containerFilesystemStatsToRows(ref, stats)...)
 	for _, row := range rows {
+		if self.dryRun != nil {
+			if err := self.logRow(row); err != nil {
+				return err
+			}
+			continue
+		}
 		err := self.client.InsertRow(row)
 		if err != nil {
 			return err
@@ -279,7 +330,39 @@ func (self *bigqueryStorage) AddStats(ref info.ContainerReference, stats *info.C
 	return nil
 }
 
+// Logs the row as JSON instead of inserting it. The rows of filesystems are
+// reported apart from the rows of stats.
+func (self *bigqueryStorage) logRow(row map[string]interface{}) error {
+	b, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	measurement := "stats"
+	if _, ok := row[colFsDevice]; ok {
+		measurement = "filesystem"
+	}
+	tags := make(map[string]string)
+	for _, col := range []string{colMachineName, colContainerName, colFsDevice} {
+		if value, ok := row[col]; ok {
+			tags[col] = fmt.Sprint(value)
+		}
+	}
+	self.dryRun.Write(measurement, tags, string(b))
+	return nil
+}
+
+// Flush is a no-op: rows are inserted right away. A dry run logs its report.
+func (self *bigqueryStorage) Flush() error {
+	if self.dryRun != nil {
+		self.dryRun.Flush()
+	}
+	return nil
+}
+
 func (self *bigqueryStorage) Close() error {
+	if self.client == nil {
+		return nil
+	}
 	self.client.Close()
 	self.client = nil
 	return nil
@@ -290,6 +373,15 @@ func (self *bigqueryStorage) Close() error {
 // instance is running on.
 // tableName: BigQuery table used for storing stats.
 func newStorage(machineName, datasetId, tableName string) (storage.StorageDriver, error) {
+	if err := checkStaticTags(); err != nil {
+		return nil, err
+	}
+	if *storage.ArgDryRun {
+		return &bigqueryStorage{
+			machineName: machineName,
+			dryRun:      storage.NewDryRun("bigquery"),
+		}, nil
+	}
 	bqClient, err := client.NewClient()
 	if err != nil {
 		return nil, err
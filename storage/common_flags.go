@@ -21,7 +21,7 @@ import (
 
 var ArgDbUsername = flag.String("storage_driver_user", "root", "database username")
 var ArgDbPassword = flag.String("storage_driver_password", "root", "database password")
-var ArgDbHost = flag.String("storage_driver_host", "localhost:8086", "database host:port")
+var ArgDbHost = flag.String("storage_driver_host", "localhost:8086", "database host:port. A comma-separated list of hosts mirrors the writes of the influxdb driver to all of them")
 var ArgDbName = flag.String("storage_driver_db", "cadvisor", "database name")
 var ArgDbTable = flag.String("storage_driver_table", "stats", "table name")
 var ArgDbIsSecure = flag.Bool("storage_driver_secure", false, "use secure connection with database")
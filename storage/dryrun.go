@@ -0,0 +1,204 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/cadvisor/logging"
+)
+
+var ArgDryRun = flag.Bool("storage_driver_dry_run", false, "log the points the storage driver would write instead of writing them, with periodic reports of their rate and tag cardinality. The storage driver doesn't connect to its backend")
+var argDryRunReportInterval = flag.Duration("storage_driver_dry_run_report_interval", time.Minute, "interval of the reports of --storage_driver_dry_run")
+
+// Number of values of a tag above which a dry run report warns of its
+// cardinality.
+const highTagCardinality = 1000
+
+// DryRun logs the serialized points of a storage driver in place of writing
+// them, and reports their rate per measurement and the cardinality of their
+// tags every --storage_driver_dry_run_report_interval.
+type DryRun struct {
+	driver   string
+	interval time.Duration
+	lock     sync.Mutex
+	// Start of the current report period, and the points per measurement,
+	// distinct series and values per tag written since.
+	periodStart  time.Time
+	measurements map[string]int
+	series       map[string]struct{}
+	tagValues    map[string]map[string]struct{}
+}
+
+// NewDryRun returns the dry run of the named storage driver. Drivers create
+// one when --storage_driver_dry_run is set.
+func NewDryRun(driver string) *DryRun {
+	logging.Infof("Dry run of storage driver %q: logging the points instead of writing them", driver)
+	return newDryRun(driver, *argDryRunReportInterval, time.Now())
+}
+
+func newDryRun(driver string, interval time.Duration, now time.Time) *DryRun {
+	self := &DryRun{
+		driver:   driver,
+		interval: interval,
+	}
+	self.startPeriod(now)
+	return self
+}
+
+// Starts a report period. Must be called with the lock held.
+func (self *DryRun) startPeriod(now time.Time) {
+	self.periodStart = now
+	self.measurements = make(map[string]int)
+	self.series = make(map[string]struct{})
+	self.tagValues = make(map[string]map[string]struct{})
+}
+
+// Write logs the serialized point of the measurement instead of writing it,
+// and accounts for its tags in the report.
+func (self *DryRun) Write(measurement string, tags map[string]string, point string) {
+	logging.Infof("Dry run of %s: %s", self.driver, point)
+	if report := self.add(measurement, tags, time.Now()); report != nil {
+		logging.Info(report.String())
+	}
+}
+
+// Accounts for the point. Returns the report of the period if it is over.
+func (self *DryRun) add(measurement string, tags map[string]string, now time.Time) *DryRunReport {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.measurements[measurement]++
+	self.series[seriesKey(measurement, tags)] = struct{}{}
+	for tag, value := range tags {
+		values, ok := self.tagValues[tag]
+		if !ok {
+			values = make(map[string]struct{})
+			self.tagValues[tag] = values
+		}
+		values[value] = struct{}{}
+	}
+	if now.Sub(self.periodStart) < self.interval {
+		return nil
+	}
+	report := self.report(now)
+	self.startPeriod(now)
+	return &report
+}
+
+// Flush logs the report of the current period, e.g. before cAdvisor exits.
+func (self *DryRun) Flush() {
+	self.lock.Lock()
+	report := self.report(time.Now())
+	self.lock.Unlock()
+	logging.Info(report.String())
+}
+
+// Must be called with the lock held.
+func (self *DryRun) report(now time.Time) DryRunReport {
+	report := DryRunReport{
+		Driver:       self.driver,
+		Period:       now.Sub(self.periodStart),
+		Measurements: make(map[string]int, len(self.measurements)),
+		Series:       len(self.series),
+		TagValues:    make(map[string]int, len(self.tagValues)),
+	}
+	for measurement, points := range self.measurements {
+		report.Measurements[measurement] = points
+		report.Points += points
+	}
+	for tag, values := range self.tagValues {
+		report.TagValues[tag] = len(values)
+	}
+	return report
+}
+
+// Identifies a series by its measurement and tags, sorted by name.
+func seriesKey(measurement string, tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for tag, value := range tags {
+		pairs = append(pairs, tag+"="+value)
+	}
+	sort.Strings(pairs)
+	return measurement + "," + strings.Join(pairs, ",")
+}
+
+// DryRunReport is the validation report of a dry run.
+type DryRunReport struct {
+	Driver string
+	// Duration of the report period, and the points per measurement,
+	// distinct series and values per tag written during it.
+	Period       time.Duration
+	Points       int
+	Measurements map[string]int
+	Series       int
+	TagValues    map[string]int
+}
+
+// Rate returns the points per second of the period.
+func (self *DryRunReport) Rate(points int) float64 {
+	if self.Period <= 0 {
+		return 0
+	}
+	return float64(points) / self.Period.Seconds()
+}
+
+// Warnings returns the tags whose cardinality is high enough to strain the
+// backend, sorted by name.
+func (self *DryRunReport) Warnings() []string {
+	var warnings []string
+	for tag, values := range self.TagValues {
+		if values > highTagCardinality {
+			warnings = append(warnings, fmt.Sprintf("tag %q has %d values, more than %d", tag, values, highTagCardinality))
+		}
+	}
+	sort.Strings(warnings)
+	return warnings
+}
+
+func (self *DryRunReport) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Dry run report of %s: %d points in %v (%.2f/s), %d series", self.Driver, self.Points, self.Period, self.Rate(self.Points), self.Series)
+	if len(self.Measurements) > 0 {
+		buf.WriteString("\n  points/s per measurement:")
+		for _, measurement := range sortedKeys(self.Measurements) {
+			fmt.Fprintf(&buf, " %s=%.2f", measurement, self.Rate(self.Measurements[measurement]))
+		}
+	}
+	if len(self.TagValues) > 0 {
+		buf.WriteString("\n  values per tag:")
+		for _, tag := range sortedKeys(self.TagValues) {
+			fmt.Fprintf(&buf, " %s=%d", tag, self.TagValues[tag])
+		}
+	}
+	for _, warning := range self.Warnings() {
+		fmt.Fprintf(&buf, "\n  warning: %s", warning)
+	}
+	return buf.String()
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,62 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDryRunReport(t *testing.T) {
+	start := time.Unix(1000, 0)
+	dryRun := newDryRun("influxdb", time.Minute, start)
+	for i := 0; i < 60; i++ {
+		tags := map[string]string{"container_name": fmt.Sprintf("/c%d", i%3), "machine": "node"}
+		if report := dryRun.add("cpu_usage_total", tags, start.Add(time.Duration(i)*time.Second)); report != nil {
+			t.Fatalf("unexpected report before the end of the period: %v", report)
+		}
+		dryRun.add("memory_usage", tags, start.Add(time.Duration(i)*time.Second))
+	}
+	report := dryRun.add("cpu_usage_total", map[string]string{"container_name": "/c0", "machine": "node"}, start.Add(time.Minute))
+	if report == nil {
+		t.Fatal("expected a report at the end of the period")
+	}
+	if report.Points != 121 || report.Series != 6 {
+		t.Errorf("expected 121 points of 6 series, got %d points of %d series", report.Points, report.Series)
+	}
+	if report.TagValues["container_name"] != 3 || report.TagValues["machine"] != 1 {
+		t.Errorf("unexpected tag cardinality %v", report.TagValues)
+	}
+	expected := "Dry run report of influxdb: 121 points in 1m0s (2.02/s), 6 series\n" +
+		"  points/s per measurement: cpu_usage_total=1.02 memory_usage=1.00\n" +
+		"  values per tag: container_name=3 machine=1"
+	if report.String() != expected {
+		t.Errorf("unexpected report:\n%s\nexpected:\n%s", report.String(), expected)
+	}
+
+	// The rates and the cardinality restart with the period.
+	for i := 0; i <= highTagCardinality; i++ {
+		dryRun.add("cpu_usage_total", map[string]string{"container_name": fmt.Sprintf("/d%d", i)}, start.Add(time.Minute+time.Second))
+	}
+	second := dryRun.report(start.Add(2 * time.Minute))
+	if second.Points != highTagCardinality+1 || second.Series != highTagCardinality+1 || second.TagValues["container_name"] != highTagCardinality+1 || second.TagValues["machine"] != 0 {
+		t.Errorf("unexpected second report %+v", second)
+	}
+	if warnings := second.Warnings(); len(warnings) != 1 || !strings.Contains(warnings[0], `"container_name" has 1001 values`) {
+		t.Errorf("expected a warning about container_name, got %v", warnings)
+	}
+}
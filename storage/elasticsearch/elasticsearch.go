@@ -15,6 +15,7 @@
 package elasticsearch
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
@@ -22,6 +23,7 @@ import (
 	"time"
 
 	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/info/v2"
 	storage "github.com/google/cadvisor/storage"
 
 	"gopkg.in/olivere/elastic.v2"
@@ -37,6 +39,10 @@ type elasticStorage struct {
 	indexName   string
 	typeName    string
 	lock        sync.Mutex
+	// Set with --storage_driver_dry_run, instead of the client.
+	dryRun *storage.DryRun
+	// Computes the rates of --storage_driver_rates.
+	rates storage.RateTracker
 }
 
 type detailSpec struct {
@@ -44,6 +50,11 @@ type detailSpec struct {
 	MachineName    string               `json:"machine_name,omitempty"`
 	ContainerName  string               `json:"container_Name,omitempty"`
 	ContainerStats *info.ContainerStats `json:"container_stats,omitempty"`
+	ContainerEvent *info.Event          `json:"container_event,omitempty"`
+	MachineTags    map[string]string    `json:"machine_tags,omitempty"`
+	DerivedStats   *v2.DerivedStats     `json:"derived_stats,omitempty"`
+	// Rates of --storage_driver_rates.
+	Rates map[string]float64 `json:"rates,omitempty"`
 }
 
 var (
@@ -81,6 +92,10 @@ func (self *elasticStorage) containerStatsAndDefaultValues(
 		MachineName:    self.machineName,
 		ContainerName:  containerName,
 		ContainerStats: stats,
+		MachineTags:    storage.MachineTags(),
+	}
+	if storage.WriteRates() {
+		detail.Rates = self.rates.Rates(ref.Name, stats)
 	}
 	return detail
 }
@@ -96,11 +111,7 @@ func (self *elasticStorage) AddStats(ref info.ContainerReference, stats *info.Co
 		// Add some default params based on ContainerStats
 		detail := self.containerStatsAndDefaultValues(ref, stats)
 		// Index a cadvisor (using JSON serialization)
-		_, err := self.client.Index().
-			Index(self.indexName).
-			Type(self.typeName).
-			BodyJson(detail).
-			Do()
+		err := self.index(detail)
 		if err != nil {
 			// Handle error
 			fmt.Printf("failed to write stats to ElasticSearch - %s", err)
@@ -110,6 +121,75 @@ func (self *elasticStorage) AddStats(ref info.ContainerReference, stats *info.Co
 	return nil
 }
 
+func (self *elasticStorage) AddEvent(event *info.Event) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	detail := &detailSpec{
+		Timestamp:      event.Timestamp.UnixNano() / 1E3,
+		MachineName:    self.machineName,
+		ContainerName:  event.ContainerName,
+		ContainerEvent: event,
+		MachineTags:    storage.MachineTags(),
+	}
+	err := self.index(detail)
+	if err != nil {
+		return fmt.Errorf("failed to write event to ElasticSearch - %s", err)
+	}
+	return nil
+}
+
+// AddDerivedStats indexes the derived stats as a document of their own.
+func (self *elasticStorage) AddDerivedStats(ref info.ContainerReference, stats *v2.DerivedStats) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	containerName := ref.Name
+	if len(ref.Aliases) > 0 {
+		containerName = ref.Aliases[0]
+	}
+	detail := &detailSpec{
+		Timestamp:     stats.Timestamp.UnixNano() / 1E3,
+		MachineName:   self.machineName,
+		ContainerName: containerName,
+		MachineTags:   storage.MachineTags(),
+		DerivedStats:  stats,
+	}
+	err := self.index(detail)
+	if err != nil {
+		return fmt.Errorf("failed to write derived stats to ElasticSearch - %s", err)
+	}
+	return nil
+}
+
+// Indexes the document, or logs it in a dry run.
+func (self *elasticStorage) index(detail *detailSpec) error {
+	if self.dryRun != nil {
+		b, err := json.Marshal(detail)
+		if err != nil {
+			return err
+		}
+		tags := map[string]string{
+			"machine_name":   detail.MachineName,
+			"container_name": detail.ContainerName,
+		}
+		self.dryRun.Write(self.indexName+"/"+self.typeName, tags, string(b))
+		return nil
+	}
+	_, err := self.client.Index().
+		Index(self.indexName).
+		Type(self.typeName).
+		BodyJson(detail).
+		Do()
+	return err
+}
+
+// Flush is a no-op: stats are indexed right away. A dry run logs its report.
+func (self *elasticStorage) Flush() error {
+	if self.dryRun != nil {
+		self.dryRun.Flush()
+	}
+	return nil
+}
+
 func (self *elasticStorage) Close() error {
 	self.client = nil
 	return nil
@@ -125,6 +205,14 @@ func newStorage(
 	elasticHost string,
 	enableSniffer bool,
 ) (storage.StorageDriver, error) {
+	if *storage.ArgDryRun {
+		return &elasticStorage{
+			machineName: machineName,
+			indexName:   indexName,
+			typeName:    typeName,
+			dryRun:      storage.NewDryRun("elasticsearch"),
+		}, nil
+	}
 	// Obtain a client and connect to the default Elasticsearch installation
 	// on 127.0.0.1:9200. Of course you can configure your client to connect
 	// to other hosts and configure it in various other ways.
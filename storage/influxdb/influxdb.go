@@ -15,17 +15,23 @@
 package influxdb
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"os"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/logging"
 	"github.com/google/cadvisor/storage"
 	"github.com/google/cadvisor/version"
 
 	influxdb "github.com/influxdb/influxdb/client"
+	"github.com/influxdb/influxdb/models"
 )
 
 func init() {
@@ -33,7 +39,8 @@ func init() {
 }
 
 type influxdbStorage struct {
-	client          *influxdb.Client
+	// The hosts the points are mirrored to.
+	endpoints       []*endpoint
 	machineName     string
 	database        string
 	retentionPolicy string
@@ -42,6 +49,10 @@ type influxdbStorage struct {
 	points          []*influxdb.Point
 	lock            sync.Mutex
 	readyToFlush    func() bool
+	// Set with --storage_driver_dry_run.
+	dryRun *storage.DryRun
+	// Computes the rates of --storage_driver_rates.
+	rates storage.RateTracker
 }
 
 // Series names
@@ -53,6 +64,11 @@ const (
 	serCpuThrottled   string = "cpu_throttled"
 	// Smoothed average of number of runnable threads x 1000.
 	serLoadAverage string = "load_average"
+	// Instantaneous number of running, uninterruptible and IO waiting
+	// threads, for consumers applying their own smoothing.
+	serTasksRunning         string = "tasks_running"
+	serTasksUninterruptible string = "tasks_uninterruptible"
+	serTasksIoWait          string = "tasks_io_wait"
 	// Memory Usage
 	serMemoryUsage string = "memory_usage"
 	// RSS size
@@ -77,6 +93,16 @@ const (
 	serIoBytes string = "io_bytes"
 	// Serviced IO operations
 	serIoOps string = "io_ops"
+	// Container events, as annotations.
+	serEvents string = "events"
+	// Percentiles of the cpu usage over a window, in milliCpus.
+	serDerivedCpu string = "derived_cpu_usage"
+	// Percentiles of the memory working set over a window.
+	serDerivedMemory string = "derived_memory_working_set"
+	// Seconds since the previous stats, written after a gap.
+	serStatsGap string = "stats_gap_seconds"
+	// Reset of the cumulative counter of the counter tag.
+	serCounterReset string = "counter_reset"
 )
 
 func new() (storage.StorageDriver, error) {
@@ -84,7 +110,7 @@ func new() (storage.StorageDriver, error) {
 	if err != nil {
 		return nil, err
 	}
-	return newStorage(
+	driver, err := newStorage(
 		hostname,
 		*storage.ArgDbTable,
 		*storage.ArgDbName,
@@ -94,6 +120,13 @@ func new() (storage.StorageDriver, error) {
 		*storage.ArgDbIsSecure,
 		*storage.ArgDbBufferDuration,
 	)
+	if err != nil {
+		return nil, err
+	}
+	if *storage.ArgDryRun {
+		driver.dryRun = storage.NewDryRun("influxdb")
+	}
+	return driver, nil
 }
 
 // Field names
@@ -101,10 +134,33 @@ const (
 	fieldValue string = "value"
 )
 
+// Timeout of the requests to a host, and of the wait for the mirrored writes
+// on Flush.
+const requestTimeout = 30 * time.Second
+
 // Tag names
 const (
 	tagContainerId string = "container_id"
+	tagCounter     string = "counter"
 	tagDevice      string = "device"
+	tagEventType   string = "event_type"
+	tagWindow      string = "window"
+)
+
+// Field names of derived stats
+const (
+	fieldMean            string = "mean"
+	fieldMax             string = "max"
+	fieldP50             string = "p50"
+	fieldP90             string = "p90"
+	fieldP95             string = "p95"
+	fieldPercentComplete string = "percent_complete"
+)
+
+// Field names of event annotations
+const (
+	fieldTitle string = "title"
+	fieldText  string = "text"
 )
 
 func (self *influxdbStorage) containerFilesystemStatsToPoints(
@@ -113,50 +169,43 @@ func (self *influxdbStorage) containerFilesystemStatsToPoints(
 	if len(stats.Filesystem) == 0 {
 		return points
 	}
+	points = make([]*influxdb.Point, 0, 2*len(stats.Filesystem))
 	for _, fsStat := range stats.Filesystem {
-		tagsFsUsage := map[string]string{
+		pointFsUsage := makePoint(serFsUsage, int64(fsStat.Usage))
+		pointFsUsage.Tags = map[string]string{
 			tagDevice: fsStat.Device,
 		}
-		fieldsFsUsage := map[string]interface{}{
-			fieldValue: int64(fsStat.Usage),
-		}
-		pointFsUsage := &influxdb.Point{
-			Measurement: serFsUsage,
-			Tags:        tagsFsUsage,
-			Fields:      fieldsFsUsage,
-		}
 
-		tagsFsLimit := map[string]string{
+		pointFsLimit := makePoint(serFsLimit, int64(fsStat.Limit))
+		pointFsLimit.Tags = map[string]string{
 			tagDevice: fsStat.Device,
 		}
-		fieldsFsLimit := map[string]interface{}{
-			fieldValue: int64(fsStat.Limit),
-		}
-		pointFsLimit := &influxdb.Point{
-			Measurement: serFsLimit,
-			Tags:        tagsFsLimit,
-			Fields:      fieldsFsLimit,
-		}
 
 		points = append(points, pointFsUsage, pointFsLimit)
 	}
 
-	self.tagPoints(ref, stats, points)
+	self.tagPoints(ref, stats.Timestamp, points)
 
 	return points
 }
 
 // Set tags and timestamp for all points of the batch.
 // Points should inherit the tags that are set for BatchPoints, but that does not seem to work.
-func (self *influxdbStorage) tagPoints(ref info.ContainerReference, stats *info.ContainerStats, points []*influxdb.Point) {
-	commonTags := map[string]string{
-		tagContainerId: ref.Name,
+func (self *influxdbStorage) tagPoints(ref info.ContainerReference, timestamp time.Time, points []*influxdb.Point) {
+	commonTags := map[string]string{}
+	for k, v := range storage.MachineTags() {
+		commonTags[k] = v
+	}
+	// Whitelisted environment variables are tagged like in Prometheus.
+	for k, v := range ref.Envs {
+		commonTags[k] = v
 	}
+	commonTags[tagContainerId] = ref.Name
 
 	for i := 0; i < len(points); i++ {
 		// merge with existing tags if any
 		addTagsToPoint(points[i], commonTags)
-		points[i].Time = stats.Timestamp
+		points[i].Time = timestamp
 	}
 }
 
@@ -164,8 +213,12 @@ func (self *influxdbStorage) containerStatsToPoints(
 	ref info.ContainerReference,
 	stats *info.ContainerStats,
 ) (points []*influxdb.Point) {
+	writeCounters := storage.WriteCounters()
+
 	// CPU usage: Total usage in nanoseconds
-	points = append(points, makePoint(serCpuUsageTotal, stats.Cpu.Usage.Total))
+	if writeCounters {
+		points = append(points, makePoint(serCpuUsageTotal, stats.Cpu.Usage.Total))
+	}
 
 	// CPU usage: Time spend in system space (in nanoseconds)
 	points = append(points, makePoint(serCpuUsageSystem, stats.Cpu.Usage.System))
@@ -179,12 +232,22 @@ func (self *influxdbStorage) containerStatsToPoints(
 	// Load Average
 	points = append(points, makePoint(serLoadAverage, stats.Cpu.LoadAverage))
 
+	// Raw task counts, only probed with the load reader
+	if stats.TaskStats != (info.LoadStats{}) {
+		points = append(points, makePoint(serTasksRunning, stats.TaskStats.NrRunning))
+		points = append(points, makePoint(serTasksUninterruptible, stats.TaskStats.NrUninterruptible))
+		points = append(points, makePoint(serTasksIoWait, stats.TaskStats.NrIoWait))
+	}
+
 	// Memory Usage
 	points = append(points, makePoint(serMemoryUsage, stats.Memory.Usage))
 
 	// RSS
 	points = append(points, makePoint(serMemoryRSS, stats.Memory.RSS))
 
+	// Working set size
+	points = append(points, makePoint(serMemoryWorkingSet, stats.Memory.WorkingSet))
+
 	// IO stats
 	var readBytes, writeBytes, readOps, writeOps uint64 = 0, 0, 0, 0
 
@@ -198,16 +261,39 @@ func (self *influxdbStorage) containerStatsToPoints(
 		writeOps += diskStats.Stats["Write"]
 	}
 
-	points = append(points, makePoint(serIoBytes, readBytes+writeBytes))
-	points = append(points, makePoint(serIoOps, readOps+writeOps))
+	if writeCounters {
+		points = append(points, makePoint(serIoBytes, readBytes+writeBytes))
+		points = append(points, makePoint(serIoOps, readOps+writeOps))
+	}
+
+	// Network Stats, unless they could not be collected
+	if stats.Valid(info.NetworkStatsSubsystem) {
+		if writeCounters {
+			points = append(points, makePoint(serRxBytes, stats.Network.RxBytes))
+			points = append(points, makePoint(serTxBytes, stats.Network.TxBytes))
+		}
+		points = append(points, makePoint(serRxErrors, stats.Network.RxErrors))
+		points = append(points, makePoint(serTxErrors, stats.Network.TxErrors))
+	}
+
+	// Discontinuities since the previous stats, with --mark_stats_gaps
+	if stats.GapStart != nil {
+		points = append(points, makePoint(serStatsGap, stats.Timestamp.Sub(*stats.GapStart).Seconds()))
+	}
+	for _, counter := range stats.CounterResets {
+		point := makePoint(serCounterReset, 1)
+		point.Tags = map[string]string{tagCounter: counter}
+		points = append(points, point)
+	}
 
-	// Network Stats
-	points = append(points, makePoint(serRxBytes, stats.Network.RxBytes))
-	points = append(points, makePoint(serRxErrors, stats.Network.RxErrors))
-	points = append(points, makePoint(serTxBytes, stats.Network.TxBytes))
-	points = append(points, makePoint(serTxErrors, stats.Network.TxErrors))
+	// Rates over the interval since the previous stats
+	if storage.WriteRates() {
+		for name, value := range self.rates.Rates(ref.Name, stats) {
+			points = append(points, makePoint(name, value))
+		}
+	}
 
-	self.tagPoints(ref, stats, points)
+	self.tagPoints(ref, stats.Timestamp, points)
 
 	return points
 }
@@ -224,47 +310,337 @@ func (self *influxdbStorage) AddStats(ref info.ContainerReference, stats *info.C
 	if stats == nil {
 		return nil
 	}
+	points := self.containerStatsToPoints(ref, stats)
+	points = append(points, self.containerFilesystemStatsToPoints(ref, stats)...)
+	return self.addPoints(points, stats.Timestamp)
+}
+
+// AddDerivedStats buffers a point per window and resource, whose fields are
+// the percentiles of the usage over the window.
+func (self *influxdbStorage) AddDerivedStats(ref info.ContainerReference, stats *v2.DerivedStats) error {
+	var points []*influxdb.Point
+	for _, window := range []struct {
+		name  string
+		usage v2.Usage
+	}{
+		{"minute", stats.MinuteUsage},
+		{"hour", stats.HourUsage},
+		{"day", stats.DayUsage},
+	} {
+		for _, resource := range []struct {
+			measurement string
+			percentiles v2.Percentiles
+		}{
+			{serDerivedCpu, window.usage.Cpu},
+			{serDerivedMemory, window.usage.Memory},
+		} {
+			percentiles := resource.percentiles
+			if !percentiles.Present {
+				continue
+			}
+			points = append(points, &influxdb.Point{
+				Measurement: storage.SeriesName(resource.measurement),
+				Tags: map[string]string{
+					tagWindow: window.name,
+				},
+				Fields: map[string]interface{}{
+					fieldMean:            int64(percentiles.Mean),
+					fieldMax:             int64(percentiles.Max),
+					fieldP50:             int64(percentiles.Fifty),
+					fieldP90:             int64(percentiles.Ninety),
+					fieldP95:             int64(percentiles.NinetyFive),
+					fieldPercentComplete: int64(window.usage.PercentComplete),
+				},
+			})
+		}
+	}
+	self.tagPoints(ref, stats.Timestamp, points)
+	return self.addPoints(points, stats.Timestamp)
+}
+
+// Buffers the points, and writes the buffered points once they have been
+// buffered for the buffer duration.
+func (self *influxdbStorage) addPoints(points []*influxdb.Point, timestamp time.Time) error {
 	var pointsToFlush []*influxdb.Point
 	func() {
 		// AddStats will be invoked simultaneously from multiple threads and only one of them will perform a write.
 		self.lock.Lock()
 		defer self.lock.Unlock()
 
-		self.points = append(self.points, self.containerStatsToPoints(ref, stats)...)
-		self.points = append(self.points, self.containerFilesystemStatsToPoints(ref, stats)...)
+		self.points = append(self.points, points...)
 		if self.readyToFlush() {
 			pointsToFlush = self.points
-			self.points = make([]*influxdb.Point, 0)
+			// The next batch is likely the same size.
+			self.points = make([]*influxdb.Point, 0, len(pointsToFlush))
 			self.lastWrite = time.Now()
 		}
 	}()
-	if len(pointsToFlush) > 0 {
-		points := make([]influxdb.Point, len(pointsToFlush))
-		for i, p := range pointsToFlush {
-			points[i] = *p
+	return self.writePoints(pointsToFlush, timestamp)
+}
+
+func (self *influxdbStorage) writePoints(pointsToFlush []*influxdb.Point, timestamp time.Time) error {
+	if len(pointsToFlush) == 0 {
+		return nil
+	}
+	points := getBatch(len(pointsToFlush))
+	for i, p := range pointsToFlush {
+		points[i] = *p
+	}
+	// The client serializes the batch before returning.
+	defer func() {
+		putBatch(points)
+		putPoints(pointsToFlush)
+	}()
+
+	bp := influxdb.BatchPoints{
+		Points:   points,
+		Database: self.database,
+		Time:     timestamp,
+	}
+	if self.dryRun != nil {
+		self.logPoints(bp)
+		return nil
+	}
+	if err := self.write(bp); err != nil {
+		return fmt.Errorf("failed to write stats to influxDb - %s", err)
+	}
+	return nil
+}
+
+// Logs the points of the batch in the line protocol instead of writing them.
+func (self *influxdbStorage) logPoints(bp influxdb.BatchPoints) {
+	for _, point := range bp.Points {
+		if point.Time.IsZero() {
+			point.Time = bp.Time
+		}
+		self.dryRun.Write(point.Measurement, point.Tags, point.MarshalString())
+	}
+}
+
+// Flush writes the points buffered for the buffer duration.
+func (self *influxdbStorage) Flush() error {
+	self.lock.Lock()
+	pointsToFlush := self.points
+	self.points = make([]*influxdb.Point, 0, len(pointsToFlush))
+	self.lastWrite = time.Now()
+	self.lock.Unlock()
+	err := self.writePoints(pointsToFlush, time.Now())
+	if self.dryRun != nil {
+		self.dryRun.Flush()
+	}
+	// Wait for the mirrored writes.
+	for _, e := range self.endpoints {
+		if e.queue == nil {
+			continue
+		}
+		if drainErr := e.drain(requestTimeout); drainErr != nil && err == nil {
+			err = drainErr
+		}
+	}
+	return err
+}
+
+// AddEvent writes the event right away as an annotation point: events are
+// rare and dashboards overlay them on the stats.
+func (self *influxdbStorage) AddEvent(event *info.Event) error {
+	text := ""
+	if oom := event.EventData.OomKill; oom != nil && oom.Pid != 0 {
+		text = fmt.Sprintf("killed process %d (%s)", oom.Pid, oom.ProcessName)
+	}
+	point := influxdb.Point{
+		Measurement: storage.SeriesName(serEvents),
+		Tags: map[string]string{
+			tagContainerId: event.ContainerName,
+			tagEventType:   string(event.EventType),
+		},
+		Fields: map[string]interface{}{
+			fieldValue: int64(1),
+			fieldTitle: fmt.Sprintf("%s %s", event.EventType, event.ContainerName),
+			fieldText:  text,
+		},
+		Time: event.Timestamp,
+	}
+	bp := influxdb.BatchPoints{
+		Points:   []influxdb.Point{point},
+		Database: self.database,
+		Time:     event.Timestamp,
+	}
+	if self.dryRun != nil {
+		self.logPoints(bp)
+		return nil
+	}
+	if err := self.write(bp); err != nil {
+		return fmt.Errorf("failed to write event to influxDb - %s", err)
+	}
+	return nil
+}
+
+// An InfluxDB host the points are written to.
+type endpoint struct {
+	host   string
+	client *influxdb.Client
+	lock   sync.Mutex
+	// Whether the last write failed, to log only the failures and
+	// recoveries of the host.
+	failing bool
+	// Batches waiting to be written to the host, when mirroring.
+	queue chan influxdb.BatchPoints
+	// Counts the batches queued and not written or dropped yet.
+	pending sync.WaitGroup
+	stop    chan struct{}
+}
+
+// Number of batches queued per host when mirroring. Above it, the oldest
+// batch of the host is dropped.
+const mirrorQueueSize = 64
+
+// Number of times a mirrored batch is written to a failing host before it is
+// dropped, and the delay before the first retry, doubled for every retry.
+const mirrorRetries = 3
+
+var mirrorRetryDelay = time.Second
+
+// Writes the batch to the host, and logs when the host starts or stops
+// failing if it isn't the only host.
+func (self *endpoint) write(bp influxdb.BatchPoints, mirrored bool) error {
+	response, err := self.client.Write(bp)
+	if err == nil {
+		err = checkResponseForErrors(response)
+	}
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if mirrored && err != nil && !self.failing {
+		logging.Warningf("Failed to write to InfluxDB at %s, still writing to the other hosts: %v", self.host, err)
+	} else if mirrored && err == nil && self.failing {
+		logging.Infof("Writing to InfluxDB at %s again", self.host)
+	}
+	self.failing = err != nil
+	return err
+}
+
+func (self *endpoint) isFailing() bool {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	return self.failing
+}
+
+// Queues the batch for the host. Returns false if the queue was full and its
+// oldest batch was dropped to make room.
+func (self *endpoint) enqueue(bp influxdb.BatchPoints) bool {
+	self.pending.Add(1)
+	for dropped := false; ; dropped = true {
+		select {
+		case self.queue <- bp:
+			return !dropped
+		default:
+		}
+		select {
+		case old := <-self.queue:
+			logging.Warningf("Dropping %d points for InfluxDB at %s, which is too slow", len(old.Points), self.host)
+			self.pending.Done()
+		default:
 		}
+	}
+}
 
-		bp := influxdb.BatchPoints{
-			Points:   points,
-			Database: self.database,
-			Time:     stats.Timestamp,
+// Writes the queued batches to the host in order, retrying the failed ones,
+// so that a slow or failing host doesn't hold back the others.
+func (self *endpoint) writeLoop() {
+	for {
+		select {
+		case bp := <-self.queue:
+			self.writeWithRetries(bp)
+			self.pending.Done()
+		case <-self.stop:
+			return
+		}
+	}
+}
+
+func (self *endpoint) writeWithRetries(bp influxdb.BatchPoints) {
+	delay := mirrorRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := self.write(bp, true)
+		if err == nil {
+			return
+		}
+		if attempt > mirrorRetries {
+			logging.Warningf("Dropping %d points for InfluxDB at %s after %d attempts: %v", len(bp.Points), self.host, attempt, err)
+			return
+		}
+		select {
+		case <-time.After(delay):
+		case <-self.stop:
+			return
+		}
+		delay *= 2
+	}
+}
+
+// Waits until the batches queued for the host are written or dropped, up to
+// the timeout.
+func (self *endpoint) drain(timeout time.Duration) error {
+	done := make(chan struct{})
+	go func() {
+		self.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("timed out writing the queued points to %s", self.host)
+	}
+}
+
+// Writes the batch to the only host, or queues it for every host when
+// mirroring. A slow or failing host then doesn't hold back the writes to the
+// others, and its failed batches are retried. A mirrored write fails when
+// batches were dropped for being queued too long, or when every host is
+// failing.
+func (self *influxdbStorage) write(bp influxdb.BatchPoints) error {
+	if len(self.endpoints) == 1 {
+		return self.endpoints[0].write(bp, false)
+	}
+	// The points are recycled once written, so the queued batches hold their
+	// line protocol instead.
+	queued := bp
+	queued.Points = make([]influxdb.Point, len(bp.Points))
+	for i := range bp.Points {
+		queued.Points[i] = influxdb.Point{Raw: bp.Points[i].MarshalString()}
+	}
+	var overflowing, failing []string
+	for _, e := range self.endpoints {
+		if !e.enqueue(queued) {
+			overflowing = append(overflowing, e.host)
 		}
-		response, err := self.client.Write(bp)
-		if err != nil || checkResponseForErrors(response) != nil {
-			return fmt.Errorf("failed to write stats to influxDb - %s", err)
+		if e.isFailing() {
+			failing = append(failing, e.host)
 		}
 	}
+	if len(overflowing) > 0 {
+		return fmt.Errorf("dropped the oldest points queued for %s", strings.Join(overflowing, ", "))
+	}
+	if len(failing) == len(self.endpoints) {
+		return fmt.Errorf("all hosts failed: %s", strings.Join(failing, ", "))
+	}
 	return nil
 }
 
 func (self *influxdbStorage) Close() error {
-	self.client = nil
+	for _, e := range self.endpoints {
+		if e.stop != nil {
+			close(e.stop)
+		}
+	}
+	self.endpoints = nil
 	return nil
 }
 
 // machineName: A unique identifier to identify the host that current cAdvisor
 // instance is running on.
-// influxdbHost: The host which runs influxdb (host:port)
+// influxdbHost: The comma-separated hosts which run influxdb (host:port), to
+// which the points are mirrored.
 func newStorage(
 	machineName,
 	tablename,
@@ -275,27 +651,46 @@ func newStorage(
 	isSecure bool,
 	bufferDuration time.Duration,
 ) (*influxdbStorage, error) {
-	url := &url.URL{
-		Scheme: "http",
-		Host:   influxdbHost,
-	}
-	if isSecure {
-		url.Scheme = "https"
-	}
+	var endpoints []*endpoint
+	for _, host := range strings.Split(influxdbHost, ",") {
+		host = strings.TrimSpace(host)
+		if host == "" {
+			continue
+		}
+		url := &url.URL{
+			Scheme: "http",
+			Host:   host,
+		}
+		if isSecure {
+			url.Scheme = "https"
+		}
 
-	config := &influxdb.Config{
-		URL:       *url,
-		Username:  username,
-		Password:  password,
-		UserAgent: fmt.Sprintf("%v/%v", "cAdvisor", version.Info["version"]),
+		config := &influxdb.Config{
+			URL:       *url,
+			Username:  username,
+			Password:  password,
+			UserAgent: fmt.Sprintf("%v/%v", "cAdvisor", version.Info["version"]),
+			Timeout:   requestTimeout,
+		}
+		client, err := influxdb.NewClient(*config)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, &endpoint{host: host, client: client})
 	}
-	client, err := influxdb.NewClient(*config)
-	if err != nil {
-		return nil, err
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("no influxdb host in %q", influxdbHost)
+	}
+	if len(endpoints) > 1 {
+		for _, e := range endpoints {
+			e.queue = make(chan influxdb.BatchPoints, mirrorQueueSize)
+			e.stop = make(chan struct{})
+			go e.writeLoop()
+		}
 	}
 
 	ret := &influxdbStorage{
-		client:         client,
+		endpoints:      endpoints,
 		machineName:    machineName,
 		database:       database,
 		bufferDuration: bufferDuration,
@@ -306,16 +701,13 @@ func newStorage(
 	return ret, nil
 }
 
-// Creates a measurement point with a single value field
+// Creates a measurement point with a single value field, named per the
+// series naming of the storage drivers.
 func makePoint(name string, value interface{}) *influxdb.Point {
-	fields := map[string]interface{}{
-		fieldValue: toSignedIfUnsigned(value),
-	}
-
-	return &influxdb.Point{
-		Measurement: name,
-		Fields:      fields,
-	}
+	point := getPoint()
+	point.Measurement = storage.SeriesName(name)
+	point.Fields[fieldValue] = toSignedIfUnsigned(value)
+	return point
 }
 
 // Adds additional tags to the existing tags of a point
@@ -331,7 +723,7 @@ func addTagsToPoint(point *influxdb.Point, tags map[string]string) {
 
 // Checks response for possible errors
 func checkResponseForErrors(response *influxdb.Response) error {
-	const msg = "failed to write stats to influxDb - %s"
+	const msg = "influxDb returned an error - %s"
 
 	if response != nil && response.Err != nil {
 		return fmt.Errorf(msg, response.Err)
@@ -369,3 +761,173 @@ func toSignedIfUnsigned(value interface{}) interface{} {
 	}
 	return value
 }
+
+// Measurements read back by ReadStats.
+var readMeasurements = []string{
+	serCpuUsageTotal,
+	serCpuUsageSystem,
+	serCpuUsageUser,
+	serMemoryUsage,
+	serMemoryRSS,
+	serMemoryWorkingSet,
+	serRxBytes,
+	serRxErrors,
+	serTxBytes,
+	serTxErrors,
+}
+
+// Name given to the default interface, whose network stats are the only ones
+// stored.
+const defaultInterfaceName = "default"
+
+// Runs the query on the first host that answers, in the order of the hosts.
+func (self *influxdbStorage) query(q influxdb.Query) (*influxdb.Response, error) {
+	var errs []string
+	for _, e := range self.endpoints {
+		response, err := e.client.Query(q)
+		if err == nil {
+			return response, nil
+		}
+		errs = append(errs, fmt.Sprintf("%s: %v", e.host, err))
+	}
+	return nil, fmt.Errorf("%s", strings.Join(errs, "; "))
+}
+
+// ReadStats reads back the last stats of each step between start and end,
+// with an InfluxQL query that InfluxDB 2 also serves on its 1.x compatible
+// query API.
+func (self *influxdbStorage) ReadStats(containerName string, start, end time.Time, step time.Duration) ([]*info.ContainerStats, error) {
+	if step < time.Second {
+		step = time.Second
+	}
+	measurements := make([]string, 0, len(readMeasurements))
+	for _, m := range readMeasurements {
+		measurements = append(measurements, quoteIdentifier(storage.SeriesName(m)))
+	}
+	conditions := []string{fmt.Sprintf("%s = %s", quoteIdentifier(tagContainerId), quoteString(containerName))}
+	machineTags := storage.MachineTags()
+	tagNames := make([]string, 0, len(machineTags))
+	for k := range machineTags {
+		tagNames = append(tagNames, k)
+	}
+	sort.Strings(tagNames)
+	for _, k := range tagNames {
+		conditions = append(conditions, fmt.Sprintf("%s = %s", quoteIdentifier(k), quoteString(machineTags[k])))
+	}
+	command := fmt.Sprintf("SELECT last(%s) FROM %s WHERE %s AND time >= %d AND time <= %d GROUP BY time(%ds) fill(none)",
+		quoteIdentifier(fieldValue), strings.Join(measurements, ","), strings.Join(conditions, " AND "),
+		start.UnixNano(), end.UnixNano(), int64(step/time.Second))
+
+	response, err := self.query(influxdb.Query{Command: command, Database: self.database})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stats from influxDb - %s", err)
+	}
+	if response.Error() != nil {
+		return nil, fmt.Errorf("failed to read stats from influxDb - %s", response.Error())
+	}
+	var rows []models.Row
+	for _, result := range response.Results {
+		rows = append(rows, result.Series...)
+	}
+	return statsFromRows(rows)
+}
+
+// Merges the rows of the measurements, each a time and a value, into stats
+// ordered by time.
+func statsFromRows(rows []models.Row) ([]*info.ContainerStats, error) {
+	// Default names of the measurements read back.
+	defaultNames := make(map[string]string, len(readMeasurements))
+	for _, m := range readMeasurements {
+		defaultNames[storage.SeriesName(m)] = m
+	}
+	byTime := make(map[time.Time]*info.ContainerStats)
+	for _, row := range rows {
+		for _, values := range row.Values {
+			if len(values) != 2 || values[1] == nil {
+				continue
+			}
+			timestamp, ok := values[0].(string)
+			if !ok {
+				return nil, fmt.Errorf("unexpected time %v in %q", values[0], row.Name)
+			}
+			t, err := time.Parse(time.RFC3339Nano, timestamp)
+			if err != nil {
+				return nil, err
+			}
+			value, err := parseUint(values[1])
+			if err != nil {
+				return nil, fmt.Errorf("unexpected value %v in %q: %v", values[1], row.Name, err)
+			}
+			stats, ok := byTime[t]
+			if !ok {
+				stats = &info.ContainerStats{Timestamp: t}
+				stats.Network.Name = defaultInterfaceName
+				byTime[t] = stats
+			}
+			switch defaultNames[row.Name] {
+			case serCpuUsageTotal:
+				stats.Cpu.Usage.Total = value
+			case serCpuUsageSystem:
+				stats.Cpu.Usage.System = value
+			case serCpuUsageUser:
+				stats.Cpu.Usage.User = value
+			case serMemoryUsage:
+				stats.Memory.Usage = value
+			case serMemoryRSS:
+				stats.Memory.RSS = value
+			case serMemoryWorkingSet:
+				stats.Memory.WorkingSet = value
+			case serRxBytes:
+				stats.Network.RxBytes = value
+			case serRxErrors:
+				stats.Network.RxErrors = value
+			case serTxBytes:
+				stats.Network.TxBytes = value
+			case serTxErrors:
+				stats.Network.TxErrors = value
+			}
+		}
+	}
+	result := make([]*info.ContainerStats, 0, len(byTime))
+	for _, stats := range byTime {
+		stats.Network.Interfaces = []info.InterfaceStats{stats.Network.InterfaceStats}
+		result = append(result, stats)
+	}
+	sort.Sort(statsByTimestamp(result))
+	return result, nil
+}
+
+type statsByTimestamp []*info.ContainerStats
+
+func (s statsByTimestamp) Len() int           { return len(s) }
+func (s statsByTimestamp) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s statsByTimestamp) Less(i, j int) bool { return s[i].Timestamp.Before(s[j].Timestamp) }
+
+// Parses a value of the query response, decoded as a json.Number.
+func parseUint(value interface{}) (uint64, error) {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			if n < 0 {
+				return 0, nil
+			}
+			return uint64(n), nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return 0, err
+		}
+		return uint64(f), nil
+	case float64:
+		return uint64(v), nil
+	}
+	return 0, fmt.Errorf("not a number")
+}
+
+func quoteIdentifier(s string) string {
+	return `"` + strings.Replace(strings.Replace(s, `\`, `\\`, -1), `"`, `\"`, -1) + `"`
+}
+
+func quoteString(s string) string {
+	return `'` + strings.Replace(strings.Replace(s, `\`, `\\`, -1), `'`, `\'`, -1) + `'`
+}
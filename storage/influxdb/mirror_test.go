@@ -0,0 +1,181 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package influxdb
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	info "github.com/google/cadvisor/info/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMirroredWrites(t *testing.T) {
+	defer func(delay time.Duration) { mirrorRetryDelay = delay }(mirrorRetryDelay)
+	mirrorRetryDelay = time.Millisecond
+
+	var lock sync.Mutex
+	var written []string
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		lock.Lock()
+		written = append(written, string(body))
+		lock.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer healthy.Close()
+	failures := 0
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		failures++
+		lock.Unlock()
+		http.Error(w, "cluster unavailable", http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	driver, err := newStorage("machineA", "", "cadvisor", "root", "root", host(failing)+", "+host(healthy), false, 0)
+	require.NoError(t, err)
+	defer driver.Close()
+	require.Equal(t, 2, len(driver.endpoints))
+	ref := info.ContainerReference{Name: "/docker/abc", Aliases: []string{"web"}}
+	stats := &info.ContainerStats{Timestamp: time.Now()}
+	assert.NoError(t, driver.AddStats(ref, stats))
+	require.NoError(t, driver.Flush())
+	lock.Lock()
+	assert.Len(t, written, 1)
+	assert.Contains(t, written[0], "cpu_usage_total,container_id=/docker/abc")
+	// The failed writes are retried.
+	assert.Equal(t, 1+mirrorRetries, failures)
+	lock.Unlock()
+	assert.True(t, driver.endpoints[0].isFailing())
+	assert.False(t, driver.endpoints[1].isFailing())
+
+	driver, err = newStorage("machineA", "", "cadvisor", "root", "root", host(failing)+","+host(failing), false, 0)
+	require.NoError(t, err)
+	defer driver.Close()
+	require.NoError(t, driver.AddStats(ref, stats))
+	require.NoError(t, driver.Flush())
+	err = driver.AddStats(ref, stats)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "all hosts failed")
+
+	_, err = newStorage("machineA", "", "cadvisor", "root", "root", " , ", false, 0)
+	assert.Error(t, err)
+}
+
+func host(server *httptest.Server) string {
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+func TestMirroredWritesRetry(t *testing.T) {
+	defer func(delay time.Duration) { mirrorRetryDelay = delay }(mirrorRetryDelay)
+	mirrorRetryDelay = time.Millisecond
+
+	var lock sync.Mutex
+	attempts := 0
+	var written []string
+	recovering := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		lock.Lock()
+		defer lock.Unlock()
+		attempts++
+		if attempts == 1 {
+			http.Error(w, "cluster unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		written = append(written, string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer recovering.Close()
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer healthy.Close()
+
+	driver, err := newStorage("machineA", "", "cadvisor", "root", "root", host(recovering)+","+host(healthy), false, 0)
+	require.NoError(t, err)
+	defer driver.Close()
+	ref := info.ContainerReference{Name: "/docker/abc"}
+	require.NoError(t, driver.AddStats(ref, &info.ContainerStats{Timestamp: time.Now()}))
+	require.NoError(t, driver.Flush())
+
+	// The host that failed once still gets the points.
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, 2, attempts)
+	require.Equal(t, 1, len(written))
+	assert.Contains(t, written[0], "cpu_usage_total,container_id=/docker/abc")
+}
+
+func TestMirroredWritesSlowHost(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer slow.Close()
+	// Unblock the slow host before closing it.
+	defer close(release)
+	received := make(chan string, 10)
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		received <- string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer healthy.Close()
+
+	driver, err := newStorage("machineA", "", "cadvisor", "root", "root", host(slow)+","+host(healthy), false, 0)
+	require.NoError(t, err)
+	defer driver.Close()
+	ref := info.ContainerReference{Name: "/docker/abc"}
+	for i := 0; i < 3; i++ {
+		require.NoError(t, driver.AddStats(ref, &info.ContainerStats{Timestamp: time.Now()}))
+	}
+	// The healthy host gets every batch while the slow host hangs.
+	for i := 0; i < 3; i++ {
+		select {
+		case body := <-received:
+			assert.Contains(t, body, "cpu_usage_total,container_id=/docker/abc")
+		case <-time.After(10 * time.Second):
+			t.Fatalf("the healthy host got %d batches", i)
+		}
+	}
+}
+
+func TestAddEventError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "database not found: cadvisor", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	driver, err := newStorage("machineA", "", "cadvisor", "root", "root", strings.TrimPrefix(server.URL, "http://"), false, 0)
+	require.NoError(t, err)
+	err = driver.AddEvent(&info.Event{
+		ContainerName: "/docker/abc",
+		Timestamp:     time.Now(),
+		EventType:     info.EventOom,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to write event to influxDb")
+	assert.Contains(t, err.Error(), "database not found: cadvisor")
+	assert.NotContains(t, err.Error(), "stats")
+}
@@ -0,0 +1,71 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package influxdb
+
+import (
+	"sync"
+
+	influxdb "github.com/influxdb/influxdb/client"
+)
+
+// The points of the stats are recycled once written: a host with a thousand
+// containers otherwise allocates tens of thousands of points and field maps
+// per second.
+var pointPool = sync.Pool{
+	New: func() interface{} {
+		return &influxdb.Point{Fields: make(map[string]interface{}, 1)}
+	},
+}
+
+// Batches of points, as written by the client.
+var batchPool sync.Pool
+
+// Returns a point without measurement, tags or fields.
+func getPoint() *influxdb.Point {
+	return pointPool.Get().(*influxdb.Point)
+}
+
+// Recycles the points, which must not be referenced anymore. Their tags may
+// be shared between points and are not reused.
+func putPoints(points []*influxdb.Point) {
+	for _, point := range points {
+		fields := point.Fields
+		if fields == nil {
+			fields = make(map[string]interface{}, 1)
+		}
+		for k := range fields {
+			delete(fields, k)
+		}
+		*point = influxdb.Point{Fields: fields}
+		pointPool.Put(point)
+	}
+}
+
+// Returns a batch of n points.
+func getBatch(n int) []influxdb.Point {
+	if batch, ok := batchPool.Get().(*[]influxdb.Point); ok && cap(*batch) >= n {
+		return (*batch)[:n]
+	}
+	return make([]influxdb.Point, n)
+}
+
+// Recycles the batch once written.
+func putBatch(batch []influxdb.Point) {
+	for i := range batch {
+		batch[i] = influxdb.Point{}
+	}
+	batch = batch[:0]
+	batchPool.Put(&batch)
+}
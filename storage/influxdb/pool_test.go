@@ -0,0 +1,91 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package influxdb
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	info "github.com/google/cadvisor/info/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecycledPoints(t *testing.T) {
+	var written []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		written = append(written, string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	driver, err := newStorage("machineA", "", "cadvisor", "root", "root", strings.TrimPrefix(server.URL, "http://"), false, 0)
+	require.NoError(t, err)
+	stats := &info.ContainerStats{Timestamp: time.Now()}
+	stats.Cpu.Usage.Total = 42
+	require.NoError(t, driver.AddStats(info.ContainerReference{Name: "/a"}, stats))
+	stats.Cpu.Usage.Total = 43
+	require.NoError(t, driver.AddStats(info.ContainerReference{Name: "/b"}, stats))
+
+	require.Equal(t, 2, len(written))
+	assert.Contains(t, written[0], "cpu_usage_total,container_id=/a")
+	assert.Contains(t, written[0], "value=42")
+	assert.Contains(t, written[1], "cpu_usage_total,container_id=/b")
+	assert.Contains(t, written[1], "value=43")
+	assert.NotContains(t, written[1], "container_id=/a")
+	assert.NotContains(t, written[1], "value=42")
+}
+
+func BenchmarkAddStats(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(ioutil.Discard, r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	driver, err := newStorage("machineA", "", "cadvisor", "root", "root", strings.TrimPrefix(server.URL, "http://"), false, time.Hour)
+	require.NoError(b, err)
+	refs := make([]info.ContainerReference, 1000)
+	for i := range refs {
+		refs[i] = info.ContainerReference{Name: fmt.Sprintf("/docker/%d", i)}
+	}
+	stats := &info.ContainerStats{
+		Timestamp: time.Now(),
+		Filesystem: []info.FsStats{
+			{Device: "/dev/sda1", Usage: 1 << 30, Limit: 1 << 40},
+		},
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, ref := range refs {
+			if err := driver.AddStats(ref, stats); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if err := driver.Flush(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
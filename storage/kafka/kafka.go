@@ -22,11 +22,12 @@ import (
 	"time"
 
 	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/info/v2"
+	"github.com/google/cadvisor/logging"
 	"github.com/google/cadvisor/storage"
 	"github.com/google/cadvisor/utils/container"
 
 	kafka "github.com/Shopify/sarama"
-	"github.com/golang/glog"
 )
 
 func init() {
@@ -42,6 +43,10 @@ type kafkaStorage struct {
 	producer    kafka.AsyncProducer
 	topic       string
 	machineName string
+	// Set with --storage_driver_dry_run, instead of the producer.
+	dryRun *storage.DryRun
+	// Computes the rates of --storage_driver_rates.
+	rates storage.RateTracker
 }
 
 type detailSpec struct {
@@ -50,7 +55,13 @@ type detailSpec struct {
 	ContainerName   string               `json:"container_Name,omitempty"`
 	ContainerID     string               `json:"container_Id,omitempty"`
 	ContainerLabels map[string]string    `json:"container_labels,omitempty"`
+	ContainerEnvs   map[string]string    `json:"container_envs,omitempty"`
 	ContainerStats  *info.ContainerStats `json:"container_stats,omitempty"`
+	ContainerEvent  *info.Event          `json:"container_event,omitempty"`
+	MachineTags     map[string]string    `json:"machine_tags,omitempty"`
+	DerivedStats    *v2.DerivedStats     `json:"derived_stats,omitempty"`
+	// Rates of --storage_driver_rates.
+	Rates map[string]float64 `json:"rates,omitempty"`
 }
 
 func (driver *kafkaStorage) infoToDetailSpec(ref info.ContainerReference, stats *info.ContainerStats) *detailSpec {
@@ -65,7 +76,12 @@ func (driver *kafkaStorage) infoToDetailSpec(ref info.ContainerReference, stats
 		ContainerName:   containerName,
 		ContainerID:     containerID,
 		ContainerLabels: containerLabels,
+		ContainerEnvs:   ref.Envs,
 		ContainerStats:  stats,
+		MachineTags:     storage.MachineTags(),
+	}
+	if storage.WriteRates() && stats != nil {
+		detail.Rates = driver.rates.Rates(ref.Name, stats)
 	}
 	return detail
 }
@@ -74,15 +90,78 @@ func (driver *kafkaStorage) AddStats(ref info.ContainerReference, stats *info.Co
 	detail := driver.infoToDetailSpec(ref, stats)
 	b, err := json.Marshal(detail)
 
+	driver.send(detail, b)
+
+	return err
+}
+
+// Sends the message, or logs it in a dry run.
+func (driver *kafkaStorage) send(detail *detailSpec, b []byte) {
+	if driver.dryRun != nil {
+		tags := map[string]string{
+			"machine_name":   detail.MachineName,
+			"container_name": detail.ContainerName,
+		}
+		driver.dryRun.Write(driver.topic, tags, string(b))
+		return
+	}
 	driver.producer.Input() <- &kafka.ProducerMessage{
 		Topic: driver.topic,
 		Value: kafka.StringEncoder(b),
 	}
+}
 
-	return err
+// AddDerivedStats sends the derived stats as a message of their own.
+func (driver *kafkaStorage) AddDerivedStats(ref info.ContainerReference, stats *v2.DerivedStats) error {
+	detail := &detailSpec{
+		Timestamp:       stats.Timestamp,
+		MachineName:     driver.machineName,
+		ContainerName:   container.GetPreferredName(ref),
+		ContainerID:     ref.Id,
+		ContainerLabels: ref.Labels,
+		ContainerEnvs:   ref.Envs,
+		MachineTags:     storage.MachineTags(),
+		DerivedStats:    stats,
+	}
+	b, err := json.Marshal(detail)
+	if err != nil {
+		return err
+	}
+
+	driver.send(detail, b)
+	return nil
+}
+
+func (driver *kafkaStorage) AddEvent(event *info.Event) error {
+	detail := &detailSpec{
+		Timestamp:      event.Timestamp,
+		MachineName:    driver.machineName,
+		ContainerName:  event.ContainerName,
+		ContainerEvent: event,
+		MachineTags:    storage.MachineTags(),
+	}
+	b, err := json.Marshal(detail)
+	if err != nil {
+		return err
+	}
+
+	driver.send(detail, b)
+	return nil
+}
+
+// Flush is a no-op: the asynchronous producer sends its buffered messages
+// when it is closed. A dry run logs its report.
+func (self *kafkaStorage) Flush() error {
+	if self.dryRun != nil {
+		self.dryRun.Flush()
+	}
+	return nil
 }
 
 func (self *kafkaStorage) Close() error {
+	if self.producer == nil {
+		return nil
+	}
 	return self.producer.Close()
 }
 
@@ -95,11 +174,18 @@ func new() (storage.StorageDriver, error) {
 }
 
 func newStorage(machineName string) (storage.StorageDriver, error) {
+	if *storage.ArgDryRun {
+		return &kafkaStorage{
+			topic:       *topic,
+			machineName: machineName,
+			dryRun:      storage.NewDryRun("kafka"),
+		}, nil
+	}
 	config := kafka.NewConfig()
 	config.Producer.RequiredAcks = kafka.WaitForAll
 
 	brokerList := strings.Split(*brokers, ",")
-	glog.V(4).Infof("Kafka brokers:%q", brokers)
+	logging.V(4).Infof("Kafka brokers:%q", *brokers)
 
 	producer, err := kafka.NewAsyncProducer(brokerList, config)
 	if err != nil {
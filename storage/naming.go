@@ -0,0 +1,85 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// Placeholder of the default series name in --storage_driver_series_template.
+const seriesNamePlaceholder = "{name}"
+
+var argSeriesTemplate = flag.String("storage_driver_series_template", seriesNamePlaceholder, "template of the series names written by the storage drivers, in which {name} is replaced by the default name, e.g. 'prod.cadvisor.{name}'. Applies to the measurements of influxdb, the metrics of statsd and the keys of stdout")
+var argSeriesNames = flag.String("storage_driver_series_names", "", "comma-separated list of <default name>=<name> renaming series before --storage_driver_series_template applies, e.g. 'cpu_usage_total=cpu_total,memory_usage=mem_usage'")
+
+var (
+	seriesTemplate = seriesNamePlaceholder
+	seriesNames    map[string]string
+)
+
+// Sets the naming of the series from the flags. Called before creating a
+// storage driver.
+func setSeriesNaming() error {
+	names, err := parsePairs(*argSeriesNames)
+	if err != nil {
+		return fmt.Errorf("invalid --storage_driver_series_names: %v", err)
+	}
+	if !strings.Contains(*argSeriesTemplate, seriesNamePlaceholder) {
+		return fmt.Errorf("invalid series template %q: it must contain %s", *argSeriesTemplate, seriesNamePlaceholder)
+	}
+	seriesTemplate = *argSeriesTemplate
+	seriesNames = names
+	return nil
+}
+
+// Parses a comma-separated list of <key>=<value> pairs.
+func parsePairs(value string) (map[string]string, error) {
+	pairs := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid pair %q, expected <key>=<value>", pair)
+		}
+		pairs[parts[0]] = parts[1]
+	}
+	return pairs, nil
+}
+
+// RenameSeries returns the name of the statistic given by
+// --storage_driver_series_names, its default name otherwise.
+func RenameSeries(name string) string {
+	if renamed, ok := seriesNames[name]; ok {
+		return renamed
+	}
+	return name
+}
+
+// ApplySeriesTemplate returns the full name of a series in
+// --storage_driver_series_template.
+func ApplySeriesTemplate(name string) string {
+	return strings.Replace(seriesTemplate, seriesNamePlaceholder, name, -1)
+}
+
+// SeriesName returns the name a storage driver writes the series of the
+// statistic under: its name, possibly renamed, in the template.
+func SeriesName(name string) string {
+	return ApplySeriesTemplate(RenameSeries(name))
+}
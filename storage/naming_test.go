@@ -0,0 +1,59 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"testing"
+)
+
+// Sets the naming flags, returning a function restoring them.
+func setTestSeriesNaming(template, names string) (func(), error) {
+	oldTemplate, oldNames := *argSeriesTemplate, *argSeriesNames
+	*argSeriesTemplate, *argSeriesNames = template, names
+	restore := func() {
+		*argSeriesTemplate, *argSeriesNames = oldTemplate, oldNames
+		setSeriesNaming()
+	}
+	return restore, setSeriesNaming()
+}
+
+func TestSeriesName(t *testing.T) {
+	restore, err := setTestSeriesNaming("prod.cadvisor.{name}", "memory_usage=mem_usage")
+	defer restore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, expected := range map[string]string{
+		"cpu_usage_total": "prod.cadvisor.cpu_usage_total",
+		"memory_usage":    "prod.cadvisor.mem_usage",
+	} {
+		if actual := SeriesName(name); actual != expected {
+			t.Errorf("expected %q for %q, got %q", expected, name, actual)
+		}
+	}
+}
+
+func TestSeriesNamingErrors(t *testing.T) {
+	restore, err := setTestSeriesNaming("prod.cadvisor", "")
+	defer restore()
+	if err == nil {
+		t.Error("expected an error for a template without {name}")
+	}
+	for _, names := range []string{"memory_usage", "=mem_usage", "memory_usage="} {
+		if _, err := parsePairs(names); err == nil {
+			t.Errorf("expected an error for %q", names)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"flag"
+	"fmt"
+	"sync"
+	"time"
+
+	info "github.com/google/cadvisor/info/v1"
+)
+
+const (
+	// Rates are written alongside the cumulative counters.
+	RatesAdd = "add"
+	// Rates are written instead of the cumulative counters they derive from.
+	RatesReplace = "replace"
+)
+
+var argRates = flag.String("storage_driver_rates", "", "write rates computed over the interval between two stats of a container: the cpu usage in percent of a core and the network and IO bytes and IO operations per second. 'add' writes them alongside the cumulative counters, 'replace' instead of the counters they derive from. Empty to write the counters only")
+
+// Names of the rates.
+const (
+	RateCpuUsage = "cpu_usage_percent"
+	RateRxBytes  = "rx_bytes_per_second"
+	RateTxBytes  = "tx_bytes_per_second"
+	RateIoBytes  = "io_bytes_per_second"
+	RateIoOps    = "io_ops_per_second"
+)
+
+// Samples of containers not seen for this long are dropped.
+const rateSampleExpiry = 5 * time.Minute
+
+var ratesMode string
+
+// Sets the rates mode from the flag. Called before creating a storage
+// driver.
+func setRates() error {
+	switch *argRates {
+	case "", RatesAdd, RatesReplace:
+		ratesMode = *argRates
+		return nil
+	}
+	return fmt.Errorf("invalid --storage_driver_rates %q, expected %q or %q", *argRates, RatesAdd, RatesReplace)
+}
+
+// WriteRates returns whether the storage drivers write rates.
+func WriteRates() bool {
+	return ratesMode != ""
+}
+
+// WriteCounters returns whether the storage drivers write the cumulative
+// counters the rates derive from.
+func WriteCounters() bool {
+	return ratesMode != RatesReplace
+}
+
+// The counters of a container the rates derive from.
+type rateSample struct {
+	timestamp time.Time
+	cpuUsage  uint64
+	rxBytes   uint64
+	txBytes   uint64
+	ioBytes   uint64
+	ioOps     uint64
+	// Whether the network counters were collected.
+	network bool
+}
+
+func newRateSample(stats *info.ContainerStats) rateSample {
+	sample := rateSample{
+		timestamp: stats.Timestamp,
+		cpuUsage:  stats.Cpu.Usage.Total,
+		rxBytes:   stats.Network.RxBytes,
+		txBytes:   stats.Network.TxBytes,
+		network:   stats.Valid(info.NetworkStatsSubsystem),
+	}
+	// Measure the intervals on the monotonic clock when possible.
+	if stats.MonotonicTimestamp != nil {
+		sample.timestamp = *stats.MonotonicTimestamp
+	}
+	for _, disk := range stats.DiskIo.IoServiceBytes {
+		sample.ioBytes += disk.Stats["Read"] + disk.Stats["Write"]
+	}
+	for _, disk := range stats.DiskIo.IoServiced {
+		sample.ioOps += disk.Stats["Read"] + disk.Stats["Write"]
+	}
+	return sample
+}
+
+// RateTracker computes the rates of the containers from their consecutive
+// stats. Its zero value is ready to use, and it is safe for concurrent use.
+type RateTracker struct {
+	lock      sync.Mutex
+	samples   map[string]rateSample
+	lastPrune time.Time
+}
+
+// Rates returns the rates of the container since its previous stats, by
+// name, or nil for its first stats, stats out of order and counters reset
+// by a restart of the container. The network rates are omitted unless the
+// network stats of both were collected.
+func (self *RateTracker) Rates(containerName string, stats *info.ContainerStats) map[string]float64 {
+	sample := newRateSample(stats)
+
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if self.samples == nil {
+		self.samples = make(map[string]rateSample)
+	}
+	self.prune(sample.timestamp)
+	previous, ok := self.samples[containerName]
+	if ok && !sample.timestamp.After(previous.timestamp) {
+		return nil
+	}
+	self.samples[containerName] = sample
+	network := sample.network && previous.network
+	if !ok || sample.cpuUsage < previous.cpuUsage || sample.ioBytes < previous.ioBytes || sample.ioOps < previous.ioOps {
+		return nil
+	}
+	if network && (sample.rxBytes < previous.rxBytes || sample.txBytes < previous.txBytes) {
+		return nil
+	}
+
+	seconds := sample.timestamp.Sub(previous.timestamp).Seconds()
+	rates := map[string]float64{
+		RateCpuUsage: float64(sample.cpuUsage-previous.cpuUsage) / 1e9 / seconds * 100,
+		RateIoBytes:  float64(sample.ioBytes-previous.ioBytes) / seconds,
+		RateIoOps:    float64(sample.ioOps-previous.ioOps) / seconds,
+	}
+	if network {
+		rates[RateRxBytes] = float64(sample.rxBytes-previous.rxBytes) / seconds
+		rates[RateTxBytes] = float64(sample.txBytes-previous.txBytes) / seconds
+	}
+	return rates
+}
+
+// Drops the samples of the containers gone for rateSampleExpiry, at most once
+// per expiry. Must be called with the lock held.
+func (self *RateTracker) prune(now time.Time) {
+	if now.Sub(self.lastPrune) < rateSampleExpiry {
+		return
+	}
+	for name, sample := range self.samples {
+		if now.Sub(sample.timestamp) > rateSampleExpiry {
+			delete(self.samples, name)
+		}
+	}
+	self.lastPrune = now
+}
@@ -0,0 +1,97 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	info "github.com/google/cadvisor/info/v1"
+)
+
+func rateStats(timestamp time.Time, cpu, rx, ops uint64) *info.ContainerStats {
+	stats := &info.ContainerStats{Timestamp: timestamp}
+	stats.Cpu.Usage.Total = cpu
+	stats.Network.RxBytes = rx
+	stats.DiskIo.IoServiced = []info.PerDiskStats{{Stats: map[string]uint64{"Read": ops, "Write": ops}}}
+	return stats
+}
+
+func TestRateTracker(t *testing.T) {
+	var tracker RateTracker
+	start := time.Unix(1000, 0)
+	if rates := tracker.Rates("/c", rateStats(start, 0, 0, 0)); rates != nil {
+		t.Errorf("expected no rates for the first stats, got %v", rates)
+	}
+	// 1.5s of cpu, 2000 bytes and 10 operations in 10s.
+	rates := tracker.Rates("/c", rateStats(start.Add(10*time.Second), 1500000000, 2000, 5))
+	expected := map[string]float64{
+		RateCpuUsage: 15,
+		RateRxBytes:  200,
+		RateTxBytes:  0,
+		RateIoBytes:  0,
+		RateIoOps:    1,
+	}
+	for name, value := range expected {
+		if rates[name] != value {
+			t.Errorf("expected %s %v, got %v", name, value, rates[name])
+		}
+	}
+
+	if rates := tracker.Rates("/c", rateStats(start.Add(10*time.Second), 1600000000, 2000, 5)); rates != nil {
+		t.Errorf("expected no rates for stats out of order, got %v", rates)
+	}
+	if rates := tracker.Rates("/c", rateStats(start.Add(20*time.Second), 100, 0, 0)); rates != nil {
+		t.Errorf("expected no rates after a counter reset, got %v", rates)
+	}
+	if rates := tracker.Rates("/c", rateStats(start.Add(30*time.Second), 1000000100, 0, 0)); rates[RateCpuUsage] != 10 {
+		t.Errorf("expected rates since the reset, got %v", rates)
+	}
+}
+
+func TestRateTrackerSkipsInvalidNetworkStats(t *testing.T) {
+	var tracker RateTracker
+	start := time.Unix(1000, 0)
+	tracker.Rates("/c", rateStats(start, 0, 1000, 0))
+	failed := rateStats(start.Add(10*time.Second), 1000000000, 0, 0)
+	failed.SetError(info.NetworkStatsSubsystem, fmt.Errorf("no such file"))
+	rates := tracker.Rates("/c", failed)
+	if rates == nil || rates[RateCpuUsage] != 10 {
+		t.Errorf("expected the cpu rate despite the network error, got %v", rates)
+	}
+	if _, ok := rates[RateRxBytes]; ok {
+		t.Errorf("expected no network rates without network stats, got %v", rates)
+	}
+	// The network rates resume once two consecutive stats have them.
+	rates = tracker.Rates("/c", rateStats(start.Add(20*time.Second), 2000000000, 2000, 0))
+	if _, ok := rates[RateRxBytes]; ok {
+		t.Errorf("expected no network rates since stats without network stats, got %v", rates)
+	}
+	rates = tracker.Rates("/c", rateStats(start.Add(30*time.Second), 3000000000, 3000, 0))
+	if rates[RateRxBytes] != 100 {
+		t.Errorf("expected rx rate 100, got %v", rates)
+	}
+}
+
+func TestRateTrackerPrunesGoneContainers(t *testing.T) {
+	var tracker RateTracker
+	start := time.Unix(1000, 0)
+	tracker.Rates("/gone", rateStats(start, 0, 0, 0))
+	tracker.Rates("/c", rateStats(start.Add(rateSampleExpiry+time.Minute), 0, 0, 0))
+	if _, ok := tracker.samples["/gone"]; ok {
+		t.Error("expected the samples of a gone container to be dropped")
+	}
+}
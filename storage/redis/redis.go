@@ -38,6 +38,10 @@ type redisStorage struct {
 	lastWrite      time.Time
 	lock           sync.Mutex
 	readyToFlush   func() bool
+	// Set with --storage_driver_dry_run, instead of the connection.
+	dryRun *storage.DryRun
+	// Computes the rates of --storage_driver_rates.
+	rates storage.RateTracker
 }
 
 type detailSpec struct {
@@ -45,6 +49,9 @@ type detailSpec struct {
 	MachineName    string               `json:"machine_name,omitempty"`
 	ContainerName  string               `json:"container_Name,omitempty"`
 	ContainerStats *info.ContainerStats `json:"container_stats,omitempty"`
+	MachineTags    map[string]string    `json:"machine_tags,omitempty"`
+	// Rates of --storage_driver_rates.
+	Rates map[string]float64 `json:"rates,omitempty"`
 }
 
 func new() (storage.StorageDriver, error) {
@@ -78,6 +85,10 @@ func (self *redisStorage) containerStatsAndDefaultValues(ref info.ContainerRefer
 		MachineName:    self.machineName,
 		ContainerName:  containerName,
 		ContainerStats: stats,
+		MachineTags:    storage.MachineTags(),
+	}
+	if storage.WriteRates() {
+		detail.Rates = self.rates.Rates(ref.Name, stats)
 	}
 	return detail
 }
@@ -88,12 +99,14 @@ func (self *redisStorage) AddStats(ref info.ContainerReference, stats *info.Cont
 		return nil
 	}
 	var seriesToFlush []byte
+	var containerName string
 	func() {
 		// AddStats will be invoked simultaneously from multiple threads and only one of them will perform a write.
 		self.lock.Lock()
 		defer self.lock.Unlock()
 		// Add some default params based on containerStats
 		detail := self.containerStatsAndDefaultValues(ref, stats)
+		containerName = detail.ContainerName
 		//To json
 		b, _ := json.Marshal(detail)
 		if self.readyToFlush() {
@@ -101,14 +114,36 @@ func (self *redisStorage) AddStats(ref info.ContainerReference, stats *info.Cont
 			self.lastWrite = time.Now()
 		}
 	}()
-	if len(seriesToFlush) > 0 {
-		//We use redis's "LPUSH" to push the data to the redis
-		self.conn.Send("LPUSH", self.redisKey, seriesToFlush)
+	if len(seriesToFlush) == 0 {
+		return nil
+	}
+	if self.dryRun != nil {
+		tags := map[string]string{
+			"machine_name":   self.machineName,
+			"container_name": containerName,
+		}
+		self.dryRun.Write(self.redisKey, tags, string(seriesToFlush))
+		return nil
 	}
+	//We use redis's "LPUSH" to push the data to the redis
+	self.conn.Send("LPUSH", self.redisKey, seriesToFlush)
 	return nil
 }
 
+// Flush sends the commands buffered by the connection. A dry run logs its
+// report.
+func (self *redisStorage) Flush() error {
+	if self.dryRun != nil {
+		self.dryRun.Flush()
+		return nil
+	}
+	return self.conn.Flush()
+}
+
 func (self *redisStorage) Close() error {
+	if self.conn == nil {
+		return nil
+	}
 	return self.conn.Close()
 }
 
@@ -123,12 +158,20 @@ func newStorage(
 	redisHost string,
 	bufferDuration time.Duration,
 ) (storage.StorageDriver, error) {
-	conn, err := redis.Dial("tcp", redisHost)
-	if err != nil {
-		return nil, err
+	var conn redis.Conn
+	var dryRun *storage.DryRun
+	if *storage.ArgDryRun {
+		dryRun = storage.NewDryRun("redis")
+	} else {
+		var err error
+		conn, err = redis.Dial("tcp", redisHost)
+		if err != nil {
+			return nil, err
+		}
 	}
 	ret := &redisStorage{
 		conn:           conn,
+		dryRun:         dryRun,
 		machineName:    machineName,
 		redisKey:       redisKey,
 		bufferDuration: bufferDuration,
@@ -16,9 +16,13 @@ package client
 
 import (
 	"fmt"
+	"io"
 	"net"
+	"sort"
+	"strconv"
+	"strings"
 
-	"github.com/golang/glog"
+	"github.com/google/cadvisor/logging"
 )
 
 type Client struct {
@@ -30,7 +34,7 @@ type Client struct {
 func (self *Client) Open() error {
 	conn, err := net.Dial("udp", self.HostPort)
 	if err != nil {
-		glog.Errorf("failed to open udp connection to %q: %v", self.HostPort, err)
+		logging.Errorf("failed to open udp connection to %q: %v", self.HostPort, err)
 		return err
 	}
 	self.conn = conn
@@ -43,13 +47,37 @@ func (self *Client) Close() error {
 	return nil
 }
 
+// Name returns the default name of the metric of the key of the container.
+func Name(namespace, containerName, key string) string {
+	return fmt.Sprintf("%s.%s.%s", namespace, containerName, key)
+}
+
+// Format returns the gauge sent for the value of the metric, an integer or
+// decimal number. Tags are appended in the DogStatsD format, sorted by name.
+func Format(name, value string, tags map[string]string) string {
+	gauge := fmt.Sprintf("%s:%s|g", name, value)
+	if len(tags) == 0 {
+		return gauge
+	}
+	pairs := make([]string, 0, len(tags))
+	for tag, tagValue := range tags {
+		pairs = append(pairs, tag+":"+tagValue)
+	}
+	sort.Strings(pairs)
+	return gauge + "|#" + strings.Join(pairs, ",")
+}
+
 // Simple send to statsd daemon without sampling.
 func (self *Client) Send(namespace, containerName, key string, value uint64) error {
-	// only send counter value
-	formatted := fmt.Sprintf("%s.%s.%s:%d|g", namespace, containerName, key, value)
-	_, err := fmt.Fprintf(self.conn, formatted)
+	return self.SendGauge(Name(namespace, containerName, key), strconv.FormatUint(value, 10), nil)
+}
+
+// SendGauge sends the value of the named metric with the tags.
+func (self *Client) SendGauge(name, value string, tags map[string]string) error {
+	formatted := Format(name, value, tags)
+	_, err := io.WriteString(self.conn, formatted)
 	if err != nil {
-		glog.V(3).Infof("failed to send data %q: %v", formatted, err)
+		logging.V(3).Infof("failed to send data %q: %v", formatted, err)
 		return err
 	}
 	return nil
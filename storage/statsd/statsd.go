@@ -15,6 +15,8 @@
 package statsd
 
 import (
+	"strconv"
+
 	info "github.com/google/cadvisor/info/v1"
 	"github.com/google/cadvisor/storage"
 	client "github.com/google/cadvisor/storage/statsd/client"
@@ -27,6 +29,10 @@ func init() {
 type statsdStorage struct {
 	client    *client.Client
 	Namespace string
+	// Set with --storage_driver_dry_run, instead of the client.
+	dryRun *storage.DryRun
+	// Computes the rates of --storage_driver_rates.
+	rates storage.RateTracker
 }
 
 const (
@@ -69,11 +75,13 @@ func (self *statsdStorage) containerStatsToValues(
 	// Working set size
 	series[colMemoryWorkingSet] = stats.Memory.WorkingSet
 
-	// Network stats.
-	series[colRxBytes] = stats.Network.RxBytes
-	series[colRxErrors] = stats.Network.RxErrors
-	series[colTxBytes] = stats.Network.TxBytes
-	series[colTxErrors] = stats.Network.TxErrors
+	// Network stats, unless they could not be collected.
+	if stats.Valid(info.NetworkStatsSubsystem) {
+		series[colRxBytes] = stats.Network.RxBytes
+		series[colRxErrors] = stats.Network.RxErrors
+		series[colTxBytes] = stats.Network.TxBytes
+		series[colTxErrors] = stats.Network.TxErrors
+	}
 
 	return series
 }
@@ -108,22 +116,63 @@ func (self *statsdStorage) AddStats(ref info.ContainerReference, stats *info.Con
 
 	series := self.containerStatsToValues(stats)
 	self.containerFsStatsToValues(&series, stats)
+	if !storage.WriteCounters() {
+		// Replaced by their rates.
+		delete(series, colCpuCumulativeUsage)
+		delete(series, colRxBytes)
+		delete(series, colTxBytes)
+	}
 	for key, value := range series {
-		err := self.client.Send(self.Namespace, containerName, key, value)
-		if err != nil {
+		if err := self.send(containerName, key, strconv.FormatUint(value, 10)); err != nil {
 			return err
 		}
 	}
+	if storage.WriteRates() {
+		for key, value := range self.rates.Rates(ref.Name, stats) {
+			if err := self.send(containerName, key, strconv.FormatFloat(value, 'f', -1, 64)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Sends the gauge of the key of the container, or logs it in a dry run.
+func (self *statsdStorage) send(containerName, key, value string) error {
+	name := storage.ApplySeriesTemplate(client.Name(self.Namespace, containerName, storage.RenameSeries(key)))
+	staticTags := storage.StaticTags()
+	if self.dryRun != nil {
+		tags := map[string]string{"container_name": containerName}
+		self.dryRun.Write(key, tags, client.Format(name, value, staticTags))
+		return nil
+	}
+	return self.client.SendGauge(name, value, staticTags)
+}
+
+// Flush is a no-op: stats are sent right away. A dry run logs its report.
+func (self *statsdStorage) Flush() error {
+	if self.dryRun != nil {
+		self.dryRun.Flush()
+	}
 	return nil
 }
 
 func (self *statsdStorage) Close() error {
+	if self.client == nil {
+		return nil
+	}
 	self.client.Close()
 	self.client = nil
 	return nil
 }
 
 func newStorage(namespace, hostPort string) (*statsdStorage, error) {
+	if *storage.ArgDryRun {
+		return &statsdStorage{
+			Namespace: namespace,
+			dryRun:    storage.NewDryRun("statsd"),
+		}, nil
+	}
 	statsdClient, err := client.New(hostPort)
 	if err != nil {
 		return nil, err
@@ -17,8 +17,12 @@ package stdout
 import (
 	"bytes"
 	"fmt"
+	"sort"
+	"strconv"
+	"time"
 
 	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/info/v2"
 	"github.com/google/cadvisor/storage"
 )
 
@@ -28,6 +32,10 @@ func init() {
 
 type stdoutStorage struct {
 	Namespace string
+	// Set with --storage_driver_dry_run.
+	dryRun *storage.DryRun
+	// Computes the rates of --storage_driver_rates.
+	rates storage.RateTracker
 }
 
 const (
@@ -52,6 +60,12 @@ const (
 	colFsLimit = "fs_limit"
 	// Filesystem usage.
 	colFsUsage = "fs_usage"
+	// Percentiles of the cpu usage over a window.
+	colDerivedCpu = "derived_cpu_usage"
+	// Percentiles of the memory working set over a window.
+	colDerivedMemory = "derived_memory_working_set"
+	// Percentage of the window covered by samples.
+	colPercentComplete = "percent_complete"
 )
 
 func new() (storage.StorageDriver, error) {
@@ -106,18 +120,117 @@ func (driver *stdoutStorage) AddStats(ref info.ContainerReference, stats *info.C
 
 	var buffer bytes.Buffer
 	buffer.WriteString(fmt.Sprintf("cName=%s host=%s", containerName, driver.Namespace))
+	writeMachineTags(&buffer)
 
 	series := driver.containerStatsToValues(stats)
 	driver.containerFsStatsToValues(&series, stats)
+	if !storage.WriteCounters() {
+		// Replaced by their rates.
+		delete(series, colCpuCumulativeUsage)
+		delete(series, colRxBytes)
+		delete(series, colTxBytes)
+	}
 	for key, value := range series {
-		buffer.WriteString(fmt.Sprintf(" %s=%v", key, value))
+		buffer.WriteString(fmt.Sprintf(" %s=%v", storage.SeriesName(key), value))
+	}
+	if storage.WriteRates() {
+		for key, value := range driver.rates.Rates(ref.Name, stats) {
+			buffer.WriteString(fmt.Sprintf(" %s=%s", storage.SeriesName(key), strconv.FormatFloat(value, 'f', -1, 64)))
+		}
+	}
+
+	return driver.print("stats", containerName, buffer.String())
+}
+
+func (driver *stdoutStorage) AddEvent(event *info.Event) error {
+	var buffer bytes.Buffer
+	buffer.WriteString(fmt.Sprintf("cName=%s host=%s event=%s timestamp=%s", event.ContainerName, driver.Namespace, event.EventType, event.Timestamp.Format(time.RFC3339)))
+	writeMachineTags(&buffer)
+	if oom := event.EventData.OomKill; oom != nil && oom.Pid != 0 {
+		buffer.WriteString(fmt.Sprintf(" pid=%d process=%s", oom.Pid, oom.ProcessName))
 	}
+	return driver.print("events", event.ContainerName, buffer.String())
+}
+
+// AddDerivedStats prints a line per window with the percentiles of the cpu
+// usage, in milliCpus, and of the memory working set over the window.
+func (driver *stdoutStorage) AddDerivedStats(ref info.ContainerReference, stats *v2.DerivedStats) error {
+	containerName := ref.Name
+	if len(ref.Aliases) > 0 {
+		containerName = ref.Aliases[0]
+	}
+	for _, window := range []struct {
+		name  string
+		usage v2.Usage
+	}{
+		{"minute", stats.MinuteUsage},
+		{"hour", stats.HourUsage},
+		{"day", stats.DayUsage},
+	} {
+		var buffer bytes.Buffer
+		buffer.WriteString(fmt.Sprintf("cName=%s host=%s window=%s timestamp=%s", containerName, driver.Namespace, window.name, stats.Timestamp.Format(time.RFC3339)))
+		writeMachineTags(&buffer)
+		buffer.WriteString(fmt.Sprintf(" %s=%d", storage.SeriesName(colPercentComplete), window.usage.PercentComplete))
+		writePercentiles(&buffer, colDerivedCpu, window.usage.Cpu)
+		writePercentiles(&buffer, colDerivedMemory, window.usage.Memory)
+		if err := driver.print("derived_stats", containerName, buffer.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	_, err := fmt.Println(buffer.String())
+// Writes the percentiles, if present, as <prefix>_<percentile>=<value>.
+func writePercentiles(buffer *bytes.Buffer, prefix string, percentiles v2.Percentiles) {
+	if !percentiles.Present {
+		return
+	}
+	for _, value := range []struct {
+		name  string
+		value uint64
+	}{
+		{"mean", percentiles.Mean},
+		{"max", percentiles.Max},
+		{"p50", percentiles.Fifty},
+		{"p90", percentiles.Ninety},
+		{"p95", percentiles.NinetyFive},
+	} {
+		buffer.WriteString(fmt.Sprintf(" %s=%d", storage.SeriesName(prefix+"_"+value.name), value.value))
+	}
+}
 
+// Prints the line, or logs it in a dry run.
+func (driver *stdoutStorage) print(measurement, containerName, line string) error {
+	if driver.dryRun != nil {
+		tags := map[string]string{"cName": containerName, "host": driver.Namespace}
+		driver.dryRun.Write(measurement, tags, line)
+		return nil
+	}
+	_, err := fmt.Println(line)
 	return err
 }
 
+// Writes the tags of the machine, sorted by name.
+func writeMachineTags(buffer *bytes.Buffer) {
+	tags := storage.MachineTags()
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		buffer.WriteString(fmt.Sprintf(" %s=%s", name, tags[name]))
+	}
+}
+
+// Flush is a no-op: stats are printed right away. A dry run logs its report.
+func (driver *stdoutStorage) Flush() error {
+	if driver.dryRun != nil {
+		driver.dryRun.Flush()
+	}
+	return nil
+}
+
 func (driver *stdoutStorage) Close() error {
 	return nil
 }
@@ -126,5 +239,8 @@ func newStorage(namespace string) (*stdoutStorage, error) {
 	stdoutStorage := &stdoutStorage{
 		Namespace: namespace,
 	}
+	if *storage.ArgDryRun {
+		stdoutStorage.dryRun = storage.NewDryRun("stdout")
+	}
 	return stdoutStorage, nil
 }
@@ -16,19 +16,46 @@ package storage
 
 import (
 	"fmt"
+	"time"
 
 	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/info/v2"
 )
 
 type StorageDriver interface {
 	AddStats(ref info.ContainerReference, stats *info.ContainerStats) error
 
+	// Flush writes the stats buffered by the storage driver, if any, to the
+	// underlying storage. Called before cAdvisor exits.
+	Flush() error
+
 	// Close will clear the state of the storage driver. The elements
 	// stored in the underlying storage may or may not be deleted depending
 	// on the implementation of the storage driver.
 	Close() error
 }
 
+// EventStorageDriver is implemented by storage drivers that can record
+// container events, e.g. as annotations to overlay on graphs.
+type EventStorageDriver interface {
+	AddEvent(event *info.Event) error
+}
+
+// DerivedStatsStorageDriver is implemented by storage drivers that can record
+// the percentiles of the usage of containers, written once per minute window.
+type DerivedStatsStorageDriver interface {
+	AddDerivedStats(ref info.ContainerReference, stats *v2.DerivedStats) error
+}
+
+// StatsReader is implemented by storage drivers that can read back the stats
+// they stored, e.g. to show ranges longer than the in-memory cache in the UI.
+type StatsReader interface {
+	// Returns the stats of the container between start and end, one per
+	// step, oldest first. Only the cumulative CPU usage, memory usage and
+	// network counters of the default interface are read back.
+	ReadStats(containerName string, start, end time.Time, step time.Duration) ([]*info.ContainerStats, error)
+}
+
 type StorageDriverFunc func() (StorageDriver, error)
 
 var registeredPlugins = map[string](StorageDriverFunc){}
@@ -45,5 +72,14 @@ func New(name string) (StorageDriver, error) {
 	if !ok {
 		return nil, fmt.Errorf("unknown backend storage driver: %s", name)
 	}
+	if err := setSeriesNaming(); err != nil {
+		return nil, err
+	}
+	if err := setStaticTags(); err != nil {
+		return nil, err
+	}
+	if err := setRates(); err != nil {
+		return nil, err
+	}
 	return f()
 }
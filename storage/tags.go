@@ -0,0 +1,88 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"flag"
+	"fmt"
+	"sync"
+)
+
+var argStaticTags = flag.String("storage_driver_tags", "", "comma-separated list of <name>=<value> tags added to every write of the storage drivers, e.g. 'env=prod,region=us-east-1'")
+
+var (
+	machineTagsLock sync.RWMutex
+	// Tags set by SetMachineTags.
+	detectedTags map[string]string
+	// Tags of --storage_driver_tags.
+	staticTags map[string]string
+	// The detected tags overridden by the static tags.
+	machineTags map[string]string
+)
+
+// Sets the static tags from the flag. Called before creating a storage
+// driver.
+func setStaticTags() error {
+	tags, err := parsePairs(*argStaticTags)
+	if err != nil {
+		return fmt.Errorf("invalid --storage_driver_tags: %v", err)
+	}
+	machineTagsLock.Lock()
+	defer machineTagsLock.Unlock()
+	staticTags = tags
+	mergeMachineTags()
+	return nil
+}
+
+// SetMachineTags sets the tags of the machine added to every write of the
+// storage drivers that support tags. The static tags of
+// --storage_driver_tags take precedence over them.
+func SetMachineTags(tags map[string]string) {
+	machineTagsLock.Lock()
+	defer machineTagsLock.Unlock()
+	detectedTags = tags
+	mergeMachineTags()
+}
+
+// Must be called with machineTagsLock held.
+func mergeMachineTags() {
+	if len(staticTags) == 0 {
+		machineTags = detectedTags
+		return
+	}
+	machineTags = make(map[string]string, len(detectedTags)+len(staticTags))
+	for name, value := range detectedTags {
+		machineTags[name] = value
+	}
+	for name, value := range staticTags {
+		machineTags[name] = value
+	}
+}
+
+// MachineTags returns the tags of the machine, including the static tags,
+// which must not be modified.
+func MachineTags() map[string]string {
+	machineTagsLock.RLock()
+	defer machineTagsLock.RUnlock()
+	return machineTags
+}
+
+// StaticTags returns the tags of --storage_driver_tags, which must not be
+// modified.
+func StaticTags() map[string]string {
+	machineTagsLock.RLock()
+	defer machineTagsLock.RUnlock()
+	return staticTags
+}
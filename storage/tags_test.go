@@ -0,0 +1,48 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStaticTagsOverrideMachineTags(t *testing.T) {
+	oldTags := *argStaticTags
+	defer func() {
+		*argStaticTags = oldTags
+		setStaticTags()
+		SetMachineTags(nil)
+	}()
+
+	*argStaticTags = "env=prod, region=us-east-1"
+	if err := setStaticTags(); err != nil {
+		t.Fatal(err)
+	}
+	SetMachineTags(map[string]string{"provider": "AWS", "region": "us-west-2"})
+	expected := map[string]string{"env": "prod", "provider": "AWS", "region": "us-east-1"}
+	if tags := MachineTags(); !reflect.DeepEqual(tags, expected) {
+		t.Errorf("expected machine tags %v, got %v", expected, tags)
+	}
+	expected = map[string]string{"env": "prod", "region": "us-east-1"}
+	if tags := StaticTags(); !reflect.DeepEqual(tags, expected) {
+		t.Errorf("expected static tags %v, got %v", expected, tags)
+	}
+
+	*argStaticTags = "env"
+	if err := setStaticTags(); err == nil {
+		t.Error("expected an error for a tag without value")
+	}
+}
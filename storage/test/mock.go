@@ -16,6 +16,7 @@ package test
 
 import (
 	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/info/v2"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -23,6 +24,7 @@ import (
 type MockStorageDriver struct {
 	mock.Mock
 	MockCloseMethod bool
+	MockFlushMethod bool
 }
 
 func (self *MockStorageDriver) AddStats(ref info.ContainerReference, stats *info.ContainerStats) error {
@@ -30,6 +32,14 @@ func (self *MockStorageDriver) AddStats(ref info.ContainerReference, stats *info
 	return args.Error(0)
 }
 
+func (self *MockStorageDriver) Flush() error {
+	if self.MockFlushMethod {
+		args := self.Called()
+		return args.Error(0)
+	}
+	return nil
+}
+
 func (self *MockStorageDriver) Close() error {
 	if self.MockCloseMethod {
 		args := self.Called()
@@ -37,3 +47,23 @@ func (self *MockStorageDriver) Close() error {
 	}
 	return nil
 }
+
+// MockEventStorageDriver also records events.
+type MockEventStorageDriver struct {
+	MockStorageDriver
+}
+
+func (self *MockEventStorageDriver) AddEvent(event *info.Event) error {
+	args := self.Called(event)
+	return args.Error(0)
+}
+
+// MockDerivedStatsStorageDriver also records derived stats.
+type MockDerivedStatsStorageDriver struct {
+	MockStorageDriver
+}
+
+func (self *MockDerivedStatsStorageDriver) AddDerivedStats(ref info.ContainerReference, stats *v2.DerivedStats) error {
+	args := self.Called(ref, stats)
+	return args.Error(0)
+}
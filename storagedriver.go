@@ -16,9 +16,11 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"time"
 
 	"github.com/google/cadvisor/cache/memory"
+	"github.com/google/cadvisor/logging"
 	"github.com/google/cadvisor/storage"
 	_ "github.com/google/cadvisor/storage/bigquery"
 	_ "github.com/google/cadvisor/storage/elasticsearch"
@@ -27,11 +29,14 @@ import (
 	_ "github.com/google/cadvisor/storage/redis"
 	_ "github.com/google/cadvisor/storage/statsd"
 	_ "github.com/google/cadvisor/storage/stdout"
-
-	"github.com/golang/glog"
 )
 
 var storageDuration = flag.Duration("storage_duration", 2*time.Minute, "How long to keep data stored (Default: 2min).")
+var storageDurationOverrides = flag.String("storage_duration_overrides", "", "comma separated list of <regexp>=<duration> setting how long to keep the stats of the containers whose absolute name or an alias matches the regexp, e.g. ^/system.slice/=5m. The first match applies. The io.cadvisor.storage_duration label of a container takes precedence")
+var storageMaxBytes = flag.Int64("storage_max_bytes", 0, "Max estimated size in bytes of the stats cached in memory for all containers. Above it, the oldest stats of the containers using the most memory are evicted first. 0 bounds the stats only by --storage_duration")
+var storageCompression = flag.Bool("storage_compression", false, "Whether to compress the stats cached in memory, trading cpu on every read for less memory")
+var storageFile = flag.String("storage_file", "", "File in which to persist the stats cached in memory so that they survive restarts. Disabled if empty")
+var storageFileInterval = flag.Duration("storage_file_interval", time.Minute, "Interval at which the stats cached in memory are persisted in --storage_file. They are also persisted on shutdown")
 
 // NewMemoryStorage creates a memory storage with an optional backend storage option.
 func NewMemoryStorage(backendStorageName string) (*memory.InMemoryCache, error) {
@@ -40,9 +45,36 @@ func NewMemoryStorage(backendStorageName string) (*memory.InMemoryCache, error)
 		return nil, err
 	}
 	if backendStorageName != "" {
-		glog.Infof("Using backend storage type %q", backendStorageName)
+		logging.Infof("Using backend storage type %q", backendStorageName)
 	}
-	glog.Infof("Caching stats in memory for %v", *storageDuration)
+	return newMemoryStorage(backendStorage)
+}
+
+// Creates the memory storage pushing the stats to the backend storage, if any.
+func newMemoryStorage(backendStorage storage.StorageDriver) (*memory.InMemoryCache, error) {
+	logging.Infof("Caching stats in memory for %v", *storageDuration)
 	storageDriver := memory.New(*storageDuration, backendStorage)
+	if *storageDurationOverrides != "" {
+		overrides, err := memory.ParseRetentionOverrides(*storageDurationOverrides)
+		if err != nil {
+			return nil, err
+		}
+		storageDriver.SetRetentionOverrides(overrides)
+	}
+	if *storageMaxBytes > 0 {
+		logging.Infof("Caching at most %d bytes of stats in memory", *storageMaxBytes)
+		storageDriver.SetByteBudget(*storageMaxBytes)
+	}
+	if *storageCompression {
+		storageDriver.EnableCompression()
+	}
+	if *storageFile != "" {
+		if *storageFileInterval <= 0 {
+			return nil, fmt.Errorf("invalid --storage_file_interval %v, expected a positive duration", *storageFileInterval)
+		}
+		if err := storageDriver.PersistTo(*storageFile, *storageFileInterval); err != nil {
+			return nil, err
+		}
+	}
 	return storageDriver, nil
 }
@@ -0,0 +1,128 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"math"
+
+	"github.com/google/cadvisor/info/v1"
+)
+
+// Metrics checked for anomalies.
+const (
+	AnomalyMetricCpu    = "cpu"
+	AnomalyMetricMemory = "memory"
+)
+
+// Baselines need this fraction of their window before values are checked.
+const minBaselineFraction = 0.5
+
+// Standard deviations below this fraction of the mean are raised to it, so
+// that small changes of a flat metric are not reported.
+const minRelativeStdDev = 0.01
+
+// Mean and variance of the last values of a metric.
+type rollingStats struct {
+	values []float64
+	// Index of the next value to replace once the window is full.
+	next       int
+	sum, sumSq float64
+}
+
+func (self *rollingStats) add(value float64, window int) {
+	if len(self.values) < window {
+		self.values = append(self.values, value)
+	} else {
+		old := self.values[self.next]
+		self.sum -= old
+		self.sumSq -= old * old
+		self.values[self.next] = value
+		self.next = (self.next + 1) % window
+	}
+	self.sum += value
+	self.sumSq += value * value
+}
+
+func (self *rollingStats) meanStdDev() (float64, float64) {
+	n := float64(len(self.values))
+	if n == 0 {
+		return 0, 0
+	}
+	mean := self.sum / n
+	variance := self.sumSq/n - mean*mean
+	if variance < 0 {
+		// Rounding errors.
+		variance = 0
+	}
+	return mean, math.Sqrt(variance)
+}
+
+type metricBaseline struct {
+	stats rollingStats
+	// Whether the last value was anomalous. Only the first of consecutive
+	// anomalous values is reported.
+	anomalous bool
+}
+
+// AnomalyDetector reports values of metrics that deviate from the rolling
+// baseline of their last values by more than a number of standard deviations.
+type AnomalyDetector struct {
+	// Number of values in the baselines.
+	window int
+	// Number of standard deviations beyond which values are anomalous.
+	threshold float64
+	baselines map[string]*metricBaseline
+}
+
+func NewAnomalyDetector(window int, threshold float64) *AnomalyDetector {
+	return &AnomalyDetector{
+		window:    window,
+		threshold: threshold,
+		baselines: make(map[string]*metricBaseline),
+	}
+}
+
+// Observe checks the value against the baseline of the metric, then adds it to
+// the baseline. It returns the anomaly if the value starts deviating.
+func (self *AnomalyDetector) Observe(metric string, value float64) *v1.AnomalyEventData {
+	baseline, ok := self.baselines[metric]
+	if !ok {
+		baseline = &metricBaseline{}
+		self.baselines[metric] = baseline
+	}
+	defer baseline.stats.add(value, self.window)
+	if float64(len(baseline.stats.values)) < minBaselineFraction*float64(self.window) {
+		return nil
+	}
+
+	mean, stdDev := baseline.stats.meanStdDev()
+	stdDev = math.Max(stdDev, math.Abs(mean)*minRelativeStdDev)
+	if stdDev == 0 {
+		return nil
+	}
+	zScore := (value - mean) / stdDev
+	wasAnomalous := baseline.anomalous
+	baseline.anomalous = math.Abs(zScore) > self.threshold
+	if !baseline.anomalous || wasAnomalous {
+		return nil
+	}
+	return &v1.AnomalyEventData{
+		Metric: metric,
+		Value:  value,
+		Mean:   mean,
+		StdDev: stdDev,
+		ZScore: zScore,
+	}
+}
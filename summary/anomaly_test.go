@@ -0,0 +1,68 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRollingStats(t *testing.T) {
+	stats := rollingStats{}
+	for _, value := range []float64{1, 2, 3, 4, 5} {
+		stats.add(value, 4)
+	}
+	// The window holds 2, 3, 4 and 5.
+	mean, stdDev := stats.meanStdDev()
+	if mean != 3.5 {
+		t.Errorf("expected mean 3.5, got %v", mean)
+	}
+	if math.Abs(stdDev-math.Sqrt(1.25)) > 1e-9 {
+		t.Errorf("expected std dev %v, got %v", math.Sqrt(1.25), stdDev)
+	}
+}
+
+func TestAnomalyDetector(t *testing.T) {
+	d := NewAnomalyDetector(20, 3)
+	// Values alternating between 90 and 110 have a mean of 100 and a
+	// standard deviation of 10.
+	for i := 0; i < 20; i++ {
+		value := 90.0
+		if i%2 == 1 {
+			value = 110
+		}
+		if anomaly := d.Observe("cpu", value); anomaly != nil {
+			t.Fatalf("unexpected anomaly %+v", anomaly)
+		}
+	}
+	if anomaly := d.Observe("cpu", 125); anomaly != nil {
+		t.Errorf("unexpected anomaly %+v within 3 standard deviations", anomaly)
+	}
+	anomaly := d.Observe("cpu", 200)
+	if anomaly == nil {
+		t.Fatalf("expected an anomaly")
+	}
+	if anomaly.Metric != "cpu" || anomaly.Value != 200 || anomaly.ZScore < 3 {
+		t.Errorf("unexpected anomaly %+v", anomaly)
+	}
+	// Consecutive anomalous values are reported once.
+	if anomaly := d.Observe("cpu", 200); anomaly != nil {
+		t.Errorf("unexpected repeated anomaly %+v", anomaly)
+	}
+	// Other metrics have their own baseline.
+	if anomaly := d.Observe("memory", 1e9); anomaly != nil {
+		t.Errorf("unexpected anomaly %+v without a baseline", anomaly)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	info "github.com/google/cadvisor/info/v2"
+)
+
+// Minute averages needed before usage is forecast.
+const minPredictionSamples = 3
+
+// Containers without a memory limit report a huge one.
+const unlimitedMemory = 1 << 62
+
+// Rates of change of the usage, fitted over the minute averages.
+type usageTrend struct {
+	samples    int
+	cpuRate    float64
+	memoryRate float64
+}
+
+// Returns the slope per sample of the least-squares line through the means of
+// the present percentiles. Assumes equally spaced samples.
+func linearRate(samples []*info.Usage, get func(*info.Usage) info.Percentiles) (float64, bool) {
+	var n, sumX, sumY, sumXY, sumXX float64
+	for i, sample := range samples {
+		p := get(sample)
+		if !p.Present {
+			continue
+		}
+		x, y := float64(i), float64(p.Mean)
+		n++
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	if n < minPredictionSamples {
+		return 0, false
+	}
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, false
+	}
+	return (n*sumXY - sumX*sumY) / denominator, true
+}
+
+// Fits the trends over the minute averages of the last hour.
+func (s *StatsSummary) fitTrend() usageTrend {
+	samples := s.minuteSamples.RecentStats(s.minuteSamples.Size())
+	trend := usageTrend{samples: len(samples)}
+	trend.cpuRate, _ = linearRate(samples, func(u *info.Usage) info.Percentiles { return u.Cpu })
+	trend.memoryRate, _ = linearRate(samples, func(u *info.Usage) info.Percentiles { return u.Memory })
+	return trend
+}
+
+// Forecasts the usage from the current value, the rate per minute and the limit.
+func forecast(current uint64, rate float64, limit uint64) *info.ResourceForecast {
+	at := func(minutes float64) uint64 {
+		return uint64(math.Max(0, float64(current)+rate*minutes))
+	}
+	f := &info.ResourceForecast{
+		Current:       current,
+		RatePerMinute: rate,
+		In5Minutes:    at(5),
+		In15Minutes:   at(15),
+		In60Minutes:   at(60),
+		Limit:         limit,
+	}
+	if limit > 0 && current >= limit {
+		minutes := 0.0
+		f.MinutesUntilLimit = &minutes
+	} else if limit > 0 && rate > 0 {
+		minutes := float64(limit-current) / rate
+		f.MinutesUntilLimit = &minutes
+	}
+	return f
+}
+
+// Returns short-term forecasts of the usage of the container, extrapolating
+// the latest usage at the rate fitted over the minute averages of the last
+// hour.
+func (s *StatsSummary) Predict() (info.Prediction, error) {
+	s.dataLock.RLock()
+	defer s.dataLock.RUnlock()
+
+	if s.trend.samples < minPredictionSamples {
+		return info.Prediction{}, fmt.Errorf("not enough samples to predict usage: %d minutes of %d collected", s.trend.samples, minPredictionSamples)
+	}
+	prediction := info.Prediction{
+		Timestamp: time.Now(),
+		Samples:   s.trend.samples,
+	}
+	usage := s.derivedStats.LatestUsage
+	if s.available.Cpu {
+		prediction.Cpu = forecast(usage.Cpu, s.trend.cpuRate, s.cpuLimit)
+	}
+	if s.available.Memory {
+		prediction.Memory = forecast(usage.Memory, s.trend.memoryRate, s.memoryLimit)
+	}
+	return prediction, nil
+}
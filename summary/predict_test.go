@@ -0,0 +1,85 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/cadvisor/info/v1"
+)
+
+func TestPredict(t *testing.T) {
+	const mb = 1024 * 1024
+	s, err := New(v1.ContainerSpec{
+		HasCpu:    true,
+		Cpu:       v1.CpuSpec{Quota: 50000, Period: 100000},
+		HasMemory: true,
+		Memory:    v1.MemorySpec{Limit: 200 * mb},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Predict(); err == nil {
+		t.Errorf("expected an error without samples")
+	}
+
+	// Memory grows by 1MB every minute, cpu usage is a steady quarter core.
+	start := time.Now()
+	for i := 0; i <= 6*60; i += 10 {
+		stat := v1.ContainerStats{Timestamp: start.Add(time.Duration(i) * time.Second)}
+		stat.Cpu.Usage.Total = uint64(i) * uint64(time.Second) / 4
+		stat.Memory.WorkingSet = 100*mb + uint64(i)*mb/60
+		if err := s.AddSample(stat); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p, err := s.Predict()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Samples < minPredictionSamples {
+		t.Errorf("expected at least %d samples, got %d", minPredictionSamples, p.Samples)
+	}
+
+	// Minute samples are a little over a minute apart.
+	if math.Abs(p.Memory.RatePerMinute-mb) > 0.2*mb {
+		t.Errorf("expected memory to grow by about 1MB per minute, got %v", p.Memory.RatePerMinute)
+	}
+	if p.Memory.Limit != 200*mb {
+		t.Errorf("expected memory limit %d, got %d", 200*mb, p.Memory.Limit)
+	}
+	if p.Memory.In15Minutes <= p.Memory.In5Minutes || p.Memory.In60Minutes <= p.Memory.In15Minutes {
+		t.Errorf("expected growing memory forecasts, got %+v", p.Memory)
+	}
+	if p.Memory.MinutesUntilLimit == nil {
+		t.Fatalf("expected minutes until the memory limit")
+	}
+	expected := float64(200*mb-p.Memory.Current) / p.Memory.RatePerMinute
+	if math.Abs(*p.Memory.MinutesUntilLimit-expected) > 1e-6 {
+		t.Errorf("expected %v minutes until the memory limit, got %v", expected, *p.Memory.MinutesUntilLimit)
+	}
+
+	if math.Abs(p.Cpu.RatePerMinute) > 1 {
+		t.Errorf("expected steady cpu usage, got a rate of %v", p.Cpu.RatePerMinute)
+	}
+	if p.Cpu.Limit != 500 {
+		t.Errorf("expected cpu limit of 500 millicores, got %d", p.Cpu.Limit)
+	}
+	if p.Cpu.MinutesUntilLimit != nil {
+		t.Errorf("expected no minutes until the cpu limit for steady usage, got %v", *p.Cpu.MinutesUntilLimit)
+	}
+}
@@ -52,6 +52,16 @@ type StatsSummary struct {
 	// Others updated every minute.
 	derivedStats info.DerivedStats // Guarded by dataLock.
 	dataLock     sync.RWMutex
+	// Growth rates of the usage over the minute samples. Guarded by dataLock.
+	trend usageTrend
+	// Limits of the container, 0 if unlimited. Cpu in cpu milliseconds/second.
+	cpuLimit    uint64
+	memoryLimit uint64
+	// Checks the latest usage for anomalies, if enabled.
+	anomalies *AnomalyDetector
+	// Whether the last AddSample rolled a minute window over, updating the
+	// derived stats.
+	rolledOver bool
 }
 
 // Adds a new seconds sample.
@@ -67,6 +77,7 @@ func (s *StatsSummary) AddSample(stat v1.ContainerStats) error {
 		sample.Memory = stat.Memory.WorkingSet
 	}
 	s.secondSamples = append(s.secondSamples, &sample)
+	s.rolledOver = false
 	s.updateLatestUsage()
 	// TODO(jnagal): Use 'available' to avoid unnecessary computation.
 	numSamples := len(s.secondSamples)
@@ -89,6 +100,7 @@ func (s *StatsSummary) AddSample(stat v1.ContainerStats) error {
 		if err != nil {
 			return err
 		}
+		s.rolledOver = true
 	}
 	return nil
 }
@@ -135,9 +147,11 @@ func (s *StatsSummary) updateDerivedStats() error {
 	}
 	derived.HourUsage = hourUsage
 	derived.DayUsage = dayUsage
+	trend := s.fitTrend()
 
 	s.dataLock.Lock()
 	defer s.dataLock.Unlock()
+	s.trend = trend
 	derived.LatestUsage = s.derivedStats.LatestUsage
 	s.derivedStats = derived
 
@@ -161,6 +175,36 @@ func (s *StatsSummary) getDerivedUsage(n int) (info.Usage, error) {
 	return usage, nil
 }
 
+// Enables the detection of anomalies in the latest usage, against baselines
+// of the given number of samples.
+func (s *StatsSummary) EnableAnomalyDetection(window int, threshold float64) {
+	s.anomalies = NewAnomalyDetector(window, threshold)
+}
+
+// Checks the usage of the latest sample for anomalies. Must be called after
+// each AddSample.
+func (s *StatsSummary) DetectAnomalies() []v1.AnomalyEventData {
+	if s.anomalies == nil || len(s.secondSamples) < 2 {
+		return nil
+	}
+	s.dataLock.RLock()
+	usage := s.derivedStats.LatestUsage
+	s.dataLock.RUnlock()
+
+	var anomalies []v1.AnomalyEventData
+	if s.available.Cpu {
+		if anomaly := s.anomalies.Observe(AnomalyMetricCpu, float64(usage.Cpu)); anomaly != nil {
+			anomalies = append(anomalies, *anomaly)
+		}
+	}
+	if s.available.Memory {
+		if anomaly := s.anomalies.Observe(AnomalyMetricMemory, float64(usage.Memory)); anomaly != nil {
+			anomalies = append(anomalies, *anomaly)
+		}
+	}
+	return anomalies
+}
+
 // Return the latest calculated derived stats.
 func (s *StatsSummary) DerivedStats() (info.DerivedStats, error) {
 	s.dataLock.RLock()
@@ -169,13 +213,30 @@ func (s *StatsSummary) DerivedStats() (info.DerivedStats, error) {
 	return s.derivedStats, nil
 }
 
+// Returns the derived stats if the last AddSample rolled a minute window
+// over, so that they can be written once per window.
+func (s *StatsSummary) RolledOverDerivedStats() (info.DerivedStats, bool) {
+	if !s.rolledOver {
+		return info.DerivedStats{}, false
+	}
+	s.dataLock.RLock()
+	defer s.dataLock.RUnlock()
+	return s.derivedStats, true
+}
+
 func New(spec v1.ContainerSpec) (*StatsSummary, error) {
 	summary := StatsSummary{}
 	if spec.HasCpu {
 		summary.available.Cpu = true
+		if spec.Cpu.Quota > 0 && spec.Cpu.Period > 0 {
+			summary.cpuLimit = spec.Cpu.Quota * 1000 / spec.Cpu.Period
+		}
 	}
 	if spec.HasMemory {
 		summary.available.Memory = true
+		if spec.Memory.Limit < unlimitedMemory {
+			summary.memoryLimit = spec.Memory.Limit
+		}
 	}
 	if !summary.available.Cpu && !summary.available.Memory {
 		return nil, fmt.Errorf("none of the resources are being tracked.")
@@ -0,0 +1,54 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/cadvisor/info/v1"
+)
+
+func TestRolledOverDerivedStats(t *testing.T) {
+	s, err := New(v1.ContainerSpec{HasCpu: true, HasMemory: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A minute window rolls over once the samples span more than a minute.
+	start := time.Now()
+	rollovers := 0
+	for i := 0; i <= 70; i += 10 {
+		stat := v1.ContainerStats{Timestamp: start.Add(time.Duration(i) * time.Second)}
+		stat.Cpu.Usage.Total = uint64(i) * uint64(time.Second)
+		stat.Memory.WorkingSet = 1024
+		if err := s.AddSample(stat); err != nil {
+			t.Fatal(err)
+		}
+		derived, ok := s.RolledOverDerivedStats()
+		if !ok {
+			continue
+		}
+		rollovers++
+		if i != 70 {
+			t.Errorf("expected the window to roll over at 70s, got %ds", i)
+		}
+		if !derived.MinuteUsage.Memory.Present || derived.MinuteUsage.Memory.Max != 1024 {
+			t.Errorf("expected the minute memory percentiles, got %+v", derived.MinuteUsage.Memory)
+		}
+	}
+	if rollovers != 1 {
+		t.Errorf("expected 1 rollover, got %d", rollovers)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package certs serves TLS certificates that are reloaded when their files change.
+package certs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/cadvisor/logging"
+)
+
+// How often the certificate files are checked for changes.
+const checkInterval = 10 * time.Second
+
+// Reloader provides the certificate of a key pair, reloading it when either
+// file is modified.
+type Reloader struct {
+	certFile string
+	keyFile  string
+
+	lock      sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+// NewReloader loads the key pair from the given PEM files.
+func NewReloader(certFile, keyFile string) (*Reloader, error) {
+	self := &Reloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+	}
+	modTime, err := self.latestModTime()
+	if err != nil {
+		return nil, err
+	}
+	if err := self.load(modTime); err != nil {
+		return nil, err
+	}
+	return self, nil
+}
+
+// Returns the latest modification time of the two files.
+func (self *Reloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, path := range []string{self.certFile, self.keyFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return latest, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (self *Reloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(self.certFile, self.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load key pair from %q and %q: %v", self.certFile, self.keyFile, err)
+	}
+	self.cert = &cert
+	self.modTime = modTime
+	return nil
+}
+
+// GetCertificate can be used as tls.Config.GetCertificate. A certificate that
+// fails to reload is logged and the previous one keeps being served.
+func (self *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	now := time.Now()
+	if now.Sub(self.lastCheck) < checkInterval {
+		return self.cert, nil
+	}
+	self.lastCheck = now
+	modTime, err := self.latestModTime()
+	if err != nil {
+		logging.Errorf("Failed to check TLS certificate for changes: %v", err)
+		return self.cert, nil
+	}
+	if !modTime.Equal(self.modTime) {
+		if err := self.load(modTime); err != nil {
+			logging.Errorf("Failed to reload TLS certificate: %v", err)
+		} else {
+			logging.Infof("Reloaded TLS certificate from %q", self.certFile)
+		}
+	}
+	return self.cert, nil
+}
+
+// NewServerConfig returns a TLS configuration serving the reloaded key pair.
+// If a client CA file is given, client certificates are requested and those
+// presented must be signed by one of its CAs.
+func NewServerConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	reloader, err := NewReloader(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	config := &tls.Config{
+		GetCertificate: reloader.GetCertificate,
+	}
+	if clientCAFile != "" {
+		pem, err := ioutil.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in client CA file %q", clientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return config, nil
+}
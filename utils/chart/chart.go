@@ -0,0 +1,268 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package chart renders line charts of time series as PNG images, without
+// any font or graphics dependency.
+package chart
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// Point is a value of a series at a time.
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// Series is a line of a chart.
+type Series struct {
+	Name   string
+	Color  color.Color
+	Points []Point
+}
+
+// Chart is a panel of line charts sharing a time axis and a value axis.
+type Chart struct {
+	Title  string
+	Series []Series
+	// Formats the values of the value axis. Defaults to FormatNumber.
+	Format func(float64) string
+}
+
+const (
+	// Size of a chart, its title and axes included, in pixels.
+	chartHeight = 200
+	// Size of the plot area of a chart.
+	marginLeft   = 80
+	marginRight  = 20
+	marginTop    = 40
+	marginBottom = 20
+	// Height of the title of the image.
+	headerHeight = 30
+	// Number of lines of the value axis grid.
+	gridLines = 4
+)
+
+var (
+	background = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	foreground = color.RGBA{0x33, 0x33, 0x33, 0xff}
+	gridColor  = color.RGBA{0xdd, 0xdd, 0xdd, 0xff}
+	axisColor  = color.RGBA{0x99, 0x99, 0x99, 0xff}
+)
+
+// Colors given to the series without one, in order.
+var Palette = []color.Color{
+	color.RGBA{0x33, 0x66, 0xcc, 0xff},
+	color.RGBA{0xdc, 0x39, 0x12, 0xff},
+	color.RGBA{0xff, 0x99, 0x00, 0xff},
+	color.RGBA{0x10, 0x96, 0x18, 0xff},
+	color.RGBA{0x99, 0x00, 0x99, 0xff},
+	color.RGBA{0x00, 0x99, 0xc6, 0xff},
+}
+
+type canvas struct {
+	*image.RGBA
+}
+
+func (self *canvas) fillRect(x, y, w, h int, c color.Color) {
+	for i := x; i < x+w; i++ {
+		for j := y; j < y+h; j++ {
+			self.Set(i, j, c)
+		}
+	}
+}
+
+// Draws a line between two points with Bresenham's algorithm.
+func (self *canvas) line(x0, y0, x1, y1 int, c color.Color) {
+	dx := abs(x1 - x0)
+	dy := -abs(y1 - y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		self.Set(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// Render draws the charts one under the other, under the title, and encodes
+// the image as a PNG.
+func Render(w io.Writer, title string, width int, charts []Chart) error {
+	if width < marginLeft+marginRight+1 {
+		return fmt.Errorf("width %d is too small", width)
+	}
+	img := &canvas{image.NewRGBA(image.Rect(0, 0, width, headerHeight+len(charts)*chartHeight))}
+	img.fillRect(0, 0, img.Bounds().Dx(), img.Bounds().Dy(), background)
+	img.text(marginLeft, (headerHeight-glyphHeight*2)/2, title, 2, foreground)
+	for i, c := range charts {
+		img.drawChart(image.Rect(0, headerHeight+i*chartHeight, width, headerHeight+(i+1)*chartHeight), &c)
+	}
+	return png.Encode(w, img)
+}
+
+func (self *canvas) drawChart(bounds image.Rectangle, c *Chart) {
+	format := c.Format
+	if format == nil {
+		format = FormatNumber
+	}
+	plot := image.Rect(bounds.Min.X+marginLeft, bounds.Min.Y+marginTop, bounds.Max.X-marginRight, bounds.Max.Y-marginBottom)
+
+	// Title and legend.
+	self.text(plot.Min.X, bounds.Min.Y+8, c.Title, 1, foreground)
+	x := plot.Min.X + textWidth(c.Title, 1) + 20
+	for i, s := range c.Series {
+		self.fillRect(x, bounds.Min.Y+8, glyphHeight, glyphHeight, seriesColor(s, i))
+		x += glyphHeight + 4
+		self.text(x, bounds.Min.Y+8, s.Name, 1, foreground)
+		x += textWidth(s.Name, 1) + 16
+	}
+
+	start, end, max := extent(c.Series)
+	// Leave some room over the highest value.
+	top := niceCeil(max * 1.1)
+
+	// Value axis grid.
+	for i := 0; i <= gridLines; i++ {
+		y := plot.Max.Y - i*plot.Dy()/gridLines
+		lineColor := gridColor
+		if i == 0 {
+			lineColor = axisColor
+		}
+		self.line(plot.Min.X, y, plot.Max.X, y, lineColor)
+		label := format(top * float64(i) / gridLines)
+		self.text(plot.Min.X-8-textWidth(label, 1), y-glyphHeight/2, label, 1, foreground)
+	}
+	self.line(plot.Min.X, plot.Min.Y, plot.Min.X, plot.Max.Y, axisColor)
+
+	// Time axis.
+	if !start.IsZero() {
+		self.text(plot.Min.X, plot.Max.Y+6, start.UTC().Format("15:04:05"), 1, foreground)
+		label := end.UTC().Format("15:04:05 MST")
+		self.text(plot.Max.X-textWidth(label, 1), plot.Max.Y+6, label, 1, foreground)
+	}
+
+	span := end.Sub(start)
+	if span <= 0 {
+		span = time.Second
+	}
+	position := func(p Point) (int, int) {
+		x := plot.Min.X + int(float64(plot.Dx())*float64(p.Time.Sub(start))/float64(span))
+		y := plot.Max.Y - int(float64(plot.Dy())*p.Value/top)
+		return x, y
+	}
+	for i, s := range c.Series {
+		lineColor := seriesColor(s, i)
+		for j := 1; j < len(s.Points); j++ {
+			x0, y0 := position(s.Points[j-1])
+			x1, y1 := position(s.Points[j])
+			self.line(x0, y0, x1, y1, lineColor)
+			// Thicken the line.
+			self.line(x0, y0-1, x1, y1-1, lineColor)
+		}
+	}
+}
+
+func seriesColor(s Series, i int) color.Color {
+	if s.Color != nil {
+		return s.Color
+	}
+	return Palette[i%len(Palette)]
+}
+
+// Returns the time range of the series and their highest value.
+func extent(series []Series) (start, end time.Time, max float64) {
+	for _, s := range series {
+		for _, p := range s.Points {
+			if start.IsZero() || p.Time.Before(start) {
+				start = p.Time
+			}
+			if p.Time.After(end) {
+				end = p.Time
+			}
+			if p.Value > max {
+				max = p.Value
+			}
+		}
+	}
+	return start, end, max
+}
+
+// Rounds the value up to 1, 2 or 5 times a power of 10, at least 1e-3.
+func niceCeil(value float64) float64 {
+	if value <= 1e-3 {
+		return 1e-3
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(value)))
+	for _, step := range []float64{1, 2, 5, 10} {
+		if value <= step*magnitude {
+			return step * magnitude
+		}
+	}
+	return 10 * magnitude
+}
+
+// FormatNumber formats a value with up to 2 decimals.
+func FormatNumber(value float64) string {
+	return trimZeros(fmt.Sprintf("%.2f", value))
+}
+
+// FormatBytes formats a number of bytes with binary prefixes.
+func FormatBytes(value float64) string {
+	units := []string{"B", "KIB", "MIB", "GIB", "TIB"}
+	i := 0
+	for value >= 1024 && i < len(units)-1 {
+		value /= 1024
+		i++
+	}
+	return trimZeros(fmt.Sprintf("%.1f", value)) + " " + units[i]
+}
+
+// Removes the trailing zeros of the decimals.
+func trimZeros(s string) string {
+	if !strings.Contains(s, ".") {
+		return s
+	}
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
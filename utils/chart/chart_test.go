@@ -0,0 +1,65 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chart
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRender(t *testing.T) {
+	start := time.Unix(1000, 0)
+	var points []Point
+	for i := 0; i < 60; i++ {
+		points = append(points, Point{Time: start.Add(time.Duration(i) * time.Second), Value: float64(i % 7)})
+	}
+	var out bytes.Buffer
+	err := Render(&out, "/docker/test", 640, []Chart{
+		{Title: "CPU", Series: []Series{{Name: "total", Points: points}}},
+		{Title: "Empty", Format: FormatBytes},
+	})
+	assert.Nil(t, err)
+
+	img, err := png.Decode(&out)
+	assert.Nil(t, err)
+	assert.Equal(t, 640, img.Bounds().Dx())
+	assert.Equal(t, headerHeight+2*chartHeight, img.Bounds().Dy())
+	// The series is drawn in the first color of the palette.
+	found := false
+	for x := marginLeft; x < 640-marginRight && !found; x++ {
+		for y := headerHeight + marginTop; y < headerHeight+chartHeight-marginBottom && !found; y++ {
+			found = img.At(x, y) == Palette[0]
+		}
+	}
+	assert.True(t, found)
+
+	assert.NotNil(t, Render(&out, "", 10, nil))
+}
+
+func TestFormat(t *testing.T) {
+	assert.Equal(t, "1.5", FormatNumber(1.5))
+	assert.Equal(t, "2", FormatNumber(2))
+	assert.Equal(t, "0.01", FormatNumber(0.0123))
+	assert.Equal(t, "512 B", FormatBytes(512))
+	assert.Equal(t, "1.5 KIB", FormatBytes(1536))
+	assert.Equal(t, "3 GIB", FormatBytes(3*1024*1024*1024))
+	assert.Equal(t, 5.0, niceCeil(4.2))
+	assert.Equal(t, 200.0, niceCeil(110))
+	assert.Equal(t, 1e-3, niceCeil(0))
+}
@@ -34,9 +34,12 @@ func onAzure() bool {
 	return strings.Contains(string(data), MicrosoftCorporation)
 }
 
-//TODO: Implement method.
 func getAzureInstanceType() info.InstanceType {
-	return info.UnknownInstance
+	compute, err := getAzureCompute()
+	if err != nil || compute.VMSize == "" {
+		return info.UnknownInstance
+	}
+	return info.InstanceType(compute.VMSize)
 }
 
 func getAzureInstanceID() info.InstanceID {
@@ -24,25 +24,50 @@ type CloudInfo interface {
 	GetCloudProvider() info.CloudProvider
 	GetInstanceType() info.InstanceType
 	GetInstanceID() info.InstanceID
+	// Zone and region of the instance, empty if unknown.
+	GetZone() string
+	GetRegion() string
+	// Tags of the instance, e.g. the instance tags exposed by the metadata
+	// service of AWS or the network tags of GCE.
+	GetTags() map[string]string
 }
 
 type realCloudInfo struct {
 	cloudProvider info.CloudProvider
 	instanceType  info.InstanceType
 	instanceID    info.InstanceID
+	zone          string
+	region        string
+	tags          map[string]string
 }
 
 func NewRealCloudInfo() CloudInfo {
 	cloudProvider := detectCloudProvider()
 	instanceType := detectInstanceType(cloudProvider)
 	instanceID := detectInstanceID(cloudProvider)
+	zone, region, tags := detectPlacement(cloudProvider)
 	return &realCloudInfo{
 		cloudProvider: cloudProvider,
 		instanceType:  instanceType,
 		instanceID:    instanceID,
+		zone:          zone,
+		region:        region,
+		tags:          tags,
 	}
 }
 
+func (self *realCloudInfo) GetZone() string {
+	return self.zone
+}
+
+func (self *realCloudInfo) GetRegion() string {
+	return self.region
+}
+
+func (self *realCloudInfo) GetTags() map[string]string {
+	return self.tags
+}
+
 func (self *realCloudInfo) GetCloudProvider() info.CloudProvider {
 	return self.cloudProvider
 }
@@ -0,0 +1,148 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudinfo
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/ec2metadata"
+	"github.com/aws/aws-sdk-go/aws/session"
+	info "github.com/google/cadvisor/info/v1"
+
+	"google.golang.org/cloud/compute/metadata"
+)
+
+const azureMetadataURL = "http://169.254.169.254/metadata/instance/compute?api-version=2017-08-01"
+
+// Returns the zone, region and tags of the instance.
+func detectPlacement(cloudProvider info.CloudProvider) (string, string, map[string]string) {
+	switch cloudProvider {
+	case info.GCE:
+		return getGcePlacement()
+	case info.AWS:
+		return getAwsPlacement()
+	case info.Azure:
+		return getAzurePlacement()
+	}
+	return "", "", nil
+}
+
+func getGcePlacement() (string, string, map[string]string) {
+	// The zone is returned as projects/<project number>/zones/<zone>.
+	zone, err := metadata.Get("instance/zone")
+	if err != nil {
+		return "", "", nil
+	}
+	zone = zone[strings.LastIndex(zone, "/")+1:]
+	var tags map[string]string
+	if networkTags, err := metadata.InstanceTags(); err == nil && len(networkTags) > 0 {
+		// Network tags have no value.
+		tags = make(map[string]string, len(networkTags))
+		for _, tag := range networkTags {
+			tags[tag] = ""
+		}
+	}
+	return zone, regionOfZone(zone), tags
+}
+
+func getAwsPlacement() (string, string, map[string]string) {
+	client := ec2metadata.New(session.New(&aws.Config{MaxRetries: aws.Int(0)}))
+	if client.Config.HTTPClient != nil {
+		client.Config.HTTPClient.Timeout = 2 * time.Second
+	}
+	zone, err := client.GetMetadata("placement/availability-zone")
+	if err != nil {
+		return "", "", nil
+	}
+	// The instance tags are only exposed when enabled on the instance.
+	var tags map[string]string
+	if keys, err := client.GetMetadata("tags/instance"); err == nil {
+		tags = make(map[string]string)
+		for _, key := range strings.Fields(keys) {
+			if value, err := client.GetMetadata("tags/instance/" + key); err == nil {
+				tags[key] = value
+			}
+		}
+	}
+	// Zones are the region with a letter suffix, e.g. us-east-1a.
+	return zone, strings.TrimRight(zone, "abcdefghijklmnopqrstuvwxyz"), tags
+}
+
+// The compute metadata returned by the Azure instance metadata service.
+type azureCompute struct {
+	Location string `json:"location"`
+	Zone     string `json:"zone"`
+	VMSize   string `json:"vmSize"`
+	// Tags as <name>:<value> separated by semicolons.
+	Tags string `json:"tags"`
+}
+
+func getAzureCompute() (*azureCompute, error) {
+	req, err := http.NewRequest("GET", azureMetadataURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata", "true")
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var compute azureCompute
+	if err := json.NewDecoder(resp.Body).Decode(&compute); err != nil {
+		return nil, err
+	}
+	return &compute, nil
+}
+
+func getAzurePlacement() (string, string, map[string]string) {
+	compute, err := getAzureCompute()
+	if err != nil {
+		return "", "", nil
+	}
+	return compute.Zone, compute.Location, parseAzureTags(compute.Tags)
+}
+
+func parseAzureTags(tags string) map[string]string {
+	if tags == "" {
+		return nil
+	}
+	parsed := make(map[string]string)
+	for _, tag := range strings.Split(tags, ";") {
+		kv := strings.SplitN(tag, ":", 2)
+		if kv[0] == "" {
+			continue
+		}
+		if len(kv) == 2 {
+			parsed[kv[0]] = kv[1]
+		} else {
+			parsed[kv[0]] = ""
+		}
+	}
+	return parsed
+}
+
+// Returns the region of a zone named <region>-<suffix>, e.g. us-central1-a.
+func regionOfZone(zone string) string {
+	if i := strings.LastIndex(zone, "-"); i > 0 {
+		return zone[:i]
+	}
+	return zone
+}
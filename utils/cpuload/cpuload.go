@@ -19,8 +19,9 @@ import (
 
 	info "github.com/google/cadvisor/info/v1"
 
-	"github.com/golang/glog"
+	"github.com/google/cadvisor/logging"
 	"github.com/google/cadvisor/utils/cpuload/netlink"
+	"github.com/google/cadvisor/utils/cpuload/procfs"
 )
 
 type CpuLoadReader interface {
@@ -36,11 +37,18 @@ type CpuLoadReader interface {
 	GetCpuLoad(name string, path string) (info.LoadStats, error)
 }
 
+// Returns a reader using the taskstats netlink interface, or sampling the task
+// states from /proc when netlink is unavailable, e.g. in user namespaces.
 func New() (CpuLoadReader, error) {
 	reader, err := netlink.New()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create a netlink based cpuload reader: %v", err)
+	if err == nil {
+		logging.V(3).Info("Using a netlink-based load reader")
+		return reader, nil
 	}
-	glog.V(3).Info("Using a netlink-based load reader")
-	return reader, nil
+	procReader, procErr := procfs.New("/proc")
+	if procErr != nil {
+		return nil, fmt.Errorf("failed to create a netlink based cpuload reader: %v, and a procfs based one: %v", err, procErr)
+	}
+	logging.V(3).Infof("Using a procfs-based load reader, netlink is unavailable: %v", err)
+	return procReader, nil
 }
@@ -12,6 +12,8 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// +build linux
+
 package netlink
 
 import (
@@ -22,7 +24,7 @@ import (
 	"os"
 	"syscall"
 
-	"github.com/golang/glog"
+	"github.com/google/cadvisor/logging"
 )
 
 type Connection struct {
@@ -55,7 +57,7 @@ func newConnection() (*Connection, error) {
 		syscall.Close(fd)
 		return nil, err
 	}
-	glog.V(4).Infof("New Netlink connection: %+v", conn)
+	logging.V(4).Infof("New Netlink connection: %+v", conn)
 	return conn, err
 }
 
@@ -94,7 +96,7 @@ func (self *Connection) ReadMessage() (msg syscall.NetlinkMessage, err error) {
 		return msg, err
 	}
 	if msg.Header.Len == 0 {
-		glog.Errorf("Unexpected netlink header: %+v", msg.Header)
+		logging.Errorf("Unexpected netlink header: %+v", msg.Header)
 		return msg, errors.New("Unexpected netlink header")
 	}
 	msg.Data = make([]byte, msg.Header.Len-syscall.NLMSG_HDRLEN)
@@ -12,6 +12,8 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// +build linux
+
 package netlink
 
 import (
@@ -19,8 +21,7 @@ import (
 	"os"
 
 	info "github.com/google/cadvisor/info/v1"
-
-	"github.com/golang/glog"
+	"github.com/google/cadvisor/logging"
 )
 
 type NetlinkReader struct {
@@ -38,7 +39,7 @@ func New() (*NetlinkReader, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get netlink family id for task stats: %s", err)
 	}
-	glog.V(4).Infof("Family id for taskstats: %d", id)
+	logging.V(4).Infof("Family id for taskstats: %d", id)
 	return &NetlinkReader{
 		familyId: id,
 		conn:     conn,
@@ -75,6 +76,6 @@ func (self *NetlinkReader) GetCpuLoad(name string, path string) (info.LoadStats,
 	if err != nil {
 		return info.LoadStats{}, err
 	}
-	glog.V(4).Infof("Task stats for %q: %+v", path, stats)
+	logging.V(4).Infof("Task stats for %q: %+v", path, stats)
 	return stats, nil
 }
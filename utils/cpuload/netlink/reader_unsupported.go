@@ -0,0 +1,43 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !linux
+
+package netlink
+
+import (
+	"fmt"
+
+	info "github.com/google/cadvisor/info/v1"
+)
+
+// NetlinkReader reads the load of cgroups using the taskstats netlink
+// interface, which only exists on Linux.
+type NetlinkReader struct{}
+
+// New returns an error: the taskstats netlink interface only exists on Linux.
+func New() (*NetlinkReader, error) {
+	return nil, fmt.Errorf("the taskstats netlink interface is only supported on Linux")
+}
+
+func (self *NetlinkReader) Stop() {
+}
+
+func (self *NetlinkReader) Start() error {
+	return nil
+}
+
+func (self *NetlinkReader) GetCpuLoad(name string, path string) (info.LoadStats, error) {
+	return info.LoadStats{}, fmt.Errorf("the taskstats netlink interface is only supported on Linux")
+}
@@ -0,0 +1,116 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Cpu load reader sampling the states of the tasks of cgroups from /proc, for
+// kernels without the taskstats netlink interface.
+package procfs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
+)
+
+type ProcReader struct {
+	// Root of the procfs of the pid namespace of the cgroups.
+	procRoot string
+}
+
+func New(procRoot string) (*ProcReader, error) {
+	if _, err := os.Stat(path.Join(procRoot, "self", "stat")); err != nil {
+		return nil, fmt.Errorf("procfs is not available at %q: %v", procRoot, err)
+	}
+	return &ProcReader{
+		procRoot: procRoot,
+	}, nil
+}
+
+func (self *ProcReader) Start() error {
+	return nil
+}
+
+func (self *ProcReader) Stop() {}
+
+// Returns the instantaneous number of tasks of the processes in the group by
+// state, sampled from /proc/<pid>/task/<tid>/stat. Tasks waiting on IO are
+// counted as uninterruptible.
+// cgroupPath is an absolute filesystem path for a container under the CPU cgroup hierarchy.
+// NOTE: non-hierarchical load is returned. It does not include load for subcontainers.
+func (self *ProcReader) GetCpuLoad(name string, cgroupPath string) (info.LoadStats, error) {
+	if len(cgroupPath) == 0 {
+		return info.LoadStats{}, fmt.Errorf("cgroup path can not be empty!")
+	}
+	procs, err := ioutil.ReadFile(path.Join(cgroupPath, "cgroup.procs"))
+	if err != nil {
+		return info.LoadStats{}, fmt.Errorf("failed to read the processes of cgroup %s: %v", cgroupPath, err)
+	}
+
+	stats := info.LoadStats{}
+	scanner := bufio.NewScanner(bytes.NewReader(procs))
+	for scanner.Scan() {
+		pid := strings.TrimSpace(scanner.Text())
+		if pid == "" {
+			continue
+		}
+		taskDir := path.Join(self.procRoot, pid, "task")
+		tids, err := ioutil.ReadDir(taskDir)
+		if err != nil {
+			// The process exited.
+			continue
+		}
+		for _, tid := range tids {
+			state, err := readTaskState(path.Join(taskDir, tid.Name(), "stat"))
+			if err != nil {
+				continue
+			}
+			addTaskState(&stats, state)
+		}
+	}
+	logging.V(4).Infof("Task stats for %q: %+v", cgroupPath, stats)
+	return stats, nil
+}
+
+// Returns the state of the task, the field following the command name in
+// parentheses, which may contain spaces.
+func readTaskState(statPath string) (byte, error) {
+	stat, err := ioutil.ReadFile(statPath)
+	if err != nil {
+		return 0, err
+	}
+	end := bytes.LastIndexByte(stat, ')')
+	if end < 0 || end+2 >= len(stat) {
+		return 0, fmt.Errorf("malformed stat file %q", statPath)
+	}
+	return stat[end+2], nil
+}
+
+func addTaskState(stats *info.LoadStats, state byte) {
+	switch state {
+	case 'R':
+		stats.NrRunning++
+	case 'S', 'I':
+		stats.NrSleeping++
+	case 'D':
+		stats.NrUninterruptible++
+	case 'T', 't':
+		stats.NrStopped++
+	}
+}
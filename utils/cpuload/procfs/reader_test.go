@@ -0,0 +1,66 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package procfs
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	info "github.com/google/cadvisor/info/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, file, content string) {
+	require.NoError(t, os.MkdirAll(path.Dir(file), 0755))
+	require.NoError(t, ioutil.WriteFile(file, []byte(content), 0644))
+}
+
+func TestGetCpuLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "procfs")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	procRoot := path.Join(dir, "proc")
+	cgroup := path.Join(dir, "cgroup")
+	writeFile(t, path.Join(procRoot, "self", "stat"), "1 (cadvisor) S 0")
+	// Pid 30 exited after the cgroup was read.
+	writeFile(t, path.Join(cgroup, "cgroup.procs"), "10\n20\n30\n")
+	writeFile(t, path.Join(procRoot, "10", "task", "10", "stat"), "10 (web server) R 1 10")
+	writeFile(t, path.Join(procRoot, "10", "task", "11", "stat"), "11 (web) (worker) S 1 10")
+	writeFile(t, path.Join(procRoot, "10", "task", "12", "stat"), "12 (web) D 1 10")
+	writeFile(t, path.Join(procRoot, "20", "task", "20", "stat"), "20 (batch) T 1 20")
+
+	reader, err := New(procRoot)
+	require.NoError(t, err)
+	stats, err := reader.GetCpuLoad("/test", cgroup)
+	require.NoError(t, err)
+	assert.Equal(t, info.LoadStats{
+		NrRunning:         1,
+		NrSleeping:        1,
+		NrUninterruptible: 1,
+		NrStopped:         1,
+	}, stats)
+
+	_, err = reader.GetCpuLoad("/missing", path.Join(dir, "missing"))
+	assert.Error(t, err)
+}
+
+func TestNewWithoutProcfs(t *testing.T) {
+	_, err := New("/nonexistent")
+	assert.Error(t, err)
+}
@@ -0,0 +1,98 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package machine
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/cadvisor/info/v2"
+)
+
+// GetHostStats reads the node-wide kernel statistics from the proc
+// filesystem under rootFs. The statistics of the network, conntrack and
+// ARP, are those of the network namespace of cAdvisor.
+func GetHostStats(rootFs string) (v2.HostStats, error) {
+	stats := v2.HostStats{Timestamp: time.Now()}
+	procPath := func(name string) string {
+		return filepath.Join(rootFs, "/proc", name)
+	}
+	var err error
+	if stats.EntropyAvailable, err = readUintFile(procPath("sys/kernel/random/entropy_avail")); err != nil {
+		return stats, err
+	}
+	// Allocated, free (always 0 since 2.6) and max file handles.
+	out, err := ioutil.ReadFile(procPath("sys/fs/file-nr"))
+	if err == nil {
+		fields := strings.Fields(string(out))
+		if len(fields) != 3 {
+			return stats, fmt.Errorf("unexpected file-nr %q", string(out))
+		}
+		allocated, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return stats, fmt.Errorf("failed to parse file-nr %q: %v", string(out), err)
+		}
+		max, err := strconv.ParseUint(fields[2], 10, 64)
+		if err != nil {
+			return stats, fmt.Errorf("failed to parse file-nr %q: %v", string(out), err)
+		}
+		stats.FileHandlesAllocated = &allocated
+		stats.FileHandlesMax = &max
+	} else if !os.IsNotExist(err) {
+		return stats, err
+	}
+	// Only reported when the conntrack module is loaded.
+	if stats.ConntrackEntries, err = readUintFile(procPath("sys/net/netfilter/nf_conntrack_count")); err != nil {
+		return stats, err
+	}
+	if stats.ConntrackMax, err = readUintFile(procPath("sys/net/netfilter/nf_conntrack_max")); err != nil {
+		return stats, err
+	}
+	out, err = ioutil.ReadFile(procPath("net/arp"))
+	if err == nil {
+		// One line per entry after the header.
+		entries := uint64(0)
+		for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n")[1:] {
+			if strings.TrimSpace(line) != "" {
+				entries++
+			}
+		}
+		stats.ArpEntries = &entries
+	} else if !os.IsNotExist(err) {
+		return stats, err
+	}
+	return stats, nil
+}
+
+// Reads a file holding a single unsigned integer, nil if it doesn't exist.
+func readUintFile(path string) (*uint64, error) {
+	out, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	value, err := strconv.ParseUint(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %q: %v", path, err)
+	}
+	return &value, nil
+}
@@ -0,0 +1,140 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package machine
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/google/cadvisor/info/v2"
+)
+
+// Writes the files, keyed by path relative to dir.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestGetHostStats(t *testing.T) {
+	rootFs, err := ioutil.TempDir("", "hoststats")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootFs)
+	writeFiles(t, filepath.Join(rootFs, "proc"), map[string]string{
+		"sys/kernel/random/entropy_avail": "3754\n",
+		"sys/fs/file-nr":                  "2272\t0\t9223372036854775807\n",
+		"net/arp":                         "IP address       HW type     Flags       HW address            Mask     Device\n10.0.0.1         0x1         0x2         52:54:00:12:35:02     *        eth0\n10.0.0.2         0x1         0x2         52:54:00:12:35:03     *        eth0\n",
+	})
+
+	stats, err := GetHostStats(rootFs)
+	if err != nil {
+		t.Fatalf("failed to get host stats: %v", err)
+	}
+	if stats.EntropyAvailable == nil || *stats.EntropyAvailable != 3754 {
+		t.Errorf("expected 3754 bits of entropy, got %v", stats.EntropyAvailable)
+	}
+	if stats.FileHandlesAllocated == nil || *stats.FileHandlesAllocated != 2272 || *stats.FileHandlesMax != 9223372036854775807 {
+		t.Errorf("unexpected file handles %v/%v", stats.FileHandlesAllocated, stats.FileHandlesMax)
+	}
+	if stats.ArpEntries == nil || *stats.ArpEntries != 2 {
+		t.Errorf("expected 2 ARP entries, got %v", stats.ArpEntries)
+	}
+	// Conntrack isn't loaded.
+	if stats.ConntrackEntries != nil || stats.ConntrackMax != nil {
+		t.Errorf("expected no conntrack stats, got %v/%v", stats.ConntrackEntries, stats.ConntrackMax)
+	}
+}
+
+func TestTemperaturesAndEnergy(t *testing.T) {
+	dir, err := ioutil.TempDir("", "power")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(hwmon, rapl string) { hwmonDir, raplDir = hwmon, rapl }(hwmonDir, raplDir)
+	hwmonDir = filepath.Join(dir, "hwmon")
+	raplDir = filepath.Join(dir, "powercap")
+	writeFiles(t, dir, map[string]string{
+		"hwmon/hwmon0/name":                         "coretemp\n",
+		"hwmon/hwmon0/temp1_input":                  "45000\n",
+		"hwmon/hwmon0/temp1_label":                  "Package id 0\n",
+		"hwmon/hwmon0/temp2_input":                  "43500\n",
+		"powercap/intel-rapl:0/name":                "package-0\n",
+		"powercap/intel-rapl:0/energy_uj":           "1500000\n",
+		"powercap/intel-rapl:0/max_energy_range_uj": "262143328850\n",
+		"powercap/intel-rapl:0:0/name":              "dram\n",
+		"powercap/intel-rapl:0:0/energy_uj":         "250000\n",
+		"powercap/intel-rapl-mmio:0/name":           "package-0\n",
+	})
+
+	temperatures, err := GetTemperatures()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedTemperatures := []v2.Temperature{
+		{Sensor: "coretemp", Label: "Package id 0", Celsius: 45},
+		{Sensor: "coretemp", Label: "temp2", Celsius: 43.5},
+	}
+	if !reflect.DeepEqual(temperatures, expectedTemperatures) {
+		t.Errorf("expected temperatures %+v, got %+v", expectedTemperatures, temperatures)
+	}
+
+	energy, err := GetEnergy()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedEnergy := []v2.EnergyCounter{
+		{Zone: "intel-rapl:0", Domain: "package-0", Joules: 1.5, MaxJoules: 262143.32885},
+		{Zone: "intel-rapl:0:0", Domain: "dram", Joules: 0.25},
+	}
+	if !reflect.DeepEqual(energy, expectedEnergy) {
+		t.Errorf("expected energy %+v, got %+v", expectedEnergy, energy)
+	}
+}
+
+func TestPackagePower(t *testing.T) {
+	previous := []v2.EnergyCounter{
+		{Zone: "intel-rapl:0", Domain: "package-0", Joules: 100, MaxJoules: 1000},
+		{Zone: "intel-rapl:0:0", Domain: "dram", Joules: 10, MaxJoules: 1000},
+		{Zone: "intel-rapl:1", Domain: "package-1", Joules: 990, MaxJoules: 1000},
+	}
+	current := []v2.EnergyCounter{
+		{Zone: "intel-rapl:0", Domain: "package-0", Joules: 150, MaxJoules: 1000},
+		{Zone: "intel-rapl:0:0", Domain: "dram", Joules: 30, MaxJoules: 1000},
+		// Wrapped around.
+		{Zone: "intel-rapl:1", Domain: "package-1", Joules: 20, MaxJoules: 1000},
+	}
+	power, err := PackagePower(previous, current, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if power != 8 {
+		t.Errorf("expected 8 watts, got %v", power)
+	}
+	if _, err := PackagePower(previous, current, 0); err == nil {
+		t.Errorf("expected readings at the same time to fail")
+	}
+}
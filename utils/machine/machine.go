@@ -23,14 +23,12 @@ import (
 
 	// s390/s390x changes
 	"runtime"
-	"syscall"
 
 	info "github.com/google/cadvisor/info/v1"
+	"github.com/google/cadvisor/logging"
 	"github.com/google/cadvisor/utils"
 	"github.com/google/cadvisor/utils/sysfs"
 	"github.com/google/cadvisor/utils/sysinfo"
-
-	"github.com/golang/glog"
 )
 
 // The utils/machine package contains functions that extract machine-level specs.
@@ -191,7 +189,7 @@ func GetTopology(sysFs sysfs.SysFs, cpuinfo string) ([]info.Node, int, error) {
 	for idx, node := range nodes {
 		caches, err := sysinfo.GetCacheInfo(sysFs, node.Cores[0].Threads[0])
 		if err != nil {
-			glog.Errorf("failed to get cache information for node %d: %v", node.Id, err)
+			logging.Errorf("failed to get cache information for node %d: %v", node.Id, err)
 			continue
 		}
 		numThreadsPerCore := len(node.Cores[0].Threads)
@@ -215,6 +213,45 @@ func GetTopology(sysFs sysfs.SysFs, cpuinfo string) ([]info.Node, int, error) {
 	return nodes, numCores, nil
 }
 
+// GetCoreCounts returns the number of sockets, of physical cores and of
+// threads per core of the topology. Threads per core is the largest number
+// of threads of a core.
+func GetCoreCounts(topology []info.Node) (sockets, physicalCores, threadsPerCore int) {
+	for _, node := range topology {
+		sockets++
+		physicalCores += len(node.Cores)
+		for _, core := range node.Cores {
+			if len(core.Threads) > threadsPerCore {
+				threadsPerCore = len(core.Threads)
+			}
+		}
+	}
+	return sockets, physicalCores, threadsPerCore
+}
+
+// GetNumaTopology returns the NUMA nodes of the machine, none if the kernel
+// doesn't report them.
+func GetNumaTopology(sysFs sysfs.SysFs) ([]info.NumaNode, error) {
+	ids, err := sysFs.GetNumaNodes()
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]info.NumaNode, 0, len(ids))
+	for _, id := range ids {
+		nodeInfo, err := sysFs.GetNumaNodeInfo(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get information of NUMA node %d: %v", id, err)
+		}
+		nodes = append(nodes, info.NumaNode{
+			Id:        id,
+			Memory:    nodeInfo.Memory,
+			Cpus:      nodeInfo.Cpus,
+			Distances: nodeInfo.Distances,
+		})
+	}
+	return nodes, nil
+}
+
 func extractValue(s string, r *regexp.Regexp) (bool, int, error) {
 	matches := r.FindSubmatch([]byte(s))
 	if len(matches) == 2 {
@@ -264,22 +301,6 @@ func addNode(nodes *[]info.Node, id int) (int, error) {
 	return idx, nil
 }
 
-// s390/s390x changes
-func getMachineArch() (string, error) {
-	uname := syscall.Utsname{}
-	err := syscall.Uname(&uname)
-	if err != nil {
-		return "", err
-	}
-
-	var arch string
-	for _, val := range uname.Machine {
-		arch += string(int(val))
-	}
-
-	return arch, nil
-}
-
 // aarch64 changes
 func isAArch64() bool {
 	arch, err := getMachineArch()
@@ -0,0 +1,163 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package machine
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/cadvisor/info/v2"
+)
+
+var (
+	hwmonDir = "/sys/class/hwmon"
+	raplDir  = "/sys/class/powercap"
+
+	tempInputRegexp = regexp.MustCompile(`^temp([0-9]+)_input$`)
+	// Top level RAPL zones and their subzones, e.g. intel-rapl:0:1.
+	raplZoneRegexp = regexp.MustCompile(`^intel-rapl:[0-9]+(:[0-9]+)?$`)
+)
+
+// GetTemperatures returns the temperatures of the hwmon sensors, e.g. of the
+// cpu packages and cores with coretemp or k10temp.
+func GetTemperatures() ([]v2.Temperature, error) {
+	sensors, err := ioutil.ReadDir(hwmonDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var temperatures []v2.Temperature
+	for _, sensor := range sensors {
+		sensorPath := filepath.Join(hwmonDir, sensor.Name())
+		name := readTrimmed(filepath.Join(sensorPath, "name"))
+		inputs, err := ioutil.ReadDir(sensorPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, input := range inputs {
+			matches := tempInputRegexp.FindStringSubmatch(input.Name())
+			if len(matches) != 2 {
+				continue
+			}
+			millidegrees, err := readUintFile(filepath.Join(sensorPath, input.Name()))
+			if err != nil || millidegrees == nil {
+				// Some sensors fail to read when not connected.
+				continue
+			}
+			label := readTrimmed(filepath.Join(sensorPath, "temp"+matches[1]+"_label"))
+			if label == "" {
+				label = "temp" + matches[1]
+			}
+			temperatures = append(temperatures, v2.Temperature{
+				Sensor:  name,
+				Label:   label,
+				Celsius: float64(*millidegrees) / 1000,
+			})
+		}
+	}
+	return temperatures, nil
+}
+
+// GetEnergy returns the cumulative energy counters of the RAPL zones, e.g.
+// "package-0" and its "dram" subzone, sorted by zone.
+func GetEnergy() ([]v2.EnergyCounter, error) {
+	zones, err := ioutil.ReadDir(raplDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var counters []v2.EnergyCounter
+	for _, zone := range zones {
+		if !raplZoneRegexp.MatchString(zone.Name()) {
+			continue
+		}
+		zonePath := filepath.Join(raplDir, zone.Name())
+		energy, err := readUintFile(filepath.Join(zonePath, "energy_uj"))
+		if os.IsPermission(err) {
+			// Only readable by root on recent kernels.
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if energy == nil {
+			continue
+		}
+		counter := v2.EnergyCounter{
+			Zone:   zone.Name(),
+			Domain: readTrimmed(filepath.Join(zonePath, "name")),
+			Joules: float64(*energy) / 1e6,
+		}
+		if max, err := readUintFile(filepath.Join(zonePath, "max_energy_range_uj")); err == nil && max != nil {
+			counter.MaxJoules = float64(*max) / 1e6
+		}
+		counters = append(counters, counter)
+	}
+	sort.Sort(energyCountersByZone(counters))
+	return counters, nil
+}
+
+// PackagePower returns the power in watts of the cpu packages between two
+// readings of the energy counters taken seconds apart, accounting for the
+// wraparound of the counters.
+func PackagePower(previous, current []v2.EnergyCounter, seconds float64) (float64, error) {
+	if seconds <= 0 {
+		return 0, fmt.Errorf("readings must be apart in time, got %v seconds", seconds)
+	}
+	last := make(map[string]v2.EnergyCounter, len(previous))
+	for _, counter := range previous {
+		last[counter.Zone] = counter
+	}
+	joules := 0.0
+	for _, counter := range current {
+		if !strings.HasPrefix(counter.Domain, "package") {
+			continue
+		}
+		before, ok := last[counter.Zone]
+		if !ok {
+			return 0, fmt.Errorf("zone %q missing from the previous reading", counter.Zone)
+		}
+		delta := counter.Joules - before.Joules
+		if delta < 0 {
+			delta += counter.MaxJoules
+		}
+		joules += delta
+	}
+	return joules / seconds, nil
+}
+
+type energyCountersByZone []v2.EnergyCounter
+
+func (self energyCountersByZone) Len() int           { return len(self) }
+func (self energyCountersByZone) Swap(i, j int)      { self[i], self[j] = self[j], self[i] }
+func (self energyCountersByZone) Less(i, j int) bool { return self[i].Zone < self[j].Zone }
+
+// Returns the trimmed content of the file, empty if it can't be read.
+func readTrimmed(path string) string {
+	out, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
@@ -115,3 +115,57 @@ func TestTopologyEmptyCpuinfo(t *testing.T) {
 		t.Errorf("Expected empty cpuinfo to fail.")
 	}
 }
+
+func TestCoreCounts(t *testing.T) {
+	topology := []info.Node{
+		{Id: 0, Cores: []info.Core{{Id: 0, Threads: []int{0, 2}}, {Id: 1, Threads: []int{1, 3}}}},
+		{Id: 1, Cores: []info.Core{{Id: 0, Threads: []int{4, 6}}, {Id: 1, Threads: []int{5}}}},
+	}
+	sockets, physicalCores, threadsPerCore := GetCoreCounts(topology)
+	if sockets != 2 || physicalCores != 4 || threadsPerCore != 2 {
+		t.Errorf("Expected 2 sockets, 4 physical cores and 2 threads per core, got %d, %d and %d", sockets, physicalCores, threadsPerCore)
+	}
+}
+
+func TestNumaTopology(t *testing.T) {
+	sysFs := &fakesysfs.FakeSysFs{}
+	sysFs.SetNumaNodes([]sysfs.NumaNodeInfo{
+		{Cpus: []int{0, 1}, Distances: []int{10, 21}, Memory: 1024},
+		{Cpus: []int{2, 3}, Distances: []int{21, 10}, Memory: 2048},
+	})
+	nodes, err := GetNumaTopology(sysFs)
+	if err != nil {
+		t.Fatalf("failed to get NUMA topology: %v", err)
+	}
+	expected := []info.NumaNode{
+		{Id: 0, Cpus: []int{0, 1}, Distances: []int{10, 21}, Memory: 1024},
+		{Id: 1, Cpus: []int{2, 3}, Distances: []int{21, 10}, Memory: 2048},
+	}
+	if !reflect.DeepEqual(nodes, expected) {
+		t.Errorf("Expected NUMA nodes %+v, got %+v", expected, nodes)
+	}
+}
+
+func TestParseCpuList(t *testing.T) {
+	for list, expected := range map[string][]int{
+		"":         nil,
+		"0":        {0},
+		"0-3,8":    {0, 1, 2, 3, 8},
+		"2-3,6-7":  {2, 3, 6, 7},
+		"10,12-12": {10, 12},
+	} {
+		cpus, err := sysfs.ParseCpuList(list)
+		if err != nil {
+			t.Errorf("failed to parse cpu list %q: %v", list, err)
+			continue
+		}
+		if !reflect.DeepEqual(cpus, expected) {
+			t.Errorf("Expected cpus %v for %q, got %v", expected, list, cpus)
+		}
+	}
+	for _, list := range []string{"a", "3-1", "0-"} {
+		if _, err := sysfs.ParseCpuList(list); err == nil {
+			t.Errorf("Expected cpu list %q to fail", list)
+		}
+	}
+}
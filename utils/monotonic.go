@@ -0,0 +1,32 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import "time"
+
+// Time at which the process started, and the reading of the monotonic clock
+// then.
+var (
+	processStart          = time.Now()
+	processStartMonotonic = monotonicClock()
+)
+
+// MonotonicNow returns the wall time at which the process started plus the
+// time elapsed since on the monotonic clock. Unlike time.Now, its readings
+// never step with the corrections of the wall clock, e.g. by NTP, from which
+// it drifts by their sum.
+func MonotonicNow() time.Time {
+	return processStart.Add(monotonicClock() - processStartMonotonic)
+}
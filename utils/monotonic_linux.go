@@ -0,0 +1,39 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package utils
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// Linux's CLOCK_MONOTONIC, from <linux/time.h>.
+const clockMonotonic = 1
+
+// Returns the reading of CLOCK_MONOTONIC, the time elapsed since an arbitrary
+// point, e.g. the boot of the machine, that never steps.
+func monotonicClock() time.Duration {
+	var ts syscall.Timespec
+	_, _, errno := syscall.Syscall(syscall.SYS_CLOCK_GETTIME, clockMonotonic, uintptr(unsafe.Pointer(&ts)), 0)
+	if errno != 0 {
+		// Only fails for an invalid clock or buffer.
+		panic(fmt.Sprintf("failed to read the monotonic clock: %v", errno))
+	}
+	return time.Duration(ts.Nano())
+}
@@ -0,0 +1,36 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMonotonicNow(t *testing.T) {
+	first := MonotonicNow()
+	time.Sleep(10 * time.Millisecond)
+	second := MonotonicNow()
+	assert.True(t, second.Sub(first) >= 10*time.Millisecond, "%v is less than 10ms after %v", second, first)
+
+	// Without steps of the wall clock, both clocks advance together.
+	drift := time.Now().Sub(second)
+	if drift < 0 {
+		drift = -drift
+	}
+	assert.True(t, drift < time.Second, "drifted by %v from the wall clock", drift)
+}
@@ -0,0 +1,408 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package msgpack encodes values in the MessagePack format
+// (https://github.com/msgpack/msgpack/blob/master/spec.md) the way
+// encoding/json encodes them in JSON: structs are maps keyed by the names in
+// their json tags, honoring "-" and omitempty, and map keys are strings.
+// Times use the timestamp extension type.
+package msgpack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Returns the MessagePack encoding of v.
+func Marshal(v interface{}) ([]byte, error) {
+	e := &encoder{}
+	if err := e.encode(reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return e.Bytes(), nil
+}
+
+type encoder struct {
+	bytes.Buffer
+	scratch [9]byte
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (self *encoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		self.WriteByte(0xc0)
+		return nil
+	}
+	if v.Type() == timeType {
+		self.encodeTime(v.Interface().(time.Time))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			self.WriteByte(0xc0)
+			return nil
+		}
+		return self.encode(v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			self.WriteByte(0xc3)
+		} else {
+			self.WriteByte(0xc2)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		self.encodeInt(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		self.encodeUint(v.Uint())
+	case reflect.Float32:
+		self.WriteByte(0xca)
+		binary.BigEndian.PutUint32(self.scratch[:4], math.Float32bits(float32(v.Float())))
+		self.Write(self.scratch[:4])
+	case reflect.Float64:
+		self.WriteByte(0xcb)
+		binary.BigEndian.PutUint64(self.scratch[:8], math.Float64bits(v.Float()))
+		self.Write(self.scratch[:8])
+	case reflect.String:
+		self.encodeString(v.String())
+	case reflect.Slice:
+		if v.IsNil() {
+			self.WriteByte(0xc0)
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			self.encodeBytes(v.Bytes())
+			return nil
+		}
+		fallthrough
+	case reflect.Array:
+		self.encodeLength(v.Len(), 0x90, 0xdc)
+		for i := 0; i < v.Len(); i++ {
+			if err := self.encode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			self.WriteByte(0xc0)
+			return nil
+		}
+		return self.encodeMap(v)
+	case reflect.Struct:
+		return self.encodeStruct(v)
+	default:
+		return fmt.Errorf("msgpack: unsupported type %v", v.Type())
+	}
+	return nil
+}
+
+func (self *encoder) encodeInt(i int64) {
+	switch {
+	case i >= 0:
+		self.encodeUint(uint64(i))
+	case i >= -32:
+		self.WriteByte(byte(i))
+	case i >= math.MinInt8:
+		self.Write([]byte{0xd0, byte(i)})
+	case i >= math.MinInt16:
+		self.WriteByte(0xd1)
+		binary.BigEndian.PutUint16(self.scratch[:2], uint16(i))
+		self.Write(self.scratch[:2])
+	case i >= math.MinInt32:
+		self.WriteByte(0xd2)
+		binary.BigEndian.PutUint32(self.scratch[:4], uint32(i))
+		self.Write(self.scratch[:4])
+	default:
+		self.WriteByte(0xd3)
+		binary.BigEndian.PutUint64(self.scratch[:8], uint64(i))
+		self.Write(self.scratch[:8])
+	}
+}
+
+func (self *encoder) encodeUint(u uint64) {
+	switch {
+	case u < 0x80:
+		self.WriteByte(byte(u))
+	case u <= math.MaxUint8:
+		self.Write([]byte{0xcc, byte(u)})
+	case u <= math.MaxUint16:
+		self.WriteByte(0xcd)
+		binary.BigEndian.PutUint16(self.scratch[:2], uint16(u))
+		self.Write(self.scratch[:2])
+	case u <= math.MaxUint32:
+		self.WriteByte(0xce)
+		binary.BigEndian.PutUint32(self.scratch[:4], uint32(u))
+		self.Write(self.scratch[:4])
+	default:
+		self.WriteByte(0xcf)
+		binary.BigEndian.PutUint64(self.scratch[:8], u)
+		self.Write(self.scratch[:8])
+	}
+}
+
+// Writes the header of an array or a map of n elements, given the tag of
+// its fix format and of its 16 bits format. The 32 bits format follows it.
+func (self *encoder) encodeLength(n int, fix, tag16 byte) {
+	switch {
+	case n < 16:
+		self.WriteByte(fix | byte(n))
+	case n <= math.MaxUint16:
+		self.WriteByte(tag16)
+		binary.BigEndian.PutUint16(self.scratch[:2], uint16(n))
+		self.Write(self.scratch[:2])
+	default:
+		self.WriteByte(tag16 + 1)
+		binary.BigEndian.PutUint32(self.scratch[:4], uint32(n))
+		self.Write(self.scratch[:4])
+	}
+}
+
+func (self *encoder) encodeString(s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		self.WriteByte(0xa0 | byte(n))
+	case n <= math.MaxUint8:
+		self.Write([]byte{0xd9, byte(n)})
+	case n <= math.MaxUint16:
+		self.WriteByte(0xda)
+		binary.BigEndian.PutUint16(self.scratch[:2], uint16(n))
+		self.Write(self.scratch[:2])
+	default:
+		self.WriteByte(0xdb)
+		binary.BigEndian.PutUint32(self.scratch[:4], uint32(n))
+		self.Write(self.scratch[:4])
+	}
+	self.WriteString(s)
+}
+
+func (self *encoder) encodeBytes(b []byte) {
+	n := len(b)
+	switch {
+	case n <= math.MaxUint8:
+		self.Write([]byte{0xc4, byte(n)})
+	case n <= math.MaxUint16:
+		self.WriteByte(0xc5)
+		binary.BigEndian.PutUint16(self.scratch[:2], uint16(n))
+		self.Write(self.scratch[:2])
+	default:
+		self.WriteByte(0xc6)
+		binary.BigEndian.PutUint32(self.scratch[:4], uint32(n))
+		self.Write(self.scratch[:4])
+	}
+	self.Write(b)
+}
+
+// Encodes a time with the timestamp extension type (-1), in its smallest
+// format.
+func (self *encoder) encodeTime(t time.Time) {
+	sec, nsec := t.Unix(), int64(t.Nanosecond())
+	switch {
+	case sec >= 0 && sec <= math.MaxUint32 && nsec == 0:
+		self.Write([]byte{0xd6, 0xff})
+		binary.BigEndian.PutUint32(self.scratch[:4], uint32(sec))
+		self.Write(self.scratch[:4])
+	case sec >= 0 && sec < 1<<34:
+		self.Write([]byte{0xd7, 0xff})
+		binary.BigEndian.PutUint64(self.scratch[:8], uint64(nsec)<<34|uint64(sec))
+		self.Write(self.scratch[:8])
+	default:
+		self.Write([]byte{0xc7, 12, 0xff})
+		binary.BigEndian.PutUint32(self.scratch[:4], uint32(nsec))
+		self.Write(self.scratch[:4])
+		binary.BigEndian.PutUint64(self.scratch[:8], uint64(sec))
+		self.Write(self.scratch[:8])
+	}
+}
+
+// Encodes a map with its keys in sorted order, as strings.
+func (self *encoder) encodeMap(v reflect.Value) error {
+	keys := make([]string, 0, v.Len())
+	values := make(map[string]reflect.Value, v.Len())
+	for _, k := range v.MapKeys() {
+		var key string
+		switch k.Kind() {
+		case reflect.String:
+			key = k.String()
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			key = strconv.FormatInt(k.Int(), 10)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			key = strconv.FormatUint(k.Uint(), 10)
+		default:
+			return fmt.Errorf("msgpack: unsupported map key type %v", k.Type())
+		}
+		keys = append(keys, key)
+		values[key] = v.MapIndex(k)
+	}
+	sort.Strings(keys)
+	self.encodeLength(len(keys), 0x80, 0xde)
+	for _, key := range keys {
+		self.encodeString(key)
+		if err := self.encode(values[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (self *encoder) encodeStruct(v reflect.Value) error {
+	fields := cachedFields(v.Type())
+	present := make([]reflect.Value, 0, len(fields))
+	for _, f := range fields {
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok || (f.omitEmpty && isEmptyValue(fv)) {
+			present = append(present, reflect.Value{})
+			continue
+		}
+		present = append(present, fv)
+	}
+	n := 0
+	for _, fv := range present {
+		if fv.IsValid() {
+			n++
+		}
+	}
+	self.encodeLength(n, 0x80, 0xde)
+	for i, fv := range present {
+		if !fv.IsValid() {
+			continue
+		}
+		self.encodeString(fields[i].name)
+		if err := self.encode(fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Returns the field at the index, or false if it is in a nil embedded
+// struct pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// An encoded struct field.
+type field struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+var (
+	fieldsLock   sync.RWMutex
+	fieldsByType = map[reflect.Type][]field{}
+)
+
+func cachedFields(t reflect.Type) []field {
+	fieldsLock.RLock()
+	fields, ok := fieldsByType[t]
+	fieldsLock.RUnlock()
+	if ok {
+		return fields
+	}
+	fields = typeFields(t, nil, map[string]bool{})
+	fieldsLock.Lock()
+	fieldsByType[t] = fields
+	fieldsLock.Unlock()
+	return fields
+}
+
+// Returns the encoded fields of a struct type, flattening the untagged
+// embedded structs. Fields of the outer struct hide the embedded ones with
+// the same name.
+func typeFields(t reflect.Type, index []int, seen map[string]bool) []field {
+	var fields []field
+	var embedded []int
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		ft := sf.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && tag == "" && ft.Kind() == reflect.Struct {
+			embedded = append(embedded, i)
+			continue
+		}
+		if sf.PkgPath != "" {
+			// Unexported.
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+		if name == "" {
+			name = sf.Name
+		}
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		f := field{
+			name:  name,
+			index: append(append([]int{}, index...), i),
+		}
+		for _, option := range parts[1:] {
+			if option == "omitempty" {
+				f.omitEmpty = true
+			}
+		}
+		fields = append(fields, f)
+	}
+	for _, i := range embedded {
+		ft := t.Field(i).Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		fields = append(fields, typeFields(ft, append(append([]int{}, index...), i), seen)...)
+	}
+	return fields
+}
@@ -0,0 +1,114 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgpack
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalScalars(t *testing.T) {
+	for _, c := range []struct {
+		value    interface{}
+		expected []byte
+	}{
+		{nil, []byte{0xc0}},
+		{true, []byte{0xc3}},
+		{false, []byte{0xc2}},
+		{7, []byte{0x07}},
+		{-3, []byte{0xfd}},
+		{200, []byte{0xcc, 0xc8}},
+		{-100, []byte{0xd0, 0x9c}},
+		{uint64(1) << 40, []byte{0xcf, 0, 0, 0x01, 0, 0, 0, 0, 0}},
+		{int64(math.MinInt64), []byte{0xd3, 0x80, 0, 0, 0, 0, 0, 0, 0}},
+		{1.5, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{float32(1.5), []byte{0xca, 0x3f, 0xc0, 0, 0}},
+		{"abc", []byte{0xa3, 'a', 'b', 'c'}},
+		{[]byte{1, 2}, []byte{0xc4, 0x02, 0x01, 0x02}},
+		{[]uint64{1, 300}, []byte{0x92, 0x01, 0xcd, 0x01, 0x2c}},
+		{map[int]bool{2: true, 1: false}, []byte{0x82, 0xa1, '1', 0xc2, 0xa1, '2', 0xc3}},
+		{time.Unix(1, 0), []byte{0xd6, 0xff, 0, 0, 0, 0x01}},
+		{time.Unix(1, 1), []byte{0xd7, 0xff, 0, 0, 0, 0x04, 0, 0, 0, 0x01}},
+		{time.Unix(-1, 0), []byte{0xc7, 0x0c, 0xff, 0, 0, 0, 0, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+	} {
+		out, err := Marshal(c.value)
+		require.NoError(t, err)
+		assert.Equal(t, c.expected, out, "%#v", c.value)
+	}
+}
+
+func TestMarshalLengths(t *testing.T) {
+	out, err := Marshal(make([]bool, 16))
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xdc, 0, 16}, out[:3])
+	assert.Len(t, out, 3+16)
+
+	s := string(make([]byte, 40))
+	out, err = Marshal(s)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xd9, 40}, out[:2])
+	assert.Len(t, out, 2+40)
+}
+
+type inner struct {
+	Shared string `json:"shared"`
+	Inner  int    `json:"inner"`
+}
+
+type outer struct {
+	*inner
+	Name     string            `json:"name"`
+	Shared   string            `json:"shared"`
+	Empty    []int             `json:"empty,omitempty"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Skipped  int               `json:"-"`
+	Untagged bool
+	private  int
+}
+
+func TestMarshalStruct(t *testing.T) {
+	out, err := Marshal(&outer{
+		inner:    &inner{Shared: "hidden", Inner: 1},
+		Name:     "a",
+		Shared:   "b",
+		Skipped:  2,
+		Untagged: true,
+		private:  3,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{
+		0x84,
+		0xa4, 'n', 'a', 'm', 'e', 0xa1, 'a',
+		0xa6, 's', 'h', 'a', 'r', 'e', 'd', 0xa1, 'b',
+		0xa8, 'U', 'n', 't', 'a', 'g', 'g', 'e', 'd', 0xc3,
+		0xa5, 'i', 'n', 'n', 'e', 'r', 0x01,
+	}, out)
+
+	// The fields of a nil embedded struct are omitted.
+	out, err = Marshal(outer{Name: "a", Shared: "b"})
+	require.NoError(t, err)
+	assert.Equal(t, byte(0x83), out[0])
+}
+
+func TestMarshalUnsupported(t *testing.T) {
+	_, err := Marshal(make(chan int))
+	assert.Error(t, err)
+	_, err = Marshal(map[float64]int{1: 1})
+	assert.Error(t, err)
+}
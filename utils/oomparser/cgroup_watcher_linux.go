@@ -0,0 +1,248 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build linux
+
+package oomparser
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/cadvisor/logging"
+	"golang.org/x/exp/inotify"
+)
+
+// CgroupWatcher reports OOMs of containers using the notifications of their
+// memory cgroup: an eventfd registered for memory.oom_control on cgroup v1 and
+// the oom_kill counter of memory.events on cgroup v2. The eventfds of all the
+// containers are polled by a single goroutine.
+type CgroupWatcher struct {
+	lock sync.Mutex
+	// Watches keyed by container name.
+	watches map[string]*cgroupWatch
+	// Containers keyed by the path of their memory.events file (cgroup v2).
+	eventsFiles map[string]string
+	// Containers keyed by their eventfd (cgroup v1).
+	eventfds  map[int]string
+	epollFd   int
+	inotify   *inotify.Watcher
+	outStream chan *CgroupOom
+}
+
+type cgroupWatch struct {
+	containerName string
+	// Path of memory.oom_control (cgroup v1) or memory.events (cgroup v2).
+	file string
+	// Nonblocking eventfd signaled on OOM (cgroup v1), or -1.
+	eventfd int
+	// Last value of the oom_kill counter.
+	oomKills uint64
+}
+
+// NewCgroupWatcher returns a watcher sending the OOMs of the watched
+// containers on outStream.
+func NewCgroupWatcher(outStream chan *CgroupOom) (*CgroupWatcher, error) {
+	epollFd, err := syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create epoll instance: %v", err)
+	}
+	watcher, err := inotify.NewWatcher()
+	if err != nil {
+		syscall.Close(epollFd)
+		return nil, err
+	}
+	self := &CgroupWatcher{
+		watches:     make(map[string]*cgroupWatch),
+		eventsFiles: make(map[string]string),
+		eventfds:    make(map[int]string),
+		epollFd:     epollFd,
+		inotify:     watcher,
+		outStream:   outStream,
+	}
+	go self.watchEventsFiles()
+	go self.waitForOoms()
+	return self, nil
+}
+
+// Watch starts watching the memory cgroup at the given path for OOMs of the
+// container.
+func (self *CgroupWatcher) Watch(containerName, memoryCgroup string) error {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	if _, ok := self.watches[containerName]; ok {
+		return nil
+	}
+
+	w := &cgroupWatch{
+		containerName: containerName,
+		eventfd:       -1,
+	}
+	eventsFile := path.Join(memoryCgroup, "memory.events")
+	if _, err := os.Stat(eventsFile); err == nil {
+		w.file = eventsFile
+		w.oomKills, _ = readOomKills(eventsFile)
+		if err := self.inotify.AddWatch(eventsFile, inotify.IN_MODIFY); err != nil {
+			return fmt.Errorf("failed to watch %q: %v", eventsFile, err)
+		}
+		self.eventsFiles[eventsFile] = containerName
+	} else {
+		w.file = path.Join(memoryCgroup, "memory.oom_control")
+		w.oomKills, _ = readOomKills(w.file)
+		if err := registerEventfd(w, memoryCgroup); err != nil {
+			return err
+		}
+		event := syscall.EpollEvent{Events: syscall.EPOLLIN, Fd: int32(w.eventfd)}
+		if err := syscall.EpollCtl(self.epollFd, syscall.EPOLL_CTL_ADD, w.eventfd, &event); err != nil {
+			syscall.Close(w.eventfd)
+			return fmt.Errorf("failed to poll the OOM notifications of %q: %v", memoryCgroup, err)
+		}
+		self.eventfds[w.eventfd] = containerName
+	}
+	self.watches[containerName] = w
+	return nil
+}
+
+// StopWatching stops watching the container for OOMs.
+func (self *CgroupWatcher) StopWatching(containerName string) {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	self.stopWatching(containerName)
+}
+
+// Must be called with the lock held.
+func (self *CgroupWatcher) stopWatching(containerName string) {
+	w, ok := self.watches[containerName]
+	if !ok {
+		return
+	}
+	delete(self.watches, containerName)
+	if w.eventfd >= 0 {
+		syscall.EpollCtl(self.epollFd, syscall.EPOLL_CTL_DEL, w.eventfd, nil)
+		syscall.Close(w.eventfd)
+		delete(self.eventfds, w.eventfd)
+		return
+	}
+	delete(self.eventsFiles, w.file)
+	self.inotify.RemoveWatch(w.file)
+}
+
+// Registers an eventfd signaled on OOM with cgroup.event_control.
+func registerEventfd(w *cgroupWatch, memoryCgroup string) error {
+	oomControl, err := os.Open(w.file)
+	if err != nil {
+		return fmt.Errorf("failed to open %q: %v", w.file, err)
+	}
+	defer oomControl.Close()
+	fd, _, errno := syscall.Syscall(syscall.SYS_EVENTFD2, 0, syscall.O_CLOEXEC|syscall.O_NONBLOCK, 0)
+	if errno != 0 {
+		return fmt.Errorf("failed to create eventfd: %v", errno)
+	}
+	registration := fmt.Sprintf("%d %d", fd, oomControl.Fd())
+	if err := ioutil.WriteFile(path.Join(memoryCgroup, "cgroup.event_control"), []byte(registration), 0700); err != nil {
+		syscall.Close(int(fd))
+		return fmt.Errorf("failed to register OOM notification for %q: %v", memoryCgroup, err)
+	}
+	w.eventfd = int(fd)
+	return nil
+}
+
+// Waits for the OOM notifications of the cgroup v1 containers.
+func (self *CgroupWatcher) waitForOoms() {
+	events := make([]syscall.EpollEvent, 128)
+	for {
+		n, err := syscall.EpollWait(self.epollFd, events, -1)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			logging.Errorf("Stopped waiting for the OOMs of memory cgroups: %v", err)
+			return
+		}
+		for _, event := range events[:n] {
+			if oom := self.readEventfd(int(event.Fd)); oom != nil {
+				self.outStream <- oom
+			}
+		}
+	}
+}
+
+// Returns the OOM signaled by the eventfd of a cgroup v1 container, if any.
+func (self *CgroupWatcher) readEventfd(fd int) *CgroupOom {
+	self.lock.Lock()
+	defer self.lock.Unlock()
+	// The container may have stopped being watched since the event.
+	name, ok := self.eventfds[fd]
+	if !ok {
+		return nil
+	}
+	w := self.watches[name]
+	buf := make([]byte, 8)
+	if _, err := syscall.Read(fd, buf); err != nil {
+		return nil
+	}
+	// The eventfd is also signaled when the cgroup is removed.
+	if _, err := os.Stat(w.file); err != nil {
+		self.stopWatching(name)
+		return nil
+	}
+	oom := &CgroupOom{
+		ContainerName: w.containerName,
+		Time:          time.Now(),
+	}
+	if kills, err := readOomKills(w.file); err == nil && kills > w.oomKills {
+		w.oomKills = kills
+		oom.Killed = true
+	}
+	return oom
+}
+
+// Reports the OOM kills counted in the memory.events files of cgroup v2
+// containers when they are modified.
+func (self *CgroupWatcher) watchEventsFiles() {
+	for {
+		select {
+		case event := <-self.inotify.Event:
+			if event.Mask&inotify.IN_MODIFY == 0 {
+				continue
+			}
+			self.lock.Lock()
+			var w *cgroupWatch
+			if name, ok := self.eventsFiles[event.Name]; ok {
+				w = self.watches[name]
+			}
+			self.lock.Unlock()
+			if w == nil {
+				continue
+			}
+			kills, err := readOomKills(w.file)
+			if err != nil || kills <= w.oomKills {
+				continue
+			}
+			w.oomKills = kills
+			self.outStream <- &CgroupOom{
+				ContainerName: w.containerName,
+				Time:          time.Now(),
+				Killed:        true,
+			}
+		case err := <-self.inotify.Error:
+			logging.Warningf("Error while watching memory.events files: %v", err)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !linux
+
+package oomparser
+
+import (
+	"fmt"
+)
+
+// CgroupWatcher reports OOMs of containers using the notifications of their
+// memory cgroup, which only exist on Linux.
+type CgroupWatcher struct{}
+
+// NewCgroupWatcher returns an error: memory cgroups only exist on Linux.
+func NewCgroupWatcher(outStream chan *CgroupOom) (*CgroupWatcher, error) {
+	return nil, fmt.Errorf("memory cgroup OOM notifications are only supported on Linux")
+}
+
+// Watch does nothing.
+func (self *CgroupWatcher) Watch(containerName, memoryCgroup string) error {
+	return nil
+}
+
+// StopWatching does nothing.
+func (self *CgroupWatcher) StopWatching(containerName string) {
+}
@@ -17,7 +17,7 @@ package main
 import (
 	"flag"
 
-	"github.com/golang/glog"
+	"github.com/google/cadvisor/logging"
 	"github.com/google/cadvisor/utils/oomparser"
 )
 
@@ -29,13 +29,13 @@ func main() {
 	outStream := make(chan *oomparser.OomInstance)
 	oomLog, err := oomparser.New()
 	if err != nil {
-		glog.Infof("Couldn't make a new oomparser. %v", err)
+		logging.Infof("Couldn't make a new oomparser. %v", err)
 	} else {
 		go oomLog.StreamOoms(outStream)
 		// demonstration of how to get oomLog's list of oomInstances or access
 		// the user-declared oomInstance channel, here called outStream
 		for oomInstance := range outStream {
-			glog.Infof("Reading the buffer. Output is %v", oomInstance)
+			logging.Infof("Reading the buffer. Output is %v", oomInstance)
 		}
 	}
 }
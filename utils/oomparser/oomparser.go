@@ -25,13 +25,14 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/google/cadvisor/logging"
 	"github.com/google/cadvisor/utils"
-
-	"github.com/golang/glog"
 )
 
 var (
 	containerRegexp = regexp.MustCompile(`Task in (.*) killed as a result of limit of (.*)`)
+	// Since Linux 4.19, e.g. "oom-kill:constraint=CONSTRAINT_MEMCG,nodemask=(null),cpuset=abc,mems_allowed=0,oom_memcg=/docker/abc,task_memcg=/docker/abc,task=stress,pid=1234,uid=0"
+	oomKillRegexp   = regexp.MustCompile(`oom-kill:.*,oom_memcg=([^,]*),task_memcg=([^,]*),task=([^,]*),pid=([0-9]+)`)
 	lastLineRegexp  = regexp.MustCompile(`(^[A-Z][a-z]{2} .*[0-9]{1,2} [0-9]{1,2}:[0-9]{2}:[0-9]{2}) .* Killed process ([0-9]+) \(([\w]+)\)`)
 	firstLineRegexp = regexp.MustCompile(`invoked oom-killer:`)
 )
@@ -59,6 +60,18 @@ type OomInstance struct {
 
 // gets the container name from a line and adds it to the oomInstance.
 func getContainerName(line string, currentOomInstance *OomInstance) error {
+	if parsedLine := oomKillRegexp.FindStringSubmatch(line); parsedLine != nil {
+		// Named like the groups of containerRegexp.
+		currentOomInstance.ContainerName = path.Join("/", parsedLine[2])
+		currentOomInstance.VictimContainerName = path.Join("/", parsedLine[1])
+		currentOomInstance.ProcessName = parsedLine[3]
+		pid, err := strconv.Atoi(parsedLine[4])
+		if err != nil {
+			return err
+		}
+		currentOomInstance.Pid = pid
+		return nil
+	}
 	parsedLine := containerRegexp.FindStringSubmatch(line)
 	if parsedLine == nil {
 		return nil
@@ -123,7 +136,7 @@ func readLinesFromFile(lineChannel chan string, ioreader *bufio.Reader) {
 			}
 			lineChannel <- line
 		} else if err != nil && err != io.EOF {
-			glog.Errorf("exiting analyzeLinesHelper with error %v", err)
+			logging.Errorf("exiting analyzeLinesHelper with error %v", err)
 		}
 	}
 }
@@ -148,18 +161,18 @@ func (self *OomParser) StreamOoms(outStream chan *OomInstance) {
 			for !finished {
 				err := getContainerName(line, oomCurrentInstance)
 				if err != nil {
-					glog.Errorf("%v", err)
+					logging.Errorf("%v", err)
 				}
 				finished, err = getProcessNamePid(line, oomCurrentInstance)
 				if err != nil {
-					glog.Errorf("%v", err)
+					logging.Errorf("%v", err)
 				}
 				line = <-lineChannel
 			}
 			outStream <- oomCurrentInstance
 		}
 	}
-	glog.Infof("exiting analyzeLines")
+	logging.Infof("exiting analyzeLines")
 }
 
 func callJournalctl() (io.ReadCloser, error) {
@@ -179,7 +192,7 @@ func trySystemd() (*OomParser, error) {
 	if err != nil {
 		return nil, err
 	}
-	glog.Infof("oomparser using systemd")
+	logging.Infof("oomparser using systemd")
 	return &OomParser{
 		ioreader: bufio.NewReader(readcloser),
 	}, nil
@@ -195,7 +208,7 @@ var kernelLogFiles = []string{"/var/log/kern.log", "/var/log/messages", "/var/lo
 func getSystemFile() (string, error) {
 	for _, logFile := range kernelLogFiles {
 		if utils.FileExists(logFile) {
-			glog.Infof("OOM parser using kernel log file: %q", logFile)
+			logging.Infof("OOM parser using kernel log file: %q", logFile)
 			return logFile, nil
 		}
 	}
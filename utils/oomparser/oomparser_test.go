@@ -17,9 +17,12 @@ package oomparser
 import (
 	"bufio"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path"
 	"reflect"
 	"strconv"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -83,6 +86,100 @@ func TestGetContainerName(t *testing.T) {
 	}
 }
 
+func TestGetContainerNameOomKillLine(t *testing.T) {
+	currentOomInstance := new(OomInstance)
+	err := getContainerName("[ 1234.567] oom-kill:constraint=CONSTRAINT_MEMCG,nodemask=(null),cpuset=abc,mems_allowed=0,oom_memcg=/docker/abc,task_memcg=/docker/abc/sub,task=stress,pid=4321,uid=0", currentOomInstance)
+	if err != nil {
+		t.Errorf("oom-kill line fed to getContainerName should yield no error, but had error %v", err)
+	}
+	expected := &OomInstance{
+		ContainerName:       "/docker/abc/sub",
+		VictimContainerName: "/docker/abc",
+		Pid:                 4321,
+		ProcessName:         "stress",
+	}
+	if !reflect.DeepEqual(expected, currentOomInstance) {
+		t.Errorf("getContainerName should have set %+v, not %+v", expected, currentOomInstance)
+	}
+}
+
+func TestReadOomKills(t *testing.T) {
+	f, err := ioutil.TempFile("", "memory.events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("low 0\nhigh 0\nmax 12\noom 3\noom_kill 2\n")
+	f.Close()
+	kills, err := readOomKills(f.Name())
+	if err != nil || kills != 2 {
+		t.Errorf("readOomKills should have returned 2, not %d (%v)", kills, err)
+	}
+}
+
+// Signals the eventfd of a cgroup v1 container like the kernel on OOM.
+func signalEventfd(t *testing.T, watcher *CgroupWatcher, containerName string) {
+	watcher.lock.Lock()
+	fd := watcher.watches[containerName].eventfd
+	watcher.lock.Unlock()
+	if _, err := syscall.Write(fd, []byte{1, 0, 0, 0, 0, 0, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCgroupWatcherEventfd(t *testing.T) {
+	cgroup, err := ioutil.TempDir("", "memory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(cgroup)
+	oomControl := path.Join(cgroup, "memory.oom_control")
+	if err := ioutil.WriteFile(oomControl, []byte("oom_kill_disable 0\nunder_oom 0\noom_kill 0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ooms := make(chan *CgroupOom, 1)
+	watcher, err := NewCgroupWatcher(ooms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := watcher.Watch("/docker/abc", cgroup); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(oomControl, []byte("oom_kill_disable 0\nunder_oom 0\noom_kill 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	signalEventfd(t, watcher, "/docker/abc")
+	select {
+	case oom := <-ooms:
+		if oom.ContainerName != "/docker/abc" || !oom.Killed {
+			t.Errorf("unexpected OOM %+v", oom)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no OOM reported")
+	}
+
+	// The eventfd is signaled when the cgroup is removed.
+	os.Remove(oomControl)
+	signalEventfd(t, watcher, "/docker/abc")
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+		watcher.lock.Lock()
+		watched := len(watcher.watches) + len(watcher.eventfds)
+		watcher.lock.Unlock()
+		if watched == 0 {
+			break
+		}
+	}
+	watcher.lock.Lock()
+	defer watcher.lock.Unlock()
+	if len(watcher.watches) != 0 || len(watcher.eventfds) != 0 {
+		t.Errorf("the removed cgroup is still watched")
+	}
+	if len(ooms) != 0 {
+		t.Errorf("the removal of the cgroup was reported as an OOM")
+	}
+}
+
 func TestGetProcessNamePid(t *testing.T) {
 	currentOomInstance := new(OomInstance)
 	couldParseLine, err := getProcessNamePid(startLine, currentOomInstance)
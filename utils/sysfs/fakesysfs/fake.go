@@ -51,8 +51,10 @@ func (self *FileInfo) Sys() interface{} {
 }
 
 type FakeSysFs struct {
-	info  FileInfo
-	cache sysfs.CacheInfo
+	info      FileInfo
+	cache     sysfs.CacheInfo
+	numaNodes []sysfs.NumaNodeInfo
+	pci       map[string]sysfs.PciDeviceInfo
 }
 
 func (self *FakeSysFs) GetBlockDevices() ([]os.FileInfo, error) {
@@ -72,6 +74,14 @@ func (self *FakeSysFs) GetBlockDeviceNumbers(name string) (string, error) {
 	return "8:0\n", nil
 }
 
+func (self *FakeSysFs) GetBlockDeviceModel(name string) (string, error) {
+	return "Fake Disk\n", nil
+}
+
+func (self *FakeSysFs) GetBlockDeviceRotational(name string) (string, error) {
+	return "1\n", nil
+}
+
 func (self *FakeSysFs) GetNetworkDevices() ([]os.FileInfo, error) {
 	return []os.FileInfo{&self.info}, nil
 }
@@ -88,6 +98,10 @@ func (self *FakeSysFs) GetNetworkSpeed(name string) (string, error) {
 	return "1000\n", nil
 }
 
+func (self *FakeSysFs) GetNetworkDuplex(name string) (string, error) {
+	return "full\n", nil
+}
+
 func (self *FakeSysFs) GetNetworkStatValue(name string, stat string) (uint64, error) {
 	return 1024, nil
 }
@@ -112,3 +126,37 @@ func (self *FakeSysFs) SetEntryName(name string) {
 func (self *FakeSysFs) GetSystemUUID() (string, error) {
 	return "1F862619-BA9F-4526-8F85-ECEAF0C97430", nil
 }
+
+func (self *FakeSysFs) GetNumaNodes() ([]int, error) {
+	ids := make([]int, len(self.numaNodes))
+	for i := range self.numaNodes {
+		ids[i] = i
+	}
+	return ids, nil
+}
+
+func (self *FakeSysFs) GetNumaNodeInfo(id int) (sysfs.NumaNodeInfo, error) {
+	return self.numaNodes[id], nil
+}
+
+// SetNumaNodes sets the NUMA nodes, with ids their indices.
+func (self *FakeSysFs) SetNumaNodes(nodes []sysfs.NumaNodeInfo) {
+	self.numaNodes = nodes
+}
+
+func (self *FakeSysFs) GetPciDevices() ([]os.FileInfo, error) {
+	var devices []os.FileInfo
+	for address := range self.pci {
+		devices = append(devices, &FileInfo{EntryName: address})
+	}
+	return devices, nil
+}
+
+func (self *FakeSysFs) GetPciDeviceInfo(address string) (sysfs.PciDeviceInfo, error) {
+	return self.pci[address], nil
+}
+
+// SetPciDevices sets the PCI devices keyed by address.
+func (self *FakeSysFs) SetPciDevices(devices map[string]sysfs.PciDeviceInfo) {
+	self.pci = devices
+}
@@ -19,6 +19,8 @@ import (
 	"io/ioutil"
 	"os"
 	"path"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -28,6 +30,8 @@ const (
 	cacheDir     = "/sys/devices/system/cpu/cpu"
 	netDir       = "/sys/class/net"
 	dmiDir       = "/sys/class/dmi"
+	numaDir      = "/sys/devices/system/node"
+	pciDir       = "/sys/bus/pci/devices"
 	ppcDevTree   = "/proc/device-tree"
 	s390xDevTree = "/etc" // s390/s390x changes
 )
@@ -43,6 +47,24 @@ type CacheInfo struct {
 	Cpus int
 }
 
+type NumaNodeInfo struct {
+	// cpus of the node.
+	Cpus []int
+	// distances from the node to every node, in node id order.
+	Distances []int
+	// memory of the node in bytes.
+	Memory uint64
+}
+
+type PciDeviceInfo struct {
+	// class, vendor and device ids, e.g. "0x020000", "0x8086" and "0x1521".
+	Class  string
+	Vendor string
+	Device string
+	// driver bound to the device, if any.
+	Driver string
+}
+
 // Abstracts the lowest level calls to sysfs.
 type SysFs interface {
 	// Get directory information for available block devices.
@@ -53,11 +75,16 @@ type SysFs interface {
 	GetBlockDeviceScheduler(string) (string, error)
 	// Get device major:minor number string.
 	GetBlockDeviceNumbers(string) (string, error)
+	// Get model of the block device.
+	GetBlockDeviceModel(string) (string, error)
+	// Get "1" if the block device is rotational, "0" otherwise.
+	GetBlockDeviceRotational(string) (string, error)
 
 	GetNetworkDevices() ([]os.FileInfo, error)
 	GetNetworkAddress(string) (string, error)
 	GetNetworkMtu(string) (string, error)
 	GetNetworkSpeed(string) (string, error)
+	GetNetworkDuplex(string) (string, error)
 	GetNetworkStatValue(dev string, stat string) (uint64, error)
 
 	// Get directory information for available caches accessible to given cpu.
@@ -66,6 +93,16 @@ type SysFs interface {
 	GetCacheInfo(cpu int, cache string) (CacheInfo, error)
 
 	GetSystemUUID() (string, error)
+
+	// Get directory information for the PCI devices, named by address.
+	GetPciDevices() ([]os.FileInfo, error)
+	// Get the ids and driver of a PCI device.
+	GetPciDeviceInfo(address string) (PciDeviceInfo, error)
+
+	// Get the ids of the NUMA nodes.
+	GetNumaNodes() ([]int, error)
+	// Get the cpus, distances and memory of a NUMA node.
+	GetNumaNodeInfo(id int) (NumaNodeInfo, error)
 }
 
 type realSysFs struct{}
@@ -102,6 +139,22 @@ func (self *realSysFs) GetBlockDeviceSize(name string) (string, error) {
 	return string(size), nil
 }
 
+func (self *realSysFs) GetBlockDeviceModel(name string) (string, error) {
+	model, err := ioutil.ReadFile(path.Join(blockDir, name, "/device/model"))
+	if err != nil {
+		return "", err
+	}
+	return string(model), nil
+}
+
+func (self *realSysFs) GetBlockDeviceRotational(name string) (string, error) {
+	rotational, err := ioutil.ReadFile(path.Join(blockDir, name, "/queue/rotational"))
+	if err != nil {
+		return "", err
+	}
+	return string(rotational), nil
+}
+
 func (self *realSysFs) GetNetworkDevices() ([]os.FileInfo, error) {
 	files, err := ioutil.ReadDir(netDir)
 	if err != nil {
@@ -148,6 +201,14 @@ func (self *realSysFs) GetNetworkSpeed(name string) (string, error) {
 	return string(speed), nil
 }
 
+func (self *realSysFs) GetNetworkDuplex(name string) (string, error) {
+	duplex, err := ioutil.ReadFile(path.Join(netDir, name, "/duplex"))
+	if err != nil {
+		return "", err
+	}
+	return string(duplex), nil
+}
+
 func (self *realSysFs) GetNetworkStatValue(dev string, stat string) (uint64, error) {
 	statPath := path.Join(netDir, dev, "/statistics", stat)
 	out, err := ioutil.ReadFile(statPath)
@@ -247,3 +308,114 @@ func (self *realSysFs) GetSystemUUID() (string, error) {
 		return "", err
 	}
 }
+
+var numaNodeRegexp = regexp.MustCompile(`^node([0-9]+)$`)
+var numaMemoryRegexp = regexp.MustCompile(`MemTotal:\s*([0-9]+) kB`)
+
+func (self *realSysFs) GetNumaNodes() ([]int, error) {
+	files, err := ioutil.ReadDir(numaDir)
+	if err != nil {
+		return nil, err
+	}
+	var ids []int
+	for _, f := range files {
+		matches := numaNodeRegexp.FindStringSubmatch(f.Name())
+		if len(matches) != 2 {
+			continue
+		}
+		id, err := strconv.Atoi(matches[1])
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids, nil
+}
+
+func (self *realSysFs) GetNumaNodeInfo(id int) (NumaNodeInfo, error) {
+	nodePath := fmt.Sprintf("%s/node%d", numaDir, id)
+	out, err := ioutil.ReadFile(path.Join(nodePath, "/cpulist"))
+	if err != nil {
+		return NumaNodeInfo{}, err
+	}
+	cpus, err := ParseCpuList(strings.TrimSpace(string(out)))
+	if err != nil {
+		return NumaNodeInfo{}, err
+	}
+	out, err = ioutil.ReadFile(path.Join(nodePath, "/distance"))
+	if err != nil {
+		return NumaNodeInfo{}, err
+	}
+	var distances []int
+	for _, field := range strings.Fields(string(out)) {
+		distance, err := strconv.Atoi(field)
+		if err != nil {
+			return NumaNodeInfo{}, fmt.Errorf("failed to parse distances %q: %v", string(out), err)
+		}
+		distances = append(distances, distance)
+	}
+	nodeInfo := NumaNodeInfo{Cpus: cpus, Distances: distances}
+	// The memory is not reported by all kernels.
+	out, err = ioutil.ReadFile(path.Join(nodePath, "/meminfo"))
+	if err == nil {
+		if matches := numaMemoryRegexp.FindSubmatch(out); len(matches) == 2 {
+			kb, err := strconv.ParseUint(string(matches[1]), 10, 64)
+			if err == nil {
+				nodeInfo.Memory = kb * 1024
+			}
+		}
+	}
+	return nodeInfo, nil
+}
+
+// ParseCpuList parses a list of cpus in the format of sysfs, e.g. "0-3,8".
+func ParseCpuList(list string) ([]int, error) {
+	var cpus []int
+	if list == "" {
+		return cpus, nil
+	}
+	for _, part := range strings.Split(list, ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		first, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse cpu list %q: %v", list, err)
+		}
+		last := first
+		if len(bounds) == 2 {
+			last, err = strconv.Atoi(bounds[1])
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse cpu list %q: %v", list, err)
+			}
+		}
+		if last < first {
+			return nil, fmt.Errorf("invalid range %q in cpu list %q", part, list)
+		}
+		for cpu := first; cpu <= last; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus, nil
+}
+
+func (self *realSysFs) GetPciDevices() ([]os.FileInfo, error) {
+	return ioutil.ReadDir(pciDir)
+}
+
+func (self *realSysFs) GetPciDeviceInfo(address string) (PciDeviceInfo, error) {
+	devicePath := path.Join(pciDir, address)
+	var ids [3]string
+	for i, name := range []string{"class", "vendor", "device"} {
+		out, err := ioutil.ReadFile(path.Join(devicePath, name))
+		if err != nil {
+			return PciDeviceInfo{}, err
+		}
+		ids[i] = strings.TrimSpace(string(out))
+	}
+	deviceInfo := PciDeviceInfo{Class: ids[0], Vendor: ids[1], Device: ids[2]}
+	// The driver is a link to the driver bound to the device, if any.
+	if driver, err := os.Readlink(path.Join(devicePath, "driver")); err == nil {
+		deviceInfo.Driver = path.Base(driver)
+	}
+	return deviceInfo, nil
+}
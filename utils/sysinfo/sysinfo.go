@@ -17,6 +17,7 @@ package sysinfo
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -74,6 +75,12 @@ func GetBlockDeviceInfo(sysfs sysfs.SysFs) (map[string]info.DiskInfo, error) {
 			}
 		}
 		device := fmt.Sprintf("%d:%d", disk_info.Major, disk_info.Minor)
+		if model, err := sysfs.GetBlockDeviceModel(name); err == nil {
+			disk_info.Model = strings.TrimSpace(model)
+		}
+		if rotational, err := sysfs.GetBlockDeviceRotational(name); err == nil {
+			disk_info.Rotational = strings.TrimSpace(rotational) == "1"
+		}
 		diskMap[device] = disk_info
 	}
 	return diskMap, nil
@@ -128,11 +135,45 @@ func GetNetworkDevices(sysfs sysfs.SysFs) ([]info.NetInfo, error) {
 			}
 			netInfo.Speed = s
 		}
+		// Virtual devices don't report their duplex mode.
+		if duplex, err := sysfs.GetNetworkDuplex(name); err == nil {
+			netInfo.Duplex = strings.TrimSpace(duplex)
+		}
 		netDevices = append(netDevices, netInfo)
 	}
 	return netDevices, nil
 }
 
+// Get information about the PCI devices of the system, sorted by address.
+func GetPciDevices(sysfs sysfs.SysFs) ([]info.PciDevice, error) {
+	devs, err := sysfs.GetPciDevices()
+	if err != nil {
+		return nil, err
+	}
+	pciDevices := []info.PciDevice{}
+	for _, dev := range devs {
+		deviceInfo, err := sysfs.GetPciDeviceInfo(dev.Name())
+		if err != nil {
+			return nil, err
+		}
+		pciDevices = append(pciDevices, info.PciDevice{
+			Address: dev.Name(),
+			Class:   deviceInfo.Class,
+			Vendor:  deviceInfo.Vendor,
+			Device:  deviceInfo.Device,
+			Driver:  deviceInfo.Driver,
+		})
+	}
+	sort.Sort(pciDevicesByAddress(pciDevices))
+	return pciDevices, nil
+}
+
+type pciDevicesByAddress []info.PciDevice
+
+func (self pciDevicesByAddress) Len() int           { return len(self) }
+func (self pciDevicesByAddress) Swap(i, j int)      { self[i], self[j] = self[j], self[i] }
+func (self pciDevicesByAddress) Less(i, j int) bool { return self[i].Address < self[j].Address }
+
 func GetCacheInfo(sysFs sysfs.SysFs, id int) ([]sysfs.CacheInfo, error) {
 	caches, err := sysFs.GetCaches(id)
 	if err != nil {
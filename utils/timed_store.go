@@ -19,26 +19,17 @@ import (
 	"time"
 )
 
+// Elements sorted by timestamp, oldest first.
 type timedStoreDataSlice []timedStoreData
 
-func (t timedStoreDataSlice) Less(i, j int) bool {
-	return t[i].timestamp.Before(t[j].timestamp)
-}
-
-func (t timedStoreDataSlice) Len() int {
-	return len(t)
-}
-
-func (t timedStoreDataSlice) Swap(i, j int) {
-	t[i], t[j] = t[j], t[i]
-}
-
 // A time-based buffer for ContainerStats.
 // Holds information for a specific time period and/or a max number of items.
 type TimedStore struct {
 	buffer   timedStoreDataSlice
 	age      time.Duration
 	maxItems int
+	// Called with the elements evicted by Add or RemoveOldest, if set.
+	onEvict func(item interface{})
 }
 
 type timedStoreData struct {
@@ -61,16 +52,13 @@ func (self *TimedStore) Add(timestamp time.Time, item interface{}) {
 	// Remove any elements if over our max size.
 	if self.maxItems >= 0 && (len(self.buffer)+1) > self.maxItems {
 		startIndex := len(self.buffer) + 1 - self.maxItems
-		self.buffer = self.buffer[startIndex:]
+		self.evict(startIndex)
 	}
-	// Add the new element first and sort. We can then remove an expired element, if required.
-	copied := item
-	self.buffer = append(self.buffer, timedStoreData{
+	// Insert the new element in order. We can then remove an expired element, if required.
+	self.insert(timedStoreData{
 		timestamp: timestamp,
-		data:      copied,
+		data:      item,
 	})
-
-	sort.Sort(self.buffer)
 	// Remove any elements before eviction time.
 	// TODO(rjnagal): This is assuming that the added entry has timestamp close to now.
 	evictTime := timestamp.Add(-self.age)
@@ -78,9 +66,57 @@ func (self *TimedStore) Add(timestamp time.Time, item interface{}) {
 		return self.buffer[index].timestamp.After(evictTime)
 	})
 	if index < len(self.buffer) {
-		self.buffer = self.buffer[index:]
+		self.evict(index)
+	}
+
+}
+
+// Inserts the element after the elements that are not after it. Elements are
+// almost always added in order, and then only appended.
+func (self *TimedStore) insert(data timedStoreData) {
+	n := len(self.buffer)
+	if n == 0 || !data.timestamp.Before(self.buffer[n-1].timestamp) {
+		self.buffer = append(self.buffer, data)
+		return
 	}
+	index := sort.Search(n, func(i int) bool {
+		return self.buffer[i].timestamp.After(data.timestamp)
+	})
+	self.buffer = append(self.buffer, timedStoreData{})
+	copy(self.buffer[index+1:], self.buffer[index:])
+	self.buffer[index] = data
+}
 
+// Removes the first n elements of the buffer.
+func (self *TimedStore) evict(n int) {
+	if self.onEvict != nil {
+		for _, evicted := range self.buffer[:n] {
+			self.onEvict(evicted.data)
+		}
+	}
+	// Move the remaining elements to the start of the buffer, so that the
+	// next elements are added without growing it, and clear the evicted ones
+	// for the garbage collector.
+	remaining := copy(self.buffer, self.buffer[n:])
+	for i := remaining; i < len(self.buffer); i++ {
+		self.buffer[i] = timedStoreData{}
+	}
+	self.buffer = self.buffer[:remaining]
+}
+
+// OnEvict sets a function called with every element evicted from the store.
+func (self *TimedStore) OnEvict(onEvict func(item interface{})) {
+	self.onEvict = onEvict
+}
+
+// RemoveOldest evicts the oldest element. It returns false if the store is
+// empty.
+func (self *TimedStore) RemoveOldest() bool {
+	if len(self.buffer) == 0 {
+		return false
+	}
+	self.evict(1)
+	return true
 }
 
 // Returns up to maxResult elements in the specified time period (inclusive).
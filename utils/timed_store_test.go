@@ -219,3 +219,39 @@ func TestLimitedSize(t *testing.T) {
 	expectSize(t, sb, 5)
 	expectAllElements(t, sb, []int{6, 7, 8, 9, 10})
 }
+
+func TestAddOutOfOrder(t *testing.T) {
+	sb := NewTimedStore(time.Hour, -1)
+	for _, i := range []int{1, 3, 0, 2, 4} {
+		sb.Add(createTime(i), i)
+	}
+	expectAllElements(t, sb, []int{0, 1, 2, 3, 4})
+}
+
+func TestEvictionReleasesElements(t *testing.T) {
+	sb := NewTimedStore(5*time.Second, -1)
+	for i := 0; i < 10; i++ {
+		sb.Add(createTime(i), i)
+	}
+	expectAllElements(t, sb, []int{5, 6, 7, 8, 9})
+	// The evicted elements are not referenced by the buffer anymore.
+	for _, data := range sb.buffer[len(sb.buffer):cap(sb.buffer)] {
+		assert.Nil(t, data.data)
+	}
+}
+
+// Adds a sample per second to the stores of 1000 containers keeping a minute
+// of samples, as the memory cache does.
+func BenchmarkAdd(b *testing.B) {
+	stores := make([]*TimedStore, 1000)
+	for i := range stores {
+		stores[i] = NewTimedStore(time.Minute, -1)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, sb := range stores {
+			sb.Add(createTime(i), i)
+		}
+	}
+}
@@ -28,8 +28,6 @@ import (
 	"github.com/google/cadvisor/container/docker"
 	"github.com/google/cadvisor/manager"
 	"github.com/google/cadvisor/utils"
-
-	"github.com/opencontainers/runc/libcontainer/cgroups"
 )
 
 const (
@@ -138,7 +136,7 @@ func validateMemoryAccounting(available_cgroups map[string]int) string {
 	if !ok {
 		return "\tHierarchical memory accounting status unknown: memory cgroup not enabled.\n"
 	}
-	mnt, err := cgroups.FindCgroupMountpoint("memory")
+	mnt, err := findCgroupMountpoint("memory")
 	if err != nil {
 		return "\tHierarchical memory accounting status unknown: memory cgroup not mounted.\n"
 	}
@@ -203,7 +201,7 @@ func validateDockerInfo() (string, string) {
 func validateCgroupMounts() (string, string) {
 	const recommendedMount = "/sys/fs/cgroup"
 	desc := fmt.Sprintf("\tAny cgroup mount point that is detectible and accessible is supported. %s is recommended as a standard location.\n", recommendedMount)
-	mnt, err := cgroups.FindCgroupMountpoint("cpu")
+	mnt, err := findCgroupMountpoint("cpu")
 	if err != nil {
 		out := "Could not locate cgroup mount point.\n"
 		out += desc